@@ -0,0 +1,237 @@
+package authentication
+
+import (
+	"errors"
+	"io"
+)
+
+// This file contains the minimal BER encoding (ITU-T X.690) needed to speak LDAP.
+
+const (
+	berClassUniversal   = 0x00
+	berClassApplication = 0x40
+	berClassContext     = 0x80
+	berConstructed      = 0x20
+
+	berTagBoolean     = 1
+	berTagInteger     = 2
+	berTagOctetString = 4
+	berTagNull        = 5
+	berTagEnumerated  = 10
+	berTagSequence    = 16
+	berTagSet         = 17
+
+	// The biggest packet accepted, to protect against broken or malicious peers
+	berMaxLength = 1 << 20
+)
+
+var errBerFormat = errors.New("Invalid BER packet")
+
+// berPacket : An element of a BER encoded message.
+/*	Class : Universal, application or context-specific.
+	Constructed : Wether the packet contains other packets (Children) or a value (Value).
+	Tag : The number of the tag in its class.
+*/
+type berPacket struct {
+	Class       byte
+	Constructed bool
+	Tag         byte
+	Value       []byte
+	Children    []*berPacket
+}
+
+func berNew(Class byte, Tag byte, Children ...*berPacket) *berPacket {
+	return &berPacket{Class: Class, Constructed: true, Tag: Tag, Children: Children}
+}
+
+func berPrimitive(Class byte, Tag byte, Value []byte) *berPacket {
+	return &berPacket{Class: Class, Tag: Tag, Value: Value}
+}
+
+func berSequence(Children ...*berPacket) *berPacket {
+	return berNew(berClassUniversal, berTagSequence, Children...)
+}
+
+func berString(Value string) *berPacket {
+	return berPrimitive(berClassUniversal, berTagOctetString, []byte(Value))
+}
+
+func berInteger(Tag byte, Value int64) *berPacket {
+	// Minimal two's complement encoding
+	bytes := []byte{byte(Value)}
+	for Value > 127 || Value < -128 {
+		Value >>= 8
+		bytes = append([]byte{byte(Value)}, bytes...)
+	}
+	return berPrimitive(berClassUniversal, Tag, bytes)
+}
+
+func berBoolean(Value bool) *berPacket {
+	if Value {
+		return berPrimitive(berClassUniversal, berTagBoolean, []byte{0xff})
+	}
+	return berPrimitive(berClassUniversal, berTagBoolean, []byte{0x00})
+}
+
+//	Encode() []byte
+/*	Returns the BER encoding of the packet and of its children.
+ */
+func (packet *berPacket) Encode() []byte {
+	content := packet.Value
+	if packet.Constructed {
+		content = nil
+		for _, child := range packet.Children {
+			content = append(content, child.Encode()...)
+		}
+	}
+
+	identifier := packet.Class | packet.Tag
+	if packet.Constructed {
+		identifier |= berConstructed
+	}
+
+	encoded := append([]byte{identifier}, berLength(len(content))...)
+	return append(encoded, content...)
+}
+
+func berLength(Length int) []byte {
+	if Length < 128 {
+		return []byte{byte(Length)}
+	}
+
+	var bytes []byte
+	for ; Length > 0; Length >>= 8 {
+		bytes = append([]byte{byte(Length)}, bytes...)
+	}
+	return append([]byte{0x80 | byte(len(bytes))}, bytes...)
+}
+
+//	Int() int64
+/*	Returns the value of an INTEGER or ENUMERATED packet.
+ */
+func (packet *berPacket) Int() int64 {
+	var value int64
+	for index, b := range packet.Value {
+		if index == 0 && b&0x80 != 0 {
+			value = -1
+		}
+		value = value<<8 | int64(b)
+	}
+	return value
+}
+
+//	String() string
+/*	Returns the value of an OCTET STRING packet.
+ */
+func (packet *berPacket) String() string {
+	return string(packet.Value)
+}
+
+//	Child(Index int) *berPacket
+/*	Returns a child of the packet, or an empty packet if it does not exist, so that broken messages can't crash the parser.
+ */
+func (packet *berPacket) Child(Index int) *berPacket {
+	if Index < len(packet.Children) {
+		return packet.Children[Index]
+	}
+	return &berPacket{}
+}
+
+//	berRead(Reader io.Reader) (*berPacket, error)
+/*	Reads a whole packet from a connection.
+ */
+func berRead(Reader io.Reader) (*berPacket, error) {
+	var (
+		err    error
+		header [2]byte
+		length int
+	)
+
+	if _, err = io.ReadFull(Reader, header[:]); err != nil {
+		return nil, err
+	}
+
+	raw := header[:]
+	if header[1]&0x80 == 0 {
+		length = int(header[1])
+	} else {
+		count := int(header[1] & 0x7f)
+		if count == 0 || count > 4 {
+			return nil, errBerFormat
+		}
+		lengthBytes := make([]byte, count)
+		if _, err = io.ReadFull(Reader, lengthBytes); err != nil {
+			return nil, err
+		}
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+		raw = append(raw, lengthBytes...)
+	}
+
+	if length > berMaxLength {
+		return nil, errBerFormat
+	}
+
+	content := make([]byte, length)
+	if _, err = io.ReadFull(Reader, content); err != nil {
+		return nil, err
+	}
+
+	packet, _, err := berParse(append(raw, content...))
+	return packet, err
+}
+
+//	berParse(Data []byte) (*berPacket, int, error)
+/*	Parses the packet at the beginning of the data.
+	Returns the packet and the number of bytes it used.
+*/
+func berParse(Data []byte) (*berPacket, int, error) {
+	if len(Data) < 2 {
+		return nil, 0, errBerFormat
+	}
+
+	packet := &berPacket{
+		Class:       Data[0] & 0xc0,
+		Constructed: Data[0]&berConstructed != 0,
+		Tag:         Data[0] & 0x1f,
+	}
+
+	// Reading the length
+	offset := 2
+	length := int(Data[1])
+	if Data[1]&0x80 != 0 {
+		count := int(Data[1] & 0x7f)
+		if count == 0 || count > 4 || len(Data) < 2+count {
+			return nil, 0, errBerFormat
+		}
+		length = 0
+		for _, b := range Data[2 : 2+count] {
+			length = length<<8 | int(b)
+		}
+		offset += count
+	}
+
+	if length < 0 || length > berMaxLength || len(Data) < offset+length {
+		return nil, 0, errBerFormat
+	}
+
+	content := Data[offset : offset+length]
+
+	if !packet.Constructed {
+		packet.Value = content
+		return packet, offset + length, nil
+	}
+
+	// Parsing the children
+	for len(content) > 0 {
+		child, used, err := berParse(content)
+		if err != nil {
+			return nil, 0, err
+		}
+		packet.Children = append(packet.Children, child)
+		content = content[used:]
+	}
+
+	return packet, offset + length, nil
+}
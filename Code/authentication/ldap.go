@@ -0,0 +1,345 @@
+package authentication
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LDAP operations and results (RFC 4511)
+const (
+	ldapBindRequest       = 0
+	ldapBindResponse      = 1
+	ldapUnbindRequest     = 2
+	ldapSearchRequest     = 3
+	ldapSearchResultEntry = 4
+	ldapSearchResultDone  = 5
+
+	ldapFilterAnd      = 0
+	ldapFilterOr       = 1
+	ldapFilterEquality = 3
+	ldapFilterPresent  = 7
+
+	ldapScopeSubtree = 2
+
+	ldapResultSuccess            = 0
+	ldapResultInvalidCredentials = 49
+)
+
+// LDAPConfig : The configuration of the LDAP provider.
+/*	URL : The address of the directory, like ldap://ldap.uca.fr:389 or ldaps://ldap.uca.fr:636.
+	BindDN, BindPassword : The service account used to search the users. Left empty, the search is anonymous.
+	BaseDN : The subtree where the users are searched.
+	LoginAttributes : The attributes compared to the login typed by the user, like uid and mail.
+	UsernameAttribute, MailAttribute, FirstNameAttribute, LastNameAttribute : The attributes read to fill the identity of the user.
+	Timeout : The maximum duration of a connection to the directory.
+	Provisioning : Wether the unknown users are created on their first login, and with which role and contract.
+*/
+type LDAPConfig struct {
+	URL                string
+	BindDN             string
+	BindPassword       string
+	BaseDN             string
+	LoginAttributes    []string
+	UsernameAttribute  string
+	MailAttribute      string
+	FirstNameAttribute string
+	LastNameAttribute  string
+	Timeout            time.Duration
+	Provisioning       Provisioning
+}
+
+// LDAPProvider : Authenticates the users with a bind on a LDAP directory.
+type LDAPProvider struct {
+	Config LDAPConfig
+}
+
+//	DefaultLDAPConfig(URL string, BaseDN string) LDAPConfig
+/*	Returns a configuration with the usual attributes of the inetOrgPerson schema.
+ */
+func DefaultLDAPConfig(URL string, BaseDN string) LDAPConfig {
+	return LDAPConfig{
+		URL:                URL,
+		BaseDN:             BaseDN,
+		LoginAttributes:    []string{"uid", "mail"},
+		UsernameAttribute:  "uid",
+		MailAttribute:      "mail",
+		FirstNameAttribute: "givenName",
+		LastNameAttribute:  "sn",
+		Timeout:            5 * time.Second,
+	}
+}
+
+//	LDAPConfigFromEnvironment() (LDAPConfig, bool)
+/*	Reads the configuration of the LDAP provider from the environment variables :
+	LDAP_URL, LDAP_BASE_DN, LDAP_BIND_DN, LDAP_BIND_PASSWORD,
+	LDAP_PROVISIONING (true or false), LDAP_DEFAULT_ROLE_ID and LDAP_DEFAULT_CONTRACT_ID.
+	Returns false if LDAP_URL is not set, which means LDAP is not used.
+*/
+func LDAPConfigFromEnvironment() (LDAPConfig, bool) {
+	address := os.Getenv("LDAP_URL")
+	if address == "" {
+		return LDAPConfig{}, false
+	}
+
+	config := DefaultLDAPConfig(address, os.Getenv("LDAP_BASE_DN"))
+	config.BindDN = os.Getenv("LDAP_BIND_DN")
+	config.BindPassword = os.Getenv("LDAP_BIND_PASSWORD")
+	config.Provisioning.Enabled, _ = strconv.ParseBool(os.Getenv("LDAP_PROVISIONING"))
+	config.Provisioning.RoleId, _ = strconv.ParseInt(os.Getenv("LDAP_DEFAULT_ROLE_ID"), 10, 64)
+	config.Provisioning.ContractId, _ = strconv.ParseInt(os.Getenv("LDAP_DEFAULT_CONTRACT_ID"), 10, 64)
+
+	return config, true
+}
+
+//	Name() string
+/*	Returns the name of the provider.
+ */
+func (provider *LDAPProvider) Name() string {
+	return "ldap"
+}
+
+//	Provisioning() Provisioning
+/*	Returns the provisioning configuration of the provider.
+ */
+func (provider *LDAPProvider) Provisioning() Provisioning {
+	return provider.Config.Provisioning
+}
+
+//	Authenticate(Login string, Password string) (Identity, error)
+/*	Searches the entry of the user with the service account, then binds as this entry with the password.
+	Returns ErrInvalidCredentials if the entry does not exist, is ambiguous, or if the bind fails.
+*/
+func (provider *LDAPProvider) Authenticate(Login string, Password string) (Identity, error) {
+	var (
+		err      error
+		identity Identity
+		conn     *ldapConn
+	)
+
+	// An empty password would make an unauthenticated bind, that most directories accept
+	if Login == "" || Password == "" {
+		return identity, ErrInvalidCredentials
+	}
+
+	if conn, err = provider.dial(); err != nil {
+		return identity, err
+	}
+	defer conn.Close()
+
+	// Searching the entry of the user
+	// A refused service account is a configuration error, not a wrong password
+	if err = conn.Bind(provider.Config.BindDN, provider.Config.BindPassword); err == ErrInvalidCredentials {
+		return identity, errors.New("The LDAP service account was refused")
+	} else if err != nil {
+		return identity, err
+	}
+
+	config := provider.Config
+	entries, err := conn.Search(config.BaseDN, config.LoginAttributes, Login,
+		[]string{config.UsernameAttribute, config.MailAttribute, config.FirstNameAttribute, config.LastNameAttribute})
+	if err != nil {
+		return identity, err
+	}
+	if len(entries) != 1 {
+		return identity, ErrInvalidCredentials
+	}
+
+	// Verifying the password
+	if err = conn.Bind(entries[0].DN, Password); err != nil {
+		return identity, err
+	}
+
+	entry := entries[0]
+	identity = Identity{
+		Username:  entry.Attribute(config.UsernameAttribute),
+		Mail:      entry.Attribute(config.MailAttribute),
+		FirstName: entry.Attribute(config.FirstNameAttribute),
+		LastName:  entry.Attribute(config.LastNameAttribute),
+	}
+
+	return identity, nil
+}
+
+func (provider *LDAPProvider) dial() (*ldapConn, error) {
+	var (
+		err     error
+		address *url.URL
+		conn    net.Conn
+	)
+
+	if address, err = url.Parse(provider.Config.URL); err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: provider.Config.Timeout}
+	switch address.Scheme {
+	case "ldap":
+		conn, err = dialer.Dial("tcp", hostWithPort(address, "389"))
+	case "ldaps":
+		conn, err = tls.DialWithDialer(dialer, "tcp", hostWithPort(address, "636"), &tls.Config{ServerName: address.Hostname()})
+	default:
+		err = errors.New("Unknown LDAP scheme : " + address.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if provider.Config.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(provider.Config.Timeout))
+	}
+
+	return &ldapConn{conn: conn}, nil
+}
+
+func hostWithPort(Address *url.URL, DefaultPort string) string {
+	if Address.Port() != "" {
+		return Address.Host
+	}
+	return net.JoinHostPort(Address.Hostname(), DefaultPort)
+}
+
+// ldapEntry : An entry returned by a search.
+type ldapEntry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+//	Attribute(Name string) string
+/*	Returns the first value of an attribute, ignoring the case of its name.
+ */
+func (entry ldapEntry) Attribute(Name string) string {
+	for attribute, values := range entry.Attributes {
+		if strings.EqualFold(attribute, Name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// ldapConn : A connection to a directory, that sends one request at a time.
+type ldapConn struct {
+	conn      net.Conn
+	messageId int64
+}
+
+func (conn *ldapConn) send(Operation *berPacket) error {
+	conn.messageId++
+	_, err := conn.conn.Write(berSequence(berInteger(berTagInteger, conn.messageId), Operation).Encode())
+	return err
+}
+
+func (conn *ldapConn) receive() (*berPacket, error) {
+	message, err := berRead(conn.conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(message.Children) < 2 || message.Child(0).Int() != conn.messageId {
+		return nil, errBerFormat
+	}
+	return message.Child(1), nil
+}
+
+//	Bind(DN string, Password string) error
+/*	Sends a simple bind request.
+ */
+func (conn *ldapConn) Bind(DN string, Password string) error {
+	err := conn.send(berNew(berClassApplication, ldapBindRequest,
+		berInteger(berTagInteger, 3),
+		berString(DN),
+		berPrimitive(berClassContext, 0, []byte(Password)),
+	))
+	if err != nil {
+		return err
+	}
+
+	response, err := conn.receive()
+	if err != nil {
+		return err
+	}
+	if response.Class != berClassApplication || response.Tag != ldapBindResponse {
+		return errBerFormat
+	}
+
+	return ldapResultError(response)
+}
+
+//	Search(BaseDN string, Attributes []string, Value string, Returned []string) ([]ldapEntry, error)
+/*	Searches the entries of the subtree where one of the attributes is equal to the value.
+	The filter is built as BER packets, so the value can't be used to inject another filter.
+*/
+func (conn *ldapConn) Search(BaseDN string, Attributes []string, Value string, Returned []string) ([]ldapEntry, error) {
+	var entries []ldapEntry
+
+	filter := berNew(berClassContext, ldapFilterOr)
+	for _, attribute := range Attributes {
+		filter.Children = append(filter.Children,
+			berNew(berClassContext, ldapFilterEquality, berString(attribute), berString(Value)))
+	}
+
+	attributes := berSequence()
+	for _, attribute := range Returned {
+		attributes.Children = append(attributes.Children, berString(attribute))
+	}
+
+	err := conn.send(berNew(berClassApplication, ldapSearchRequest,
+		berString(BaseDN),
+		berInteger(berTagEnumerated, ldapScopeSubtree),
+		berInteger(berTagEnumerated, 0),
+		berInteger(berTagInteger, 2),
+		berInteger(berTagInteger, 0),
+		berBoolean(false),
+		filter,
+		attributes,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		response, err := conn.receive()
+		if err != nil {
+			return nil, err
+		}
+		if response.Class != berClassApplication {
+			return nil, errBerFormat
+		}
+
+		switch response.Tag {
+		case ldapSearchResultEntry:
+			entry := ldapEntry{DN: response.Child(0).String(), Attributes: map[string][]string{}}
+			for _, attribute := range response.Child(1).Children {
+				for _, value := range attribute.Child(1).Children {
+					entry.Attributes[attribute.Child(0).String()] = append(entry.Attributes[attribute.Child(0).String()], value.String())
+				}
+			}
+			entries = append(entries, entry)
+		case ldapSearchResultDone:
+			return entries, ldapResultError(response)
+		}
+	}
+}
+
+//	Close()
+/*	Sends an unbind request and closes the connection.
+ */
+func (conn *ldapConn) Close() {
+	conn.send(berPrimitive(berClassApplication, ldapUnbindRequest, nil))
+	conn.conn.Close()
+}
+
+func ldapResultError(Result *berPacket) error {
+	switch Result.Child(0).Int() {
+	case ldapResultSuccess:
+		return nil
+	case ldapResultInvalidCredentials:
+		return ErrInvalidCredentials
+	default:
+		return errors.New("LDAP error " + strconv.FormatInt(Result.Child(0).Int(), 10) + " : " + Result.Child(2).String())
+	}
+}
@@ -0,0 +1,184 @@
+package authentication
+
+import (
+	"net"
+	"strings"
+	"sync"
+)
+
+// LDAPStandInEntry : An entry of the stand-in directory.
+/*	DN : The distinguished name of the entry, used to bind.
+	Password : The password of the entry.
+	Attributes : The attributes of the entry, that can be searched.
+*/
+type LDAPStandInEntry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// LDAPStandIn : A minimal in-process LDAP server.
+// It understands the simple binds and the searches with equality, presence, and, or filters, which is what LDAPProvider needs.
+// It is used to test the LDAP authentication without a real directory.
+type LDAPStandIn struct {
+	Entries []LDAPStandInEntry
+
+	listener net.Listener
+	mutex    sync.Mutex
+	binds    int
+}
+
+//	StartLDAPStandIn(Entries []LDAPStandInEntry) (*LDAPStandIn, error)
+/*	Starts a stand-in directory on a free local port.
+ */
+func StartLDAPStandIn(Entries []LDAPStandInEntry) (*LDAPStandIn, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &LDAPStandIn{Entries: Entries, listener: listener}
+	go server.serve()
+
+	return server, nil
+}
+
+//	URL() string
+/*	Returns the URL to give to LDAPConfig.
+ */
+func (server *LDAPStandIn) URL() string {
+	return "ldap://" + server.listener.Addr().String()
+}
+
+//	Binds() int
+/*	Returns the number of bind requests received, successful or not.
+ */
+func (server *LDAPStandIn) Binds() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.binds
+}
+
+//	Close()
+/*	Stops the stand-in directory.
+ */
+func (server *LDAPStandIn) Close() {
+	server.listener.Close()
+}
+
+func (server *LDAPStandIn) serve() {
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handle(conn)
+	}
+}
+
+func (server *LDAPStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		message, err := berRead(conn)
+		if err != nil || len(message.Children) < 2 {
+			return
+		}
+
+		messageId := message.Child(0).Int()
+		request := message.Child(1)
+
+		reply := func(Operation *berPacket) {
+			conn.Write(berSequence(berInteger(berTagInteger, messageId), Operation).Encode())
+		}
+		result := func(Tag byte, Code int64) *berPacket {
+			return berNew(berClassApplication, Tag, berInteger(berTagEnumerated, Code), berString(""), berString(""))
+		}
+
+		switch request.Tag {
+		case ldapBindRequest:
+			reply(result(ldapBindResponse, server.bind(request.Child(1).String(), request.Child(2).String())))
+		case ldapSearchRequest:
+			for _, entry := range server.search(request.Child(0).String(), request.Child(6)) {
+				attributes := berSequence()
+				for name, values := range entry.Attributes {
+					set := berNew(berClassUniversal, berTagSet)
+					for _, value := range values {
+						set.Children = append(set.Children, berString(value))
+					}
+					attributes.Children = append(attributes.Children, berSequence(berString(name), set))
+				}
+				reply(berNew(berClassApplication, ldapSearchResultEntry, berString(entry.DN), attributes))
+			}
+			reply(result(ldapSearchResultDone, ldapResultSuccess))
+		case ldapUnbindRequest:
+			return
+		}
+	}
+}
+
+func (server *LDAPStandIn) bind(DN string, Password string) int64 {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.binds++
+
+	// Anonymous bind
+	if DN == "" && Password == "" {
+		return ldapResultSuccess
+	}
+
+	for _, entry := range server.Entries {
+		if strings.EqualFold(entry.DN, DN) && entry.Password != "" && entry.Password == Password {
+			return ldapResultSuccess
+		}
+	}
+	return ldapResultInvalidCredentials
+}
+
+func (server *LDAPStandIn) search(BaseDN string, Filter *berPacket) []LDAPStandInEntry {
+	var entries []LDAPStandInEntry
+	for _, entry := range server.Entries {
+		if strings.HasSuffix(strings.ToLower(entry.DN), strings.ToLower(BaseDN)) && standInMatch(entry, Filter) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func standInMatch(Entry LDAPStandInEntry, Filter *berPacket) bool {
+	switch Filter.Tag {
+	case ldapFilterAnd:
+		for _, child := range Filter.Children {
+			if !standInMatch(Entry, child) {
+				return false
+			}
+		}
+		return true
+	case ldapFilterOr:
+		for _, child := range Filter.Children {
+			if standInMatch(Entry, child) {
+				return true
+			}
+		}
+		return false
+	case ldapFilterEquality:
+		for name, values := range Entry.Attributes {
+			if strings.EqualFold(name, Filter.Child(0).String()) {
+				for _, value := range values {
+					if strings.EqualFold(value, Filter.Child(1).String()) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	case ldapFilterPresent:
+		for name := range Entry.Attributes {
+			if strings.EqualFold(name, string(Filter.Value)) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
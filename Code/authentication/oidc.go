@@ -0,0 +1,423 @@
+package authentication
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// OIDCConfig : The configuration of an OpenID Connect identity provider.
+/*	Name : The name of the provider, used in the URLs and stored in the auth_provider column of its users.
+	Issuer : The URL of the provider, where /.well-known/openid-configuration is found.
+	ClientID, ClientSecret : The credentials of the application, registered at the provider.
+	RedirectURL : The URL of GET /oidc/{provider}/callback, as registered at the provider.
+	Scopes : The scopes asked, openid is always added.
+	AllowedDomains : The domains of the mails the provider is trusted for. Left empty, every domain is accepted.
+	Timeout : The maximum duration of a request to the provider.
+	Provisioning : Wether the unknown users are created on their first login, and with which role and contract.
+*/
+type OIDCConfig struct {
+	Name           string
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string
+	Scopes         []string
+	AllowedDomains []string
+	Timeout        time.Duration
+	Provisioning   Provisioning
+}
+
+// OIDCProvider : Authenticates the users with the authorization code flow of OpenID Connect, protected by PKCE.
+// The metadata and the keys of the provider are fetched on the first login, and kept.
+type OIDCProvider struct {
+	Config OIDCConfig
+
+	client   *http.Client
+	mutex    sync.Mutex
+	metadata *oidcMetadata
+	keys     map[string]*rsa.PublicKey
+}
+
+// InvalidTokenError : Returned when the provider refuses the code, or when the ID token it gives is not valid.
+type InvalidTokenError struct {
+	Reason string
+}
+
+func (err *InvalidTokenError) Error() string {
+	return "Invalid ID token : " + err.Reason
+}
+
+// oidcMetadata : The part of the discovery document of a provider that is used.
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcTokenResponse : The answer of the token endpoint.
+type oidcTokenResponse struct {
+	IDToken string `json:"id_token"`
+	Error   string `json:"error"`
+}
+
+// jsonWebKeys : The keys published by a provider to verify its ID tokens.
+type jsonWebKeys struct {
+	Keys []struct {
+		KeyType string `json:"kty"`
+		KeyId   string `json:"kid"`
+		Use     string `json:"use"`
+		N       string `json:"n"`
+		E       string `json:"e"`
+	} `json:"keys"`
+}
+
+//	NewOIDCProvider(Config OIDCConfig) *OIDCProvider
+/*	Creates a provider. Nothing is fetched before the first login.
+ */
+func NewOIDCProvider(Config OIDCConfig) *OIDCProvider {
+	if Config.Timeout == 0 {
+		Config.Timeout = 10 * time.Second
+	}
+
+	return &OIDCProvider{
+		Config: Config,
+		client: &http.Client{Timeout: Config.Timeout},
+	}
+}
+
+//	OIDCConfigsFromEnvironment() []OIDCConfig
+/*	Reads the configuration of the OpenID Connect providers from the environment variables.
+	OIDC_PROVIDERS is the comma separated list of their names. For a provider named "partner", the variables are
+	OIDC_PARTNER_ISSUER, OIDC_PARTNER_CLIENT_ID, OIDC_PARTNER_CLIENT_SECRET, OIDC_PARTNER_REDIRECT_URL,
+	OIDC_PARTNER_ALLOWED_DOMAINS (comma separated), OIDC_PARTNER_PROVISIONING (true or false),
+	OIDC_PARTNER_DEFAULT_ROLE_ID and OIDC_PARTNER_DEFAULT_CONTRACT_ID.
+*/
+func OIDCConfigsFromEnvironment() []OIDCConfig {
+	var configs []OIDCConfig
+
+	for _, name := range splitList(os.Getenv("OIDC_PROVIDERS")) {
+		prefix := "OIDC_" + strings.ToUpper(strings.Replace(name, "-", "_", -1)) + "_"
+
+		config := OIDCConfig{
+			Name:           name,
+			Issuer:         os.Getenv(prefix + "ISSUER"),
+			ClientID:       os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret:   os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:    os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:         []string{"email", "profile"},
+			AllowedDomains: splitList(os.Getenv(prefix + "ALLOWED_DOMAINS")),
+		}
+		config.Provisioning.Enabled, _ = strconv.ParseBool(os.Getenv(prefix + "PROVISIONING"))
+		config.Provisioning.RoleId, _ = strconv.ParseInt(os.Getenv(prefix+"DEFAULT_ROLE_ID"), 10, 64)
+		config.Provisioning.ContractId, _ = strconv.ParseInt(os.Getenv(prefix+"DEFAULT_CONTRACT_ID"), 10, 64)
+
+		configs = append(configs, config)
+	}
+
+	return configs
+}
+
+func splitList(List string) []string {
+	var items []string
+	for _, item := range strings.Split(List, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+//	RandomURLToken() (string, error)
+/*	Returns 32 random bytes encoded for an URL. It is used for the state, the nonce and the PKCE verifier.
+ */
+func RandomURLToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+//	PKCEChallenge(Verifier string) string
+/*	Returns the S256 challenge of a PKCE verifier (RFC 7636).
+ */
+func PKCEChallenge(Verifier string) string {
+	sum := sha256.Sum256([]byte(Verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+//	AuthCodeURL(State string, Nonce string, Verifier string) (string, error)
+/*	Returns the URL of the provider the user is redirected to, to log in.
+ */
+func (provider *OIDCProvider) AuthCodeURL(State string, Nonce string, Verifier string) (string, error) {
+	metadata, err := provider.discover()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.Config.ClientID},
+		"redirect_uri":          {provider.Config.RedirectURL},
+		"scope":                 {strings.Join(append([]string{"openid"}, provider.Config.Scopes...), " ")},
+		"state":                 {State},
+		"nonce":                 {Nonce},
+		"code_challenge":        {PKCEChallenge(Verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+//	Exchange(Code string, Verifier string, Nonce string) (Identity, error)
+/*	Exchanges the code given to the callback for an ID token, verifies it and returns the identity it contains.
+	Returns an InvalidTokenError if the code is refused or if the token is not valid.
+*/
+func (provider *OIDCProvider) Exchange(Code string, Verifier string, Nonce string) (Identity, error) {
+	var (
+		err      error
+		metadata *oidcMetadata
+		response *http.Response
+		token    oidcTokenResponse
+	)
+
+	if metadata, err = provider.discover(); err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {Code},
+		"redirect_uri":  {provider.Config.RedirectURL},
+		"code_verifier": {Verifier},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(provider.Config.ClientID), url.QueryEscape(provider.Config.ClientSecret))
+
+	if response, err = provider.client.Do(request); err != nil {
+		return Identity{}, err
+	}
+	defer response.Body.Close()
+
+	if err = json.NewDecoder(response.Body).Decode(&token); err != nil && response.StatusCode == http.StatusOK {
+		return Identity{}, err
+	}
+
+	// The code is refused when it is unknown, used twice, or when the verifier does not match
+	if response.StatusCode == http.StatusBadRequest || response.StatusCode == http.StatusUnauthorized {
+		return Identity{}, &InvalidTokenError{Reason: "the code was refused (" + token.Error + ")"}
+	}
+	if response.StatusCode != http.StatusOK {
+		return Identity{}, errors.New("The token endpoint answered " + response.Status)
+	}
+	if token.IDToken == "" {
+		return Identity{}, &InvalidTokenError{Reason: "no ID token"}
+	}
+
+	return provider.verifyIDToken(token.IDToken, Nonce)
+}
+
+//	verifyIDToken(Raw string, Nonce string) (Identity, error)
+/*	Verifies the signature and the claims of an ID token (OpenID Connect Core, 3.1.3.7).
+ */
+func (provider *OIDCProvider) verifyIDToken(Raw string, Nonce string) (Identity, error) {
+	var identity Identity
+
+	metadata, err := provider.discover()
+	if err != nil {
+		return identity, err
+	}
+
+	token, err := jwt.Parse(Raw, func(token *jwt.Token) (interface{}, error) {
+		// Only RS256, so that a token can't choose a weaker or symmetric algorithm
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, errors.New("unexpected signing method " + token.Method.Alg())
+		}
+		keyId, _ := token.Header["kid"].(string)
+		return provider.key(keyId)
+	})
+	if err != nil || !token.Valid {
+		reason := "invalid token"
+		if err != nil {
+			reason = err.Error()
+		}
+		return identity, &InvalidTokenError{Reason: reason}
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+
+	if issuer, _ := claims["iss"].(string); issuer != metadata.Issuer {
+		return identity, &InvalidTokenError{Reason: "wrong issuer"}
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return identity, &InvalidTokenError{Reason: "expired"}
+	}
+
+	// The audience is a string or a list, that must contain the application
+	audiences := []string{}
+	switch audience := claims["aud"].(type) {
+	case string:
+		audiences = append(audiences, audience)
+	case []interface{}:
+		for _, item := range audience {
+			if value, ok := item.(string); ok {
+				audiences = append(audiences, value)
+			}
+		}
+	}
+	found := false
+	for _, audience := range audiences {
+		found = found || audience == provider.Config.ClientID
+	}
+	if !found {
+		return identity, &InvalidTokenError{Reason: "wrong audience"}
+	}
+	if authorizedParty, ok := claims["azp"].(string); (len(audiences) > 1 || ok) && authorizedParty != provider.Config.ClientID {
+		return identity, &InvalidTokenError{Reason: "wrong authorized party"}
+	}
+
+	// The nonce links the token to the login started by this browser
+	if nonce, _ := claims["nonce"].(string); Nonce == "" || subtle.ConstantTimeCompare([]byte(nonce), []byte(Nonce)) != 1 {
+		return identity, &InvalidTokenError{Reason: "wrong nonce"}
+	}
+
+	// Only a verified mail can be mapped to a user
+	mail, _ := claims["email"].(string)
+	if verified, _ := claims["email_verified"].(bool); mail == "" || !verified {
+		return identity, &InvalidTokenError{Reason: "no verified mail"}
+	}
+
+	if !provider.allowedMail(mail) {
+		return identity, &InvalidTokenError{Reason: "the provider is not trusted for " + mail}
+	}
+
+	identity.Mail = mail
+	identity.Username, _ = claims["preferred_username"].(string)
+	identity.FirstName, _ = claims["given_name"].(string)
+	identity.LastName, _ = claims["family_name"].(string)
+
+	return identity, nil
+}
+
+func (provider *OIDCProvider) allowedMail(Mail string) bool {
+	if len(provider.Config.AllowedDomains) == 0 {
+		return true
+	}
+
+	at := strings.LastIndex(Mail, "@")
+	for _, domain := range provider.Config.AllowedDomains {
+		if at >= 0 && strings.EqualFold(Mail[at+1:], domain) {
+			return true
+		}
+	}
+	return false
+}
+
+//	discover() (*oidcMetadata, error)
+/*	Fetches the discovery document of the provider, the first time only.
+ */
+func (provider *OIDCProvider) discover() (*oidcMetadata, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.metadata != nil {
+		return provider.metadata, nil
+	}
+
+	var metadata oidcMetadata
+	if err := provider.getJSON(strings.TrimSuffix(provider.Config.Issuer, "/")+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, err
+	}
+
+	if metadata.Issuer != provider.Config.Issuer {
+		return nil, errors.New("The discovery document is for another issuer : " + metadata.Issuer)
+	}
+
+	provider.metadata = &metadata
+	return provider.metadata, nil
+}
+
+//	key(KeyId string) (*rsa.PublicKey, error)
+/*	Returns a key of the provider. The keys are fetched again when the key is unknown, as the provider may have rotated them.
+ */
+func (provider *OIDCProvider) key(KeyId string) (*rsa.PublicKey, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if key, ok := provider.keys[KeyId]; ok {
+		return key, nil
+	}
+
+	var published jsonWebKeys
+	if err := provider.getJSON(provider.metadata.JWKSURI, &published); err != nil {
+		return nil, err
+	}
+
+	provider.keys = map[string]*rsa.PublicKey{}
+	for _, key := range published.Keys {
+		if key.KeyType != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+
+		modulus, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			continue
+		}
+		exponent, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			continue
+		}
+
+		provider.keys[key.KeyId] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(modulus),
+			E: int(new(big.Int).SetBytes(exponent).Int64()),
+		}
+	}
+
+	if key, ok := provider.keys[KeyId]; ok {
+		return key, nil
+	}
+	return nil, errors.New("unknown key " + KeyId)
+}
+
+func (provider *OIDCProvider) getJSON(URL string, Value interface{}) error {
+	response, err := provider.client.Get(URL)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return errors.New(URL + " answered " + response.Status)
+	}
+
+	return json.NewDecoder(response.Body).Decode(Value)
+}
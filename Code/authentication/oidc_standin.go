@@ -0,0 +1,229 @@
+package authentication
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// The identifier of the key used by the stand-in issuer.
+const standInKeyId = "stand-in"
+
+// OIDCStandInUser : The user logged in at the stand-in issuer.
+type OIDCStandInUser struct {
+	Subject      string
+	Mail         string
+	MailVerified bool
+	FirstName    string
+	LastName     string
+}
+
+// OIDCStandIn : A minimal in-process OpenID Connect issuer.
+// It implements the discovery, the keys, the authorization endpoint (that logs in the user set with SignIn
+// without asking anything) and the token endpoint, with the verifications of PKCE.
+// It is used to test the OpenID Connect authentication without a real identity provider.
+/*	ClientID, ClientSecret : The only client accepted.
+	Tamper : If set, called on the claims of every ID token before signing it, so the tests can break them.
+*/
+type OIDCStandIn struct {
+	ClientID     string
+	ClientSecret string
+	Tamper       func(Claims jwt.MapClaims)
+
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	mutex  sync.Mutex
+	user   OIDCStandInUser
+	codes  map[string]oidcStandInCode
+}
+
+// oidcStandInCode : What the stand-in remembers about an authorization code.
+type oidcStandInCode struct {
+	RedirectURI string
+	Challenge   string
+	Nonce       string
+	User        OIDCStandInUser
+}
+
+//	StartOIDCStandIn(ClientID string, ClientSecret string) (*OIDCStandIn, error)
+/*	Starts a stand-in issuer on a free local port.
+ */
+func StartOIDCStandIn(ClientID string, ClientSecret string) (*OIDCStandIn, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	issuer := &OIDCStandIn{
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		key:          key,
+		codes:        map[string]oidcStandInCode{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/keys", issuer.keys)
+	mux.HandleFunc("/authorize", issuer.authorize)
+	mux.HandleFunc("/token", issuer.token)
+	issuer.server = httptest.NewServer(mux)
+
+	return issuer, nil
+}
+
+//	Issuer() string
+/*	Returns the issuer URL to give to OIDCConfig.
+ */
+func (issuer *OIDCStandIn) Issuer() string {
+	return issuer.server.URL
+}
+
+//	SignIn(User OIDCStandInUser)
+/*	Sets the user that the authorization endpoint logs in.
+ */
+func (issuer *OIDCStandIn) SignIn(User OIDCStandInUser) {
+	issuer.mutex.Lock()
+	defer issuer.mutex.Unlock()
+	issuer.user = User
+}
+
+//	Close()
+/*	Stops the stand-in issuer.
+ */
+func (issuer *OIDCStandIn) Close() {
+	issuer.server.Close()
+}
+
+func (issuer *OIDCStandIn) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(oidcMetadata{
+		Issuer:                issuer.Issuer(),
+		AuthorizationEndpoint: issuer.Issuer() + "/authorize",
+		TokenEndpoint:         issuer.Issuer() + "/token",
+		JWKSURI:               issuer.Issuer() + "/keys",
+	})
+}
+
+func (issuer *OIDCStandIn) keys(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": standInKeyId,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(issuer.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(issuer.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (issuer *OIDCStandIn) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("response_type") != "code" || query.Get("client_id") != issuer.ClientID ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(query.Get("redirect_uri"))
+	if err != nil || query.Get("redirect_uri") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code, err := RandomURLToken()
+	if err != nil {
+		http.Error(w, "server_error", http.StatusInternalServerError)
+		return
+	}
+
+	issuer.mutex.Lock()
+	issuer.codes[code] = oidcStandInCode{
+		RedirectURI: query.Get("redirect_uri"),
+		Challenge:   query.Get("code_challenge"),
+		Nonce:       query.Get("nonce"),
+		User:        issuer.user,
+	}
+	issuer.mutex.Unlock()
+
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (issuer *OIDCStandIn) token(w http.ResponseWriter, r *http.Request) {
+	fail := func(Error string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": Error})
+	}
+
+	clientId, clientSecret, _ := r.BasicAuth()
+	clientId, _ = url.QueryUnescape(clientId)
+	clientSecret, _ = url.QueryUnescape(clientSecret)
+	if clientId != issuer.ClientID || clientSecret != issuer.ClientSecret {
+		fail("invalid_client")
+		return
+	}
+
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		fail("unsupported_grant_type")
+		return
+	}
+
+	// A code can only be used once
+	issuer.mutex.Lock()
+	code, ok := issuer.codes[r.PostFormValue("code")]
+	delete(issuer.codes, r.PostFormValue("code"))
+	issuer.mutex.Unlock()
+
+	if !ok || code.RedirectURI != r.PostFormValue("redirect_uri") || PKCEChallenge(r.PostFormValue("code_verifier")) != code.Challenge {
+		fail("invalid_grant")
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":            issuer.Issuer(),
+		"sub":            code.User.Subject,
+		"aud":            issuer.ClientID,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		"nonce":          code.Nonce,
+		"email":          code.User.Mail,
+		"email_verified": code.User.MailVerified,
+		"given_name":     code.User.FirstName,
+		"family_name":    code.User.LastName,
+	}
+	if issuer.Tamper != nil {
+		issuer.Tamper(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = standInKeyId
+
+	idToken, err := token.SignedString(issuer.key)
+	if err != nil {
+		http.Error(w, "server_error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "stand-in-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
@@ -0,0 +1,46 @@
+package authentication
+
+import (
+	"errors"
+)
+
+// The name of the authentication done with the passwords stored in the database.
+const LocalProvider = "local"
+
+// Returned by the providers when the credentials are wrong, or when the user is unknown.
+var ErrInvalidCredentials = errors.New("Invalid credentials")
+
+// Identity : What a provider knows about an authenticated user.
+/*	Username : The username of the user in the directory (his UCA username).
+	Mail : The email address of the user.
+	FirstName, LastName : The name of the user, if the directory knows it.
+*/
+type Identity struct {
+	Username  string
+	Mail      string
+	FirstName string
+	LastName  string
+}
+
+// Provisioning : Defines if and how the users authenticated by a provider are created on their first login.
+/*	Enabled : Wether unknown users are created.
+	RoleId : The role given to the created users.
+	ContractId : The contract given to the created users.
+*/
+type Provisioning struct {
+	Enabled    bool
+	RoleId     int64
+	ContractId int64
+}
+
+// Provider : An external service that verifies the credentials of the users, like a directory.
+/*	Name : The name stored in the auth_provider column of the users authenticated by this provider.
+	Authenticate : Verifies a login (a username or a mail) and a password.
+		Returns ErrInvalidCredentials when they are wrong.
+	Provisioning : Tells if the unknown users authenticated by this provider must be created.
+*/
+type Provider interface {
+	Name() string
+	Authenticate(Login string, Password string) (Identity, error)
+	Provisioning() Provisioning
+}
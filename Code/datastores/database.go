@@ -16,12 +16,26 @@ PRAGMA temp_store = 2;
 PRAGMA journal_mode = WAL;
 PRAGMA temp_store = MEMORY;
 
+DROP TABLE IF EXISTS Holiday;
+DROP TABLE IF EXISTS ScheduleBreak;
+DROP TABLE IF EXISTS TemplateSlot;
+DROP TABLE IF EXISTS ScheduleTemplate;
+DROP TABLE IF EXISTS LockDate;
+DROP TABLE IF EXISTS TimesheetEvent;
+DROP TABLE IF EXISTS TimesheetPeriod;
+DROP TABLE IF EXISTS ImpersonationAction;
+DROP TABLE IF EXISTS Impersonation;
+DROP TABLE IF EXISTS AccessToken;
+DROP TABLE IF EXISTS RecoveryCode;
+DROP TABLE IF EXISTS TwoFactor;
+DROP TABLE IF EXISTS LoginAttempt;
 DROP TABLE IF EXISTS UserFunction;
 DROP TABLE IF EXISTS UserSchedule;
 DROP TABLE IF EXISTS CompanyUser;
 DROP TABLE IF EXISTS CompanyProject;
 DROP TABLE IF EXISTS Comment;
 DROP TABLE IF EXISTS Schedule;
+DROP TABLE IF EXISTS ScheduleSeries;
 DROP TABLE IF EXISTS User;
 DROP TABLE IF EXISTS Role;
 DROP TABLE IF EXISTS Project;
@@ -31,7 +45,17 @@ DROP TABLE IF EXISTS Contract;
 
 CREATE TABLE IF NOT EXISTS Contract (
     contract_id integer PRIMARY KEY AUTOINCREMENT,
-	contract_name text NOT NULL
+	contract_name text NOT NULL,
+    break_after_minutes integer NOT NULL DEFAULT 0,
+    minimum_break_minutes integer NOT NULL DEFAULT 0,
+    max_daily_minutes integer NOT NULL DEFAULT 0,
+    max_weekly_minutes integer NOT NULL DEFAULT 0,
+    max_average_weekly_minutes integer NOT NULL DEFAULT 0,
+    min_daily_rest_minutes integer NOT NULL DEFAULT 0,
+    min_weekly_rest_minutes integer NOT NULL DEFAULT 0,
+    max_night_minutes integer NOT NULL DEFAULT 0,
+    night_work_forbidden bool NOT NULL DEFAULT 0,
+    block_violations bool NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS Function (
@@ -41,12 +65,15 @@ CREATE TABLE IF NOT EXISTS Function (
 
 CREATE TABLE IF NOT EXISTS Company (
     company_id integer PRIMARY KEY AUTOINCREMENT,
-    company_name text NOT NULL
+    company_name text NOT NULL,
+    time_zone text NOT NULL DEFAULT '',
+    holiday_calendar text NOT NULL DEFAULT ''
 );
 
 CREATE TABLE IF NOT EXISTS Project (
     project_id integer PRIMARY KEY AUTOINCREMENT,
-    project_name text NOT NULL UNIQUE
+    project_name text NOT NULL UNIQUE,
+    allow_overlaps bool NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS Role (
@@ -55,7 +82,8 @@ CREATE TABLE IF NOT EXISTS Role (
     can_add_and_modify_users bool NOT NULL,
     can_see_other_schedules bool NOT NULL,
     can_add_projects bool NOT NULL,
-    can_see_reports bool NOT NULL
+    can_see_reports bool NOT NULL,
+    require_two_factor bool NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS User (
@@ -69,16 +97,35 @@ CREATE TABLE IF NOT EXISTS User (
     mail text NOT NULL UNIQUE,
     theorical_hours_worked integer NOT NULL,
     vacation_hours integer NOT NULL,
+    must_change_password bool NOT NULL DEFAULT 0,
+    auth_provider text NOT NULL DEFAULT 'local',
+    time_zone text NOT NULL DEFAULT '',
     CONSTRAINT FK_User_Contract FOREIGN KEY (contract_id) REFERENCES Contract(contract_id),
     CONSTRAINT FK_User_Role FOREIGN KEY (role_id) REFERENCES Role(role_id)
 );
 
+CREATE TABLE IF NOT EXISTS ScheduleSeries (
+    series_id integer PRIMARY KEY AUTOINCREMENT,
+    project_id integer NOT NULL,
+    user_id integer NOT NULL,
+    start_date datetime NOT NULL,
+    end_date datetime NOT NULL,
+    time_zone text NOT NULL,
+    recurrence_rule text NOT NULL,
+    exception_dates text NOT NULL DEFAULT '',
+    generated_until datetime NOT NULL,
+    CONSTRAINT FK_ScheduleSeries_Project FOREIGN KEY (project_id) REFERENCES Project(project_id),
+    CONSTRAINT FK_ScheduleSeries_User FOREIGN KEY (user_id) REFERENCES User(user_id)
+);
+
 CREATE TABLE IF NOT EXISTS Schedule (
     schedule_id integer PRIMARY KEY AUTOINCREMENT,
     project_id integer NOT NULL,
     start_date datetime NOT NULL,
-    end_date datetime NOT NULL,
-    CONSTRAINT FK_Schedule_Function FOREIGN KEY (project_id) REFERENCES Project(project_id)
+    end_date datetime,
+    series_id integer,
+    CONSTRAINT FK_Schedule_Function FOREIGN KEY (project_id) REFERENCES Project(project_id),
+    CONSTRAINT FK_Schedule_ScheduleSeries FOREIGN KEY (series_id) REFERENCES ScheduleSeries(series_id)
 );
 
 CREATE TABLE IF NOT EXISTS Comment (
@@ -120,6 +167,128 @@ CREATE TABLE IF NOT EXISTS UserFunction (
     CONSTRAINT FK_UF_Function FOREIGN KEY (function_id) REFERENCES Function(function_id),
     CONSTRAINT PK_UserFunction PRIMARY KEY (user_id, function_id)
 );
+
+CREATE TABLE IF NOT EXISTS LoginAttempt (
+    attempt_key text PRIMARY KEY,
+    failed_attempts integer NOT NULL,
+    last_failure datetime,
+    locked_until datetime
+);
+
+CREATE TABLE IF NOT EXISTS TwoFactor (
+    user_id integer PRIMARY KEY,
+    secret text NOT NULL,
+    enabled bool NOT NULL,
+    last_used_step integer NOT NULL DEFAULT 0,
+    CONSTRAINT FK_TwoFactor_User FOREIGN KEY (user_id) REFERENCES User(user_id)
+);
+
+CREATE TABLE IF NOT EXISTS RecoveryCode (
+    user_id integer,
+    code_hash text,
+    CONSTRAINT FK_RecoveryCode_User FOREIGN KEY (user_id) REFERENCES User(user_id),
+    CONSTRAINT PK_RecoveryCode PRIMARY KEY (user_id, code_hash)
+);
+
+CREATE TABLE IF NOT EXISTS AccessToken (
+    access_token_id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL,
+    name text NOT NULL,
+    token_hash text NOT NULL UNIQUE,
+    prefix text NOT NULL,
+    scope text NOT NULL,
+    created_at datetime NOT NULL,
+    expires_at datetime,
+    last_used_at datetime,
+    CONSTRAINT FK_AccessToken_User FOREIGN KEY (user_id) REFERENCES User(user_id)
+);
+
+CREATE TABLE IF NOT EXISTS Impersonation (
+    impersonation_id integer PRIMARY KEY AUTOINCREMENT,
+    impersonator_id integer NOT NULL,
+    user_id integer NOT NULL,
+    reason text NOT NULL,
+    started_at datetime NOT NULL,
+    expires_at datetime NOT NULL,
+    ended_at datetime
+);
+
+CREATE TABLE IF NOT EXISTS ImpersonationAction (
+    impersonation_action_id integer PRIMARY KEY AUTOINCREMENT,
+    impersonation_id integer NOT NULL,
+    impersonator_id integer NOT NULL,
+    user_id integer NOT NULL,
+    method text NOT NULL,
+    path text NOT NULL,
+    status_code integer NOT NULL,
+    created_at datetime NOT NULL,
+    CONSTRAINT FK_ImpersonationAction_Impersonation FOREIGN KEY (impersonation_id) REFERENCES Impersonation(impersonation_id)
+);
+CREATE TABLE IF NOT EXISTS TimesheetPeriod (
+    period_id integer PRIMARY KEY AUTOINCREMENT,
+    user_id integer NOT NULL,
+    period text NOT NULL,
+    start_date datetime NOT NULL,
+    end_date datetime NOT NULL,
+    state text NOT NULL,
+    CONSTRAINT UQ_TimesheetPeriod UNIQUE (user_id, period)
+);
+
+CREATE TABLE IF NOT EXISTS TimesheetEvent (
+    event_id integer PRIMARY KEY AUTOINCREMENT,
+    period_id integer NOT NULL,
+    state text NOT NULL,
+    author_id integer NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    created_at datetime NOT NULL,
+    CONSTRAINT FK_TimesheetEvent_TimesheetPeriod FOREIGN KEY (period_id) REFERENCES TimesheetPeriod(period_id)
+);
+
+CREATE TABLE IF NOT EXISTS LockDate (
+    lock_id integer PRIMARY KEY AUTOINCREMENT,
+    lock_date datetime,
+    author_id integer NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    created_at datetime NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS ScheduleTemplate (
+    template_id integer PRIMARY KEY AUTOINCREMENT,
+    template_name text NOT NULL,
+    user_id integer,
+    company_id integer,
+    CONSTRAINT FK_ScheduleTemplate_User FOREIGN KEY (user_id) REFERENCES User(user_id),
+    CONSTRAINT FK_ScheduleTemplate_Company FOREIGN KEY (company_id) REFERENCES Company(company_id)
+);
+
+CREATE TABLE IF NOT EXISTS TemplateSlot (
+    slot_id integer PRIMARY KEY AUTOINCREMENT,
+    template_id integer NOT NULL,
+    project_id integer NOT NULL,
+    weekday integer NOT NULL,
+    start_time text NOT NULL,
+    end_time text NOT NULL,
+    CONSTRAINT FK_TemplateSlot_ScheduleTemplate FOREIGN KEY (template_id) REFERENCES ScheduleTemplate(template_id),
+    CONSTRAINT FK_TemplateSlot_Project FOREIGN KEY (project_id) REFERENCES Project(project_id)
+);
+
+CREATE TABLE IF NOT EXISTS ScheduleBreak (
+    break_id integer PRIMARY KEY AUTOINCREMENT,
+    schedule_id integer NOT NULL,
+    start_date datetime NOT NULL,
+    end_date datetime NOT NULL,
+    CONSTRAINT FK_ScheduleBreak_Schedule FOREIGN KEY (schedule_id) REFERENCES Schedule(schedule_id)
+);
+
+CREATE TABLE IF NOT EXISTS Holiday (
+    holiday_id integer PRIMARY KEY AUTOINCREMENT,
+    company_id integer NOT NULL,
+    day text NOT NULL,
+    name text NOT NULL,
+    kind text NOT NULL DEFAULT 'holiday',
+    CONSTRAINT UQ_Holiday UNIQUE (company_id, day),
+    CONSTRAINT FK_Holiday_Company FOREIGN KEY (company_id) REFERENCES Company(company_id)
+);
 `
 
 type ConcreteDatastore struct {
@@ -188,6 +357,7 @@ func NewDatabase(databaseName string) (*ConcreteDatastore, error) {
 
 	// Creating a "default" user with all permissions.. Otherwise, we can't do anything
 	// For that, we need a contract. This contract will only be used for this user.
+	// As its credentials are publicly known, the password has to be changed before using the API.
 	if adminContractId, err = datastore.CreateContract(model.Contract{
 		ContractName: "Admin",
 	}); err != nil {
@@ -199,11 +369,12 @@ func NewDatabase(databaseName string) (*ConcreteDatastore, error) {
 	}
 
 	if _, err = datastore.CreateUser(model.User{
-		ContractId: adminContractId,
-		RoleId:     adminRoleId,
-		Username:   "Admin",
-		Mail:       "admin@mydb",
-		Password:   string(cryptedPassword),
+		ContractId:         adminContractId,
+		RoleId:             adminRoleId,
+		Username:           "Admin",
+		Mail:               "admin@mydb",
+		Password:           string(cryptedPassword),
+		MustChangePassword: true,
 	}); err != nil {
 		return nil, err
 	}
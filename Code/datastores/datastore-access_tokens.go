@@ -0,0 +1,128 @@
+package datastores
+
+import (
+	"database/sql"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//  GetAccessTokensOfUser(UserId int64) (model.AccessTokens, error)
+/*	This method is used to get all the API tokens of a user.
+ */
+func (db *ConcreteDatastore) GetAccessTokensOfUser(UserId int64) (model.AccessTokens, error) {
+	var (
+		err    error
+		tokens model.AccessTokens
+	)
+
+	// Setting up and executing the request
+	request := `SELECT * FROM AccessToken WHERE user_id=?`
+	if err = db.Select(&tokens, request, UserId); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+//  GetAccessToken(AccessTokenId int64) (model.AccessToken, error)
+/*	This method is used to get an API token.
+ */
+func (db *ConcreteDatastore) GetAccessToken(AccessTokenId int64) (model.AccessToken, error) {
+	var (
+		err   error
+		token model.AccessToken
+	)
+
+	// Setting up and executing the request
+	request := `SELECT * FROM AccessToken WHERE access_token_id=?`
+	if err = db.Get(&token, request, AccessTokenId); err != nil {
+		return model.AccessToken{}, err
+	}
+
+	return token, nil
+}
+
+//  GetAccessTokenFromHash(TokenHash string) (model.AccessToken, error)
+/*	This method is used to find the API token used by a request.
+	Returns sql.ErrNoRows if no token has this hash.
+*/
+func (db *ConcreteDatastore) GetAccessTokenFromHash(TokenHash string) (model.AccessToken, error) {
+	var (
+		err   error
+		token model.AccessToken
+	)
+
+	// Setting up and executing the request
+	request := `SELECT * FROM AccessToken WHERE token_hash=?`
+	if err = db.Get(&token, request, TokenHash); err != nil {
+		return model.AccessToken{}, err
+	}
+
+	return token, nil
+}
+
+//  CreateAccessToken(Token model.AccessToken) (int64, error)
+/*	This method is used to create an API token.
+	The token must already be hashed.
+*/
+func (db *ConcreteDatastore) CreateAccessToken(Token model.AccessToken) (int64, error) {
+	var (
+		tx  *sql.Tx
+		err error
+		res sql.Result
+		id  int64
+	)
+
+	// Starting
+	if tx, err = db.Begin(); err != nil {
+		return -1, err
+	}
+
+	// Executing the request
+	request := `INSERT INTO AccessToken(user_id, name, token_hash, prefix, scope, created_at, expires_at, last_used_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if res, err = tx.Exec(request, Token.UserId, Token.Name, Token.TokenHash, Token.Prefix, Token.Scope, Token.CreatedAt, Token.ExpiresAt, Token.LastUsedAt); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Getting the id of the new token
+	if id, err = res.LastInsertId(); err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+//  DeleteAccessToken(AccessTokenId int64) error
+/*	This method is used to revoke an API token.
+ */
+func (db *ConcreteDatastore) DeleteAccessToken(AccessTokenId int64) error {
+	request := `DELETE FROM AccessToken WHERE access_token_id=?`
+	if _, err := db.Exec(request, AccessTokenId); err != nil {
+		return err
+	}
+	return nil
+}
+
+//  UpdateAccessTokenLastUse(AccessTokenId int64, LastUsedAt time.Time) error
+/*	This method is used to save the date of the last request made with an API token.
+ */
+func (db *ConcreteDatastore) UpdateAccessTokenLastUse(AccessTokenId int64, LastUsedAt time.Time) error {
+	request := `UPDATE AccessToken SET last_used_at=? WHERE access_token_id=?`
+	if _, err := db.Exec(request, LastUsedAt, AccessTokenId); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package datastores
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//  GetBreak(BreakId int64) (model.ScheduleBreak, error)
+/*	This method is used to get a break taken during a schedule.
+ */
+func (db *ConcreteDatastore) GetBreak(BreakId int64) (model.ScheduleBreak, error) {
+	var scheduleBreak model.ScheduleBreak
+
+	request := `SELECT * FROM ScheduleBreak WHERE break_id=?`
+	if err := db.Get(&scheduleBreak, request, BreakId); err != nil {
+		return model.ScheduleBreak{}, err
+	}
+
+	return scheduleBreak, nil
+}
+
+//  GetBreaksOfSchedule(ScheduleId int64) (model.ScheduleBreaks, error)
+/*	This method is used to get the breaks taken during a schedule, the first one first.
+ */
+func (db *ConcreteDatastore) GetBreaksOfSchedule(ScheduleId int64) (model.ScheduleBreaks, error) {
+	breaks := model.ScheduleBreaks{}
+
+	request := `SELECT * FROM ScheduleBreak WHERE schedule_id=? ORDER BY start_date, break_id`
+	if err := db.Select(&breaks, request, ScheduleId); err != nil {
+		return nil, err
+	}
+
+	return breaks, nil
+}
+
+//  GetBreaksOfUser(UserId int64) (model.ScheduleBreaks, error)
+/*	This method is used to get the breaks taken during all the schedules of a user, the first one first.
+ */
+func (db *ConcreteDatastore) GetBreaksOfUser(UserId int64) (model.ScheduleBreaks, error) {
+	breaks := model.ScheduleBreaks{}
+
+	request := `SELECT B.break_id, B.schedule_id, B.start_date, B.end_date
+	FROM ScheduleBreak B, UserSchedule US
+	WHERE B.schedule_id = US.schedule_id
+	AND US.user_id=?
+	ORDER BY B.start_date, B.break_id`
+	if err := db.Select(&breaks, request, UserId); err != nil {
+		return nil, err
+	}
+
+	return breaks, nil
+}
+
+//  CreateBreak(Break model.ScheduleBreak) (int64, error)
+/*	This method is used to add a break to a schedule.
+	Returns a LockedError if the schedule starts before the lock date, or is in an approved timesheet period of one of its users.
+*/
+func (db *ConcreteDatastore) CreateBreak(Break model.ScheduleBreak) (int64, error) {
+	var (
+		tx  *sqlx.Tx
+		err error
+		res sql.Result
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return -1, err
+	}
+
+	// The schedule must not be locked
+	if err = checkScheduleLocked(tx, Break.ScheduleId); err == nil {
+		request := `INSERT INTO ScheduleBreak(schedule_id, start_date, end_date) VALUES (?, ?, ?)`
+		if res, err = tx.Exec(request, Break.ScheduleId, Break.StartDate.UTC(), Break.EndDate.UTC()); err == nil {
+			Break.BreakId, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	return Break.BreakId, nil
+}
+
+//  UpdateBreak(Break model.ScheduleBreak) (model.ScheduleBreak, error)
+/*	This method is used to change the dates of a break. It stays with its schedule.
+	Returns a LockedError as CreateBreak.
+*/
+func (db *ConcreteDatastore) UpdateBreak(Break model.ScheduleBreak) (model.ScheduleBreak, error) {
+	var (
+		tx  *sqlx.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return model.ScheduleBreak{}, err
+	}
+
+	if err = checkBreakLocked(tx, Break.BreakId); err == nil {
+		request := `UPDATE ScheduleBreak SET start_date=?, end_date=? WHERE break_id=?`
+		_, err = tx.Exec(request, Break.StartDate.UTC(), Break.EndDate.UTC(), Break.BreakId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.ScheduleBreak{}, errr
+		}
+		return model.ScheduleBreak{}, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.ScheduleBreak{}, errr
+		}
+		return model.ScheduleBreak{}, err
+	}
+
+	return Break, nil
+}
+
+//  DeleteBreak(BreakId int64) error
+/*	This method is used to delete a break : its hours count as worked again.
+	Returns a LockedError as CreateBreak.
+*/
+func (db *ConcreteDatastore) DeleteBreak(BreakId int64) error {
+	var (
+		tx  *sqlx.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	if err = checkBreakLocked(tx, BreakId); err == nil {
+		_, err = tx.Exec(`DELETE FROM ScheduleBreak WHERE break_id=?`, BreakId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
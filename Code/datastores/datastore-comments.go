@@ -156,17 +156,26 @@ func (db *ConcreteDatastore) GetCommentsOfProject(ProjectId int64) (model.Commen
 //  CreateComment(Comment model.Comment) (int64, error)
 /*	This method is used to create a new comment.
 	It returns the id of the created comment, or an error.
+	Returns a LockedError if its schedule is in an approved timesheet period.
 */
 func (db *ConcreteDatastore) CreateComment(Comment model.Comment) (int64, error) {
 	var (
-		tx        *sql.Tx
+		tx        *sqlx.Tx
 		err       error
 		res       sql.Result
 		commentId int64
 	)
 
 	// Preparing to request
-	if tx, err = db.Begin(); err != nil {
+	if tx, err = db.Beginx(); err != nil {
+		return -1, err
+	}
+
+	// The schedule must not be in an approved period
+	if err = checkScheduleLocked(tx, Comment.ScheduleId); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
 		return -1, err
 	}
 
@@ -200,15 +209,41 @@ func (db *ConcreteDatastore) CreateComment(Comment model.Comment) (int64, error)
 
 //  DeleteComment(CommentId int64) error
 /*	This method is used to delete the comment of the given id.
-	Returns an error if there is one
+	Returns a LockedError if its schedule is in an approved timesheet period.
 */
 func (db *ConcreteDatastore) DeleteComment(CommentId int64) error {
-	// Setting up and executing the request
-	request := `DELETE FROM Comment 
-	WHERE comment_id=?`
-	if _, err := db.Exec(request, CommentId); err != nil {
+	var (
+		tx  *sqlx.Tx
+		err error
+	)
+
+	// Preparing the request
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	// The schedule of the comment must not be in an approved period
+	if err = checkCommentLocked(tx, CommentId); err == nil {
+		// Setting up and executing the request
+		request := `DELETE FROM Comment 
+		WHERE comment_id=?`
+		_, err = tx.Exec(request, CommentId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
 		return err
 	}
+
 	return nil
 }
 
@@ -216,15 +251,27 @@ func (db *ConcreteDatastore) DeleteComment(CommentId int64) error {
 /*	This method is used to update a comment.
 	The comment taken as a parameter is the new version of the comment.
 	Returns the new comment, or an error
+	Returns a LockedError if its schedule, or its new schedule, is in an approved timesheet period.
 */
 func (db *ConcreteDatastore) UpdateComment(Comment model.Comment) (model.Comment, error) {
 	var (
-		tx  *sql.Tx
+		tx  *sqlx.Tx
 		err error
 	)
 
 	// Preparing the request
-	if tx, err = db.Begin(); err != nil {
+	if tx, err = db.Beginx(); err != nil {
+		return model.Comment{}, err
+	}
+
+	// Neither the schedule of the comment nor its new one must be in an approved period
+	if err = checkCommentLocked(tx, Comment.CommentId); err == nil {
+		err = checkScheduleLocked(tx, Comment.ScheduleId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.Comment{}, errr
+		}
 		return model.Comment{}, err
 	}
 
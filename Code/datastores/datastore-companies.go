@@ -54,6 +54,37 @@ func (db *ConcreteDatastore) GetCompany(CompanyId int64) (model.Company, error)
 	return company, nil
 }
 
+//  GetCompaniesOfUser(UserId int64) (model.Companies, error)
+/*	This method is used to get the companies a user is linked to.
+	Returns the list of companies, or an error
+*/
+func (db *ConcreteDatastore) GetCompaniesOfUser(UserId int64) (model.Companies, error) {
+	// Setting up the request and executing it
+	request := `SELECT C.company_id, C.company_name, C.time_zone, C.holiday_calendar
+	FROM Company C, CompanyUser CU
+	WHERE C.company_id = CU.company_id
+	AND CU.user_id=?`
+	rows, err := db.Queryx(request, UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Formatting the data
+	companiesList := model.Companies{}
+
+	for rows.Next() {
+		company := model.Company{}
+		err := rows.StructScan(&company)
+		if err != nil {
+			return nil, err
+		}
+		companiesList = append(companiesList, company)
+	}
+
+	defer rows.Close()
+	return companiesList, nil
+}
+
 //  CreateCompany(Company model.Company) (int64, error)
 /*	This method is used to create a new company.
 	Takes the new company as a parameter.
@@ -73,8 +104,8 @@ func (db *ConcreteDatastore) CreateCompany(Company model.Company) (int64, error)
 	}
 
 	// Setting up the request and executing it
-	request := `INSERT INTO Company(company_name) VALUES (?)`
-	if res, err = tx.Exec(request, Company.CompanyName); err != nil {
+	request := `INSERT INTO Company(company_name, time_zone, holiday_calendar) VALUES (?, ?, ?)`
+	if res, err = tx.Exec(request, Company.CompanyName, Company.TimeZone, Company.HolidayCalendar); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return -1, errr
 		}
@@ -132,9 +163,9 @@ func (db *ConcreteDatastore) UpdateCompany(Company model.Company) (model.Company
 
 	// Setting up the request and executing it
 	request := `UPDATE Company 
-	SET company_name=?
+	SET company_name=?, time_zone=?, holiday_calendar=?
 	WHERE company_id=?`
-	if _, err = tx.Exec(request, Company.CompanyName, Company.CompanyId); err != nil {
+	if _, err = tx.Exec(request, Company.CompanyName, Company.TimeZone, Company.HolidayCalendar, Company.CompanyId); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return model.Company{}, errr
 		}
@@ -98,8 +98,12 @@ func (db *ConcreteDatastore) CreateContract(Contract model.Contract) (int64, err
 	}
 
 	// Setting up the request and executing it
-	request := `INSERT INTO Contract(contract_name) VALUES (?)`
-	if res, err = tx.Exec(request, Contract.ContractName); err != nil {
+	request := `INSERT INTO Contract(contract_name, break_after_minutes, minimum_break_minutes, max_daily_minutes, max_weekly_minutes,
+	max_average_weekly_minutes, min_daily_rest_minutes, min_weekly_rest_minutes, max_night_minutes, night_work_forbidden, block_violations)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if res, err = tx.Exec(request, Contract.ContractName, Contract.BreakAfterMinutes, Contract.MinimumBreakMinutes, Contract.MaxDailyMinutes,
+		Contract.MaxWeeklyMinutes, Contract.MaxAverageWeeklyMinutes, Contract.MinDailyRestMinutes, Contract.MinWeeklyRestMinutes,
+		Contract.MaxNightMinutes, Contract.NightWorkForbidden, Contract.BlockViolations); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return -1, errr
 		}
@@ -155,9 +159,13 @@ func (db *ConcreteDatastore) UpdateContract(Contract model.Contract) (model.Cont
 
 	// Setting up the request and executing it
 	request := `UPDATE Contract 
-	SET contract_name=?
+	SET contract_name=?, break_after_minutes=?, minimum_break_minutes=?, max_daily_minutes=?, max_weekly_minutes=?,
+	max_average_weekly_minutes=?, min_daily_rest_minutes=?, min_weekly_rest_minutes=?, max_night_minutes=?, night_work_forbidden=?,
+	block_violations=?
 	WHERE contract_id=?`
-	if _, err = tx.Exec(request, Contract.ContractName, Contract.ContractId); err != nil {
+	if _, err = tx.Exec(request, Contract.ContractName, Contract.BreakAfterMinutes, Contract.MinimumBreakMinutes, Contract.MaxDailyMinutes,
+		Contract.MaxWeeklyMinutes, Contract.MaxAverageWeeklyMinutes, Contract.MinDailyRestMinutes, Contract.MinWeeklyRestMinutes,
+		Contract.MaxNightMinutes, Contract.NightWorkForbidden, Contract.BlockViolations, Contract.ContractId); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return model.Contract{}, errr
 		}
@@ -0,0 +1,104 @@
+package datastores
+
+import (
+	"database/sql"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//  GetHoliday(HolidayId int64) (model.Holiday, error)
+/*	This method is used to get a day off of a company.
+ */
+func (db *ConcreteDatastore) GetHoliday(HolidayId int64) (model.Holiday, error) {
+	var holiday model.Holiday
+
+	request := `SELECT * FROM Holiday WHERE holiday_id=?`
+	if err := db.Get(&holiday, request, HolidayId); err != nil {
+		return model.Holiday{}, err
+	}
+
+	return holiday, nil
+}
+
+//  GetHolidaysOfCompany(CompanyId int64) (model.Holidays, error)
+/*	This method is used to get the days off of a company, the first one first.
+ */
+func (db *ConcreteDatastore) GetHolidaysOfCompany(CompanyId int64) (model.Holidays, error) {
+	holidays := model.Holidays{}
+
+	request := `SELECT * FROM Holiday WHERE company_id=? ORDER BY day`
+	if err := db.Select(&holidays, request, CompanyId); err != nil {
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+//  GetHolidaysOfUser(UserId int64) (model.Holidays, error)
+/*	This method is used to get the days off of all the companies of a user, the first one first.
+ */
+func (db *ConcreteDatastore) GetHolidaysOfUser(UserId int64) (model.Holidays, error) {
+	holidays := model.Holidays{}
+
+	request := `SELECT H.holiday_id, H.company_id, H.day, H.name, H.kind
+	FROM Holiday H, CompanyUser CU
+	WHERE H.company_id = CU.company_id
+	AND CU.user_id=?
+	ORDER BY H.day, H.company_id`
+	if err := db.Select(&holidays, request, UserId); err != nil {
+		return nil, err
+	}
+
+	return holidays, nil
+}
+
+//  CreateHoliday(Holiday model.Holiday) (int64, error)
+/*	This method is used to add a day off to a company. A company has a single day off per day.
+ */
+func (db *ConcreteDatastore) CreateHoliday(Holiday model.Holiday) (int64, error) {
+	var (
+		tx        *sql.Tx
+		err       error
+		res       sql.Result
+		holidayId int64
+	)
+
+	// Preparing the request
+	if tx, err = db.Begin(); err != nil {
+		return -1, err
+	}
+
+	// Setting up the request and executing it
+	request := `INSERT INTO Holiday(company_id, day, name, kind) VALUES (?, ?, ?, ?)`
+	if res, err = tx.Exec(request, Holiday.CompanyId, Holiday.Day, Holiday.Name, Holiday.Kind); err == nil {
+		holidayId, err = res.LastInsertId()
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	return holidayId, nil
+}
+
+//  DeleteHoliday(HolidayId int64) error
+/*	This method is used to delete a day off of a company.
+ */
+func (db *ConcreteDatastore) DeleteHoliday(HolidayId int64) error {
+	request := `DELETE FROM Holiday 
+	WHERE holiday_id=?`
+	if _, err := db.Exec(request, HolidayId); err != nil {
+		return err
+	}
+	return nil
+}
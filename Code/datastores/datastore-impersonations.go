@@ -0,0 +1,168 @@
+package datastores
+
+import (
+	"database/sql"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//  GetImpersonations() (model.Impersonations, error)
+/*	This method is used to get all the impersonations, the most recent first.
+ */
+func (db *ConcreteDatastore) GetImpersonations() (model.Impersonations, error) {
+	var (
+		err            error
+		impersonations model.Impersonations
+	)
+
+	// Setting up and executing the request
+	request := `SELECT * FROM Impersonation ORDER BY started_at DESC, impersonation_id DESC`
+	if err = db.Select(&impersonations, request); err != nil {
+		return nil, err
+	}
+
+	return impersonations, nil
+}
+
+//  GetImpersonation(ImpersonationId int64) (model.Impersonation, error)
+/*	This method is used to get an impersonation.
+ */
+func (db *ConcreteDatastore) GetImpersonation(ImpersonationId int64) (model.Impersonation, error) {
+	var (
+		err           error
+		impersonation model.Impersonation
+	)
+
+	// Setting up and executing the request
+	request := `SELECT * FROM Impersonation WHERE impersonation_id=?`
+	if err = db.Get(&impersonation, request, ImpersonationId); err != nil {
+		return model.Impersonation{}, err
+	}
+
+	return impersonation, nil
+}
+
+//  CreateImpersonation(Impersonation model.Impersonation) (int64, error)
+/*	This method is used to record the start of an impersonation.
+ */
+func (db *ConcreteDatastore) CreateImpersonation(Impersonation model.Impersonation) (int64, error) {
+	var (
+		tx  *sql.Tx
+		err error
+		res sql.Result
+		id  int64
+	)
+
+	// Starting
+	if tx, err = db.Begin(); err != nil {
+		return -1, err
+	}
+
+	// Executing the request
+	request := `INSERT INTO Impersonation(impersonator_id, user_id, reason, started_at, expires_at, ended_at)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	if res, err = tx.Exec(request, Impersonation.ImpersonatorId, Impersonation.UserId, Impersonation.Reason, Impersonation.StartedAt, Impersonation.ExpiresAt, Impersonation.EndedAt); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Getting the id of the new impersonation
+	if id, err = res.LastInsertId(); err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+//  EndImpersonation(ImpersonationId int64, EndedAt time.Time) error
+/*	This method is used to record the end of an impersonation. Its token is refused after that.
+ */
+func (db *ConcreteDatastore) EndImpersonation(ImpersonationId int64, EndedAt time.Time) error {
+	request := `UPDATE Impersonation SET ended_at=? WHERE impersonation_id=? AND ended_at IS NULL`
+	if _, err := db.Exec(request, EndedAt, ImpersonationId); err != nil {
+		return err
+	}
+	return nil
+}
+
+//  GetActionsOfImpersonation(ImpersonationId int64) (model.ImpersonationActions, error)
+/*	This method is used to get the requests that modified something during an impersonation.
+ */
+func (db *ConcreteDatastore) GetActionsOfImpersonation(ImpersonationId int64) (model.ImpersonationActions, error) {
+	var (
+		err     error
+		actions model.ImpersonationActions
+	)
+
+	// Setting up and executing the request
+	request := `SELECT * FROM ImpersonationAction WHERE impersonation_id=? ORDER BY impersonation_action_id`
+	if err = db.Select(&actions, request, ImpersonationId); err != nil {
+		return nil, err
+	}
+
+	return actions, nil
+}
+
+//  CreateImpersonationAction(Action model.ImpersonationAction) (int64, error)
+/*	This method is used to record a request made during an impersonation, before it is handled.
+ */
+func (db *ConcreteDatastore) CreateImpersonationAction(Action model.ImpersonationAction) (int64, error) {
+	var (
+		tx  *sql.Tx
+		err error
+		res sql.Result
+		id  int64
+	)
+
+	// Starting
+	if tx, err = db.Begin(); err != nil {
+		return -1, err
+	}
+
+	// Executing the request
+	request := `INSERT INTO ImpersonationAction(impersonation_id, impersonator_id, user_id, method, path, status_code, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if res, err = tx.Exec(request, Action.ImpersonationId, Action.ImpersonatorId, Action.UserId, Action.Method, Action.Path, Action.StatusCode, Action.CreatedAt); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Getting the id of the new action
+	if id, err = res.LastInsertId(); err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+//  UpdateImpersonationActionStatus(ImpersonationActionId int64, StatusCode int64) error
+/*	This method is used to save the answer of a request made during an impersonation, once it is handled.
+ */
+func (db *ConcreteDatastore) UpdateImpersonationActionStatus(ImpersonationActionId int64, StatusCode int64) error {
+	request := `UPDATE ImpersonationAction SET status_code=? WHERE impersonation_action_id=?`
+	if _, err := db.Exec(request, StatusCode, ImpersonationActionId); err != nil {
+		return err
+	}
+	return nil
+}
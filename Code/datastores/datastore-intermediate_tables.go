@@ -3,6 +3,7 @@ package datastores
 import (
 	"database/sql"
 
+	"github.com/jmoiron/sqlx"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
 )
 
@@ -78,21 +79,37 @@ func (db *ConcreteDatastore) CreateCompanyUser(CU model.CompanyUser) error {
 
 //  CreateUserSchedule(US model.UserSchedule) error
 /*  Creates a link between a User and a Schedule.
-    Can return an error
+    Returns an OverlapError if the schedule covers the same hours as another schedule of the user,
+    and a LockedError if it starts before the lock date, or is in an approved timesheet period of the user.
 */
 func (db *ConcreteDatastore) CreateUserSchedule(US model.UserSchedule) error {
 	var (
-		tx  *sql.Tx
-		err error
+		tx       *sqlx.Tx
+		err      error
+		schedule model.Schedule
 	)
 
 	// Preparing the request
-	if tx, err = db.Begin(); err != nil {
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	// The schedule must not overlap the other ones of the user, nor be in his approved periods
+	request := `SELECT schedule_id, project_id, start_date, end_date FROM Schedule WHERE schedule_id=?`
+	if err = tx.Get(&schedule, request, US.ScheduleId); err == nil {
+		if err = checkOverlaps(tx, []int64{US.UserId}, schedule); err == nil {
+			err = checkLocked(tx, []int64{US.UserId}, schedule)
+		}
+	}
+	if err != nil && err != sql.ErrNoRows {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
 		return err
 	}
 
 	// Setting up the request and executing it
-	request := `INSERT INTO UserSchedule(user_id, schedule_id) VALUES (?, ?)`
+	request = `INSERT INTO UserSchedule(user_id, schedule_id) VALUES (?, ?)`
 	if _, err = tx.Exec(request, US.UserId, US.ScheduleId); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return errr
@@ -178,16 +195,47 @@ func (db *ConcreteDatastore) DeleteCompanyUser(CU model.CompanyUser) error {
 
 //  DeleteUserSchedule(US model.UserSchedule) error
 /*  Deletes a link between a User and a Schedule
-    Can return an error
+    Returns a LockedError if the schedule starts before the lock date, or is in an approved timesheet period of the user.
 */
 func (db *ConcreteDatastore) DeleteUserSchedule(US model.UserSchedule) error {
-	// Setting up and executing a request
-	request := `DELETE FROM UserSchedule 
-	WHERE user_id=?
-	AND schedule_id=?`
-	if _, err := db.Exec(request, US.UserId, US.ScheduleId); err != nil {
+	var (
+		tx       *sqlx.Tx
+		err      error
+		schedule model.Schedule
+	)
+
+	// Preparing the request
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	// The schedule must not be in an approved period of the user
+	request := `SELECT schedule_id, project_id, start_date, end_date FROM Schedule WHERE schedule_id=?`
+	if err = tx.Get(&schedule, request, US.ScheduleId); err == nil {
+		err = checkLocked(tx, []int64{US.UserId}, schedule)
+	}
+	if err == nil || err == sql.ErrNoRows {
+		// Setting up and executing a request
+		request = `DELETE FROM UserSchedule 
+		WHERE user_id=?
+		AND schedule_id=?`
+		_, err = tx.Exec(request, US.UserId, US.ScheduleId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
 		return err
 	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
 	return nil
 }
 
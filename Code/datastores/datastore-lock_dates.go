@@ -0,0 +1,127 @@
+package datastores
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// ErrLockDate : Returned by Lock when the new lock date is not after the current one, and by Unlock when it is not before.
+var ErrLockDate = errors.New("the lock date can't move this way")
+
+//  GetLockDate() (model.LockDate, error)
+/*	This method is used to get the current lock date of the company : the last change of it.
+	Returns sql.ErrNoRows if the hours were never locked.
+*/
+func (db *ConcreteDatastore) GetLockDate() (model.LockDate, error) {
+	return currentLockDate(db)
+}
+
+//  GetLockDates() (model.LockDates, error)
+/*	This method is used to get the history of the lock date, the last change first.
+ */
+func (db *ConcreteDatastore) GetLockDates() (model.LockDates, error) {
+	locks := model.LockDates{}
+
+	request := `SELECT lock_id, lock_date, author_id, reason, created_at FROM LockDate ORDER BY lock_id DESC`
+	if err := db.Select(&locks, request); err != nil {
+		return nil, err
+	}
+
+	return locks, nil
+}
+
+//  Lock(Lock model.LockDate) (model.LockDate, error)
+/*	This method is used to move the lock date of the company forward, once the payroll of a month is closed.
+	Returns ErrLockDate if the new lock date is not after the current one.
+*/
+func (db *ConcreteDatastore) Lock(Lock model.LockDate) (model.LockDate, error) {
+	return db.changeLockDate(Lock, true)
+}
+
+//  Unlock(Lock model.LockDate) (model.LockDate, error)
+/*	This method is used to move the lock date of the company back, or to remove it with an empty date,
+	so the hours before the current one can change again. The change is kept in the history, with its reason.
+	Returns ErrLockDate if nothing is locked, or if the new lock date is not before the current one.
+*/
+func (db *ConcreteDatastore) Unlock(Lock model.LockDate) (model.LockDate, error) {
+	return db.changeLockDate(Lock, false)
+}
+
+//  changeLockDate(Lock model.LockDate, Later bool) (model.LockDate, error)
+/*	Saves a new lock date, which must be after the current one to lock, or before it to unlock.
+ */
+func (db *ConcreteDatastore) changeLockDate(Lock model.LockDate, Later bool) (model.LockDate, error) {
+	var (
+		tx      *sqlx.Tx
+		err     error
+		res     sql.Result
+		current model.LockDate
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return model.LockDate{}, err
+	}
+
+	if current, err = currentLockDate(tx); err != nil && err != sql.ErrNoRows {
+		if errr := tx.Rollback(); errr != nil {
+			return model.LockDate{}, errr
+		}
+		return model.LockDate{}, err
+	}
+
+	// A missing lock date is before every other one
+	if Lock.LockDate.Valid {
+		Lock.LockDate.Time = Lock.LockDate.Time.UTC()
+	}
+	allowed := Lock.LockDate.Valid && (!current.LockDate.Valid || Lock.LockDate.Time.After(current.LockDate.Time))
+	if !Later {
+		allowed = current.LockDate.Valid && (!Lock.LockDate.Valid || Lock.LockDate.Time.Before(current.LockDate.Time))
+	}
+	if !allowed {
+		if errr := tx.Rollback(); errr != nil {
+			return model.LockDate{}, errr
+		}
+		return current, ErrLockDate
+	}
+
+	Lock.CreatedAt = time.Now().UTC()
+	request := `INSERT INTO LockDate(lock_date, author_id, reason, created_at) VALUES (?, ?, ?, ?)`
+	if res, err = tx.Exec(request, Lock.LockDate, Lock.AuthorId, Lock.Reason, Lock.CreatedAt); err == nil {
+		Lock.LockId, err = res.LastInsertId()
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.LockDate{}, errr
+		}
+		return model.LockDate{}, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.LockDate{}, errr
+		}
+		return model.LockDate{}, err
+	}
+
+	return Lock, nil
+}
+
+//  currentLockDate(q sqlx.Queryer) (model.LockDate, error)
+/*	Returns the current lock date of the company : the last change of it. Returns sql.ErrNoRows if the hours were never locked.
+ */
+func currentLockDate(q sqlx.Queryer) (model.LockDate, error) {
+	var lock model.LockDate
+
+	request := `SELECT lock_id, lock_date, author_id, reason, created_at FROM LockDate ORDER BY lock_id DESC LIMIT 1`
+	if err := sqlx.Get(q, &lock, request); err != nil {
+		return model.LockDate{}, err
+	}
+
+	return lock, nil
+}
@@ -0,0 +1,132 @@
+package datastores
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// LockedError : Returned when a schedule, or a comment on it, is in a period that can't change anymore.
+/*	Period : The approved timesheet period of a user holding the schedule.
+	LockDate : The lock date of the company, when the schedule starts before it. Zero otherwise.
+*/
+type LockedError struct {
+	Period   model.TimesheetPeriod
+	LockDate time.Time
+}
+
+func (e *LockedError) Error() string {
+	if !e.LockDate.IsZero() {
+		return "the schedule starts before the lock date " + e.LockDate.UTC().Format(time.RFC3339)
+	}
+	return "the schedule is in the approved timesheet " + e.Period.Period + " of the user " + strconv.FormatInt(e.Period.UserId, 10)
+}
+
+//  checkLocked(q sqlx.Queryer, UserIds []int64, Schedules ...model.Schedule) error
+/*	Returns a LockedError if one of the schedules starts before the lock date of the company,
+	or is in an approved timesheet period of one of the users.
+*/
+func checkLocked(q sqlx.Queryer, UserIds []int64, Schedules ...model.Schedule) error {
+	now := time.Now()
+
+	lock, err := currentLockDate(q)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	for _, schedule := range Schedules {
+		if lock.LockDate.Valid && schedule.StartDate.Time.Before(lock.LockDate.Time) {
+			return &LockedError{LockDate: lock.LockDate.Time}
+		}
+	}
+
+	for _, userId := range UserIds {
+		periods := model.TimesheetPeriods{}
+		request := `SELECT period_id, user_id, period, start_date, end_date, state
+		FROM TimesheetPeriod
+		WHERE user_id=?
+		AND state=?`
+		if err := sqlx.Select(q, &periods, request, userId, model.TimesheetApproved); err != nil {
+			return err
+		}
+
+		for _, period := range periods {
+			for _, schedule := range Schedules {
+				if inPeriod(schedule, period, now) {
+					return &LockedError{Period: period}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+//  checkScheduleLocked(q sqlx.Queryer, ScheduleId int64, Changes ...model.Schedule) error
+/*	Returns a LockedError if a saved schedule, or the new versions of it, is in an approved timesheet period
+	of one of its users. An unknown schedule is not locked.
+*/
+func checkScheduleLocked(q sqlx.Queryer, ScheduleId int64, Changes ...model.Schedule) error {
+	var (
+		err      error
+		schedule model.Schedule
+		userIds  []int64
+	)
+
+	request := `SELECT schedule_id, project_id, start_date, end_date FROM Schedule WHERE schedule_id=?`
+	if err = sqlx.Get(q, &schedule, request, ScheduleId); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if userIds, err = usersOfSchedule(q, ScheduleId); err != nil {
+		return err
+	}
+
+	return checkLocked(q, userIds, append(Changes, schedule)...)
+}
+
+//  checkCommentLocked(q sqlx.Queryer, CommentId int64) error
+/*	Returns a LockedError if the schedule of a saved comment is in an approved timesheet period.
+	An unknown comment is not locked.
+*/
+func checkCommentLocked(q sqlx.Queryer, CommentId int64) error {
+	var scheduleId int64
+
+	if err := sqlx.Get(q, &scheduleId, `SELECT schedule_id FROM Comment WHERE comment_id=?`, CommentId); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return checkScheduleLocked(q, scheduleId)
+}
+
+//  checkBreakLocked(q sqlx.Queryer, BreakId int64) error
+/*	Returns a LockedError if the schedule of a saved break is locked. An unknown break is not locked.
+ */
+func checkBreakLocked(q sqlx.Queryer, BreakId int64) error {
+	var scheduleId int64
+
+	if err := sqlx.Get(q, &scheduleId, `SELECT schedule_id FROM ScheduleBreak WHERE break_id=?`, BreakId); err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return checkScheduleLocked(q, scheduleId)
+}
+
+//  inPeriod(Schedule model.Schedule, Period model.TimesheetPeriod, Now time.Time) bool
+/*	Tells wether a schedule covers some hours of a period, or starts in it. A running timer lasts until now.
+ */
+func inPeriod(Schedule model.Schedule, Period model.TimesheetPeriod, Now time.Time) bool {
+	start, end := Schedule.StartDate.Time, scheduleEnd(Schedule, Now)
+	if !start.Before(Period.EndDate) {
+		return false
+	}
+	return end.After(Period.StartDate) || !start.Before(Period.StartDate)
+}
@@ -0,0 +1,74 @@
+package datastores
+
+import (
+	"database/sql"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//  GetLoginAttempt(AttemptKey string) (model.LoginAttempt, error)
+/*	This method is used to get the failed login attempts counted for a key.
+	Returns sql.ErrNoRows if nothing was counted for this key.
+*/
+func (db *ConcreteDatastore) GetLoginAttempt(AttemptKey string) (model.LoginAttempt, error) {
+	var (
+		err     error
+		attempt model.LoginAttempt
+	)
+
+	// Setting up and executing the request
+	request := `SELECT * FROM LoginAttempt WHERE attempt_key=?`
+	if err = db.Get(&attempt, request, AttemptKey); err != nil {
+		return model.LoginAttempt{}, err
+	}
+
+	return attempt, nil
+}
+
+//  SaveLoginAttempt(Attempt model.LoginAttempt) error
+/*	This method is used to create or replace the failed login attempts counted for a key.
+ */
+func (db *ConcreteDatastore) SaveLoginAttempt(Attempt model.LoginAttempt) error {
+	var (
+		tx  *sql.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Begin(); err != nil {
+		return err
+	}
+
+	// Executing the request
+	request := `INSERT OR REPLACE INTO LoginAttempt(attempt_key, failed_attempts, last_failure, locked_until)
+	VALUES (?, ?, ?, ?)`
+	if _, err = tx.Exec(request, Attempt.AttemptKey, Attempt.FailedAttempts, Attempt.LastFailure, Attempt.LockedUntil); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  DeleteLoginAttempt(AttemptKey string) error
+/*	This method is used to forget the failed login attempts counted for a key.
+	It also removes the lockout of the key.
+*/
+func (db *ConcreteDatastore) DeleteLoginAttempt(AttemptKey string) error {
+	request := `DELETE FROM LoginAttempt
+	WHERE attempt_key=?`
+	if _, err := db.Exec(request, AttemptKey); err != nil {
+		return err
+	}
+	return nil
+}
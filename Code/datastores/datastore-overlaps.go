@@ -0,0 +1,209 @@
+package datastores
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// OverlapError : Returned when a schedule would cover the same hours as other schedules of one of its users.
+/*	Schedules : The schedules it would overlap.
+ */
+type OverlapError struct {
+	Schedules model.Schedules
+}
+
+func (e *OverlapError) Error() string {
+	return "the schedule overlaps " + strconv.Itoa(len(e.Schedules)) + " other schedules"
+}
+
+// userSchedule : A schedule of a user, with the overlap policy of its project.
+type userSchedule struct {
+	model.Schedule
+	AllowOverlaps bool `db:"allow_overlaps"`
+}
+
+//  GetOverlapsOfUser(UserId int64) (model.ScheduleOverlaps, error)
+/*	This method is used to get the pairs of schedules of a user that cover the same hours,
+	leaving out the projects that allow overlaps.
+*/
+func (db *ConcreteDatastore) GetOverlapsOfUser(UserId int64) (model.ScheduleOverlaps, error) {
+	var (
+		err       error
+		schedules []userSchedule
+	)
+
+	if schedules, err = schedulesOfUser(db, UserId); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].StartDate.Time.Before(schedules[j].StartDate.Time)
+	})
+
+	now := time.Now()
+	overlaps := model.ScheduleOverlaps{}
+	for i, first := range schedules {
+		if first.AllowOverlaps {
+			continue
+		}
+		// The schedules are sorted : the ones starting after the end of the first can't overlap it
+		for _, second := range schedules[i+1:] {
+			if !second.StartDate.Time.Before(scheduleEnd(first.Schedule, now)) {
+				break
+			}
+			if !second.AllowOverlaps && overlap(first.Schedule, second.Schedule, now) {
+				overlaps = append(overlaps, model.ScheduleOverlap{UserId: UserId, First: first.Schedule, Second: second.Schedule})
+			}
+		}
+	}
+
+	return overlaps, nil
+}
+
+//  checkOverlaps(q sqlx.Queryer, UserIds []int64, Schedule model.Schedule) error
+/*	Returns an OverlapError if a schedule covers the same hours as another schedule of one of the users.
+	The schedules of the projects that allow overlaps never conflict.
+*/
+func checkOverlaps(q sqlx.Queryer, UserIds []int64, Schedule model.Schedule) error {
+	var (
+		err           error
+		allowOverlaps bool
+		schedules     []userSchedule
+	)
+
+	// An unknown project is left to the foreign key
+	if err = sqlx.Get(q, &allowOverlaps, `SELECT allow_overlaps FROM Project WHERE project_id=?`, Schedule.ProjectId); err == sql.ErrNoRows || allowOverlaps {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	conflicts := model.Schedules{}
+	seen := map[int64]bool{}
+	for _, userId := range UserIds {
+		if schedules, err = schedulesOfUser(q, userId); err != nil {
+			return err
+		}
+
+		for _, other := range schedules {
+			if other.ScheduleId == Schedule.ScheduleId || other.AllowOverlaps || seen[other.ScheduleId] {
+				continue
+			}
+			if overlap(Schedule, other.Schedule, now) {
+				seen[other.ScheduleId] = true
+				conflicts = append(conflicts, other.Schedule)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return &OverlapError{Schedules: conflicts}
+	}
+	return nil
+}
+
+//  stoppedTimer(q sqlx.Queryer, ScheduleId int64, EndDate time.Time) (model.Schedule, error)
+/*	Returns a running timer ended at a date, or at the start of the first schedule of one of its users it would
+	overlap otherwise. The schedules are only checked up to now when a timer starts, so the ones added later in its
+	hours are found when it stops. An unknown schedule, or one that already has an end, is returned empty.
+*/
+func stoppedTimer(q sqlx.Queryer, ScheduleId int64, EndDate time.Time) (model.Schedule, error) {
+	var (
+		err       error
+		timer     userSchedule
+		userIds   []int64
+		schedules []userSchedule
+	)
+
+	request := `SELECT S.schedule_id, S.project_id, S.start_date, S.end_date, P.allow_overlaps
+	FROM Schedule S, Project P
+	WHERE P.project_id = S.project_id
+	AND S.schedule_id=?
+	AND S.end_date IS NULL`
+	if err = sqlx.Get(q, &timer, request, ScheduleId); err == sql.ErrNoRows {
+		return model.Schedule{}, nil
+	} else if err != nil {
+		return model.Schedule{}, err
+	}
+
+	if !timer.AllowOverlaps {
+		if userIds, err = usersOfSchedule(q, ScheduleId); err != nil {
+			return model.Schedule{}, err
+		}
+		for _, userId := range userIds {
+			if schedules, err = schedulesOfUser(q, userId); err != nil {
+				return model.Schedule{}, err
+			}
+
+			for _, other := range schedules {
+				start := other.StartDate.Time
+				if other.ScheduleId != ScheduleId && !other.AllowOverlaps && start.After(timer.StartDate.Time) && start.Before(EndDate) {
+					EndDate = start
+				}
+			}
+		}
+	}
+
+	timer.EndDate = sql.NullTime{Valid: true, Time: EndDate}
+	return timer.Schedule, nil
+}
+
+//  usersOfSchedule(q sqlx.Queryer, ScheduleId int64) ([]int64, error)
+/*	Returns the ids of the users linked to a schedule.
+ */
+func usersOfSchedule(q sqlx.Queryer, ScheduleId int64) ([]int64, error) {
+	userIds := []int64{}
+	if err := sqlx.Select(q, &userIds, `SELECT user_id FROM UserSchedule WHERE schedule_id=?`, ScheduleId); err != nil {
+		return nil, err
+	}
+	return userIds, nil
+}
+
+//  schedulesOfUser(q sqlx.Queryer, UserId int64) ([]userSchedule, error)
+/*	Returns the schedules of a user, with the overlap policy of their project.
+ */
+func schedulesOfUser(q sqlx.Queryer, UserId int64) ([]userSchedule, error) {
+	schedules := []userSchedule{}
+
+	request := `SELECT S.schedule_id, S.project_id, S.start_date, S.end_date, P.allow_overlaps
+	FROM Schedule S, UserSchedule US, Project P
+	WHERE S.schedule_id = US.schedule_id
+	AND P.project_id = S.project_id
+	AND US.user_id=?`
+	if err := sqlx.Select(q, &schedules, request, UserId); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+//  overlap(First model.Schedule, Second model.Schedule, Now time.Time) bool
+/*	Tells wether two schedules cover the same hours. A schedule that ends when the other starts does not overlap it,
+	and a schedule that ends when it starts covers no hour.
+*/
+func overlap(First model.Schedule, Second model.Schedule, Now time.Time) bool {
+	firstEnd, secondEnd := scheduleEnd(First, Now), scheduleEnd(Second, Now)
+	if !firstEnd.After(First.StartDate.Time) || !secondEnd.After(Second.StartDate.Time) {
+		return false
+	}
+	return First.StartDate.Time.Before(secondEnd) && Second.StartDate.Time.Before(firstEnd)
+}
+
+//  scheduleEnd(Schedule model.Schedule, Now time.Time) time.Time
+/*	Returns the end of a schedule. A running timer lasts until now, and covers at least the moment it started.
+ */
+func scheduleEnd(Schedule model.Schedule, Now time.Time) time.Time {
+	if Schedule.EndDate.Valid {
+		return Schedule.EndDate.Time
+	}
+	if !Now.After(Schedule.StartDate.Time) {
+		return Schedule.StartDate.Time.Add(time.Nanosecond)
+	}
+	return Now
+}
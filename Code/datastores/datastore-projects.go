@@ -89,7 +89,7 @@ func (db *ConcreteDatastore) GetProjectsOfCompany(CompanyId int64) (model.Projec
 */
 func (db *ConcreteDatastore) GetProjectsOfUser(UserId int64) (model.Projects, error) {
 	// Setting up and executing the request
-	request := `SELECT DISTINCT Project.project_id, project.project_name
+	request := `SELECT DISTINCT Project.project_id, Project.project_name, Project.allow_overlaps
 	FROM Project, Schedule, UserSchedule
 	WHERE Project.project_id = Schedule.project_id
 	AND Schedule.schedule_id=UserSchedule.schedule_id
@@ -133,8 +133,8 @@ func (db *ConcreteDatastore) CreateProject(Project model.Project) (int64, error)
 	}
 
 	// Setting up and executing the request
-	request := `INSERT INTO Project(project_name) VALUES (?)`
-	if res, err = tx.Exec(request, Project.ProjectName); err != nil {
+	request := `INSERT INTO Project(project_name, allow_overlaps) VALUES (?, ?)`
+	if res, err = tx.Exec(request, Project.ProjectName, Project.AllowOverlaps); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return -1, errr
 		}
@@ -190,9 +190,9 @@ func (db *ConcreteDatastore) UpdateProject(Project model.Project) (model.Project
 
 	// Executing the request
 	request := `UPDATE Project 
-	SET project_name=?
+	SET project_name=?, allow_overlaps=?
 	WHERE project_id=?`
-	if _, err = tx.Exec(request, Project.ProjectName, Project.ProjectId); err != nil {
+	if _, err = tx.Exec(request, Project.ProjectName, Project.AllowOverlaps, Project.ProjectId); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return model.Project{}, errr
 		}
@@ -115,8 +115,8 @@ func (db *ConcreteDatastore) CreateRole(Role model.Role) (int64, error) {
 	}
 
 	// Setting up and executing the request
-	request := `INSERT INTO Role(role_name, can_add_and_modify_users, can_see_other_schedules, can_add_projects, can_see_reports) VALUES (?, ?, ?, ?, ?)`
-	if res, err = tx.Exec(request, Role.RoleName, Role.CanAddAndModifyUsers, Role.CanSeeOtherSchedules, Role.CanAddProjects, Role.CanSeeReports); err != nil {
+	request := `INSERT INTO Role(role_name, can_add_and_modify_users, can_see_other_schedules, can_add_projects, can_see_reports, require_two_factor) VALUES (?, ?, ?, ?, ?, ?)`
+	if res, err = tx.Exec(request, Role.RoleName, Role.CanAddAndModifyUsers, Role.CanSeeOtherSchedules, Role.CanAddProjects, Role.CanSeeReports, Role.RequireTwoFactor); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return -1, errr
 		}
@@ -170,9 +170,9 @@ func (db *ConcreteDatastore) UpdateRole(Role model.Role) (model.Role, error) {
 
 	// Executing the request
 	request := `UPDATE Role 
-	SET role_name=?, can_add_and_modify_users=?, can_see_other_schedules=?, can_add_projects=?, can_see_reports=?
+	SET role_name=?, can_add_and_modify_users=?, can_see_other_schedules=?, can_add_projects=?, can_see_reports=?, require_two_factor=?
 	WHERE role_id=?`
-	if _, err = tx.Exec(request, Role.RoleName, Role.CanAddAndModifyUsers, Role.CanSeeOtherSchedules, Role.CanAddProjects, Role.CanSeeReports, Role.RoleId); err != nil {
+	if _, err = tx.Exec(request, Role.RoleName, Role.CanAddAndModifyUsers, Role.CanSeeOtherSchedules, Role.CanAddProjects, Role.CanSeeReports, Role.RequireTwoFactor, Role.RoleId); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return model.Role{}, errr
 		}
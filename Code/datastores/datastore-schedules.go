@@ -2,11 +2,16 @@ package datastores
 
 import (
 	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
 )
 
+// ErrTimerRunning : Returned by StartTimer when the user already has a running timer.
+var ErrTimerRunning = errors.New("a timer is already running")
+
 //  GetSchedule(ScheduleId) (model.Schedule, error)
 /*	This method is used to get a specific schedule from the database.
  */
@@ -16,7 +21,7 @@ func (db *ConcreteDatastore) GetSchedule(ScheduleId int64) (model.Schedule, erro
 		schedule model.Schedule
 	)
 
-	request := `SELECT schedule_id, project_id, start_date, end_date 
+	request := `SELECT schedule_id, project_id, start_date, end_date, series_id
 	FROM Schedule 
 	WHERE Schedule.schedule_id=?`
 	if err = db.Get(&schedule, request, ScheduleId); err != nil {
@@ -89,25 +94,38 @@ func (db *ConcreteDatastore) GetSchedulesOfProject(ProjectId int64) (model.Sched
 	return vacationList, nil
 }
 
-//  CreateSchedule(Schedule model.Schedule) (int64, error)
-/*	This method is used to create a new schedule.
- */
-func (db *ConcreteDatastore) CreateSchedule(Schedule model.Schedule) (int64, error) {
+//  CreateSchedule(Schedule model.Schedule, UserIds ...int64) (int64, error)
+/*	This method is used to create a new schedule, and link it to some users.
+	Returns an OverlapError if it covers the same hours as another schedule of one of the users,
+	and a LockedError if it starts before the lock date, or is in an approved timesheet period of one of them.
+*/
+func (db *ConcreteDatastore) CreateSchedule(Schedule model.Schedule, UserIds ...int64) (int64, error) {
 	var (
-		tx         *sql.Tx
+		tx         *sqlx.Tx
 		err        error
 		res        sql.Result
 		scheduleId int64
 	)
 
 	// Starting
-	if tx, err = db.Begin(); err != nil {
+	if tx, err = db.Beginx(); err != nil {
 		return -1, err
 	}
 
-	// Executing the request
+	// The schedule must not overlap the ones of its users, nor be in their approved periods
+	if err = checkOverlaps(tx, UserIds, Schedule); err == nil {
+		err = checkLocked(tx, UserIds, Schedule)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Executing the request. The dates are stored in UTC, so they can be compared whatever the zone of the client
 	request := `INSERT INTO Schedule(project_id, start_date, end_date) VALUES (?, ?, ?)`
-	if res, err = tx.Exec(request, Schedule.ProjectId, Schedule.StartDate.Time, Schedule.EndDate.Time); err != nil {
+	if res, err = tx.Exec(request, Schedule.ProjectId, Schedule.StartDate.Time.UTC(), utcEndDate(Schedule)); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return -1, errr
 		}
@@ -122,6 +140,14 @@ func (db *ConcreteDatastore) CreateSchedule(Schedule model.Schedule) (int64, err
 		return -1, err
 	}
 
+	// Linking it to its users
+	if err = linkUsers(tx, UserIds, scheduleId); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
 	// Saving
 	if err = tx.Commit(); err != nil {
 		if errr := tx.Rollback(); errr != nil {
@@ -133,37 +159,153 @@ func (db *ConcreteDatastore) CreateSchedule(Schedule model.Schedule) (int64, err
 	return scheduleId, nil
 }
 
+//  CheckSchedule(Schedule model.Schedule, UserIds ...int64) error
+/*	This method is used to know wether a new schedule could be created, without creating it.
+	Returns an OverlapError or a LockedError as CreateSchedule.
+*/
+func (db *ConcreteDatastore) CheckSchedule(Schedule model.Schedule, UserIds ...int64) error {
+	if err := checkOverlaps(db, UserIds, Schedule); err != nil {
+		return err
+	}
+	return checkLocked(db, UserIds, Schedule)
+}
+
+//  CreateSchedules(Schedules model.Schedules, UserIds ...int64) ([]int64, error)
+/*	This method is used to create several schedules at once, all linked to the same users. Either all of them are saved, or none.
+	Returns an OverlapError or a LockedError as CreateSchedule, also when two of them overlap.
+*/
+func (db *ConcreteDatastore) CreateSchedules(Schedules model.Schedules, UserIds ...int64) ([]int64, error) {
+	var (
+		tx          *sqlx.Tx
+		err         error
+		res         sql.Result
+		scheduleId  int64
+		scheduleIds []int64
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return nil, err
+	}
+
+	// Each schedule is checked against the ones saved before it
+	request := `INSERT INTO Schedule(project_id, start_date, end_date) VALUES (?, ?, ?)`
+	for _, schedule := range Schedules {
+		if err = checkOverlaps(tx, UserIds, schedule); err == nil {
+			err = checkLocked(tx, UserIds, schedule)
+		}
+		if err == nil {
+			res, err = tx.Exec(request, schedule.ProjectId, schedule.StartDate.Time.UTC(), utcEndDate(schedule))
+		}
+		if err == nil {
+			scheduleId, err = res.LastInsertId()
+		}
+		if err == nil {
+			err = linkUsers(tx, UserIds, scheduleId)
+		}
+		if err != nil {
+			if errr := tx.Rollback(); errr != nil {
+				return nil, errr
+			}
+			return nil, err
+		}
+		scheduleIds = append(scheduleIds, scheduleId)
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return nil, errr
+		}
+		return nil, err
+	}
+
+	return scheduleIds, nil
+}
+
 //  DeleteSchedule(ScheduleId int64) error
-/*	This method is used to delete a schedule
- */
+/*	This method is used to delete a schedule, with its breaks
+	Returns a LockedError if it starts before the lock date, or is in an approved timesheet period of one of its users.
+*/
 func (db *ConcreteDatastore) DeleteSchedule(ScheduleId int64) error {
-	request := `DELETE FROM Schedule 
-	WHERE schedule_id=?`
-	if _, err := db.Exec(request, ScheduleId); err != nil {
+	var (
+		tx  *sqlx.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
 		return err
 	}
+
+	// The schedule must not be in an approved period
+	if err = checkScheduleLocked(tx, ScheduleId); err == nil {
+		_, err = tx.Exec(`DELETE FROM ScheduleBreak WHERE schedule_id=?`, ScheduleId)
+	}
+	if err == nil {
+		request := `DELETE FROM Schedule 
+		WHERE schedule_id=?`
+		_, err = tx.Exec(request, ScheduleId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
 	return nil
 }
 
 //  UpdateSchedule(Schedule model.Schedule) (model.Schedule, error)
-/*	This method is used to update an existing schedule
- */
+/*	This method is used to update an existing schedule. Its breaks out of its new hours are deleted.
+	Returns an OverlapError if it would cover the same hours as another schedule of one of its users,
+	and a LockedError if it starts, or would start, before the lock date, or is, or would be, in an approved timesheet period of one of them.
+*/
 func (db *ConcreteDatastore) UpdateSchedule(Schedule model.Schedule) (model.Schedule, error) {
 	var (
-		tx  *sql.Tx
-		err error
+		tx      *sqlx.Tx
+		err     error
+		userIds []int64
 	)
 
 	// Starting
-	if tx, err = db.Begin(); err != nil {
+	if tx, err = db.Beginx(); err != nil {
 		return model.Schedule{}, err
 	}
 
-	// Executing the request
+	// The schedule must not overlap the other ones of its users, nor be in their approved periods
+	if userIds, err = usersOfSchedule(tx, Schedule.ScheduleId); err == nil {
+		err = checkOverlaps(tx, userIds, Schedule)
+	}
+	if err == nil {
+		err = checkScheduleLocked(tx, Schedule.ScheduleId, Schedule)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.Schedule{}, errr
+		}
+		return model.Schedule{}, err
+	}
+
+	// Executing the request. The dates are stored in UTC, so they can be compared whatever the zone of the client
 	request := `UPDATE Schedule
 	SET project_id=?, start_date=?, end_date=?
 	WHERE schedule_id=?`
-	if _, err = tx.Exec(request, Schedule.ProjectId, Schedule.StartDate, Schedule.EndDate, Schedule.ScheduleId); err != nil {
+	if _, err = tx.Exec(request, Schedule.ProjectId, Schedule.StartDate.Time.UTC(), utcEndDate(Schedule), Schedule.ScheduleId); err == nil && Schedule.EndDate.Valid {
+		// The breaks out of the new hours are dropped
+		request = `DELETE FROM ScheduleBreak WHERE schedule_id=? AND (start_date < ? OR end_date > ?)`
+		_, err = tx.Exec(request, Schedule.ScheduleId, Schedule.StartDate.Time.UTC(), Schedule.EndDate.Time.UTC())
+	}
+	if err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return model.Schedule{}, errr
 		}
@@ -180,3 +322,215 @@ func (db *ConcreteDatastore) UpdateSchedule(Schedule model.Schedule) (model.Sche
 
 	return Schedule, nil
 }
+
+//  GetRunningScheduleOfUser(UserId int64) (model.Schedule, error)
+/*	This method is used to get the running timer of a user : his schedule that has no end yet.
+	Returns sql.ErrNoRows if no timer is running.
+*/
+func (db *ConcreteDatastore) GetRunningScheduleOfUser(UserId int64) (model.Schedule, error) {
+	var (
+		err      error
+		schedule model.Schedule
+	)
+
+	request := `SELECT S.schedule_id, S.project_id, S.start_date, S.end_date
+	FROM Schedule S, UserSchedule US
+	WHERE S.schedule_id = US.schedule_id
+	AND US.user_id=?
+	AND S.end_date IS NULL`
+	if err = db.Get(&schedule, request, UserId); err != nil {
+		return model.Schedule{}, err
+	}
+
+	return schedule, nil
+}
+
+//  GetRunningUsersSchedules() (model.UsersSchedules, error)
+/*	This method is used to get the running timers of every user, as links between the users and their schedule.
+ */
+func (db *ConcreteDatastore) GetRunningUsersSchedules() (model.UsersSchedules, error) {
+	var (
+		rows *sqlx.Rows
+		err  error
+	)
+
+	request := `SELECT US.user_id, US.schedule_id
+	FROM Schedule S, UserSchedule US
+	WHERE S.schedule_id = US.schedule_id
+	AND S.end_date IS NULL`
+	if rows, err = db.Queryx(request); err != nil {
+		return nil, err
+	}
+
+	// Formatting
+	links := model.UsersSchedules{}
+	for rows.Next() {
+		link := model.UserSchedule{}
+		if err = rows.StructScan(&link); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+//  StartTimer(UserId int64, Schedule model.Schedule) (int64, error)
+/*	This method is used to create a schedule without end for a user, and link it to him.
+	Returns ErrTimerRunning if the user already has a running timer : a user has only one.
+	Returns an OverlapError if it starts during another schedule of the user,
+	and a LockedError if it starts in an approved timesheet period of the user.
+*/
+func (db *ConcreteDatastore) StartTimer(UserId int64, Schedule model.Schedule) (int64, error) {
+	var (
+		tx         *sqlx.Tx
+		err        error
+		res        sql.Result
+		created    int64
+		scheduleId int64
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return -1, err
+	}
+
+	// The timer must not start during another schedule of the user. A running timer is reported first
+	running := model.Schedule{}
+	request := `SELECT S.schedule_id, S.project_id, S.start_date, S.end_date
+	FROM Schedule S, UserSchedule US
+	WHERE S.schedule_id = US.schedule_id
+	AND US.user_id=?
+	AND S.end_date IS NULL`
+	if err = tx.Get(&running, request, UserId); err == nil {
+		err = ErrTimerRunning
+	} else if err == sql.ErrNoRows {
+		if err = checkOverlaps(tx, []int64{UserId}, Schedule); err == nil {
+			err = checkLocked(tx, []int64{UserId}, Schedule)
+		}
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// The schedule is only created if the user has no running timer, in the same request so two timers can't be started
+	request = `INSERT INTO Schedule(project_id, start_date, end_date)
+	SELECT ?, ?, NULL
+	WHERE NOT EXISTS (
+		SELECT 1 FROM Schedule S, UserSchedule US
+		WHERE S.schedule_id = US.schedule_id
+		AND US.user_id=?
+		AND S.end_date IS NULL
+	)`
+	if res, err = tx.Exec(request, Schedule.ProjectId, Schedule.StartDate.Time.UTC(), UserId); err == nil {
+		if created, err = res.RowsAffected(); err == nil && created == 0 {
+			err = ErrTimerRunning
+		}
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Getting the id of the new item
+	if scheduleId, err = res.LastInsertId(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Linking it to the user
+	if err = linkUsers(tx, []int64{UserId}, scheduleId); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	return scheduleId, nil
+}
+
+//  StopSchedule(ScheduleId int64, EndDate time.Time) error
+/*	This method is used to end a running timer. A schedule that already has an end is not changed.
+	The timer ends before the schedules of its users it would overlap, added while it was running.
+	Returns a LockedError if it starts before the lock date, or would end in an approved timesheet period of one of them.
+*/
+func (db *ConcreteDatastore) StopSchedule(ScheduleId int64, EndDate time.Time) error {
+	var (
+		tx      *sqlx.Tx
+		err     error
+		timer   model.Schedule
+		userIds []int64
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	// The hours of the stopped timer must not be locked
+	if timer, err = stoppedTimer(tx, ScheduleId, EndDate); err == nil && timer.ScheduleId != 0 {
+		if userIds, err = usersOfSchedule(tx, ScheduleId); err == nil {
+			err = checkLocked(tx, userIds, timer)
+		}
+	}
+	if err == nil && timer.ScheduleId != 0 {
+		request := `UPDATE Schedule
+		SET end_date=?
+		WHERE schedule_id=?`
+		_, err = tx.Exec(request, timer.EndDate.Time.UTC(), ScheduleId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  linkUsers(tx *sqlx.Tx, UserIds []int64, ScheduleId int64) error
+/*	Links a schedule to some users.
+ */
+func linkUsers(tx *sqlx.Tx, UserIds []int64, ScheduleId int64) error {
+	request := `INSERT INTO UserSchedule(user_id, schedule_id) VALUES (?, ?)`
+	for _, userId := range UserIds {
+		if _, err := tx.Exec(request, userId, ScheduleId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//  utcEndDate(Schedule model.Schedule) sql.NullTime
+/*	Returns the end of a schedule in UTC, or NULL for a running timer.
+ */
+func utcEndDate(Schedule model.Schedule) sql.NullTime {
+	if !Schedule.EndDate.Valid {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Valid: true, Time: Schedule.EndDate.Time.UTC()}
+}
@@ -0,0 +1,462 @@
+package datastores
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//  GetSeries(SeriesId int64) (model.ScheduleSeries, error)
+/*	This method is used to get a recurring series of schedules.
+ */
+func (db *ConcreteDatastore) GetSeries(SeriesId int64) (model.ScheduleSeries, error) {
+	var series model.ScheduleSeries
+
+	request := `SELECT * FROM ScheduleSeries WHERE series_id=?`
+	if err := db.Get(&series, request, SeriesId); err != nil {
+		return model.ScheduleSeries{}, err
+	}
+
+	return series, nil
+}
+
+//  GetSeriesOfUser(UserId int64) (model.ScheduleSeriesList, error)
+/*	This method is used to get the recurring series of a user, the first one to start first.
+ */
+func (db *ConcreteDatastore) GetSeriesOfUser(UserId int64) (model.ScheduleSeriesList, error) {
+	series := model.ScheduleSeriesList{}
+
+	request := `SELECT * FROM ScheduleSeries WHERE user_id=? ORDER BY start_date, series_id`
+	if err := db.Select(&series, request, UserId); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+//  GetSeriesToExtend(Until time.Time) (model.ScheduleSeriesList, error)
+/*	This method is used to get the series whose occurrences are not saved until a date yet.
+ */
+func (db *ConcreteDatastore) GetSeriesToExtend(Until time.Time) (model.ScheduleSeriesList, error) {
+	series := model.ScheduleSeriesList{}
+
+	request := `SELECT * FROM ScheduleSeries WHERE generated_until<? ORDER BY series_id`
+	if err := db.Select(&series, request, Until.UTC()); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+//  GetSchedulesOfSeries(SeriesId int64) (model.Schedules, error)
+/*	This method is used to get the saved occurrences of a series, the first one first.
+ */
+func (db *ConcreteDatastore) GetSchedulesOfSeries(SeriesId int64) (model.Schedules, error) {
+	schedules := model.Schedules{}
+
+	request := `SELECT schedule_id, project_id, start_date, end_date, series_id
+	FROM Schedule
+	WHERE series_id=?
+	ORDER BY start_date, schedule_id`
+	if err := db.Select(&schedules, request, SeriesId); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+//  CreateSeries(Series model.ScheduleSeries, Occurrences model.Schedules) (int64, error)
+/*	This method is used to create a recurring series, and save its first occurrences as schedules of its user.
+	Returns an OverlapError if an occurrence covers the same hours as another schedule of the user,
+	and a LockedError if one starts before the lock date, or is in an approved timesheet period of the user.
+*/
+func (db *ConcreteDatastore) CreateSeries(Series model.ScheduleSeries, Occurrences model.Schedules) (int64, error) {
+	var (
+		tx  *sqlx.Tx
+		err error
+		res sql.Result
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return -1, err
+	}
+
+	request := `INSERT INTO ScheduleSeries(project_id, user_id, start_date, end_date, time_zone, recurrence_rule, exception_dates, generated_until)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	if res, err = tx.Exec(request, Series.ProjectId, Series.UserId, Series.StartDate.UTC(), Series.EndDate.UTC(), Series.TimeZone, Series.RecurrenceRule, Series.ExceptionDates, Series.GeneratedUntil.UTC()); err == nil {
+		if Series.SeriesId, err = res.LastInsertId(); err == nil {
+			_, err = insertOccurrences(tx, Series, Occurrences, true)
+		}
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	return Series.SeriesId, nil
+}
+
+//  UpdateSeries(Series model.ScheduleSeries, From time.Time, Occurrences model.Schedules) error
+/*	This method is used to change a whole series that has no occurrence before a date : every occurrence from this date
+	is deleted, even the ones changed alone, and the new ones are saved instead.
+	Returns an OverlapError or a LockedError as CreateSeries, also when an old occurrence is locked.
+*/
+func (db *ConcreteDatastore) UpdateSeries(Series model.ScheduleSeries, From time.Time, Occurrences model.Schedules) error {
+	var (
+		tx  *sqlx.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	if err = deleteOccurrences(tx, Series.SeriesId, From); err == nil {
+		if err = saveSeries(tx, Series); err == nil {
+			_, err = insertOccurrences(tx, Series, Occurrences, true)
+		}
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  SplitSeries(Series model.ScheduleSeries, From time.Time, Following model.ScheduleSeries, Occurrences model.Schedules) (int64, error)
+/*	This method is used to change an occurrence and the following ones : the series is saved with a rule that ends
+	before them, its occurrences starting from a date are deleted, and a new series is created for the following ones.
+	Returns the id of the new series, and an OverlapError or a LockedError as UpdateSeries.
+*/
+func (db *ConcreteDatastore) SplitSeries(Series model.ScheduleSeries, From time.Time, Following model.ScheduleSeries, Occurrences model.Schedules) (int64, error) {
+	var (
+		tx  *sqlx.Tx
+		err error
+		res sql.Result
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return -1, err
+	}
+
+	if err = deleteOccurrences(tx, Series.SeriesId, From); err == nil {
+		err = saveSeries(tx, Series)
+	}
+	if err == nil {
+		request := `INSERT INTO ScheduleSeries(project_id, user_id, start_date, end_date, time_zone, recurrence_rule, exception_dates, generated_until)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+		if res, err = tx.Exec(request, Following.ProjectId, Following.UserId, Following.StartDate.UTC(), Following.EndDate.UTC(), Following.TimeZone, Following.RecurrenceRule, Following.ExceptionDates, Following.GeneratedUntil.UTC()); err == nil {
+			Following.SeriesId, err = res.LastInsertId()
+		}
+	}
+	if err == nil {
+		_, err = insertOccurrences(tx, Following, Occurrences, true)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	return Following.SeriesId, nil
+}
+
+//  ExtendSeries(Series model.ScheduleSeries, Occurrences model.Schedules) (model.ScheduleSeries, error)
+/*	This method is used to save the next occurrences of a series, as the horizon moves with the time.
+	The occurrences that would overlap another schedule of the user, or be locked, are skipped and kept as exceptions.
+	Returns the series with its new exceptions.
+*/
+func (db *ConcreteDatastore) ExtendSeries(Series model.ScheduleSeries, Occurrences model.Schedules) (model.ScheduleSeries, error) {
+	var (
+		tx      *sqlx.Tx
+		err     error
+		skipped []time.Time
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return model.ScheduleSeries{}, err
+	}
+
+	if skipped, err = insertOccurrences(tx, Series, Occurrences, false); err == nil {
+		Series.SetExceptions(append(Series.Exceptions(), skipped...))
+		err = saveSeries(tx, Series)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.ScheduleSeries{}, errr
+		}
+		return model.ScheduleSeries{}, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.ScheduleSeries{}, errr
+		}
+		return model.ScheduleSeries{}, err
+	}
+
+	return Series, nil
+}
+
+//  EndSeries(Series model.ScheduleSeries, From time.Time) error
+/*	This method is used to delete an occurrence and the following ones : the series is saved with a rule that ends
+	before them, and its occurrences starting from a date are deleted.
+	Returns a LockedError if one of them starts before the lock date, or is in an approved timesheet period of the user.
+*/
+func (db *ConcreteDatastore) EndSeries(Series model.ScheduleSeries, From time.Time) error {
+	var (
+		tx  *sqlx.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	if err = deleteOccurrences(tx, Series.SeriesId, From); err == nil {
+		err = saveSeries(tx, Series)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  DeleteSeries(SeriesId int64, From time.Time) error
+/*	This method is used to delete a series, and its occurrences from a date.
+	The earlier occurrences are kept as history, as schedules of the user out of any series.
+	Returns a LockedError if one of the deleted ones is locked.
+*/
+func (db *ConcreteDatastore) DeleteSeries(SeriesId int64, From time.Time) error {
+	var (
+		tx  *sqlx.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	err = deleteOccurrences(tx, SeriesId, From)
+	if err == nil {
+		_, err = tx.Exec(`UPDATE Schedule SET series_id=NULL WHERE series_id=?`, SeriesId)
+	}
+	if err == nil {
+		_, err = tx.Exec(`DELETE FROM ScheduleSeries WHERE series_id=?`, SeriesId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  DeleteOccurrence(ScheduleId int64) error
+/*	This method is used to delete one occurrence of a series, so its start is kept as an exception of the series.
+	Returns sql.ErrNoRows if the schedule is not in a series,
+	and a LockedError if it starts before the lock date, or is in an approved timesheet period of the user.
+*/
+func (db *ConcreteDatastore) DeleteOccurrence(ScheduleId int64) error {
+	var (
+		tx       *sqlx.Tx
+		err      error
+		schedule model.Schedule
+		series   model.ScheduleSeries
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	request := `SELECT schedule_id, project_id, start_date, end_date, series_id FROM Schedule WHERE schedule_id=?`
+	if err = tx.Get(&schedule, request, ScheduleId); err == nil && !schedule.SeriesId.Valid {
+		err = sql.ErrNoRows
+	}
+	if err == nil {
+		err = tx.Get(&series, `SELECT * FROM ScheduleSeries WHERE series_id=?`, schedule.SeriesId.Int64)
+	}
+	if err == nil {
+		err = deleteOccurrence(tx, schedule)
+	}
+	if err == nil {
+		series.SetExceptions(append(series.Exceptions(), schedule.StartDate.Time))
+		err = saveSeries(tx, series)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  insertOccurrences(tx *sqlx.Tx, Series model.ScheduleSeries, Occurrences model.Schedules, Strict bool) ([]time.Time, error)
+/*	Saves the occurrences of a series, linked to its user. When it is not strict, the occurrences that overlap
+	another schedule of the user or are locked are skipped, and their starts returned.
+*/
+func insertOccurrences(tx *sqlx.Tx, Series model.ScheduleSeries, Occurrences model.Schedules, Strict bool) ([]time.Time, error) {
+	var (
+		err        error
+		res        sql.Result
+		scheduleId int64
+		overlapErr *OverlapError
+		lockedErr  *LockedError
+	)
+
+	skipped := []time.Time{}
+	userIds := []int64{Series.UserId}
+
+	for _, occurrence := range Occurrences {
+		// The occurrence must not overlap the other schedules of the user, nor be locked
+		if err = checkOverlaps(tx, userIds, occurrence); err == nil {
+			err = checkLocked(tx, userIds, occurrence)
+		}
+		if !Strict && (errors.As(err, &overlapErr) || errors.As(err, &lockedErr)) {
+			skipped = append(skipped, occurrence.StartDate.Time)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		request := `INSERT INTO Schedule(project_id, start_date, end_date, series_id) VALUES (?, ?, ?, ?)`
+		if res, err = tx.Exec(request, Series.ProjectId, occurrence.StartDate.Time.UTC(), utcEndDate(occurrence), Series.SeriesId); err != nil {
+			return nil, err
+		}
+		if scheduleId, err = res.LastInsertId(); err != nil {
+			return nil, err
+		}
+		if err = linkUsers(tx, userIds, scheduleId); err != nil {
+			return nil, err
+		}
+	}
+
+	return skipped, nil
+}
+
+//  deleteOccurrences(tx *sqlx.Tx, SeriesId int64, From time.Time) error
+/*	Deletes the occurrences of a series that start from a date, and their links to the user.
+	Returns a LockedError if one of them is locked.
+*/
+func deleteOccurrences(tx *sqlx.Tx, SeriesId int64, From time.Time) error {
+	occurrences := model.Schedules{}
+
+	request := `SELECT schedule_id, project_id, start_date, end_date, series_id
+	FROM Schedule
+	WHERE series_id=?
+	AND start_date>=?`
+	if err := tx.Select(&occurrences, request, SeriesId, From.UTC()); err != nil {
+		return err
+	}
+
+	for _, occurrence := range occurrences {
+		if err := deleteOccurrence(tx, occurrence); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//  deleteOccurrence(tx *sqlx.Tx, Occurrence model.Schedule) error
+/*	Deletes an occurrence of a series, with its breaks and its links to the users. Returns a LockedError if it is locked.
+ */
+func deleteOccurrence(tx *sqlx.Tx, Occurrence model.Schedule) error {
+	if err := checkScheduleLocked(tx, Occurrence.ScheduleId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM ScheduleBreak WHERE schedule_id=?`, Occurrence.ScheduleId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM UserSchedule WHERE schedule_id=?`, Occurrence.ScheduleId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM Schedule WHERE schedule_id=?`, Occurrence.ScheduleId); err != nil {
+		return err
+	}
+	return nil
+}
+
+//  saveSeries(tx *sqlx.Tx, Series model.ScheduleSeries) error
+/*	Saves the changes of a series, but not of its occurrences.
+ */
+func saveSeries(tx *sqlx.Tx, Series model.ScheduleSeries) error {
+	request := `UPDATE ScheduleSeries
+	SET project_id=?, user_id=?, start_date=?, end_date=?, time_zone=?, recurrence_rule=?, exception_dates=?, generated_until=?
+	WHERE series_id=?`
+	_, err := tx.Exec(request, Series.ProjectId, Series.UserId, Series.StartDate.UTC(), Series.EndDate.UTC(), Series.TimeZone, Series.RecurrenceRule, Series.ExceptionDates, Series.GeneratedUntil.UTC(), Series.SeriesId)
+	return err
+}
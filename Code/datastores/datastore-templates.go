@@ -0,0 +1,194 @@
+package datastores
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//  GetTemplate(TemplateId int64) (model.ScheduleTemplate, error)
+/*	This method is used to get a template of schedules.
+ */
+func (db *ConcreteDatastore) GetTemplate(TemplateId int64) (model.ScheduleTemplate, error) {
+	var template model.ScheduleTemplate
+
+	request := `SELECT * FROM ScheduleTemplate WHERE template_id=?`
+	if err := db.Get(&template, request, TemplateId); err != nil {
+		return model.ScheduleTemplate{}, err
+	}
+
+	return template, nil
+}
+
+//  GetTemplatesOfUser(UserId int64) (model.ScheduleTemplates, error)
+/*	This method is used to get the templates a user can apply : his own ones, and the ones of his companies,
+	by name.
+*/
+func (db *ConcreteDatastore) GetTemplatesOfUser(UserId int64) (model.ScheduleTemplates, error) {
+	templates := model.ScheduleTemplates{}
+
+	request := `SELECT * FROM ScheduleTemplate
+	WHERE user_id=?
+	OR company_id IN (SELECT company_id FROM CompanyUser WHERE user_id=?)
+	ORDER BY template_name, template_id`
+	if err := db.Select(&templates, request, UserId, UserId); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+//  GetTemplatesOfCompany(CompanyId int64) (model.ScheduleTemplates, error)
+/*	This method is used to get the templates shared with the users of a company, by name.
+ */
+func (db *ConcreteDatastore) GetTemplatesOfCompany(CompanyId int64) (model.ScheduleTemplates, error) {
+	templates := model.ScheduleTemplates{}
+
+	request := `SELECT * FROM ScheduleTemplate WHERE company_id=? ORDER BY template_name, template_id`
+	if err := db.Select(&templates, request, CompanyId); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+//  GetSlotsOfTemplate(TemplateId int64) (model.TemplateSlots, error)
+/*	This method is used to get the time slots of a template, from monday morning to sunday evening.
+ */
+func (db *ConcreteDatastore) GetSlotsOfTemplate(TemplateId int64) (model.TemplateSlots, error) {
+	slots := model.TemplateSlots{}
+
+	request := `SELECT * FROM TemplateSlot WHERE template_id=? ORDER BY weekday, start_time, slot_id`
+	if err := db.Select(&slots, request, TemplateId); err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+//  CreateTemplate(Template model.ScheduleTemplate, Slots model.TemplateSlots) (int64, error)
+/*	This method is used to create a template with its time slots.
+ */
+func (db *ConcreteDatastore) CreateTemplate(Template model.ScheduleTemplate, Slots model.TemplateSlots) (int64, error) {
+	var (
+		tx  *sqlx.Tx
+		err error
+		res sql.Result
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return -1, err
+	}
+
+	request := `INSERT INTO ScheduleTemplate(template_name, user_id, company_id) VALUES (?, ?, ?)`
+	if res, err = tx.Exec(request, Template.TemplateName, Template.UserId, Template.CompanyId); err == nil {
+		if Template.TemplateId, err = res.LastInsertId(); err == nil {
+			err = insertSlots(tx, Template.TemplateId, Slots)
+		}
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
+	return Template.TemplateId, nil
+}
+
+//  UpdateTemplate(Template model.ScheduleTemplate, Slots model.TemplateSlots) error
+/*	This method is used to rename a template and replace its time slots. It stays with its user or its company.
+	The schedules already filled from it don't change.
+*/
+func (db *ConcreteDatastore) UpdateTemplate(Template model.ScheduleTemplate, Slots model.TemplateSlots) error {
+	var (
+		tx  *sqlx.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	request := `UPDATE ScheduleTemplate SET template_name=? WHERE template_id=?`
+	if _, err = tx.Exec(request, Template.TemplateName, Template.TemplateId); err == nil {
+		if _, err = tx.Exec(`DELETE FROM TemplateSlot WHERE template_id=?`, Template.TemplateId); err == nil {
+			err = insertSlots(tx, Template.TemplateId, Slots)
+		}
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  DeleteTemplate(TemplateId int64) error
+/*	This method is used to delete a template with its time slots. The schedules filled from it are kept.
+ */
+func (db *ConcreteDatastore) DeleteTemplate(TemplateId int64) error {
+	var (
+		tx  *sqlx.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`DELETE FROM TemplateSlot WHERE template_id=?`, TemplateId); err == nil {
+		_, err = tx.Exec(`DELETE FROM ScheduleTemplate WHERE template_id=?`, TemplateId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  insertSlots(tx *sqlx.Tx, TemplateId int64, Slots model.TemplateSlots) error
+/*	Saves the time slots of a template.
+ */
+func insertSlots(tx *sqlx.Tx, TemplateId int64, Slots model.TemplateSlots) error {
+	request := `INSERT INTO TemplateSlot(template_id, project_id, weekday, start_time, end_time) VALUES (?, ?, ?, ?, ?)`
+	for _, slot := range Slots {
+		if _, err := tx.Exec(request, TemplateId, slot.ProjectId, slot.Weekday, slot.StartTime, slot.EndTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package datastores
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// ErrTimesheetState : Returned by ChangeTimesheetPeriodState when the period can't go from its state to the new one.
+var ErrTimesheetState = errors.New("the timesheet period can't go to this state")
+
+// The states a timesheet period can go to, from each state.
+// A draft or a rejected period is submitted, a submitted one approved or rejected, and an approved one reopened as a draft.
+var timesheetTransitions = map[string][]string{
+	model.TimesheetDraft:     {model.TimesheetSubmitted},
+	model.TimesheetSubmitted: {model.TimesheetApproved, model.TimesheetRejected},
+	model.TimesheetRejected:  {model.TimesheetSubmitted},
+	model.TimesheetApproved:  {model.TimesheetDraft},
+}
+
+//  GetTimesheetPeriodOfUser(UserId int64, Period string) (model.TimesheetPeriod, error)
+/*	This method is used to get a week or a month of the timesheet of a user, like 2021-W12.
+	Returns sql.ErrNoRows if it was never submitted.
+*/
+func (db *ConcreteDatastore) GetTimesheetPeriodOfUser(UserId int64, Period string) (model.TimesheetPeriod, error) {
+	var (
+		err    error
+		period model.TimesheetPeriod
+	)
+
+	request := `SELECT period_id, user_id, period, start_date, end_date, state
+	FROM TimesheetPeriod
+	WHERE user_id=?
+	AND period=?`
+	if err = db.Get(&period, request, UserId, Period); err != nil {
+		return model.TimesheetPeriod{}, err
+	}
+
+	return period, nil
+}
+
+//  GetTimesheetPeriodsOfUser(UserId int64) (model.TimesheetPeriods, error)
+/*	This method is used to get the periods of the timesheet of a user, the last ones first.
+ */
+func (db *ConcreteDatastore) GetTimesheetPeriodsOfUser(UserId int64) (model.TimesheetPeriods, error) {
+	periods := model.TimesheetPeriods{}
+
+	request := `SELECT period_id, user_id, period, start_date, end_date, state
+	FROM TimesheetPeriod
+	WHERE user_id=?
+	ORDER BY start_date DESC`
+	if err := db.Select(&periods, request, UserId); err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+//  GetTimesheetPeriodsByState(State string) (model.TimesheetPeriods, error)
+/*	This method is used to get the periods of every user in a state, like the submitted ones waiting for a manager.
+ */
+func (db *ConcreteDatastore) GetTimesheetPeriodsByState(State string) (model.TimesheetPeriods, error) {
+	periods := model.TimesheetPeriods{}
+
+	request := `SELECT period_id, user_id, period, start_date, end_date, state
+	FROM TimesheetPeriod
+	WHERE state=?
+	ORDER BY start_date, user_id`
+	if err := db.Select(&periods, request, State); err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+//  GetTimesheetEvents(PeriodId int64) (model.TimesheetEvents, error)
+/*	This method is used to get the history of a timesheet period, the first change first.
+ */
+func (db *ConcreteDatastore) GetTimesheetEvents(PeriodId int64) (model.TimesheetEvents, error) {
+	events := model.TimesheetEvents{}
+
+	request := `SELECT event_id, period_id, state, author_id, reason, created_at
+	FROM TimesheetEvent
+	WHERE period_id=?
+	ORDER BY event_id`
+	if err := db.Select(&events, request, PeriodId); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+//  ChangeTimesheetPeriodState(Period model.TimesheetPeriod, Event model.TimesheetEvent) (model.TimesheetPeriod, error)
+/*	This method is used to move a period of a user to the state of an event, and keep the event in its history.
+	A period that does not exist yet is created as a draft first, with the dates given.
+	Returns ErrTimesheetState if the period can't go from its state to the new one.
+*/
+func (db *ConcreteDatastore) ChangeTimesheetPeriodState(Period model.TimesheetPeriod, Event model.TimesheetEvent) (model.TimesheetPeriod, error) {
+	var (
+		tx     *sqlx.Tx
+		err    error
+		res    sql.Result
+		period model.TimesheetPeriod
+	)
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return model.TimesheetPeriod{}, err
+	}
+
+	// Fetching the period, or creating it as a draft
+	request := `SELECT period_id, user_id, period, start_date, end_date, state
+	FROM TimesheetPeriod
+	WHERE user_id=?
+	AND period=?`
+	if err = tx.Get(&period, request, Period.UserId, Period.Period); err == sql.ErrNoRows {
+		period = model.TimesheetPeriod{
+			UserId:    Period.UserId,
+			Period:    Period.Period,
+			StartDate: Period.StartDate.UTC(),
+			EndDate:   Period.EndDate.UTC(),
+			State:     model.TimesheetDraft,
+		}
+		request = `INSERT INTO TimesheetPeriod(user_id, period, start_date, end_date, state) VALUES (?, ?, ?, ?, ?)`
+		if res, err = tx.Exec(request, period.UserId, period.Period, period.StartDate, period.EndDate, period.State); err == nil {
+			period.PeriodId, err = res.LastInsertId()
+		}
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.TimesheetPeriod{}, errr
+		}
+		return model.TimesheetPeriod{}, err
+	}
+
+	// The period must be allowed to go to the new state
+	allowed := false
+	for _, state := range timesheetTransitions[period.State] {
+		allowed = allowed || state == Event.State
+	}
+	if !allowed {
+		if errr := tx.Rollback(); errr != nil {
+			return model.TimesheetPeriod{}, errr
+		}
+		return period, ErrTimesheetState
+	}
+
+	// Changing the state, and keeping the change
+	request = `UPDATE TimesheetPeriod SET state=? WHERE period_id=?`
+	if _, err = tx.Exec(request, Event.State, period.PeriodId); err == nil {
+		request = `INSERT INTO TimesheetEvent(period_id, state, author_id, reason, created_at) VALUES (?, ?, ?, ?, ?)`
+		_, err = tx.Exec(request, period.PeriodId, Event.State, Event.AuthorId, Event.Reason, time.Now().UTC())
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.TimesheetPeriod{}, errr
+		}
+		return model.TimesheetPeriod{}, err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.TimesheetPeriod{}, errr
+		}
+		return model.TimesheetPeriod{}, err
+	}
+
+	period.State = Event.State
+	return period, nil
+}
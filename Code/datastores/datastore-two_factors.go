@@ -0,0 +1,171 @@
+package datastores
+
+import (
+	"database/sql"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//  GetTwoFactor(UserId int64) (model.TwoFactor, error)
+/*	This method is used to get the two-factor authentication of a user.
+	Returns sql.ErrNoRows if the user never started to enroll.
+*/
+func (db *ConcreteDatastore) GetTwoFactor(UserId int64) (model.TwoFactor, error) {
+	var (
+		err       error
+		twoFactor model.TwoFactor
+	)
+
+	// Setting up and executing the request
+	request := `SELECT * FROM TwoFactor WHERE user_id=?`
+	if err = db.Get(&twoFactor, request, UserId); err != nil {
+		return model.TwoFactor{}, err
+	}
+
+	return twoFactor, nil
+}
+
+//  SaveTwoFactor(TwoFactor model.TwoFactor) error
+/*	This method is used to create or replace the two-factor authentication of a user.
+ */
+func (db *ConcreteDatastore) SaveTwoFactor(TwoFactor model.TwoFactor) error {
+	var (
+		tx  *sql.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Begin(); err != nil {
+		return err
+	}
+
+	// Executing the request
+	request := `INSERT OR REPLACE INTO TwoFactor(user_id, secret, enabled, last_used_step)
+	VALUES (?, ?, ?, ?)`
+	if _, err = tx.Exec(request, TwoFactor.UserId, TwoFactor.Secret, TwoFactor.Enabled, TwoFactor.LastUsedStep); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  DeleteTwoFactor(UserId int64) error
+/*	This method is used to disable the two-factor authentication of a user.
+	His recovery codes are deleted too.
+*/
+func (db *ConcreteDatastore) DeleteTwoFactor(UserId int64) error {
+	var (
+		tx  *sql.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Begin(); err != nil {
+		return err
+	}
+
+	// Executing the requests
+	for _, request := range []string{
+		`DELETE FROM RecoveryCode WHERE user_id=?`,
+		`DELETE FROM TwoFactor WHERE user_id=?`,
+	} {
+		if _, err = tx.Exec(request, UserId); err != nil {
+			if errr := tx.Rollback(); errr != nil {
+				return errr
+			}
+			return err
+		}
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  SetRecoveryCodes(UserId int64, CodeHashes []string) error
+/*	This method is used to replace all the recovery codes of a user.
+	The codes must already be hashed.
+*/
+func (db *ConcreteDatastore) SetRecoveryCodes(UserId int64, CodeHashes []string) error {
+	var (
+		tx  *sql.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Begin(); err != nil {
+		return err
+	}
+
+	// Deleting the old codes
+	request := `DELETE FROM RecoveryCode WHERE user_id=?`
+	if _, err = tx.Exec(request, UserId); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Inserting the new ones
+	request = `INSERT INTO RecoveryCode(user_id, code_hash) VALUES (?, ?)`
+	for _, codeHash := range CodeHashes {
+		if _, err = tx.Exec(request, UserId, codeHash); err != nil {
+			if errr := tx.Rollback(); errr != nil {
+				return errr
+			}
+			return err
+		}
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  UseRecoveryCode(UserId int64, CodeHash string) (bool, error)
+/*	This method is used to consume a recovery code of a user.
+	Returns false if the user has no such code, which can only be used once.
+*/
+func (db *ConcreteDatastore) UseRecoveryCode(UserId int64, CodeHash string) (bool, error) {
+	var (
+		err      error
+		res      sql.Result
+		affected int64
+	)
+
+	request := `DELETE FROM RecoveryCode
+	WHERE user_id=?
+	AND code_hash=?`
+	if res, err = db.Exec(request, UserId, CodeHash); err != nil {
+		return false, err
+	}
+
+	if affected, err = res.RowsAffected(); err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
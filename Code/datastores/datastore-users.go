@@ -176,9 +176,9 @@ func (db *ConcreteDatastore) CreateUser(User model.User) (int64, error) {
 	}
 
 	// Executing the request
-	request := `INSERT INTO User(contract_id, role_id, username, password, last_name, first_name, mail, theorical_hours_worked, vacation_hours) 
-				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	if res, err = tx.Exec(request, User.ContractId, User.RoleId, User.Username, User.Password, User.LastName, User.FirstName, User.Mail, User.TheoricalHoursWorked, User.VacationHours); err != nil {
+	request := `INSERT INTO User(contract_id, role_id, username, password, last_name, first_name, mail, theorical_hours_worked, vacation_hours, must_change_password, auth_provider, time_zone) 
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if res, err = tx.Exec(request, User.ContractId, User.RoleId, User.Username, User.Password, User.LastName, User.FirstName, User.Mail, User.TheoricalHoursWorked, User.VacationHours, User.MustChangePassword, User.AuthProvider, User.TimeZone); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return -1, errr
 		}
@@ -232,9 +232,9 @@ func (db *ConcreteDatastore) UpdateUser(User model.User) (model.User, error) {
 
 	// Executing the request
 	request := `UPDATE User
-	SET contract_id=?, role_id=?, username=?, password=?, last_name=?, first_name=?, mail=?, theorical_hours_worked=?, vacation_hours=? 
+	SET contract_id=?, role_id=?, username=?, password=?, last_name=?, first_name=?, mail=?, theorical_hours_worked=?, vacation_hours=?, must_change_password=?, auth_provider=?, time_zone=? 
 	WHERE user_id =?`
-	if _, err = tx.Exec(request, User.ContractId, User.RoleId, User.Username, User.Password, User.LastName, User.FirstName, User.Mail, User.TheoricalHoursWorked, User.VacationHours, User.UserId); err != nil {
+	if _, err = tx.Exec(request, User.ContractId, User.RoleId, User.Username, User.Password, User.LastName, User.FirstName, User.Mail, User.TheoricalHoursWorked, User.VacationHours, User.MustChangePassword, User.AuthProvider, User.TimeZone, User.UserId); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return model.User{}, errr
 		}
@@ -251,3 +251,95 @@ func (db *ConcreteDatastore) UpdateUser(User model.User) (model.User, error) {
 
 	return User, nil
 }
+
+//  UpdateUserPassword(UserId int64, Password string, MustChangePassword bool) error
+/*	This method is used to change the password of a user.
+	The password must already be crypted.
+	MustChangePassword tells wether the user will have to change it again before using the API.
+*/
+func (db *ConcreteDatastore) UpdateUserPassword(UserId int64, Password string, MustChangePassword bool) error {
+	var (
+		tx  *sql.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Begin(); err != nil {
+		return err
+	}
+
+	// Executing the request
+	request := `UPDATE User
+	SET password=?, must_change_password=?
+	WHERE user_id=?`
+	if _, err = tx.Exec(request, Password, MustChangePassword, UserId); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
+
+//  AnonymizeUser(Anonymous model.User) error
+/*	This method is used to erase the personal data of a user, while keeping his schedules for the reports.
+	The names, the mail, the password and the provider are replaced by the ones of Anonymous.
+	His API tokens and his two-factor authentication are deleted, and so are the comments of the schedules
+	no other user is linked to. Everything is done in the same transaction.
+*/
+func (db *ConcreteDatastore) AnonymizeUser(Anonymous model.User) error {
+	var (
+		tx  *sql.Tx
+		err error
+	)
+
+	// Starting
+	if tx, err = db.Begin(); err != nil {
+		return err
+	}
+
+	// Executing the requests
+	requests := []struct {
+		request   string
+		arguments []interface{}
+	}{
+		{`UPDATE User
+		SET username=?, password=?, last_name=?, first_name=?, mail=?, must_change_password=?, auth_provider=?, time_zone=?
+		WHERE user_id=?`, []interface{}{Anonymous.Username, Anonymous.Password, Anonymous.LastName, Anonymous.FirstName, Anonymous.Mail, Anonymous.MustChangePassword, Anonymous.AuthProvider, Anonymous.TimeZone, Anonymous.UserId}},
+		{`DELETE FROM AccessToken WHERE user_id=?`, []interface{}{Anonymous.UserId}},
+		{`DELETE FROM RecoveryCode WHERE user_id=?`, []interface{}{Anonymous.UserId}},
+		{`DELETE FROM TwoFactor WHERE user_id=?`, []interface{}{Anonymous.UserId}},
+		{`UPDATE Comment
+		SET comment=''
+		WHERE schedule_id IN (SELECT schedule_id FROM UserSchedule WHERE user_id=?)
+		AND schedule_id NOT IN (SELECT schedule_id FROM UserSchedule WHERE user_id<>?)`, []interface{}{Anonymous.UserId, Anonymous.UserId}},
+	}
+
+	for _, request := range requests {
+		if _, err = tx.Exec(request.request, request.arguments...); err != nil {
+			if errr := tx.Rollback(); errr != nil {
+				return errr
+			}
+			return err
+		}
+	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
+	return nil
+}
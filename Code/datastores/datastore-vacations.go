@@ -72,32 +72,46 @@ func (db *ConcreteDatastore) GetVacation(VacationId int64) (model.Schedule, erro
 	return schedule, nil
 }
 
-// CreateVacation(Schedule model.Schedule) (int64, error)
-/*	This method is used to create a new vacation.
- */
-func (db *ConcreteDatastore) CreateVacation(Schedule model.Schedule) (int64, error) {
+// CreateVacation(Schedule model.Schedule, UserIds ...int64) (int64, error)
+/*	This method is used to create a new vacation, and link it to some users.
+	Returns an OverlapError if it covers the same hours as another schedule of one of the users,
+	and a LockedError if it starts before the lock date, or is in an approved timesheet period of one of them.
+*/
+func (db *ConcreteDatastore) CreateVacation(Schedule model.Schedule, UserIds ...int64) (int64, error) {
 	var (
-		tx              *sql.Tx
+		tx              *sqlx.Tx
 		err             error
 		res             sql.Result
 		scheduleId      int64
 		vacationProject model.Project
 	)
 
+	// Fetching the vacation project
+	if vacationProject, err = db.GetVacationProject(); err != nil {
+		return -1, err
+	}
+	Schedule.ProjectId = vacationProject.ProjectId
+
 	// Starting
-	if tx, err = db.Begin(); err != nil {
+	if tx, err = db.Beginx(); err != nil {
 		return -1, err
 	}
 
-	// Fetching the vacation project
-	if vacationProject, err = db.GetVacationProject(); err != nil {
-		return -1, nil
+	// The vacation must not overlap the schedules of its users, nor be in their approved periods
+	if err = checkOverlaps(tx, UserIds, Schedule); err == nil {
+		err = checkLocked(tx, UserIds, Schedule)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
 	}
 
-	// Executing the request
+	// Executing the request. The dates are stored in UTC, so they can be compared whatever the zone of the client
 	request := `INSERT INTO Schedule(project_id, start_date, end_date)
 	VALUES (?, ?, ?)`
-	if res, err = tx.Exec(request, vacationProject.ProjectId, Schedule.StartDate.Time, Schedule.EndDate.Time); err != nil {
+	if res, err = tx.Exec(request, vacationProject.ProjectId, Schedule.StartDate.Time.UTC(), Schedule.EndDate.Time.UTC()); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return -1, errr
 		}
@@ -112,6 +126,14 @@ func (db *ConcreteDatastore) CreateVacation(Schedule model.Schedule) (int64, err
 		return -1, err
 	}
 
+	// Linking it to its users
+	if err = linkUsers(tx, UserIds, scheduleId); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return -1, errr
+		}
+		return -1, err
+	}
+
 	// Saving changes
 	if err = tx.Commit(); err != nil {
 		if errr := tx.Rollback(); errr != nil {
@@ -126,51 +148,92 @@ func (db *ConcreteDatastore) CreateVacation(Schedule model.Schedule) (int64, err
 //  DeleteVacation(VacationId int64) error
 /*	This method is used to delete a vacation.
 	It's the same method as DeleteSchedule, but we restrict the delete to the Vacation project only.
+	Returns a LockedError if it starts before the lock date, or is in an approved timesheet period of one of its users.
 */
 func (db *ConcreteDatastore) DeleteVacation(VacationId int64) error {
 	var (
+		tx              *sqlx.Tx
 		vacationProject model.Project
 		err             error
 	)
 	if vacationProject, err = db.GetVacationProject(); err != nil {
 		return err
 	}
-	request := `DELETE FROM Schedule 
-	WHERE schedule_id=?
-	AND project_id=?`
-	if _, err = db.Exec(request, VacationId, vacationProject.ProjectId); err != nil {
+
+	// Starting
+	if tx, err = db.Beginx(); err != nil {
+		return err
+	}
+
+	// The vacation must not be in an approved period
+	if err = checkScheduleLocked(tx, VacationId); err == nil {
+		request := `DELETE FROM Schedule 
+		WHERE schedule_id=?
+		AND project_id=?`
+		_, err = tx.Exec(request, VacationId, vacationProject.ProjectId)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
 		return err
 	}
+
+	// Saving
+	if err = tx.Commit(); err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return errr
+		}
+		return err
+	}
+
 	return nil
 }
 
 //  UpdateVacation(Vacation model.Schedule) (model.Schedule, error)
 /*	This method is used to update an existing vacation.
 	It's basically the same method as UpdateSchedule, but restricts the update to the Vacation project only.
+	Returns an OverlapError if it would cover the same hours as another schedule of one of its users,
+	and a LockedError if it starts, or would start, before the lock date, or is, or would be, in an approved timesheet period of one of them.
 */
 func (db *ConcreteDatastore) UpdateVacation(Vacation model.Schedule) (model.Schedule, error) {
 	var (
-		tx              *sql.Tx
+		tx              *sqlx.Tx
 		err             error
 		vacationProject model.Project
+		userIds         []int64
 	)
 
+	// Fetching the vacation project
+	if vacationProject, err = db.GetVacationProject(); err != nil {
+		return model.Schedule{}, err
+	}
+
 	// Starting
-	if tx, err = db.Begin(); err != nil {
+	if tx, err = db.Beginx(); err != nil {
 		return model.Schedule{}, err
 	}
 
-	// Fetching the vacation project
-	if vacationProject, err = db.GetVacationProject(); err != nil {
-		return model.Schedule{}, nil
+	// The vacation must not overlap the other schedules of its users, nor be in their approved periods
+	if userIds, err = usersOfSchedule(tx, Vacation.ScheduleId); err == nil {
+		err = checkOverlaps(tx, userIds, Vacation)
+	}
+	if err == nil {
+		err = checkScheduleLocked(tx, Vacation.ScheduleId, Vacation)
+	}
+	if err != nil {
+		if errr := tx.Rollback(); errr != nil {
+			return model.Schedule{}, errr
+		}
+		return model.Schedule{}, err
 	}
 
-	// Executing the request
+	// Executing the request. The dates are stored in UTC, so they can be compared whatever the zone of the client
 	request := `UPDATE Schedule
 	SET project_id=?, start_date=?, end_date=?
 	WHERE schedule_id=?
 	AND project_id=?`
-	if _, err = tx.Exec(request, Vacation.ProjectId, Vacation.StartDate, Vacation.EndDate, Vacation.ScheduleId, vacationProject.ProjectId); err != nil {
+	if _, err = tx.Exec(request, Vacation.ProjectId, Vacation.StartDate.Time.UTC(), Vacation.EndDate.Time.UTC(), Vacation.ScheduleId, vacationProject.ProjectId); err != nil {
 		if errr := tx.Rollback(); errr != nil {
 			return model.Schedule{}, errr
 		}
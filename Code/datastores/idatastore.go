@@ -1,6 +1,8 @@
 package datastores
 
 import (
+	"time"
+
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
 )
 
@@ -17,14 +19,24 @@ type IDatastore interface {
 	CreateUser(User model.User) (int64, error)
 	DeleteUser(UserId int64) error
 	UpdateUser(User model.User) (model.User, error)
+	UpdateUserPassword(UserId int64, Password string, MustChangePassword bool) error
+	AnonymizeUser(Anonymous model.User) error
 
 	//Companies
 	GetCompanies() (model.Companies, error)
 	GetCompany(CompanyId int64) (model.Company, error)
+	GetCompaniesOfUser(UserId int64) (model.Companies, error)
 	CreateCompany(Company model.Company) (int64, error)
 	DeleteCompany(CompanyId int64) error
 	UpdateCompany(Company model.Company) (model.Company, error)
 
+	//Days off of the companies
+	GetHoliday(HolidayId int64) (model.Holiday, error)
+	GetHolidaysOfCompany(CompanyId int64) (model.Holidays, error)
+	GetHolidaysOfUser(UserId int64) (model.Holidays, error)
+	CreateHoliday(Holiday model.Holiday) (int64, error)
+	DeleteHoliday(HolidayId int64) error
+
 	//Projects
 	GetProjects() (model.Projects, error)
 	GetProject(ProjectId int64) (model.Project, error)
@@ -48,7 +60,7 @@ type IDatastore interface {
 	//Vacations
 	GetVacationsOfUser(UserId int64) (model.Schedules, error)
 	GetVacation(VacationId int64) (model.Schedule, error)
-	CreateVacation(Schedule model.Schedule) (int64, error)
+	CreateVacation(Schedule model.Schedule, UserIds ...int64) (int64, error)
 	DeleteVacation(VacationId int64) error
 	UpdateVacation(Vacation model.Schedule) (model.Schedule, error)
 
@@ -56,9 +68,46 @@ type IDatastore interface {
 	GetSchedule(ScheduleId int64) (model.Schedule, error)
 	GetSchedulesOfUser(UserId int64) (model.Schedules, error)
 	GetSchedulesOfProject(ProjectId int64) (model.Schedules, error)
-	CreateSchedule(Schedule model.Schedule) (int64, error)
+	CreateSchedule(Schedule model.Schedule, UserIds ...int64) (int64, error)
+	CheckSchedule(Schedule model.Schedule, UserIds ...int64) error
+	CreateSchedules(Schedules model.Schedules, UserIds ...int64) ([]int64, error)
 	DeleteSchedule(ScheduleId int64) error
 	UpdateSchedule(Schedule model.Schedule) (model.Schedule, error)
+	GetRunningScheduleOfUser(UserId int64) (model.Schedule, error)
+	GetRunningUsersSchedules() (model.UsersSchedules, error)
+	StartTimer(UserId int64, Schedule model.Schedule) (int64, error)
+	StopSchedule(ScheduleId int64, EndDate time.Time) error
+	GetOverlapsOfUser(UserId int64) (model.ScheduleOverlaps, error)
+
+	//Breaks within schedules
+	GetBreak(BreakId int64) (model.ScheduleBreak, error)
+	GetBreaksOfSchedule(ScheduleId int64) (model.ScheduleBreaks, error)
+	GetBreaksOfUser(UserId int64) (model.ScheduleBreaks, error)
+	CreateBreak(Break model.ScheduleBreak) (int64, error)
+	UpdateBreak(Break model.ScheduleBreak) (model.ScheduleBreak, error)
+	DeleteBreak(BreakId int64) error
+
+	//Recurring series of schedules
+	GetSeries(SeriesId int64) (model.ScheduleSeries, error)
+	GetSeriesOfUser(UserId int64) (model.ScheduleSeriesList, error)
+	GetSeriesToExtend(Until time.Time) (model.ScheduleSeriesList, error)
+	GetSchedulesOfSeries(SeriesId int64) (model.Schedules, error)
+	CreateSeries(Series model.ScheduleSeries, Occurrences model.Schedules) (int64, error)
+	UpdateSeries(Series model.ScheduleSeries, From time.Time, Occurrences model.Schedules) error
+	SplitSeries(Series model.ScheduleSeries, From time.Time, Following model.ScheduleSeries, Occurrences model.Schedules) (int64, error)
+	ExtendSeries(Series model.ScheduleSeries, Occurrences model.Schedules) (model.ScheduleSeries, error)
+	EndSeries(Series model.ScheduleSeries, From time.Time) error
+	DeleteSeries(SeriesId int64, From time.Time) error
+	DeleteOccurrence(ScheduleId int64) error
+
+	//Templates of schedules
+	GetTemplate(TemplateId int64) (model.ScheduleTemplate, error)
+	GetTemplatesOfUser(UserId int64) (model.ScheduleTemplates, error)
+	GetTemplatesOfCompany(CompanyId int64) (model.ScheduleTemplates, error)
+	GetSlotsOfTemplate(TemplateId int64) (model.TemplateSlots, error)
+	CreateTemplate(Template model.ScheduleTemplate, Slots model.TemplateSlots) (int64, error)
+	UpdateTemplate(Template model.ScheduleTemplate, Slots model.TemplateSlots) error
+	DeleteTemplate(TemplateId int64) error
 
 	//Roles
 	GetRoles() (model.Roles, error)
@@ -85,6 +134,48 @@ type IDatastore interface {
 	DeleteFunction(FunctionId int64) error
 	UpdateFunction(Function model.Function) (model.Function, error)
 
+	//Login attempts
+	GetLoginAttempt(AttemptKey string) (model.LoginAttempt, error)
+	SaveLoginAttempt(Attempt model.LoginAttempt) error
+	DeleteLoginAttempt(AttemptKey string) error
+
+	//Two-factor authentication
+	GetTwoFactor(UserId int64) (model.TwoFactor, error)
+	SaveTwoFactor(TwoFactor model.TwoFactor) error
+	DeleteTwoFactor(UserId int64) error
+	SetRecoveryCodes(UserId int64, CodeHashes []string) error
+	UseRecoveryCode(UserId int64, CodeHash string) (bool, error)
+
+	//Access tokens
+	GetAccessTokensOfUser(UserId int64) (model.AccessTokens, error)
+	GetAccessToken(AccessTokenId int64) (model.AccessToken, error)
+	GetAccessTokenFromHash(TokenHash string) (model.AccessToken, error)
+	CreateAccessToken(Token model.AccessToken) (int64, error)
+	DeleteAccessToken(AccessTokenId int64) error
+	UpdateAccessTokenLastUse(AccessTokenId int64, LastUsedAt time.Time) error
+
+	//Impersonations
+	GetImpersonations() (model.Impersonations, error)
+	GetImpersonation(ImpersonationId int64) (model.Impersonation, error)
+	CreateImpersonation(Impersonation model.Impersonation) (int64, error)
+	EndImpersonation(ImpersonationId int64, EndedAt time.Time) error
+	GetActionsOfImpersonation(ImpersonationId int64) (model.ImpersonationActions, error)
+	CreateImpersonationAction(Action model.ImpersonationAction) (int64, error)
+	UpdateImpersonationActionStatus(ImpersonationActionId int64, StatusCode int64) error
+
+	//Timesheet periods
+	GetTimesheetPeriodOfUser(UserId int64, Period string) (model.TimesheetPeriod, error)
+	GetTimesheetPeriodsOfUser(UserId int64) (model.TimesheetPeriods, error)
+	GetTimesheetPeriodsByState(State string) (model.TimesheetPeriods, error)
+	GetTimesheetEvents(PeriodId int64) (model.TimesheetEvents, error)
+	ChangeTimesheetPeriodState(Period model.TimesheetPeriod, Event model.TimesheetEvent) (model.TimesheetPeriod, error)
+
+	//Lock dates
+	GetLockDate() (model.LockDate, error)
+	GetLockDates() (model.LockDates, error)
+	Lock(Lock model.LockDate) (model.LockDate, error)
+	Unlock(Lock model.LockDate) (model.LockDate, error)
+
 	//Intermediate tables
 	CreateCompanyProject(CP model.CompanyProject) error
 	CreateCompanyUser(CU model.CompanyUser) error
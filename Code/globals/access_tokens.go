@@ -0,0 +1,89 @@
+package globals
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// The beginning of every API token, so they are easy to recognize, by the API and by the secret scanners.
+const AccessTokenPrefix = "gtp_"
+
+// The items an API token scope can be limited to.
+var AccessTokenItems = []string{"breaks", "comments", "companies", "contracts", "functions", "holidays", "locks", "projects", "roles", "schedules", "series", "templates", "timesheets", "users", "vacations"}
+
+//	GenerateAccessToken() (string, error)
+/*	Returns a new API token : the prefix followed by 32 random bytes.
+ */
+func GenerateAccessToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+//	HashAccessToken(Token string) string
+/*	Returns the hash of an API token, that is stored instead of the token.
+	The tokens are random enough for a SHA-256 hash, which can be looked up.
+*/
+func HashAccessToken(Token string) string {
+	sum := sha256.Sum256([]byte(Token))
+	return hex.EncodeToString(sum[:])
+}
+
+//	ValidAccessTokenScope(Scope string) bool
+/*	Verifies a scope : a space separated list of "read", "write", "<item>:read" or "<item>:write".
+	An empty scope is valid, and gives every permission of the owner.
+*/
+func ValidAccessTokenScope(Scope string) bool {
+	for _, part := range strings.Fields(Scope) {
+		item, access := "", part
+		index := strings.Index(part, ":")
+		if index >= 0 {
+			item, access = part[:index], part[index+1:]
+		}
+
+		if access != "read" && access != "write" {
+			return false
+		}
+
+		if index >= 0 {
+			known := false
+			for _, knownItem := range AccessTokenItems {
+				known = known || item == knownItem
+			}
+			if !known {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+//	AccessTokenScopeAllows(Scope string, Item string, Method string) bool
+/*	Tells wether a scope allows a request. GET and HEAD requests need a read access, the others a write access,
+	which includes the read access. Item is the first part of the path, like "schedules".
+*/
+func AccessTokenScopeAllows(Scope string, Item string, Method string) bool {
+	if strings.TrimSpace(Scope) == "" {
+		return true
+	}
+
+	readOnly := Method == http.MethodGet || Method == http.MethodHead
+
+	for _, part := range strings.Fields(Scope) {
+		if part == "write" || (Item != "" && part == Item+":write") {
+			return true
+		}
+		if readOnly && (part == "read" || (Item != "" && part == Item+":read")) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,53 @@
+package globals
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// LegacyAPIRules : How long the paths of the first version of the API are still answered at the root, without /v1.
+/*	Enabled : Wether the root paths still answer. Disabled, they are not found.
+	DeprecatedAt : When the root paths were deprecated, sent in the Deprecation header.
+	Sunset : When the root paths will stop answering, sent in the Sunset header.
+*/
+type LegacyAPIRules struct {
+	Enabled      bool
+	DeprecatedAt time.Time
+	Sunset       time.Time
+}
+
+//	DefaultLegacyAPIRules() LegacyAPIRules
+/*	Returns the rules used when nothing else is configured : the root paths answer until the 1st of april 2027.
+ */
+func DefaultLegacyAPIRules() LegacyAPIRules {
+	return LegacyAPIRules{
+		Enabled:      true,
+		DeprecatedAt: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		Sunset:       time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+//	LegacyAPIRulesFromEnvironment() LegacyAPIRules
+/*	Returns the default rules, changed by the environment variables LEGACY_API_ENABLED (true or false)
+	and LEGACY_API_SUNSET (a day like 2027-04-01, or an RFC 3339 date).
+*/
+func LegacyAPIRulesFromEnvironment() LegacyAPIRules {
+	rules := DefaultLegacyAPIRules()
+
+	if enabled, err := strconv.ParseBool(os.Getenv("LEGACY_API_ENABLED")); err == nil {
+		rules.Enabled = enabled
+	}
+
+	if sunset := os.Getenv("LEGACY_API_SUNSET"); sunset != "" {
+		if date, err := time.Parse(DayLayout, sunset); err == nil {
+			rules.Sunset = date
+		} else if date, err = ParseDate(sunset); err == nil {
+			rules.Sunset = date
+		} else {
+			Log.Warn("Unreadable date " + sunset + " in LEGACY_API_SUNSET")
+		}
+	}
+
+	return rules
+}
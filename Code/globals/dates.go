@@ -0,0 +1,157 @@
+package globals
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DateLayout : The layout of the dates exchanged with the clients : RFC 3339, with the offset of their zone.
+const DateLayout = time.RFC3339
+
+// DayLayout : The layout of the days given to the reports, which are days of the zone of the user.
+const DayLayout = "2006-01-02"
+
+// MonthLayout : The layout of the months given to the timesheets, like 2021-03.
+const MonthLayout = "2006-01"
+
+// ClockLayout : The layout of the hours of the templates of schedules, like 08:30.
+const ClockLayout = "15:04"
+
+// The zone used when neither the user nor his companies have one.
+const defaultTimeZoneName = "Europe/Paris"
+
+//	DefaultLocation() *time.Location
+/*	Returns the zone used when nothing else is configured, or UTC if the zones are not installed.
+ */
+func DefaultLocation() *time.Location {
+	if location, err := LoadTimeZone(defaultTimeZoneName); err == nil {
+		return location
+	}
+	return time.UTC
+}
+
+//	TimeZoneFromEnvironment() *time.Location
+/*	Returns the zone of the environment variable DEFAULT_TIME_ZONE, like "Europe/Paris", or the default one.
+ */
+func TimeZoneFromEnvironment() *time.Location {
+	if name := os.Getenv("DEFAULT_TIME_ZONE"); name != "" {
+		if location, err := LoadTimeZone(name); err == nil {
+			return location
+		}
+		Log.Warn("Unknown time zone " + name + " in DEFAULT_TIME_ZONE")
+	}
+	return DefaultLocation()
+}
+
+//	LoadTimeZone(Name string) (*time.Location, error)
+/*	Returns the IANA zone of a name, like "Europe/Paris" or "UTC".
+	The local zone of the server is refused, as it depends on where the API runs.
+*/
+func LoadTimeZone(Name string) (*time.Location, error) {
+	if Name == "" || Name == "Local" {
+		return nil, errors.New("not an IANA time zone : " + Name)
+	}
+	return time.LoadLocation(Name)
+}
+
+//	ParseDate(Value string) (time.Time, error)
+/*	Reads an RFC 3339 date given by a client, and returns it in UTC, as the dates are stored.
+ */
+func ParseDate(Value string) (time.Time, error) {
+	date, err := time.Parse(DateLayout, Value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date.UTC(), nil
+}
+
+//	FormatDate(Date time.Time, Location *time.Location) string
+/*	Writes a date for a client, in RFC 3339 with the offset of the zone, like 2021-03-28T03:00:00+02:00.
+ */
+func FormatDate(Date time.Time, Location *time.Location) string {
+	return Date.In(Location).Format(DateLayout)
+}
+
+//	StartOfDay(Date time.Time, Location *time.Location) time.Time
+/*	Returns the midnight that starts the day of a date, in a zone.
+ */
+func StartOfDay(Date time.Time, Location *time.Location) time.Time {
+	year, month, day := Date.In(Location).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, Location)
+}
+
+//	StartOfWeek(Date time.Time, Location *time.Location) time.Time
+/*	Returns the midnight that starts the week (on monday) of a date, in a zone.
+ */
+func StartOfWeek(Date time.Time, Location *time.Location) time.Time {
+	local := Date.In(Location)
+	year, month, day := local.Date()
+	return time.Date(year, month, day-(int(local.Weekday())+6)%7, 0, 0, 0, 0, Location)
+}
+
+//	AddDays(Date time.Time, Days int, Location *time.Location) time.Time
+/*	Moves a date by a number of days of the calendar of a zone, keeping its time of the day.
+	The days are not always 24 hours long : the day the clocks change lasts 23 or 25 hours.
+*/
+func AddDays(Date time.Time, Days int, Location *time.Location) time.Time {
+	return Date.In(Location).AddDate(0, 0, Days)
+}
+
+//	AtClock(Day time.Time, Clock string, Location *time.Location) (time.Time, error)
+/*	Returns the date at an hour like 08:30 on the day of a date in a zone.
+ */
+func AtClock(Day time.Time, Clock string, Location *time.Location) (time.Time, error) {
+	clock, err := time.Parse(ClockLayout, Clock)
+	if err != nil {
+		return time.Time{}, errors.New("must be an hour like 08:30")
+	}
+
+	year, month, day := Day.In(Location).Date()
+	return time.Date(year, month, day, clock.Hour(), clock.Minute(), 0, 0, Location), nil
+}
+
+//	ParseWeek(Value string, Location *time.Location) (time.Time, error)
+/*	Reads an ISO 8601 week, like 2021-W12, and returns the midnight that starts its monday in a zone.
+	The first week of a year is the one holding its first thursday, so it can start in december.
+*/
+func ParseWeek(Value string, Location *time.Location) (time.Time, error) {
+	var year, week int
+
+	if _, err := fmt.Sscanf(Value, "%4d-W%2d", &year, &week); err != nil || len(Value) != len("2006-W01") {
+		return time.Time{}, errors.New("the week must be written like 2021-W12")
+	}
+
+	// The 4th of january is always in the first week
+	monday := StartOfWeek(time.Date(year, time.January, 4, 12, 0, 0, 0, Location), Location).AddDate(0, 0, (week-1)*7)
+	if isoYear, isoWeek := monday.ISOWeek(); isoYear != year || isoWeek != week {
+		return time.Time{}, errors.New("the year " + fmt.Sprint(year) + " has no week " + fmt.Sprint(week))
+	}
+	return monday, nil
+}
+
+//	ParseMonth(Value string, Location *time.Location) (time.Time, error)
+/*	Reads a month, like 2021-03, and returns the midnight that starts its first day in a zone.
+ */
+func ParseMonth(Value string, Location *time.Location) (time.Time, error) {
+	month, err := time.ParseInLocation(MonthLayout, Value, Location)
+	if err != nil {
+		return time.Time{}, errors.New("the month must be written like 2021-03")
+	}
+	return month, nil
+}
+
+//	ParsePeriod(Value string, Location *time.Location) (time.Time, time.Time, error)
+/*	Reads a week like 2021-W12 or a month like 2021-03, and returns the midnights that start and end it in a zone.
+ */
+func ParsePeriod(Value string, Location *time.Location) (time.Time, time.Time, error) {
+	if strings.Contains(Value, "W") {
+		start, err := ParseWeek(Value, Location)
+		return start, AddDays(start, 7, Location), err
+	}
+
+	start, err := ParseMonth(Value, Location)
+	return start, start.AddDate(0, 1, 0), err
+}
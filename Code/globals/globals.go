@@ -3,6 +3,7 @@ package globals
 import (
 	"crypto/rand"
 	"errors"
+	"time"
 
 	"github.com/gorilla/schema"
 	_ "github.com/gorilla/schema"
@@ -10,9 +11,18 @@ import (
 )
 
 var (
-	Decoder      *schema.Decoder
-	Log          *logrus.Logger
-	TokenSignKey []byte
+	Decoder           *schema.Decoder
+	Log               *logrus.Logger
+	TokenSignKey      []byte
+	PasswordRules     PasswordPolicy
+	AccountThrottling ThrottlingRules
+	IPThrottling      ThrottlingRules
+	TOTPIssuer        string
+	SessionCookies    CookieRules
+	CORS              CORSRules
+	DefaultTimeZone   *time.Location
+	LegacyAPI         LegacyAPIRules
+	Timers            TimerRules
 )
 
 func Init() {
@@ -20,6 +30,15 @@ func Init() {
 
 	Decoder = schema.NewDecoder()
 	Log = logrus.New()
+	PasswordRules = DefaultPasswordPolicy()
+	AccountThrottling = DefaultAccountThrottling()
+	IPThrottling = DefaultIPThrottling()
+	TOTPIssuer = "Gestion TPS"
+	SessionCookies = DefaultCookieRules()
+	CORS = DefaultCORSRules()
+	DefaultTimeZone = DefaultLocation()
+	LegacyAPI = DefaultLegacyAPIRules()
+	Timers = DefaultTimerRules()
 
 	if TokenSignKey, err = GenSymmetricKey(64); err != nil {
 		panic(err)
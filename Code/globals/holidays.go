@@ -0,0 +1,96 @@
+package globals
+
+import (
+	"sort"
+	"time"
+)
+
+// The calendars of public holidays a company can follow.
+const (
+	HolidayCalendarFrance        = "FR"
+	HolidayCalendarAlsaceMoselle = "FR-ALSACE-MOSELLE"
+)
+
+// HolidayCalendars : The known calendars of public holidays.
+var HolidayCalendars = []string{HolidayCalendarFrance, HolidayCalendarAlsaceMoselle}
+
+// PublicHoliday : A public holiday of a calendar.
+/*	Day : The day, like 2021-05-13.
+	Name : The name of the holiday, like Ascension.
+*/
+type PublicHoliday struct {
+	Day  string
+	Name string
+}
+
+//	IsHolidayCalendar(Calendar string) bool
+/*	Returns whether a calendar of public holidays is known.
+ */
+func IsHolidayCalendar(Calendar string) bool {
+	for _, known := range HolidayCalendars {
+		if Calendar == known {
+			return true
+		}
+	}
+	return false
+}
+
+//	Easter(Year int) time.Time
+/*	Returns the easter sunday of a year of the gregorian calendar, at midnight UTC (the anonymous gregorian algorithm).
+ */
+func Easter(Year int) time.Time {
+	a := Year % 19
+	b, c := Year/100, Year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(Year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+//	PublicHolidays(Calendar string, Year int) []PublicHoliday
+/*	Returns the public holidays of a calendar during a year, sorted by day. An unknown calendar has none.
+	Alsace-Moselle adds good friday and saint stephen's day to the ones of France.
+*/
+func PublicHolidays(Calendar string, Year int) []PublicHoliday {
+	if !IsHolidayCalendar(Calendar) {
+		return []PublicHoliday{}
+	}
+
+	easter := Easter(Year)
+	fixed := func(Month time.Month, Day int) time.Time {
+		return time.Date(Year, Month, Day, 0, 0, 0, 0, time.UTC)
+	}
+
+	days := map[time.Time]string{
+		fixed(time.January, 1):   "Jour de l'an",
+		easter.AddDate(0, 0, 1):  "Lundi de Pâques",
+		fixed(time.May, 1):       "Fête du travail",
+		fixed(time.May, 8):       "Victoire 1945",
+		easter.AddDate(0, 0, 39): "Ascension",
+		easter.AddDate(0, 0, 50): "Lundi de Pentecôte",
+		fixed(time.July, 14):     "Fête nationale",
+		fixed(time.August, 15):   "Assomption",
+		fixed(time.November, 1):  "Toussaint",
+		fixed(time.November, 11): "Armistice 1918",
+		fixed(time.December, 25): "Noël",
+	}
+	if Calendar == HolidayCalendarAlsaceMoselle {
+		days[easter.AddDate(0, 0, -2)] = "Vendredi saint"
+		days[fixed(time.December, 26)] = "Saint Étienne"
+	}
+
+	holidays := []PublicHoliday{}
+	for day, name := range days {
+		holidays = append(holidays, PublicHoliday{Day: day.Format(DayLayout), Name: name})
+	}
+	sort.Slice(holidays, func(i, j int) bool { return holidays[i].Day < holidays[j].Day })
+
+	return holidays
+}
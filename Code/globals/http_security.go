@@ -0,0 +1,134 @@
+package globals
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CookieRules : How the session cookies are sent to the browsers.
+/*	Secure : Wether the cookies are only sent over HTTPS. It is only disabled to develop without TLS.
+	SameSite : Wether the browsers send the cookies with the requests coming from other sites.
+	Domain : The domain of the cookies, empty for the host of the API only.
+*/
+type CookieRules struct {
+	Secure   bool
+	SameSite http.SameSite
+	Domain   string
+}
+
+// CORSRules : Which web applications, served from other origins, can call the API from a browser.
+/*	AllowedOrigins : The origins allowed, like "https://tps.uca.fr". "*" allows every origin, but never with the credentials.
+	AllowedHeaders : The headers the browsers can send.
+	ExposedHeaders : The headers of the answers the web applications can read.
+	AllowCredentials : Wether the browsers send the cookies with the requests of the allowed origins.
+	MaxAge : How long, in seconds, the browsers can keep the answer of a preflight request.
+*/
+type CORSRules struct {
+	AllowedOrigins   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int
+}
+
+//	DefaultCookieRules() CookieRules
+/*	Returns the rules used when nothing else is configured.
+ */
+func DefaultCookieRules() CookieRules {
+	return CookieRules{
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+//	DefaultCORSRules() CORSRules
+/*	Returns the rules used when nothing else is configured : no other origin is allowed.
+ */
+func DefaultCORSRules() CORSRules {
+	return CORSRules{
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Request-Id"},
+		ExposedHeaders: []string{"Deprecation", "Link", "Retry-After", "Sunset", "X-Impersonated-By", "X-Impersonated-User", "X-Request-Id"},
+		MaxAge:         600,
+	}
+}
+
+//	CookieRulesFromEnvironment() CookieRules
+/*	Returns the default rules, changed by the environment variables COOKIE_SECURE (true or false),
+	COOKIE_SAMESITE (strict, lax or none) and COOKIE_DOMAIN.
+*/
+func CookieRulesFromEnvironment() CookieRules {
+	rules := DefaultCookieRules()
+
+	if secure, err := strconv.ParseBool(os.Getenv("COOKIE_SECURE")); err == nil {
+		rules.Secure = secure
+	}
+
+	switch strings.ToLower(os.Getenv("COOKIE_SAMESITE")) {
+	case "lax":
+		rules.SameSite = http.SameSiteLaxMode
+	case "none":
+		// The browsers refuse the cookies with SameSite=None that are not secure
+		rules.SameSite = http.SameSiteNoneMode
+		rules.Secure = true
+	}
+
+	rules.Domain = os.Getenv("COOKIE_DOMAIN")
+
+	return rules
+}
+
+//	CORSRulesFromEnvironment() CORSRules
+/*	Returns the default rules, changed by the environment variables CORS_ALLOWED_ORIGINS and CORS_ALLOWED_HEADERS
+	(comma separated lists), CORS_ALLOW_CREDENTIALS (true or false) and CORS_MAX_AGE (in seconds).
+*/
+func CORSRulesFromEnvironment() CORSRules {
+	rules := DefaultCORSRules()
+
+	if origins := splitList(os.Getenv("CORS_ALLOWED_ORIGINS")); len(origins) > 0 {
+		rules.AllowedOrigins = origins
+	}
+
+	if headers := splitList(os.Getenv("CORS_ALLOWED_HEADERS")); len(headers) > 0 {
+		rules.AllowedHeaders = headers
+	}
+
+	rules.AllowCredentials, _ = strconv.ParseBool(os.Getenv("CORS_ALLOW_CREDENTIALS"))
+
+	if maxAge, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE")); err == nil && maxAge >= 0 {
+		rules.MaxAge = maxAge
+	}
+
+	return rules
+}
+
+//	AllowedOrigin(Origin string) (bool, bool)
+/*	Tells wether an origin is allowed, and wether the credentials can be sent with its requests.
+	The credentials are only allowed to the origins that are explicitly listed.
+*/
+func (rules CORSRules) AllowedOrigin(Origin string) (bool, bool) {
+	if Origin == "" {
+		return false, false
+	}
+
+	allowed := false
+	for _, origin := range rules.AllowedOrigins {
+		if strings.EqualFold(origin, Origin) {
+			return true, rules.AllowCredentials
+		}
+		allowed = allowed || origin == "*"
+	}
+
+	return allowed, false
+}
+
+func splitList(List string) []string {
+	var items []string
+	for _, item := range strings.Split(List, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,101 @@
+package globals
+
+import (
+	"time"
+)
+
+// ThrottlingRules : Defines how the failed login attempts are slowed down.
+/*	FreeAttempts : The number of failed attempts allowed before the back-off starts.
+	BaseDelay : The delay to wait after the first attempt that is not free. It doubles after each new failure.
+	MaxDelay : The maximum delay of the back-off.
+	LockoutAttempts : The number of failed attempts that locks the account or the address. 0 means no lockout.
+	LockoutDuration : How long a lockout lasts.
+	ResetAfter : The failures are forgotten when nothing happened during this duration.
+*/
+type ThrottlingRules struct {
+	FreeAttempts    int64
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAttempts int64
+	LockoutDuration time.Duration
+	ResetAfter      time.Duration
+}
+
+//	DefaultAccountThrottling() ThrottlingRules
+/*	Returns the rules applied to the failed attempts on a same account.
+ */
+func DefaultAccountThrottling() ThrottlingRules {
+	return ThrottlingRules{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		LockoutAttempts: 10,
+		LockoutDuration: 30 * time.Minute,
+		ResetAfter:      time.Hour,
+	}
+}
+
+//	DefaultIPThrottling() ThrottlingRules
+/*	Returns the rules applied to the failed attempts coming from a same address.
+	They are looser than the account ones, as several users can share an address.
+*/
+func DefaultIPThrottling() ThrottlingRules {
+	return ThrottlingRules{
+		FreeAttempts:    20,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAttempts: 100,
+		LockoutDuration: time.Hour,
+		ResetAfter:      time.Hour,
+	}
+}
+
+//	Wait(FailedAttempts int64, LastFailure time.Time, LockedUntil time.Time, Now time.Time) time.Duration
+/*	This method computes how long one has to wait before trying to log in again.
+	Returns 0 if a new attempt is allowed right now.
+*/
+func (rules ThrottlingRules) Wait(FailedAttempts int64, LastFailure time.Time, LockedUntil time.Time, Now time.Time) time.Duration {
+	// Locked
+	if LockedUntil.After(Now) {
+		return LockedUntil.Sub(Now)
+	}
+
+	// The failures are too old to matter
+	if rules.Expired(LastFailure, LockedUntil, Now) || FailedAttempts < rules.FreeAttempts {
+		return 0
+	}
+
+	// Exponential back-off
+	delay := rules.BaseDelay
+	for i := rules.FreeAttempts; i < FailedAttempts && delay < rules.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > rules.MaxDelay {
+		delay = rules.MaxDelay
+	}
+
+	if next := LastFailure.Add(delay); next.After(Now) {
+		return next.Sub(Now)
+	}
+
+	return 0
+}
+
+//	Expired(LastFailure time.Time, LockedUntil time.Time, Now time.Time) bool
+/*	This method tells wether the previous failures must be forgotten,
+	either because they are old enough or because the lockout they caused is over.
+*/
+func (rules ThrottlingRules) Expired(LastFailure time.Time, LockedUntil time.Time, Now time.Time) bool {
+	if !LockedUntil.IsZero() && !LockedUntil.After(Now) {
+		return true
+	}
+
+	return Now.Sub(LastFailure) > rules.ResetAfter
+}
+
+//	Locks(FailedAttempts int64) bool
+/*	This method tells wether the given number of failed attempts triggers a lockout.
+ */
+func (rules ThrottlingRules) Locks(FailedAttempts int64) bool {
+	return rules.LockoutAttempts > 0 && FailedAttempts >= rules.LockoutAttempts
+}
@@ -0,0 +1,162 @@
+package globals
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy : The rules a password has to follow to be accepted.
+/*	MinLength : The minimum number of characters of the password.
+	RequireLower : Wether the password must contain a lowercase letter.
+	RequireUpper : Wether the password must contain an uppercase letter.
+	RequireDigit : Wether the password must contain a digit.
+	RequireSpecial : Wether the password must contain a character that is neither a letter nor a digit.
+	Denylist : Common passwords that are refused, whatever the other rules say. The comparison ignores the case.
+*/
+type PasswordPolicy struct {
+	MinLength      int
+	RequireLower   bool
+	RequireUpper   bool
+	RequireDigit   bool
+	RequireSpecial bool
+	Denylist       []string
+}
+
+// The most common passwords, that are refused by the default policy.
+var commonPasswords = []string{
+	"123456", "123456789", "12345678", "1234567890", "password", "password1", "password123",
+	"azerty", "azerty123", "azertyuiop", "qwerty", "qwerty123", "qwertyuiop", "motdepasse",
+	"admin", "admin123", "administrator", "superadmin", "welcome", "welcome1", "letmein",
+	"iloveyou", "soleil", "bonjour", "changeme", "passw0rd", "p@ssw0rd", "p@ssword1",
+}
+
+//	DefaultPasswordPolicy() PasswordPolicy
+/*	Returns the policy used when nothing else is configured.
+ */
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:      10,
+		RequireLower:   true,
+		RequireUpper:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+		Denylist:       commonPasswords,
+	}
+}
+
+//	PasswordPolicyFromEnvironment() PasswordPolicy
+/*	Returns the default policy, changed by the environment variables PASSWORD_MIN_LENGTH, PASSWORD_REQUIRED_CLASSES
+	(a comma separated list of lower, upper, digit and special, or none) and PASSWORD_DENYLIST_FILE (a file of common
+	passwords, one per line, refused along with the default ones).
+*/
+func PasswordPolicyFromEnvironment() PasswordPolicy {
+	policy := DefaultPasswordPolicy()
+
+	if value := os.Getenv("PASSWORD_MIN_LENGTH"); value != "" {
+		if length, err := strconv.Atoi(value); err == nil && length > 0 {
+			policy.MinLength = length
+		} else {
+			Log.Warn("Unreadable length " + value + " in PASSWORD_MIN_LENGTH")
+		}
+	}
+
+	if value := os.Getenv("PASSWORD_REQUIRED_CLASSES"); value != "" {
+		policy.RequireLower, policy.RequireUpper, policy.RequireDigit, policy.RequireSpecial = false, false, false, false
+		for _, class := range splitList(strings.ToLower(value)) {
+			switch class {
+			case "lower":
+				policy.RequireLower = true
+			case "upper":
+				policy.RequireUpper = true
+			case "digit":
+				policy.RequireDigit = true
+			case "special":
+				policy.RequireSpecial = true
+			case "none":
+			default:
+				Log.Warn("Unknown class " + class + " in PASSWORD_REQUIRED_CLASSES")
+			}
+		}
+	}
+
+	if file := os.Getenv("PASSWORD_DENYLIST_FILE"); file != "" {
+		if content, err := ioutil.ReadFile(file); err == nil {
+			denylist := append([]string{}, commonPasswords...)
+			for _, line := range strings.Split(string(content), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					denylist = append(denylist, line)
+				}
+			}
+			policy.Denylist = denylist
+		} else {
+			Log.Warn("Unreadable file " + file + " in PASSWORD_DENYLIST_FILE : " + err.Error())
+		}
+	}
+
+	return policy
+}
+
+//	Check(Password string, Username string, Mail string) []string
+/*	This method verifies that a password follows the policy.
+	The username and the mail of the user are given so the password can't be one of them.
+	Returns the list of the rules that are not respected, or an empty list if the password is valid.
+*/
+func (policy PasswordPolicy) Check(Password string, Username string, Mail string) []string {
+	var (
+		violations []string
+		hasLower   bool
+		hasUpper   bool
+		hasDigit   bool
+		hasSpecial bool
+	)
+
+	for _, char := range Password {
+		switch {
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		default:
+			hasSpecial = true
+		}
+	}
+
+	if len([]rune(Password)) < policy.MinLength {
+		violations = append(violations, "The password must contain at least "+strconv.Itoa(policy.MinLength)+" characters")
+	}
+	if policy.RequireLower && !hasLower {
+		violations = append(violations, "The password must contain a lowercase letter")
+	}
+	if policy.RequireUpper && !hasUpper {
+		violations = append(violations, "The password must contain an uppercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		violations = append(violations, "The password must contain a digit")
+	}
+	if policy.RequireSpecial && !hasSpecial {
+		violations = append(violations, "The password must contain a special character")
+	}
+
+	// The password can't be one of the common passwords
+	for _, common := range policy.Denylist {
+		if strings.EqualFold(Password, common) {
+			violations = append(violations, "The password is too common")
+			break
+		}
+	}
+
+	// Nor can it be the username or the mail of the user
+	mailName := strings.Split(Mail, "@")[0]
+	if (Username != "" && strings.EqualFold(Password, Username)) ||
+		(Mail != "" && strings.EqualFold(Password, Mail)) ||
+		(mailName != "" && strings.EqualFold(Password, mailName)) {
+		violations = append(violations, "The password can't be the username or the mail")
+	}
+
+	return violations
+}
@@ -0,0 +1,338 @@
+package globals
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The frequencies of the recurrence rules.
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+// SeriesHorizon : How long after now the occurrences of the recurring schedules are saved as schedules.
+var SeriesHorizon = 90 * 24 * time.Hour
+
+// The layouts of the UNTIL part of the rules : a UTC date, a date of the zone of the series, or a day.
+const (
+	untilUTCLayout   = "20060102T150405Z"
+	untilLocalLayout = "20060102T150405"
+	untilDayLayout   = "20060102"
+)
+
+// The most occurrences a rule can give before it stops, so a wrong rule can't run forever.
+const maxOccurrences = 5000
+
+// The days of the week of the BYDAY part, from monday as the weeks start on monday.
+var recurrenceDays = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+// Recurrence : A recurrence rule of RFC 5545, like FREQ=WEEKLY;BYDAY=MO,TU;UNTIL=20210630T000000Z.
+// Only the parts used by the schedules are read : the yearly rules, the positions and the hours are refused.
+/*	Frequency : DAILY, WEEKLY or MONTHLY.
+	Interval : Every how many days, weeks or months the rule repeats. 1 by default.
+	Count : How many occurrences the rule gives, the first one included. 0 if not limited.
+	Until : The last date an occurrence can start at. Zero if not limited.
+	ByDay : The days of the week of the occurrences, as time.Weekday. By default, every day for the daily rules,
+		and the day of the first occurrence for the weekly ones.
+	ByMonthDay : The days of the month of the monthly rules, negative from the end of the month.
+		By default, every day of ByDay, or the day of the month of the first occurrence.
+*/
+type Recurrence struct {
+	Frequency  string
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []time.Weekday
+	ByMonthDay []int
+}
+
+//	ParseRecurrence(Value string, Location *time.Location) (Recurrence, error)
+/*	Reads a recurrence rule, with or without its RRULE: prefix. An UNTIL without zone is read in a zone,
+	and an UNTIL day includes the whole day.
+*/
+func ParseRecurrence(Value string, Location *time.Location) (Recurrence, error) {
+	rule := Recurrence{Interval: 1}
+
+	Value = strings.TrimPrefix(strings.TrimSpace(Value), "RRULE:")
+	if Value == "" {
+		return Recurrence{}, errors.New("is required")
+	}
+
+	for _, part := range strings.Split(Value, ";") {
+		pair := strings.SplitN(part, "=", 2)
+		if len(pair) != 2 || pair[1] == "" {
+			return Recurrence{}, errors.New("can't read " + part)
+		}
+		name, value := strings.ToUpper(pair[0]), strings.ToUpper(pair[1])
+
+		var err error
+		switch name {
+		case "FREQ":
+			if value != Daily && value != Weekly && value != Monthly {
+				return Recurrence{}, errors.New("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+			rule.Frequency = value
+		case "INTERVAL":
+			if rule.Interval, err = strconv.Atoi(value); err != nil || rule.Interval < 1 {
+				return Recurrence{}, errors.New("INTERVAL must be a positive number")
+			}
+		case "COUNT":
+			if rule.Count, err = strconv.Atoi(value); err != nil || rule.Count < 1 {
+				return Recurrence{}, errors.New("COUNT must be a positive number")
+			}
+		case "UNTIL":
+			if rule.Until, err = parseUntil(value, Location); err != nil {
+				return Recurrence{}, err
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := recurrenceDay(day)
+				if !ok {
+					return Recurrence{}, errors.New("BYDAY must be days like MO,TU, without position")
+				}
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return Recurrence{}, errors.New("BYMONTHDAY must be days from 1 to 31, or from -31 to -1")
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		case "WKST":
+			if value != "MO" {
+				return Recurrence{}, errors.New("WKST can only be MO")
+			}
+		default:
+			return Recurrence{}, errors.New(name + " is not supported")
+		}
+	}
+
+	switch {
+	case rule.Frequency == "":
+		return Recurrence{}, errors.New("FREQ is required")
+	case rule.Count > 0 && !rule.Until.IsZero():
+		return Recurrence{}, errors.New("COUNT and UNTIL can't be both given")
+	case len(rule.ByMonthDay) > 0 && rule.Frequency != Monthly:
+		return Recurrence{}, errors.New("BYMONTHDAY is only for the MONTHLY rules")
+	}
+
+	return rule, nil
+}
+
+//	String() string
+/*	Writes the rule back, with its UNTIL in UTC.
+ */
+func (rule Recurrence) String() string {
+	parts := []string{"FREQ=" + rule.Frequency}
+	if rule.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(rule.Interval))
+	}
+	if rule.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(rule.Count))
+	}
+	if !rule.Until.IsZero() {
+		parts = append(parts, "UNTIL="+rule.Until.UTC().Format(untilUTCLayout))
+	}
+	if len(rule.ByDay) > 0 {
+		days := []string{}
+		for _, weekday := range rule.ByDay {
+			days = append(days, recurrenceDays[(int(weekday)+6)%7])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(rule.ByMonthDay) > 0 {
+		days := []string{}
+		for _, monthDay := range rule.ByMonthDay {
+			days = append(days, strconv.Itoa(monthDay))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+//	Occurrences(Start time.Time, Location *time.Location, Exceptions []time.Time, From time.Time, To time.Time) []time.Time
+/*	Returns the starts of the occurrences of a rule whose first occurrence starts at a date, from a date (included)
+	to another one (excluded), without the exceptions (the EXDATEs). The rule is followed in a zone, so the occurrences
+	keep the hour of the first one when the clocks change. The first occurrence always counts, as in RFC 5545.
+*/
+func (rule Recurrence) Occurrences(Start time.Time, Location *time.Location, Exceptions []time.Time, From time.Time, To time.Time) []time.Time {
+	occurrences := []time.Time{}
+
+	excluded := map[int64]bool{}
+	for _, exception := range Exceptions {
+		excluded[exception.Unix()] = true
+	}
+
+	local := Start.In(Location)
+	year, month, day := local.Date()
+	hour, minute, second := local.Clock()
+	at := func(Year int, Month time.Month, Day int) time.Time {
+		return time.Date(Year, Month, Day, hour, minute, second, 0, Location)
+	}
+
+	count := 0
+	keep := func(Date time.Time) bool {
+		if Date.Before(Start) || (count > 0 && !Date.After(Start)) {
+			return true
+		}
+		if (rule.Count > 0 && count >= rule.Count) || (!rule.Until.IsZero() && Date.After(rule.Until)) || !Date.Before(To) || count >= maxOccurrences {
+			return false
+		}
+		count++
+		if !Date.Before(From) && !excluded[Date.Unix()] {
+			occurrences = append(occurrences, Date)
+		}
+		return true
+	}
+
+	// The first occurrence, even if the rule would not give it
+	if !keep(Start) {
+		return occurrences
+	}
+
+	for step := 0; ; step++ {
+		var periodStart time.Time
+		candidates := []time.Time{}
+
+		// The day, the week from monday or the month of the step
+		switch rule.Frequency {
+		case Daily:
+			periodStart = at(year, month, day+step*rule.Interval)
+			if rule.hasDay(periodStart.Weekday()) {
+				candidates = append(candidates, periodStart)
+			}
+		case Weekly:
+			monday := day - (int(local.Weekday())+6)%7 + step*7*rule.Interval
+			periodStart = at(year, month, monday)
+			for offset := 0; offset < 7; offset++ {
+				date := at(year, month, monday+offset)
+				if (len(rule.ByDay) == 0 && date.Weekday() == local.Weekday()) || (len(rule.ByDay) > 0 && rule.hasDay(date.Weekday())) {
+					candidates = append(candidates, date)
+				}
+			}
+		case Monthly:
+			periodStart = at(year, month+time.Month(step*rule.Interval), 1)
+			daysInMonth := at(periodStart.Year(), periodStart.Month()+1, 0).Day()
+			for monthDay := 1; monthDay <= daysInMonth; monthDay++ {
+				date := at(periodStart.Year(), periodStart.Month(), monthDay)
+				if rule.hasMonthDay(monthDay, daysInMonth, day) && rule.hasDay(date.Weekday()) {
+					candidates = append(candidates, date)
+				}
+			}
+		default:
+			return occurrences
+		}
+
+		if !periodStart.Before(To) || (!rule.Until.IsZero() && periodStart.After(rule.Until)) {
+			return occurrences
+		}
+
+		for _, candidate := range candidates {
+			if !keep(candidate) {
+				return occurrences
+			}
+		}
+	}
+}
+
+//	CountBefore(Start time.Time, Location *time.Location, To time.Time) int
+/*	Returns how many occurrences of a rule start before a date, the exceptions included as they count in COUNT.
+ */
+func (rule Recurrence) CountBefore(Start time.Time, Location *time.Location, To time.Time) int {
+	return len(rule.Occurrences(Start, Location, nil, Start, To))
+}
+
+//	Shift(Days int) Recurrence
+/*	Returns the rule whose BYDAY and BYMONTHDAY parts move by a number of days, as when its occurrences move
+	to another day. A day of the month that goes past an end of the month is counted from the other end.
+*/
+func (rule Recurrence) Shift(Days int) Recurrence {
+	shifted := rule
+	shifted.ByDay, shifted.ByMonthDay = nil, nil
+
+	for _, weekday := range rule.ByDay {
+		shifted.ByDay = append(shifted.ByDay, time.Weekday(((int(weekday)+Days)%7+7)%7))
+	}
+	for _, monthDay := range rule.ByMonthDay {
+		moved := monthDay + Days
+		switch {
+		case monthDay > 0 && moved < 1:
+			moved--
+		case monthDay > 0 && moved > 31:
+			moved -= 31
+		case monthDay < 0 && moved > -1:
+			moved++
+		case monthDay < 0 && moved < -31:
+			moved += 31
+		}
+		shifted.ByMonthDay = append(shifted.ByMonthDay, moved)
+	}
+
+	return shifted
+}
+
+//	hasDay(Weekday time.Weekday) bool
+/*	Tells wether the BYDAY part of a rule allows a day of the week. Every day is allowed without BYDAY.
+ */
+func (rule Recurrence) hasDay(Weekday time.Weekday) bool {
+	if len(rule.ByDay) == 0 {
+		return true
+	}
+	for _, day := range rule.ByDay {
+		if day == Weekday {
+			return true
+		}
+	}
+	return false
+}
+
+//	hasMonthDay(MonthDay int, DaysInMonth int, FirstDay int) bool
+/*	Tells wether the BYMONTHDAY part of a monthly rule allows a day of a month. Without it, the rule gives
+	every day of BYDAY, or the day of the month of the first occurrence.
+*/
+func (rule Recurrence) hasMonthDay(MonthDay int, DaysInMonth int, FirstDay int) bool {
+	if len(rule.ByMonthDay) == 0 {
+		return len(rule.ByDay) > 0 || MonthDay == FirstDay
+	}
+	for _, wanted := range rule.ByMonthDay {
+		if wanted == MonthDay || DaysInMonth+wanted+1 == MonthDay {
+			return true
+		}
+	}
+	return false
+}
+
+//	recurrenceDay(Value string) (time.Weekday, bool)
+/*	Reads a day of the BYDAY part, like MO.
+ */
+func recurrenceDay(Value string) (time.Weekday, bool) {
+	for i, day := range recurrenceDays {
+		if day == Value {
+			return time.Weekday((i + 1) % 7), true
+		}
+	}
+	return time.Sunday, false
+}
+
+//	parseUntil(Value string, Location *time.Location) (time.Time, error)
+/*	Reads the UNTIL part of a rule : a UTC date like 20210630T170000Z, a date of the zone like 20210630T170000,
+	or a day like 20210630, which includes the whole day.
+*/
+func parseUntil(Value string, Location *time.Location) (time.Time, error) {
+	if until, err := time.Parse(untilUTCLayout, Value); err == nil {
+		return until, nil
+	}
+	if until, err := time.ParseInLocation(untilLocalLayout, Value, Location); err == nil {
+		return until, nil
+	}
+	if until, err := time.ParseInLocation(untilDayLayout, Value, Location); err == nil {
+		return AddDays(until, 1, Location).Add(-time.Second), nil
+	}
+	return time.Time{}, errors.New("UNTIL must be a date like 20210630T170000Z, or a day like 20210630")
+}
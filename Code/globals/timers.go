@@ -0,0 +1,69 @@
+package globals
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimerRules : How the running timers of the users are handled.
+/*	AutoStop : Wether the timers forgotten by the users are stopped automatically.
+	AutoStopHour : The hour of the day, in the zone of the user, at which a forgotten timer is stopped.
+	AutoStopCheck : How often the forgotten timers are looked for.
+*/
+type TimerRules struct {
+	AutoStop      bool
+	AutoStopHour  int
+	AutoStopCheck time.Duration
+}
+
+//	DefaultTimerRules() TimerRules
+/*	Returns the rules used when nothing else is configured : the forgotten timers are stopped at 8 PM.
+ */
+func DefaultTimerRules() TimerRules {
+	return TimerRules{
+		AutoStop:      true,
+		AutoStopHour:  20,
+		AutoStopCheck: time.Minute,
+	}
+}
+
+//	TimerRulesFromEnvironment() TimerRules
+/*	Returns the default rules, changed by the environment variable TIMER_AUTO_STOP_HOUR :
+	an hour from 0 to 23, or "none" so the timers run until the users stop them.
+*/
+func TimerRulesFromEnvironment() TimerRules {
+	rules := DefaultTimerRules()
+
+	if value := os.Getenv("TIMER_AUTO_STOP_HOUR"); value != "" {
+		if strings.EqualFold(value, "none") {
+			rules.AutoStop = false
+		} else if hour, err := strconv.Atoi(value); err == nil && hour >= 0 && hour <= 23 {
+			rules.AutoStopHour = hour
+		} else {
+			Log.Warn("Unreadable hour " + value + " in TIMER_AUTO_STOP_HOUR")
+		}
+	}
+
+	return rules
+}
+
+//	AutoStopDate(Start time.Time, Location *time.Location) (time.Time, bool)
+/*	Returns when a timer started at a date is stopped if it is forgotten : the first time the clocks of the zone
+	show the hour of the rules after the start. Returns false if the timers are never stopped automatically.
+*/
+func (rules TimerRules) AutoStopDate(Start time.Time, Location *time.Location) (time.Time, bool) {
+	if !rules.AutoStop {
+		return time.Time{}, false
+	}
+
+	local := Start.In(Location)
+	year, month, day := local.Date()
+	stop := time.Date(year, month, day, rules.AutoStopHour, 0, 0, 0, Location)
+	if !stop.After(Start) {
+		stop = time.Date(year, month, day+1, rules.AutoStopHour, 0, 0, 0, Location)
+	}
+
+	return stop, true
+}
@@ -0,0 +1,143 @@
+package globals
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The parameters of the TOTP codes (RFC 6238) : the ones every authenticator application supports.
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+	// The number of periods accepted before and after the current one, to allow clock drifts.
+	TOTPSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+//	GenerateTOTPSecret() (string, error)
+/*	Generates a new random secret, encoded in base32 as authenticator applications expect it.
+ */
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+//	TOTPCode(Secret string, Step int64) (string, error)
+/*	Computes the code of the given time step (RFC 4226 and RFC 6238, with HMAC-SHA1).
+ */
+func TOTPCode(Secret string, Step int64) (string, error) {
+	var (
+		key     []byte
+		err     error
+		message [8]byte
+	)
+
+	if key, err = totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(Secret, "="))); err != nil {
+		return "", err
+	}
+
+	binary.BigEndian.PutUint64(message[:], uint64(Step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	code := make([]byte, TOTPDigits)
+	for i := TOTPDigits - 1; i >= 0; i-- {
+		code[i] = byte('0' + value%10)
+		value /= 10
+	}
+
+	return string(code), nil
+}
+
+//	TOTPStep(Time time.Time) int64
+/*	Returns the time step a date belongs to.
+ */
+func TOTPStep(Time time.Time) int64 {
+	return Time.Unix() / int64(TOTPPeriod/time.Second)
+}
+
+//	MatchTOTPCode(Secret string, Code string, Now time.Time) (int64, bool)
+/*	Verifies a code given by a user.
+	Returns the time step the code belongs to, so that a code can't be used twice.
+*/
+func MatchTOTPCode(Secret string, Code string, Now time.Time) (int64, bool) {
+	Code = strings.TrimSpace(Code)
+	current := TOTPStep(Now)
+
+	for step := current - TOTPSkew; step <= current+TOTPSkew; step++ {
+		expected, err := TOTPCode(Secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(Code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+//	TOTPProvisioningURI(Issuer string, Account string, Secret string) string
+/*	Returns the otpauth:// URI authenticator applications use to register a secret, usually shown as a QR code.
+ */
+func TOTPProvisioningURI(Issuer string, Account string, Secret string) string {
+	values := url.Values{}
+	values.Set("secret", Secret)
+	values.Set("issuer", Issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", "6")
+	values.Set("period", "30")
+
+	return "otpauth://totp/" + url.PathEscape(Issuer+":"+Account) + "?" + values.Encode()
+}
+
+//	GenerateRecoveryCodes(Count int) ([]string, error)
+/*	Generates single-use codes a user can give instead of a TOTP code when he lost his device.
+ */
+func GenerateRecoveryCodes(Count int) ([]string, error) {
+	if Count <= 0 {
+		return nil, errors.New("Recovery codes count error")
+	}
+
+	codes := make([]string, Count)
+	for index := range codes {
+		random := make([]byte, 5)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(totpEncoding.EncodeToString(random))
+		codes[index] = code[:4] + "-" + code[4:]
+	}
+
+	return codes, nil
+}
+
+//	HashRecoveryCode(Code string) string
+/*	Returns the hash of a recovery code, as they are stored.
+	The codes are random enough for SHA-256 to be used instead of bcrypt.
+*/
+func HashRecoveryCode(Code string) string {
+	normalized := strings.ToLower(strings.Replace(strings.TrimSpace(Code), "-", "", -1))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,263 @@
+package handler_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// Sends a request authenticated with an API token and returns its result
+func sendTokenRequest(t *testing.T, Method string, URL string, Body interface{}, Token string) *httptest.ResponseRecorder {
+	var (
+		err        error
+		request    *http.Request
+		jsonObject []byte
+	)
+
+	if Body != nil {
+		if jsonObject, err = json.Marshal(Body); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if request, err = http.NewRequest(Method, URL, bytes.NewBuffer(jsonObject)); err != nil {
+		t.Error(err)
+	}
+	request.Header.Set("Authorization", "Bearer "+Token)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+
+	return rr
+}
+
+// Creates an API token for the admin and returns it
+func createAccessToken(t *testing.T, Form handlers.AccessTokenRequest) handlers.AccessTokenCreated {
+	var created handlers.AccessTokenCreated
+
+	rr := sendRequest(t, http.MethodPost, "/users/1/tokens", Form, tokenCookie)
+	if rr.Code != http.StatusOK {
+		t.Fatal("Could not create an API token :", rr.Code, rr.Body.String())
+	}
+
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	return created
+}
+
+/*
+	TESTED : POST /users/{id}/tokens
+	TESTED : GET /users/{id}/tokens
+	TESTED : DELETE /users/{id}/tokens/{token_id}
+	TESTED : Requests authenticated with an API token
+*/
+func TestAccessTokenHandler(t *testing.T) {
+	var (
+		err    error
+		tokens model.AccessTokens
+		rr     *httptest.ResponseRecorder
+	)
+
+	//
+	//	POST /users/{id}/tokens
+	//
+	readToken := createAccessToken(t, handlers.AccessTokenRequest{Name: "Payroll", Scope: "read"})
+
+	if readToken.Token == "" || readToken.AccessTokenId == 0 || readToken.UserId != 1 {
+		t.Error("Wrong API token :", readToken)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/users/1/tokens", handlers.AccessTokenRequest{Name: "Wrong", Scope: "everything"}, tokenCookie); rr.Code != http.StatusUnprocessableEntity {
+		t.Error("An API token was created with an invalid scope")
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if rr = sendRequest(t, http.MethodPost, "/users/1/tokens", handlers.AccessTokenRequest{Name: "Expired", ExpiresAt: &past}, tokenCookie); rr.Code != http.StatusUnprocessableEntity {
+		t.Error("An expired API token was created")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/users/2/tokens", handlers.AccessTokenRequest{Name: "Not mine"}, tokenCookie); rr.Code != http.StatusForbidden {
+		t.Error("An API token was created for another user")
+	}
+
+	globals.Log.Debug("POST /users/{id}/tokens - PASSED")
+
+	//
+	//	Requests with an API token
+	//
+	if rr = sendTokenRequest(t, http.MethodGet, "/projects", nil, readToken.Token); rr.Code != http.StatusOK {
+		t.Error("Could not read with an API token :", rr.Code)
+	}
+
+	// The scope is read only
+	if rr = sendTokenRequest(t, http.MethodPost, "/projects", model.Project{ProjectName: "Token project"}, readToken.Token); rr.Code != http.StatusForbidden {
+		t.Error("A read only API token could write")
+	}
+
+	// A token can't create other tokens
+	if rr = sendTokenRequest(t, http.MethodPost, "/users/1/tokens", handlers.AccessTokenRequest{Name: "Child"}, readToken.Token); rr.Code != http.StatusForbidden {
+		t.Error("An API token created another token")
+	}
+
+	// A token limited to an item
+	projectsToken := createAccessToken(t, handlers.AccessTokenRequest{Name: "Projects", Scope: "projects:read"})
+
+	if rr = sendTokenRequest(t, http.MethodGet, "/projects", nil, projectsToken.Token); rr.Code != http.StatusOK {
+		t.Error("Could not read the item of the scope :", rr.Code)
+	}
+
+	if rr = sendTokenRequest(t, http.MethodGet, "/companies", nil, projectsToken.Token); rr.Code != http.StatusForbidden {
+		t.Error("An API token read an item out of its scope")
+	}
+
+	// The routes of a user need the scope of what they are about
+	schedulesToken := createAccessToken(t, handlers.AccessTokenRequest{Name: "Schedules", Scope: "schedules:read"})
+	for _, url := range []string{"/users/1/schedules", "/me/schedules", "/me/timer", "/users/1/overlaps"} {
+		if rr = sendTokenRequest(t, http.MethodGet, url, nil, schedulesToken.Token); rr.Code == http.StatusForbidden {
+			t.Error("Could not read the schedules of a user :", url, rr.Code)
+		}
+	}
+	for _, url := range []string{"/users/1/vacations", "/me/timesheets?week=2021-W10", "/me/balance", "/users/1/report?from=2021-03-01&to=2021-03-07", "/me"} {
+		if rr = sendTokenRequest(t, http.MethodGet, url, nil, schedulesToken.Token); rr.Code != http.StatusForbidden {
+			t.Error("An API token read an item of a user out of its scope :", url, rr.Code)
+		}
+	}
+
+	usersToken := createAccessToken(t, handlers.AccessTokenRequest{Name: "Users", Scope: "users:write"})
+	if rr = sendTokenRequest(t, http.MethodGet, "/me/schedules", nil, usersToken.Token); rr.Code != http.StatusForbidden {
+		t.Error("The users scope gave the schedules of a user :", rr.Code)
+	}
+	if rr = sendTokenRequest(t, http.MethodPost, "/me/timer/stop", nil, usersToken.Token); rr.Code != http.StatusForbidden {
+		t.Error("The users scope stopped a timer :", rr.Code)
+	}
+	if rr = sendTokenRequest(t, http.MethodPost, "/me/timesheets/2021-W10/submit", nil, usersToken.Token); rr.Code != http.StatusForbidden {
+		t.Error("The users scope submitted a timesheet :", rr.Code)
+	}
+	if rr = sendTokenRequest(t, http.MethodGet, "/users/1/export", nil, usersToken.Token); rr.Code != http.StatusForbidden || errorCode(rr) != handlers.ErrorDelegatedSessionRefused {
+		t.Error("The personal data were exported with an API token :", rr.Code)
+	}
+	if rr = sendTokenRequest(t, http.MethodGet, "/me", nil, usersToken.Token); rr.Code != http.StatusOK {
+		t.Error("Could not read the user of the scope :", rr.Code)
+	}
+
+	// Unknown and expired tokens are refused
+	if rr = sendTokenRequest(t, http.MethodGet, "/projects", nil, globals.AccessTokenPrefix+"unknown"); rr.Code != http.StatusUnauthorized {
+		t.Error("An unknown API token was accepted")
+	}
+
+	expiredToken, _ := globals.GenerateAccessToken()
+	expiredId, err := env.DB.CreateAccessToken(model.AccessToken{
+		UserId:    1,
+		Name:      "Expired",
+		TokenHash: globals.HashAccessToken(expiredToken),
+		Prefix:    expiredToken[:10],
+		CreatedAt: past.Add(-time.Hour),
+		ExpiresAt: &past,
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if rr = sendTokenRequest(t, http.MethodGet, "/projects", nil, expiredToken); rr.Code != http.StatusUnauthorized {
+		t.Error("An expired API token was accepted")
+	}
+
+	// A token never has more permissions than its owner
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "TokenUser@mydb",
+		Password:   "!",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Error(err)
+	}
+
+	userToken, _ := globals.GenerateAccessToken()
+	userTokenId, err := env.DB.CreateAccessToken(model.AccessToken{
+		UserId:    user.UserId,
+		Name:      "Full scope",
+		TokenHash: globals.HashAccessToken(userToken),
+		Prefix:    userToken[:10],
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Error(err)
+	}
+
+	if rr = sendTokenRequest(t, http.MethodDelete, "/users/"+strconv.FormatInt(user.UserId, 10), nil, userToken); rr.Code != http.StatusForbidden {
+		t.Error("An API token had more permissions than its owner")
+	}
+
+	globals.Log.Debug("Requests with an API token - PASSED")
+
+	//
+	//	GET /users/{id}/tokens
+	//
+	if rr = sendRequest(t, http.MethodGet, "/users/1/tokens", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not list the API tokens")
+	}
+
+	if bytes.Contains(rr.Body.Bytes(), []byte(globals.HashAccessToken(readToken.Token))) || bytes.Contains(rr.Body.Bytes(), []byte(readToken.Token)) {
+		t.Error("The list of the API tokens contains the tokens")
+	}
+
+	if err = json.NewDecoder(rr.Body).Decode(&tokens); err != nil {
+		t.Error(err)
+	}
+
+	if len(tokens) != 5 {
+		t.Error("Wrong number of API tokens :", len(tokens))
+	}
+
+	for _, token := range tokens {
+		if token.AccessTokenId == readToken.AccessTokenId && token.LastUsedAt == nil {
+			t.Error("The last use of the API token was not saved")
+		}
+	}
+
+	// The superadmin sees the tokens of the other users
+	if rr = sendRequest(t, http.MethodGet, "/users/"+strconv.FormatInt(user.UserId, 10)+"/tokens", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not list the API tokens of another user")
+	}
+
+	globals.Log.Debug("GET /users/{id}/tokens - PASSED")
+
+	//
+	//	DELETE /users/{id}/tokens/{token_id}
+	//
+	if rr = sendRequest(t, http.MethodDelete, "/users/"+strconv.FormatInt(user.UserId, 10)+"/tokens/"+strconv.FormatInt(readToken.AccessTokenId, 10), nil, tokenCookie); rr.Code != http.StatusNotFound {
+		t.Error("An API token was revoked through another user")
+	}
+
+	if rr = sendRequest(t, http.MethodDelete, "/users/1/tokens/"+strconv.FormatInt(readToken.AccessTokenId, 10), nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not revoke an API token")
+	}
+
+	if rr = sendTokenRequest(t, http.MethodGet, "/projects", nil, readToken.Token); rr.Code != http.StatusUnauthorized {
+		t.Error("A revoked API token was accepted")
+	}
+
+	globals.Log.Debug("DELETE /users/{id}/tokens/{token_id} - PASSED")
+
+	// Deleting the tokens and the user, so the other tests are not disturbed
+	for _, tokenId := range []int64{projectsToken.AccessTokenId, expiredId, userTokenId} {
+		if err = env.DB.DeleteAccessToken(tokenId); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if err = env.DB.DeleteUser(user.UserId); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,77 @@
+package handler_tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+)
+
+/*
+	TESTED : The routes of /v1
+	TESTED : The deprecated routes of the root
+*/
+func TestAPIVersionHandler(t *testing.T) {
+	//
+	//	The routes of /v1
+	//
+	versioned := sendRequest(t, http.MethodGet, "/v1/roles", nil, tokenCookie)
+	if versioned.Code != http.StatusOK || versioned.Header().Get("Deprecation") != "" {
+		t.Error("Wrong answer of /v1/roles :", versioned.Code, versioned.Header())
+	}
+
+	if rr := sendRequest(t, http.MethodGet, "/v1/unknown", nil, tokenCookie); rr.Code != http.StatusNotFound || errorCode(rr) != handlers.ErrorNotFound {
+		t.Error("An unknown route of /v1 was found :", rr.Code)
+	}
+
+	if rr := sendRequest(t, http.MethodPut, "/v1/roles", nil, tokenCookie); rr.Code != http.StatusMethodNotAllowed {
+		t.Error("A wrong method of /v1 was allowed :", rr.Code)
+	}
+
+	if rr := sendRequest(t, http.MethodGet, "/v2/roles", nil, tokenCookie); rr.Code != http.StatusNotFound {
+		t.Error("An unknown version was found :", rr.Code)
+	}
+
+	globals.Log.Debug("Routes of /v1 - PASSED")
+
+	//
+	//	The deprecated routes of the root
+	//
+	legacy := sendRequest(t, http.MethodGet, "/roles", nil, tokenCookie)
+	if legacy.Code != http.StatusOK || legacy.Body.String() != versioned.Body.String() {
+		t.Error("The root path does not answer like /v1 :", legacy.Code)
+	}
+
+	if legacy.Header().Get("Deprecation") != "@1792368000" || legacy.Header().Get("Sunset") != "Thu, 01 Apr 2027 00:00:00 GMT" {
+		t.Error("The root path is not deprecated :", legacy.Header())
+	}
+
+	if legacy.Header().Get("Link") != `</v1/roles>; rel="successor-version"` {
+		t.Error("Wrong successor of the root path :", legacy.Header().Get("Link"))
+	}
+
+	// The errors are deprecated too
+	if rr := sendRequest(t, http.MethodGet, "/unknown", nil, tokenCookie); rr.Code != http.StatusNotFound || rr.Header().Get("Sunset") == "" {
+		t.Error("Wrong answer of an unknown root path :", rr.Code, rr.Header())
+	}
+
+	// Once disabled, the root paths are not found
+	globals.LegacyAPI.Enabled = false
+	router := mux.NewRouter()
+	handlers.HandleRoutes(router, env)
+	globals.LegacyAPI.Enabled = true
+
+	request, _ := http.NewRequest(http.MethodGet, "/v1/openapi.json", nil)
+	if match := (&mux.RouteMatch{}); !router.Match(request, match) {
+		t.Error("The routes of /v1 are lost without the root paths")
+	}
+
+	request, _ = http.NewRequest(http.MethodGet, "/roles", nil)
+	if match := (&mux.RouteMatch{}); router.Match(request, match) && match.MatchErr == nil {
+		t.Error("A root path was found after its sunset")
+	}
+
+	globals.Log.Debug("Deprecated routes of the root - PASSED")
+}
@@ -0,0 +1,238 @@
+package handler_tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : POST /breaks, PATCH /breaks/{id}, DELETE /breaks/{id}
+	TESTED : GET /breaks/{id}, GET /schedules/{id}/breaks
+	TESTED : The breaks removed from the hours of the reports and the timesheets
+	TESTED : The days flagged when the mandatory break of the contract is missing
+*/
+func TestBreakHandler(t *testing.T) {
+	var (
+		err       error
+		rr        *httptest.ResponseRecorder
+		breaks    []handlers.BreakIntermediate
+		fields    []string
+		report    handlers.Report
+		timesheet handlers.Timesheet
+	)
+
+	// A break of 20 minutes at least after 6 hours of work
+	contractId, err := env.DB.CreateContract(model.Contract{ContractName: "Break contract", BreakAfterMinutes: 360, MinimumBreakMinutes: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Break password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: contractId,
+		RoleId:     3,
+		Mail:       "BreakUser@mydb",
+		Password:   string(cryptedPassword),
+		TimeZone:   "UTC",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Break project"})
+
+	userCookie := login(t, user.Mail, "Break password").Result().Cookies()[0]
+	userURL := "/users/" + strconv.FormatInt(user.UserId, 10)
+
+	at := func(Day int, Hour int, Minute int) time.Time {
+		return time.Date(2021, 3, Day, Hour, Minute, 0, 0, time.UTC)
+	}
+	between := func(Start time.Time, End time.Time) model.Schedule {
+		return model.Schedule{
+			ProjectId: projectId,
+			StartDate: sql.NullTime{Valid: true, Time: Start},
+			EndDate:   sql.NullTime{Valid: true, Time: End},
+		}
+	}
+
+	// 9 hours on monday and 8 on tuesday, and a pause of 10 minutes only on wednesday
+	var scheduleIds []int64
+	for _, schedule := range []model.Schedule{
+		between(at(8, 8, 0), at(8, 17, 0)),
+		between(at(9, 8, 0), at(9, 16, 0)),
+		between(at(10, 8, 0), at(10, 12, 0)),
+		between(at(10, 12, 10), at(10, 16, 0)),
+	} {
+		scheduleId, err := env.DB.CreateSchedule(schedule, user.UserId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheduleIds = append(scheduleIds, scheduleId)
+	}
+	vacation := between(at(11, 8, 0), at(11, 17, 0))
+	vacation.ProjectId = 0
+	vacationId, err := env.DB.CreateVacation(vacation, user.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduleIds = append(scheduleIds, vacationId)
+
+	// Answers with the break of a request, or stops the test
+	breakOf := func(rr *httptest.ResponseRecorder) handlers.BreakIntermediate {
+		var scheduleBreak handlers.BreakIntermediate
+		if rr.Code != http.StatusOK {
+			t.Fatal("Wrong answer :", rr.Code, rr.Body.String())
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&scheduleBreak); err != nil {
+			t.Fatal(err)
+		}
+		return scheduleBreak
+	}
+	breakURL := func(BreakId int64) string {
+		return "/breaks/" + strconv.FormatInt(BreakId, 10)
+	}
+	mondayHours := func() float64 {
+		var report handlers.Report
+		if rr := sendRequest(t, http.MethodGet, userURL+"/report?from=2021-03-08&to=2021-03-08", nil, userCookie); rr.Code != http.StatusOK {
+			t.Fatal("Could not get the report :", rr.Code, rr.Body.String())
+		} else if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		return report.Hours
+	}
+
+	//
+	//	POST /breaks
+	//
+	lunch := breakOf(sendRequest(t, http.MethodPost, "/breaks", handlers.BreakIntermediate{
+		ScheduleId: scheduleIds[0],
+		StartDate:  "2021-03-08T12:00:00Z",
+		EndDate:    "2021-03-08T13:00:00Z",
+	}, userCookie))
+	if lunch.BreakId == 0 || lunch.ScheduleId != scheduleIds[0] || lunch.StartDate != "2021-03-08T12:00:00Z" {
+		t.Error("Wrong created break :", lunch)
+	}
+
+	for _, wrong := range []struct {
+		scheduleBreak handlers.BreakIntermediate
+		fields        []string
+	}{
+		{handlers.BreakIntermediate{ScheduleId: scheduleIds[0], StartDate: "2021-03-08T07:00:00Z", EndDate: "2021-03-08T08:30:00Z"}, []string{"start_date"}},
+		{handlers.BreakIntermediate{ScheduleId: scheduleIds[0], StartDate: "2021-03-08T16:30:00Z", EndDate: "2021-03-08T16:00:00Z"}, []string{"end_date"}},
+		{handlers.BreakIntermediate{ScheduleId: scheduleIds[0], StartDate: "2021-03-08T12:30:00Z", EndDate: "2021-03-08T13:30:00Z"}, []string{"start_date"}},
+		{handlers.BreakIntermediate{ScheduleId: vacationId, StartDate: "2021-03-11T12:00:00Z", EndDate: "2021-03-11T13:00:00Z"}, []string{"schedule_id"}},
+		{handlers.BreakIntermediate{ScheduleId: -1, StartDate: "2021-03-08T12:00:00Z", EndDate: "2021-03-08T13:00:00Z"}, []string{"schedule_id"}},
+	} {
+		rr = sendRequest(t, http.MethodPost, "/breaks", wrong.scheduleBreak, userCookie)
+		if fields = violatedFields(t, rr); !sameFields(fields, wrong.fields...) {
+			t.Error("A wrong break was created :", wrong.scheduleBreak, fields)
+		}
+	}
+
+	globals.Log.Debug("POST /breaks - PASSED")
+
+	//
+	//	GET /breaks/{id} and GET /schedules/{id}/breaks
+	//
+	if scheduleBreak := breakOf(sendRequest(t, http.MethodGet, breakURL(lunch.BreakId), nil, userCookie)); scheduleBreak != lunch {
+		t.Error("Wrong break :", scheduleBreak)
+	}
+	if rr = sendRequest(t, http.MethodGet, "/schedules/"+strconv.FormatInt(scheduleIds[0], 10)+"/breaks", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the breaks of a schedule :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&breaks); err != nil || len(breaks) != 1 || breaks[0] != lunch {
+		t.Error("Wrong breaks of the schedule :", breaks, err)
+	}
+	if rr = sendRequest(t, http.MethodGet, breakURL(0), nil, userCookie); rr.Code != http.StatusNotFound {
+		t.Error("An unknown break was found :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /breaks/{id} - PASSED")
+
+	//
+	//	The hours without the breaks, and the missing breaks
+	//
+	if hours := mondayHours(); hours != 8 {
+		t.Error("The break was counted as worked :", hours)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, userURL+"/timesheets?week=2021-W10", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the timesheet :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&timesheet); err != nil || len(timesheet.Days) != 7 {
+		t.Fatal("Wrong timesheet :", timesheet, err)
+	}
+	if timesheet.Days[0].Hours != 8 || timesheet.Days[0].MissingBreak || !timesheet.Days[1].MissingBreak || !timesheet.Days[2].MissingBreak || timesheet.MissingBreaks != 2 {
+		t.Error("Wrong missing breaks :", timesheet)
+	}
+
+	globals.Log.Debug("Hours without the breaks - PASSED")
+
+	//
+	//	PATCH /breaks/{id}
+	//
+	coffee := breakOf(sendRequest(t, http.MethodPost, "/breaks", handlers.BreakIntermediate{
+		ScheduleId: scheduleIds[1],
+		StartDate:  "2021-03-09T11:00:00Z",
+		EndDate:    "2021-03-09T11:10:00Z",
+	}, userCookie))
+
+	// Long enough, the break is not missing anymore. It stays with its schedule
+	coffee.EndDate = "2021-03-09T11:30:00Z"
+	moved := coffee
+	moved.ScheduleId = scheduleIds[0]
+	if changed := breakOf(sendRequest(t, http.MethodPatch, breakURL(coffee.BreakId), moved, userCookie)); changed != coffee {
+		t.Error("Wrong changed break :", changed)
+	}
+	moved.StartDate, moved.EndDate = "2021-03-09T15:30:00Z", "2021-03-09T16:30:00Z"
+	if fields = violatedFields(t, sendRequest(t, http.MethodPatch, breakURL(coffee.BreakId), moved, userCookie)); !sameFields(fields, "end_date") {
+		t.Error("A break was moved out of its schedule :", fields)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, userURL+"/timesheets?week=2021-W10", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the timesheet :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&timesheet); err != nil || timesheet.Days[1].MissingBreak || timesheet.Days[1].Hours != 7.5 || timesheet.MissingBreaks != 1 {
+		t.Error("Wrong timesheet after the break :", timesheet, err)
+	}
+
+	globals.Log.Debug("PATCH /breaks/{id} - PASSED")
+
+	//
+	//	DELETE /breaks/{id}
+	//
+	if rr = sendRequest(t, http.MethodDelete, breakURL(lunch.BreakId), nil, userCookie); rr.Code != http.StatusOK {
+		t.Error("Could not delete the break :", rr.Code, rr.Body.String())
+	}
+	if rr = sendRequest(t, http.MethodDelete, breakURL(lunch.BreakId), nil, userCookie); rr.Code != http.StatusNotFound {
+		t.Error("A deleted break was deleted again :", rr.Code)
+	}
+	if hours := mondayHours(); hours != 9 {
+		t.Error("The deleted break was not counted as worked :", hours)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, userURL+"/report?from=2021-03-09&to=2021-03-09", nil, userCookie); rr.Code == http.StatusOK {
+		json.NewDecoder(rr.Body).Decode(&report)
+	}
+	if report.Hours != 7.5 {
+		t.Error("Wrong hours with a break :", report)
+	}
+
+	globals.Log.Debug("DELETE /breaks/{id} - PASSED")
+
+	for _, scheduleId := range scheduleIds {
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId})
+		env.DB.DeleteSchedule(scheduleId)
+	}
+	env.DB.DeleteProject(projectId)
+	env.DB.DeleteUser(user.UserId)
+	env.DB.DeleteContract(contractId)
+}
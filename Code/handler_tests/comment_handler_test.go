@@ -47,7 +47,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/comments", nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing the request
 	r.ServeHTTP(rr, request)
 
@@ -80,7 +80,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPost, "/comments", bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -102,7 +102,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/comments/"+strconv.FormatInt(comment1.CommentId, 10), nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -128,7 +128,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/1/comments", nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -154,7 +154,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/projects/1/comments", nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -180,7 +180,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/schedules/1/comments", nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -213,7 +213,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPatch, "/comments/"+strconv.FormatInt(comment.CommentId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -221,7 +221,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/comments/"+strconv.FormatInt(comment.CommentId, 10), nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -244,7 +244,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodDelete, "/comments/"+strconv.FormatInt(comment.CommentId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -252,7 +252,7 @@ func TestCommentHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/comments/"+strconv.FormatInt(comment.CommentId, 10), nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
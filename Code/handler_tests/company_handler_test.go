@@ -44,7 +44,7 @@ func TestCompanyHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/companies", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing the request
 	r.ServeHTTP(rr, request)
 
@@ -75,7 +75,7 @@ func TestCompanyHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPost, "/companies", bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -97,7 +97,7 @@ func TestCompanyHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/companies/"+strconv.FormatInt(company1.CompanyId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -129,7 +129,7 @@ func TestCompanyHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPatch, "/companies/"+strconv.FormatInt(company.CompanyId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -137,7 +137,7 @@ func TestCompanyHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/companies/"+strconv.FormatInt(company.CompanyId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -160,7 +160,7 @@ func TestCompanyHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodDelete, "/companies/"+strconv.FormatInt(company.CompanyId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -168,7 +168,7 @@ func TestCompanyHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/companies/"+strconv.FormatInt(company.CompanyId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
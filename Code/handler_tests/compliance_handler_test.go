@@ -0,0 +1,326 @@
+package handler_tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : GET /users/{id}/compliance, GET /me/compliance
+	TESTED : The hours per day, per week and on average, the daily and the weekly rests, and the night work
+	TESTED : The new schedules refused when they break the rules of a contract blocking them
+	TESTED : The same refusal on PATCH /schedules/{id}, POST /users/{id}/schedules/{id}, the series and the templates
+*/
+func TestComplianceHandler(t *testing.T) {
+	var (
+		err        error
+		rr         *httptest.ResponseRecorder
+		compliance handlers.Compliance
+		answer     handlers.ErrorResponse
+		series     handlers.SeriesIntermediate
+		template   handlers.TemplateIntermediate
+	)
+
+	// The rules of a young apprentice, with an average low enough to be broken
+	contract := model.Contract{
+		ContractName:            "Compliance contract",
+		MaxDailyMinutes:         600,
+		MaxWeeklyMinutes:        2880,
+		MaxAverageWeeklyMinutes: 300,
+		MinDailyRestMinutes:     660,
+		MinWeeklyRestMinutes:    2100,
+		NightWorkForbidden:      true,
+	}
+	if contract.ContractId, err = env.DB.CreateContract(contract); err != nil {
+		t.Fatal(err)
+	}
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Compliance password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: contract.ContractId,
+		RoleId:     3,
+		Mail:       "ComplianceUser@mydb",
+		Password:   string(cryptedPassword),
+		TimeZone:   "UTC",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Compliance project"})
+
+	userCookie := login(t, user.Mail, "Compliance password").Result().Cookies()[0]
+	userURL := "/users/" + strconv.FormatInt(user.UserId, 10)
+
+	at := func(Day int, Hour int) time.Time {
+		return time.Date(2021, 3, Day, Hour, 0, 0, 0, time.UTC)
+	}
+
+	// 11 hours on monday, and 3 hours of night until tuesday morning. Then 8 hours every day from the next monday on
+	var scheduleIds []int64
+	periods := [][2]time.Time{{at(8, 7), at(8, 18)}, {at(9, 2), at(9, 5)}}
+	for day := 15; day <= 22; day++ {
+		periods = append(periods, [2]time.Time{at(day, 9), at(day, 17)})
+	}
+	for _, period := range periods {
+		scheduleId, err := env.DB.CreateSchedule(model.Schedule{
+			ProjectId: projectId,
+			StartDate: sql.NullTime{Valid: true, Time: period[0]},
+			EndDate:   sql.NullTime{Valid: true, Time: period[1]},
+		}, user.UserId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheduleIds = append(scheduleIds, scheduleId)
+	}
+
+	// Answers with the broken rules, or stops the test
+	rulesOf := func(rr *httptest.ResponseRecorder) []string {
+		var rules []string
+		if rr.Code != http.StatusOK {
+			t.Fatal("Could not get the compliance :", rr.Code, rr.Body.String())
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&compliance); err != nil {
+			t.Fatal(err)
+		}
+		for _, violation := range compliance.Violations {
+			rules = append(rules, violation.Rule)
+		}
+		return rules
+	}
+
+	//
+	//	GET /users/{id}/compliance
+	//
+	rules := rulesOf(sendRequest(t, http.MethodGet, userURL+"/compliance?from=2021-03-08&to=2021-03-21", nil, userCookie))
+	if !sameFields(rules, handlers.RuleMaxDaily, handlers.RuleNightWork, handlers.RuleDailyRest, handlers.RuleMaxWeekly, handlers.RuleMaxAverageWeekly, handlers.RuleWeeklyRest) {
+		t.Fatal("Wrong broken rules :", compliance)
+	}
+
+	for i, expected := range []handlers.ComplianceViolation{
+		{UserId: user.UserId, Rule: handlers.RuleMaxDaily, Start: "2021-03-08T00:00:00Z", End: "2021-03-09T00:00:00Z", Minutes: 660, LimitMinutes: 600},
+		{UserId: user.UserId, Rule: handlers.RuleNightWork, Start: "2021-03-08T21:00:00Z", End: "2021-03-09T06:00:00Z", Minutes: 180, LimitMinutes: 0},
+		{UserId: user.UserId, Rule: handlers.RuleDailyRest, Start: "2021-03-08T07:00:00Z", End: "2021-03-09T05:00:00Z", Minutes: 120, LimitMinutes: 660},
+		{UserId: user.UserId, Rule: handlers.RuleMaxWeekly, Start: "2021-03-15T00:00:00Z", End: "2021-03-22T00:00:00Z", Minutes: 3360, LimitMinutes: 2880},
+		{UserId: user.UserId, Rule: handlers.RuleMaxAverageWeekly, Start: "2020-12-28T00:00:00Z", End: "2021-03-22T00:00:00Z", Minutes: 350, LimitMinutes: 300},
+		{UserId: user.UserId, Rule: handlers.RuleWeeklyRest, Start: "2021-03-15T00:00:00Z", End: "2021-03-22T00:00:00Z", Minutes: 960, LimitMinutes: 2100},
+	} {
+		if compliance.Violations[i] != expected {
+			t.Error("Wrong broken rule :", compliance.Violations[i], expected)
+		}
+	}
+
+	// A single day of the first week
+	if rules = rulesOf(sendRequest(t, http.MethodGet, "/me/compliance?from=2021-03-10&to=2021-03-10", nil, userCookie)); len(rules) != 0 {
+		t.Error("Rules were broken on a day without work :", compliance)
+	}
+	if fields := violatedFields(t, sendRequest(t, http.MethodGet, userURL+"/compliance?from=2021-03-10", nil, userCookie)); !sameFields(fields, "to") {
+		t.Error("A compliance without its last day was checked :", fields)
+	}
+	if rr = sendRequest(t, http.MethodGet, "/users/1/compliance?from=2021-03-08&to=2021-03-08", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("The compliance of another user was seen :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /users/{id}/compliance - PASSED")
+
+	//
+	//	POST /schedules, with a contract blocking the broken rules
+	//
+	newSchedule := func(Start string, End string) *httptest.ResponseRecorder {
+		return sendRequest(t, http.MethodPost, "/schedules", handlers.ScheduleIntermediate{
+			ProjectId: projectId,
+			UserId:    user.UserId,
+			StartDate: Start,
+			EndDate:   End,
+		}, userCookie)
+	}
+
+	// Only reported at first
+	var scheduleId struct {
+		ScheduleId int64 `json:"schedule_id"`
+	}
+	if rr = newSchedule("2021-03-10T20:00:00Z", "2021-03-10T22:00:00Z"); rr.Code != http.StatusOK {
+		t.Fatal("A schedule was refused by a contract not blocking :", rr.Code, rr.Body.String())
+	}
+	json.NewDecoder(rr.Body).Decode(&scheduleId)
+	scheduleIds = append(scheduleIds, scheduleId.ScheduleId)
+
+	contract.BlockViolations = true
+	if _, err = env.DB.UpdateContract(contract); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr = newSchedule("2021-03-11T20:00:00Z", "2021-03-11T22:00:00Z"); rr.Code != http.StatusConflict {
+		t.Fatal("A schedule at night was not refused :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&answer); err != nil || answer.Error.Code != handlers.ErrorComplianceViolation || len(answer.Error.Violations) != 1 {
+		t.Fatal("Wrong refusal :", answer, err)
+	}
+	if violation := answer.Error.Violations[0]; violation.Rule != handlers.RuleNightWork || violation.UserId != user.UserId || violation.Start != "2021-03-11T21:00:00Z" {
+		t.Error("Wrong broken rule :", violation)
+	}
+
+	// The rules already broken don't block the other schedules of the week
+	if rr = newSchedule("2021-03-12T09:00:00Z", "2021-03-12T12:00:00Z"); rr.Code != http.StatusOK {
+		t.Error("A schedule breaking no rule was refused :", rr.Code, rr.Body.String())
+	}
+	json.NewDecoder(rr.Body).Decode(&scheduleId)
+	scheduleIds = append(scheduleIds, scheduleId.ScheduleId)
+
+	globals.Log.Debug("POST /schedules with the rules of the contract - PASSED")
+
+	// Answers with the rule broken by a refused request
+	brokenRule := func(rr *httptest.ResponseRecorder) string {
+		var answer handlers.ErrorResponse
+		if rr.Code != http.StatusConflict {
+			t.Error("A request breaking the rules was not refused :", rr.Code, rr.Body.String())
+			return ""
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&answer); err != nil || answer.Error.Code != handlers.ErrorComplianceViolation || len(answer.Error.Violations) == 0 {
+			t.Error("Wrong refusal :", answer, err)
+			return ""
+		}
+		return answer.Error.Violations[0].Rule
+	}
+
+	//
+	//	PATCH /schedules/{id}, stretched to 14 hours
+	//
+	scheduleURL := "/schedules/" + strconv.FormatInt(scheduleId.ScheduleId, 10)
+	stretched := handlers.ScheduleIntermediate{ProjectId: projectId, StartDate: "2021-03-12T07:00:00Z", EndDate: "2021-03-12T21:00:00Z"}
+	if rule := brokenRule(sendRequest(t, http.MethodPatch, scheduleURL, stretched, userCookie)); rule != handlers.RuleMaxDaily {
+		t.Error("Wrong rule broken by the changed schedule :", rule)
+	}
+
+	// Its old version doesn't count with the new one
+	stretched.StartDate, stretched.EndDate = "2021-03-12T08:00:00Z", "2021-03-12T17:00:00Z"
+	if rr = sendRequest(t, http.MethodPatch, scheduleURL, stretched, userCookie); rr.Code != http.StatusOK {
+		t.Error("A changed schedule breaking no rule was refused :", rr.Code, rr.Body.String())
+	}
+
+	globals.Log.Debug("PATCH /schedules/{id} with the rules of the contract - PASSED")
+
+	//
+	//	POST /users/{id}/schedules/{id}
+	//
+	nightId, err := env.DB.CreateSchedule(model.Schedule{
+		ProjectId: projectId,
+		StartDate: sql.NullTime{Valid: true, Time: at(11, 20)},
+		EndDate:   sql.NullTime{Valid: true, Time: at(11, 22)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rule := brokenRule(sendRequest(t, http.MethodPost, userURL+"/schedules/"+strconv.FormatInt(nightId, 10), nil, tokenCookie)); rule != handlers.RuleNightWork {
+		t.Error("Wrong rule broken by the linked schedule :", rule)
+	}
+	env.DB.DeleteSchedule(nightId)
+
+	globals.Log.Debug("POST /users/{id}/schedules/{id} with the rules of the contract - PASSED")
+
+	//
+	//	POST /series and PATCH /series/{id}, checked with all their occurrences
+	//
+	nights := handlers.SeriesIntermediate{
+		ProjectId:      projectId,
+		UserId:         user.UserId,
+		StartDate:      "2021-03-01T20:00:00Z",
+		EndDate:        "2021-03-01T22:00:00Z",
+		RecurrenceRule: "FREQ=DAILY;COUNT=2",
+	}
+	if rule := brokenRule(sendRequest(t, http.MethodPost, "/series", nights, userCookie)); rule != handlers.RuleNightWork {
+		t.Error("Wrong rule broken by the series :", rule)
+	}
+
+	nextWeek := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 7)
+	mornings := nights
+	mornings.StartDate, mornings.EndDate = nextWeek.Add(9*time.Hour).Format(time.RFC3339), nextWeek.Add(12*time.Hour).Format(time.RFC3339)
+	if rr = sendRequest(t, http.MethodPost, "/series", mornings, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("A series breaking no rule was refused :", rr.Code, rr.Body.String())
+	}
+	json.NewDecoder(rr.Body).Decode(&series)
+	seriesURL := "/series/" + strconv.FormatInt(series.SeriesId, 10)
+
+	mornings.StartDate, mornings.EndDate = nextWeek.Add(20*time.Hour).Format(time.RFC3339), nextWeek.Add(23*time.Hour).Format(time.RFC3339)
+	if rule := brokenRule(sendRequest(t, http.MethodPatch, seriesURL, mornings, userCookie)); rule != handlers.RuleNightWork {
+		t.Error("Wrong rule broken by the changed series :", rule)
+	}
+	moved := series.Schedules[0]
+	moved.StartDate, moved.EndDate = mornings.StartDate, mornings.EndDate
+	if rule := brokenRule(sendRequest(t, http.MethodPatch, "/schedules/"+strconv.FormatInt(moved.ScheduleId, 10)+"?scope=following", moved, userCookie)); rule != handlers.RuleNightWork {
+		t.Error("Wrong rule broken by the following occurrences :", rule)
+	}
+	sendRequest(t, http.MethodDelete, seriesURL, nil, userCookie)
+
+	globals.Log.Debug("POST /series with the rules of the contract - PASSED")
+
+	//
+	//	The extension of the series, which skips the occurrences breaking the rules
+	//
+	endless := model.ScheduleSeries{
+		ProjectId:      projectId,
+		UserId:         user.UserId,
+		StartDate:      nextWeek.Add(21 * time.Hour),
+		EndDate:        nextWeek.Add(22 * time.Hour),
+		TimeZone:       "UTC",
+		RecurrenceRule: "FREQ=DAILY;COUNT=3",
+		GeneratedUntil: time.Now(),
+	}
+	if endless.SeriesId, err = env.DB.CreateSeries(endless, model.Schedules{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = env.ExtendSeries(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if endless, err = env.DB.GetSeries(endless.SeriesId); err != nil || len(endless.Exceptions()) != 3 {
+		t.Error("The occurrences breaking the rules were not skipped :", endless, err)
+	}
+	if occurrences, err := env.DB.GetSchedulesOfSeries(endless.SeriesId); err != nil || len(occurrences) != 0 {
+		t.Error("Occurrences breaking the rules were saved :", occurrences, err)
+	}
+	env.DB.DeleteSeries(endless.SeriesId, time.Time{})
+
+	globals.Log.Debug("Extension of the series with the rules of the contract - PASSED")
+
+	//
+	//	POST /templates/{id}/apply, checked with all its slots
+	//
+	evenings := handlers.TemplateIntermediate{
+		TemplateName: "Compliance evenings",
+		UserId:       user.UserId,
+		Slots: model.TemplateSlots{
+			{ProjectId: projectId, Weekday: 3, StartTime: "19:00", EndTime: "20:00"},
+			{ProjectId: projectId, Weekday: 3, StartTime: "20:00", EndTime: "22:00"},
+		},
+	}
+	if rr = sendRequest(t, http.MethodPost, "/templates", evenings, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not create the template :", rr.Code, rr.Body.String())
+	}
+	json.NewDecoder(rr.Body).Decode(&template)
+	templateURL := "/templates/" + strconv.FormatInt(template.TemplateId, 10)
+	if rule := brokenRule(sendRequest(t, http.MethodPost, templateURL+"/apply", handlers.TemplateApplication{Week: "2021-W09"}, userCookie)); rule != handlers.RuleNightWork {
+		t.Error("Wrong rule broken by the template :", rule)
+	}
+	if schedules, _ := env.DB.GetSchedulesOfUser(user.UserId); len(schedules) != len(scheduleIds) {
+		t.Error("The slots of a refused template were created :", schedules)
+	}
+	sendRequest(t, http.MethodDelete, templateURL, nil, userCookie)
+
+	globals.Log.Debug("POST /templates/{id}/apply with the rules of the contract - PASSED")
+
+	for _, scheduleId := range scheduleIds {
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId})
+		env.DB.DeleteSchedule(scheduleId)
+	}
+	env.DB.DeleteProject(projectId)
+	env.DB.DeleteUser(user.UserId)
+	env.DB.DeleteContract(contract.ContractId)
+}
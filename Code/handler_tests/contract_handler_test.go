@@ -45,7 +45,7 @@ func TestContractHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/contracts", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing the request
 	r.ServeHTTP(rr, request)
 
@@ -76,7 +76,7 @@ func TestContractHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPost, "/contracts", bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -98,7 +98,7 @@ func TestContractHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/contracts/"+strconv.FormatInt(contract1.ContractId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -124,7 +124,7 @@ func TestContractHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/1/contract", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -157,7 +157,7 @@ func TestContractHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPatch, "/contracts/"+strconv.FormatInt(contract.ContractId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -165,7 +165,7 @@ func TestContractHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/contracts/"+strconv.FormatInt(contract.ContractId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -188,7 +188,7 @@ func TestContractHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodDelete, "/contracts/"+strconv.FormatInt(contract.ContractId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -196,7 +196,7 @@ func TestContractHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/contracts/"+strconv.FormatInt(contract.ContractId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
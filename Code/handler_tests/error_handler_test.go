@@ -0,0 +1,127 @@
+package handler_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// Decodes the envelope of an error answer
+func decodeError(t *testing.T, rr *httptest.ResponseRecorder) handlers.ErrorBody {
+	var answer handlers.ErrorResponse
+
+	if err := json.NewDecoder(rr.Body).Decode(&answer); err != nil {
+		t.Error("The error is not in the JSON envelope :", err)
+	}
+
+	return answer.Error
+}
+
+/*
+	TESTED : The JSON envelope of the errors
+	TESTED : X-Request-Id
+	TESTED : The status of the unexpected errors (missing row, constraint, parsing, empty body)
+*/
+func TestErrorHandler(t *testing.T) {
+	var (
+		rr      *httptest.ResponseRecorder
+		request *http.Request
+		err     error
+		body    handlers.ErrorBody
+	)
+
+	//
+	//	Unknown routes and methods
+	//
+	rr = sendRequest(t, http.MethodGet, "/unknown", nil, tokenCookie)
+	if body = decodeError(t, rr); rr.Code != http.StatusNotFound || body.Code != handlers.ErrorNotFound || body.Status != http.StatusNotFound {
+		t.Error("Wrong error for an unknown route :", rr.Code, body)
+	}
+
+	if rr.Header().Get("Content-Type") != "application/json; charset=UTF-8" {
+		t.Error("The error is not sent as JSON :", rr.Header().Get("Content-Type"))
+	}
+
+	rr = sendRequest(t, http.MethodPut, "/projects", nil, tokenCookie)
+	if body = decodeError(t, rr); rr.Code != http.StatusMethodNotAllowed || body.Code != handlers.ErrorMethodNotAllowed {
+		t.Error("Wrong error for an unknown method :", rr.Code, body)
+	}
+
+	globals.Log.Debug("Unknown routes and methods - PASSED")
+
+	//
+	//	X-Request-Id
+	//
+	if request, err = http.NewRequest(http.MethodGet, "/users/999999", nil); err != nil {
+		t.Error(err)
+	}
+	addSession(request, tokenCookie)
+	request.Header.Set("X-Request-Id", "support-42")
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+
+	if rr.Header().Get("X-Request-Id") != "support-42" {
+		t.Error("The id of the request was not sent back :", rr.Header().Get("X-Request-Id"))
+	}
+
+	if body = decodeError(t, rr); body.RequestId != "support-42" {
+		t.Error("The error does not contain the id of the request :", body.RequestId)
+	}
+
+	// An id that does not look like an id is replaced
+	request.Header.Set("X-Request-Id", "not an id\n")
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+
+	if id := rr.Header().Get("X-Request-Id"); id == "" || id == "not an id\n" {
+		t.Error("A wrong request id was kept :", id)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/projects", nil, tokenCookie); rr.Header().Get("X-Request-Id") == "" {
+		t.Error("A successful answer has no request id")
+	}
+
+	globals.Log.Debug("X-Request-Id - PASSED")
+
+	//
+	//	Unexpected errors
+	//
+	rr = sendRequest(t, http.MethodGet, "/users/999999", nil, tokenCookie)
+	if body = decodeError(t, rr); rr.Code != http.StatusNotFound || body.Code != handlers.ErrorNotFound {
+		t.Error("A missing row did not return a 404 code :", rr.Code, body)
+	}
+
+	rr = sendRequest(t, http.MethodGet, "/roles/abc", nil, tokenCookie)
+	if body = decodeError(t, rr); rr.Code != http.StatusBadRequest || body.Code != handlers.ErrorBadRequest {
+		t.Error("An id that is not a number did not return a 400 code :", rr.Code, body)
+	}
+
+	rr = sendRequest(t, http.MethodPost, "/companies/1/projects/1", nil, tokenCookie)
+	if body = decodeError(t, rr); rr.Code != http.StatusConflict || body.Code != handlers.ErrorConflict {
+		t.Error("An existing link did not return a 409 code :", rr.Code, body)
+	}
+
+	rr = sendRequest(t, http.MethodPost, "/v1/get-token", nil, nil)
+	if body = decodeError(t, rr); rr.Code != http.StatusBadRequest || body.Code != handlers.ErrorBadRequest {
+		t.Error("An empty login form did not return a 400 code :", rr.Code, body)
+	}
+
+	globals.Log.Debug("Unexpected errors - PASSED")
+
+	//
+	//	Details
+	//
+	user := model.User{ContractId: 1, RoleId: 3, Mail: "ErrorUser@mydb", Password: "short"}
+	rr = sendRequest(t, http.MethodPost, "/users", user, tokenCookie)
+	if body = decodeError(t, rr); rr.Code != http.StatusUnprocessableEntity || len(body.Details) == 0 || body.Details[0].Field != "password" {
+		t.Error("The violations of the password policy are not detailed :", rr.Code, body)
+	}
+
+	globals.Log.Debug("Details - PASSED")
+}
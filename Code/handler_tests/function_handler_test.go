@@ -45,7 +45,7 @@ func TestFunctionHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/functions", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing the request
 	r.ServeHTTP(rr, request)
 
@@ -76,7 +76,7 @@ func TestFunctionHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPost, "/functions", bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -98,7 +98,7 @@ func TestFunctionHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/functions/"+strconv.FormatInt(function1.FunctionId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -124,7 +124,7 @@ func TestFunctionHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/1/functions", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -157,7 +157,7 @@ func TestFunctionHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPatch, "/functions/"+strconv.FormatInt(function.FunctionId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -165,7 +165,7 @@ func TestFunctionHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/functions/"+strconv.FormatInt(function.FunctionId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -188,7 +188,7 @@ func TestFunctionHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodDelete, "/functions/"+strconv.FormatInt(function.FunctionId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -196,7 +196,7 @@ func TestFunctionHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/functions/"+strconv.FormatInt(function.FunctionId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
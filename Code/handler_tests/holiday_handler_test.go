@@ -0,0 +1,245 @@
+package handler_tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : GET /companies/{id}/holidays, GET /users/{id}/holidays, GET /me/holidays
+	TESTED : POST /holidays, GET /holidays/{id}, DELETE /holidays/{id}
+	TESTED : The days off in the timesheets, the series and the templates
+*/
+func TestHolidayHandler(t *testing.T) {
+	var (
+		err       error
+		rr        *httptest.ResponseRecorder
+		holiday   model.Holiday
+		timesheet handlers.Timesheet
+		series    handlers.SeriesIntermediate
+		template  handlers.TemplateIntermediate
+		fields    []string
+	)
+
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Holiday password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId:           1,
+		RoleId:               3,
+		Mail:                 "HolidayUser@mydb",
+		Password:             string(cryptedPassword),
+		TimeZone:             "UTC",
+		TheoricalHoursWorked: 35,
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	company := model.Company{CompanyName: "Holiday company", HolidayCalendar: globals.HolidayCalendarAlsaceMoselle}
+	if company.CompanyId, err = env.DB.CreateCompany(company); err != nil {
+		t.Fatal(err)
+	}
+	if err = env.DB.CreateCompanyUser(model.CompanyUser{CompanyId: company.CompanyId, UserId: user.UserId}); err != nil {
+		t.Fatal(err)
+	}
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Holiday project"})
+
+	userCookie := login(t, user.Mail, "Holiday password").Result().Cookies()[0]
+	companyURL := "/companies/" + strconv.FormatInt(company.CompanyId, 10)
+
+	// Answers with the days off of a request, or stops the test
+	calendarOf := func(rr *httptest.ResponseRecorder) map[string]handlers.CalendarDay {
+		var calendar []handlers.CalendarDay
+		if rr.Code != http.StatusOK {
+			t.Fatal("Could not get the days off :", rr.Code, rr.Body.String())
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&calendar); err != nil {
+			t.Fatal(err)
+		}
+		days := map[string]handlers.CalendarDay{}
+		for _, day := range calendar {
+			days[day.Day] = day
+		}
+		return days
+	}
+
+	//
+	//	GET /companies/{id}/holidays
+	//
+	days := calendarOf(sendRequest(t, http.MethodGet, companyURL+"/holidays?year=2021", nil, userCookie))
+	if len(days) != 13 || days["2021-04-02"].Name != "Vendredi saint" || days["2021-05-13"].Kind != handlers.HolidayKindPublic {
+		t.Error("Wrong public holidays of the company :", days)
+	}
+	if fields = violatedFields(t, sendRequest(t, http.MethodGet, companyURL+"/holidays?year=21st", nil, userCookie)); !sameFields(fields, "year") {
+		t.Error("The days off of a wrong year were given :", fields)
+	}
+
+	globals.Log.Debug("GET /companies/{id}/holidays - PASSED")
+
+	//
+	//	POST /holidays and GET /holidays/{id}
+	//
+	closure := model.Holiday{CompanyId: company.CompanyId, Day: "2021-05-14", Name: "Pont de l'Ascension", Kind: model.HolidayKindClosure}
+	if rr = sendRequest(t, http.MethodPost, "/holidays", closure, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A day off was added without the right to :", rr.Code)
+	}
+
+	wrong := model.Holiday{CompanyId: company.CompanyId, Day: "14/05/2021", Name: "Wrong", Kind: "weekend"}
+	if fields = violatedFields(t, sendRequest(t, http.MethodPost, "/holidays", wrong, tokenCookie)); !sameFields(fields, "day", "kind") {
+		t.Error("A wrong day off was added :", fields)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/holidays", closure, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not add the day off :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&holiday); err != nil || holiday.HolidayId == 0 {
+		t.Fatal("Wrong added day off :", holiday, err)
+	}
+	closure.HolidayId = holiday.HolidayId
+	holidayURL := "/holidays/" + strconv.FormatInt(closure.HolidayId, 10)
+
+	if rr = sendRequest(t, http.MethodPost, "/holidays", closure, tokenCookie); rr.Code != http.StatusConflict {
+		t.Error("The same day off was added twice :", rr.Code)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, holidayURL, nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the day off :", rr.Code)
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&holiday); err != nil || holiday != closure {
+		t.Error("Wrong day off :", holiday, err)
+	}
+
+	globals.Log.Debug("POST /holidays - PASSED")
+
+	//
+	//	GET /users/{id}/holidays and GET /me/holidays
+	//
+	days = calendarOf(sendRequest(t, http.MethodGet, "/me/holidays?year=2021", nil, userCookie))
+	if len(days) != 14 || days["2021-05-14"].HolidayId != closure.HolidayId || days["2021-05-14"].Kind != model.HolidayKindClosure {
+		t.Error("Wrong days off of the user :", days)
+	}
+	if days = calendarOf(sendRequest(t, http.MethodGet, "/users/"+strconv.FormatInt(user.UserId, 10)+"/holidays?year=2022", nil, tokenCookie)); len(days) != 13 {
+		t.Error("Wrong days off of the user in 2022 :", days)
+	}
+	if rr = sendRequest(t, http.MethodGet, "/users/1/holidays", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("The days off of another user were seen :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /users/{id}/holidays - PASSED")
+
+	//
+	//	The days off in the timesheets : nothing expected on the ascension, where the vacation doesn't count
+	//
+	at := func(Day int, Hour int) sql.NullTime {
+		return sql.NullTime{Valid: true, Time: time.Date(2021, 5, Day, Hour, 0, 0, 0, time.UTC)}
+	}
+	scheduleId, err := env.DB.CreateSchedule(model.Schedule{ProjectId: projectId, StartDate: at(12, 9), EndDate: at(12, 17)}, user.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vacationId, err := env.DB.CreateVacation(model.Schedule{StartDate: at(13, 0), EndDate: at(13, 23)}, user.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/me/timesheets?week=2021-W19", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the timesheet :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&timesheet); err != nil || len(timesheet.Days) != 7 {
+		t.Fatal("Wrong timesheet :", timesheet, err)
+	}
+	ascension, bridge := timesheet.Days[3], timesheet.Days[4]
+	if ascension.Holiday != "Ascension" || ascension.ExpectedHours != 0 || ascension.VacationHours != 0 {
+		t.Error("Wrong ascension in the timesheet :", ascension)
+	}
+	if bridge.Holiday != closure.Name || bridge.ExpectedHours != 0 || timesheet.Days[2].ExpectedHours == 0 || timesheet.Days[2].Holiday != "" {
+		t.Error("Wrong days off in the timesheet :", timesheet.Days)
+	}
+	if timesheet.VacationHours != 0 || timesheet.Hours != 8 {
+		t.Error("Wrong hours of the timesheet :", timesheet)
+	}
+
+	globals.Log.Debug("GET /me/timesheets with days off - PASSED")
+
+	//
+	//	The days off in the series and in the templates
+	//
+	daily := handlers.SeriesIntermediate{
+		ProjectId:      projectId,
+		UserId:         user.UserId,
+		StartDate:      "2021-05-10T18:00:00Z",
+		EndDate:        "2021-05-10T19:00:00Z",
+		RecurrenceRule: "RRULE:FREQ=DAILY;COUNT=5",
+	}
+	if rr = sendRequest(t, http.MethodPost, "/series", daily, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not create the series :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&series); err != nil || len(series.Schedules) != 3 || series.Schedules[2].StartDate != "2021-05-12T18:00:00Z" {
+		t.Error("The series has some occurrences on days off :", series, err)
+	}
+
+	week := handlers.TemplateIntermediate{
+		TemplateName: "Holiday week",
+		UserId:       user.UserId,
+		Slots: model.TemplateSlots{
+			{ProjectId: projectId, Weekday: 1, StartTime: "07:00", EndTime: "08:00"},
+			{ProjectId: projectId, Weekday: 4, StartTime: "07:00", EndTime: "08:00"},
+		},
+	}
+	if rr = sendRequest(t, http.MethodPost, "/templates", week, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not create the template :", rr.Code, rr.Body.String())
+	}
+	json.NewDecoder(rr.Body).Decode(&template)
+	templateURL := "/templates/" + strconv.FormatInt(template.TemplateId, 10)
+
+	var preview handlers.TemplateApplicationResult
+	if rr = sendRequest(t, http.MethodPost, templateURL+"/preview", handlers.TemplateApplication{Week: "2021-W19"}, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not preview the template :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&preview); err != nil || len(preview.Slots) != 2 || preview.Slots[0].Status != handlers.SlotFree || preview.Slots[1].Status != handlers.SlotHoliday {
+		t.Error("Wrong preview of the template on a week with days off :", preview, err)
+	}
+
+	globals.Log.Debug("POST /series and POST /templates/{id}/preview with days off - PASSED")
+
+	//
+	//	DELETE /holidays/{id}
+	//
+	if rr = sendRequest(t, http.MethodDelete, holidayURL, nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A day off was removed without the right to :", rr.Code)
+	}
+	if rr = sendRequest(t, http.MethodDelete, holidayURL, nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not remove the day off :", rr.Code, rr.Body.String())
+	}
+	if rr = sendRequest(t, http.MethodDelete, holidayURL, nil, tokenCookie); rr.Code != http.StatusNotFound {
+		t.Error("A removed day off was removed again :", rr.Code)
+	}
+	if days = calendarOf(sendRequest(t, http.MethodGet, "/me/holidays?year=2021", nil, userCookie)); len(days) != 13 {
+		t.Error("The removed day off is still in the calendar :", days)
+	}
+
+	globals.Log.Debug("DELETE /holidays/{id} - PASSED")
+
+	sendRequest(t, http.MethodDelete, templateURL, nil, userCookie)
+	sendRequest(t, http.MethodDelete, "/series/"+strconv.FormatInt(series.SeriesId, 10), nil, userCookie)
+	ids := []int64{scheduleId, vacationId}
+	for _, occurrence := range series.Schedules {
+		ids = append(ids, occurrence.ScheduleId)
+	}
+	for _, id := range ids {
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: id})
+		env.DB.DeleteSchedule(id)
+	}
+	env.DB.DeleteCompanyUser(model.CompanyUser{CompanyId: company.CompanyId, UserId: user.UserId})
+	env.DB.DeleteCompany(company.CompanyId)
+	env.DB.DeleteProject(projectId)
+	env.DB.DeleteUser(user.UserId)
+}
@@ -0,0 +1,177 @@
+package handler_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : POST /users/{id}/impersonate
+	TESTED : DELETE /impersonation
+	TESTED : GET /impersonations
+	TESTED : GET /impersonations/{id}/actions
+	TESTED : Requests made while impersonating a user
+*/
+func TestImpersonationHandler(t *testing.T) {
+	var (
+		err            error
+		rr             *httptest.ResponseRecorder
+		started        handlers.ImpersonationStarted
+		impersonations model.Impersonations
+		actions        model.ImpersonationActions
+	)
+
+	// A user without the right to add and modify users
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Impersonated password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "ImpersonatedUser@mydb",
+		Password:   string(cryptedPassword),
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	userURL := "/users/" + strconv.FormatInt(user.UserId, 10)
+
+	//
+	//	POST /users/{id}/impersonate
+	//
+	if rr = sendRequest(t, http.MethodPost, "/users/1/impersonate", nil, tokenCookie); rr.Code != http.StatusBadRequest {
+		t.Error("A user impersonated himself")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/users/999/impersonate", nil, tokenCookie); rr.Code != http.StatusNotFound {
+		t.Error("An unexisting user was impersonated")
+	}
+
+	userCookie := login(t, user.Mail, "Impersonated password").Result().Cookies()[0]
+	if rr = sendRequest(t, http.MethodPost, "/users/1/impersonate", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user without the right to manage users impersonated somebody")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, userURL+"/impersonate", handlers.ImpersonationRequest{Reason: "Ticket 42"}, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not impersonate a user :", rr.Code, rr.Body.String())
+	}
+
+	if err = json.NewDecoder(rr.Body).Decode(&started); err != nil {
+		t.Error(err)
+	}
+
+	if started.Token == "" || started.ImpersonatorId != 1 || started.UserId != user.UserId || started.Reason != "Ticket 42" || started.EndedAt != nil {
+		t.Error("Wrong impersonation :", started)
+	}
+
+	impersonationCookie := rr.Result().Cookies()[0]
+	if impersonationCookie.Value != started.Token {
+		t.Error("The cookie does not contain the impersonation token")
+	}
+
+	globals.Log.Debug("POST /users/{id}/impersonate - PASSED")
+
+	//
+	//	Requests made while impersonating a user
+	//
+	if rr = sendRequest(t, http.MethodGet, "/projects", nil, impersonationCookie); rr.Code != http.StatusOK {
+		t.Error("Could not use the impersonation token :", rr.Code)
+	}
+
+	if rr.Header().Get("X-Impersonated-By") != "1" || rr.Header().Get("X-Impersonated-User") != strconv.FormatInt(user.UserId, 10) {
+		t.Error("The answer is not flagged as impersonated")
+	}
+
+	// The impersonated user has his own permissions only
+	if rr = sendRequest(t, http.MethodDelete, "/projects/2", nil, impersonationCookie); rr.Code != http.StatusForbidden {
+		t.Error("The impersonation gave more permissions than the user has")
+	}
+
+	// An impersonation can't start another one, nor create other credentials
+	if rr = sendRequest(t, http.MethodPost, "/users/1/impersonate", nil, impersonationCookie); rr.Code != http.StatusForbidden {
+		t.Error("An impersonation was started from another one")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, userURL+"/tokens", handlers.AccessTokenRequest{Name: "Impersonated"}, impersonationCookie); rr.Code != http.StatusForbidden {
+		t.Error("An API token was created while impersonating a user")
+	}
+
+	globals.Log.Debug("Requests made while impersonating a user - PASSED")
+
+	//
+	//	GET /impersonations
+	//
+	if rr = sendRequest(t, http.MethodGet, "/impersonations", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user without the right to manage users listed the impersonations")
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/impersonations", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not list the impersonations")
+	}
+
+	if err = json.NewDecoder(rr.Body).Decode(&impersonations); err != nil {
+		t.Error(err)
+	}
+
+	if len(impersonations) != 1 || impersonations[0].ImpersonationId != started.ImpersonationId {
+		t.Error("Wrong impersonations :", impersonations)
+	}
+
+	globals.Log.Debug("GET /impersonations - PASSED")
+
+	//
+	//	GET /impersonations/{id}/actions
+	//
+	actionsURL := "/impersonations/" + strconv.FormatInt(started.ImpersonationId, 10) + "/actions"
+	if rr = sendRequest(t, http.MethodGet, actionsURL, nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not list the actions of the impersonation")
+	}
+
+	if err = json.NewDecoder(rr.Body).Decode(&actions); err != nil {
+		t.Error(err)
+	}
+
+	// Only the requests that may modify something are recorded
+	if len(actions) != 3 {
+		t.Fatal("Wrong number of recorded actions :", len(actions))
+	}
+
+	if actions[0].ImpersonatorId != 1 || actions[0].UserId != user.UserId || actions[0].Method != http.MethodDelete || actions[0].Path != "/v1/projects/2" || actions[0].StatusCode != http.StatusForbidden {
+		t.Error("Wrong recorded action :", actions[0])
+	}
+
+	globals.Log.Debug("GET /impersonations/{id}/actions - PASSED")
+
+	//
+	//	DELETE /impersonation
+	//
+	if rr = sendRequest(t, http.MethodDelete, "/impersonation", nil, tokenCookie); rr.Code != http.StatusBadRequest {
+		t.Error("An impersonation was stopped without being in progress")
+	}
+
+	if rr = sendRequest(t, http.MethodDelete, "/impersonation", nil, impersonationCookie); rr.Code != http.StatusOK {
+		t.Error("Could not stop the impersonation :", rr.Code, rr.Body.String())
+	}
+
+	// The admin gets his own token back
+	if rr = sendRequest(t, http.MethodGet, "/users", nil, rr.Result().Cookies()[0]); rr.Code != http.StatusOK {
+		t.Error("The token given back after the impersonation does not work")
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/projects", nil, impersonationCookie); rr.Code != http.StatusUnauthorized {
+		t.Error("The token of a stopped impersonation was accepted")
+	}
+
+	globals.Log.Debug("DELETE /impersonation - PASSED")
+
+	// Deleting the user, so the other tests are not disturbed
+	if err = env.DB.DeleteUser(user.UserId); err != nil {
+		t.Error(err)
+	}
+}
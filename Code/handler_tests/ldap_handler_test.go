@@ -0,0 +1,208 @@
+package handler_tests
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : POST /get-token with a LDAP directory
+	TESTED : POST /users with auth_provider
+	TESTED : POST /users/{id}/password for a directory user
+*/
+func TestLDAPHandler(t *testing.T) {
+	var (
+		err             error
+		user            model.User
+		localUser       model.User
+		cryptedPassword []byte
+	)
+
+	// Starting a stand-in directory
+	directory, err := authentication.StartLDAPStandIn([]authentication.LDAPStandInEntry{
+		{
+			DN:       "uid=jdupont,ou=people,dc=uca,dc=fr",
+			Password: "Directory-passw0rd",
+			Attributes: map[string][]string{
+				"uid":       {"jdupont"},
+				"mail":      {"jean.dupont@uca.fr"},
+				"givenName": {"Jean"},
+				"sn":        {"Dupont"},
+			},
+		},
+		{
+			DN:       "uid=mmartin,ou=people,dc=uca,dc=fr",
+			Password: "Directory-passw0rd",
+			Attributes: map[string][]string{
+				"uid":  {"mmartin"},
+				"mail": {"marie.martin@uca.fr"},
+			},
+		},
+		{
+			DN:       "uid=pbernard,ou=people,dc=uca,dc=fr",
+			Password: "Directory-passw0rd",
+			Attributes: map[string][]string{
+				"uid":  {"pbernard"},
+				"mail": {"pierre.bernard@uca.fr"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer directory.Close()
+
+	config := authentication.DefaultLDAPConfig(directory.URL(), "ou=people,dc=uca,dc=fr")
+	config.Provisioning = authentication.Provisioning{
+		Enabled:    true,
+		RoleId:     3,
+		ContractId: 1,
+	}
+	provider := &authentication.LDAPProvider{Config: config}
+
+	env.AuthProviders = map[string]authentication.Provider{provider.Name(): provider}
+	defer func() {
+		env.AuthProviders = nil
+	}()
+
+	//
+	//	POST /get-token : provisioning on the first login
+	//
+	if rr := login(t, "jdupont", "Wrong-passw0rd"); rr.Code != http.StatusUnauthorized {
+		t.Error("A wrong directory password did not return a 401 code")
+	}
+
+	if _, err = env.DB.GetUserFromEmail("jean.dupont@uca.fr"); err == nil {
+		t.Error("A user was created with a wrong password")
+	}
+
+	if rr := login(t, "jdupont", "Directory-passw0rd"); rr.Code != http.StatusOK {
+		t.Error("Could not log in with the directory")
+	}
+
+	if user, err = env.DB.GetUserFromEmail("jean.dupont@uca.fr"); err != nil {
+		t.Fatal("The user was not created on his first login")
+	}
+
+	if user.AuthProvider != "ldap" || user.RoleId != 3 || user.ContractId != 1 ||
+		user.Username != "jdupont" || user.FirstName != "Jean" || user.LastName != "Dupont" {
+		t.Error("Wrong provisioned user :", user)
+	}
+
+	// The next logins use the same user, with the username or the mail
+	if rr := login(t, "jean.dupont@uca.fr", "Directory-passw0rd"); rr.Code != http.StatusOK {
+		t.Error("Could not log in with the mail of a directory user")
+	}
+
+	if rr := login(t, "jdupont", "Directory-passw0rd"); rr.Code != http.StatusOK {
+		t.Error("Could not log in again with the directory")
+	}
+
+	// The password stored in the database is not usable
+	if rr := login(t, "jean.dupont@uca.fr", user.Password); rr.Code != http.StatusUnauthorized {
+		t.Error("A directory user could log in with the stored password")
+	}
+
+	globals.Log.Debug("POST /get-token with provisioning - PASSED")
+
+	//
+	//	POST /users/{id}/password : the password of a directory user can't be changed
+	//
+	if rr := sendRequest(t, http.MethodPost, "/users/"+strconv.FormatInt(user.UserId, 10)+"/password", handlers.PasswordChange{NewPassword: "New-Local-passw0rd"}, tokenCookie); rr.Code != http.StatusBadRequest {
+		t.Error("The password of a directory user was changed")
+	}
+
+	globals.Log.Debug("POST /users/{id}/password for a directory user - PASSED")
+
+	//
+	//	POST /get-token : without provisioning
+	//
+	config.Provisioning.Enabled = false
+	provider.Config = config
+
+	if rr := login(t, "mmartin", "Directory-passw0rd"); rr.Code != http.StatusUnauthorized {
+		t.Error("An unknown user could log in without provisioning")
+	}
+
+	// A local user with the same mail is not taken over by the directory
+	if cryptedPassword, err = bcrypt.GenerateFromPassword([]byte("Local-passw0rd"), bcrypt.DefaultCost); err != nil {
+		t.Error(err)
+	}
+
+	localUser = model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "pierre.bernard@uca.fr",
+		Password:   string(cryptedPassword),
+	}
+	if localUser.UserId, err = env.DB.CreateUser(localUser); err != nil {
+		t.Error(err)
+	}
+
+	if rr := login(t, "pbernard", "Directory-passw0rd"); rr.Code != http.StatusUnauthorized {
+		t.Error("A local user was authenticated by the directory")
+	}
+
+	if rr := login(t, "pierre.bernard@uca.fr", "Local-passw0rd"); rr.Code != http.StatusOK {
+		t.Error("A local user could not log in")
+	}
+
+	globals.Log.Debug("POST /get-token without provisioning - PASSED")
+
+	//
+	//	POST /users : a directory user is created without a password
+	//
+	if rr := sendRequest(t, http.MethodPost, "/users", model.User{
+		ContractId:   1,
+		RoleId:       3,
+		Username:     "mmartin",
+		Mail:         "marie.martin@uca.fr",
+		AuthProvider: "cas",
+	}, tokenCookie); rr.Code != http.StatusUnprocessableEntity {
+		t.Error("A user was created with an unknown provider")
+	}
+
+	if rr := sendRequest(t, http.MethodPost, "/users", model.User{
+		ContractId:   1,
+		RoleId:       3,
+		Username:     "mmartin",
+		Mail:         "marie.martin@uca.fr",
+		AuthProvider: "ldap",
+	}, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not create a directory user")
+	}
+
+	if rr := login(t, "marie.martin@uca.fr", "Directory-passw0rd"); rr.Code != http.StatusOK {
+		t.Error("A directory user created by an administrator could not log in")
+	}
+
+	globals.Log.Debug("POST /users with auth_provider - PASSED")
+
+	//
+	//	POST /get-token : the directory is unreachable
+	//
+	directory.Close()
+
+	if rr := login(t, "jean.dupont@uca.fr", "Directory-passw0rd"); rr.Code != http.StatusServiceUnavailable {
+		t.Error("An unreachable directory did not return a 503 code")
+	}
+
+	globals.Log.Debug("POST /get-token with an unreachable directory - PASSED")
+
+	// Deleting the users, so the other tests are not disturbed
+	for _, mail := range []string{"jean.dupont@uca.fr", "marie.martin@uca.fr", "pierre.bernard@uca.fr"} {
+		if user, err = env.DB.GetUserFromEmail(mail); err != nil {
+			t.Error(err)
+		}
+		if err = env.DB.DeleteUser(user.UserId); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package handler_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : POST /locks, POST /locks/unlock
+	TESTED : GET /locks/current, GET /locks
+	TESTED : The schedules refused before the lock date
+*/
+func TestLockDateHandler(t *testing.T) {
+	var (
+		err     error
+		rr      *httptest.ResponseRecorder
+		lock    handlers.LockDateIntermediate
+		locks   []handlers.LockDateIntermediate
+		fields  []string
+		created struct {
+			ScheduleId int64 `json:"schedule_id"`
+		}
+	)
+
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Lock password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "LockUser@mydb",
+		Password:   string(cryptedPassword),
+		TimeZone:   "UTC",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Lock project"})
+
+	userCookie := login(t, user.Mail, "Lock password").Result().Cookies()[0]
+
+	march := handlers.ScheduleIntermediate{
+		ProjectId: projectId,
+		StartDate: "2021-03-01T08:00:00Z",
+		EndDate:   "2021-03-01T12:00:00Z",
+	}
+
+	//
+	//	Only the managers change the lock date
+	//
+	if rr = sendRequest(t, http.MethodGet, "/locks/current", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the lock date :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&lock); err != nil || lock.LockDate != "" {
+		t.Error("A lock date was found before any lock :", lock, err)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/locks", handlers.LockRequest{LockDate: "2021-04-01"}, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user locked the hours :", rr.Code)
+	}
+	if rr = sendRequest(t, http.MethodGet, "/locks", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user saw the history of the lock date :", rr.Code)
+	}
+
+	//
+	//	Locking
+	//
+	rr = sendRequest(t, http.MethodPost, "/locks", handlers.LockRequest{}, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "lock_date") {
+		t.Error("The hours were locked without date :", fields)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/locks", handlers.LockRequest{LockDate: "2021-04-01", Reason: "March is closed"}, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not lock the hours :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&lock); err != nil || lock.LockDate == "" || lock.AuthorId != 1 {
+		t.Error("Wrong lock date :", lock, err)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/locks", handlers.LockRequest{LockDate: "2021-03-01"}, tokenCookie); rr.Code != http.StatusConflict {
+		t.Error("The lock date was moved back without unlock :", rr.Code)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/schedules", march, tokenCookie); rr.Code != http.StatusConflict || errorCode(rr) != handlers.ErrorPeriodLocked {
+		t.Error("A schedule before the lock date was created :", rr.Code, rr.Body.String())
+	}
+
+	globals.Log.Debug("POST /locks - PASSED")
+
+	//
+	//	Unlocking
+	//
+	rr = sendRequest(t, http.MethodPost, "/locks/unlock", handlers.LockRequest{}, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "reason") {
+		t.Error("The hours were unlocked without reason :", fields)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/locks/unlock", handlers.LockRequest{LockDate: "2021-05-01", Reason: "Later"}, tokenCookie); rr.Code != http.StatusConflict {
+		t.Error("The lock date was moved forward by an unlock :", rr.Code)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/locks/unlock", handlers.LockRequest{Reason: "Wrong project in March"}, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not unlock the hours :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&lock); err != nil || lock.LockDate != "" || lock.Reason != "Wrong project in March" {
+		t.Error("Wrong lock date :", lock, err)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/schedules", march, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("An unlocked schedule was refused :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Error(err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/locks", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the history of the lock date :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&locks); err != nil || len(locks) < 2 || locks[0].LockDate != "" || locks[1].Reason != "March is closed" {
+		t.Error("Wrong history :", locks, err)
+	}
+
+	globals.Log.Debug("POST /locks/unlock - PASSED")
+
+	// Deleting the data, so the other tests are not disturbed
+	for _, cleanup := range []error{
+		env.DB.DeleteSchedule(created.ScheduleId),
+		env.DB.DeleteProject(projectId),
+		env.DB.DeleteUser(user.UserId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package handler_tests
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// A Notifier that keeps the sent messages, so the tests can check them
+type fakeNotifier struct {
+	subjects []string
+}
+
+func (notifier *fakeNotifier) Notify(User model.User, Subject string, Message string) error {
+	notifier.subjects = append(notifier.subjects, User.Mail+" : "+Subject)
+	return nil
+}
+
+// Sends a POST /get-token request and returns its result
+func login(t *testing.T, Mail string, Password string) *httptest.ResponseRecorder {
+	var (
+		err        error
+		request    *http.Request
+		jsonObject []byte
+	)
+
+	if jsonObject, err = json.Marshal(model.User{
+		Mail:     Mail,
+		Password: Password,
+	}); err != nil {
+		t.Error(err)
+	}
+
+	if request, err = http.NewRequest(http.MethodPost, "/get-token", bytes.NewBuffer(jsonObject)); err != nil {
+		t.Error(err)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+
+	return rr
+}
+
+// Sends a DELETE /users/{id}/lock request as the admin and returns its result
+func unlock(t *testing.T, UserId int64) *httptest.ResponseRecorder {
+	var (
+		err     error
+		request *http.Request
+	)
+
+	if request, err = http.NewRequest(http.MethodDelete, "/users/"+strconv.FormatInt(UserId, 10)+"/lock", nil); err != nil {
+		t.Error(err)
+	}
+	addSession(request, tokenCookie)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+
+	return rr
+}
+
+/*
+	TESTED : POST /get-token
+	TESTED : DELETE /users/{id}/lock
+*/
+func TestLoginHandler(t *testing.T) {
+	var (
+		err             error
+		cryptedPassword []byte
+		rr              *httptest.ResponseRecorder
+	)
+
+	// Restoring the configuration at the end of the test
+	accountThrottling := globals.AccountThrottling
+	defer func() {
+		globals.AccountThrottling = accountThrottling
+		env.Notifier = nil
+	}()
+
+	notifier := &fakeNotifier{}
+	env.Notifier = notifier
+
+	// Creating a user to log in with
+	password := "Login-test-passw0rd"
+	if cryptedPassword, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost); err != nil {
+		t.Error(err)
+	}
+
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "LoginUser@mydb",
+		Password:   string(cryptedPassword),
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Error(err)
+	}
+
+	//
+	//	POST /get-token : the answer does not tell if the mail exists
+	//
+	wrongPassword := login(t, user.Mail, "Wrong-passw0rd")
+	unknownMail := login(t, "Unknown@mydb", "Wrong-passw0rd")
+
+	if wrongPassword.Code != http.StatusUnauthorized || unknownMail.Code != http.StatusUnauthorized {
+		t.Error("A failed login did not return a 401 code")
+	}
+
+	// Only the ids of the requests are different
+	var wrongPasswordError, unknownMailError handlers.ErrorResponse
+	json.NewDecoder(wrongPassword.Body).Decode(&wrongPasswordError)
+	json.NewDecoder(unknownMail.Body).Decode(&unknownMailError)
+	wrongPasswordError.Error.RequestId, unknownMailError.Error.RequestId = "", ""
+
+	if !cmp.Equal(wrongPasswordError, unknownMailError) || wrongPasswordError.Error.Message == "" {
+		t.Error("A failed login tells if the mail exists")
+	}
+
+	if rr = login(t, user.Mail, password); rr.Code != http.StatusOK {
+		t.Error("Could not log in")
+	}
+
+	globals.Log.Debug("POST /get-token with wrong credentials - PASSED")
+
+	//
+	//	POST /get-token : exponential back-off
+	//
+	globals.AccountThrottling = globals.ThrottlingRules{
+		FreeAttempts: 1,
+		BaseDelay:    time.Hour,
+		MaxDelay:     time.Hour,
+		ResetAfter:   time.Hour,
+	}
+
+	if rr = login(t, user.Mail, "Wrong-passw0rd"); rr.Code != http.StatusUnauthorized {
+		t.Error("A failed login did not return a 401 code")
+	}
+
+	// Even the right password is refused during the back-off
+	if rr = login(t, user.Mail, password); rr.Code != http.StatusTooManyRequests {
+		t.Error("A login was allowed during the back-off")
+	}
+
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("No Retry-After header during the back-off")
+	}
+
+	globals.Log.Debug("POST /get-token back-off - PASSED")
+
+	//
+	//	DELETE /users/{id}/lock
+	//
+	if rr = unlock(t, user.UserId); rr.Code != http.StatusOK {
+		t.Error("Could not unlock the user")
+	}
+
+	if rr = login(t, user.Mail, password); rr.Code != http.StatusOK {
+		t.Error("Could not log in after being unlocked")
+	}
+
+	// The two-factor codes are unlocked too
+	locked := sql.NullTime{Valid: true, Time: time.Now().Add(time.Hour)}
+	if err = env.DB.SaveLoginAttempt(model.LoginAttempt{AttemptKey: handlers.TOTPLoginKey(user.UserId), FailedAttempts: 10, LastFailure: locked, LockedUntil: locked}); err != nil {
+		t.Fatal(err)
+	}
+	if rr = unlock(t, user.UserId); rr.Code != http.StatusOK {
+		t.Error("Could not unlock the user")
+	}
+	if _, err = env.DB.GetLoginAttempt(handlers.TOTPLoginKey(user.UserId)); err != sql.ErrNoRows {
+		t.Error("The two-factor codes are still locked :", err)
+	}
+
+	globals.Log.Debug("DELETE /users/{id}/lock - PASSED")
+
+	//
+	//	POST /get-token : lockout
+	//
+	globals.AccountThrottling = globals.ThrottlingRules{
+		FreeAttempts:    10,
+		LockoutAttempts: 2,
+		LockoutDuration: time.Hour,
+		ResetAfter:      time.Hour,
+	}
+
+	login(t, user.Mail, "Wrong-passw0rd")
+	if len(notifier.subjects) != 0 {
+		t.Error("The user got notified before the lockout")
+	}
+
+	login(t, user.Mail, "Wrong-passw0rd")
+	if len(notifier.subjects) != 1 {
+		t.Error("The user did not get notified of the lockout")
+	}
+
+	if rr = login(t, user.Mail, password); rr.Code != http.StatusTooManyRequests {
+		t.Error("A login was allowed on a locked account")
+	}
+
+	if rr = unlock(t, user.UserId); rr.Code != http.StatusOK {
+		t.Error("Could not unlock the user")
+	}
+
+	if rr = login(t, user.Mail, password); rr.Code != http.StatusOK {
+		t.Error("Could not log in after being unlocked")
+	}
+
+	globals.Log.Debug("POST /get-token lockout - PASSED")
+
+	// Deleting the user, so the other tests are not disturbed
+	if err = env.DB.DeleteUser(user.UserId); err != nil {
+		t.Error(err)
+	}
+}
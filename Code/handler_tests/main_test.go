@@ -1,6 +1,7 @@
 package handler_tests
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
@@ -18,6 +20,9 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// The password given to the default admin when initializing the tests
+const adminPassword = "Adm1n-Test-Passw0rd"
+
 var (
 	env         *handlers.Env
 	r           *mux.Router
@@ -94,6 +99,24 @@ func InitializeDatabase() {
 	// Retrieving the token and storing it
 	tokenCookie = rr.Result().Cookies()[0]
 
+	// The default admin has to change his password before using the API
+	if jsonObject, err = json.Marshal(handlers.PasswordChange{
+		OldPassword: "Admin",
+		NewPassword: adminPassword,
+	}); err != nil {
+		panic(err)
+	}
+
+	rr = httptest.NewRecorder()
+	request, _ = http.NewRequest("POST", "/users/1/password", strings.NewReader(string(jsonObject)))
+	addSession(request, tokenCookie)
+
+	r.ServeHTTP(rr, request)
+
+	if rr.Code != http.StatusOK {
+		panic("Could not change the password of the default admin")
+	}
+
 	globals.Log.Debug("Initialized data for tests")
 }
 
@@ -371,3 +394,57 @@ func PopulateDatabase() {
 		panic(err)
 	}
 }
+
+// Sends a request to the router and returns its result.
+// The body is turned into JSON if it is not nil, and the cookie is added if it is not nil.
+func sendRequest(t *testing.T, Method string, URL string, Body interface{}, Cookie *http.Cookie) *httptest.ResponseRecorder {
+	var (
+		err        error
+		request    *http.Request
+		jsonObject []byte
+	)
+
+	if Body != nil {
+		if jsonObject, err = json.Marshal(Body); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if request, err = http.NewRequest(Method, URL, bytes.NewBuffer(jsonObject)); err != nil {
+		t.Error(err)
+	}
+
+	if Cookie != nil {
+		addSession(request, Cookie)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+
+	return rr
+}
+
+// Adds a session cookie to a request, with the CSRF token a web application would copy from the "csrf_token" cookie.
+// Here the token is read from the claims of the session, without verifying them.
+func addSession(Request *http.Request, Cookie *http.Cookie) {
+	Request.AddCookie(Cookie)
+
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(Cookie.Value, claims); err != nil {
+		return
+	}
+
+	if csrfToken, ok := claims["csrf"].(string); ok {
+		Request.AddCookie(&http.Cookie{Name: "csrf_token", Value: csrfToken})
+		Request.Header.Set("X-CSRF-Token", csrfToken)
+	}
+}
+
+// Returns the machine-readable code of an error answer, or an empty string
+func errorCode(rr *httptest.ResponseRecorder) string {
+	var answer handlers.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &answer); err != nil {
+		return ""
+	}
+	return answer.Error.Code
+}
@@ -0,0 +1,170 @@
+package handler_tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : GET /me
+	TESTED : GET /me/schedules
+	TESTED : GET /me/role
+	TESTED : GET /me/balance
+	TESTED : GET /users/{id}/balance
+*/
+func TestMeHandler(t *testing.T) {
+	var (
+		err       error
+		rr        *httptest.ResponseRecorder
+		me        model.User
+		role      model.Role
+		schedules []handlers.ScheduleIntermediate
+		balance   handlers.Balance
+	)
+
+	// A user without any right, working 2 hours on the monday of this week
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Me password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId:           1,
+		RoleId:               3,
+		Mail:                 "MeUser@mydb",
+		Password:             string(cryptedPassword),
+		TheoricalHoursWorked: 35,
+		VacationHours:        70,
+		TimeZone:             "UTC",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	monday := globals.StartOfWeek(time.Now(), time.UTC)
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Me project"})
+	scheduleId, _ := env.DB.CreateSchedule(model.Schedule{
+		ProjectId: projectId,
+		StartDate: sql.NullTime{Valid: true, Time: monday.Add(8 * time.Hour)},
+		EndDate:   sql.NullTime{Valid: true, Time: monday.Add(10 * time.Hour)},
+	})
+	if err = env.DB.CreateUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}); err != nil {
+		t.Error(err)
+	}
+
+	userCookie := login(t, user.Mail, "Me password").Result().Cookies()[0]
+
+	//
+	//	GET /me
+	//
+	if rr = sendRequest(t, http.MethodGet, "/me", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the connected user :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&me); err != nil {
+		t.Error(err)
+	}
+	if me.UserId != user.UserId || me.Mail != user.Mail {
+		t.Error("Not the connected user :", me)
+	}
+
+	me = model.User{}
+	if rr = sendRequest(t, http.MethodGet, "/me", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the connected user :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&me); err != nil || me.UserId != 1 {
+		t.Error("Not the connected administrator :", me, err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/me", nil, nil); rr.Code != http.StatusUnauthorized {
+		t.Error("Got the connected user without being connected :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /me - PASSED")
+
+	//
+	//	GET /me/schedules
+	//
+	// The user can't see the schedules of the others, but can see his own
+	if rr = sendRequest(t, http.MethodGet, "/me/schedules", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the schedules of the connected user :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&schedules); err != nil {
+		t.Error(err)
+	}
+	if len(schedules) != 1 || schedules[0].ScheduleId != scheduleId {
+		t.Error("Wrong schedules of the connected user :", schedules)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/users/1/schedules", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user saw the schedules of another user :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /me/schedules - PASSED")
+
+	//
+	//	GET /me/role
+	//
+	if rr = sendRequest(t, http.MethodGet, "/me/role", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the role of the connected user :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&role); err != nil || role.RoleId != 3 {
+		t.Error("Wrong role of the connected user :", role, err)
+	}
+
+	globals.Log.Debug("GET /me/role - PASSED")
+
+	//
+	//	GET /me/balance and GET /users/{id}/balance
+	//
+	if rr = sendRequest(t, http.MethodGet, "/me/balance", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the balance of the connected user :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&balance); err != nil {
+		t.Error(err)
+	}
+	expected := handlers.Balance{
+		UserId:                 user.UserId,
+		TimeZone:               "UTC",
+		WeekStart:              globals.FormatDate(monday, time.UTC),
+		WeekEnd:                globals.FormatDate(monday.AddDate(0, 0, 7), time.UTC),
+		TheoricalHours:         35,
+		Hours:                  2,
+		RemainingHours:         33,
+		RemainingVacationHours: 70,
+	}
+	if balance != expected {
+		t.Error("Wrong balance :", balance, "instead of", expected)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/users/1/balance", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user saw the balance of another user :", rr.Code)
+	}
+
+	balance = handlers.Balance{}
+	if rr = sendRequest(t, http.MethodGet, "/users/"+strconv.FormatInt(user.UserId, 10)+"/balance", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the balance of a user :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&balance); err != nil || balance != expected {
+		t.Error("Wrong balance :", balance, err)
+	}
+
+	globals.Log.Debug("GET /me/balance - PASSED")
+
+	// Deleting the data, so the other tests are not disturbed
+	for _, cleanup := range []error{
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}),
+		env.DB.DeleteSchedule(scheduleId),
+		env.DB.DeleteProject(projectId),
+		env.DB.DeleteUser(user.UserId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+}
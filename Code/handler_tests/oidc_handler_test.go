@@ -0,0 +1,214 @@
+package handler_tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// Goes through a whole login with an identity provider, like a browser, and returns the answer of the callback
+func oidcLogin(t *testing.T, Provider string) *httptest.ResponseRecorder {
+	var (
+		err      error
+		request  *http.Request
+		location *url.URL
+	)
+
+	// GET /oidc/{provider}/login redirects to the issuer
+	rr := sendRequest(t, http.MethodGet, "/oidc/"+Provider+"/login", nil, nil)
+	if rr.Code != http.StatusFound {
+		t.Fatal("The login did not redirect to the identity provider :", rr.Code)
+	}
+
+	cookies := rr.Result().Cookies()
+
+	// The issuer redirects to the callback
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	response, err := client.Get(rr.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	if location, err = url.Parse(response.Header.Get("Location")); err != nil {
+		t.Fatal(err)
+	}
+
+	if request, err = http.NewRequest(http.MethodGet, location.RequestURI(), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, cookie := range cookies {
+		request.AddCookie(cookie)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+
+	return rr
+}
+
+/*
+	TESTED : GET /oidc/{provider}/login
+	TESTED : GET /oidc/{provider}/callback
+*/
+func TestOIDCHandler(t *testing.T) {
+	var (
+		err  error
+		user model.User
+	)
+
+	// Starting a stand-in issuer
+	issuer, err := authentication.StartOIDCStandIn("gestion-tps", "Client-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+
+	provider := authentication.NewOIDCProvider(authentication.OIDCConfig{
+		Name:         "partner",
+		Issuer:       issuer.Issuer(),
+		ClientID:     "gestion-tps",
+		ClientSecret: "Client-secret",
+		RedirectURL:  "http://localhost:8080/oidc/partner/callback",
+		Provisioning: authentication.Provisioning{
+			Enabled:    true,
+			RoleId:     3,
+			ContractId: 1,
+		},
+	})
+
+	env.OIDCProviders = map[string]*authentication.OIDCProvider{"partner": provider}
+	defer func() {
+		env.OIDCProviders = nil
+	}()
+
+	//
+	//	GET /oidc/{provider}/login : unknown provider
+	//
+	if rr := sendRequest(t, http.MethodGet, "/oidc/unknown/login", nil, nil); rr.Code != http.StatusNotFound {
+		t.Error("An unknown identity provider did not return a 404 code")
+	}
+
+	globals.Log.Debug("GET /oidc/{provider}/login - PASSED")
+
+	//
+	//	GET /oidc/{provider}/callback : provisioning on the first login
+	//
+	issuer.SignIn(authentication.OIDCStandInUser{
+		Subject:      "1234",
+		Mail:         "jean.dupont@partner.fr",
+		MailVerified: true,
+		FirstName:    "Jean",
+		LastName:     "Dupont",
+	})
+
+	rr := oidcLogin(t, "partner")
+	if rr.Code != http.StatusOK {
+		t.Error("Could not log in with the identity provider :", rr.Code, rr.Body.String())
+	}
+
+	// The usual session token is given
+	var sessionCookie *http.Cookie
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == "token" && cookie.Value != "" {
+			sessionCookie = cookie
+		}
+	}
+	if sessionCookie == nil {
+		t.Fatal("No session token after the login")
+	}
+
+	if user, err = env.DB.GetUserFromEmail("jean.dupont@partner.fr"); err != nil {
+		t.Fatal("The user was not created on his first login")
+	}
+
+	if user.AuthProvider != "partner" || user.RoleId != 3 || user.FirstName != "Jean" || user.LastName != "Dupont" {
+		t.Error("Wrong provisioned user :", user)
+	}
+
+	// The session token works
+	if rr = sendRequest(t, http.MethodGet, "/projects", nil, sessionCookie); rr.Code != http.StatusOK {
+		t.Error("The session token of an identity provider login does not work :", rr.Code)
+	}
+
+	// The user of an identity provider can't log in with a password
+	if rr = login(t, user.Mail, user.Password); rr.Code != http.StatusUnauthorized {
+		t.Error("The user of an identity provider could log in with a password")
+	}
+
+	globals.Log.Debug("GET /oidc/{provider}/callback with provisioning - PASSED")
+
+	//
+	//	GET /oidc/{provider}/callback : the next logins use the same user
+	//
+	if rr = oidcLogin(t, "partner"); rr.Code != http.StatusOK {
+		t.Error("Could not log in again with the identity provider")
+	}
+
+	users, _ := env.DB.GetUsers()
+	count := 0
+	for _, u := range users {
+		if u.Mail == "jean.dupont@partner.fr" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Error("The user was created twice")
+	}
+
+	globals.Log.Debug("GET /oidc/{provider}/callback with an existing user - PASSED")
+
+	//
+	//	GET /oidc/{provider}/callback : refused logins
+	//
+
+	// A local user is not taken over by the identity provider
+	issuer.SignIn(authentication.OIDCStandInUser{Subject: "1", Mail: "admin@mydb", MailVerified: true})
+	if rr = oidcLogin(t, "partner"); rr.Code != http.StatusUnauthorized {
+		t.Error("A local user was taken over by the identity provider")
+	}
+
+	// An unverified mail is refused
+	issuer.SignIn(authentication.OIDCStandInUser{Subject: "5678", Mail: "marie.martin@partner.fr", MailVerified: false})
+	if rr = oidcLogin(t, "partner"); rr.Code != http.StatusUnauthorized {
+		t.Error("An unverified mail was accepted")
+	}
+
+	// Without provisioning, an unknown user is refused
+	provider.Config.Provisioning.Enabled = false
+	issuer.SignIn(authentication.OIDCStandInUser{Subject: "5678", Mail: "marie.martin@partner.fr", MailVerified: true})
+	if rr = oidcLogin(t, "partner"); rr.Code != http.StatusUnauthorized {
+		t.Error("An unknown user was accepted without provisioning")
+	}
+
+	// The callback needs the cookie of the login, and the same state
+	if rr = sendRequest(t, http.MethodGet, "/oidc/partner/callback?code=code&state=state", nil, nil); rr.Code != http.StatusBadRequest {
+		t.Error("A callback without login was accepted")
+	}
+
+	loginAnswer := sendRequest(t, http.MethodGet, "/oidc/partner/login", nil, nil)
+	request, _ := http.NewRequest(http.MethodGet, "/oidc/partner/callback?code=code&state=forged", nil)
+	for _, cookie := range loginAnswer.Result().Cookies() {
+		request.AddCookie(cookie)
+	}
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+	if rr.Code != http.StatusUnauthorized {
+		t.Error("A callback with a forged state was accepted")
+	}
+
+	globals.Log.Debug("GET /oidc/{provider}/callback refused logins - PASSED")
+
+	// Deleting the user, so the other tests are not disturbed
+	if err = env.DB.DeleteUser(user.UserId); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,135 @@
+package handler_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+)
+
+// Adds the references of a schema and of its properties to a list
+func schemaRefs(Schema *handlers.OpenAPISchema, Refs []string) []string {
+	if Schema == nil {
+		return Refs
+	}
+	if Schema.Ref != "" {
+		Refs = append(Refs, Schema.Ref)
+	}
+	for _, property := range Schema.Properties {
+		Refs = schemaRefs(property, Refs)
+	}
+	return schemaRefs(Schema.Items, Refs)
+}
+
+/*
+	TESTED : GET /v1/openapi.json
+	TESTED : GET /v1/docs
+*/
+func TestOpenAPIHandler(t *testing.T) {
+	var (
+		err  error
+		spec handlers.OpenAPIDocument
+		refs []string
+	)
+
+	//
+	//	GET /openapi.json
+	//
+	rr := sendRequest(t, http.MethodGet, "/v1/openapi.json", nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatal("Could not get the specification :", rr.Code, rr.Body.String())
+	}
+
+	if err = json.NewDecoder(rr.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") || len(spec.Servers) != 1 || spec.Servers[0].URL != handlers.V1Prefix {
+		t.Error("Not the OpenAPI 3 document of the first version :", spec.OpenAPI, spec.Servers)
+	}
+
+	// Every route of the router is in the specification
+	operations := 0
+	r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		pathTemplate, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			operations++
+			operation, ok := spec.Paths[strings.TrimPrefix(handlers.OpenAPIPath(pathTemplate), handlers.V1Prefix)][strings.ToLower(method)]
+			if !ok || operation.Summary == "" {
+				t.Error("The route", method, pathTemplate, "is missing from the specification")
+			}
+		}
+		return nil
+	})
+
+	// And nothing else
+	for path, pathOperations := range spec.Paths {
+		operations -= len(pathOperations)
+		for method, operation := range pathOperations {
+			if operation.RequestBody != nil {
+				for _, media := range operation.RequestBody.Content {
+					refs = schemaRefs(media.Schema, refs)
+				}
+			}
+			for _, response := range operation.Responses {
+				for _, media := range response.Content {
+					refs = schemaRefs(media.Schema, refs)
+				}
+			}
+
+			if _, ok := operation.Responses["default"]; !ok {
+				t.Error("The errors of", method, path, "are not described")
+			}
+		}
+	}
+	if operations != 0 {
+		t.Error("The specification does not have the operations of the router :", operations)
+	}
+
+	for _, schema := range spec.Components.Schemas {
+		refs = schemaRefs(schema, refs)
+	}
+	for _, ref := range refs {
+		if _, ok := spec.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; !ok {
+			t.Error("Unknown schema", ref)
+		}
+	}
+
+	// The schemas are the ones of the types
+	user := spec.Components.Schemas["User"]
+	if user == nil || user.Properties["time_zone"] == nil || user.Properties["function_id"] != nil || user.Properties["mail"].Format != "email" {
+		t.Error("Wrong schema of the users :", user)
+	}
+
+	if report := spec.Paths["/users/{id}/report"]["get"]; report == nil || len(report.Parameters) != 4 {
+		t.Error("Wrong parameters of the report :", report)
+	}
+
+	if login := spec.Paths["/get-token"]["post"]; login == nil || login.Security == nil || len(*login.Security) != 0 {
+		t.Error("The login is not public in the specification")
+	}
+
+	globals.Log.Debug("GET /v1/openapi.json - PASSED")
+
+	//
+	//	GET /docs
+	//
+	rr = sendRequest(t, http.MethodGet, "/v1/docs", nil, nil)
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html") || !strings.Contains(rr.Body.String(), "openapi.json") {
+		t.Error("Could not get the documentation page :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /v1/docs - PASSED")
+}
@@ -0,0 +1,158 @@
+package handler_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : The overlaps refused by POST /schedules and POST /users/{id}/schedules/{id}
+	TESTED : GET /users/{id}/overlaps
+*/
+func TestOverlapHandler(t *testing.T) {
+	var (
+		err     error
+		rr      *httptest.ResponseRecorder
+		created struct {
+			ScheduleId int64 `json:"schedule_id"`
+		}
+		overlaps []handlers.ScheduleOverlap
+		fields   []string
+	)
+
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Overlap password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "OverlapUser@mydb",
+		Password:   string(cryptedPassword),
+		TimeZone:   "UTC",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	userURL := "/users/" + strconv.FormatInt(user.UserId, 10)
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Overlap project"})
+	onCallId, _ := env.DB.CreateProject(model.Project{ProjectName: "Overlap on-call", AllowOverlaps: true})
+
+	userCookie := login(t, user.Mail, "Overlap password").Result().Cookies()[0]
+
+	morning := handlers.ScheduleIntermediate{
+		ProjectId: projectId,
+		StartDate: "2021-03-01T08:00:00Z",
+		EndDate:   "2021-03-01T12:00:00Z",
+		UserId:    user.UserId,
+	}
+	if rr = sendRequest(t, http.MethodPost, "/schedules", morning, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not create the schedule of a user :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Error(err)
+	}
+	scheduleIds := []int64{created.ScheduleId}
+
+	//
+	//	POST /schedules
+	//
+	overlapping := morning
+	overlapping.StartDate, overlapping.EndDate = "2021-03-01T11:00:00+01:00", "2021-03-01T14:00:00+01:00"
+	rr = sendRequest(t, http.MethodPost, "/schedules", overlapping, tokenCookie)
+	if body := decodeError(t, rr); rr.Code != http.StatusConflict || body.Code != handlers.ErrorScheduleOverlap || len(body.Conflicts) != 1 || body.Conflicts[0].ScheduleId != scheduleIds[0] {
+		t.Error("An overlapping schedule was not refused :", rr.Code, body)
+	}
+
+	overlapping.UserId = 999999
+	rr = sendRequest(t, http.MethodPost, "/schedules", overlapping, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "user_id") {
+		t.Error("A schedule was linked to an unknown user :", fields)
+	}
+
+	// The projects that allow overlaps are not checked
+	onCall := morning
+	onCall.ProjectId = onCallId
+	if rr = sendRequest(t, http.MethodPost, "/schedules", onCall, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("An on-call schedule was refused :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Error(err)
+	}
+	scheduleIds = append(scheduleIds, created.ScheduleId)
+
+	globals.Log.Debug("POST /schedules - PASSED")
+
+	//
+	//	POST /users/{id}/schedules/{id}
+	//
+	overlapping.UserId = 0
+	if rr = sendRequest(t, http.MethodPost, "/schedules", overlapping, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not create a schedule :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Error(err)
+	}
+	if rr = sendRequest(t, http.MethodPost, userURL+"/schedules/"+strconv.FormatInt(created.ScheduleId, 10), nil, tokenCookie); rr.Code != http.StatusConflict || errorCode(rr) != handlers.ErrorScheduleOverlap {
+		t.Error("An overlapping schedule was linked :", rr.Code)
+	}
+	if err = env.DB.DeleteSchedule(created.ScheduleId); err != nil {
+		t.Error(err)
+	}
+
+	globals.Log.Debug("POST /users/{id}/schedules/{id} - PASSED")
+
+	//
+	//	GET /users/{id}/overlaps
+	//
+	if rr = sendRequest(t, http.MethodGet, userURL+"/overlaps", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the overlaps :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&overlaps); err != nil || len(overlaps) != 0 {
+		t.Error("Wrong overlaps :", overlaps, err)
+	}
+
+	// Once the on-call project does not allow overlaps anymore, its schedule is listed
+	if rr = sendRequest(t, http.MethodPatch, "/projects/"+strconv.FormatInt(onCallId, 10), model.Project{ProjectName: "Overlap on-call"}, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not update the project :", rr.Code, rr.Body.String())
+	}
+	if rr = sendRequest(t, http.MethodGet, userURL+"/overlaps", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the overlaps :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&overlaps); err != nil || len(overlaps) != 1 {
+		t.Fatal("Wrong overlaps :", overlaps, err)
+	}
+	if overlaps[0].UserId != user.UserId || overlaps[0].First.ScheduleId != scheduleIds[0] || overlaps[0].Second.ScheduleId != scheduleIds[1] {
+		t.Error("Wrong overlap :", overlaps[0])
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/users/1/overlaps", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user saw the overlaps of another user :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /users/{id}/overlaps - PASSED")
+
+	// Deleting the data, so the other tests are not disturbed
+	for _, scheduleId := range scheduleIds {
+		if err = env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}); err != nil {
+			t.Error(err)
+		}
+		if err = env.DB.DeleteSchedule(scheduleId); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, cleanup := range []error{
+		env.DB.DeleteProject(projectId),
+		env.DB.DeleteProject(onCallId),
+		env.DB.DeleteUser(user.UserId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+}
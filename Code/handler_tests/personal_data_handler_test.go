@@ -0,0 +1,182 @@
+package handler_tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : GET /users/{id}/export
+	TESTED : POST /users/{id}/anonymize
+*/
+func TestPersonalDataHandler(t *testing.T) {
+	var (
+		err    error
+		rr     *httptest.ResponseRecorder
+		export handlers.PersonalDataExport
+		user   model.User
+	)
+
+	// A user with some data everywhere
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Personal password"), bcrypt.MinCost)
+	user = model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Username:   "jdurand",
+		FirstName:  "Jeanne",
+		LastName:   "Durand",
+		Mail:       "PersonalUser@mydb",
+		Password:   string(cryptedPassword),
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	userURL := "/users/" + strconv.FormatInt(user.UserId, 10)
+
+	companyId, _ := env.DB.CreateCompany(model.Company{CompanyName: "Personal company"})
+	functionId, _ := env.DB.CreateFunction(model.Function{FunctionName: "Personal function"})
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Personal project"})
+	scheduleId, _ := env.DB.CreateSchedule(model.Schedule{
+		ProjectId: projectId,
+		StartDate: sql.NullTime{Valid: true, Time: time.Now()},
+		EndDate:   sql.NullTime{Valid: true, Time: time.Now().Add(time.Hour)},
+	})
+	// The day after the schedule, so they don't overlap
+	vacationId, _ := env.DB.CreateVacation(model.Schedule{
+		StartDate: sql.NullTime{Valid: true, Time: time.Now().AddDate(0, 0, 1)},
+		EndDate:   sql.NullTime{Valid: true, Time: time.Now().AddDate(0, 0, 1).Add(7 * time.Hour)},
+	})
+	commentId, _ := env.DB.CreateComment(model.Comment{ScheduleId: scheduleId, Comment: "Jeanne left early"})
+
+	for _, link := range []error{
+		env.DB.CreateCompanyUser(model.CompanyUser{CompanyId: companyId, UserId: user.UserId}),
+		env.DB.CreateUserFunction(model.UserFunction{UserId: user.UserId, FunctionId: functionId}),
+		env.DB.CreateUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}),
+		env.DB.CreateUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: vacationId}),
+	} {
+		if link != nil {
+			t.Error(link)
+		}
+	}
+
+	userCookie := login(t, user.Mail, "Personal password").Result().Cookies()[0]
+
+	//
+	//	GET /users/{id}/export
+	//
+	if rr = sendRequest(t, http.MethodGet, userURL+"/export", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not export the personal data :", rr.Code, rr.Body.String())
+	}
+
+	if err = json.NewDecoder(rr.Body).Decode(&export); err != nil {
+		t.Error(err)
+	}
+
+	if export.Profile.Mail != user.Mail || export.Profile.Password != "" || export.Contract.ContractId != 1 || export.Role.RoleId != 3 {
+		t.Error("Wrong profile in the export :", export.Profile)
+	}
+
+	if len(export.Functions) != 1 || len(export.Companies) != 1 || len(export.Schedules) != 1 || len(export.Vacations) != 1 || len(export.Comments) != 1 {
+		t.Error("Missing data in the export :", export)
+	}
+
+	if rr.Header().Get("Content-Disposition") == "" {
+		t.Error("The export is not given as a file")
+	}
+
+	// Only the users that can add and modify users export the data of the others
+	if rr = sendRequest(t, http.MethodGet, "/users/1/export", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user exported the data of another user")
+	}
+
+	if rr = sendRequest(t, http.MethodGet, userURL+"/export?format=zip", nil, tokenCookie); rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "application/zip" {
+		t.Fatal("Could not export the personal data as an archive :", rr.Code)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]bool{}
+	for _, file := range archive.File {
+		files[file.Name] = true
+	}
+	for _, name := range []string{"profile.json", "contract.json", "role.json", "functions.json", "companies.json", "schedules.json", "vacations.json", "comments.json"} {
+		if !files["user-"+strconv.FormatInt(user.UserId, 10)+"-export/"+name] {
+			t.Error("The archive does not contain", name)
+		}
+	}
+
+	if rr = sendRequest(t, http.MethodGet, userURL+"/export?format=xml", nil, tokenCookie); rr.Code != http.StatusBadRequest {
+		t.Error("An unknown format was accepted")
+	}
+
+	globals.Log.Debug("GET /users/{id}/export - PASSED")
+
+	//
+	//	POST /users/{id}/anonymize
+	//
+	if rr = sendRequest(t, http.MethodPost, "/users/1/anonymize", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user without the right to manage users anonymized somebody")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/users/1/anonymize", nil, tokenCookie); rr.Code != http.StatusBadRequest {
+		t.Error("A user anonymized himself")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, userURL+"/anonymize", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not anonymize the user :", rr.Code, rr.Body.String())
+	}
+
+	anonymous, _ := env.DB.GetUser(user.UserId)
+	if anonymous.FirstName != "" || anonymous.LastName != "" || anonymous.Mail == user.Mail || anonymous.Username == user.Username || anonymous.Password == user.Password {
+		t.Error("The user was not anonymized :", anonymous)
+	}
+
+	if rr = login(t, user.Mail, "Personal password"); rr.Code != http.StatusUnauthorized {
+		t.Error("An anonymized user could log in")
+	}
+
+	// The hours are kept for the reports, but not the comments
+	if schedules, _ := env.DB.GetSchedulesOfUser(user.UserId); len(schedules) != 2 {
+		t.Error("The schedules of the anonymized user were lost")
+	}
+
+	if comment, _ := env.DB.GetComment(commentId); comment.Comment != "" {
+		t.Error("The comment of the anonymized user was kept")
+	}
+
+	globals.Log.Debug("POST /users/{id}/anonymize - PASSED")
+
+	// Deleting the data, so the other tests are not disturbed
+	for _, cleanup := range []error{
+		env.DB.DeleteComment(commentId),
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}),
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: vacationId}),
+		env.DB.DeleteUserFunction(model.UserFunction{UserId: user.UserId, FunctionId: functionId}),
+		env.DB.DeleteCompanyUser(model.CompanyUser{CompanyId: companyId, UserId: user.UserId}),
+		env.DB.DeleteSchedule(scheduleId),
+		env.DB.DeleteVacation(vacationId),
+		env.DB.DeleteProject(projectId),
+		env.DB.DeleteFunction(functionId),
+		env.DB.DeleteCompany(companyId),
+		env.DB.DeleteUser(user.UserId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+}
@@ -46,7 +46,7 @@ func TestProjectHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/projects", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing the request
 	r.ServeHTTP(rr, request)
 
@@ -77,7 +77,7 @@ func TestProjectHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPost, "/projects", bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -99,7 +99,7 @@ func TestProjectHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/projects/"+strconv.FormatInt(project1.ProjectId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -125,7 +125,7 @@ func TestProjectHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/companies/1/projects", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -152,7 +152,7 @@ func TestProjectHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/1/projects", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -185,7 +185,7 @@ func TestProjectHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPatch, "/projects/"+strconv.FormatInt(project.ProjectId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -193,7 +193,7 @@ func TestProjectHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/projects/"+strconv.FormatInt(project.ProjectId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -216,7 +216,7 @@ func TestProjectHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodDelete, "/projects/"+strconv.FormatInt(project.ProjectId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -224,7 +224,7 @@ func TestProjectHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/projects/"+strconv.FormatInt(project.ProjectId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
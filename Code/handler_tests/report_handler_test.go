@@ -0,0 +1,184 @@
+package handler_tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : GET /users/{id}/report
+	TESTED : The time zones of the users and of the companies
+*/
+func TestReportHandler(t *testing.T) {
+	var (
+		err    error
+		rr     *httptest.ResponseRecorder
+		report handlers.Report
+		fields []string
+	)
+
+	// A user without a zone, in a company of New York
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Report password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "ReportUser@mydb",
+		Password:   string(cryptedPassword),
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	reportURL := "/users/" + strconv.FormatInt(user.UserId, 10) + "/report"
+
+	companyId, _ := env.DB.CreateCompany(model.Company{CompanyName: "Report company", TimeZone: "America/New_York"})
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Report project"})
+
+	// Working the night the clocks of Paris go forward : from 22:00 (+01:00) to 04:00 (+02:00), so 5 hours
+	scheduleId, _ := env.DB.CreateSchedule(model.Schedule{
+		ProjectId: projectId,
+		StartDate: sql.NullTime{Valid: true, Time: time.Date(2021, 3, 27, 21, 0, 0, 0, time.UTC)},
+		EndDate:   sql.NullTime{Valid: true, Time: time.Date(2021, 3, 28, 2, 0, 0, 0, time.UTC)},
+	})
+	// And a day off on the monday after
+	vacationId, _ := env.DB.CreateVacation(model.Schedule{
+		StartDate: sql.NullTime{Valid: true, Time: time.Date(2021, 3, 29, 7, 0, 0, 0, time.UTC)},
+		EndDate:   sql.NullTime{Valid: true, Time: time.Date(2021, 3, 29, 15, 0, 0, 0, time.UTC)},
+	})
+
+	for _, link := range []error{
+		env.DB.CreateCompanyUser(model.CompanyUser{CompanyId: companyId, UserId: user.UserId}),
+		env.DB.CreateUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}),
+		env.DB.CreateUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: vacationId}),
+	} {
+		if link != nil {
+			t.Error(link)
+		}
+	}
+
+	userCookie := login(t, user.Mail, "Report password").Result().Cookies()[0]
+
+	//
+	//	The zone of the company is used when the user has none
+	//
+	if rr = sendRequest(t, http.MethodGet, reportURL+"?from=2021-03-27&to=2021-03-27", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the report :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Error(err)
+	}
+
+	// In New York, the whole night is on the 27th
+	if report.TimeZone != "America/New_York" || report.Hours != 5 || report.Periods[0].Start != "2021-03-27T00:00:00-04:00" {
+		t.Error("The zone of the company was not used :", report)
+	}
+
+	globals.Log.Debug("Zone of the company - PASSED")
+
+	//
+	//	The days of the zone of the user, the day the clocks change
+	//
+	user, _ = env.DB.GetUser(user.UserId)
+	user.TimeZone = "Europe/Paris"
+	if _, err = env.DB.UpdateUser(user); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, reportURL+"?from=2021-03-27&to=2021-03-28&period=day", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the report :", rr.Code, rr.Body.String())
+	}
+	report = handlers.Report{}
+	if err = json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Error(err)
+	}
+
+	expected := []handlers.ReportPeriod{
+		{Start: "2021-03-27T00:00:00+01:00", End: "2021-03-28T00:00:00+01:00", Hours: 2},
+		{Start: "2021-03-28T00:00:00+01:00", End: "2021-03-29T00:00:00+02:00", Hours: 3},
+	}
+	if report.TimeZone != "Europe/Paris" || report.Hours != 5 || len(report.Periods) != len(expected) {
+		t.Fatal("Wrong report :", report)
+	}
+	for i := range expected {
+		if report.Periods[i] != expected[i] {
+			t.Error("Wrong day in the report :", report.Periods[i], "instead of", expected[i])
+		}
+	}
+
+	globals.Log.Debug("Days of the zone of the user - PASSED")
+
+	//
+	//	The weeks start on monday, and the vacations are counted apart
+	//
+	if rr = sendRequest(t, http.MethodGet, reportURL+"?from=2021-03-24&to=2021-03-30&period=week", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the report :", rr.Code, rr.Body.String())
+	}
+	report = handlers.Report{}
+	if err = json.NewDecoder(rr.Body).Decode(&report); err != nil {
+		t.Error(err)
+	}
+
+	expected = []handlers.ReportPeriod{
+		{Start: "2021-03-22T00:00:00+01:00", End: "2021-03-29T00:00:00+02:00", Hours: 5},
+		{Start: "2021-03-29T00:00:00+02:00", End: "2021-04-05T00:00:00+02:00", VacationHours: 8},
+	}
+	if report.Hours != 5 || report.VacationHours != 8 || len(report.Periods) != len(expected) {
+		t.Fatal("Wrong report :", report)
+	}
+	for i := range expected {
+		if report.Periods[i] != expected[i] {
+			t.Error("Wrong week in the report :", report.Periods[i], "instead of", expected[i])
+		}
+	}
+
+	globals.Log.Debug("Weeks of the report - PASSED")
+
+	//
+	//	Rights and invalid requests
+	//
+	if rr = sendRequest(t, http.MethodGet, "/users/1/report?from=2021-03-27&to=2021-03-28", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user saw the report of another user :", rr.Code)
+	}
+
+	rr = sendRequest(t, http.MethodGet, reportURL+"?to=2021-03-32&period=month", nil, userCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "period", "from", "to") {
+		t.Error("Wrong violations for a report :", fields)
+	}
+
+	rr = sendRequest(t, http.MethodGet, reportURL+"?from=2021-03-28&to=2021-03-27", nil, userCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "to") {
+		t.Error("A report ending before its start was accepted :", fields)
+	}
+
+	rr = sendRequest(t, http.MethodPost, "/companies", model.Company{CompanyName: "Lost company", TimeZone: "Europe/Atlantis"}, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "time_zone") {
+		t.Error("An unknown time zone was accepted :", fields)
+	}
+
+	globals.Log.Debug("Rights and invalid requests - PASSED")
+
+	// Deleting the data, so the other tests are not disturbed
+	for _, cleanup := range []error{
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}),
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: vacationId}),
+		env.DB.DeleteCompanyUser(model.CompanyUser{CompanyId: companyId, UserId: user.UserId}),
+		env.DB.DeleteSchedule(scheduleId),
+		env.DB.DeleteVacation(vacationId),
+		env.DB.DeleteProject(projectId),
+		env.DB.DeleteCompany(companyId),
+		env.DB.DeleteUser(user.UserId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+}
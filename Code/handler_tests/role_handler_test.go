@@ -45,7 +45,7 @@ func TestRoleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/roles", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing the request
 	r.ServeHTTP(rr, request)
 
@@ -81,7 +81,7 @@ func TestRoleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPost, "/roles", bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -104,7 +104,7 @@ func TestRoleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/roles/"+strconv.FormatInt(role1.RoleId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -130,7 +130,7 @@ func TestRoleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/1/role", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -163,7 +163,7 @@ func TestRoleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPatch, "/roles/"+strconv.FormatInt(role.RoleId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -171,7 +171,7 @@ func TestRoleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/roles/"+strconv.FormatInt(role.RoleId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -194,7 +194,7 @@ func TestRoleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodDelete, "/roles/"+strconv.FormatInt(role.RoleId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -202,7 +202,7 @@ func TestRoleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/roles/"+strconv.FormatInt(role.RoleId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -59,7 +59,7 @@ func TestScheduleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/1/schedules", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -104,7 +104,7 @@ func TestScheduleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/projects/1/schedules", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -135,8 +135,8 @@ func TestScheduleHandler(t *testing.T) {
 	//
 	SI2 := handlers.ScheduleIntermediate{
 		ProjectId: 2,
-		StartDate: "2015-06-15 10:19:30",
-		EndDate:   "2020-06-15 10:19:30",
+		StartDate: "2015-06-15T10:19:30+02:00",
+		EndDate:   "2020-06-15T10:19:30+02:00",
 	}
 	// Turning the object into JSON
 	if jsonObject, err = json.Marshal(SI2); err != nil {
@@ -146,7 +146,7 @@ func TestScheduleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPost, "/schedules", bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -174,7 +174,7 @@ func TestScheduleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/schedules/"+strconv.FormatInt(SI2.ScheduleId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -204,9 +204,9 @@ func TestScheduleHandler(t *testing.T) {
 	//
 
 	// Fetching a schedule
-	ISpatch := handlers.ScheduleToIntermediate(fakeSchedules[2])
+	ISpatch := handlers.ScheduleToIntermediate(fakeSchedules[2], globals.DefaultTimeZone)
 	// Modifying it
-	ISpatch.StartDate = "2007-05-30 10:09:27"
+	ISpatch.StartDate = "2007-05-30T10:09:27+02:00"
 	// JSON-ing the object
 	if jsonObject, err = json.Marshal(ISpatch); err != nil {
 		t.Error(err)
@@ -216,7 +216,7 @@ func TestScheduleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPatch, "/schedules/"+strconv.FormatInt(ISpatch.ScheduleId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -224,7 +224,7 @@ func TestScheduleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/schedules/"+strconv.FormatInt(ISpatch.ScheduleId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -247,7 +247,7 @@ func TestScheduleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodDelete, "/schedules/"+strconv.FormatInt(ISpatch.ScheduleId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -255,7 +255,7 @@ func TestScheduleHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/schedules/"+strconv.FormatInt(ISpatch.ScheduleId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -0,0 +1,366 @@
+package handler_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : POST /series, PATCH /series/{id}, DELETE /series/{id}
+	TESTED : GET /series/{id}, GET /users/{id}/series, GET /me/series
+	TESTED : PATCH /schedules/{id} and DELETE /schedules/{id} with a scope
+	TESTED : The extension of the series as the time goes
+*/
+func TestSeriesHandler(t *testing.T) {
+	var (
+		err     error
+		rr      *httptest.ResponseRecorder
+		series  handlers.SeriesIntermediate
+		list    []handlers.SeriesIntermediate
+		fields  []string
+		created struct {
+			ScheduleId int64 `json:"schedule_id"`
+		}
+	)
+
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Series password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "SeriesUser@mydb",
+		Password:   string(cryptedPassword),
+		TimeZone:   "Europe/Paris",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Series project"})
+
+	userCookie := login(t, user.Mail, "Series password").Result().Cookies()[0]
+
+	// Answers with the series of a request, or stops the test
+	seriesOf := func(rr *httptest.ResponseRecorder) handlers.SeriesIntermediate {
+		var series handlers.SeriesIntermediate
+		if rr.Code != http.StatusOK {
+			t.Fatal("Wrong answer :", rr.Code, rr.Body.String())
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&series); err != nil {
+			t.Fatal(err)
+		}
+		return series
+	}
+	startsOf := func(Series handlers.SeriesIntermediate) []string {
+		starts := []string{}
+		for _, schedule := range Series.Schedules {
+			starts = append(starts, schedule.StartDate)
+		}
+		return starts
+	}
+	scheduleURL := func(Schedule handlers.ScheduleIntermediate, Scope string) string {
+		return "/schedules/" + strconv.FormatInt(Schedule.ScheduleId, 10) + "?scope=" + Scope
+	}
+
+	// From 8 to 12 in Paris, on the mondays and the wednesdays
+	mondays := handlers.SeriesIntermediate{
+		ProjectId:      projectId,
+		UserId:         user.UserId,
+		StartDate:      "2021-03-01T08:00:00+01:00",
+		EndDate:        "2021-03-01T12:00:00+01:00",
+		RecurrenceRule: "RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4",
+	}
+
+	//
+	//	POST /series
+	//
+	series = seriesOf(sendRequest(t, http.MethodPost, "/series", mondays, userCookie))
+	if series.SeriesId == 0 || series.TimeZone != "Europe/Paris" || series.RecurrenceRule != "FREQ=WEEKLY;COUNT=4;BYDAY=MO,WE" {
+		t.Error("Wrong created series :", series)
+	}
+	if starts := startsOf(series); !sameFields(starts, "2021-03-01T08:00:00+01:00", "2021-03-03T08:00:00+01:00", "2021-03-08T08:00:00+01:00", "2021-03-10T08:00:00+01:00") {
+		t.Error("Wrong occurrences :", starts)
+	}
+	if series.Schedules[0].SeriesId != series.SeriesId || series.Schedules[0].EndDate != "2021-03-01T12:00:00+01:00" {
+		t.Error("Wrong first occurrence :", series.Schedules[0])
+	}
+	seriesId := series.SeriesId
+
+	wrong := mondays
+	wrong.RecurrenceRule = "FREQ=YEARLY"
+	wrong.TimeZone = "Nowhere/Somewhere"
+	rr = sendRequest(t, http.MethodPost, "/series", wrong, userCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "time_zone") {
+		t.Error("A series in a wrong zone was created :", fields)
+	}
+	wrong.TimeZone = "UTC"
+	wrong.EndDate = wrong.StartDate
+	rr = sendRequest(t, http.MethodPost, "/series", wrong, userCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "end_date", "recurrence_rule") {
+		t.Error("A wrong series was created :", fields)
+	}
+
+	// A series that overlaps the other schedules of the user is refused
+	overlapping := mondays
+	overlapping.StartDate, overlapping.EndDate = "2021-03-08T11:00:00+01:00", "2021-03-08T13:00:00+01:00"
+	overlapping.RecurrenceRule = "FREQ=DAILY;COUNT=2"
+	if rr = sendRequest(t, http.MethodPost, "/series", overlapping, userCookie); rr.Code != http.StatusConflict || errorCode(rr) != handlers.ErrorScheduleOverlap {
+		t.Error("An overlapping series was created :", rr.Code, rr.Body.String())
+	}
+
+	globals.Log.Debug("POST /series - PASSED")
+
+	//
+	//	GET /series/{id}, GET /users/{id}/series and GET /me/series
+	//
+	if series = seriesOf(sendRequest(t, http.MethodGet, "/series/"+strconv.FormatInt(seriesId, 10), nil, userCookie)); len(series.Schedules) != 4 {
+		t.Error("Wrong series :", series)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/me/series", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the series of the connected user :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&list); err != nil || len(list) != 1 || list[0].SeriesId != seriesId || len(list[0].Schedules) != 0 {
+		t.Error("Wrong series of the connected user :", list, err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/users/"+strconv.FormatInt(user.UserId, 10)+"/series", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not get the series of a user :", rr.Code, rr.Body.String())
+	}
+	if rr = sendRequest(t, http.MethodGet, "/users/1/series", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("The series of another user were seen :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /series/{id} - PASSED")
+
+	//
+	//	DELETE /schedules/{id} of one occurrence, and the wrong scopes
+	//
+	if rr = sendRequest(t, http.MethodDelete, scheduleURL(series.Schedules[1], "all"), nil, userCookie); !sameFields(violatedFields(t, rr), "scope") {
+		t.Error("A wrong scope was accepted :", rr.Code)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/schedules", handlers.ScheduleIntermediate{ProjectId: projectId, StartDate: "2021-03-02T08:00:00Z", EndDate: "2021-03-02T09:00:00Z"}, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not create a schedule :", rr.Code, rr.Body.String())
+	}
+	json.NewDecoder(rr.Body).Decode(&created)
+	alone := handlers.ScheduleIntermediate{ScheduleId: created.ScheduleId}
+	if rr = sendRequest(t, http.MethodDelete, scheduleURL(alone, handlers.ScopeSeries), nil, userCookie); !sameFields(violatedFields(t, rr), "scope") {
+		t.Error("A schedule out of any series was deleted as a series :", rr.Code)
+	}
+	if rr = sendRequest(t, http.MethodDelete, scheduleURL(alone, ""), nil, userCookie); rr.Code != http.StatusOK {
+		t.Error("Could not delete a schedule out of any series :", rr.Code, rr.Body.String())
+	}
+
+	// The deleted occurrence becomes an exception of the series
+	if rr = sendRequest(t, http.MethodDelete, scheduleURL(series.Schedules[1], ""), nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not delete an occurrence :", rr.Code, rr.Body.String())
+	}
+	series = seriesOf(sendRequest(t, http.MethodGet, "/series/"+strconv.FormatInt(seriesId, 10), nil, userCookie))
+	if len(series.Schedules) != 3 || !sameFields(series.ExceptionDates, "2021-03-03T08:00:00+01:00") {
+		t.Error("Wrong series after the deletion of an occurrence :", series)
+	}
+
+	globals.Log.Debug("DELETE /schedules/{id} of an occurrence - PASSED")
+
+	//
+	//	PATCH /schedules/{id} with a scope
+	//
+
+	// One hour later from the monday 8th : the following occurrences become a new series, with what is left of COUNT
+	moved := series.Schedules[1]
+	moved.StartDate, moved.EndDate = "2021-03-08T09:00:00+01:00", "2021-03-08T12:00:00+01:00"
+	following := seriesOf(sendRequest(t, http.MethodPatch, scheduleURL(series.Schedules[1], handlers.ScopeFollowing), moved, userCookie))
+	if following.SeriesId == seriesId || following.RecurrenceRule != "FREQ=WEEKLY;COUNT=2;BYDAY=MO,WE" || !sameFields(startsOf(following), "2021-03-08T09:00:00+01:00", "2021-03-10T09:00:00+01:00") {
+		t.Error("Wrong following series :", following)
+	}
+	if following.Schedules[1].EndDate != "2021-03-10T12:00:00+01:00" {
+		t.Error("Wrong length of the following occurrences :", following.Schedules[1])
+	}
+	series = seriesOf(sendRequest(t, http.MethodGet, "/series/"+strconv.FormatInt(seriesId, 10), nil, userCookie))
+	if series.RecurrenceRule != "FREQ=WEEKLY;COUNT=2;BYDAY=MO,WE" || !sameFields(startsOf(series), "2021-03-01T08:00:00+01:00") {
+		t.Error("Wrong series before the change :", series)
+	}
+
+	// The whole new series can't move back one hour, as all its occurrences are past
+	moved = following.Schedules[1]
+	moved.StartDate, moved.EndDate = "2021-03-10T08:00:00+01:00", "2021-03-10T10:00:00+01:00"
+	if fields = violatedFields(t, sendRequest(t, http.MethodPatch, scheduleURL(following.Schedules[1], handlers.ScopeSeries), moved, userCookie)); !sameFields(fields, "recurrence_rule") {
+		t.Error("A series without any occurrence left was changed :", fields)
+	}
+
+	globals.Log.Debug("PATCH /schedules/{id} with a scope - PASSED")
+
+	//
+	//	DELETE /schedules/{id} with a scope
+	//
+	if rr = sendRequest(t, http.MethodDelete, scheduleURL(following.Schedules[1], handlers.ScopeFollowing), nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not delete the following occurrences :", rr.Code, rr.Body.String())
+	}
+	following = seriesOf(sendRequest(t, http.MethodGet, "/series/"+strconv.FormatInt(following.SeriesId, 10), nil, userCookie))
+	if following.RecurrenceRule != "FREQ=WEEKLY;COUNT=1;BYDAY=MO,WE" || len(following.Schedules) != 1 {
+		t.Error("Wrong series after the deletion of the following occurrences :", following)
+	}
+
+	if rr = sendRequest(t, http.MethodDelete, scheduleURL(following.Schedules[0], handlers.ScopeSeries), nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not delete the series of an occurrence :", rr.Code, rr.Body.String())
+	}
+	if rr = sendRequest(t, http.MethodGet, "/series/"+strconv.FormatInt(following.SeriesId, 10), nil, userCookie); rr.Code != http.StatusNotFound {
+		t.Error("The series was not deleted :", rr.Code)
+	}
+
+	globals.Log.Debug("DELETE /schedules/{id} with a scope - PASSED")
+
+	//
+	//	PATCH /series/{id}
+	//
+	daily := mondays
+	daily.RecurrenceRule = "FREQ=DAILY;COUNT=3"
+	daily.ExceptionDates = []string{"2021-03-02T07:00:00Z"}
+	if fields = violatedFields(t, sendRequest(t, http.MethodPatch, "/series/"+strconv.FormatInt(seriesId, 10), daily, userCookie)); !sameFields(fields, "recurrence_rule") {
+		t.Error("A series without any occurrence left was changed :", fields)
+	}
+	if series = seriesOf(sendRequest(t, http.MethodGet, "/series/"+strconv.FormatInt(seriesId, 10), nil, userCookie)); series.RecurrenceRule != "FREQ=WEEKLY;COUNT=2;BYDAY=MO,WE" {
+		t.Error("The refused change was saved :", series)
+	}
+	if rr = sendRequest(t, http.MethodPatch, "/series/0", daily, userCookie); rr.Code != http.StatusNotFound {
+		t.Error("An unknown series was changed :", rr.Code)
+	}
+
+	globals.Log.Debug("PATCH /series/{id} - PASSED")
+
+	//
+	//	The series are extended as the time goes
+	//
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(27 * time.Hour)
+	endless := mondays
+	endless.StartDate, endless.EndDate = tomorrow.Format(time.RFC3339), tomorrow.Add(time.Hour).Format(time.RFC3339)
+	endless.TimeZone = "UTC"
+	endless.RecurrenceRule = "FREQ=WEEKLY"
+	endless = seriesOf(sendRequest(t, http.MethodPost, "/series", endless, userCookie))
+	saved := len(endless.Schedules)
+	if saved < 12 || saved > 14 {
+		t.Error("Wrong occurrences saved until the horizon :", saved)
+	}
+
+	if extended, err := env.ExtendSeries(time.Now().Add(14 * 24 * time.Hour)); err != nil || extended < 1 {
+		t.Error("No series was extended :", extended, err)
+	}
+	if endless = seriesOf(sendRequest(t, http.MethodGet, "/series/"+strconv.FormatInt(endless.SeriesId, 10), nil, userCookie)); len(endless.Schedules) != saved+2 {
+		t.Error("Wrong occurrences of the extended series :", len(endless.Schedules), saved)
+	}
+
+	// Its following occurrences are created again one hour later
+	endless.StartDate, endless.EndDate = tomorrow.Add(time.Hour).Format(time.RFC3339), tomorrow.Add(2*time.Hour).Format(time.RFC3339)
+	endless.Schedules = nil
+	endless = seriesOf(sendRequest(t, http.MethodPatch, "/series/"+strconv.FormatInt(endless.SeriesId, 10), endless, userCookie))
+	if first, err := time.Parse(time.RFC3339, endless.Schedules[0].StartDate); err != nil || !first.Equal(tomorrow.Add(time.Hour)) || len(endless.Schedules) < 12 {
+		t.Error("Wrong following occurrences of the changed series :", endless.Schedules, err)
+	}
+
+	globals.Log.Debug("Extension of the series - PASSED")
+
+	//
+	//	PATCH /schedules/{id} to another day of the week
+	//
+	monday := tomorrow.Truncate(24*time.Hour).AddDate(0, 0, (8-int(tomorrow.Weekday()))%7).Add(10 * time.Hour)
+	weekdays := endless
+	weekdays.StartDate, weekdays.EndDate = monday.Format(time.RFC3339), monday.Add(time.Hour).Format(time.RFC3339)
+	weekdays.RecurrenceRule = "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4"
+	weekdays = seriesOf(sendRequest(t, http.MethodPost, "/series", weekdays, userCookie))
+
+	// Moved from the monday to the tuesday, the occurrences are on the tuesdays and the thursdays
+	moved = weekdays.Schedules[0]
+	moved.StartDate, moved.EndDate = monday.AddDate(0, 0, 1).Format(time.RFC3339), monday.AddDate(0, 0, 1).Add(time.Hour).Format(time.RFC3339)
+	weekdays = seriesOf(sendRequest(t, http.MethodPatch, scheduleURL(weekdays.Schedules[0], handlers.ScopeSeries), moved, userCookie))
+	if weekdays.RecurrenceRule != "FREQ=WEEKLY;COUNT=4;BYDAY=TU,TH" || len(weekdays.Schedules) != 4 {
+		t.Error("Wrong series moved to another day :", weekdays)
+	}
+	for i, schedule := range weekdays.Schedules {
+		start, _ := time.Parse(time.RFC3339, schedule.StartDate)
+		if wanted := monday.AddDate(0, 0, 1+7*(i/2)+2*(i%2)); !start.Equal(wanted) {
+			t.Error("Wrong occurrence of the series moved to another day :", start, wanted)
+		}
+	}
+
+	globals.Log.Debug("PATCH /schedules/{id} to another day of the week - PASSED")
+
+	//
+	//	PATCH /series/{id} of a series started before now
+	//
+	started := endless
+	threeDaysAgo := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -3)
+	started.StartDate, started.EndDate = threeDaysAgo.Add(12*time.Hour).Format(time.RFC3339), threeDaysAgo.Add(13*time.Hour).Format(time.RFC3339)
+	started.RecurrenceRule = "FREQ=DAILY;COUNT=10"
+	started = seriesOf(sendRequest(t, http.MethodPost, "/series", started, userCookie))
+
+	// The past occurrences stay with the old series, which ends before now, and the next ones of the new rule
+	// become a new series
+	changed := started
+	changed.StartDate, changed.EndDate = threeDaysAgo.Add(15*time.Hour).Format(time.RFC3339), threeDaysAgo.Add(16*time.Hour).Format(time.RFC3339)
+	changed.Schedules = nil
+	changed = seriesOf(sendRequest(t, http.MethodPatch, "/series/"+strconv.FormatInt(started.SeriesId, 10), changed, userCookie))
+	started = seriesOf(sendRequest(t, http.MethodGet, "/series/"+strconv.FormatInt(started.SeriesId, 10), nil, userCookie))
+	oldRule, _ := globals.ParseRecurrence(started.RecurrenceRule, time.UTC)
+	newRule, _ := globals.ParseRecurrence(changed.RecurrenceRule, time.UTC)
+	left := 10
+	for day := 0; day < 10; day++ {
+		if threeDaysAgo.AddDate(0, 0, day).Add(15 * time.Hour).Before(time.Now()) {
+			left--
+		}
+	}
+	if changed.SeriesId == started.SeriesId || oldRule.Count != len(started.Schedules) || newRule.Count != left || len(changed.Schedules) != left {
+		t.Error("Wrong series changed after its start :", started, changed)
+	}
+	for _, schedule := range started.Schedules {
+		if start, _ := time.Parse(time.RFC3339, schedule.StartDate); !start.Before(time.Now()) || start.UTC().Hour() != 12 {
+			t.Error("Wrong occurrence kept with the old series :", schedule)
+		}
+	}
+	for _, schedule := range changed.Schedules {
+		if start, _ := time.Parse(time.RFC3339, schedule.StartDate); start.Before(time.Now()) || start.UTC().Hour() != 15 {
+			t.Error("Wrong occurrence of the new series :", schedule)
+		}
+	}
+
+	globals.Log.Debug("PATCH /series/{id} of a series started before now - PASSED")
+
+	//
+	//	DELETE /series/{id}
+	//
+	for _, id := range []int64{seriesId, endless.SeriesId, weekdays.SeriesId, started.SeriesId, changed.SeriesId} {
+		if rr = sendRequest(t, http.MethodDelete, "/series/"+strconv.FormatInt(id, 10), nil, userCookie); rr.Code != http.StatusOK {
+			t.Error("Could not delete the series :", rr.Code, rr.Body.String())
+		}
+	}
+	if rr = sendRequest(t, http.MethodDelete, "/series/"+strconv.FormatInt(seriesId, 10), nil, userCookie); rr.Code != http.StatusNotFound {
+		t.Error("A deleted series was deleted again :", rr.Code)
+	}
+
+	// Only the past occurrences are kept, out of any series
+	schedules, _ := env.DB.GetSchedulesOfUser(user.UserId)
+	if len(schedules) != 2+len(started.Schedules) {
+		t.Error("Wrong schedules left by the deleted series :", schedules)
+	}
+	for _, schedule := range schedules {
+		if schedule.SeriesId.Valid || !schedule.StartDate.Time.Before(time.Now()) {
+			t.Error("Wrong schedule left by the deleted series :", schedule)
+		}
+	}
+
+	globals.Log.Debug("DELETE /series/{id} - PASSED")
+
+	for _, schedule := range schedules {
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: schedule.ScheduleId})
+		env.DB.DeleteSchedule(schedule.ScheduleId)
+	}
+	env.DB.DeleteProject(projectId)
+	env.DB.DeleteUser(user.UserId)
+}
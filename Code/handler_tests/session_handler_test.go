@@ -0,0 +1,178 @@
+package handler_tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+// Sends a request coming from a web application served from another origin
+func sendCrossOriginRequest(t *testing.T, Method string, URL string, Origin string, Headers map[string]string) *httptest.ResponseRecorder {
+	request, err := http.NewRequest(Method, URL, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	request.Header.Set("Origin", Origin)
+	for name, value := range Headers {
+		request.Header.Set(name, value)
+	}
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+
+	return rr
+}
+
+/*
+	TESTED : The session cookies
+	TESTED : The CSRF tokens
+	TESTED : The expiration of the tokens
+	TESTED : OPTIONS and the CORS headers
+*/
+func TestSessionHandler(t *testing.T) {
+	var rr *httptest.ResponseRecorder
+
+	//
+	//	The session cookies
+	//
+	rr = login(t, "admin@mydb", adminPassword)
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range rr.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	session, csrf := cookies["token"], cookies["csrf_token"]
+	if session == nil || csrf == nil {
+		t.Fatal("The session cookies were not set")
+	}
+
+	if !session.HttpOnly || !session.Secure || session.SameSite != http.SameSiteStrictMode || session.Path != "/" {
+		t.Error("The session cookie is not protected :", session)
+	}
+
+	if session.MaxAge <= 0 || session.Expires.Before(time.Now().Add(7*time.Hour)) || session.Expires.After(time.Now().Add(9*time.Hour)) {
+		t.Error("Wrong expiration of the session cookie :", session.Expires)
+	}
+
+	// The web application has to read the CSRF token
+	if csrf.HttpOnly || !csrf.Secure || csrf.Value == "" {
+		t.Error("Wrong CSRF cookie :", csrf)
+	}
+
+	globals.Log.Debug("The session cookies - PASSED")
+
+	//
+	//	The CSRF tokens
+	//
+	// Deleting the vacation project is always refused, but only after the CSRF token is verified
+	request, _ := http.NewRequest(http.MethodDelete, "/projects/1", nil)
+	request.AddCookie(session)
+	request.AddCookie(csrf)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+	if rr.Code != http.StatusForbidden || errorCode(rr) != "invalid_csrf_token" {
+		t.Error("A request without the CSRF header was accepted :", rr.Code)
+	}
+
+	request, _ = http.NewRequest(http.MethodDelete, "/projects/1", nil)
+	request.AddCookie(session)
+	request.AddCookie(csrf)
+	request.Header.Set("X-CSRF-Token", "forged")
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+	if rr.Code != http.StatusForbidden || errorCode(rr) != "invalid_csrf_token" {
+		t.Error("A request with a wrong CSRF header was accepted :", rr.Code)
+	}
+
+	// The CSRF token of another session is refused too
+	request, _ = http.NewRequest(http.MethodDelete, "/projects/1", nil)
+	request.AddCookie(session)
+	request.AddCookie(&http.Cookie{Name: "csrf_token", Value: "forged"})
+	request.Header.Set("X-CSRF-Token", "forged")
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+	if rr.Code != http.StatusForbidden || errorCode(rr) != "invalid_csrf_token" {
+		t.Error("A CSRF token of another session was accepted :", rr.Code)
+	}
+
+	request, _ = http.NewRequest(http.MethodDelete, "/projects/1", nil)
+	request.AddCookie(session)
+	request.AddCookie(csrf)
+	request.Header.Set("X-CSRF-Token", csrf.Value)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+	if rr.Code != http.StatusForbidden || errorCode(rr) != "protected_item" {
+		t.Error("A request with the CSRF token was refused :", rr.Code, errorCode(rr))
+	}
+
+	// The requests that don't modify anything don't need it
+	request, _ = http.NewRequest(http.MethodGet, "/projects", nil)
+	request.AddCookie(session)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, request)
+	if rr.Code != http.StatusOK {
+		t.Error("A GET request needed the CSRF token :", rr.Code)
+	}
+
+	globals.Log.Debug("The CSRF tokens - PASSED")
+
+	//
+	//	The expiration of the tokens
+	//
+	for name, claims := range map[string]jwt.MapClaims{
+		"without expiration": {"mail": "admin@mydb", "user_id": 1, "role_id": 1},
+		"expired":            {"mail": "admin@mydb", "user_id": 1, "role_id": 1, "exp": time.Now().Add(-time.Minute).Unix()},
+	} {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(globals.TokenSignKey)
+		if rr = sendRequest(t, http.MethodGet, "/projects", nil, &http.Cookie{Name: "token", Value: tokenString}); rr.Code != http.StatusUnauthorized {
+			t.Error("A token", name, "was accepted")
+		}
+	}
+
+	globals.Log.Debug("The expiration of the tokens - PASSED")
+
+	//
+	//	OPTIONS and the CORS headers
+	//
+	defaultCORS := globals.CORS
+	defer func() { globals.CORS = defaultCORS }()
+
+	preflight := map[string]string{"Access-Control-Request-Method": "PATCH", "Access-Control-Request-Headers": "X-CSRF-Token"}
+
+	// No other origin is allowed by default
+	rr = sendCrossOriginRequest(t, http.MethodOptions, "/users/1", "https://evil.example", preflight)
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("An unknown origin was allowed")
+	}
+
+	globals.CORS.AllowedOrigins = []string{"https://tps.uca.fr"}
+	globals.CORS.AllowCredentials = true
+
+	rr = sendCrossOriginRequest(t, http.MethodOptions, "/users/1", "https://tps.uca.fr", preflight)
+	if rr.Code != http.StatusNoContent {
+		t.Error("The preflight request failed :", rr.Code)
+	}
+
+	if rr.Header().Get("Access-Control-Allow-Origin") != "https://tps.uca.fr" || rr.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		rr.Header().Get("Access-Control-Allow-Methods") == "" || rr.Header().Get("Access-Control-Allow-Headers") == "" || rr.Header().Get("Vary") != "Origin" {
+		t.Error("Wrong CORS headers :", rr.Header())
+	}
+
+	// The other requests are not changed, only allowed to be read
+	rr = sendCrossOriginRequest(t, http.MethodGet, "/projects", "https://tps.uca.fr", nil)
+	if rr.Code != http.StatusUnauthorized || rr.Header().Get("Access-Control-Allow-Origin") != "https://tps.uca.fr" || rr.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Error("Wrong CORS headers :", rr.Header())
+	}
+
+	rr = sendCrossOriginRequest(t, http.MethodGet, "/projects", "https://evil.example", nil)
+	if rr.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("An unknown origin was allowed")
+	}
+
+	globals.Log.Debug("OPTIONS and the CORS headers - PASSED")
+}
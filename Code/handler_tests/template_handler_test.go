@@ -0,0 +1,274 @@
+package handler_tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : POST /templates, PATCH /templates/{id}, DELETE /templates/{id}
+	TESTED : GET /templates/{id}, GET /users/{id}/templates, GET /companies/{id}/templates, GET /me/templates
+	TESTED : POST /templates/{id}/preview, POST /templates/{id}/apply
+*/
+func TestTemplateHandler(t *testing.T) {
+	var (
+		err       error
+		rr        *httptest.ResponseRecorder
+		template  handlers.TemplateIntermediate
+		templates []handlers.TemplateIntermediate
+		fields    []string
+		schedules model.Schedules
+	)
+
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Template password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "TemplateUser@mydb",
+		Password:   string(cryptedPassword),
+		TimeZone:   "Europe/Paris",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Template project"})
+	companyId, _ := env.DB.CreateCompany(model.Company{CompanyName: "Template company"})
+	if err = env.DB.CreateCompanyUser(model.CompanyUser{CompanyId: companyId, UserId: user.UserId}); err != nil {
+		t.Fatal(err)
+	}
+
+	userCookie := login(t, user.Mail, "Template password").Result().Cookies()[0]
+
+	// In the week of the monday 8th of March 2021, a schedule on tuesday morning and a vacation on wednesday
+	paris, _ := time.LoadLocation("Europe/Paris")
+	between := func(Day int, Start int, End int) model.Schedule {
+		return model.Schedule{
+			ProjectId: projectId,
+			StartDate: sql.NullTime{Valid: true, Time: time.Date(2021, 3, Day, Start, 0, 0, 0, paris).UTC()},
+			EndDate:   sql.NullTime{Valid: true, Time: time.Date(2021, 3, Day, End, 0, 0, 0, paris).UTC()},
+		}
+	}
+	if _, err = env.DB.CreateSchedule(between(9, 9, 10), user.UserId); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = env.DB.CreateVacation(between(10, 0, 23), user.UserId); err != nil {
+		t.Fatal(err)
+	}
+
+	// Answers with the template of a request, or stops the test
+	templateOf := func(rr *httptest.ResponseRecorder) handlers.TemplateIntermediate {
+		var template handlers.TemplateIntermediate
+		if rr.Code != http.StatusOK {
+			t.Fatal("Wrong answer :", rr.Code, rr.Body.String())
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&template); err != nil {
+			t.Fatal(err)
+		}
+		return template
+	}
+	// Answers with the states of the slots of a template applied to a week, or stops the test
+	statesOf := func(rr *httptest.ResponseRecorder) []string {
+		var result handlers.TemplateApplicationResult
+		if rr.Code != http.StatusOK {
+			t.Fatal("Wrong answer :", rr.Code, rr.Body.String())
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		states := []string{}
+		for _, slot := range result.Slots {
+			states = append(states, slot.Status)
+		}
+		return states
+	}
+	templateURL := func(TemplateId int64) string {
+		return "/templates/" + strconv.FormatInt(TemplateId, 10)
+	}
+
+	week := handlers.TemplateIntermediate{
+		TemplateName: "Week at the office",
+		UserId:       user.UserId,
+		Slots: model.TemplateSlots{
+			{ProjectId: projectId, Weekday: 1, StartTime: "8:00", EndTime: "12:00"},
+			{ProjectId: projectId, Weekday: 1, StartTime: "13:30", EndTime: "17:00"},
+			{ProjectId: projectId, Weekday: 2, StartTime: "08:00", EndTime: "12:00"},
+			{ProjectId: projectId, Weekday: 3, StartTime: "08:00", EndTime: "12:00"},
+			{ProjectId: projectId, Weekday: 5, StartTime: "08:00", EndTime: "12:00"},
+		},
+	}
+
+	//
+	//	POST /templates
+	//
+	template = templateOf(sendRequest(t, http.MethodPost, "/templates", week, userCookie))
+	if template.TemplateId == 0 || template.UserId != user.UserId || len(template.Slots) != 5 || template.Slots[0].StartTime != "08:00" {
+		t.Error("Wrong created template :", template)
+	}
+	templateId := template.TemplateId
+
+	wrong := week
+	wrong.CompanyId = companyId
+	wrong.Slots = model.TemplateSlots{
+		{ProjectId: projectId, Weekday: 1, StartTime: "08:00", EndTime: "12:00"},
+		{ProjectId: projectId, Weekday: 1, StartTime: "11:00", EndTime: "13:00"},
+	}
+	rr = sendRequest(t, http.MethodPost, "/templates", wrong, userCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "user_id", "slots") {
+		t.Error("A wrong template was created :", fields)
+	}
+	wrong.CompanyId = 0
+	wrong.Slots = model.TemplateSlots{{ProjectId: projectId, Weekday: 8, StartTime: "12:00", EndTime: "08:00"}}
+	rr = sendRequest(t, http.MethodPost, "/templates", wrong, userCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "slots") {
+		t.Error("A template with wrong slots was created :", fields)
+	}
+
+	// Only the managers share the templates of a company
+	shared := week
+	shared.TemplateName, shared.UserId, shared.CompanyId = "Shared week", 0, companyId
+	shared.Slots = week.Slots[:1]
+	if rr = sendRequest(t, http.MethodPost, "/templates", shared, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user shared a template with a company :", rr.Code)
+	}
+	shared = templateOf(sendRequest(t, http.MethodPost, "/templates", shared, tokenCookie))
+
+	globals.Log.Debug("POST /templates - PASSED")
+
+	//
+	//	GET /templates/{id}, GET /users/{id}/templates, GET /companies/{id}/templates and GET /me/templates
+	//
+	if template = templateOf(sendRequest(t, http.MethodGet, templateURL(templateId), nil, userCookie)); template.TemplateName != week.TemplateName {
+		t.Error("Wrong template :", template)
+	}
+	if rr = sendRequest(t, http.MethodGet, templateURL(shared.TemplateId), nil, userCookie); rr.Code != http.StatusOK {
+		t.Error("Could not get the template of a company :", rr.Code)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/me/templates", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the templates of the connected user :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&templates); err != nil || len(templates) != 2 || templates[0].TemplateId != shared.TemplateId {
+		t.Error("Wrong templates of the connected user :", templates, err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/companies/"+strconv.FormatInt(companyId, 10)+"/templates", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the templates of a company :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&templates); err != nil || len(templates) != 1 || len(templates[0].Slots) != 1 {
+		t.Error("Wrong templates of the company :", templates, err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/users/"+strconv.FormatInt(user.UserId, 10)+"/templates", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not get the templates of a user :", rr.Code, rr.Body.String())
+	}
+	if rr = sendRequest(t, http.MethodGet, "/users/1/templates", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("The templates of another user were seen :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /templates/{id} - PASSED")
+
+	//
+	//	POST /templates/{id}/preview and POST /templates/{id}/apply
+	//
+	application := handlers.TemplateApplication{Week: "2021-W10", SkipDays: []string{"2021-03-12"}}
+	expected := []string{handlers.SlotFree, handlers.SlotFree, handlers.SlotConflict, handlers.SlotVacation, handlers.SlotSkipped}
+
+	if states := statesOf(sendRequest(t, http.MethodPost, templateURL(templateId)+"/preview", application, userCookie)); !sameFields(states, expected...) {
+		t.Error("Wrong preview of the template :", states)
+	}
+	if schedules, _ = env.DB.GetSchedulesOfUser(user.UserId); len(schedules) != 2 {
+		t.Error("The preview created some schedules :", schedules)
+	}
+
+	rr = sendRequest(t, http.MethodPost, templateURL(templateId)+"/apply", handlers.TemplateApplication{Week: "2021-10"}, userCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "week") {
+		t.Error("A template was applied to a wrong week :", fields)
+	}
+	application.UserId = 1
+	if rr = sendRequest(t, http.MethodPost, templateURL(templateId)+"/apply", application, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A template was applied to the week of another user :", rr.Code)
+	}
+	application.UserId = 0
+
+	expected[0], expected[1] = handlers.SlotCreated, handlers.SlotCreated
+	if states := statesOf(sendRequest(t, http.MethodPost, templateURL(templateId)+"/apply", application, userCookie)); !sameFields(states, expected...) {
+		t.Error("Wrong application of the template :", states)
+	}
+	if schedules, _ = env.DB.GetSchedulesOfUser(user.UserId); len(schedules) != 4 {
+		t.Error("The schedules were not created :", schedules)
+	}
+
+	// Applied again, the template gives only conflicts
+	expected[0], expected[1] = handlers.SlotConflict, handlers.SlotConflict
+	if states := statesOf(sendRequest(t, http.MethodPost, templateURL(templateId)+"/apply", application, userCookie)); !sameFields(states, expected...) {
+		t.Error("Wrong second application of the template :", states)
+	}
+
+	globals.Log.Debug("POST /templates/{id}/apply - PASSED")
+
+	//
+	//	PATCH /templates/{id}
+	//
+	week.TemplateName = "Short week"
+	week.Slots = week.Slots[:1]
+	if template = templateOf(sendRequest(t, http.MethodPatch, templateURL(templateId), week, userCookie)); template.TemplateName != "Short week" || len(template.Slots) != 1 {
+		t.Error("Wrong changed template :", template)
+	}
+	if rr = sendRequest(t, http.MethodPatch, templateURL(shared.TemplateId), shared, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user changed the template of a company :", rr.Code)
+	}
+	if rr = sendRequest(t, http.MethodPatch, templateURL(0), week, userCookie); rr.Code != http.StatusNotFound {
+		t.Error("An unknown template was changed :", rr.Code)
+	}
+
+	globals.Log.Debug("PATCH /templates/{id} - PASSED")
+
+	//
+	//	DELETE /templates/{id}
+	//
+	if rr = sendRequest(t, http.MethodDelete, templateURL(shared.TemplateId), nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user deleted the template of a company :", rr.Code)
+	}
+	for _, cookie := range []*http.Cookie{userCookie, tokenCookie} {
+		id := templateId
+		if cookie == tokenCookie {
+			id = shared.TemplateId
+		}
+		if rr = sendRequest(t, http.MethodDelete, templateURL(id), nil, cookie); rr.Code != http.StatusOK {
+			t.Error("Could not delete the template :", rr.Code, rr.Body.String())
+		}
+	}
+	if rr = sendRequest(t, http.MethodGet, templateURL(templateId), nil, userCookie); rr.Code != http.StatusNotFound {
+		t.Error("The template was not deleted :", rr.Code)
+	}
+	if schedules, _ = env.DB.GetSchedulesOfUser(user.UserId); len(schedules) != 4 {
+		t.Error("The schedules filled from the template were deleted :", schedules)
+	}
+
+	globals.Log.Debug("DELETE /templates/{id} - PASSED")
+
+	for _, schedule := range schedules {
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: schedule.ScheduleId})
+		env.DB.DeleteSchedule(schedule.ScheduleId)
+	}
+	if vacations, err := env.DB.GetVacationsOfUser(user.UserId); err == nil {
+		for _, vacation := range vacations {
+			env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: vacation.ScheduleId})
+			env.DB.DeleteSchedule(vacation.ScheduleId)
+		}
+	}
+	env.DB.DeleteCompanyUser(model.CompanyUser{CompanyId: companyId, UserId: user.UserId})
+	env.DB.DeleteCompany(companyId)
+	env.DB.DeleteProject(projectId)
+	env.DB.DeleteUser(user.UserId)
+}
@@ -0,0 +1,184 @@
+package handler_tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : POST /me/timer/start
+	TESTED : GET /me/timer
+	TESTED : POST /me/timer/stop
+	TESTED : The automatic stop of the forgotten timers
+	TESTED : GET /me/timer of a forgotten timer in locked hours
+*/
+func TestTimerHandler(t *testing.T) {
+	var (
+		err    error
+		rr     *httptest.ResponseRecorder
+		timer  handlers.ScheduleIntermediate
+		fields []string
+	)
+
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Timer password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "TimerUser@mydb",
+		Password:   string(cryptedPassword),
+		TimeZone:   "UTC",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Timer project"})
+
+	userCookie := login(t, user.Mail, "Timer password").Result().Cookies()[0]
+
+	//
+	//	POST /me/timer/start
+	//
+	if rr = sendRequest(t, http.MethodPost, "/me/timer/start", handlers.TimerStart{ProjectId: projectId}, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not start the timer :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&timer); err != nil {
+		t.Error(err)
+	}
+	if timer.ProjectId != projectId || timer.StartDate == "" || timer.EndDate != "" {
+		t.Error("Wrong started timer :", timer)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/me/timer/start", handlers.TimerStart{ProjectId: projectId}, userCookie); rr.Code != http.StatusConflict {
+		t.Error("A second timer was started :", rr.Code)
+	}
+
+	rr = sendRequest(t, http.MethodPost, "/me/timer/start", handlers.TimerStart{ProjectId: 1}, userCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "project_id") {
+		t.Error("A timer was started on the vacations :", fields)
+	}
+
+	globals.Log.Debug("POST /me/timer/start - PASSED")
+
+	//
+	//	GET /me/timer
+	//
+	running := handlers.ScheduleIntermediate{}
+	if rr = sendRequest(t, http.MethodGet, "/me/timer", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the running timer :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&running); err != nil || running != timer {
+		t.Error("Wrong running timer :", running, err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/me/timer", nil, tokenCookie); rr.Code != http.StatusNotFound {
+		t.Error("The administrator has a running timer :", rr.Code)
+	}
+
+	globals.Log.Debug("GET /me/timer - PASSED")
+
+	//
+	//	POST /me/timer/stop
+	//
+	stopped := handlers.ScheduleIntermediate{}
+	if rr = sendRequest(t, http.MethodPost, "/me/timer/stop", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not stop the timer :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&stopped); err != nil {
+		t.Error(err)
+	}
+	if stopped.ScheduleId != timer.ScheduleId || stopped.EndDate == "" {
+		t.Error("Wrong stopped timer :", stopped)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/me/timer/stop", nil, userCookie); rr.Code != http.StatusConflict {
+		t.Error("A stopped timer was stopped again :", rr.Code)
+	}
+	if rr = sendRequest(t, http.MethodGet, "/me/timer", nil, userCookie); rr.Code != http.StatusNotFound {
+		t.Error("The stopped timer is still running :", rr.Code)
+	}
+
+	globals.Log.Debug("POST /me/timer/stop - PASSED")
+
+	//
+	//	The forgotten timers are stopped at the hour of the rules
+	//
+	// The stopped timer is deleted first, as the forgotten one would overlap it
+	if err = env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: timer.ScheduleId}); err != nil {
+		t.Error(err)
+	}
+	if err = env.DB.DeleteSchedule(timer.ScheduleId); err != nil {
+		t.Error(err)
+	}
+
+	start := time.Now().AddDate(0, 0, -2)
+	forgottenId, err := env.DB.StartTimer(user.UserId, model.Schedule{ProjectId: projectId, StartDate: sql.NullTime{Valid: true, Time: start}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stopped, err := env.StopForgottenTimers(time.Now()); err != nil || stopped != 1 {
+		t.Error("The forgotten timer was not stopped :", stopped, err)
+	}
+
+	forgotten, _ := env.DB.GetSchedule(forgottenId)
+	expected, _ := globals.Timers.AutoStopDate(start, time.UTC)
+	if !forgotten.EndDate.Valid || !forgotten.EndDate.Time.Equal(expected) {
+		t.Error("Wrong end of the forgotten timer :", forgotten.EndDate, "instead of", expected)
+	}
+
+	globals.Log.Debug("Forgotten timers - PASSED")
+
+	//
+	//	GET /me/timer of a forgotten timer in locked hours
+	//
+	// The stopped forgotten timer is deleted first, as the locked one would overlap it
+	if err = env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: forgottenId}); err != nil {
+		t.Error(err)
+	}
+	if err = env.DB.DeleteSchedule(forgottenId); err != nil {
+		t.Error(err)
+	}
+
+	lockedId, err := env.DB.StartTimer(user.UserId, model.Schedule{ProjectId: projectId, StartDate: sql.NullTime{Valid: true, Time: start}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The lock date is after the hour the timer should have been stopped at
+	if _, err = env.DB.Lock(model.LockDate{LockDate: sql.NullTime{Valid: true, Time: time.Now().Add(-time.Minute)}, AuthorId: 1, Reason: "Timer"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/me/timer", nil, userCookie); rr.Code != http.StatusConflict || errorCode(rr) != "period_locked" {
+		t.Error("A forgotten timer in locked hours was not refused :", rr.Code, rr.Body.String())
+	}
+	if locked, _ := env.DB.GetSchedule(lockedId); locked.EndDate.Valid {
+		t.Error("A forgotten timer was stopped in locked hours :", locked.EndDate)
+	}
+
+	if _, err = env.DB.Unlock(model.LockDate{AuthorId: 1, Reason: "Timer"}); err != nil {
+		t.Fatal(err)
+	}
+
+	globals.Log.Debug("GET /me/timer in locked hours - PASSED")
+
+	// Deleting the data, so the other tests are not disturbed
+	for _, cleanup := range []error{
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: lockedId}),
+		env.DB.DeleteSchedule(lockedId),
+		env.DB.DeleteProject(projectId),
+		env.DB.DeleteUser(user.UserId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+}
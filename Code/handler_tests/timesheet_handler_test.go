@@ -0,0 +1,240 @@
+package handler_tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : GET /users/{id}/timesheets
+	TESTED : GET /me/timesheets
+	TESTED : The balance of the current week, without the days to come
+*/
+func TestTimesheetHandler(t *testing.T) {
+	var (
+		err       error
+		rr        *httptest.ResponseRecorder
+		timesheet handlers.Timesheet
+		fields    []string
+	)
+
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Timesheet password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId:           1,
+		RoleId:               3,
+		Mail:                 "TimesheetUser@mydb",
+		Password:             string(cryptedPassword),
+		TimeZone:             "Europe/Paris",
+		TheoricalHoursWorked: 35,
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	timesheetURL := "/users/" + strconv.FormatInt(user.UserId, 10) + "/timesheets"
+
+	firstId, _ := env.DB.CreateProject(model.Project{ProjectName: "Timesheet first project"})
+	secondId, _ := env.DB.CreateProject(model.Project{ProjectName: "Timesheet second project"})
+
+	between := func(ProjectId int64, Start time.Time, End time.Time) model.Schedule {
+		return model.Schedule{
+			ProjectId: ProjectId,
+			StartDate: sql.NullTime{Valid: true, Time: Start},
+			EndDate:   sql.NullTime{Valid: true, Time: End},
+		}
+	}
+
+	var scheduleIds []int64
+	for _, schedule := range []model.Schedule{
+		// 10 hours on the monday of the week before
+		between(firstId, time.Date(2021, 3, 15, 7, 0, 0, 0, time.UTC), time.Date(2021, 3, 15, 17, 0, 0, 0, time.UTC)),
+		// 8 hours on monday, and 4 hours on each project on tuesday
+		between(firstId, time.Date(2021, 3, 22, 7, 0, 0, 0, time.UTC), time.Date(2021, 3, 22, 15, 0, 0, 0, time.UTC)),
+		between(firstId, time.Date(2021, 3, 23, 7, 0, 0, 0, time.UTC), time.Date(2021, 3, 23, 11, 0, 0, 0, time.UTC)),
+		between(secondId, time.Date(2021, 3, 23, 12, 0, 0, 0, time.UTC), time.Date(2021, 3, 23, 16, 0, 0, 0, time.UTC)),
+		// The night the clocks go forward : 2 hours on saturday and 3 hours on sunday
+		between(secondId, time.Date(2021, 3, 27, 21, 0, 0, 0, time.UTC), time.Date(2021, 3, 28, 2, 0, 0, 0, time.UTC)),
+	} {
+		scheduleId, err := env.DB.CreateSchedule(schedule, user.UserId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheduleIds = append(scheduleIds, scheduleId)
+	}
+
+	// A whole day off on wednesday
+	vacationId, err := env.DB.CreateVacation(between(0, time.Date(2021, 3, 24, 7, 0, 0, 0, time.UTC), time.Date(2021, 3, 24, 14, 0, 0, 0, time.UTC)), user.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduleIds = append(scheduleIds, vacationId)
+
+	userCookie := login(t, user.Mail, "Timesheet password").Result().Cookies()[0]
+
+	//
+	//	A week
+	//
+	if rr = sendRequest(t, http.MethodGet, timesheetURL+"?week=2021-W12", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the timesheet :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&timesheet); err != nil {
+		t.Error(err)
+	}
+
+	// 35 hours, without the 7 hours of vacation, against 21 hours worked, and 25 hours missing the week before
+	if timesheet.Period != "2021-W12" || timesheet.Start != "2021-03-22T00:00:00+01:00" || timesheet.End != "2021-03-29T00:00:00+02:00" ||
+		timesheet.ExpectedHours != 28 || timesheet.Hours != 21 || timesheet.VacationHours != 7 || timesheet.OvertimeHours != -7 || timesheet.CumulativeBalance != -32 {
+		t.Error("Wrong timesheet :", timesheet)
+	}
+
+	expectedProjects := []handlers.TimesheetProject{{ProjectId: firstId, Hours: 12}, {ProjectId: secondId, Hours: 9}}
+	if len(timesheet.Projects) != len(expectedProjects) || timesheet.Projects[0] != expectedProjects[0] || timesheet.Projects[1] != expectedProjects[1] {
+		t.Error("Wrong hours per project :", timesheet.Projects)
+	}
+
+	expectedDays := []handlers.TimesheetDay{
+		{Day: "2021-03-22", ExpectedHours: 7, Hours: 8, OvertimeHours: 1},
+		{Day: "2021-03-23", ExpectedHours: 7, Hours: 8, OvertimeHours: 1},
+		{Day: "2021-03-24", ExpectedHours: 0, VacationHours: 7},
+		{Day: "2021-03-25", ExpectedHours: 7, OvertimeHours: -7},
+		{Day: "2021-03-26", ExpectedHours: 7, OvertimeHours: -7},
+		{Day: "2021-03-27", Hours: 2, OvertimeHours: 2},
+		{Day: "2021-03-28", Hours: 3, OvertimeHours: 3},
+	}
+	if len(timesheet.Days) != len(expectedDays) {
+		t.Fatal("Wrong days :", timesheet.Days)
+	}
+	for i, expected := range expectedDays {
+		day := timesheet.Days[i]
+		if day.Day != expected.Day || day.ExpectedHours != expected.ExpectedHours || day.Hours != expected.Hours ||
+			day.VacationHours != expected.VacationHours || day.OvertimeHours != expected.OvertimeHours {
+			t.Error("Wrong day :", day, "instead of", expected)
+		}
+	}
+	if len(timesheet.Days[1].Projects) != 2 || len(timesheet.Days[2].Projects) != 0 {
+		t.Error("Wrong projects of the days :", timesheet.Days[1].Projects, timesheet.Days[2].Projects)
+	}
+
+	// Before the first schedule, there is no balance
+	if rr = sendRequest(t, http.MethodGet, "/me/timesheets?week=2021-W10", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the timesheet :", rr.Code, rr.Body.String())
+	}
+	timesheet = handlers.Timesheet{}
+	if err = json.NewDecoder(rr.Body).Decode(&timesheet); err != nil {
+		t.Error(err)
+	}
+	if timesheet.UserId != user.UserId || timesheet.ExpectedHours != 35 || timesheet.OvertimeHours != -35 || timesheet.CumulativeBalance != 0 {
+		t.Error("Wrong timesheet before the first schedule :", timesheet)
+	}
+
+	globals.Log.Debug("Week - PASSED")
+
+	//
+	//	A month
+	//
+	if rr = sendRequest(t, http.MethodGet, timesheetURL+"?month=2021-03", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the timesheet :", rr.Code, rr.Body.String())
+	}
+	timesheet = handlers.Timesheet{}
+	if err = json.NewDecoder(rr.Body).Decode(&timesheet); err != nil {
+		t.Error(err)
+	}
+
+	// 23 working days, and the balance from the 15th to the 31st
+	if len(timesheet.Days) != 31 || timesheet.ExpectedHours != 154 || timesheet.Hours != 31 || timesheet.OvertimeHours != -123 || timesheet.CumulativeBalance != -53 {
+		t.Error("Wrong timesheet of the month :", timesheet.ExpectedHours, timesheet.Hours, timesheet.OvertimeHours, timesheet.CumulativeBalance)
+	}
+
+	globals.Log.Debug("Month - PASSED")
+
+	//
+	//	The current week : the days to come are not in the balance
+	//
+	paris, _ := time.LoadLocation(user.TimeZone)
+	isoWeek := func(Date time.Time) string {
+		year, week := Date.In(paris).ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	today := time.Now().In(paris).Format(globals.DayLayout)
+
+	// The balance of the week before is the balance up to the current week
+	previous := handlers.Timesheet{}
+	if rr = sendRequest(t, http.MethodGet, "/me/timesheets?week="+isoWeek(time.Now().AddDate(0, 0, -7)), nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the timesheet :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&previous); err != nil {
+		t.Error(err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/me/timesheets?week="+isoWeek(time.Now()), nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the timesheet :", rr.Code, rr.Body.String())
+	}
+	timesheet = handlers.Timesheet{}
+	if err = json.NewDecoder(rr.Body).Decode(&timesheet); err != nil {
+		t.Error(err)
+	}
+
+	balance, expectedHours := previous.CumulativeBalance, 0.0
+	for _, day := range timesheet.Days {
+		if day.Day <= today {
+			balance += day.OvertimeHours
+		}
+		expectedHours += day.ExpectedHours
+	}
+	if len(timesheet.Days) != 7 || timesheet.CumulativeBalance != balance || timesheet.ExpectedHours != expectedHours {
+		t.Error("Wrong balance of the current week :", timesheet.CumulativeBalance, "instead of", balance, timesheet.Days)
+	}
+
+	globals.Log.Debug("Current week - PASSED")
+
+	//
+	//	The wrong periods, and the timesheets of the others
+	//
+	for query, field := range map[string]string{
+		"":                             "week",
+		"?week=2021-W53":               "week",
+		"?week=2021-12":                "week",
+		"?month=2021-13":               "month",
+		"?week=2021-W12&month=2021-03": "month",
+	} {
+		rr = sendRequest(t, http.MethodGet, timesheetURL+query, nil, userCookie)
+		if fields = violatedFields(t, rr); !sameFields(fields, field) {
+			t.Error("Wrong fields for", query, ":", fields)
+		}
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/users/1/timesheets?week=2021-W12", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user saw the timesheet of another user :", rr.Code)
+	}
+
+	globals.Log.Debug("Errors - PASSED")
+
+	// Deleting the data, so the other tests are not disturbed
+	for _, scheduleId := range scheduleIds {
+		if err = env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}); err != nil {
+			t.Error(err)
+		}
+		if err = env.DB.DeleteSchedule(scheduleId); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, cleanup := range []error{
+		env.DB.DeleteProject(firstId),
+		env.DB.DeleteProject(secondId),
+		env.DB.DeleteUser(user.UserId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package handler_tests
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : POST /users/{id}/timesheets/{period}/submit, approve, reject and reopen
+	TESTED : GET /users/{id}/timesheets/{period} and GET /users/{id}/timesheets/periods
+	TESTED : GET /timesheets
+	TESTED : The schedules and the comments locked by an approved period
+*/
+func TestTimesheetPeriodHandler(t *testing.T) {
+	var (
+		err     error
+		rr      *httptest.ResponseRecorder
+		period  handlers.TimesheetPeriodIntermediate
+		periods []handlers.TimesheetPeriodIntermediate
+		fields  []string
+	)
+
+	cryptedPassword, _ := bcrypt.GenerateFromPassword([]byte("Approval password"), bcrypt.MinCost)
+	user := model.User{
+		ContractId: 1,
+		RoleId:     3,
+		Mail:       "ApprovalUser@mydb",
+		Password:   string(cryptedPassword),
+		TimeZone:   "UTC",
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	periodURL := "/users/" + strconv.FormatInt(user.UserId, 10) + "/timesheets/2021-W09"
+
+	projectId, _ := env.DB.CreateProject(model.Project{ProjectName: "Approval project"})
+	scheduleId, err := env.DB.CreateSchedule(model.Schedule{
+		ProjectId: projectId,
+		StartDate: sql.NullTime{Valid: true, Time: time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC)},
+		EndDate:   sql.NullTime{Valid: true, Time: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)},
+	}, user.UserId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userCookie := login(t, user.Mail, "Approval password").Result().Cookies()[0]
+
+	// Sends a request and reads the period of the answer
+	changeState := func(URL string, Body interface{}, Cookie *http.Cookie, Status int, State string) {
+		t.Helper()
+		rr = sendRequest(t, http.MethodPost, URL, Body, Cookie)
+		if rr.Code != Status {
+			t.Fatal("Wrong status for", URL, ":", rr.Code, rr.Body.String())
+		}
+		if Status == http.StatusOK {
+			period = handlers.TimesheetPeriodIntermediate{}
+			if err = json.NewDecoder(rr.Body).Decode(&period); err != nil || period.State != State {
+				t.Error("Wrong period :", period, err)
+			}
+		}
+	}
+
+	//
+	//	A period that was never submitted is a draft
+	//
+	if rr = sendRequest(t, http.MethodGet, "/me/timesheets/2021-W09", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the period :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&period); err != nil || period.State != model.TimesheetDraft || period.PeriodId != 0 ||
+		period.StartDate != "2021-03-01T00:00:00Z" || period.EndDate != "2021-03-08T00:00:00Z" || len(period.Events) != 0 {
+		t.Error("Wrong draft :", period, err)
+	}
+
+	changeState(periodURL+"/approve", nil, tokenCookie, http.StatusNotFound, "")
+
+	globals.Log.Debug("Draft - PASSED")
+
+	//
+	//	The submission, by the user only
+	//
+	changeState("/users/1/timesheets/2021-W09/submit", nil, userCookie, http.StatusForbidden, "")
+	changeState("/me/timesheets/2021-W53/submit", nil, userCookie, http.StatusUnprocessableEntity, "")
+	changeState("/me/timesheets/2021-W09/submit", nil, userCookie, http.StatusOK, model.TimesheetSubmitted)
+
+	globals.Log.Debug("Submission - PASSED")
+
+	//
+	//	The decisions, by the managers only
+	//
+	changeState(periodURL+"/approve", nil, userCookie, http.StatusForbidden, "")
+	changeState("/users/1/timesheets/2021-W09/approve", nil, tokenCookie, http.StatusForbidden, "")
+
+	rr = sendRequest(t, http.MethodPost, periodURL+"/reject", handlers.TimesheetDecision{}, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "reason") {
+		t.Error("A timesheet was rejected without reason :", fields)
+	}
+
+	changeState(periodURL+"/reject", handlers.TimesheetDecision{Reason: "The afternoon is missing"}, tokenCookie, http.StatusOK, model.TimesheetRejected)
+	changeState("/me/timesheets/2021-W09/submit", nil, userCookie, http.StatusOK, model.TimesheetSubmitted)
+	changeState(periodURL+"/approve", nil, tokenCookie, http.StatusOK, model.TimesheetApproved)
+	changeState(periodURL+"/approve", nil, tokenCookie, http.StatusConflict, "")
+
+	if len(period.Events) != 4 || period.Events[1].State != model.TimesheetRejected || period.Events[1].Reason != "The afternoon is missing" || period.Events[1].AuthorId != 1 || period.Events[2].AuthorId != user.UserId {
+		t.Error("Wrong history :", period.Events)
+	}
+
+	globals.Log.Debug("Decisions - PASSED")
+
+	//
+	//	The approved period is locked
+	//
+	scheduleURL := "/schedules/" + strconv.FormatInt(scheduleId, 10)
+	moved := handlers.ScheduleIntermediate{ProjectId: projectId, StartDate: "2021-03-01T09:00:00Z", EndDate: "2021-03-01T12:00:00Z"}
+	if rr = sendRequest(t, http.MethodPatch, scheduleURL, moved, tokenCookie); rr.Code != http.StatusConflict || errorCode(rr) != handlers.ErrorPeriodLocked {
+		t.Error("A schedule of an approved period was changed :", rr.Code, rr.Body.String())
+	}
+	if rr = sendRequest(t, http.MethodPost, "/comments", model.Comment{ScheduleId: scheduleId, Comment: "Too late"}, tokenCookie); rr.Code != http.StatusConflict || errorCode(rr) != handlers.ErrorPeriodLocked {
+		t.Error("A schedule of an approved period was commented :", rr.Code, rr.Body.String())
+	}
+
+	globals.Log.Debug("Locks - PASSED")
+
+	//
+	//	The lists of periods
+	//
+	if rr = sendRequest(t, http.MethodGet, "/timesheets?state=approved", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the periods :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&periods); err != nil || len(periods) != 1 || periods[0].UserId != user.UserId || periods[0].Events != nil {
+		t.Error("Wrong approved periods :", periods, err)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/timesheets", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user saw the periods of everybody :", rr.Code)
+	}
+	rr = sendRequest(t, http.MethodGet, "/timesheets?state=closed", nil, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "state") {
+		t.Error("A wrong state was accepted :", fields)
+	}
+
+	if rr = sendRequest(t, http.MethodGet, "/me/timesheets/periods", nil, userCookie); rr.Code != http.StatusOK {
+		t.Fatal("Could not get the periods :", rr.Code, rr.Body.String())
+	}
+	if err = json.NewDecoder(rr.Body).Decode(&periods); err != nil || len(periods) != 1 || periods[0].Period != "2021-W09" {
+		t.Error("Wrong periods of the user :", periods, err)
+	}
+
+	globals.Log.Debug("Lists - PASSED")
+
+	//
+	//	Once reopened, the schedules can change again
+	//
+	changeState(periodURL+"/reopen", handlers.TimesheetDecision{Reason: "Wrong project"}, tokenCookie, http.StatusOK, model.TimesheetDraft)
+	if rr = sendRequest(t, http.MethodPatch, scheduleURL, moved, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("A schedule of a reopened period could not be changed :", rr.Code, rr.Body.String())
+	}
+
+	globals.Log.Debug("Reopening - PASSED")
+
+	// Deleting the data, so the other tests are not disturbed
+	for _, cleanup := range []error{
+		env.DB.DeleteUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}),
+		env.DB.DeleteSchedule(scheduleId),
+		env.DB.DeleteProject(projectId),
+		env.DB.DeleteUser(user.UserId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+}
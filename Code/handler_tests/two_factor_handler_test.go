@@ -0,0 +1,207 @@
+package handler_tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+	TESTED : POST /users/{id}/two-factor
+	TESTED : POST /users/{id}/two-factor/confirm
+	TESTED : POST /get-token/two-factor
+	TESTED : DELETE /users/{id}/two-factor
+*/
+func TestTwoFactorHandler(t *testing.T) {
+	var (
+		err             error
+		cryptedPassword []byte
+		enrollment      handlers.TwoFactorEnrollment
+		recoveryCodes   handlers.TwoFactorRecoveryCodes
+		challenge       handlers.TwoFactorChallenge
+		code            string
+	)
+
+	// Creating a role that requires the two-factor authentication, and a user with this role
+	role := model.Role{
+		RoleName:         "Two-factor role",
+		RequireTwoFactor: true,
+	}
+	if role.RoleId, err = env.DB.CreateRole(role); err != nil {
+		t.Error(err)
+	}
+
+	password := "Two-factor-passw0rd"
+	if cryptedPassword, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost); err != nil {
+		t.Error(err)
+	}
+
+	user := model.User{
+		ContractId: 1,
+		RoleId:     role.RoleId,
+		Mail:       "TwoFactorUser@mydb",
+		Password:   string(cryptedPassword),
+	}
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		t.Error(err)
+	}
+	userURL := "/users/" + strconv.FormatInt(user.UserId, 10)
+
+	rr := login(t, user.Mail, password)
+	if rr.Code != http.StatusOK {
+		t.Error("Could not log in")
+	}
+	userCookie := rr.Result().Cookies()[0]
+
+	// The API can't be used before enabling the two-factor authentication
+	if rr = sendRequest(t, http.MethodGet, "/users", nil, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user without two-factor authentication could use the API")
+	}
+
+	//
+	//	POST /users/{id}/two-factor
+	//
+
+	// Nobody can enroll somebody else
+	if rr = sendRequest(t, http.MethodPost, userURL+"/two-factor", nil, tokenCookie); rr.Code != http.StatusForbidden {
+		t.Error("A user got enrolled by somebody else")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, userURL+"/two-factor", nil, userCookie); rr.Code != http.StatusOK {
+		t.Error("Could not start the enrollment")
+	}
+
+	if err = json.NewDecoder(rr.Body).Decode(&enrollment); err != nil {
+		t.Error(err)
+	}
+
+	if enrollment.Secret == "" || enrollment.ProvisioningURI == "" {
+		t.Error("The secret is missing")
+	}
+
+	globals.Log.Debug("POST /users/{id}/two-factor - PASSED")
+
+	//
+	//	POST /users/{id}/two-factor/confirm
+	//
+
+	if rr = sendRequest(t, http.MethodPost, userURL+"/two-factor/confirm", handlers.TwoFactorCode{Code: "000000"}, userCookie); rr.Code != http.StatusBadRequest {
+		t.Error("The enrollment got confirmed with a wrong code")
+	}
+
+	if code, err = globals.TOTPCode(enrollment.Secret, globals.TOTPStep(time.Now())); err != nil {
+		t.Error(err)
+	}
+
+	if rr = sendRequest(t, http.MethodPost, userURL+"/two-factor/confirm", handlers.TwoFactorCode{Code: code}, userCookie); rr.Code != http.StatusOK {
+		t.Error("Could not confirm the enrollment")
+	}
+
+	if err = json.NewDecoder(rr.Body).Decode(&recoveryCodes); err != nil {
+		t.Error(err)
+	}
+
+	if len(recoveryCodes.RecoveryCodes) != 10 {
+		t.Error("The recovery codes are missing")
+	}
+
+	// Now the API can be used
+	if rr = sendRequest(t, http.MethodGet, "/users", nil, userCookie); rr.Code != http.StatusOK {
+		t.Error("The user can't use the API after enabling the two-factor authentication")
+	}
+
+	globals.Log.Debug("POST /users/{id}/two-factor/confirm - PASSED")
+
+	//
+	//	POST /get-token/two-factor
+	//
+
+	// The password is not enough anymore
+	if rr = login(t, user.Mail, password); rr.Code != http.StatusAccepted {
+		t.Error("The two-factor code was not asked")
+	}
+
+	if len(rr.Result().Cookies()) != 0 {
+		t.Error("A token was given without the two-factor code")
+	}
+
+	if err = json.NewDecoder(rr.Body).Decode(&challenge); err != nil {
+		t.Error(err)
+	}
+
+	// The intermediate token can't be used on the API
+	if rr = sendRequest(t, http.MethodGet, "/users", nil, &http.Cookie{Name: "token", Value: challenge.TwoFactorToken}); rr.Code != http.StatusUnauthorized {
+		t.Error("The two-factor token could be used on the API")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/get-token/two-factor", handlers.TwoFactorLogin{
+		TwoFactorToken: challenge.TwoFactorToken,
+		Code:           "000000",
+	}, nil); rr.Code != http.StatusUnauthorized {
+		t.Error("A wrong code got accepted")
+	}
+
+	// The code used to confirm the enrollment can't be used again, but a recovery code can
+	if rr = sendRequest(t, http.MethodPost, "/get-token/two-factor", handlers.TwoFactorLogin{
+		TwoFactorToken: challenge.TwoFactorToken,
+		Code:           code,
+	}, nil); rr.Code != http.StatusUnauthorized {
+		t.Error("A code got used twice")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/get-token/two-factor", handlers.TwoFactorLogin{
+		TwoFactorToken: challenge.TwoFactorToken,
+		Code:           recoveryCodes.RecoveryCodes[0],
+	}, nil); rr.Code != http.StatusOK {
+		t.Error("Could not log in with a recovery code")
+	}
+
+	if cookies := rr.Result().Cookies(); len(cookies) == 0 || cookies[0].Name != "token" {
+		t.Error("No token was given after the two-factor code")
+	}
+
+	if rr = sendRequest(t, http.MethodPost, "/get-token/two-factor", handlers.TwoFactorLogin{
+		TwoFactorToken: challenge.TwoFactorToken,
+		Code:           recoveryCodes.RecoveryCodes[0],
+	}, nil); rr.Code != http.StatusUnauthorized {
+		t.Error("A recovery code got used twice")
+	}
+
+	globals.Log.Debug("POST /get-token/two-factor - PASSED")
+
+	//
+	//	DELETE /users/{id}/two-factor
+	//
+
+	// The role requires it
+	if rr = sendRequest(t, http.MethodDelete, userURL+"/two-factor", handlers.TwoFactorCode{Code: recoveryCodes.RecoveryCodes[1]}, userCookie); rr.Code != http.StatusForbidden {
+		t.Error("A mandatory two-factor authentication got disabled")
+	}
+
+	// But an admin can reset it
+	if rr = sendRequest(t, http.MethodDelete, userURL+"/two-factor", nil, tokenCookie); rr.Code != http.StatusOK {
+		t.Error("Could not disable the two-factor authentication")
+	}
+
+	if rr = login(t, user.Mail, password); rr.Code != http.StatusOK {
+		t.Error("The two-factor code is still asked")
+	}
+
+	globals.Log.Debug("DELETE /users/{id}/two-factor - PASSED")
+
+	// Deleting the data, so the other tests are not disturbed
+	if err = env.DB.DeleteUser(user.UserId); err != nil {
+		t.Error(err)
+	}
+
+	if err = env.DB.DeleteRole(role.RoleId); err != nil {
+		t.Error(err)
+	}
+}
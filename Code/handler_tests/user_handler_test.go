@@ -10,6 +10,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
 )
 
@@ -22,6 +23,7 @@ import (
 	TESTED : POST /users
 	TESTED : PATCH /users/{id}
 	TESTED : DELETE /users/{id}
+	TESTED : POST /users/{id}/password
 */
 func TestUserHandler(t *testing.T) {
 	var (
@@ -47,7 +49,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users", nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing the request
 	r.ServeHTTP(rr, request)
 
@@ -63,6 +65,35 @@ func TestUserHandler(t *testing.T) {
 
 	globals.Log.Debug("GET /users - PASSED")
 
+	//
+	//	POST /users with a password that does not follow the policy
+	//
+
+	weakUser := model.User{
+		RoleId:     1,
+		ContractId: 1,
+		Username:   "Weak user",
+		Password:   "password",
+		Mail:       "Weakuser@mail",
+	}
+	if jsonObject, err = json.Marshal(weakUser); err != nil {
+		t.Error(err)
+	}
+
+	if request, err = http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(jsonObject)); err != nil {
+		t.Error(err)
+	}
+	addSession(request, tokenCookie)
+
+	weakRecorder := httptest.NewRecorder()
+	r.ServeHTTP(weakRecorder, request)
+
+	if weakRecorder.Code != http.StatusUnprocessableEntity {
+		t.Error("A weak password got accepted")
+	}
+
+	globals.Log.Debug("POST /users with a weak password - PASSED")
+
 	//
 	//	POST /users
 	//
@@ -71,6 +102,7 @@ func TestUserHandler(t *testing.T) {
 		RoleId:     1,
 		ContractId: 1,
 		Username:   "New test user",
+		Password:   "New-test-passw0rd",
 		Mail:       "Newtestuser@mail",
 	}
 	// Turning the object into JSON
@@ -81,7 +113,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -103,7 +135,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/"+strconv.FormatInt(user1.UserId, 10), nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -132,7 +164,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/companies/1/users", nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -158,7 +190,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/projects/1/users", nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -184,7 +216,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/schedules/1/users", nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -217,7 +249,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPatch, "/users/"+strconv.FormatInt(user.UserId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -225,7 +257,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/"+strconv.FormatInt(user.UserId, 10), nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -248,7 +280,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodDelete, "/users/"+strconv.FormatInt(user.UserId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -256,7 +288,7 @@ func TestUserHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/"+strconv.FormatInt(user.UserId, 10), nil); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -266,4 +298,113 @@ func TestUserHandler(t *testing.T) {
 	}
 
 	globals.Log.Debug("DELETE /users/{id} - PASSED")
+
+	//
+	//	POST /users/{id}/password
+	//
+
+	// Giving a temporary password to the new user
+	temporaryPassword := handlers.PasswordChange{
+		NewPassword: "Temp0rary-passw0rd",
+	}
+	if jsonObject, err = json.Marshal(temporaryPassword); err != nil {
+		t.Error(err)
+	}
+
+	if request, err = http.NewRequest(http.MethodPost, "/users/"+strconv.FormatInt(user1.UserId, 10)+"/password", bytes.NewBuffer(jsonObject)); err != nil {
+		t.Error(err)
+	}
+	addSession(request, tokenCookie)
+
+	passwordRecorder := httptest.NewRecorder()
+	r.ServeHTTP(passwordRecorder, request)
+
+	if passwordRecorder.Code != http.StatusOK {
+		t.Error("Could not set the password of another user")
+	}
+
+	// Logging in with the temporary password
+	if jsonObject, err = json.Marshal(model.User{
+		Mail:     user1.Mail,
+		Password: temporaryPassword.NewPassword,
+	}); err != nil {
+		t.Error(err)
+	}
+
+	if request, err = http.NewRequest(http.MethodPost, "/get-token", bytes.NewBuffer(jsonObject)); err != nil {
+		t.Error(err)
+	}
+
+	loginRecorder := httptest.NewRecorder()
+	r.ServeHTTP(loginRecorder, request)
+
+	userCookie := loginRecorder.Result().Cookies()[0]
+
+	// The API can't be used before changing the password
+	if request, err = http.NewRequest(http.MethodGet, "/users", nil); err != nil {
+		t.Error(err)
+	}
+	addSession(request, userCookie)
+
+	blockedRecorder := httptest.NewRecorder()
+	r.ServeHTTP(blockedRecorder, request)
+
+	if blockedRecorder.Code != http.StatusForbidden {
+		t.Error("A user with a temporary password could use the API")
+	}
+
+	// Changing the password with a wrong old password
+	if jsonObject, err = json.Marshal(handlers.PasswordChange{
+		OldPassword: "Wrong-passw0rd",
+		NewPassword: "Brand-new-passw0rd",
+	}); err != nil {
+		t.Error(err)
+	}
+
+	if request, err = http.NewRequest(http.MethodPost, "/users/"+strconv.FormatInt(user1.UserId, 10)+"/password", bytes.NewBuffer(jsonObject)); err != nil {
+		t.Error(err)
+	}
+	addSession(request, userCookie)
+
+	passwordRecorder = httptest.NewRecorder()
+	r.ServeHTTP(passwordRecorder, request)
+
+	if passwordRecorder.Code != http.StatusUnauthorized {
+		t.Error("The password got changed with a wrong old password")
+	}
+
+	// Changing the password with the right one
+	if jsonObject, err = json.Marshal(handlers.PasswordChange{
+		OldPassword: temporaryPassword.NewPassword,
+		NewPassword: "Brand-new-passw0rd",
+	}); err != nil {
+		t.Error(err)
+	}
+
+	if request, err = http.NewRequest(http.MethodPost, "/users/"+strconv.FormatInt(user1.UserId, 10)+"/password", bytes.NewBuffer(jsonObject)); err != nil {
+		t.Error(err)
+	}
+	addSession(request, userCookie)
+
+	passwordRecorder = httptest.NewRecorder()
+	r.ServeHTTP(passwordRecorder, request)
+
+	if passwordRecorder.Code != http.StatusOK {
+		t.Error("Could not change the password")
+	}
+
+	// Now the API can be used
+	if request, err = http.NewRequest(http.MethodGet, "/users", nil); err != nil {
+		t.Error(err)
+	}
+	addSession(request, userCookie)
+
+	blockedRecorder = httptest.NewRecorder()
+	r.ServeHTTP(blockedRecorder, request)
+
+	if blockedRecorder.Code != http.StatusOK {
+		t.Error("The user can't use the API after changing the password")
+	}
+
+	globals.Log.Debug("POST /users/{id}/password - PASSED")
 }
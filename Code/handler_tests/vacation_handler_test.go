@@ -56,7 +56,7 @@ func TestVacationHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/users/1/vacations", nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -88,8 +88,8 @@ func TestVacationHandler(t *testing.T) {
 	//
 	SI2 := handlers.ScheduleIntermediate{
 		ProjectId: 2,
-		StartDate: "2015-06-15 10:19:30",
-		EndDate:   "2020-06-15 10:19:30",
+		StartDate: "2015-06-15T10:19:30+02:00",
+		EndDate:   "2020-06-15T10:19:30+02:00",
 	}
 	// Turning the object into JSON
 	if jsonObject, err = json.Marshal(SI2); err != nil {
@@ -99,7 +99,7 @@ func TestVacationHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPost, "/vacations", bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -127,7 +127,7 @@ func TestVacationHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/vacations/"+strconv.FormatInt(SI2.ScheduleId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 
 	// Sending the request
 	r.ServeHTTP(rr, request)
@@ -157,9 +157,10 @@ func TestVacationHandler(t *testing.T) {
 	//
 
 	// Fetching a vacation
-	ISpatch := handlers.ScheduleToIntermediate(fakeSchedules[1])
-	// Modifying it
-	ISpatch.StartDate = "2007-05-30 10:09:27"
+	ISpatch := handlers.ScheduleToIntermediate(fakeSchedules[1], globals.DefaultTimeZone)
+	// Modifying it, on a day where the user has no other schedule
+	ISpatch.StartDate = "2007-05-29T10:09:27+02:00"
+	ISpatch.EndDate = "2007-05-29T18:09:27+02:00"
 	// JSON-ing the object
 	if jsonObject, err = json.Marshal(ISpatch); err != nil {
 		t.Error(err)
@@ -169,7 +170,7 @@ func TestVacationHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodPatch, "/vacations/"+strconv.FormatInt(ISpatch.ScheduleId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err)
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -177,7 +178,7 @@ func TestVacationHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/vacations/"+strconv.FormatInt(ISpatch.ScheduleId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -200,7 +201,7 @@ func TestVacationHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodDelete, "/vacations/"+strconv.FormatInt(ISpatch.ScheduleId, 10), bytes.NewBuffer(jsonObject)); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
@@ -208,7 +209,7 @@ func TestVacationHandler(t *testing.T) {
 	if request, err = http.NewRequest(http.MethodGet, "/vacations/"+strconv.FormatInt(ISpatch.ScheduleId, 10), nil); err != nil {
 		t.Error(err.Error())
 	}
-	request.AddCookie(tokenCookie)
+	addSession(request, tokenCookie)
 	// Executing it
 	r.ServeHTTP(rr, request)
 
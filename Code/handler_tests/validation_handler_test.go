@@ -0,0 +1,114 @@
+package handler_tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// Returns the fields of the violations of an error, in order
+func violatedFields(t *testing.T, rr *httptest.ResponseRecorder) []string {
+	var fields []string
+
+	body := decodeError(t, rr)
+	if rr.Code != http.StatusUnprocessableEntity || body.Code != handlers.ErrorValidationFailed {
+		t.Error("The request was not refused as invalid :", rr.Code, body)
+	}
+
+	for _, detail := range body.Details {
+		fields = append(fields, detail.Field)
+	}
+
+	return fields
+}
+
+// Tells wether the list contains exactly the fields
+func sameFields(Fields []string, Expected ...string) bool {
+	if len(Fields) != len(Expected) {
+		return false
+	}
+
+	for i := range Fields {
+		if Fields[i] != Expected[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+	TESTED : The validation of the requests
+*/
+func TestValidationHandler(t *testing.T) {
+	var fields []string
+
+	//
+	//	Required fields, ranges and formats, all returned at once
+	//
+	user := model.User{
+		ContractId:           1,
+		Mail:                 "not a mail",
+		Password:             "Val1d-Passw0rd!",
+		TheoricalHoursWorked: -35,
+	}
+
+	rr := sendRequest(t, http.MethodPost, "/users", user, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "role_id", "mail", "theorical_hours_worked") {
+		t.Error("Wrong violations for a user :", fields)
+	}
+
+	rr = sendRequest(t, http.MethodPost, "/projects", model.Project{ProjectName: "   "}, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "project_name") {
+		t.Error("Wrong violations for a project :", fields)
+	}
+
+	rr = sendRequest(t, http.MethodPost, "/schedules", handlers.ScheduleIntermediate{ProjectId: 1, StartDate: "yesterday"}, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "end_date", "start_date") {
+		t.Error("Wrong violations for a schedule :", fields)
+	}
+
+	globals.Log.Debug("Required fields, ranges and formats - PASSED")
+
+	//
+	//	Rules between fields
+	//
+	rr = sendRequest(t, http.MethodPost, "/schedules", handlers.ScheduleIntermediate{
+		ProjectId: 1,
+		StartDate: "2021-03-01T10:00:00+01:00",
+		EndDate:   "2021-03-01T08:00:00+01:00",
+	}, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "end_date") {
+		t.Error("A schedule ending before its start was accepted :", fields)
+	}
+
+	globals.Log.Debug("Rules between fields - PASSED")
+
+	//
+	//	Referenced ids
+	//
+	rr = sendRequest(t, http.MethodPost, "/comments", model.Comment{ScheduleId: 999999, Comment: "Lost"}, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "schedule_id") {
+		t.Error("A comment on an unknown schedule was accepted :", fields)
+	}
+
+	user = model.User{ContractId: 999999, RoleId: 3, Mail: "Validation@mydb", Password: "Val1d-Passw0rd!"}
+	rr = sendRequest(t, http.MethodPost, "/users", user, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "contract_id") {
+		t.Error("A user with an unknown contract was accepted :", fields)
+	}
+
+	rr = sendRequest(t, http.MethodPost, "/schedules", handlers.ScheduleIntermediate{
+		ProjectId: 999999,
+		StartDate: "2021-03-01T08:00:00+01:00",
+		EndDate:   "2021-03-01T10:00:00+01:00",
+	}, tokenCookie)
+	if fields = violatedFields(t, rr); !sameFields(fields, "project_id") {
+		t.Error("A schedule of an unknown project was accepted :", fields)
+	}
+
+	globals.Log.Debug("Referenced ids - PASSED")
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//	GetAccessTokensOfUserHandler
+/*	The handler called by the following endpoint : GET /users/{id}/tokens
+	This method is used to list the API tokens of a user, without the tokens themselves.
+	Users see their own tokens, the users that can add and modify users see everybody's.
+*/
+func (env *Env) GetAccessTokensOfUserHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err    error
+		userId int64
+		tokens model.AccessTokens
+	)
+
+	globals.Log.Debug("GetAccessTokensOfUserHandler called")
+
+	if userId, err = env.selfOrManagedUserId(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Seeing the API tokens of another user is forbidden",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if tokens, err = env.DB.GetAccessTokensOfUser(userId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when getting the API tokens",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(tokens); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the API tokens",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	CreateAccessTokenHandler
+/*	The handler called by the following endpoint : POST /users/{id}/tokens
+	This method is used by users to create an API token for themselves.
+	The token is only given in this answer : only its hash is stored.
+*/
+func (env *Env) CreateAccessTokenHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err     error
+		form    AccessTokenRequest
+		userId  int64
+		token   string
+		created AccessTokenCreated
+	)
+
+	globals.Log.Debug("CreateAccessTokenHandler called")
+
+	if userId, err = env.selfUserId(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Users can only create API tokens for themselves",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if appErr := forbidDelegatedSession(r); appErr != nil {
+		return appErr
+	}
+
+	if err = json.NewDecoder(r.Body).Decode(&form); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	form.Name = strings.TrimSpace(form.Name)
+	form.Scope = strings.Join(strings.Fields(form.Scope), " ")
+
+	if appErr := env.validate(&form).result(); appErr != nil {
+		return appErr
+	}
+
+	if token, err = globals.GenerateAccessToken(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when generating the API token",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	created = AccessTokenCreated{
+		AccessToken: model.AccessToken{
+			UserId:    userId,
+			Name:      form.Name,
+			TokenHash: globals.HashAccessToken(token),
+			Prefix:    token[:len(globals.AccessTokenPrefix)+6],
+			Scope:     form.Scope,
+			CreatedAt: time.Now(),
+			ExpiresAt: form.ExpiresAt,
+		},
+		Token: token,
+	}
+
+	if created.AccessTokenId, err = env.DB.CreateAccessToken(created.AccessToken); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when creating the API token",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.Debug("API token created")
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(created); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the API token",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	DeleteAccessTokenHandler
+/*	The handler called by the following endpoint : DELETE /users/{id}/tokens/{token_id}
+	This method is used to revoke an API token.
+	Users revoke their own tokens, the users that can add and modify users revoke anybody's.
+*/
+func (env *Env) DeleteAccessTokenHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err     error
+		userId  int64
+		tokenId int
+		token   model.AccessToken
+	)
+
+	globals.Log.Debug("DeleteAccessTokenHandler called")
+
+	if userId, err = env.selfOrManagedUserId(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Revoking the API tokens of another user is forbidden",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if appErr := forbidDelegatedSession(r); appErr != nil {
+		return appErr
+	}
+
+	if tokenId, err = strconv.Atoi(mux.Vars(r)["token_id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	// The token must belong to the user of the URL
+	if token, err = env.DB.GetAccessToken(int64(tokenId)); err != nil || token.UserId != userId {
+		return &AppError{
+			Error:   err,
+			Message: "Unexisting API token",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	if err = env.DB.DeleteAccessToken(token.AccessTokenId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when revoking the API token",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.Debug("API token revoked")
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+//	selfOrManagedUserId(r *http.Request) (int64, error)
+/*	Returns the id of the request if it is the one of the connected user,
+	or if the connected user can add and modify users.
+*/
+func (env *Env) selfOrManagedUserId(r *http.Request) (int64, error) {
+	var (
+		err           error
+		userId        int
+		currentUserId int64
+		currentRoleId int64
+		currentRole   model.Role
+	)
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return 0, err
+	}
+
+	if currentUserId, currentRoleId, err = contextUser(r); err != nil {
+		return 0, err
+	}
+
+	if int64(userId) == currentUserId {
+		return currentUserId, nil
+	}
+
+	if currentRole, err = env.DB.GetRole(currentRoleId); err != nil {
+		return 0, err
+	}
+
+	if !currentRole.CanAddAndModifyUsers {
+		return 0, errors.New("forbidden")
+	}
+
+	return int64(userId), nil
+}
+
+//	forbidDelegatedSession(r *http.Request) *AppError
+/*	Refuses the requests made with an API token or during an impersonation, for what only the real user can do :
+	a token can't create other tokens nor keep itself alive, and an impersonation can't last longer than planned.
+*/
+func forbidDelegatedSession(r *http.Request) *AppError {
+	userData := r.Context().Value("UserData").(map[string]string)
+
+	if userData["access_token_id"] != "" || userData["impersonation_id"] != "" {
+		return &AppError{
+			Error:     errors.New("delegated session"),
+			Message:   "This can't be done with an API token or while impersonating a user",
+			Code:      http.StatusForbidden,
+			ErrorCode: ErrorDelegatedSessionRefused,
+		}
+	}
+	return nil
+}
+
+//	accessTokenUserData(Token string, Now time.Time) (map[string]string, error)
+/*	Verifies an API token and returns the context data of the request : the ones of its owner, with his current role,
+	the id of the token and its scope. The last use of the token is saved.
+*/
+func (env *Env) accessTokenUserData(Token string, Now time.Time) (map[string]string, error) {
+	var (
+		err   error
+		token model.AccessToken
+		owner model.User
+	)
+
+	if token, err = env.DB.GetAccessTokenFromHash(globals.HashAccessToken(Token)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("unknown API token")
+		}
+		return nil, err
+	}
+
+	if token.ExpiresAt != nil && !Now.Before(*token.ExpiresAt) {
+		return nil, errors.New("expired API token")
+	}
+
+	if owner, err = env.DB.GetUser(token.UserId); err != nil {
+		return nil, err
+	}
+
+	if err = env.DB.UpdateAccessTokenLastUse(token.AccessTokenId, Now); err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"user_id":         strconv.FormatInt(owner.UserId, 10),
+		"role_id":         strconv.FormatInt(owner.RoleId, 10),
+		"mail":            owner.Mail,
+		"access_token_id": strconv.FormatInt(token.AccessTokenId, 10),
+		"scope":           token.Scope,
+	}, nil
+}
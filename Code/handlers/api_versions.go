@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+// V1Prefix : The prefix of the paths of the first version of the API.
+const V1Prefix = "/v1"
+
+// The paths that start with a version, like /v1/users : they are never legacy paths.
+var versionedPathRegex = regexp.MustCompile(`^/v[0-9]+(/|$)`)
+
+//	unversionedPath(r *http.Request, Match *mux.RouteMatch) bool
+/*	Matches the paths that don't start with a version, like /users.
+ */
+func unversionedPath(r *http.Request, Match *mux.RouteMatch) bool {
+	return !versionedPathRegex.MatchString(r.URL.Path)
+}
+
+//	LegacyAPIHandler(Router *mux.Router, Prefix string) http.Handler
+/*	Answers the paths of the root, like /users, as the same paths of a version of the API, like /v1/users.
+	The answers tell the clients the root paths are deprecated, when they will stop answering (see globals.LegacyAPIRules),
+	and where the same route is now.
+*/
+func (env *Env) LegacyAPIHandler(Router *mux.Router, Prefix string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		globals.Log.Debug("Legacy path " + r.URL.Path + " called")
+
+		// The same request, on the path of the version
+		versioned := r.Clone(r.Context())
+		versioned.URL.Path = Prefix + r.URL.Path
+		if r.URL.RawPath != "" {
+			versioned.URL.RawPath = Prefix + r.URL.RawPath
+		}
+		versioned.RequestURI = versioned.URL.RequestURI()
+
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(globals.LegacyAPI.DeprecatedAt.Unix(), 10))
+		w.Header().Set("Sunset", globals.LegacyAPI.Sunset.UTC().Format(http.TimeFormat))
+		w.Header().Add("Link", "<"+versioned.URL.EscapedPath()+`>; rel="successor-version"`)
+
+		Router.ServeHTTP(w, versioned)
+	})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The password stored for the users authenticated by a directory.
+// It is not a bcrypt hash, so no password can match it.
+const noPassword = "!"
+
+// The weekly hours given to the users created on their first login, until an administrator changes them.
+const provisionedWeeklyHours = 35
+
+// Returned when a directory can't be reached, to tell it apart from wrong credentials.
+var errDirectoryUnavailable = errors.New("The directory is unavailable")
+
+//	authenticateUser(Login string, Password string) (model.User, bool, bool, error)
+/*	Verifies the credentials of a user, with his password stored in the database or with his directory.
+	A login that matches no mail is tried against every directory : it can be the username of a directory user,
+	or a user that does not exist yet and is created if the provisioning of the directory is enabled.
+	Returns the user, wether the user exists, and wether the credentials are valid.
+*/
+func (env *Env) authenticateUser(Login string, Password string) (model.User, bool, bool, error) {
+	var (
+		err      error
+		user     model.User
+		provider authentication.Provider
+		identity authentication.Identity
+	)
+
+	if user, err = env.DB.GetUserFromEmail(Login); err != nil && err != sql.ErrNoRows {
+		return user, false, false, err
+	}
+
+	if err == nil {
+		if user.UsesLocalAuthentication() {
+			return user, true, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(Password)) == nil, nil
+		}
+
+		// The users of an identity provider log in with GET /oidc/{provider}/login. The dummy hash keeps them
+		// from being told apart from the local users by the time of the answer
+		if _, ok := env.OIDCProviders[user.AuthProvider]; ok {
+			bcrypt.CompareHashAndPassword(dummyPassword, []byte(Password))
+			return user, true, false, nil
+		}
+
+		// The provider of the user may have been removed from the configuration
+		var ok bool
+		if provider, ok = env.AuthProviders[user.AuthProvider]; !ok {
+			globals.Log.WithFields(logrus.Fields{"provider": user.AuthProvider}).Warn("Unknown authentication provider")
+			bcrypt.CompareHashAndPassword(dummyPassword, []byte(Password))
+			return user, true, false, nil
+		}
+
+		if _, err = provider.Authenticate(Login, Password); err != nil {
+			return user, true, false, directoryError(err)
+		}
+		return user, true, true, nil
+	}
+
+	// An unknown mail : trying the directories
+	for _, name := range env.authProviderNames() {
+		provider = env.AuthProviders[name]
+
+		if identity, err = provider.Authenticate(Login, Password); err == authentication.ErrInvalidCredentials {
+			continue
+		} else if err != nil {
+			return user, false, false, directoryError(err)
+		}
+
+		if identity.Mail == "" {
+			identity.Mail = Login
+		}
+		return env.externalUser(provider.Name(), provider.Provisioning(), identity)
+	}
+
+	// Nobody matched, the dummy hash makes the answer take the same time as a wrong password
+	bcrypt.CompareHashAndPassword(dummyPassword, []byte(Password))
+
+	return user, false, false, nil
+}
+
+//	externalUser(ProviderName string, Provisioning authentication.Provisioning, Identity authentication.Identity) (model.User, bool, bool, error)
+/*	Returns the user authenticated by a directory or an identity provider, and creates it if the provisioning is enabled.
+	A user with the same mail but another provider, like a local user, is not taken over.
+*/
+func (env *Env) externalUser(ProviderName string, Provisioning authentication.Provisioning, Identity authentication.Identity) (model.User, bool, bool, error) {
+	var (
+		err  error
+		user model.User
+	)
+
+	if user, err = env.DB.GetUserFromEmail(Identity.Mail); err == nil {
+		return user, true, user.AuthProvider == ProviderName, nil
+	} else if err != sql.ErrNoRows {
+		return user, false, false, err
+	}
+
+	if !Provisioning.Enabled {
+		return user, false, false, nil
+	}
+
+	user = model.User{
+		ContractId:           Provisioning.ContractId,
+		RoleId:               Provisioning.RoleId,
+		Username:             Identity.Username,
+		Password:             noPassword,
+		LastName:             Identity.LastName,
+		FirstName:            Identity.FirstName,
+		Mail:                 Identity.Mail,
+		TheoricalHoursWorked: provisionedWeeklyHours,
+		AuthProvider:         ProviderName,
+	}
+
+	if user.UserId, err = env.DB.CreateUser(user); err != nil {
+		return user, false, false, err
+	}
+
+	globals.Log.WithFields(logrus.Fields{"mail": user.Mail, "provider": user.AuthProvider}).Info("User created on his first login")
+
+	return user, true, true, nil
+}
+
+//	authProviderNames() []string
+/*	Returns the names of the configured providers, sorted so that they are always tried in the same order.
+ */
+func (env *Env) authProviderNames() []string {
+	var names []string
+	for name := range env.AuthProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//	hasAuthProvider(User model.User) bool
+/*	Tells wether the provider of a user is configured.
+ */
+func (env *Env) hasAuthProvider(User model.User) bool {
+	if User.UsesLocalAuthentication() {
+		return true
+	}
+
+	_, isDirectory := env.AuthProviders[User.AuthProvider]
+	_, isIdentityProvider := env.OIDCProviders[User.AuthProvider]
+
+	return isDirectory || isIdentityProvider
+}
+
+func directoryError(err error) error {
+	if err == authentication.ErrInvalidCredentials {
+		return nil
+	}
+
+	globals.Log.WithFields(logrus.Fields{"error": err}).Error("Directory error")
+	return errDirectoryUnavailable
+}
@@ -3,27 +3,56 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// A hash compared to the given password when the user does not exist,
+// so that the answer takes the same time whether the mail is known or not.
+var dummyPassword, _ = bcrypt.GenerateFromPassword([]byte("This is not a password"), bcrypt.DefaultCost)
+
+// The message returned for every failed authentication, so that nobody can tell if a mail is known.
+const invalidCredentialsMessage = "Invalid mail or password"
+
+// How long the token given by the first step of a login with two-factor authentication is valid.
+const twoFactorTokenLifetime = 5 * time.Minute
+
+// loginKey : A key the login attempts are counted for, and the throttling rules applied to it.
+type loginKey struct {
+	key   string
+	rules globals.ThrottlingRules
+}
+
 //	GetTokenHandler
 /*	The handler called by the following endpoint : POST /get-token.
 	This method takes the email adress and the password of the user in order to connect them.
-	Uses bcrypt to compare the given password and the crypted database password.
+	Uses bcrypt to compare the given password and the crypted database password,
+	or the directory of the user if he is not a local one (see authenticateUser).
+	The failed attempts are counted per mail and per client address : they are slowed down
+	with an exponential back-off, then locked for a while (see globals.ThrottlingRules).
+	If the two-factor authentication is enabled, it answers with a 202 code and a token for POST /get-token/two-factor.
 	Is this method works, it sets up a cookie that contains the token.
 */
 func (env *Env) GetTokenHandler(w http.ResponseWriter, r *http.Request) *AppError {
 	var (
-		err          error
-		databaseUser model.User
-		formUser     model.User
+		err           error
+		databaseUser  model.User
+		formUser      model.User
+		userExists    bool
+		authenticated bool
+		wait          time.Duration
 	)
 
 	// Parsing the form
@@ -40,59 +69,127 @@ func (env *Env) GetTokenHandler(w http.ResponseWriter, r *http.Request) *AppErro
 		return &AppError{
 			Error:   err,
 			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	globals.Log.WithFields(logrus.Fields{"Form User : ": formUser.Mail}).Debug("GetTokenHandler")
+
+	keys := []loginKey{
+		{key: MailLoginKey(formUser.Mail), rules: globals.AccountThrottling},
+		{key: "ip:" + clientAddress(r), rules: globals.IPThrottling},
+	}
+
+	// Refusing the attempt if the mail or the address is throttled
+	if wait, err = env.loginWait(keys, time.Now()); err != nil {
+		return &AppError{
+			Code:    http.StatusInternalServerError,
+			Error:   err,
+			Message: "Error when getting the login attempts",
+		}
+	}
+
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+		return &AppError{
+			Code:    http.StatusTooManyRequests,
+			Error:   errors.New("login throttled"),
+			Message: "Too many failed attempts, try again later",
+		}
+	}
+
+	// Trying to authenticate the User, with his password or his directory
+	if databaseUser, userExists, authenticated, err = env.authenticateUser(formUser.Mail, formUser.Password); err == errDirectoryUnavailable {
+		return &AppError{
+			Code:    http.StatusServiceUnavailable,
+			Error:   err,
+			Message: "The directory is unavailable, try again later",
+		}
+	} else if err != nil {
+		return &AppError{
 			Code:    http.StatusInternalServerError,
+			Error:   err,
+			Message: "Error when authenticating the user",
 		}
 	}
 
-	globals.Log.WithFields(logrus.Fields{"Form User : ": formUser}).Debug("GetTokenHandler")
+	globals.Log.WithFields(logrus.Fields{"Databse User : ": databaseUser.UserId}).Debug("GetTokenHandler")
 
-	// Trying to identify the User
-	if databaseUser, err = env.DB.GetUserFromEmail(formUser.Mail); err != nil {
-		if err == sql.ErrNoRows {
+	if !authenticated {
+		if errr := env.registerLoginFailure(keys, databaseUser, userExists, time.Now()); errr != nil {
 			return &AppError{
-				Code:    http.StatusUnauthorized,
-				Error:   err,
-				Message: "User does not exist",
+				Code:    http.StatusInternalServerError,
+				Error:   errr,
+				Message: "Error when saving the login attempt",
 			}
 		}
+
+		return &AppError{
+			Code:      http.StatusUnauthorized,
+			Error:     errors.New("authentication failed"),
+			Message:   invalidCredentialsMessage,
+			ErrorCode: ErrorInvalidCredentials,
+		}
+	}
+
+	// The failures of this account are forgotten
+	if err = env.DB.DeleteLoginAttempt(keys[0].key); err != nil {
 		return &AppError{
 			Code:    http.StatusInternalServerError,
 			Error:   err,
-			Message: "Error when getting the user",
+			Message: "Error when resetting the login attempts",
 		}
 	}
 
-	globals.Log.WithFields(logrus.Fields{"Databse User : ": databaseUser}).Debug("GetTokenHandler")
+	return env.completeLogin(w, databaseUser)
+}
 
-	// Trying to authenticate the User with his password
-	if err = bcrypt.CompareHashAndPassword([]byte(databaseUser.Password), []byte(formUser.Password)); err != nil {
+//	completeLogin(w http.ResponseWriter, User model.User) *AppError
+/*	Ends the login of an authenticated user : writes the session token,
+	or asks for a code if the two-factor authentication is enabled.
+*/
+func (env *Env) completeLogin(w http.ResponseWriter, User model.User) *AppError {
+	twoFactor, err := env.DB.GetTwoFactor(User.UserId)
+	if err != nil && err != sql.ErrNoRows {
 		return &AppError{
-			Code:    http.StatusUnauthorized,
+			Code:    http.StatusInternalServerError,
 			Error:   err,
-			Message: "The password is incorrect",
+			Message: "Error when getting the two-factor authentication",
 		}
 	}
 
-	// Creating the token
-	token := jwt.New(jwt.SigningMethodHS256)
+	// With the two-factor authentication, a code is needed before getting the token
+	if err == nil && twoFactor.Enabled {
+		return env.writeTwoFactorChallenge(w, User)
+	}
 
-	// Initializing the claims and creating them
-	claims := token.Claims.(jwt.MapClaims)
-	claims["mail"] = databaseUser.Mail
-	claims["user_id"] = databaseUser.UserId
-	claims["role_id"] = databaseUser.RoleId
-	claims["expiration"] = time.Now().Add(time.Hour * 8)
+	return env.writeSessionToken(w, User)
+}
 
-	// Sign the token with the Globals secret key
-	tokenString, _ := token.SignedString(globals.TokenSignKey)
+//	writeSessionToken(w http.ResponseWriter, User model.User) *AppError
+/*	Creates the token of an authenticated user, and writes it in the "token" cookie and in the body.
+ */
+func (env *Env) writeSessionToken(w http.ResponseWriter, User model.User) *AppError {
+	var (
+		err         error
+		tokenString string
+	)
 
-	// Write the token to the browser
-	cookieToken := http.Cookie{
-		Name:  "token",
-		Value: tokenString,
+	// Initializing the claims, the expiration and the CSRF token are added by issueSession
+	claims := jwt.MapClaims{
+		"mail":    User.Mail,
+		"user_id": User.UserId,
+		"role_id": User.RoleId,
 	}
 
-	http.SetCookie(w, &cookieToken)
+	// Sign the token and write it to the browser
+	if tokenString, err = issueSession(w, claims, time.Now().Add(sessionLifetime)); err != nil {
+		return &AppError{
+			Code:    http.StatusInternalServerError,
+			Error:   err,
+			Message: "Error when signing the token",
+		}
+	}
 
 	w.WriteHeader(http.StatusOK)
 	if _, err = w.Write([]byte(tokenString)); err != nil {
@@ -105,6 +202,349 @@ func (env *Env) GetTokenHandler(w http.ResponseWriter, r *http.Request) *AppErro
 	return nil
 }
 
+//	writeTwoFactorChallenge(w http.ResponseWriter, User model.User) *AppError
+/*	Answers a login with a valid password when the two-factor authentication is enabled.
+	The body contains a short-lived token that can only be used with POST /get-token/two-factor.
+*/
+func (env *Env) writeTwoFactorChallenge(w http.ResponseWriter, User model.User) *AppError {
+	var (
+		err         error
+		tokenString string
+	)
+
+	token := jwt.New(jwt.SigningMethodHS256)
+
+	claims := token.Claims.(jwt.MapClaims)
+	claims["user_id"] = User.UserId
+	claims["two_factor_pending"] = true
+	claims["exp"] = time.Now().Add(twoFactorTokenLifetime).Unix()
+
+	if tokenString, err = token.SignedString(globals.TokenSignKey); err != nil {
+		return &AppError{
+			Code:    http.StatusInternalServerError,
+			Error:   err,
+			Message: "Error when signing the token",
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusAccepted)
+
+	if err = json.NewEncoder(w).Encode(TwoFactorChallenge{
+		TwoFactorRequired: true,
+		TwoFactorToken:    tokenString,
+	}); err != nil {
+		return &AppError{
+			Code:    http.StatusInternalServerError,
+			Error:   err,
+			Message: "Error when encoding the two-factor token",
+		}
+	}
+
+	return nil
+}
+
+//	GetTokenTwoFactorHandler
+/*	The handler called by the following endpoint : POST /get-token/two-factor.
+	This method is the second step of the login when the two-factor authentication is enabled.
+	It takes the token given by POST /get-token and a TOTP code, or one of the recovery codes.
+	The failed attempts are throttled like the passwords ones.
+	If the code is valid, it sets up the cookie that contains the token.
+*/
+func (env *Env) GetTokenTwoFactorHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err       error
+		form      TwoFactorLogin
+		token     *jwt.Token
+		user      model.User
+		twoFactor model.TwoFactor
+		wait      time.Duration
+		valid     bool
+	)
+
+	if err = json.NewDecoder(r.Body).Decode(&form); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	// Verifying the token given by the first step
+	if token, err = jwt.Parse(form.TwoFactorToken, func(token *jwt.Token) (interface{}, error) {
+		return globals.TokenSignKey, nil
+	}); err != nil || !token.Valid {
+		return &AppError{
+			Error:   err,
+			Message: "The two-factor token is invalid or expired",
+			Code:    http.StatusUnauthorized,
+		}
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	userId, ok := claims["user_id"].(float64)
+	if pending, _ := claims["two_factor_pending"].(bool); !ok || !pending {
+		return &AppError{
+			Error:   errors.New("not a two-factor token"),
+			Message: "The two-factor token is invalid or expired",
+			Code:    http.StatusUnauthorized,
+		}
+	}
+
+	if user, err = env.DB.GetUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "The two-factor token is invalid or expired",
+			Code:    http.StatusUnauthorized,
+		}
+	}
+
+	// The codes are throttled like the passwords
+	keys := []loginKey{
+		{key: TOTPLoginKey(user.UserId), rules: globals.AccountThrottling},
+	}
+
+	if wait, err = env.loginWait(keys, time.Now()); err != nil {
+		return &AppError{
+			Code:    http.StatusInternalServerError,
+			Error:   err,
+			Message: "Error when getting the login attempts",
+		}
+	}
+
+	if wait > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+		return &AppError{
+			Code:    http.StatusTooManyRequests,
+			Error:   errors.New("login throttled"),
+			Message: "Too many failed attempts, try again later",
+		}
+	}
+
+	if twoFactor, err = env.DB.GetTwoFactor(user.UserId); err != nil || !twoFactor.Enabled {
+		return &AppError{
+			Error:   err,
+			Message: "The two-factor authentication is not enabled",
+			Code:    http.StatusUnauthorized,
+		}
+	}
+
+	if valid, err = env.checkTwoFactorCode(twoFactor, form.Code); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when verifying the code",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if !valid {
+		if errr := env.registerLoginFailure(keys, user, true, time.Now()); errr != nil {
+			return &AppError{
+				Code:    http.StatusInternalServerError,
+				Error:   errr,
+				Message: "Error when saving the login attempt",
+			}
+		}
+
+		return &AppError{
+			Error:     errors.New("invalid code"),
+			Message:   "The code is incorrect",
+			Code:      http.StatusUnauthorized,
+			ErrorCode: ErrorInvalidCredentials,
+		}
+	}
+
+	if err = env.DB.DeleteLoginAttempt(keys[0].key); err != nil {
+		return &AppError{
+			Code:    http.StatusInternalServerError,
+			Error:   err,
+			Message: "Error when resetting the login attempts",
+		}
+	}
+
+	return env.writeSessionToken(w, user)
+}
+
+//	checkTwoFactorCode(TwoFactor model.TwoFactor, Code string) (bool, error)
+/*	Verifies a TOTP code, that can't have been used before, or a recovery code, that gets consumed.
+ */
+func (env *Env) checkTwoFactorCode(TwoFactor model.TwoFactor, Code string) (bool, error) {
+	if step, ok := globals.MatchTOTPCode(TwoFactor.Secret, Code, time.Now()); ok {
+		// A code can only be used once
+		if step <= TwoFactor.LastUsedStep {
+			return false, nil
+		}
+
+		TwoFactor.LastUsedStep = step
+		if err := env.DB.SaveTwoFactor(TwoFactor); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	return env.DB.UseRecoveryCode(TwoFactor.UserId, globals.HashRecoveryCode(Code))
+}
+
+//	MailLoginKey(Mail string) string
+/*	Returns the key the login attempts of a mail are counted for.
+ */
+func MailLoginKey(Mail string) string {
+	return "mail:" + strings.ToLower(Mail)
+}
+
+//	TOTPLoginKey(UserId int64) string
+/*	Returns the key the two-factor codes of a user are counted for.
+ */
+func TOTPLoginKey(UserId int64) string {
+	return "totp:" + strconv.FormatInt(UserId, 10)
+}
+
+//	deleteLoginAttempts(User model.User) error
+/*	Forgets every failed login counted for an account : its passwords and its two-factor codes.
+ */
+func (env *Env) deleteLoginAttempts(User model.User) error {
+	for _, key := range []string{MailLoginKey(User.Mail), TOTPLoginKey(User.UserId)} {
+		if err := env.DB.DeleteLoginAttempt(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//	clientAddress(r *http.Request) string
+/*	Returns the address of the client that sent the request, without the port.
+ */
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+//	loginWait(Keys []loginKey, Now time.Time) (time.Duration, error)
+/*	Returns how long the client has to wait before trying to log in, according to the failures of every key.
+ */
+func (env *Env) loginWait(Keys []loginKey, Now time.Time) (time.Duration, error) {
+	var (
+		err     error
+		attempt model.LoginAttempt
+		wait    time.Duration
+	)
+
+	for _, key := range Keys {
+		if attempt, err = env.DB.GetLoginAttempt(key.key); err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return 0, err
+		}
+
+		if keyWait := key.rules.Wait(attempt.FailedAttempts, attempt.LastFailure.Time, attempt.LockedUntil.Time, Now); keyWait > wait {
+			wait = keyWait
+		}
+	}
+
+	return wait, nil
+}
+
+//	registerLoginFailure(Keys []loginKey, User model.User, UserExists bool, Now time.Time) error
+/*	Counts a failed attempt for every key, and locks the keys that reached their limit.
+	The owner of the account is notified when his account gets locked.
+*/
+func (env *Env) registerLoginFailure(Keys []loginKey, User model.User, UserExists bool, Now time.Time) error {
+	var (
+		err     error
+		attempt model.LoginAttempt
+	)
+
+	for index, key := range Keys {
+		if attempt, err = env.DB.GetLoginAttempt(key.key); err != nil {
+			if err != sql.ErrNoRows {
+				return err
+			}
+			attempt = model.LoginAttempt{AttemptKey: key.key}
+		}
+
+		// Forgetting the old failures
+		if attempt.FailedAttempts > 0 && key.rules.Expired(attempt.LastFailure.Time, attempt.LockedUntil.Time, Now) {
+			attempt.FailedAttempts = 0
+			attempt.LockedUntil = sql.NullTime{}
+		}
+
+		attempt.FailedAttempts++
+		attempt.LastFailure = sql.NullTime{Valid: true, Time: Now}
+
+		if key.rules.Locks(attempt.FailedAttempts) && !attempt.LockedUntil.Valid {
+			attempt.LockedUntil = sql.NullTime{Valid: true, Time: Now.Add(key.rules.LockoutDuration)}
+
+			globals.Log.WithFields(logrus.Fields{"key": key.key}).Warn("Login locked after too many failed attempts")
+
+			// The first key is the account one
+			if index == 0 && UserExists {
+				env.notify(User, "Your account has been locked",
+					"Your account has been locked after "+strconv.FormatInt(attempt.FailedAttempts, 10)+
+						" failed login attempts. It will be unlocked at "+attempt.LockedUntil.Time.Format(time.RFC1123)+
+						", or sooner by an administrator. If you did not try to log in, please contact an administrator.")
+			}
+		}
+
+		if err = env.DB.SaveLoginAttempt(attempt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//	UnlockUserHandler
+/*	The handler called by the following endpoint : DELETE /users/{id}/lock
+	This method is used to unlock an account locked after too many failed login attempts.
+	Its failed attempts are forgotten.
+*/
+func (env *Env) UnlockUserHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err    error
+		user   model.User
+		userId int
+	)
+
+	globals.Log.Debug("UnlockUserHandler called")
+
+	vars := mux.Vars(r)
+
+	if userId, err = strconv.Atoi(vars["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if user, err = env.DB.GetUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Unexisting user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if err = env.deleteLoginAttempts(user); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when unlocking the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.Debug("User unlocked")
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
 //	DeleteTokenHandler
 /*	The handler called by the following endpoint : POST /get-token.
 	This method deletes the token cookie.
@@ -113,12 +553,7 @@ func (env *Env) DeleteTokenHandler(w http.ResponseWriter, r *http.Request) *AppE
 	globals.Log.Debug("DeleteTokenHandler called")
 
 	// Erase the cookies
-	cookieToken := http.Cookie{
-		Name:  "token",
-		Value: "",
-	}
-
-	http.SetCookie(w, &cookieToken)
+	clearSession(w)
 
 	w.WriteHeader(http.StatusOK)
 	return nil
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//	GetBreakHandler
+/*	The handler called by the following endpoint : GET /breaks/{id}
+	This method is used to get a break taken during a schedule.
+*/
+func (env *Env) GetBreakHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err           error
+		scheduleBreak model.ScheduleBreak
+	)
+
+	globals.Log.Debug("Calling GetBreakHandler")
+
+	if scheduleBreak, err = env.breakOfRequest(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the break",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeBreak(w, r, scheduleBreak)
+}
+
+//	GetBreaksOfScheduleHandler
+/*	The handler called by the following endpoint : GET /schedules/{id}/breaks
+	This method is used to get the breaks taken during a schedule, the first one first.
+*/
+func (env *Env) GetBreaksOfScheduleHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err        error
+		scheduleId int
+		breaks     model.ScheduleBreaks
+	)
+
+	globals.Log.Debug("Calling GetBreaksOfScheduleHandler")
+
+	if scheduleId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if _, err = env.DB.GetSchedule(int64(scheduleId)); err == nil {
+		breaks, err = env.DB.GetBreaksOfSchedule(int64(scheduleId))
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the breaks",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	intermediates := []BreakIntermediate{}
+	for _, scheduleBreak := range breaks {
+		intermediates = append(intermediates, BreakToIntermediate(scheduleBreak, location))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(intermediates); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the breaks",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	CreateBreakHandler
+/*	The handler called by the following endpoint : POST /breaks
+	This method is used to add a break to a schedule, like the lunch, whose hours don't count as worked.
+*/
+func (env *Env) CreateBreakHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err           error
+		scheduleBreak model.ScheduleBreak
+	)
+
+	globals.Log.Debug("Calling CreateBreakHandler")
+
+	intermediate := BreakIntermediate{}
+	if err = json.NewDecoder(r.Body).Decode(&intermediate); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+	intermediate.BreakId = 0
+
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
+	}
+
+	if scheduleBreak, err = IntermediateToBreak(intermediate); err == nil {
+		scheduleBreak.BreakId, err = env.DB.CreateBreak(scheduleBreak)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when creating the break",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeBreak(w, r, scheduleBreak)
+}
+
+//	UpdateBreakHandler
+/*	The handler called by the following endpoint : PATCH /breaks/{id}
+	This method is used to change the dates of a break. It stays with its schedule.
+*/
+func (env *Env) UpdateBreakHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err           error
+		stored        model.ScheduleBreak
+		scheduleBreak model.ScheduleBreak
+	)
+
+	globals.Log.Debug("Calling UpdateBreakHandler")
+
+	if stored, err = env.breakOfRequest(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the break",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	intermediate := BreakIntermediate{}
+	if err = json.NewDecoder(r.Body).Decode(&intermediate); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+	intermediate.BreakId, intermediate.ScheduleId = stored.BreakId, stored.ScheduleId
+
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
+	}
+
+	if scheduleBreak, err = IntermediateToBreak(intermediate); err == nil {
+		scheduleBreak, err = env.DB.UpdateBreak(scheduleBreak)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when updating the break",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeBreak(w, r, scheduleBreak)
+}
+
+//	DeleteBreakHandler
+/*	The handler called by the following endpoint : DELETE /breaks/{id}
+	This method is used to delete a break : its hours count as worked again.
+*/
+func (env *Env) DeleteBreakHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err           error
+		scheduleBreak model.ScheduleBreak
+	)
+
+	globals.Log.Debug("Calling DeleteBreakHandler")
+
+	if scheduleBreak, err = env.breakOfRequest(r); err == nil {
+		err = env.DB.DeleteBreak(scheduleBreak.BreakId)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when deleting the break",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+//	schedulesOfUser(UserId int64) (model.Schedules, error)
+/*	Returns the schedules of a user with their breaks, as needed to count the hours he worked.
+ */
+func (env *Env) schedulesOfUser(UserId int64) (model.Schedules, error) {
+	schedules, err := env.DB.GetSchedulesOfUser(UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	breaks, err := env.DB.GetBreaksOfUser(UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return withBreaks(schedules, breaks), nil
+}
+
+//	withBreaks(Schedules model.Schedules, Breaks model.ScheduleBreaks) model.Schedules
+/*	Returns the schedules with the breaks taken during each of them.
+ */
+func withBreaks(Schedules model.Schedules, Breaks model.ScheduleBreaks) model.Schedules {
+	breaks := map[int64]model.ScheduleBreaks{}
+	for _, scheduleBreak := range Breaks {
+		breaks[scheduleBreak.ScheduleId] = append(breaks[scheduleBreak.ScheduleId], scheduleBreak)
+	}
+
+	for i := range Schedules {
+		Schedules[i].Breaks = breaks[Schedules[i].ScheduleId]
+	}
+	return Schedules
+}
+
+//	breakOfRequest(r *http.Request) (model.ScheduleBreak, error)
+/*	Returns the break of the id of a route.
+ */
+func (env *Env) breakOfRequest(r *http.Request) (model.ScheduleBreak, error) {
+	breakId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return model.ScheduleBreak{}, err
+	}
+	return env.DB.GetBreak(int64(breakId))
+}
+
+//	writeBreak(w http.ResponseWriter, r *http.Request, Break model.ScheduleBreak) *AppError
+/*	Answers with a break, in the zone of the request.
+ */
+func (env *Env) writeBreak(w http.ResponseWriter, r *http.Request, Break model.ScheduleBreak) *AppError {
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(BreakToIntermediate(Break, location)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the break",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
@@ -210,6 +210,10 @@ func (env *Env) CreateCommentHandler(w http.ResponseWriter, r *http.Request) *Ap
 		}
 	}
 
+	if appErr := env.validate(&comment).result(); appErr != nil {
+		return appErr
+	}
+
 	globals.Log.Debug("Calling CreateComment method")
 
 	if commentId, err = env.DB.CreateComment(comment); err != nil {
@@ -261,6 +265,10 @@ func (env *Env) UpdateCommentHandler(w http.ResponseWriter, r *http.Request) *Ap
 		}
 	}
 
+	if appErr := env.validate(&comment).result(); appErr != nil {
+		return appErr
+	}
+
 	vars := mux.Vars(r)
 
 	if commentId, err = strconv.Atoi(vars["id"]); err != nil {
@@ -96,6 +96,10 @@ func (env *Env) CreateCompanyHandler(w http.ResponseWriter, r *http.Request) *Ap
 		}
 	}
 
+	if appErr := env.validate(&company).result(); appErr != nil {
+		return appErr
+	}
+
 	globals.Log.Debug("Decoded company : " + company.String())
 	globals.Log.Debug("Calling CreateCompany method")
 
@@ -148,6 +152,10 @@ func (env *Env) UpdateCompanyHandler(w http.ResponseWriter, r *http.Request) *Ap
 		}
 	}
 
+	if appErr := env.validate(&company).result(); appErr != nil {
+		return appErr
+	}
+
 	vars := mux.Vars(r)
 
 	if companyId, err = strconv.Atoi(vars["id"]); err != nil {
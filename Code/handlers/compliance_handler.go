@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// The working time rules a contract can set (see model.Contract).
+const (
+	RuleMaxDaily         = "max_daily"
+	RuleMaxWeekly        = "max_weekly"
+	RuleMaxAverageWeekly = "max_average_weekly"
+	RuleDailyRest        = "daily_rest"
+	RuleWeeklyRest       = "weekly_rest"
+	RuleNightWork        = "night_work"
+)
+
+// The night, from 21h to 6h in the zone of the user, and the number of weeks of the average weekly hours.
+const (
+	nightStartHour = 21
+	nightEndHour   = 6
+	averageWeeks   = 12
+)
+
+// violation : A working time rule broken by a user.
+/*	rule : The broken rule, like max_daily.
+	start : The start of the day, the week or the work the rule was broken during.
+	end : Its end.
+	minutes : The minutes worked, or the minutes of rest for the rest rules.
+	limit : The limit of the contract.
+*/
+type violation struct {
+	rule    string
+	start   time.Time
+	end     time.Time
+	minutes int64
+	limit   int64
+}
+
+//	GetComplianceHandler
+/*	The handler called by the following endpoint : GET /users/{id}/compliance?from=2021-03-01&to=2021-03-31
+	This method is used to check the schedules of a user against the working time rules of his contract : the hours
+	per day, per week and on average over 12 weeks, the rest between two days and during the week, and the night work.
+	Users can see their own compliance, and the users that can see the reports the ones of everybody.
+*/
+func (env *Env) GetComplianceHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err             error
+		userId          int
+		user            model.User
+		contract        model.Contract
+		location        *time.Location
+		schedules       model.Schedules
+		vacationProject model.Project
+		from, to        time.Time
+	)
+
+	globals.Log.Debug("Calling GetComplianceHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireReportAccess(r, int64(userId)); appErr != nil {
+		return appErr
+	}
+
+	if user, err = env.DB.GetUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if contract, err = env.DB.GetContract(user.ContractId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the contract of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if location, err = env.userLocation(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the time zone of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// Reading the days to check, in the zone of the user
+	query := r.URL.Query()
+
+	v := &validation{}
+	from = v.day("from", query.Get("from"), location)
+	to = v.day("to", query.Get("to"), location)
+	if !v.has("from") && !v.has("to") {
+		if to.Before(from) {
+			v.add("to", "must not be before from")
+		} else if globals.AddDays(from, maxReportDays, location).Before(to) {
+			v.add("to", "must be at most "+strconv.Itoa(maxReportDays)+" days after from")
+		}
+	}
+
+	if appErr := v.result(); appErr != nil {
+		return appErr
+	}
+
+	if schedules, err = env.schedulesOfUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the schedules",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if vacationProject, err = env.DB.GetVacationProject(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the vacation project",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	compliance := Compliance{
+		UserId:     int64(userId),
+		TimeZone:   location.String(),
+		From:       query.Get("from"),
+		To:         query.Get("to"),
+		Violations: []ComplianceViolation{},
+	}
+	for _, broken := range complianceViolations(schedules, vacationProject.ProjectId, contract, from, globals.AddDays(to, 1, location), location) {
+		compliance.Violations = append(compliance.Violations, violationToIntermediate(broken, int64(userId), location))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(compliance); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the compliance",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	complianceError(Schedules model.Schedules, UserIds []int64, Replaced ...int64) *AppError
+/*	Refuses new schedules that would make one of their users break a working time rule, when his contract blocks them.
+	The schedules are checked together, like the occurrences of a series, instead of the saved schedules they replace,
+	like the old version of a changed schedule. Only the rules broken because of them count : the ones already broken
+	are only reported. The vacations and the running timers are never refused.
+*/
+func (env *Env) complianceError(Schedules model.Schedules, UserIds []int64, Replaced ...int64) *AppError {
+	var (
+		err             error
+		vacationProject model.Project
+		violations      []ComplianceViolation
+	)
+
+	if vacationProject, err = env.DB.GetVacationProject(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the vacation project",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	worked := model.Schedules{}
+	for _, schedule := range Schedules {
+		if schedule.ProjectId != vacationProject.ProjectId && schedule.EndDate.Valid {
+			worked = append(worked, schedule)
+		}
+	}
+	if len(worked) == 0 {
+		return nil
+	}
+
+	for _, userId := range UserIds {
+		broken, err := env.newViolations(userId, worked, Replaced, vacationProject.ProjectId)
+		if err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when checking the working time rules",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+		violations = append(violations, broken...)
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &AppError{
+		Error:      errors.New("compliance violation"),
+		Message:    "The schedule breaks the working time rules of the contract of the user",
+		Code:       http.StatusConflict,
+		ErrorCode:  ErrorComplianceViolation,
+		Violations: violations,
+	}
+}
+
+//	newViolations(UserId int64, Schedules model.Schedules, Replaced []int64, VacationProjectId int64) ([]ComplianceViolation, error)
+/*	Returns the working time rules a user would break with new schedules instead of some of his saved ones,
+	and not without, if his contract blocks them. The weeks of the schedules are checked, and the ones whose average they change.
+*/
+func (env *Env) newViolations(UserId int64, Schedules model.Schedules, Replaced []int64, VacationProjectId int64) ([]ComplianceViolation, error) {
+	var (
+		err        error
+		user       model.User
+		contract   model.Contract
+		location   *time.Location
+		schedules  model.Schedules
+		violations []ComplianceViolation
+	)
+
+	if user, err = env.DB.GetUser(UserId); err != nil {
+		return nil, err
+	}
+	if contract, err = env.DB.GetContract(user.ContractId); err != nil || !contract.BlockViolations {
+		return nil, err
+	}
+	if location, err = env.userLocation(UserId); err != nil {
+		return nil, err
+	}
+	if schedules, err = env.schedulesOfUser(UserId); err != nil {
+		return nil, err
+	}
+
+	first, last := Schedules[0].StartDate.Time, Schedules[0].EndDate.Time
+	for _, schedule := range Schedules {
+		if schedule.StartDate.Time.Before(first) {
+			first = schedule.StartDate.Time
+		}
+		if schedule.EndDate.Time.After(last) {
+			last = schedule.EndDate.Time
+		}
+	}
+	start := globals.StartOfWeek(first, location)
+	end := globals.AddDays(globals.StartOfWeek(last, location), 7*averageWeeks, location)
+
+	before := map[string]bool{}
+	for _, broken := range complianceViolations(schedules, VacationProjectId, contract, start, end, location) {
+		before[broken.rule+broken.start.String()] = true
+	}
+
+	replaced := map[int64]bool{}
+	for _, scheduleId := range Replaced {
+		replaced[scheduleId] = true
+	}
+	withSchedules := append(model.Schedules{}, Schedules...)
+	for _, schedule := range schedules {
+		if !replaced[schedule.ScheduleId] {
+			withSchedules = append(withSchedules, schedule)
+		}
+	}
+	for _, broken := range complianceViolations(withSchedules, VacationProjectId, contract, start, end, location) {
+		if !before[broken.rule+broken.start.String()] {
+			violations = append(violations, violationToIntermediate(broken, UserId, location))
+		}
+	}
+
+	return violations, nil
+}
+
+//	complianceViolations(Schedules model.Schedules, VacationProjectId int64, Contract model.Contract, Start time.Time, End time.Time, Location *time.Location) []violation
+/*	Returns the working time rules of a contract broken by the schedules between two midnights of a zone.
+	The vacations and the breaks are not worked, and the rules of the contract set to 0 are not checked.
+	The days and the nights are the ones of the zone, and the weeks start on monday.
+*/
+func complianceViolations(Schedules model.Schedules, VacationProjectId int64, Contract model.Contract, Start time.Time, End time.Time, Location *time.Location) []violation {
+	violations := []violation{}
+	work := workIntervals(Schedules, VacationProjectId)
+
+	// The days and their nights
+	for day := Start; day.Before(End); day = globals.AddDays(day, 1, Location) {
+		next := globals.AddDays(day, 1, Location)
+		if minutes := workedMinutes(work, day, next); Contract.MaxDailyMinutes > 0 && minutes > Contract.MaxDailyMinutes {
+			violations = append(violations, violation{RuleMaxDaily, day, next, minutes, Contract.MaxDailyMinutes})
+		}
+
+		nightStart := time.Date(day.Year(), day.Month(), day.Day(), nightStartHour, 0, 0, 0, Location)
+		nightEnd := time.Date(next.Year(), next.Month(), next.Day(), nightEndHour, 0, 0, 0, Location)
+		minutes := workedMinutes(work, nightStart, nightEnd)
+		if Contract.NightWorkForbidden && minutes > 0 {
+			violations = append(violations, violation{RuleNightWork, nightStart, nightEnd, minutes, 0})
+		} else if Contract.MaxNightMinutes > 0 && minutes > Contract.MaxNightMinutes {
+			violations = append(violations, violation{RuleNightWork, nightStart, nightEnd, minutes, Contract.MaxNightMinutes})
+		}
+	}
+
+	// The rest between two days of work : the work separated by less than the rest is a single day, which can't
+	// last longer than the rest of the 24 hours
+	if Contract.MinDailyRestMinutes > 0 {
+		dailyRest := time.Duration(Contract.MinDailyRestMinutes) * time.Minute
+		for _, stretch := range stretches(work, dailyRest) {
+			rest := 24*time.Hour - stretch.end.Sub(stretch.start)
+			if rest < 0 {
+				rest = 0
+			}
+			if rest < dailyRest && stretch.end.After(Start) && stretch.start.Before(End) {
+				violations = append(violations, violation{RuleDailyRest, stretch.start, stretch.end, int64(rest.Minutes()), Contract.MinDailyRestMinutes})
+			}
+		}
+	}
+
+	// The weeks
+	busy := stretches(work, 0)
+	for week := globals.StartOfWeek(Start, Location); week.Before(End); week = globals.AddDays(week, 7, Location) {
+		next := globals.AddDays(week, 7, Location)
+		minutes := workedMinutes(work, week, next)
+
+		if Contract.MaxWeeklyMinutes > 0 && minutes > Contract.MaxWeeklyMinutes {
+			violations = append(violations, violation{RuleMaxWeekly, week, next, minutes, Contract.MaxWeeklyMinutes})
+		}
+
+		if Contract.MaxAverageWeeklyMinutes > 0 {
+			first := globals.AddDays(week, -7*(averageWeeks-1), Location)
+			if average := workedMinutes(work, first, next) / averageWeeks; average > Contract.MaxAverageWeeklyMinutes {
+				violations = append(violations, violation{RuleMaxAverageWeekly, first, next, average, Contract.MaxAverageWeeklyMinutes})
+			}
+		}
+
+		if Contract.MinWeeklyRestMinutes > 0 && minutes > 0 {
+			if rest := longestRest(busy, week, next); rest < Contract.MinWeeklyRestMinutes {
+				violations = append(violations, violation{RuleWeeklyRest, week, next, rest, Contract.MinWeeklyRestMinutes})
+			}
+		}
+	}
+
+	return violations
+}
+
+//	workedMinutes(Work []interval, Start time.Time, End time.Time) int64
+/*	Returns the minutes worked between two dates.
+ */
+func workedMinutes(Work []interval, Start time.Time, End time.Time) int64 {
+	var duration time.Duration
+	for _, hours := range Work {
+		duration += between(hours.start, hours.end, Start, End)
+	}
+	return int64(duration.Minutes())
+}
+
+//	stretches(Work []interval, Gap time.Duration) []interval
+/*	Returns the stretches of work : the hours worked separated by less than a gap are joined.
+ */
+func stretches(Work []interval, Gap time.Duration) []interval {
+	joined := []interval{}
+	for _, hours := range Work {
+		last := len(joined) - 1
+		if last >= 0 && hours.start.Sub(joined[last].end) < Gap {
+			if hours.end.After(joined[last].end) {
+				joined[last].end = hours.end
+			}
+			continue
+		}
+		joined = append(joined, hours)
+	}
+	return joined
+}
+
+//	longestRest(Busy []interval, Start time.Time, End time.Time) int64
+/*	Returns the minutes of the longest rest starting during a week, between two stretches of work : the rest from
+	saturday evening to monday morning counts for the week of the saturday. After the last work, the rest never ends.
+*/
+func longestRest(Busy []interval, Start time.Time, End time.Time) int64 {
+	var longest time.Duration
+	for i, hours := range Busy {
+		if hours.end.Before(Start) || !hours.end.Before(End) {
+			continue
+		}
+		if i == len(Busy)-1 {
+			return math.MaxInt64
+		}
+		if rest := Busy[i+1].start.Sub(hours.end); rest > longest {
+			longest = rest
+		}
+	}
+	return int64(longest.Minutes())
+}
+
+//	violationToIntermediate(Violation violation, UserId int64, Location *time.Location) ComplianceViolation
+/*	Returns a broken rule of a user, as sent to the clients.
+ */
+func violationToIntermediate(Violation violation, UserId int64, Location *time.Location) ComplianceViolation {
+	return ComplianceViolation{
+		UserId:       UserId,
+		Rule:         Violation.rule,
+		Start:        globals.FormatDate(Violation.start, Location),
+		End:          globals.FormatDate(Violation.end, Location),
+		Minutes:      Violation.minutes,
+		LimitMinutes: Violation.limit,
+	}
+}
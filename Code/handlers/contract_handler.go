@@ -134,6 +134,10 @@ func (env *Env) CreateContractHandler(w http.ResponseWriter, r *http.Request) *A
 		}
 	}
 
+	if appErr := env.validate(&contract).result(); appErr != nil {
+		return appErr
+	}
+
 	globals.Log.Debug("Decoded contract : " + contract.String())
 	globals.Log.Debug("Calling CreateContract method")
 
@@ -186,6 +190,10 @@ func (env *Env) UpdateContractHandler(w http.ResponseWriter, r *http.Request) *A
 		}
 	}
 
+	if appErr := env.validate(&contract).result(); appErr != nil {
+		return appErr
+	}
+
 	vars := mux.Vars(r)
 
 	if contractId, err = strconv.Atoi(vars["id"]); err != nil {
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+// The machine-readable codes of the errors. The clients should rely on them rather than on the messages.
+const (
+	ErrorBadRequest              = "bad_request"
+	ErrorUnauthorized            = "unauthorized"
+	ErrorForbidden               = "forbidden"
+	ErrorNotFound                = "not_found"
+	ErrorMethodNotAllowed        = "method_not_allowed"
+	ErrorConflict                = "conflict"
+	ErrorValidationFailed        = "validation_failed"
+	ErrorTooManyRequests         = "too_many_requests"
+	ErrorInternal                = "internal_error"
+	ErrorUnavailable             = "unavailable"
+	ErrorMissingToken            = "missing_token"
+	ErrorInvalidToken            = "invalid_token"
+	ErrorInvalidAPIToken         = "invalid_api_token"
+	ErrorInvalidCSRFToken        = "invalid_csrf_token"
+	ErrorTwoFactorPending        = "two_factor_pending"
+	ErrorPasswordChangeRequired  = "password_change_required"
+	ErrorTwoFactorSetupRequired  = "two_factor_setup_required"
+	ErrorTokenScope              = "token_scope"
+	ErrorImpersonationOver       = "impersonation_over"
+	ErrorProtectedItem           = "protected_item"
+	ErrorInvalidCredentials      = "invalid_credentials"
+	ErrorDelegatedSessionRefused = "delegated_session"
+	ErrorScheduleOverlap         = "schedule_overlap"
+	ErrorPeriodLocked            = "period_locked"
+	ErrorComplianceViolation     = "compliance_violation"
+)
+
+// The ids of the requests given by the clients are only kept if they look like ids.
+var requestIdRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+//	withRequestId(w http.ResponseWriter, r *http.Request) *http.Request
+/*	Gives an id to the request : the X-Request-Id header of the client, or a random one.
+	It is sent back in the X-Request-Id header and in the errors, and put in the logs, to find a request again.
+*/
+func withRequestId(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get("X-Request-Id")
+	if !requestIdRegex.MatchString(id) {
+		bytes := make([]byte, 16)
+		rand.Read(bytes)
+		id = hex.EncodeToString(bytes)
+	}
+
+	w.Header().Set("X-Request-Id", id)
+	return r.WithContext(context.WithValue(r.Context(), "RequestId", id))
+}
+
+//	requestId(r *http.Request) string
+/*	Returns the id given to the request by HeadersMiddleware.
+ */
+func requestId(r *http.Request) string {
+	id, _ := r.Context().Value("RequestId").(string)
+	return id
+}
+
+//	writeError(w http.ResponseWriter, r *http.Request, Status int, ErrorCode string, Message string, Details []ErrorDetail)
+/*	Writes an error in the JSON envelope used by every endpoint (see ErrorResponse).
+	The machine-readable code is deduced from the status when it is empty.
+*/
+func writeError(w http.ResponseWriter, r *http.Request, Status int, ErrorCode string, Message string, Details []ErrorDetail) {
+	writeErrorBody(w, r, ErrorBody{
+		Code:    ErrorCode,
+		Message: Message,
+		Status:  Status,
+		Details: Details,
+	})
+}
+
+//	writeErrorBody(w http.ResponseWriter, r *http.Request, Body ErrorBody)
+/*	Writes an error in the JSON envelope, with the id of the request.
+	The machine-readable code is deduced from the status when it is empty.
+*/
+func writeErrorBody(w http.ResponseWriter, r *http.Request, Body ErrorBody) {
+	if Body.Code == "" {
+		Body.Code = statusErrorCode(Body.Status)
+	}
+	Body.RequestId = requestId(r)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(Body.Status)
+
+	json.NewEncoder(w).Encode(ErrorResponse{Error: Body})
+}
+
+//	statusErrorCode(Status int) string
+/*	Returns the default machine-readable code of a status.
+ */
+func statusErrorCode(Status int) string {
+	switch Status {
+	case http.StatusBadRequest:
+		return ErrorBadRequest
+	case http.StatusUnauthorized:
+		return ErrorUnauthorized
+	case http.StatusForbidden:
+		return ErrorForbidden
+	case http.StatusNotFound:
+		return ErrorNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrorMethodNotAllowed
+	case http.StatusConflict:
+		return ErrorConflict
+	case http.StatusUnprocessableEntity:
+		return ErrorValidationFailed
+	case http.StatusTooManyRequests:
+		return ErrorTooManyRequests
+	case http.StatusServiceUnavailable:
+		return ErrorUnavailable
+	}
+
+	if Status >= 500 {
+		return ErrorInternal
+	}
+	return ErrorBadRequest
+}
+
+//	normalize()
+/*	Corrects the status of the errors the handlers did not expect : a missing row is a 404,
+	a constraint violation (like a mail that is already used) or a locked schedule a 409,
+	and an id or a body that can't be parsed a 400.
+*/
+func (e *AppError) normalize() {
+	if e.Code == 0 {
+		e.Code = http.StatusInternalServerError
+	}
+
+	if e.Code != http.StatusInternalServerError || e.Error == nil {
+		return
+	}
+
+	var (
+		numError       *strconv.NumError
+		syntaxError    *json.SyntaxError
+		unmarshalError *json.UnmarshalTypeError
+		lockedError    *datastores.LockedError
+	)
+
+	switch {
+	case errors.Is(e.Error, sql.ErrNoRows):
+		e.Code = http.StatusNotFound
+	case isConstraintViolation(e.Error):
+		e.Code = http.StatusConflict
+	case errors.As(e.Error, &lockedError):
+		e.Code = http.StatusConflict
+		e.ErrorCode = ErrorPeriodLocked
+		e.Message = "The schedule is in the approved timesheet " + lockedError.Period.Period + " of the user " + strconv.FormatInt(lockedError.Period.UserId, 10) + ", which must be reopened first"
+		if !lockedError.LockDate.IsZero() {
+			e.Message = "The schedule starts before the lock date " + globals.FormatDate(lockedError.LockDate, time.UTC) + ", the hours must be unlocked first"
+		}
+	case errors.As(e.Error, &numError), errors.As(e.Error, &syntaxError), errors.As(e.Error, &unmarshalError):
+		e.Code = http.StatusBadRequest
+	}
+}
+
+//	isConstraintViolation(err error) bool
+/*	Tells wether an error of the database is a violated constraint : unique, foreign key, not null or check.
+ */
+func isConstraintViolation(err error) bool {
+	var (
+		sqliteError sqlite3.Error
+		pqError     *pq.Error
+	)
+
+	if errors.As(err, &sqliteError) {
+		return sqliteError.Code == sqlite3.ErrConstraint
+	}
+
+	if errors.As(err, &pqError) {
+		return pqError.Code.Class() == "23"
+	}
+
+	return false
+}
+
+//	logError(r *http.Request, e *AppError)
+/*	Logs an error with the request it answers. The unexpected ones are logged as errors, with their cause.
+ */
+func logError(r *http.Request, e *AppError) {
+	fields := logrus.Fields{"request_id": requestId(r), "method": r.Method, "path": r.URL.Path, "status": e.Code}
+	if e.Error != nil {
+		fields["error"] = e.Error.Error()
+	}
+
+	if e.Code >= 500 {
+		globals.Log.WithFields(fields).Error(e.Message)
+	} else {
+		globals.Log.WithFields(fields).Debug(e.Message)
+	}
+}
+
+//	NotFoundHandler
+/*	The handler called when no route matches the path.
+ */
+func (env *Env) NotFoundHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	return &AppError{
+		Error:   errors.New("no route"),
+		Message: "This endpoint does not exist",
+		Code:    http.StatusNotFound,
+	}
+}
+
+//	MethodNotAllowedHandler
+/*	The handler called when a route matches the path, but not the method.
+ */
+func (env *Env) MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	return &AppError{
+		Error:   errors.New("wrong method"),
+		Message: "This method is not allowed on this endpoint",
+		Code:    http.StatusMethodNotAllowed,
+	}
+}
@@ -134,6 +134,10 @@ func (env *Env) CreateFunctionHandler(w http.ResponseWriter, r *http.Request) *A
 		}
 	}
 
+	if appErr := env.validate(&function).result(); appErr != nil {
+		return appErr
+	}
+
 	globals.Log.Debug("Decoded function : " + function.String())
 	globals.Log.Debug("Calling CreateFunction method")
 
@@ -186,6 +190,10 @@ func (env *Env) UpdateFunctionHandler(w http.ResponseWriter, r *http.Request) *A
 		}
 	}
 
+	if appErr := env.validate(&function).result(); appErr != nil {
+		return appErr
+	}
+
 	vars := mux.Vars(r)
 
 	if functionId, err = strconv.Atoi(vars["id"]); err != nil {
@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// HolidayKindPublic : The kind of the public holidays of the calendars, which are computed rather than saved.
+const HolidayKindPublic = "public"
+
+//	GetHolidayHandler
+/*	The handler called by the following endpoint : GET /holidays/{id}
+	This method is used to get a day off added to a company.
+*/
+func (env *Env) GetHolidayHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err     error
+		holiday model.Holiday
+	)
+
+	globals.Log.Debug("Calling GetHolidayHandler")
+
+	if holiday, err = env.holidayOfRequest(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the day off",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(holiday); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the day off",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	GetHolidaysOfCompanyHandler
+/*	The handler called by the following endpoint : GET /companies/{id}/holidays?year=2021
+	This method is used to get the days off of a company during a year, the current one by default : the public holidays
+	of its calendar, and the days off added to it.
+*/
+func (env *Env) GetHolidaysOfCompanyHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err       error
+		companyId int
+		company   model.Company
+		holidays  model.Holidays
+	)
+
+	globals.Log.Debug("Calling GetHolidaysOfCompanyHandler")
+
+	if companyId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	year, appErr := yearOfRequest(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	if company, err = env.DB.GetCompany(int64(companyId)); err == nil {
+		holidays, err = env.DB.GetHolidaysOfCompany(company.CompanyId)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the days off of the company",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return writeCalendar(w, calendarOf(model.Companies{company}, holidays, year))
+}
+
+//	GetHolidaysOfUserHandler
+/*	The handler called by the following endpoint : GET /users/{id}/holidays?year=2021, and GET /me/holidays
+	This method is used to get the days off of a user during a year, the current one by default : the ones of all his
+	companies, once per day.
+	Users can see their own days off, and the users that can see the reports the ones of everybody.
+*/
+func (env *Env) GetHolidaysOfUserHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		userId   int
+		calendar []CalendarDay
+	)
+
+	globals.Log.Debug("Calling GetHolidaysOfUserHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireReportAccess(r, int64(userId)); appErr != nil {
+		return appErr
+	}
+
+	year, appErr := yearOfRequest(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	if calendar, err = env.calendarOfUser(int64(userId), year, year); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the days off of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return writeCalendar(w, calendar)
+}
+
+//	CreateHolidayHandler
+/*	The handler called by the following endpoint : POST /holidays
+	This method is used to add a day off to a company, a holiday or a closure day, on which its users are not expected
+	to work. It needs the right to see the reports.
+*/
+func (env *Env) CreateHolidayHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err     error
+		holiday model.Holiday
+	)
+
+	globals.Log.Debug("Calling CreateHolidayHandler")
+
+	if appErr := env.requireManager(r, "Managing the days off of the companies"); appErr != nil {
+		return appErr
+	}
+
+	if err = json.NewDecoder(r.Body).Decode(&holiday); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+	holiday.HolidayId = 0
+	if holiday.Kind == "" {
+		holiday.Kind = model.HolidayKindHoliday
+	}
+
+	if appErr := env.validate(&holiday).result(); appErr != nil {
+		return appErr
+	}
+
+	if holiday.HolidayId, err = env.DB.CreateHoliday(holiday); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when creating the day off",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(holiday); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the day off",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	DeleteHolidayHandler
+/*	The handler called by the following endpoint : DELETE /holidays/{id}
+	This method is used to remove a day off of a company. It needs the right to see the reports.
+*/
+func (env *Env) DeleteHolidayHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err     error
+		holiday model.Holiday
+	)
+
+	globals.Log.Debug("Calling DeleteHolidayHandler")
+
+	if appErr := env.requireManager(r, "Managing the days off of the companies"); appErr != nil {
+		return appErr
+	}
+
+	if holiday, err = env.holidayOfRequest(r); err == nil {
+		err = env.DB.DeleteHoliday(holiday.HolidayId)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when deleting the day off",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+//	calendarOfUser(UserId int64, FirstYear int, LastYear int) ([]CalendarDay, error)
+/*	Returns the days off of a user between two years, included : the public holidays of the calendars of his companies
+	and their days off, once per day, the first one first.
+*/
+func (env *Env) calendarOfUser(UserId int64, FirstYear int, LastYear int) ([]CalendarDay, error) {
+	companies, err := env.DB.GetCompaniesOfUser(UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays, err := env.DB.GetHolidaysOfUser(UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := []CalendarDay{}
+	for year := FirstYear; year <= LastYear; year++ {
+		calendar = append(calendar, calendarOf(companies, holidays, year)...)
+	}
+	return calendar, nil
+}
+
+//	holidaysOfUser(UserId int64, Start time.Time, End time.Time, Location *time.Location) (map[string]string, error)
+/*	Returns the names of the days off of a user between two dates, keyed by day of a zone like 2021-05-13.
+ */
+func (env *Env) holidaysOfUser(UserId int64, Start time.Time, End time.Time, Location *time.Location) (map[string]string, error) {
+	calendar, err := env.calendarOfUser(UserId, Start.In(Location).Year(), End.In(Location).Year())
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := map[string]string{}
+	for _, day := range calendar {
+		holidays[day.Day] = day.Name
+	}
+	return holidays, nil
+}
+
+//	calendarOf(Companies model.Companies, Holidays model.Holidays, Year int) []CalendarDay
+/*	Returns the days off of some companies during a year, once per day, the first one first : the public holidays of
+	their calendars come before the days off added to them.
+*/
+func calendarOf(Companies model.Companies, Holidays model.Holidays, Year int) []CalendarDay {
+	days := map[string]CalendarDay{}
+
+	for _, company := range Companies {
+		for _, holiday := range globals.PublicHolidays(company.HolidayCalendar, Year) {
+			if _, found := days[holiday.Day]; !found {
+				days[holiday.Day] = CalendarDay{Day: holiday.Day, Name: holiday.Name, Kind: HolidayKindPublic, CompanyId: company.CompanyId}
+			}
+		}
+	}
+
+	prefix := strconv.Itoa(Year) + "-"
+	for _, holiday := range Holidays {
+		if _, found := days[holiday.Day]; !found && strings.HasPrefix(holiday.Day, prefix) {
+			days[holiday.Day] = CalendarDay{Day: holiday.Day, Name: holiday.Name, Kind: holiday.Kind, CompanyId: holiday.CompanyId, HolidayId: holiday.HolidayId}
+		}
+	}
+
+	calendar := []CalendarDay{}
+	for _, day := range days {
+		calendar = append(calendar, day)
+	}
+	sort.Slice(calendar, func(i, j int) bool { return calendar[i].Day < calendar[j].Day })
+
+	return calendar
+}
+
+//	holidayIntervals(Holidays map[string]string, Location *time.Location) []interval
+/*	Returns the days off as spans of time, from the midnight that starts them in a zone to the next one.
+ */
+func holidayIntervals(Holidays map[string]string, Location *time.Location) []interval {
+	intervals := []interval{}
+	for day := range Holidays {
+		if start, err := time.ParseInLocation(globals.DayLayout, day, Location); err == nil {
+			intervals = append(intervals, interval{start, globals.AddDays(start, 1, Location)})
+		}
+	}
+	return intervals
+}
+
+//	yearOfRequest(r *http.Request) (int, *AppError)
+/*	Returns the year of the request, like ?year=2021, or the current one.
+ */
+func yearOfRequest(r *http.Request) (int, *AppError) {
+	value := r.URL.Query().Get("year")
+	if value == "" {
+		return time.Now().Year(), nil
+	}
+
+	v := &validation{}
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 1900 || year > 2200 {
+		v.add("year", "must be a year like 2021")
+	}
+	return year, v.result()
+}
+
+//	holidayOfRequest(r *http.Request) (model.Holiday, error)
+/*	Returns the day off of the id of a route.
+ */
+func (env *Env) holidayOfRequest(r *http.Request) (model.Holiday, error) {
+	holidayId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return model.Holiday{}, err
+	}
+	return env.DB.GetHoliday(int64(holidayId))
+}
+
+//	writeCalendar(w http.ResponseWriter, Calendar []CalendarDay) *AppError
+/*	Answers with some days off.
+ */
+func writeCalendar(w http.ResponseWriter, Calendar []CalendarDay) *AppError {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(Calendar); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the days off",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
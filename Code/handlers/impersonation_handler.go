@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// How long an impersonation lasts, unless it is stopped before.
+const impersonationLifetime = 30 * time.Minute
+
+//	StartImpersonationHandler
+/*	The handler called by the following endpoint : POST /users/{id}/impersonate
+	This method is used by the users that can add and modify users to see the API as another user.
+	It gives a token with the permissions of the impersonated user, that also contains the real user.
+	The impersonation is recorded, and so is every request that modifies something during it.
+*/
+func (env *Env) StartImpersonationHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err           error
+		form          ImpersonationRequest
+		userId        int
+		currentUserId int64
+		currentRoleId int64
+		currentRole   model.Role
+		user          model.User
+		tokenString   string
+	)
+
+	globals.Log.Debug("StartImpersonationHandler called")
+
+	if appErr := forbidDelegatedSession(r); appErr != nil {
+		return appErr
+	}
+
+	// The reason is optional
+	if err = json.NewDecoder(r.Body).Decode(&form); err != nil && err != io.EOF {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if currentUserId, currentRoleId, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if currentRole, err = env.DB.GetRole(currentRoleId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the role",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if !currentRole.CanAddAndModifyUsers {
+		return &AppError{
+			Error:   errors.New("forbidden"),
+			Message: "Impersonating a user is forbidden",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if int64(userId) == currentUserId {
+		return &AppError{
+			Error:   errors.New("self impersonation"),
+			Message: "Users can't impersonate themselves",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if user, err = env.DB.GetUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Unexisting user",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	now := time.Now()
+	impersonation := model.Impersonation{
+		ImpersonatorId: currentUserId,
+		UserId:         user.UserId,
+		Reason:         form.Reason,
+		StartedAt:      now,
+		ExpiresAt:      now.Add(impersonationLifetime),
+	}
+
+	if impersonation.ImpersonationId, err = env.DB.CreateImpersonation(impersonation); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when recording the impersonation",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.WithFields(logrus.Fields{"impersonator": currentUserId, "user": user.UserId}).Warn("Impersonation started")
+
+	// The token of the impersonated user, with the real one
+	claims := jwt.MapClaims{
+		"mail":             user.Mail,
+		"user_id":          user.UserId,
+		"role_id":          user.RoleId,
+		"impersonator_id":  currentUserId,
+		"impersonation_id": impersonation.ImpersonationId,
+	}
+
+	if tokenString, err = issueSession(w, claims, impersonation.ExpiresAt); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when signing the token",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(ImpersonationStarted{
+		Impersonation: impersonation,
+		Token:         tokenString,
+	}); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the impersonation",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	StopImpersonationHandler
+/*	The handler called by the following endpoint : DELETE /impersonation
+	This method ends the current impersonation : its token is refused from now on,
+	and the real user gets his own token back.
+*/
+func (env *Env) StopImpersonationHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err             error
+		impersonationId int
+		impersonation   model.Impersonation
+		impersonator    model.User
+	)
+
+	globals.Log.Debug("StopImpersonationHandler called")
+
+	userData := r.Context().Value("UserData").(map[string]string)
+
+	if impersonationId, err = strconv.Atoi(userData["impersonation_id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "No impersonation in progress",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if impersonation, err = env.DB.GetImpersonation(int64(impersonationId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when getting the impersonation",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if err = env.DB.EndImpersonation(impersonation.ImpersonationId, time.Now()); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when ending the impersonation",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.WithFields(logrus.Fields{"impersonator": impersonation.ImpersonatorId, "user": impersonation.UserId}).Warn("Impersonation stopped")
+
+	if impersonator, err = env.DB.GetUser(impersonation.ImpersonatorId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Unexisting user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeSessionToken(w, impersonator)
+}
+
+//	GetImpersonationsHandler
+/*	The handler called by the following endpoint : GET /impersonations
+	This method is used to audit the impersonations. It needs the right to add and modify users.
+*/
+func (env *Env) GetImpersonationsHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err            error
+		impersonations model.Impersonations
+	)
+
+	globals.Log.Debug("GetImpersonationsHandler called")
+
+	if appErr := env.requireUserManagement(r); appErr != nil {
+		return appErr
+	}
+
+	if impersonations, err = env.DB.GetImpersonations(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when getting the impersonations",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(impersonations); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the impersonations",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	GetActionsOfImpersonationHandler
+/*	The handler called by the following endpoint : GET /impersonations/{id}/actions
+	This method is used to audit the requests that modified something during an impersonation.
+	It needs the right to add and modify users.
+*/
+func (env *Env) GetActionsOfImpersonationHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err             error
+		impersonationId int
+		actions         model.ImpersonationActions
+	)
+
+	globals.Log.Debug("GetActionsOfImpersonationHandler called")
+
+	if appErr := env.requireUserManagement(r); appErr != nil {
+		return appErr
+	}
+
+	if impersonationId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if actions, err = env.DB.GetActionsOfImpersonation(int64(impersonationId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when getting the actions",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(actions); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the actions",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	requireUserManagement(r *http.Request) *AppError
+/*	Refuses the request if the connected user can't add and modify users.
+ */
+func (env *Env) requireUserManagement(r *http.Request) *AppError {
+	var (
+		err         error
+		roleId      int64
+		currentRole model.Role
+	)
+
+	if _, roleId, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if currentRole, err = env.DB.GetRole(roleId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the role",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if !currentRole.CanAddAndModifyUsers {
+		return &AppError{
+			Error:   errors.New("forbidden"),
+			Message: "This needs the right to add and modify users",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	return nil
+}
+
+// statusRecorder : Keeps the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(Code int) {
+	recorder.status = Code
+	recorder.ResponseWriter.WriteHeader(Code)
+}
+
+//	serveImpersonation(w http.ResponseWriter, r *http.Request, h http.Handler, Claims jwt.MapClaims, Values map[string]string)
+/*	Serves a request made with an impersonation token. The token is refused once the impersonation is over.
+	The answers are flagged with the X-Impersonated-By header. The requests that may modify something are
+	recorded before being handled, so that they are attributable to the real user even if they fail.
+*/
+func (env *Env) serveImpersonation(w http.ResponseWriter, r *http.Request, h http.Handler, Claims jwt.MapClaims, Values map[string]string) {
+	var (
+		err           error
+		impersonation model.Impersonation
+		actionId      int64
+	)
+
+	impersonationId, _ := Claims["impersonation_id"].(float64)
+
+	if impersonation, err = env.DB.GetImpersonation(int64(impersonationId)); err != nil ||
+		impersonation.EndedAt != nil || !time.Now().Before(impersonation.ExpiresAt) ||
+		strconv.FormatInt(impersonation.UserId, 10) != Values["user_id"] {
+		globals.Log.Debug("The impersonation is over")
+		writeError(w, r, http.StatusUnauthorized, ErrorImpersonationOver, "The impersonation is over", nil)
+		return
+	}
+
+	Values["impersonation_id"] = strconv.FormatInt(impersonation.ImpersonationId, 10)
+	Values["impersonator_id"] = strconv.FormatInt(impersonation.ImpersonatorId, 10)
+
+	w.Header().Set("X-Impersonated-By", Values["impersonator_id"])
+	w.Header().Set("X-Impersonated-User", Values["user_id"])
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		h.ServeHTTP(w, r)
+		return
+	}
+
+	if actionId, err = env.DB.CreateImpersonationAction(model.ImpersonationAction{
+		ImpersonationId: impersonation.ImpersonationId,
+		ImpersonatorId:  impersonation.ImpersonatorId,
+		UserId:          impersonation.UserId,
+		Method:          r.Method,
+		Path:            r.URL.RequestURI(),
+		CreatedAt:       time.Now(),
+	}); err != nil {
+		globals.Log.WithFields(logrus.Fields{"error": err}).Error("Could not record the impersonation action")
+		writeError(w, r, http.StatusInternalServerError, "", "Error when recording the impersonation action", nil)
+		return
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	h.ServeHTTP(recorder, r)
+
+	if err = env.DB.UpdateImpersonationActionStatus(actionId, int64(recorder.status)); err != nil {
+		globals.Log.WithFields(logrus.Fields{"error": err}).Error("Could not record the answer of the impersonation action")
+	}
+}
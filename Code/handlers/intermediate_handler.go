@@ -108,12 +108,14 @@ func (env *Env) DeleteCompanyUserHandler(w http.ResponseWriter, r *http.Request)
 //	CreateUserScheduleHandler
 /*	The handler called by the following endpoint : POST /users/{user_id}/schedules/{schedule_id}
 	This method is used to create a new link between a user and a schedule.
+	It is refused when the schedule breaks the working time rules of the contract of the user, if the contract blocks them.
 */
 func (env *Env) CreateUserScheduleHandler(w http.ResponseWriter, r *http.Request) *AppError {
 	var (
 		err        error
 		userId     int
 		scheduleId int
+		schedule   model.Schedule
 	)
 
 	globals.Log.Debug("Calling CreateUserScheduleHandler")
@@ -136,10 +138,25 @@ func (env *Env) CreateUserScheduleHandler(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	if schedule, err = env.DB.GetSchedule(int64(scheduleId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the schedule",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.complianceError(model.Schedules{schedule}, []int64{int64(userId)}); appErr != nil {
+		return appErr
+	}
+
 	if err = env.DB.CreateUserSchedule(model.UserSchedule{
 		UserId:     int64(userId),
 		ScheduleId: int64(scheduleId),
 	}); err != nil {
+		if appErr := env.overlapError(r, err); appErr != nil {
+			return appErr
+		}
 		return &AppError{
 			Error:   err,
 			Code:    http.StatusInternalServerError,
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//	GetLockDateHandler
+/*	The handler called by the following endpoint : GET /locks/current
+	This method is used to get the current lock date of the company : the schedules and the vacations starting
+	before it can't change anymore. The lock date is empty if nothing is locked.
+*/
+func (env *Env) GetLockDateHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err  error
+		lock model.LockDate
+	)
+
+	globals.Log.Debug("Calling GetLockDateHandler")
+
+	if lock, err = env.DB.GetLockDate(); err != nil && err != sql.ErrNoRows {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the lock date",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	return writeLockDate(w, LockDateToIntermediate(lock, location))
+}
+
+//	GetLockDatesHandler
+/*	The handler called by the following endpoint : GET /locks
+	This method is used to get the history of the lock date, the last change first, with the author and the reason
+	of every lock and unlock. It needs the right to see the reports.
+*/
+func (env *Env) GetLockDatesHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err   error
+		locks model.LockDates
+	)
+
+	globals.Log.Debug("Calling GetLockDatesHandler")
+
+	if appErr := env.requireManager(r, "Managing the lock date"); appErr != nil {
+		return appErr
+	}
+
+	if locks, err = env.DB.GetLockDates(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the lock dates",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	intermediates := []LockDateIntermediate{}
+	for _, lock := range locks {
+		intermediates = append(intermediates, LockDateToIntermediate(lock, location))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(intermediates); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the lock dates",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	LockHandler
+/*	The handler called by the following endpoint : POST /locks
+	This method is used to move the lock date of the company forward, once the payroll of the months before it is closed.
+	The lock date is a day, starting at midnight in the zone of the manager. It needs the right to see the reports.
+*/
+func (env *Env) LockHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	globals.Log.Debug("Calling LockHandler")
+
+	return env.changeLockDate(w, r, true)
+}
+
+//	UnlockHandler
+/*	The handler called by the following endpoint : POST /locks/unlock
+	This method is used to move the lock date of the company back to an earlier day, or to remove it with an empty date,
+	so the hours after it can be corrected. The reason is required, and kept in the history.
+	It needs the right to see the reports.
+*/
+func (env *Env) UnlockHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	globals.Log.Debug("Calling UnlockHandler")
+
+	return env.changeLockDate(w, r, false)
+}
+
+//	changeLockDate(w http.ResponseWriter, r *http.Request, Later bool) *AppError
+/*	Saves the lock date of the request, which must be after the current one to lock, or before it to unlock,
+	and answers with the new lock date.
+*/
+func (env *Env) changeLockDate(w http.ResponseWriter, r *http.Request, Later bool) *AppError {
+	var (
+		err           error
+		currentUserId int64
+		form          LockRequest
+		lock          model.LockDate
+		location      *time.Location
+	)
+
+	if appErr := env.requireManager(r, "Managing the lock date"); appErr != nil {
+		return appErr
+	}
+
+	if currentUserId, _, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	if err = json.NewDecoder(r.Body).Decode(&form); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	v := env.validate(&form)
+	if Later || form.LockDate != "" {
+		if day := v.day("lock_date", form.LockDate, location); !v.has("lock_date") {
+			lock.LockDate = sql.NullTime{Valid: true, Time: day}
+		}
+	}
+	if !Later && !v.has("reason") && form.Reason == "" {
+		v.add("reason", "is required to unlock the hours")
+	}
+	if appErr := v.result(); appErr != nil {
+		return appErr
+	}
+
+	lock.AuthorId = currentUserId
+	lock.Reason = form.Reason
+
+	if Later {
+		lock, err = env.DB.Lock(lock)
+	} else {
+		lock, err = env.DB.Unlock(lock)
+	}
+	if err == datastores.ErrLockDate {
+		message := "The lock date must be after the current one, unlock the hours first to move it back"
+		if !Later {
+			message = "The lock date must be before the current one"
+			if !lock.LockDate.Valid {
+				message = "Nothing is locked"
+			}
+		}
+		return &AppError{
+			Error:   err,
+			Message: message,
+			Code:    http.StatusConflict,
+		}
+	} else if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when changing the lock date",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return writeLockDate(w, LockDateToIntermediate(lock, location))
+}
+
+//	writeLockDate(w http.ResponseWriter, Lock LockDateIntermediate) *AppError
+/*	Answers with a lock date.
+ */
+func writeLockDate(w http.ResponseWriter, Lock LockDateIntermediate) *AppError {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(Lock); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the lock date",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gorilla/mux"
@@ -15,9 +16,27 @@ import (
 
 func (env *Env) HeadersMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		w.Header().Set("Access-Control-Allow-Methods", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Accept")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
+		req = withRequestId(w, req)
+
+		origin := req.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+
+		// Only the configured origins can read the answers (see globals.CORSRules)
+		if allowed, credentials := globals.CORS.AllowedOrigin(origin); allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(globals.CORS.ExposedHeaders, ", "))
+
+			// Preflight request
+			if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(corsMethods, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(globals.CORS.AllowedHeaders, ", "))
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(globals.CORS.MaxAge))
+			}
+		}
+
 		h.ServeHTTP(w, req)
 	})
 }
@@ -25,7 +44,16 @@ func (env *Env) HeadersMiddleware(h http.Handler) http.Handler {
 func (env *Env) AppMiddleware(h AppHandlerFunc) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if e := h(w, r); e != nil {
-			http.Error(w, e.Message, e.Code)
+			e.normalize()
+			logError(r, e)
+			writeErrorBody(w, r, ErrorBody{
+				Code:       e.ErrorCode,
+				Message:    e.Message,
+				Status:     e.Code,
+				Details:    e.Details,
+				Conflicts:  e.Conflicts,
+				Violations: e.Violations,
+			})
 		}
 	})
 }
@@ -47,20 +75,34 @@ func (env *Env) AuthenticateMiddleware(h http.Handler) http.Handler {
 			token           *jwt.Token
 		)
 
+		// The API tokens are given in the Authorization header, instead of the cookie
+		if bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); strings.HasPrefix(bearer, globals.AccessTokenPrefix) {
+			values, err := env.accessTokenUserData(bearer, time.Now())
+			if err != nil {
+				globals.Log.Debug("Invalid API token")
+				writeError(w, r, http.StatusUnauthorized, ErrorInvalidAPIToken, "Invalid API token", nil)
+				return
+			}
+
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "UserData", values)))
+			return
+		}
+
 		// Token regex
 		tokenRegex := regexp.MustCompile("token=.+")
 
 		// Extracting the token from the header
 		if requestToken, err = r.Cookie("token"); err != nil {
 			globals.Log.Debug("Token was not found in cookies")
-			http.Error(w, "Token not found in cookies", http.StatusUnauthorized)
+			writeError(w, r, http.StatusUnauthorized, ErrorMissingToken, "Token not found in cookies", nil)
 			return
 		}
 
 		// Verifying the token
 		if !tokenRegex.MatchString(requestToken.String()) {
 			globals.Log.Debug("The token has an invalid format")
-			http.Error(w, "Token has an invalid format", http.StatusUnauthorized)
+			writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "Token has an invalid format", nil)
+			return
 		}
 
 		splitToken := strings.Split(requestToken.String(), "token=")
@@ -68,16 +110,37 @@ func (env *Env) AuthenticateMiddleware(h http.Handler) http.Handler {
 		if token, err = jwt.Parse(requestTokenStr, func(token *jwt.Token) (interface{}, error) {
 			return globals.TokenSignKey, nil
 		}); err != nil {
-			http.Error(w, "Token signature is invalid", http.StatusUnauthorized)
+			writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "Token is invalid or expired", nil)
 			return
 		}
 
 		// Extracting the claims
 		if claims, ok = token.Claims.(jwt.MapClaims); ok && token.Valid {
+			// A token waiting for the two-factor code can't be used
+			if pending, _ := claims["two_factor_pending"].(bool); pending {
+				globals.Log.Debug("The token is waiting for the two-factor code")
+				writeError(w, r, http.StatusUnauthorized, ErrorTwoFactorPending, "The two-factor code is missing", nil)
+				return
+			}
+
+			// The tokens without expiration are refused : the expiration is verified by jwt.Parse
+			if _, ok = claims["exp"]; !ok {
+				globals.Log.Debug("The token has no expiration")
+				writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "Token has no expiration", nil)
+				return
+			}
+
+			// The cookie is sent by the browser whatever the site making the request : the CSRF token proves it is ours
+			if !validCSRFToken(r, claims) {
+				globals.Log.Debug("Invalid CSRF token")
+				writeError(w, r, http.StatusForbidden, ErrorInvalidCSRFToken, "Invalid CSRF token", nil)
+				return
+			}
+
 			// Claim the email
 			if claimMail, ok = claims["mail"]; !ok {
 				globals.Log.Debug("Did not find Mail in claims")
-				http.Error(w, "Mail not found in claims", http.StatusBadRequest)
+				writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "Mail not found in claims", nil)
 				return
 			}
 			userMail = claimMail.(string)
@@ -85,7 +148,7 @@ func (env *Env) AuthenticateMiddleware(h http.Handler) http.Handler {
 			// Claim the id
 			if claimId, ok = claims["user_id"]; !ok {
 				globals.Log.Debug("Did not find UserId in claims")
-				http.Error(w, "UserId not found in claims", http.StatusBadRequest)
+				writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "UserId not found in claims", nil)
 				return
 			}
 			userId = strconv.FormatFloat(claimId.(float64), 'f', 0, 64)
@@ -93,14 +156,14 @@ func (env *Env) AuthenticateMiddleware(h http.Handler) http.Handler {
 			// Claim the role id
 			if claimRoleId, ok = claims["role_id"]; !ok {
 				globals.Log.Debug("Did not find RoleId in claims")
-				http.Error(w, "RoleId not found in claims", http.StatusBadRequest)
+				writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "RoleId not found in claims", nil)
 				return
 			}
 			roleId = strconv.FormatFloat(claimRoleId.(float64), 'f', 0, 64)
 
 		} else {
 			globals.Log.Debug("Can not extract claims")
-			http.Error(w, "Could not extract claims", http.StatusBadRequest)
+			writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "Could not extract claims", nil)
 			return
 		}
 
@@ -113,10 +176,140 @@ func (env *Env) AuthenticateMiddleware(h http.Handler) http.Handler {
 
 		ctx := context.WithValue(r.Context(), "UserData", values)
 
+		// The impersonation tokens are checked and audited
+		if _, impersonating := claims["impersonation_id"]; impersonating {
+			env.serveImpersonation(w, r.WithContext(ctx), h, claims, values)
+			return
+		}
+
 		h.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+func (env *Env) AccountSetupMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			err       error
+			userId    int64
+			user      model.User
+			role      model.Role
+			twoFactor model.TwoFactor
+		)
+
+		// Extracting data from the context
+		if userId, _, err = contextUser(r); err != nil {
+			globals.Log.Debug("Could not convert UserId from string to int")
+			writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "Atoi conversion error", nil)
+			return
+		}
+
+		if user, err = env.DB.GetUser(userId); err != nil {
+			globals.Log.Debug("Could not retrieve the user")
+			writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "The user of the token does not exist anymore", nil)
+			return
+		}
+
+		// A user with a temporary password can only change it
+		if user.MustChangePassword {
+			globals.Log.Debug("Current user has to change his password")
+			writeError(w, r, http.StatusForbidden, ErrorPasswordChangeRequired, "The password must be changed before using the API", nil)
+			return
+		}
+
+		// Some roles need the two-factor authentication
+		if role, err = env.DB.GetRole(user.RoleId); err != nil {
+			globals.Log.Debug("Could not retrieved role information")
+			writeError(w, r, http.StatusInternalServerError, "", "GetRole error", nil)
+			return
+		}
+
+		if role.RequireTwoFactor {
+			if twoFactor, err = env.DB.GetTwoFactor(userId); err != nil || !twoFactor.Enabled {
+				globals.Log.Debug("Current user has to enable the two-factor authentication")
+				writeError(w, r, http.StatusForbidden, ErrorTwoFactorSetupRequired, "The two-factor authentication must be enabled before using the API", nil)
+				return
+			}
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+//	MeMiddleware(h http.Handler) http.Handler
+/*	Turns the routes of /me into the ones of /users/{id}, with the id of the connected user put in the context
+	by AuthenticateMiddleware. The handlers and the rights are then the same as for the id of the user.
+*/
+func (env *Env) MeMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userData := r.Context().Value("UserData").(map[string]string)
+
+		vars := map[string]string{}
+		for name, value := range mux.Vars(r) {
+			vars[name] = value
+		}
+		vars["item"] = "users"
+		vars["id"] = userData["user_id"]
+
+		h.ServeHTTP(w, mux.SetURLVars(r, vars))
+	})
+}
+
+//	contextUser(r *http.Request) (int64, int64, error)
+/*	Returns the ids of the connected user and of his role, put in the context by AuthenticateMiddleware.
+ */
+func contextUser(r *http.Request) (int64, int64, error) {
+	var (
+		err    error
+		userId int
+		roleId int
+	)
+
+	userData := r.Context().Value("UserData").(map[string]string)
+
+	if userId, err = strconv.Atoi(userData["user_id"]); err != nil {
+		return 0, 0, err
+	}
+
+	if roleId, err = strconv.Atoi(userData["role_id"]); err != nil {
+		return 0, 0, err
+	}
+
+	return int64(userId), int64(roleId), nil
+}
+
+// The items of the scopes of the API tokens that guard the goals of the routes which are not items themselves.
+var scopeItemOfGoal = map[string]string{
+	"balance":    "timesheets",
+	"compliance": "timesheets",
+	"report":     "timesheets",
+	"overlaps":   "schedules",
+	"timer":      "schedules",
+	"contract":   "contracts",
+	"role":       "roles",
+}
+
+//	scopeItem(Vars map[string]string) string
+/*	Returns the item of the scope of the API tokens a route needs : the item it links, like the schedules of
+	/users/{id}/schedules/{other_id}, the one it lists, like the schedules of /users/{id}/schedules and of /me/schedules,
+	or else its first part.
+*/
+func scopeItem(Vars map[string]string) string {
+	if Vars["other_item"] != "" {
+		return Vars["other_item"]
+	}
+
+	if item, found := scopeItemOfGoal[Vars["goal"]]; found {
+		return item
+	}
+	for _, item := range globals.AccessTokenItems {
+		if Vars["goal"] == item {
+			return item
+		}
+	}
+
+	return Vars["item"]
+}
+
 func (env *Env) AuthorizeMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var (
@@ -135,14 +328,14 @@ func (env *Env) AuthorizeMiddleware(h http.Handler) http.Handler {
 		// Extracting context data
 		if roleId, err = strconv.Atoi(userData["role_id"]); err != nil {
 			globals.Log.Debug("Could not convert RoleId from string to int")
-			http.Error(w, "Atoi conversion error", http.StatusBadRequest)
+			writeError(w, r, http.StatusUnauthorized, ErrorInvalidToken, "Atoi conversion error", nil)
 			return
 		}
 
 		// Getting permissions from database
 		if userRole, err = env.DB.GetRole(int64(roleId)); err != nil {
 			globals.Log.Debug("Could not retrieved role information")
-			http.Error(w, "GetRole error", http.StatusBadRequest)
+			writeError(w, r, http.StatusInternalServerError, "", "GetRole error", nil)
 			return
 		}
 
@@ -155,6 +348,13 @@ func (env *Env) AuthorizeMiddleware(h http.Handler) http.Handler {
 		}
 		goal = vars["goal"]
 
+		// An API token can be limited to some items, or to reading
+		if userData["access_token_id"] != "" && !globals.AccessTokenScopeAllows(userData["scope"], scopeItem(vars), r.Method) {
+			globals.Log.Debug("The scope of the API token does not allow the request")
+			writeError(w, r, http.StatusForbidden, ErrorTokenScope, "The scope of the API token does not allow this request", nil)
+			return
+		}
+
 		// And now, checking for authorizations
 		switch r.Method {
 		case "DELETE":
@@ -163,28 +363,28 @@ func (env *Env) AuthorizeMiddleware(h http.Handler) http.Handler {
 				// Can't Delete a user if you don't have the right to
 				if !userRole.CanAddAndModifyUsers {
 					globals.Log.Debug("Current user can't add or modify users")
-					http.Error(w, "Deleting a user is forbidden", http.StatusForbidden)
+					writeError(w, r, http.StatusForbidden, "", "Deleting a user is forbidden", nil)
 					return
 				}
 			case "projects":
 				// Can't delete the vacation project
 				if itemId == 1 {
 					globals.Log.Debug("Can't Delete the vacation project")
-					http.Error(w, "Can't delete the vacation project", http.StatusUnauthorized)
+					writeError(w, r, http.StatusForbidden, ErrorProtectedItem, "Can't delete the vacation project", nil)
 					return
 				}
 
 				// Can't delete a project if you don't have the right to add one
 				if !userRole.CanAddProjects {
 					globals.Log.Debug("Current user can't delete project")
-					http.Error(w, "Deleting a project is forbidden", http.StatusForbidden)
+					writeError(w, r, http.StatusForbidden, "", "Deleting a project is forbidden", nil)
 					return
 				}
 			case "roles":
 				// Can't delete one of the basic roles
 				if itemId == 1 || itemId == 2 || itemId == 3 {
 					globals.Log.Debug("Can't delete one of the basic roles")
-					http.Error(w, "Can't delete one of the basic roles", http.StatusUnauthorized)
+					writeError(w, r, http.StatusForbidden, ErrorProtectedItem, "Can't delete one of the basic roles", nil)
 					return
 				}
 			}
@@ -194,42 +394,46 @@ func (env *Env) AuthorizeMiddleware(h http.Handler) http.Handler {
 				// Can't Modify a user if you don't have the right to
 				if !userRole.CanAddAndModifyUsers {
 					globals.Log.Debug("Current user can't add or modify users")
-					http.Error(w, "Updating a user is forbidden", http.StatusForbidden)
+					writeError(w, r, http.StatusForbidden, "", "Updating a user is forbidden", nil)
 					return
 				}
 			case "projects":
 				// Can't modify the vacation project
 				if itemId == 1 {
 					globals.Log.Debug("Can't Modify the vacation project")
-					http.Error(w, "Can't Modify the vacation project", http.StatusUnauthorized)
+					writeError(w, r, http.StatusForbidden, ErrorProtectedItem, "Can't modify the vacation project", nil)
 					return
 				}
 
 			}
-		case "PUT":
+		case "POST", "PUT":
+			// Only the creations are checked here : the other actions on an item check their own rights
+			_, hasId := vars["id"]
+
 			switch item {
 			case "users":
 				// Can't Add a user if you don't have the right to
-				if !userRole.CanAddAndModifyUsers {
+				if !hasId && !userRole.CanAddAndModifyUsers {
 					globals.Log.Debug("Current user can't add or modify users")
-					http.Error(w, "Creating a user is forbidden", http.StatusForbidden)
+					writeError(w, r, http.StatusForbidden, "", "Creating a user is forbidden", nil)
 					return
 				}
 			case "projects":
 				// Can't add a project if you don't have the right to
-				if !userRole.CanAddProjects {
+				if !hasId && !userRole.CanAddProjects {
 					globals.Log.Debug("Current user can't add new projects")
-					http.Error(w, "Creating a new project is forbidden", http.StatusForbidden)
+					writeError(w, r, http.StatusForbidden, "", "Creating a new project is forbidden", nil)
 					return
 				}
 			}
 		case "GET":
 			switch item {
 			case "users":
-				// Can't see other scedules if you don't have the right
-				if goal == "schedules" && !userRole.CanSeeOtherSchedules {
+				// Can't see other scedules (or their overlaps and series) if you don't have the right, but everybody can see his own
+				if (goal == "schedules" || goal == "overlaps" || goal == "series") && vars["id"] != userData["user_id"] && !userRole.CanSeeOtherSchedules {
 					globals.Log.Debug("Current user can't see other schedules")
-					http.Error(w, "Getting other schedules is forbidden", http.StatusForbidden)
+					writeError(w, r, http.StatusForbidden, "", "Getting other schedules is forbidden", nil)
+					return
 				}
 			}
 		}
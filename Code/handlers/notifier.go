@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"github.com/sirupsen/logrus"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// Notifier : Sends a message to a user, for instance when something happens to his account.
+type Notifier interface {
+	Notify(User model.User, Subject string, Message string) error
+}
+
+// LogNotifier : A Notifier that only writes the messages in the logs.
+// It is used when no other Notifier is configured.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(User model.User, Subject string, Message string) error {
+	globals.Log.WithFields(logrus.Fields{"mail": User.Mail, "subject": Subject}).Info(Message)
+	return nil
+}
+
+//	notify(User model.User, Subject string, Message string)
+/*	Sends a message to a user with the configured Notifier.
+	A failure is only logged, as it should not prevent the request from succeeding.
+*/
+func (env *Env) notify(User model.User, Subject string, Message string) {
+	var notifier Notifier = LogNotifier{}
+	if env.Notifier != nil {
+		notifier = env.Notifier
+	}
+
+	if err := notifier.Notify(User, Subject, Message); err != nil {
+		globals.Log.WithFields(logrus.Fields{"mail": User.Mail, "error": err}).Error("Could not notify the user")
+	}
+}
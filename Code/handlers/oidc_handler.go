@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+// The cookie that keeps the state, the nonce and the PKCE verifier of a login with an identity provider.
+const oidcCookieName = "oidc_login"
+
+// How long the user has to log in at the identity provider.
+const oidcLoginLifetime = 10 * time.Minute
+
+// The message returned for every failed login with an identity provider.
+const oidcFailedMessage = "The login with the identity provider failed"
+
+//	OIDCLoginHandler
+/*	The handler called by the following endpoint : GET /oidc/{provider}/login
+	This method starts a login with an OpenID Connect identity provider : it redirects the user to the provider.
+	The state, the nonce and the PKCE verifier are kept in a signed cookie until the callback.
+*/
+func (env *Env) OIDCLoginHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err         error
+		state       string
+		nonce       string
+		verifier    string
+		authURL     string
+		cookieValue string
+	)
+
+	globals.Log.Debug("OIDCLoginHandler called")
+
+	name := mux.Vars(r)["provider"]
+	provider, ok := env.OIDCProviders[name]
+	if !ok {
+		return &AppError{
+			Error:   errors.New("unknown identity provider " + name),
+			Message: "Unknown identity provider",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	for _, value := range []*string{&state, &nonce, &verifier} {
+		if *value, err = authentication.RandomURLToken(); err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when generating the login",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+	}
+
+	if authURL, err = provider.AuthCodeURL(state, nonce, verifier); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "The identity provider is unavailable, try again later",
+			Code:    http.StatusServiceUnavailable,
+		}
+	}
+
+	token := jwt.New(jwt.SigningMethodHS256)
+
+	claims := token.Claims.(jwt.MapClaims)
+	claims["oidc_provider"] = name
+	claims["oidc_state"] = state
+	claims["oidc_nonce"] = nonce
+	claims["oidc_verifier"] = verifier
+	claims["exp"] = time.Now().Add(oidcLoginLifetime).Unix()
+
+	if cookieValue, err = token.SignedString(globals.TokenSignKey); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when signing the login",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// Lax, so that the cookie comes back with the redirection of the provider
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    cookieValue,
+		Path:     "/oidc/" + name,
+		MaxAge:   int(oidcLoginLifetime.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+
+	return nil
+}
+
+//	OIDCCallbackHandler
+/*	The handler called by the following endpoint : GET /oidc/{provider}/callback
+	The identity provider redirects the user here with a code, that is exchanged for an ID token.
+	The verified mail of the token is mapped to the user of this provider with the same mail,
+	who is created if the provisioning is enabled. Then the token is given like with POST /get-token.
+*/
+func (env *Env) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err           error
+		cookie        *http.Cookie
+		token         *jwt.Token
+		identity      authentication.Identity
+		authenticated bool
+	)
+
+	globals.Log.Debug("OIDCCallbackHandler called")
+
+	name := mux.Vars(r)["provider"]
+	provider, ok := env.OIDCProviders[name]
+	if !ok {
+		return &AppError{
+			Error:   errors.New("unknown identity provider " + name),
+			Message: "Unknown identity provider",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		return &AppError{
+			Error:   errors.New("identity provider error " + query.Get("error")),
+			Message: oidcFailedMessage,
+			Code:    http.StatusUnauthorized,
+		}
+	}
+
+	// The login must have been started by this browser
+	if cookie, err = r.Cookie(oidcCookieName); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "No login in progress",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if token, err = jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return globals.TokenSignKey, nil
+	}); err != nil || !token.Valid {
+		return &AppError{
+			Error:   err,
+			Message: "No login in progress",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	cookieProvider, _ := claims["oidc_provider"].(string)
+	state, _ := claims["oidc_state"].(string)
+	nonce, _ := claims["oidc_nonce"].(string)
+	verifier, _ := claims["oidc_verifier"].(string)
+
+	if cookieProvider != name || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(query.Get("state"))) != 1 {
+		return &AppError{
+			Error:   errors.New("wrong state"),
+			Message: oidcFailedMessage,
+			Code:    http.StatusUnauthorized,
+		}
+	}
+
+	// The login can only be completed once
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Value:    "",
+		Path:     "/oidc/" + name,
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	if identity, err = provider.Exchange(query.Get("code"), verifier, nonce); err != nil {
+		if _, invalid := err.(*authentication.InvalidTokenError); invalid {
+			globals.Log.WithFields(logrus.Fields{"provider": name, "error": err}).Warn("OIDC login refused")
+			return &AppError{
+				Error:   err,
+				Message: oidcFailedMessage,
+				Code:    http.StatusUnauthorized,
+			}
+		}
+
+		globals.Log.WithFields(logrus.Fields{"provider": name, "error": err}).Error("Identity provider error")
+		return &AppError{
+			Error:   err,
+			Message: "The identity provider is unavailable, try again later",
+			Code:    http.StatusServiceUnavailable,
+		}
+	}
+
+	user, _, authenticated, err := env.externalUser(name, provider.Config.Provisioning, identity)
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when getting the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if !authenticated {
+		globals.Log.WithFields(logrus.Fields{"provider": name, "mail": identity.Mail}).Warn("No user for this identity")
+		return &AppError{
+			Error:   errors.New("no user for " + identity.Mail),
+			Message: oidcFailedMessage,
+			Code:    http.StatusUnauthorized,
+		}
+	}
+
+	return env.completeLogin(w, user)
+}
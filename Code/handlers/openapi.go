@@ -0,0 +1,436 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+// The variables of the router that only choose an item, like {item:users} : they are written as their value.
+var routeItemRegex = regexp.MustCompile(`\{(item|goal|other_item):([^}]+)\}`)
+
+// The other variables of the router with a pattern, like {period:[0-9]+-W?[0-9]+} : they are written without it.
+var routePatternRegex = regexp.MustCompile(`\{(\w+):[^}]+\}`)
+
+// The page that shows the specification, with ReDoc.
+const openAPIDocumentationPage = `<!DOCTYPE html>
+<html>
+<head>
+	<meta charset="utf-8">
+	<title>Gestion TPS API</title>
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+	<redoc spec-url="openapi.json"></redoc>
+	<script src="https://cdn.redoc.ly/redoc/latest/bundles/redoc.standalone.js"></script>
+</body>
+</html>
+`
+
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Servers    []OpenAPIServer                         `json:"servers"`
+	Security   []map[string][]string                   `json:"security"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type OpenAPIServer struct {
+	URL string `json:"url"`
+}
+
+type OpenAPIOperation struct {
+	Summary     string                      `json:"summary"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+	Security    *[]map[string][]string      `json:"security,omitempty"`
+}
+
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required"`
+	Schema      *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPISchema struct {
+	Ref        string                    `json:"$ref,omitempty"`
+	Type       string                    `json:"type,omitempty"`
+	Format     string                    `json:"format,omitempty"`
+	Nullable   bool                      `json:"nullable,omitempty"`
+	Properties map[string]*OpenAPISchema `json:"properties,omitempty"`
+	Required   []string                  `json:"required,omitempty"`
+	Items      *OpenAPISchema            `json:"items,omitempty"`
+	Enum       []string                  `json:"enum,omitempty"`
+	Minimum    *int64                    `json:"minimum,omitempty"`
+	MaxLength  *int64                    `json:"maxLength,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes"`
+}
+
+type OpenAPISecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+//	OpenAPIPath(PathTemplate string) string
+/*	Returns the OpenAPI path of a route of the router : /{item:users}/{id}/{goal:report} is /users/{id}/report,
+	and /{item:users}/{id}/{goal:timesheets}/{period:[0-9]+-W?[0-9]+} is /users/{id}/timesheets/{period}.
+*/
+func OpenAPIPath(PathTemplate string) string {
+	return routePatternRegex.ReplaceAllString(routeItemRegex.ReplaceAllString(PathTemplate, "$2"), "{$1}")
+}
+
+//	OpenAPISpec(Router *mux.Router, Prefix string) (OpenAPIDocument, []string)
+/*	Builds the OpenAPI 3 specification of the routes of the router of a version, with the descriptions of apiOperations.
+	The paths are written without the prefix of the version, which is the URL of the server.
+	The schemas are read from the types of the requests and the answers. Also returns the routes that have no description,
+	which are left out of the specification.
+*/
+func OpenAPISpec(Router *mux.Router, Prefix string) (OpenAPIDocument, []string) {
+	var undocumented []string
+
+	builder := &openAPIBuilder{schemas: map[string]*OpenAPISchema{}}
+	spec := OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title: "Gestion TPS API",
+			Description: "The API of the time tracking of the projects. The requests are authenticated by the token cookie given by POST /get-token, " +
+				"whose changes need the X-CSRF-Token header, or by an API token in the Authorization header. " +
+				"The errors are returned in a JSON envelope.",
+			Version: "1.0.0",
+		},
+		Servers:  []OpenAPIServer{{URL: Prefix}},
+		Security: []map[string][]string{{"cookieAuth": {}}, {"bearerAuth": {}}},
+		Paths:    map[string]map[string]*OpenAPIOperation{},
+		Components: OpenAPIComponents{
+			Schemas: builder.schemas,
+			SecuritySchemes: map[string]OpenAPISecurityScheme{
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "token", Description: "The session given by POST /get-token."},
+				"bearerAuth": {Type: "http", Scheme: "bearer", Description: "An API token, created with POST /users/{id}/tokens."},
+			},
+		},
+	}
+
+	// Every answer can be an error
+	errorSchema := builder.schemaOf(reflect.TypeOf(ErrorResponse{}))
+
+	Router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		pathTemplate, err := route.GetPathTemplate()
+		if err != nil {
+			// The preflight requests are answered on every path
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		path := strings.TrimPrefix(OpenAPIPath(pathTemplate), Prefix)
+		for _, method := range methods {
+			description, ok := apiOperations[method+" "+path]
+			if !ok {
+				undocumented = append(undocumented, method+" "+path)
+				continue
+			}
+
+			if spec.Paths[path] == nil {
+				spec.Paths[path] = map[string]*OpenAPIOperation{}
+			}
+			spec.Paths[path][strings.ToLower(method)] = builder.operation(path, description, errorSchema)
+		}
+		return nil
+	})
+
+	return spec, undocumented
+}
+
+//	OpenAPIHandler(Router *mux.Router, Prefix string) AppHandlerFunc
+/*	The handler called by the following endpoint : GET /v1/openapi.json
+	This method is used to get the OpenAPI specification of a version of the API. It is built once, from the routes of its router.
+*/
+func (env *Env) OpenAPIHandler(Router *mux.Router, Prefix string) AppHandlerFunc {
+	var (
+		once sync.Once
+		spec []byte
+		err  error
+	)
+
+	return func(w http.ResponseWriter, r *http.Request) *AppError {
+		globals.Log.Debug("Calling OpenAPIHandler")
+
+		once.Do(func() {
+			document, undocumented := OpenAPISpec(Router, Prefix)
+			for _, route := range undocumented {
+				globals.Log.Warn("The route " + route + " is missing from the OpenAPI specification")
+			}
+			spec, err = json.Marshal(document)
+		})
+
+		if err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when encoding the specification",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(spec)
+
+		return nil
+	}
+}
+
+//	DocumentationHandler
+/*	The handler called by the following endpoint : GET /v1/docs
+	This method is used to read the OpenAPI specification in a browser.
+*/
+func (env *Env) DocumentationHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	globals.Log.Debug("Calling DocumentationHandler")
+
+	w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(openAPIDocumentationPage))
+
+	return nil
+}
+
+// openAPIBuilder : Turns the descriptions of the routes into operations.
+/*	schemas : The schemas of the named types, shared by the operations through their references.
+ */
+type openAPIBuilder struct {
+	schemas map[string]*OpenAPISchema
+}
+
+//	operation(Path string, Description apiOperation, ErrorSchema *OpenAPISchema) *OpenAPIOperation
+/*	Builds the operation of a route : its parameters, its body and its answers.
+ */
+func (builder *openAPIBuilder) operation(Path string, Description apiOperation, ErrorSchema *OpenAPISchema) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		Summary:   Description.Summary,
+		Tags:      []string{Description.Tag},
+		Responses: map[string]*OpenAPIResponse{},
+	}
+
+	if Description.Public {
+		operation.Security = &[]map[string][]string{}
+	}
+
+	// The ids of the path, like {id}
+	for _, part := range strings.Split(Path, "/") {
+		if !strings.HasPrefix(part, "{") {
+			continue
+		}
+		name := strings.Trim(part, "{}")
+		schema := &OpenAPISchema{Type: "integer", Format: "int64"}
+		if !strings.HasSuffix(name, "id") {
+			schema = &OpenAPISchema{Type: "string"}
+		}
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+
+	for _, parameter := range Description.Query {
+		parameter.In = "query"
+		if parameter.Schema == nil {
+			parameter.Schema = &OpenAPISchema{Type: "string"}
+		}
+		operation.Parameters = append(operation.Parameters, parameter)
+	}
+
+	if Description.Request != nil {
+		operation.RequestBody = &OpenAPIRequestBody{
+			Required: !Description.OptionalBody,
+			Content:  builder.content(Description.Request),
+		}
+	}
+
+	status := Description.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := &OpenAPIResponse{Description: http.StatusText(status)}
+	if Description.Response != nil {
+		response.Content = builder.content(Description.Response)
+	}
+	operation.Responses[strconv.Itoa(status)] = response
+
+	if Description.Accepted != nil {
+		operation.Responses[strconv.Itoa(http.StatusAccepted)] = &OpenAPIResponse{
+			Description: "The two-factor authentication is needed",
+			Content:     builder.content(Description.Accepted),
+		}
+	}
+
+	operation.Responses["default"] = &OpenAPIResponse{
+		Description: "An error, in the JSON envelope",
+		Content:     map[string]OpenAPIMediaType{"application/json": {Schema: ErrorSchema}},
+	}
+
+	return operation
+}
+
+//	content(Value interface{}) map[string]OpenAPIMediaType
+/*	Returns the content of a body : a string is the media type of a text, a schema or any other value is sent as JSON.
+ */
+func (builder *openAPIBuilder) content(Value interface{}) map[string]OpenAPIMediaType {
+	if schema, ok := Value.(*OpenAPISchema); ok {
+		return map[string]OpenAPIMediaType{"application/json": {Schema: schema}}
+	}
+
+	if mediaType, ok := Value.(string); ok {
+		return map[string]OpenAPIMediaType{mediaType: {Schema: &OpenAPISchema{Type: "string"}}}
+	}
+
+	valueType := reflect.TypeOf(Value)
+	return map[string]OpenAPIMediaType{"application/json": {Schema: builder.schemaOf(valueType)}}
+}
+
+//	schemaOf(Type reflect.Type) *OpenAPISchema
+/*	Returns the schema of a type, as encoding/json writes it. The named structs are put in the components,
+	and referenced.
+*/
+func (builder *openAPIBuilder) schemaOf(Type reflect.Type) *OpenAPISchema {
+	if Type.Kind() == reflect.Ptr {
+		schema := builder.schemaOf(Type.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	}
+
+	if Type == reflect.TypeOf(time.Time{}) {
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	}
+
+	switch Type.Kind() {
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if Type.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: builder.schemaOf(Type.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object"}
+	case reflect.Struct:
+		if Type.Name() == "" {
+			return builder.objectOf(Type)
+		}
+
+		if _, ok := builder.schemas[Type.Name()]; !ok {
+			// Reserved first, for the types that reference themselves
+			builder.schemas[Type.Name()] = &OpenAPISchema{}
+			*builder.schemas[Type.Name()] = *builder.objectOf(Type)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + Type.Name()}
+	}
+
+	return &OpenAPISchema{}
+}
+
+//	objectOf(Type reflect.Type) *OpenAPISchema
+/*	Returns the schema of the fields of a struct, named after their json tag. The embedded structs are flattened,
+	and the rules of the validate tags are kept when the schema can tell them.
+*/
+func (builder *openAPIBuilder) objectOf(Type reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+
+	for i := 0; i < Type.NumField(); i++ {
+		field := Type.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		if field.Anonymous && name == "" {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			embedded := builder.objectOf(embeddedType)
+			for property, propertySchema := range embedded.Properties {
+				schema.Properties[property] = propertySchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := builder.schemaOf(field.Type)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			parts := strings.SplitN(rule, "=", 2)
+			switch parts[0] {
+			case "required":
+				schema.Required = append(schema.Required, name)
+			case "mail":
+				property.Format = "email"
+			case "min", "max":
+				if len(parts) != 2 || property.Ref != "" {
+					continue
+				}
+				limit, _ := strconv.ParseInt(parts[1], 10, 64)
+				if parts[0] == "max" && property.Type == "string" {
+					property.MaxLength = &limit
+				} else if parts[0] == "min" && property.Type == "integer" {
+					property.Minimum = &limit
+				}
+			}
+		}
+		schema.Properties[name] = property
+	}
+
+	sort.Strings(schema.Required)
+	return schema
+}
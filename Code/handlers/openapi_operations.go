@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"net/http"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// apiOperation : The description of a route in the OpenAPI specification.
+/*	Summary : What the route does.
+	Tag : The group of the route, like the sections of Doc/Endpoints.md.
+	Public : Wether the route is called without being authenticated.
+	Query : The parameters of the query string.
+	Request : A value of the type of the body, if there is one.
+	OptionalBody : Wether the body can be left out.
+	Response : A value of the type of the answer, if there is one, or its schema. A string is the media type of a text answer.
+	Status : The code of the answer, 200 if 0.
+	Accepted : The answer given with a 202 code when the login needs the two-factor authentication.
+*/
+type apiOperation struct {
+	Summary      string
+	Tag          string
+	Public       bool
+	Query        []OpenAPIParameter
+	Request      interface{}
+	OptionalBody bool
+	Response     interface{}
+	Status       int
+	Accepted     interface{}
+}
+
+//	createdId(Name string) *OpenAPISchema
+/*	Returns the schema of the answer of a creation : the id of the new item, like {"company_id": 1}.
+ */
+func createdId(Name string) *OpenAPISchema {
+	return &OpenAPISchema{
+		Type:       "object",
+		Properties: map[string]*OpenAPISchema{Name: {Type: "integer", Format: "int64"}},
+		Required:   []string{Name},
+	}
+}
+
+// The parameters of the timesheets, shared by GET /users/{id}/timesheets and GET /me/timesheets.
+var timesheetQuery = []OpenAPIParameter{
+	{Name: "week", Description: "The ISO week, like 2021-W12, unless month is given", Schema: &OpenAPISchema{Type: "string"}},
+	{Name: "month", Description: "The month, like 2021-03, unless week is given", Schema: &OpenAPISchema{Type: "string"}},
+}
+
+// The days of the compliance, shared by GET /users/{id}/compliance and GET /me/compliance.
+var complianceQuery = []OpenAPIParameter{
+	{Name: "from", Description: "The first day, like 2021-03-01", Required: true, Schema: &OpenAPISchema{Type: "string", Format: "date"}},
+	{Name: "to", Description: "The last day, included", Required: true, Schema: &OpenAPISchema{Type: "string", Format: "date"}},
+}
+
+// The year of the days off, shared by the calendars of the companies and of the users.
+var holidayQuery = []OpenAPIParameter{
+	{Name: "year", Description: "The year, like 2021, the current one by default", Schema: &OpenAPISchema{Type: "integer"}},
+}
+
+// The scope of the changes of the occurrences of a series.
+var scheduleScopeQuery = []OpenAPIParameter{
+	{Name: "scope", Description: "For an occurrence of a series : occurrence by default, following for the following ones too, or series for the whole series", Schema: &OpenAPISchema{Type: "string", Enum: []string{ScopeOccurrence, ScopeFollowing, ScopeSeries}}},
+}
+
+// The descriptions of the routes, by method and OpenAPI path. Every route of HandleRoutes must have one.
+var apiOperations = map[string]apiOperation{
+	//
+	// Documentation
+	//
+	"GET /openapi.json": {Summary: "Get the OpenAPI specification of the API", Tag: "Documentation", Public: true, Response: map[string]interface{}{}},
+	"GET /docs":         {Summary: "Read the specification in a browser", Tag: "Documentation", Public: true, Response: "text/html"},
+
+	//
+	// Login
+	//
+	"POST /get-token": {
+		Summary:  "Log in with a mail (or a directory username) and a password, the token is also set in the token cookie",
+		Tag:      "Login",
+		Public:   true,
+		Request:  model.User{},
+		Response: "text/plain",
+		Accepted: TwoFactorChallenge{},
+	},
+	"POST /get-token/two-factor": {Summary: "Log in with the token of the first step and a TOTP or recovery code", Tag: "Login", Public: true, Request: TwoFactorLogin{}, Response: "text/plain"},
+	"DELETE /users/{id}/lock":    {Summary: "Unlock a user locked after too many failed logins", Tag: "Login"},
+	"GET /oidc/{provider}/login": {Summary: "Redirect to an OpenID Connect identity provider", Tag: "Login", Public: true, Status: http.StatusFound},
+	"GET /oidc/{provider}/callback": {
+		Summary: "Log in with the code given by the identity provider",
+		Tag:     "Login",
+		Public:  true,
+		Query: []OpenAPIParameter{
+			{Name: "code", Description: "The code given by the identity provider", Required: true},
+			{Name: "state", Description: "The state sent to the identity provider", Required: true},
+		},
+		Response: "text/plain",
+		Accepted: TwoFactorChallenge{},
+	},
+
+	//
+	// Two-factor authentication
+	//
+	"POST /users/{id}/two-factor":         {Summary: "Start enabling the two-factor authentication", Tag: "Two-factor authentication", Response: TwoFactorEnrollment{}},
+	"POST /users/{id}/two-factor/confirm": {Summary: "Confirm the two-factor authentication with a first code", Tag: "Two-factor authentication", Request: TwoFactorCode{}, Response: TwoFactorRecoveryCodes{}},
+	"DELETE /users/{id}/two-factor":       {Summary: "Disable the two-factor authentication", Tag: "Two-factor authentication", Request: TwoFactorCode{}, OptionalBody: true},
+
+	//
+	// API tokens
+	//
+	"GET /users/{id}/tokens":               {Summary: "List the API tokens of a user", Tag: "API tokens", Response: model.AccessTokens{}},
+	"POST /users/{id}/tokens":              {Summary: "Create an API token, only shown once", Tag: "API tokens", Request: AccessTokenRequest{}, Response: AccessTokenCreated{}},
+	"DELETE /users/{id}/tokens/{token_id}": {Summary: "Revoke an API token", Tag: "API tokens"},
+
+	//
+	// Impersonation
+	//
+	"POST /users/{id}/impersonate":     {Summary: "Start impersonating a user", Tag: "Impersonation", Request: ImpersonationRequest{}, OptionalBody: true, Response: ImpersonationStarted{}},
+	"DELETE /impersonation":            {Summary: "Stop the impersonation, and get back the session of the impersonator", Tag: "Impersonation", Response: "text/plain"},
+	"GET /impersonations":              {Summary: "List the impersonations", Tag: "Impersonation", Response: model.Impersonations{}},
+	"GET /impersonations/{id}/actions": {Summary: "List the changes made during an impersonation", Tag: "Impersonation", Response: model.ImpersonationActions{}},
+
+	//
+	// Comments
+	//
+	"GET /comments":                {Summary: "List the comments", Tag: "Comments", Response: model.Comments{}},
+	"GET /comments/{id}":           {Summary: "Get a comment", Tag: "Comments", Response: model.Comment{}},
+	"GET /users/{id}/comments":     {Summary: "List the comments of a user", Tag: "Comments", Response: model.Comments{}},
+	"GET /schedules/{id}/comments": {Summary: "List the comments of a schedule", Tag: "Comments", Response: model.Comments{}},
+	"GET /projects/{id}/comments":  {Summary: "List the comments of a project", Tag: "Comments", Response: model.Comments{}},
+	"POST /comments":               {Summary: "Create a comment", Tag: "Comments", Request: model.Comment{}, Response: createdId("comment_id")},
+	"PATCH /comments/{id}":         {Summary: "Update a comment", Tag: "Comments", Request: model.Comment{}, Response: model.Comment{}},
+	"DELETE /comments/{id}":        {Summary: "Delete a comment", Tag: "Comments"},
+
+	//
+	// Companies
+	//
+	"GET /companies":         {Summary: "List the companies", Tag: "Companies", Response: model.Companies{}},
+	"GET /companies/{id}":    {Summary: "Get a company", Tag: "Companies", Response: model.Company{}},
+	"POST /companies":        {Summary: "Create a company", Tag: "Companies", Request: model.Company{}, Response: createdId("company_id")},
+	"PATCH /companies/{id}":  {Summary: "Update a company", Tag: "Companies", Request: model.Company{}, Response: model.Company{}},
+	"DELETE /companies/{id}": {Summary: "Delete a company", Tag: "Companies"},
+
+	//
+	// Holidays
+	//
+	"GET /holidays/{id}":           {Summary: "Get a day off added to a company", Tag: "Holidays", Response: model.Holiday{}},
+	"GET /companies/{id}/holidays": {Summary: "List the public holidays and the days off of a company during a year", Tag: "Holidays", Query: holidayQuery, Response: []CalendarDay{}},
+	"GET /users/{id}/holidays":     {Summary: "List the days off of all the companies of a user during a year", Tag: "Holidays", Query: holidayQuery, Response: []CalendarDay{}},
+	"POST /holidays":               {Summary: "Add a holiday or a closure day to a company", Tag: "Holidays", Request: model.Holiday{}, Response: model.Holiday{}},
+	"DELETE /holidays/{id}":        {Summary: "Remove a day off of a company", Tag: "Holidays"},
+
+	//
+	// Contracts
+	//
+	"GET /contracts":           {Summary: "List the contracts", Tag: "Contracts", Response: model.Contracts{}},
+	"GET /contracts/{id}":      {Summary: "Get a contract", Tag: "Contracts", Response: model.Contract{}},
+	"GET /users/{id}/contract": {Summary: "Get the contract of a user", Tag: "Contracts", Response: model.Contract{}},
+	"POST /contracts":          {Summary: "Create a contract", Tag: "Contracts", Request: model.Contract{}, Response: createdId("contract_id")},
+	"PATCH /contracts/{id}":    {Summary: "Update a contract", Tag: "Contracts", Request: model.Contract{}, Response: model.Contract{}},
+	"DELETE /contracts/{id}":   {Summary: "Delete a contract", Tag: "Contracts"},
+
+	//
+	// Functions
+	//
+	"GET /functions":            {Summary: "List the functions", Tag: "Functions", Response: model.Functions{}},
+	"GET /functions/{id}":       {Summary: "Get a function", Tag: "Functions", Response: model.Function{}},
+	"GET /users/{id}/functions": {Summary: "List the functions of a user", Tag: "Functions", Response: model.Functions{}},
+	"POST /functions":           {Summary: "Create a function", Tag: "Functions", Request: model.Function{}, Response: createdId("function_id")},
+	"PATCH /functions/{id}":     {Summary: "Update a function", Tag: "Functions", Request: model.Function{}, Response: model.Function{}},
+	"DELETE /functions/{id}":    {Summary: "Delete a function", Tag: "Functions"},
+
+	//
+	// Projects
+	//
+	"GET /projects":                {Summary: "List the projects", Tag: "Projects", Response: model.Projects{}},
+	"GET /projects/{id}":           {Summary: "Get a project", Tag: "Projects", Response: model.Project{}},
+	"GET /companies/{id}/projects": {Summary: "List the projects of a company", Tag: "Projects", Response: model.Projects{}},
+	"GET /users/{id}/projects":     {Summary: "List the projects of a user", Tag: "Projects", Response: model.Projects{}},
+	"POST /projects":               {Summary: "Create a project", Tag: "Projects", Request: model.Project{}, Response: createdId("project_id")},
+	"PATCH /projects/{id}":         {Summary: "Update a project", Tag: "Projects", Request: model.Project{}, Response: model.Project{}},
+	"DELETE /projects/{id}":        {Summary: "Delete a project", Tag: "Projects"},
+
+	//
+	// Roles
+	//
+	"GET /roles":           {Summary: "List the roles", Tag: "Roles", Response: model.Roles{}},
+	"GET /roles/{id}":      {Summary: "Get a role", Tag: "Roles", Response: model.Role{}},
+	"GET /users/{id}/role": {Summary: "Get the role of a user", Tag: "Roles", Response: model.Role{}},
+	"POST /roles":          {Summary: "Create a role", Tag: "Roles", Request: model.Role{}, Response: createdId("role_id")},
+	"PATCH /roles/{id}":    {Summary: "Update a role", Tag: "Roles", Request: model.Role{}, Response: model.Role{}},
+	"DELETE /roles/{id}":   {Summary: "Delete a role", Tag: "Roles"},
+
+	//
+	// Schedules
+	//
+	"GET /schedules/{id}":          {Summary: "Get a schedule", Tag: "Schedules", Response: ScheduleIntermediate{}},
+	"GET /users/{id}/schedules":    {Summary: "List the schedules of a user", Tag: "Schedules", Response: []ScheduleIntermediate{}},
+	"GET /users/{id}/overlaps":     {Summary: "List the pairs of schedules of a user that cover the same hours", Tag: "Schedules", Response: []ScheduleOverlap{}},
+	"GET /projects/{id}/schedules": {Summary: "List the schedules of a project", Tag: "Schedules", Response: []ScheduleIntermediate{}},
+	"POST /schedules":              {Summary: "Create a schedule, with RFC 3339 dates", Tag: "Schedules", Request: ScheduleIntermediate{}, Response: createdId("schedule_id")},
+	"PATCH /schedules/{id}":        {Summary: "Update a schedule, or the occurrences of its series", Tag: "Schedules", Query: scheduleScopeQuery, Request: ScheduleIntermediate{}, Response: ScheduleIntermediate{}},
+	"DELETE /schedules/{id}":       {Summary: "Delete a schedule, or the occurrences of its series", Tag: "Schedules", Query: scheduleScopeQuery},
+
+	//
+	// Breaks within schedules
+	//
+	"GET /breaks/{id}":           {Summary: "Get a break taken during a schedule", Tag: "Breaks", Response: BreakIntermediate{}},
+	"GET /schedules/{id}/breaks": {Summary: "List the breaks taken during a schedule", Tag: "Breaks", Response: []BreakIntermediate{}},
+	"POST /breaks":               {Summary: "Add a break to a schedule, whose hours don't count as worked", Tag: "Breaks", Request: BreakIntermediate{}, Response: BreakIntermediate{}},
+	"PATCH /breaks/{id}":         {Summary: "Change the dates of a break", Tag: "Breaks", Request: BreakIntermediate{}, Response: BreakIntermediate{}},
+	"DELETE /breaks/{id}":        {Summary: "Delete a break", Tag: "Breaks"},
+
+	//
+	// Recurring series of schedules
+	//
+	"GET /series/{id}":       {Summary: "Get a recurring series of schedules, with its saved occurrences", Tag: "Series", Response: SeriesIntermediate{}},
+	"GET /users/{id}/series": {Summary: "List the recurring series of schedules of a user", Tag: "Series", Response: []SeriesIntermediate{}},
+	"POST /series":           {Summary: "Create schedules repeated by a recurrence rule of RFC 5545", Tag: "Series", Request: SeriesIntermediate{}, Response: SeriesIntermediate{}},
+	"PATCH /series/{id}":     {Summary: "Update a whole series, creating its occurrences again", Tag: "Series", Request: SeriesIntermediate{}, Response: SeriesIntermediate{}},
+	"DELETE /series/{id}":    {Summary: "Delete a series with all its occurrences", Tag: "Series"},
+
+	//
+	// Templates of schedules
+	//
+	"GET /templates/{id}":           {Summary: "Get a template of schedules with its time slots", Tag: "Templates", Response: TemplateIntermediate{}},
+	"GET /users/{id}/templates":     {Summary: "List the templates a user can apply : his own ones, and the ones of his companies", Tag: "Templates", Response: []TemplateIntermediate{}},
+	"GET /companies/{id}/templates": {Summary: "List the templates shared with the users of a company", Tag: "Templates", Response: []TemplateIntermediate{}},
+	"POST /templates":               {Summary: "Save a typical week of schedules, for a user or a company", Tag: "Templates", Request: TemplateIntermediate{}, Response: TemplateIntermediate{}},
+	"PATCH /templates/{id}":         {Summary: "Rename a template and replace its time slots", Tag: "Templates", Request: TemplateIntermediate{}, Response: TemplateIntermediate{}},
+	"DELETE /templates/{id}":        {Summary: "Delete a template, keeping the schedules filled from it", Tag: "Templates"},
+	"POST /templates/{id}/apply":    {Summary: "Fill a week of a user with the schedules of a template", Tag: "Templates", Request: TemplateApplication{}, Response: TemplateApplicationResult{}},
+	"POST /templates/{id}/preview":  {Summary: "Show what applying a template to a week would do, without creating anything", Tag: "Templates", Request: TemplateApplication{}, Response: TemplateApplicationResult{}},
+
+	//
+	// Reports
+	//
+	"GET /users/{id}/report": {
+		Summary: "Get the hours worked by a user, per day or per week of his time zone",
+		Tag:     "Reports",
+		Query: []OpenAPIParameter{
+			{Name: "from", Description: "The first day, like 2021-03-01", Required: true, Schema: &OpenAPISchema{Type: "string", Format: "date"}},
+			{Name: "to", Description: "The last day, included", Required: true, Schema: &OpenAPISchema{Type: "string", Format: "date"}},
+			{Name: "period", Description: "The length of the periods, day by default", Schema: &OpenAPISchema{Type: "string", Enum: []string{"day", "week"}}},
+		},
+		Response: Report{},
+	},
+	"GET /users/{id}/balance": {Summary: "Get the hours worked by a user this week, and the ones left to work", Tag: "Reports", Response: Balance{}},
+	"GET /users/{id}/timesheets": {
+		Summary:  "Get the timesheet of a user for a week or a month, with his overtime and his cumulative balance",
+		Tag:      "Reports",
+		Query:    timesheetQuery,
+		Response: Timesheet{},
+	},
+	"GET /users/{id}/compliance": {
+		Summary:  "Check the schedules of a user against the working time rules of his contract",
+		Tag:      "Reports",
+		Query:    complianceQuery,
+		Response: Compliance{},
+	},
+
+	//
+	// Approval of the timesheets
+	//
+	"GET /timesheets": {
+		Summary: "List the timesheet periods of every user in a state, the submitted ones by default",
+		Tag:     "Timesheets",
+		Query: []OpenAPIParameter{
+			{Name: "state", Description: "The state of the periods", Schema: &OpenAPISchema{Type: "string", Enum: []string{"draft", "submitted", "approved", "rejected"}}},
+		},
+		Response: []TimesheetPeriodIntermediate{},
+	},
+	"GET /users/{id}/timesheets/periods":           {Summary: "List the submitted timesheet periods of a user", Tag: "Timesheets", Response: []TimesheetPeriodIntermediate{}},
+	"GET /users/{id}/timesheets/{period}":          {Summary: "Get the state of a week or a month of the timesheet of a user, with its history", Tag: "Timesheets", Response: TimesheetPeriodIntermediate{}},
+	"POST /users/{id}/timesheets/{period}/submit":  {Summary: "Submit a week or a month of his own timesheet", Tag: "Timesheets", Response: TimesheetPeriodIntermediate{}},
+	"POST /users/{id}/timesheets/{period}/approve": {Summary: "Approve a submitted timesheet period, which locks its schedules", Tag: "Timesheets", Request: TimesheetDecision{}, OptionalBody: true, Response: TimesheetPeriodIntermediate{}},
+	"POST /users/{id}/timesheets/{period}/reject":  {Summary: "Reject a submitted timesheet period, with a reason", Tag: "Timesheets", Request: TimesheetDecision{}, Response: TimesheetPeriodIntermediate{}},
+	"POST /users/{id}/timesheets/{period}/reopen":  {Summary: "Reopen an approved timesheet period as a draft", Tag: "Timesheets", Request: TimesheetDecision{}, OptionalBody: true, Response: TimesheetPeriodIntermediate{}},
+
+	//
+	// Lock date of the company
+	//
+	"GET /locks":         {Summary: "List the changes of the lock date, the last one first", Tag: "Locks", Response: []LockDateIntermediate{}},
+	"GET /locks/current": {Summary: "Get the lock date before which the schedules and the vacations can't change", Tag: "Locks", Response: LockDateIntermediate{}},
+	"POST /locks":        {Summary: "Move the lock date forward, once the payroll is closed", Tag: "Locks", Request: LockRequest{}, Response: LockDateIntermediate{}},
+	"POST /locks/unlock": {Summary: "Move the lock date back, or remove it, with a reason", Tag: "Locks", Request: LockRequest{}, Response: LockDateIntermediate{}},
+
+	//
+	// Connected user
+	//
+	"GET /me":                             {Summary: "Get the connected user", Tag: "Me", Response: model.User{}},
+	"GET /me/schedules":                   {Summary: "List the schedules of the connected user", Tag: "Me", Response: []ScheduleIntermediate{}},
+	"GET /me/series":                      {Summary: "List the recurring series of schedules of the connected user", Tag: "Me", Response: []SeriesIntermediate{}},
+	"GET /me/templates":                   {Summary: "List the templates the connected user can apply", Tag: "Me", Response: []TemplateIntermediate{}},
+	"GET /me/holidays":                    {Summary: "List the days off of the connected user during a year", Tag: "Me", Query: holidayQuery, Response: []CalendarDay{}},
+	"GET /me/vacations":                   {Summary: "List the vacations of the connected user", Tag: "Me", Response: []ScheduleIntermediate{}},
+	"GET /me/comments":                    {Summary: "List the comments of the connected user", Tag: "Me", Response: model.Comments{}},
+	"GET /me/projects":                    {Summary: "List the projects of the connected user", Tag: "Me", Response: model.Projects{}},
+	"GET /me/role":                        {Summary: "Get the role of the connected user", Tag: "Me", Response: model.Role{}},
+	"GET /me/balance":                     {Summary: "Get the hours worked by the connected user this week, and the ones left to work", Tag: "Me", Response: Balance{}},
+	"GET /me/timesheets":                  {Summary: "Get the timesheet of the connected user for a week or a month", Tag: "Me", Query: timesheetQuery, Response: Timesheet{}},
+	"GET /me/compliance":                  {Summary: "Check the schedules of the connected user against the working time rules of his contract", Tag: "Me", Query: complianceQuery, Response: Compliance{}},
+	"GET /me/timesheets/periods":          {Summary: "List the submitted timesheet periods of the connected user", Tag: "Me", Response: []TimesheetPeriodIntermediate{}},
+	"GET /me/timesheets/{period}":         {Summary: "Get the state of a week or a month of the timesheet of the connected user", Tag: "Me", Response: TimesheetPeriodIntermediate{}},
+	"POST /me/timesheets/{period}/submit": {Summary: "Submit a week or a month of the timesheet of the connected user", Tag: "Me", Response: TimesheetPeriodIntermediate{}},
+	"GET /me/timer":                       {Summary: "Get the running timer of the connected user, a schedule without end", Tag: "Me", Response: ScheduleIntermediate{}},
+	"POST /me/timer/start":                {Summary: "Start a timer on a project, if none is running", Tag: "Me", Request: TimerStart{}, Response: ScheduleIntermediate{}},
+	"POST /me/timer/stop":                 {Summary: "Stop the running timer of the connected user", Tag: "Me", Response: ScheduleIntermediate{}},
+
+	//
+	// Users
+	//
+	"GET /users":                {Summary: "List the users", Tag: "Users", Response: model.Users{}},
+	"GET /users/{id}":           {Summary: "Get a user", Tag: "Users", Response: model.User{}},
+	"GET /companies/{id}/users": {Summary: "List the users of a company", Tag: "Users", Response: model.Users{}},
+	"GET /schedules/{id}/users": {Summary: "List the users of a schedule", Tag: "Users", Response: model.Users{}},
+	"GET /projects/{id}/users":  {Summary: "List the users of a project", Tag: "Users", Response: model.Users{}},
+	"POST /users":               {Summary: "Create a user", Tag: "Users", Request: model.User{}, Response: createdId("user_id")},
+	"PATCH /users/{id}":         {Summary: "Update a user", Tag: "Users", Request: model.User{}, Response: model.User{}},
+	"DELETE /users/{id}":        {Summary: "Delete a user", Tag: "Users"},
+	"POST /users/{id}/password": {Summary: "Change the password of a user", Tag: "Users", Request: PasswordChange{}},
+	"GET /users/{id}/export": {
+		Summary: "Export the personal data of a user",
+		Tag:     "Users",
+		Query: []OpenAPIParameter{
+			{Name: "format", Description: "json by default, or zip for an archive", Schema: &OpenAPISchema{Type: "string", Enum: []string{"json", "zip"}}},
+		},
+		Response: PersonalDataExport{},
+	},
+	"POST /users/{id}/anonymize": {Summary: "Anonymize a user, keeping his hours", Tag: "Users", Response: model.User{}},
+
+	//
+	// Vacations
+	//
+	"GET /users/{id}/vacations": {Summary: "List the vacations of a user", Tag: "Vacations", Response: []ScheduleIntermediate{}},
+	"GET /vacations/{id}":       {Summary: "Get a vacation", Tag: "Vacations", Response: ScheduleIntermediate{}},
+	"POST /vacations":           {Summary: "Create a vacation, with RFC 3339 dates", Tag: "Vacations", Request: ScheduleIntermediate{}, Response: createdId("vacation_id")},
+	"PATCH /vacations/{id}":     {Summary: "Update a vacation", Tag: "Vacations", Request: ScheduleIntermediate{}, Response: ScheduleIntermediate{}},
+	"DELETE /vacations/{id}":    {Summary: "Delete a vacation", Tag: "Vacations"},
+
+	//
+	// Links between the items
+	//
+	"POST /companies/{id}/users/{other_id}":      {Summary: "Add a user to a company", Tag: "Links"},
+	"DELETE /companies/{id}/users/{other_id}":    {Summary: "Remove a user from a company", Tag: "Links"},
+	"POST /users/{id}/schedules/{other_id}":      {Summary: "Add a schedule to a user", Tag: "Links"},
+	"DELETE /users/{id}/schedules/{other_id}":    {Summary: "Remove a schedule from a user", Tag: "Links"},
+	"POST /companies/{id}/projects/{other_id}":   {Summary: "Add a project to a company", Tag: "Links"},
+	"DELETE /companies/{id}/projects/{other_id}": {Summary: "Remove a project from a company", Tag: "Links"},
+	"POST /users/{id}/functions/{other_id}":      {Summary: "Give a function to a user", Tag: "Links"},
+	"DELETE /users/{id}/functions/{other_id}":    {Summary: "Remove a function from a user", Tag: "Links"},
+}
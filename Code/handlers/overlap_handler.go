@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//	GetOverlapsOfUserHandler
+/*	The handler called by the following endpoint : GET /users/{id}/overlaps
+	This method is used to get the pairs of schedules of a user that cover the same hours, so they can be cleaned up.
+	The schedules of the projects that allow overlaps are left out.
+*/
+func (env *Env) GetOverlapsOfUserHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		userId   int
+		overlaps model.ScheduleOverlaps
+	)
+
+	globals.Log.Debug("Calling GetOverlapsOfUserHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if overlaps, err = env.DB.GetOverlapsOfUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the overlaps",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	intermediates := []ScheduleOverlap{}
+	for _, overlap := range overlaps {
+		intermediates = append(intermediates, ScheduleOverlap{
+			UserId: overlap.UserId,
+			First:  ScheduleToIntermediate(overlap.First, location),
+			Second: ScheduleToIntermediate(overlap.Second, location),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(intermediates); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the overlaps",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	overlapError(r *http.Request, Err error) *AppError
+/*	Returns a 409 error with the conflicting schedules if an error of the datastore is an overlap, nil otherwise.
+ */
+func (env *Env) overlapError(r *http.Request, Err error) *AppError {
+	var overlapErr *datastores.OverlapError
+	if !errors.As(Err, &overlapErr) {
+		return nil
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	appErr = &AppError{
+		Error:     Err,
+		Message:   "The schedule covers the same hours as other schedules of the user",
+		Code:      http.StatusConflict,
+		ErrorCode: ErrorScheduleOverlap,
+		Conflicts: []ScheduleIntermediate{},
+	}
+	for _, schedule := range overlapErr.Schedules {
+		appErr.Conflicts = append(appErr.Conflicts, ScheduleToIntermediate(schedule, location))
+	}
+	return appErr
+}
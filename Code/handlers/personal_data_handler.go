@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// The domain of the mails given to the anonymized users. It is reserved, so it can't belong to anybody.
+const anonymizedMailDomain = "anonymized.invalid"
+
+//	ExportPersonalDataHandler
+/*	The handler called by the following endpoint : GET /users/{id}/export
+	This method is used to answer a subject access request : it returns everything the application knows about a user.
+	Users can export their own data, the users that can add and modify users can export everybody's.
+	The data is returned as a JSON document, or as a ZIP archive with a JSON file per part with ?format=zip.
+*/
+func (env *Env) ExportPersonalDataHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err            error
+		userId         int64
+		export         PersonalDataExport
+		vacation       model.Project
+		schedules      model.Schedules
+		impersonations model.Impersonations
+		twoFactor      model.TwoFactor
+	)
+
+	globals.Log.Debug("ExportPersonalDataHandler called")
+
+	// Everything about a user is only given to the real user, not to his API tokens
+	if appErr := forbidDelegatedSession(r); appErr != nil {
+		return appErr
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		return &AppError{
+			Error:   errors.New("unknown format"),
+			Message: "The format must be json or zip",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if userId, err = env.selfOrManagedUserId(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Exporting the data of another user is forbidden",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if export.Profile, err = env.DB.GetUser(userId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Unexisting user",
+			Code:    http.StatusNotFound,
+		}
+	}
+	// Even hashed, the password is not given back
+	export.Profile.Password = ""
+
+	export.ExportedAt = time.Now()
+
+	if export.Contract, err = env.DB.GetContract(export.Profile.ContractId); err != nil {
+		return exportError(err, "contract")
+	}
+
+	if export.Role, err = env.DB.GetRole(export.Profile.RoleId); err != nil {
+		return exportError(err, "role")
+	}
+
+	if export.Functions, err = env.DB.GetFunctionsOfUser(userId); err != nil {
+		return exportError(err, "functions")
+	}
+
+	if export.Companies, err = env.DB.GetCompaniesOfUser(userId); err != nil {
+		return exportError(err, "companies")
+	}
+
+	// The vacations are schedules of the vacation project : they are given apart
+	if vacation, err = env.DB.GetVacationProject(); err != nil {
+		return exportError(err, "vacations")
+	}
+
+	if schedules, err = env.schedulesOfUser(userId); err != nil {
+		return exportError(err, "schedules")
+	}
+
+	export.Schedules, export.Vacations = model.Schedules{}, model.Schedules{}
+	for _, schedule := range schedules {
+		if schedule.ProjectId == vacation.ProjectId {
+			export.Vacations = append(export.Vacations, schedule)
+		} else {
+			export.Schedules = append(export.Schedules, schedule)
+		}
+	}
+
+	if export.Comments, err = env.DB.GetCommentsOfUser(userId); err != nil {
+		return exportError(err, "comments")
+	}
+
+	if export.AccessTokens, err = env.DB.GetAccessTokensOfUser(userId); err != nil {
+		return exportError(err, "API tokens")
+	}
+
+	if twoFactor, err = env.DB.GetTwoFactor(userId); err == nil {
+		export.TwoFactorEnabled = twoFactor.Enabled
+	}
+
+	// The impersonations the user was part of, on both sides
+	if impersonations, err = env.DB.GetImpersonations(); err != nil {
+		return exportError(err, "impersonations")
+	}
+
+	export.Impersonations = model.Impersonations{}
+	for _, impersonation := range impersonations {
+		if impersonation.UserId == userId || impersonation.ImpersonatorId == userId {
+			export.Impersonations = append(export.Impersonations, impersonation)
+		}
+	}
+
+	globals.Log.WithFields(logrus.Fields{"user": userId, "format": format}).Info("Personal data exported")
+
+	fileName := "user-" + strconv.FormatInt(userId, 10) + "-export"
+
+	if format != "zip" {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+fileName+`.json"`)
+		w.WriteHeader(http.StatusOK)
+
+		if err = json.NewEncoder(w).Encode(export); err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when encoding the export",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+		return nil
+	}
+
+	// A file per part of the export
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"contract.json", export.Contract},
+		{"role.json", export.Role},
+		{"functions.json", export.Functions},
+		{"companies.json", export.Companies},
+		{"schedules.json", export.Schedules},
+		{"vacations.json", export.Vacations},
+		{"comments.json", export.Comments},
+		{"access_tokens.json", export.AccessTokens},
+		{"security.json", map[string]interface{}{"two_factor_enabled": export.TwoFactorEnabled, "impersonations": export.Impersonations}},
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+fileName+`.zip"`)
+	w.WriteHeader(http.StatusOK)
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		writer, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     fileName + "/" + file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err == nil {
+			encoder := json.NewEncoder(writer)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(file.content)
+		}
+		if err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when writing the archive",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when writing the archive",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	AnonymizeUserHandler
+/*	The handler called by the following endpoint : POST /users/{id}/anonymize
+	This method is used to erase a user, as asked by the GDPR, without losing the hours of the reports.
+	The names, the mail and the password are scrubbed, the API tokens and the two-factor authentication deleted,
+	and so are the comments of the schedules no other user is linked to. The schedules, the vacations, the contract,
+	the role, the functions and the companies are kept, but can't be linked to the person anymore.
+	It needs the right to add and modify users, and can't be undone.
+*/
+func (env *Env) AnonymizeUserHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err           error
+		userId        int
+		currentUserId int64
+		user          model.User
+	)
+
+	globals.Log.Debug("AnonymizeUserHandler called")
+
+	if appErr := forbidDelegatedSession(r); appErr != nil {
+		return appErr
+	}
+
+	if appErr := env.requireUserManagement(r); appErr != nil {
+		return appErr
+	}
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if currentUserId, _, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if int64(userId) == currentUserId {
+		return &AppError{
+			Error:   errors.New("self anonymization"),
+			Message: "Users can't anonymize themselves",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if user, err = env.DB.GetUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Unexisting user",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	alias := "anonymous-" + strconv.FormatInt(user.UserId, 10)
+	anonymous := user
+	anonymous.Username = alias
+	anonymous.FirstName = ""
+	anonymous.LastName = ""
+	anonymous.Mail = alias + "@" + anonymizedMailDomain
+	anonymous.Password = noPassword
+	anonymous.MustChangePassword = false
+	anonymous.AuthProvider = authentication.LocalProvider
+
+	if err = env.DB.AnonymizeUser(anonymous); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when anonymizing the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// The failed logins are counted for the old mail
+	if err = env.deleteLoginAttempts(user); err != nil {
+		globals.Log.WithFields(logrus.Fields{"error": err}).Error("Could not delete the login attempts of the anonymized user")
+	}
+
+	globals.Log.WithFields(logrus.Fields{"user": user.UserId, "by": currentUserId}).Warn("User anonymized")
+
+	if anonymous, err = env.DB.GetUser(user.UserId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+	anonymous.Password = ""
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(anonymous); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	exportError(err error, Part string) *AppError
+/*	Returns the error of a part of the export that could not be fetched.
+ */
+func exportError(err error, Part string) *AppError {
+	return &AppError{
+		Error:   err,
+		Message: "Error when fetching the " + Part + " of the user",
+		Code:    http.StatusInternalServerError,
+	}
+}
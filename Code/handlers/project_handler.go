@@ -172,6 +172,10 @@ func (env *Env) CreateProjectHandler(w http.ResponseWriter, r *http.Request) *Ap
 		}
 	}
 
+	if appErr := env.validate(&project).result(); appErr != nil {
+		return appErr
+	}
+
 	globals.Log.Debug("Calling CreateProject method")
 
 	if projectId, err = env.DB.CreateProject(project); err != nil {
@@ -233,6 +237,10 @@ func (env *Env) UpdateProjectHandler(w http.ResponseWriter, r *http.Request) *Ap
 		}
 	}
 
+	if appErr := env.validate(&project).result(); appErr != nil {
+		return appErr
+	}
+
 	vars := mux.Vars(r)
 
 	if projectId, err = strconv.Atoi(vars["id"]); err != nil {
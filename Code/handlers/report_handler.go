@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// The longest report that can be asked, in days.
+const maxReportDays = 366
+
+//	GetReportHandler
+/*	The handler called by the following endpoint : GET /users/{id}/report?from=2021-03-01&to=2021-03-31&period=day
+	This method is used to get the hours worked by a user, per day or per week (starting on monday).
+	The days and the weeks are the ones of the zone of the user : the day the clocks change lasts 23 or 25 hours.
+	The vacations don't count on his days off.
+	Users can see their own report, and the users that can see the reports the ones of everybody.
+*/
+func (env *Env) GetReportHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err             error
+		userId          int
+		location        *time.Location
+		schedules       model.Schedules
+		vacationProject model.Project
+		holidays        map[string]string
+		from, to        time.Time
+	)
+
+	globals.Log.Debug("Calling GetReportHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireReportAccess(r, int64(userId)); appErr != nil {
+		return appErr
+	}
+
+	if location, err = env.userLocation(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the time zone of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// Reading the days of the report, in the zone of the user
+	query := r.URL.Query()
+	period := query.Get("period")
+	if period == "" {
+		period = "day"
+	}
+
+	v := &validation{}
+	if period != "day" && period != "week" {
+		v.add("period", "must be day or week")
+	}
+	from = v.day("from", query.Get("from"), location)
+	to = v.day("to", query.Get("to"), location)
+	if !v.has("from") && !v.has("to") {
+		if to.Before(from) {
+			v.add("to", "must not be before from")
+		} else if globals.AddDays(from, maxReportDays, location).Before(to) {
+			v.add("to", "must be at most "+strconv.Itoa(maxReportDays)+" days after from")
+		}
+	}
+
+	if appErr := v.result(); appErr != nil {
+		return appErr
+	}
+
+	if schedules, err = env.schedulesOfUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the schedules",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if vacationProject, err = env.DB.GetVacationProject(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the vacation project",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// The periods cover whole days or weeks
+	start, end, days := globals.StartOfDay(from, location), globals.AddDays(to, 1, location), 1
+	if period == "week" {
+		start, end, days = globals.StartOfWeek(from, location), globals.AddDays(globals.StartOfWeek(to, location), 7, location), 7
+	}
+
+	if holidays, err = env.holidaysOfUser(int64(userId), start, end, location); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the days off of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+	offDays := holidayIntervals(holidays, location)
+
+	report := Report{
+		UserId:   int64(userId),
+		TimeZone: location.String(),
+		Period:   period,
+		Periods:  []ReportPeriod{},
+	}
+
+	for periodStart := start; periodStart.Before(end); {
+		periodEnd := globals.AddDays(periodStart, days, location)
+		reportPeriod := ReportPeriod{
+			Start: globals.FormatDate(periodStart, location),
+			End:   globals.FormatDate(periodEnd, location),
+		}
+
+		reportPeriod.Hours, reportPeriod.VacationHours = sumHours(schedules, vacationProject.ProjectId, offDays, periodStart, periodEnd)
+		report.Hours += reportPeriod.Hours
+		report.VacationHours += reportPeriod.VacationHours
+		report.Periods = append(report.Periods, reportPeriod)
+
+		periodStart = periodEnd
+	}
+
+	report.Hours, report.VacationHours = roundHours(report.Hours), roundHours(report.VacationHours)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(report); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the report",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	GetBalanceHandler
+/*	The handler called by the following endpoint : GET /users/{id}/balance, and GET /me/balance
+	This method is used to get where a user stands this week, in his zone : the hours he worked, the hours he still
+	has to work to reach his theorical hours (negative when he worked more), and his remaining paid vacation hours.
+	His days off from monday to friday are removed from the theorical hours, and the vacations don't count on them.
+	Users can see their own balance, and the users that can see the reports the ones of everybody.
+*/
+func (env *Env) GetBalanceHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err             error
+		userId          int
+		user            model.User
+		location        *time.Location
+		schedules       model.Schedules
+		vacationProject model.Project
+		holidays        map[string]string
+	)
+
+	globals.Log.Debug("Calling GetBalanceHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireReportAccess(r, int64(userId)); appErr != nil {
+		return appErr
+	}
+
+	if user, err = env.DB.GetUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if location, err = env.userLocation(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the time zone of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if schedules, err = env.schedulesOfUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the schedules",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if vacationProject, err = env.DB.GetVacationProject(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the vacation project",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	weekStart := globals.StartOfWeek(time.Now(), location)
+	weekEnd := globals.AddDays(weekStart, 7, location)
+
+	if holidays, err = env.holidaysOfUser(int64(userId), weekStart, weekEnd, location); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the days off of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	balance := Balance{
+		UserId:                 int64(userId),
+		TimeZone:               location.String(),
+		WeekStart:              globals.FormatDate(weekStart, location),
+		WeekEnd:                globals.FormatDate(weekEnd, location),
+		TheoricalHours:         user.TheoricalHoursWorked,
+		RemainingVacationHours: user.VacationHours,
+	}
+	balance.Hours, balance.VacationHours = sumHours(schedules, vacationProject.ProjectId, holidayIntervals(holidays, location), weekStart, weekEnd)
+	for day := weekStart; day.Before(weekEnd); day = globals.AddDays(day, 1, location) {
+		if _, off := holidays[day.Format(globals.DayLayout)]; off && day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			balance.HolidayHours += float64(user.TheoricalHoursWorked) / workingDaysPerWeek
+		}
+	}
+	balance.HolidayHours = roundHours(balance.HolidayHours)
+	balance.RemainingHours = roundHours(float64(user.TheoricalHoursWorked) - balance.HolidayHours - balance.Hours - balance.VacationHours)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(balance); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the balance",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	requireManager(r *http.Request, Action string) *AppError
+/*	Verifies the connected user is a manager, whose role can see the reports, as needed to lock or unlock the hours,
+	or to manage the templates of the companies. The action is named in the error.
+*/
+func (env *Env) requireManager(r *http.Request, Action string) *AppError {
+	var (
+		err           error
+		currentRoleId int64
+		currentRole   model.Role
+	)
+
+	if _, currentRoleId, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if currentRole, err = env.DB.GetRole(currentRoleId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the role",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if !currentRole.CanSeeReports {
+		return &AppError{
+			Error:   errors.New("forbidden"),
+			Message: Action + " is forbidden",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	return nil
+}
+
+//	requireReportAccess(r *http.Request, UserId int64) *AppError
+/*	Verifies the connected user can see the report of a user : his own, or any if his role can see the reports.
+ */
+func (env *Env) requireReportAccess(r *http.Request, UserId int64) *AppError {
+	var (
+		err           error
+		currentUserId int64
+		currentRoleId int64
+		currentRole   model.Role
+	)
+
+	if currentUserId, currentRoleId, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if currentUserId == UserId {
+		return nil
+	}
+
+	if currentRole, err = env.DB.GetRole(currentRoleId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the role",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if !currentRole.CanSeeReports {
+		return &AppError{
+			Error:   errors.New("forbidden"),
+			Message: "Seeing the reports of the other users is forbidden",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	return nil
+}
+
+//	day(Field string, Value string, Location *time.Location) time.Time
+/*	Parses a day of a request, like 2021-03-28, as the midnight that starts it in a zone.
+ */
+func (v *validation) day(Field string, Value string, Location *time.Location) time.Time {
+	if Value == "" {
+		v.add(Field, "is required")
+		return time.Time{}
+	}
+
+	day, err := time.ParseInLocation(globals.DayLayout, Value, Location)
+	if err != nil {
+		v.add(Field, "must be a day like 2021-03-28")
+	}
+	return day
+}
+
+//	overlap(Schedule model.Schedule, Start time.Time, End time.Time) time.Duration
+/*	Returns how long a schedule lasts between two dates, breaks included. A running timer lasts until now.
+ */
+func overlap(Schedule model.Schedule, Start time.Time, End time.Time) time.Duration {
+	scheduleEnd := Schedule.EndDate.Time
+	if !Schedule.EndDate.Valid {
+		scheduleEnd = time.Now()
+	}
+
+	return between(Schedule.StartDate.Time, scheduleEnd, Start, End)
+}
+
+//	worked(Schedule model.Schedule, Start time.Time, End time.Time) time.Duration
+/*	Returns how long a schedule lasts between two dates without its breaks : the net duration all the totals count.
+ */
+func worked(Schedule model.Schedule, Start time.Time, End time.Time) time.Duration {
+	duration := overlap(Schedule, Start, End)
+	for _, scheduleBreak := range Schedule.Breaks {
+		duration -= between(scheduleBreak.StartDate, scheduleBreak.EndDate, Start, End)
+	}
+	return duration
+}
+
+//	between(From time.Time, To time.Time, Start time.Time, End time.Time) time.Duration
+/*	Returns how long the interval from From to To covers the one from Start to End.
+ */
+func between(From time.Time, To time.Time, Start time.Time, End time.Time) time.Duration {
+	if From.After(Start) {
+		Start = From
+	}
+	if To.Before(End) {
+		End = To
+	}
+
+	if !End.After(Start) {
+		return 0
+	}
+	return End.Sub(Start)
+}
+
+//	outOfHolidays(Schedule model.Schedule, Holidays []interval, Start time.Time, End time.Time) time.Duration
+/*	Returns how long a schedule lasts between two dates without its breaks, out of some days off.
+ */
+func outOfHolidays(Schedule model.Schedule, Holidays []interval, Start time.Time, End time.Time) time.Duration {
+	duration := worked(Schedule, Start, End)
+	for _, holiday := range Holidays {
+		from, to := holiday.start, holiday.end
+		if Start.After(from) {
+			from = Start
+		}
+		if End.Before(to) {
+			to = End
+		}
+		if to.After(from) {
+			duration -= worked(Schedule, from, to)
+		}
+	}
+	return duration
+}
+
+//	sumHours(Schedules model.Schedules, VacationProjectId int64, Holidays []interval, Start time.Time, End time.Time) (float64, float64)
+/*	Returns the hours worked between two dates without the breaks, and apart the ones spent in vacation out of the
+	days off, rounded.
+*/
+func sumHours(Schedules model.Schedules, VacationProjectId int64, Holidays []interval, Start time.Time, End time.Time) (float64, float64) {
+	var hours, vacationHours float64
+
+	for _, schedule := range Schedules {
+		if schedule.ProjectId == VacationProjectId {
+			vacationHours += outOfHolidays(schedule, Holidays, Start, End).Hours()
+		} else {
+			hours += worked(schedule, Start, End).Hours()
+		}
+	}
+
+	return roundHours(hours), roundHours(vacationHours)
+}
+
+//	roundHours(Hours float64) float64
+/*	Rounds a number of hours to the hundredth, so the sums of durations don't show floating point noise.
+ */
+func roundHours(Hours float64) float64 {
+	return math.Round(Hours*100) / 100
+}
@@ -134,6 +134,10 @@ func (env *Env) CreateRoleHandler(w http.ResponseWriter, r *http.Request) *AppEr
 		}
 	}
 
+	if appErr := env.validate(&role).result(); appErr != nil {
+		return appErr
+	}
+
 	globals.Log.Debug("Calling CreateRole method")
 
 	if roleId, err = env.DB.CreateRole(role); err != nil {
@@ -213,6 +217,10 @@ func (env *Env) UpdateRoleHandler(w http.ResponseWriter, r *http.Request) *AppEr
 		}
 	}
 
+	if appErr := env.validate(&role).result(); appErr != nil {
+		return appErr
+	}
+
 	vars := mux.Vars(r)
 
 	if roleId, err = strconv.Atoi(vars["id"]); err != nil {
@@ -6,16 +6,82 @@ import (
 	"github.com/justinas/alice"
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
 )
 
+// The periods of the timesheets in the paths : a week like 2021-W12, or a month like 2021-03.
+const periodPattern = "{period:[0-9]+-W?[0-9]+}"
+
 func HandleRoutes(r *mux.Router, env *Env) {
 	commonChain := alice.New(env.HeadersMiddleware)
-	secureChain := alice.New(env.HeadersMiddleware, env.AuthenticateMiddleware, env.AuthorizeMiddleware)
+
+	// The errors of the router are written like the other ones
+	r.NotFoundHandler = commonChain.Then(env.AppMiddleware(env.NotFoundHandler))
+	r.MethodNotAllowedHandler = commonChain.Then(env.AppMiddleware(env.MethodNotAllowedHandler))
+
+	// The preflight requests of the browsers, before the requests from other origins.
+	// The header is required so the other paths still answer 404 or 405.
+	r.Methods("OPTIONS").HeadersRegexp("Access-Control-Request-Method", ".+").Handler(commonChain.Then(env.AppMiddleware(env.PreflightHandler)))
+
+	// Each version of the API has its own routes under its prefix : a new version, like /v2, gets its own
+	// handleV2Routes, with the handlers and the types that changed, without breaking the clients of the previous ones.
+	handleV1Routes(r.PathPrefix(V1Prefix).Subrouter(), env)
+
+	// The paths of the first version are still answered at the root, until their sunset
+	if globals.LegacyAPI.Enabled {
+		r.MatcherFunc(unversionedPath).Handler(env.LegacyAPIHandler(r, V1Prefix))
+	}
+}
+
+//	handleV1Routes(r *mux.Router, env *Env)
+/*	The routes of the first version of the API, under /v1.
+ */
+func handleV1Routes(r *mux.Router, env *Env) {
+	commonChain := alice.New(env.HeadersMiddleware)
+	secureChain := alice.New(env.HeadersMiddleware, env.AuthenticateMiddleware, env.AuthorizeMiddleware, env.AccountSetupMiddleware)
+	// Users that must change their password or enable the two-factor authentication can still reach the routes of this chain
+	setupChain := alice.New(env.HeadersMiddleware, env.AuthenticateMiddleware, env.AuthorizeMiddleware)
+	// The routes of /me are the ones of /users/{id}, for the connected user
+	meChain := alice.New(env.HeadersMiddleware, env.AuthenticateMiddleware, env.MeMiddleware, env.AuthorizeMiddleware, env.AccountSetupMiddleware)
+
+	//
+	// Routing documentation
+	//
+	r.Handle("/openapi.json", commonChain.Then(env.AppMiddleware(env.OpenAPIHandler(r, V1Prefix)))).Methods("GET")
+	r.Handle("/docs", commonChain.Then(env.AppMiddleware(env.DocumentationHandler))).Methods("GET")
 
 	//
 	// Routing login
 	//
 	r.Handle("/get-token", commonChain.Then(env.AppMiddleware(env.GetTokenHandler))).Methods("POST")
+	r.Handle("/get-token/two-factor", commonChain.Then(env.AppMiddleware(env.GetTokenTwoFactorHandler))).Methods("POST")
+	r.Handle("/{item:users}/{id}/{goal:lock}", secureChain.Then(env.AppMiddleware(env.UnlockUserHandler))).Methods("DELETE")
+	r.Handle("/oidc/{provider}/login", commonChain.Then(env.AppMiddleware(env.OIDCLoginHandler))).Methods("GET")
+	r.Handle("/oidc/{provider}/callback", commonChain.Then(env.AppMiddleware(env.OIDCCallbackHandler))).Methods("GET")
+
+	//
+	// Routing two-factor authentication
+	//
+	r.Handle("/{item:users}/{id}/{goal:two-factor}", setupChain.Then(env.AppMiddleware(env.StartTwoFactorHandler))).Methods("POST")
+	r.Handle("/{item:users}/{id}/{goal:two-factor}/confirm", setupChain.Then(env.AppMiddleware(env.ConfirmTwoFactorHandler))).Methods("POST")
+	// Not using {item:users}, so that the users can disable their own two-factor authentication : the handler checks the rights
+	r.Handle("/users/{id}/two-factor", setupChain.Then(env.AppMiddleware(env.DisableTwoFactorHandler))).Methods("DELETE")
+
+	//
+	// Routing API tokens
+	//
+	// Not using {item:users}, so that the users can manage their own tokens : the handlers check the rights
+	r.Handle("/users/{id}/tokens", secureChain.Then(env.AppMiddleware(env.GetAccessTokensOfUserHandler))).Methods("GET")
+	r.Handle("/users/{id}/tokens", secureChain.Then(env.AppMiddleware(env.CreateAccessTokenHandler))).Methods("POST")
+	r.Handle("/users/{id}/tokens/{token_id}", secureChain.Then(env.AppMiddleware(env.DeleteAccessTokenHandler))).Methods("DELETE")
+
+	//
+	// Routing impersonations
+	//
+	r.Handle("/users/{id}/impersonate", secureChain.Then(env.AppMiddleware(env.StartImpersonationHandler))).Methods("POST")
+	r.Handle("/impersonation", setupChain.Then(env.AppMiddleware(env.StopImpersonationHandler))).Methods("DELETE")
+	r.Handle("/{item:impersonations}", secureChain.Then(env.AppMiddleware(env.GetImpersonationsHandler))).Methods("GET")
+	r.Handle("/{item:impersonations}/{id}/{goal:actions}", secureChain.Then(env.AppMiddleware(env.GetActionsOfImpersonationHandler))).Methods("GET")
 
 	//
 	// Routing comments
@@ -38,6 +104,15 @@ func HandleRoutes(r *mux.Router, env *Env) {
 	r.Handle("/{item:companies}/{id}", secureChain.Then(env.AppMiddleware(env.UpdateCompanyHandler))).Methods("PATCH")
 	r.Handle("/{item:companies}/{id}", secureChain.Then(env.AppMiddleware(env.DeleteCompanyHandler))).Methods("DELETE")
 
+	//
+	// Routing the days off of the companies
+	//
+	r.Handle("/{item:holidays}/{id}", secureChain.Then(env.AppMiddleware(env.GetHolidayHandler))).Methods("GET")
+	r.Handle("/{item:companies}/{id}/{goal:holidays}", secureChain.Then(env.AppMiddleware(env.GetHolidaysOfCompanyHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:holidays}", secureChain.Then(env.AppMiddleware(env.GetHolidaysOfUserHandler))).Methods("GET")
+	r.Handle("/{item:holidays}", secureChain.Then(env.AppMiddleware(env.CreateHolidayHandler))).Methods("POST")
+	r.Handle("/{item:holidays}/{id}", secureChain.Then(env.AppMiddleware(env.DeleteHolidayHandler))).Methods("DELETE")
+
 	//
 	// Routing contracts
 	//
@@ -84,11 +159,69 @@ func HandleRoutes(r *mux.Router, env *Env) {
 	//
 	r.Handle("/{item:schedules}/{id}", secureChain.Then(env.AppMiddleware(env.GetScheduleHandler))).Methods("GET")
 	r.Handle("/{item:users}/{id}/{goal:schedules}", secureChain.Then(env.AppMiddleware(env.GetSchedulesOfUserHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:overlaps}", secureChain.Then(env.AppMiddleware(env.GetOverlapsOfUserHandler))).Methods("GET")
 	r.Handle("/{item:projects}/{id}/{goal:schedules}", secureChain.Then(env.AppMiddleware(env.GetSchedulesOfProjectHandler))).Methods("GET")
 	r.Handle("/{item:schedules}", secureChain.Then(env.AppMiddleware(env.CreateScheduleHandler))).Methods("POST")
 	r.Handle("/{item:schedules}/{id}", secureChain.Then(env.AppMiddleware(env.UpdateScheduleHandler))).Methods("PATCH")
 	r.Handle("/{item:schedules}/{id}", secureChain.Then(env.AppMiddleware(env.DeleteScheduleHandler))).Methods("DELETE")
 
+	//
+	// Routing the breaks within schedules
+	//
+	r.Handle("/{item:breaks}/{id}", secureChain.Then(env.AppMiddleware(env.GetBreakHandler))).Methods("GET")
+	r.Handle("/{item:schedules}/{id}/{goal:breaks}", secureChain.Then(env.AppMiddleware(env.GetBreaksOfScheduleHandler))).Methods("GET")
+	r.Handle("/{item:breaks}", secureChain.Then(env.AppMiddleware(env.CreateBreakHandler))).Methods("POST")
+	r.Handle("/{item:breaks}/{id}", secureChain.Then(env.AppMiddleware(env.UpdateBreakHandler))).Methods("PATCH")
+	r.Handle("/{item:breaks}/{id}", secureChain.Then(env.AppMiddleware(env.DeleteBreakHandler))).Methods("DELETE")
+
+	//
+	// Routing the recurring series of schedules
+	//
+	r.Handle("/{item:series}/{id}", secureChain.Then(env.AppMiddleware(env.GetSeriesHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:series}", secureChain.Then(env.AppMiddleware(env.GetSeriesOfUserHandler))).Methods("GET")
+	r.Handle("/{item:series}", secureChain.Then(env.AppMiddleware(env.CreateSeriesHandler))).Methods("POST")
+	r.Handle("/{item:series}/{id}", secureChain.Then(env.AppMiddleware(env.UpdateSeriesHandler))).Methods("PATCH")
+	r.Handle("/{item:series}/{id}", secureChain.Then(env.AppMiddleware(env.DeleteSeriesHandler))).Methods("DELETE")
+
+	//
+	// Routing the templates of schedules
+	//
+	r.Handle("/{item:templates}/{id}", secureChain.Then(env.AppMiddleware(env.GetTemplateHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:templates}", secureChain.Then(env.AppMiddleware(env.GetTemplatesOfUserHandler))).Methods("GET")
+	r.Handle("/{item:companies}/{id}/{goal:templates}", secureChain.Then(env.AppMiddleware(env.GetTemplatesOfCompanyHandler))).Methods("GET")
+	r.Handle("/{item:templates}", secureChain.Then(env.AppMiddleware(env.CreateTemplateHandler))).Methods("POST")
+	r.Handle("/{item:templates}/{id}", secureChain.Then(env.AppMiddleware(env.UpdateTemplateHandler))).Methods("PATCH")
+	r.Handle("/{item:templates}/{id}", secureChain.Then(env.AppMiddleware(env.DeleteTemplateHandler))).Methods("DELETE")
+	r.Handle("/{item:templates}/{id}/apply", secureChain.Then(env.AppMiddleware(env.ApplyTemplateHandler))).Methods("POST")
+	r.Handle("/{item:templates}/{id}/preview", secureChain.Then(env.AppMiddleware(env.PreviewTemplateHandler))).Methods("POST")
+
+	//
+	// Routing reports
+	//
+	r.Handle("/{item:users}/{id}/{goal:report}", secureChain.Then(env.AppMiddleware(env.GetReportHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:balance}", secureChain.Then(env.AppMiddleware(env.GetBalanceHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:timesheets}", secureChain.Then(env.AppMiddleware(env.GetTimesheetHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:compliance}", secureChain.Then(env.AppMiddleware(env.GetComplianceHandler))).Methods("GET")
+
+	//
+	// Routing the approval of the timesheets
+	//
+	r.Handle("/{item:timesheets}", secureChain.Then(env.AppMiddleware(env.GetTimesheetPeriodsHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:timesheets}/periods", secureChain.Then(env.AppMiddleware(env.GetTimesheetPeriodsOfUserHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:timesheets}/"+periodPattern, secureChain.Then(env.AppMiddleware(env.GetTimesheetPeriodHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:timesheets}/"+periodPattern+"/submit", secureChain.Then(env.AppMiddleware(env.SubmitTimesheetHandler))).Methods("POST")
+	r.Handle("/{item:users}/{id}/{goal:timesheets}/"+periodPattern+"/approve", secureChain.Then(env.AppMiddleware(env.ApproveTimesheetHandler))).Methods("POST")
+	r.Handle("/{item:users}/{id}/{goal:timesheets}/"+periodPattern+"/reject", secureChain.Then(env.AppMiddleware(env.RejectTimesheetHandler))).Methods("POST")
+	r.Handle("/{item:users}/{id}/{goal:timesheets}/"+periodPattern+"/reopen", secureChain.Then(env.AppMiddleware(env.ReopenTimesheetHandler))).Methods("POST")
+
+	//
+	// Routing the lock date of the company
+	//
+	r.Handle("/{item:locks}", secureChain.Then(env.AppMiddleware(env.GetLockDatesHandler))).Methods("GET")
+	r.Handle("/{item:locks}", secureChain.Then(env.AppMiddleware(env.LockHandler))).Methods("POST")
+	r.Handle("/{item:locks}/current", secureChain.Then(env.AppMiddleware(env.GetLockDateHandler))).Methods("GET")
+	r.Handle("/{item:locks}/unlock", secureChain.Then(env.AppMiddleware(env.UnlockHandler))).Methods("POST")
+
 	//
 	// Routing users
 	//
@@ -100,6 +233,31 @@ func HandleRoutes(r *mux.Router, env *Env) {
 	r.Handle("/{item:users}", secureChain.Then(env.AppMiddleware(env.CreateUserHandler))).Methods("POST")
 	r.Handle("/{item:users}/{id}", secureChain.Then(env.AppMiddleware(env.UpdateUserHandler))).Methods("PATCH")
 	r.Handle("/{item:users}/{id}", secureChain.Then(env.AppMiddleware(env.DeleteUserHandler))).Methods("DELETE")
+	r.Handle("/{item:users}/{id}/{goal:password}", setupChain.Then(env.AppMiddleware(env.ChangePasswordHandler))).Methods("POST")
+	r.Handle("/{item:users}/{id}/{goal:export}", secureChain.Then(env.AppMiddleware(env.ExportPersonalDataHandler))).Methods("GET")
+	r.Handle("/{item:users}/{id}/{goal:anonymize}", secureChain.Then(env.AppMiddleware(env.AnonymizeUserHandler))).Methods("POST")
+
+	//
+	// Routing the connected user
+	//
+	r.Handle("/me", meChain.Then(env.AppMiddleware(env.GetUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:schedules}", meChain.Then(env.AppMiddleware(env.GetSchedulesOfUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:series}", meChain.Then(env.AppMiddleware(env.GetSeriesOfUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:templates}", meChain.Then(env.AppMiddleware(env.GetTemplatesOfUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:holidays}", meChain.Then(env.AppMiddleware(env.GetHolidaysOfUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:vacations}", meChain.Then(env.AppMiddleware(env.GetVacationsOfUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:comments}", meChain.Then(env.AppMiddleware(env.GetCommentsOfUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:projects}", meChain.Then(env.AppMiddleware(env.GetProjectsOfUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:role}", meChain.Then(env.AppMiddleware(env.GetRoleOfUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:balance}", meChain.Then(env.AppMiddleware(env.GetBalanceHandler))).Methods("GET")
+	r.Handle("/me/{goal:compliance}", meChain.Then(env.AppMiddleware(env.GetComplianceHandler))).Methods("GET")
+	r.Handle("/me/{goal:timesheets}", meChain.Then(env.AppMiddleware(env.GetTimesheetHandler))).Methods("GET")
+	r.Handle("/me/{goal:timesheets}/periods", meChain.Then(env.AppMiddleware(env.GetTimesheetPeriodsOfUserHandler))).Methods("GET")
+	r.Handle("/me/{goal:timesheets}/"+periodPattern, meChain.Then(env.AppMiddleware(env.GetTimesheetPeriodHandler))).Methods("GET")
+	r.Handle("/me/{goal:timesheets}/"+periodPattern+"/submit", meChain.Then(env.AppMiddleware(env.SubmitTimesheetHandler))).Methods("POST")
+	r.Handle("/me/{goal:timer}", meChain.Then(env.AppMiddleware(env.GetTimerHandler))).Methods("GET")
+	r.Handle("/me/{goal:timer}/start", meChain.Then(env.AppMiddleware(env.StartTimerHandler))).Methods("POST")
+	r.Handle("/me/{goal:timer}/stop", meChain.Then(env.AppMiddleware(env.StopTimerHandler))).Methods("POST")
 
 	//
 	// Routing vacations
@@ -1,11 +1,9 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gorilla/mux"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
@@ -43,19 +41,15 @@ func (env *Env) GetScheduleHandler(w http.ResponseWriter, r *http.Request) *AppE
 		}
 	}
 
-	format := "2006-01-02 15:04:05"
-	startDate := schedule.StartDate.Time.Format(format)
-	endDate := schedule.EndDate.Time.Format(format)
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
 
 	w.Header().Set("Content-type", "application/json;charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 
-	json.NewEncoder(w).Encode(ScheduleIntermediate{
-		ScheduleId: schedule.ScheduleId,
-		ProjectId:  schedule.ProjectId,
-		StartDate:  startDate,
-		EndDate:    endDate,
-	})
+	json.NewEncoder(w).Encode(ScheduleToIntermediate(schedule, location))
 
 	return nil
 }
@@ -92,18 +86,13 @@ func (env *Env) GetSchedulesOfUserHandler(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	format := "2006-01-02 15:04:05"
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
 
 	for _, schedule := range schedules {
-		startDate := schedule.StartDate.Time.Format(format)
-		endDate := schedule.EndDate.Time.Format(format)
-
-		intermediates = append(intermediates, ScheduleIntermediate{
-			ScheduleId: schedule.ScheduleId,
-			ProjectId:  schedule.ProjectId,
-			StartDate:  startDate,
-			EndDate:    endDate,
-		})
+		intermediates = append(intermediates, ScheduleToIntermediate(schedule, location))
 	}
 
 	w.Header().Set("Content-type", "application/json;charset=UTF-8")
@@ -146,18 +135,13 @@ func (env *Env) GetSchedulesOfProjectHandler(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
-	format := "2006-01-02 15:04:05"
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
 
 	for _, schedule := range schedules {
-		startDate := schedule.StartDate.Time.Format(format)
-		endDate := schedule.EndDate.Time.Format(format)
-
-		intermediates = append(intermediates, ScheduleIntermediate{
-			ScheduleId: schedule.ScheduleId,
-			ProjectId:  schedule.ProjectId,
-			StartDate:  startDate,
-			EndDate:    endDate,
-		})
+		intermediates = append(intermediates, ScheduleToIntermediate(schedule, location))
 	}
 
 	w.Header().Set("Content-type", "application/json;charset=UTF-8")
@@ -171,14 +155,13 @@ func (env *Env) GetSchedulesOfProjectHandler(w http.ResponseWriter, r *http.Requ
 //	CreateScheduleHandler
 /*	The handler called by the following endpoint : POST /schedules
 	This method is used to create a new schedule.
+	It is refused when it breaks the working time rules of the contract of one of its users, if the contract blocks them.
 */
 func (env *Env) CreateScheduleHandler(w http.ResponseWriter, r *http.Request) *AppError {
 	var (
 		err        error
 		schedule   model.Schedule
 		scheduleId int64
-		startTime  time.Time
-		endTime    time.Time
 	)
 
 	globals.Log.Debug("CreateScheduleHandler called")
@@ -193,33 +176,28 @@ func (env *Env) CreateScheduleHandler(w http.ResponseWriter, r *http.Request) *A
 		}
 	}
 
-	format := "2006-01-02 15:04:05"
-
-	if startTime, err = time.Parse(format, intermediate.StartDate); err != nil {
-		return &AppError{
-			Error:   err,
-			Message: "Error with the date",
-			Code:    http.StatusInternalServerError,
-		}
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
 	}
 
-	if endTime, err = time.Parse(format, intermediate.EndDate); err != nil {
+	if schedule, err = IntermediateToSchedule(intermediate); err != nil {
 		return &AppError{
 			Error:   err,
 			Message: "Error with the date",
-			Code:    http.StatusInternalServerError,
+			Code:    http.StatusBadRequest,
 		}
 	}
 
-	schedule = model.Schedule{
-		ProjectId: intermediate.ProjectId,
-		StartDate: sql.NullTime{Valid: true, Time: startTime},
-		EndDate:   sql.NullTime{Valid: true, Time: endTime},
+	if appErr := env.complianceError(model.Schedules{schedule}, intermediate.userIds()); appErr != nil {
+		return appErr
 	}
 
 	globals.Log.Debug("Calling CreateSchedule method")
 
-	if scheduleId, err = env.DB.CreateSchedule(schedule); err != nil {
+	if scheduleId, err = env.DB.CreateSchedule(schedule, intermediate.userIds()...); err != nil {
+		if appErr := env.overlapError(r, err); appErr != nil {
+			return appErr
+		}
 		return &AppError{
 			Error:   err,
 			Message: "Error creating the schedule",
@@ -250,14 +228,17 @@ func (env *Env) CreateScheduleHandler(w http.ResponseWriter, r *http.Request) *A
 //	UpdateScheduleHandler
 /*	The handler called by the following endpoint : PATCH /schedules/{id}
 	This method is used to update an existing schedule.
+	An occurrence of a series is changed alone by default : ?scope=following changes the following ones too,
+	and ?scope=series the whole series.
+	It is refused when it breaks the working time rules of the contract of one of its users, if the contract blocks them.
 */
 func (env *Env) UpdateScheduleHandler(w http.ResponseWriter, r *http.Request) *AppError {
 	var (
 		err        error
 		schedule   model.Schedule
+		stored     model.Schedule
+		users      model.Users
 		scheduleId int
-		startTime  time.Time
-		endTime    time.Time
 	)
 
 	globals.Log.Debug("CreateScheduleHandler called")
@@ -272,45 +253,69 @@ func (env *Env) UpdateScheduleHandler(w http.ResponseWriter, r *http.Request) *A
 		}
 	}
 
-	format := "2006-01-02 15:04:05"
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
+	}
 
-	if startTime, err = time.Parse(format, intermediate.StartDate); err != nil {
+	if schedule, err = IntermediateToSchedule(intermediate); err != nil {
 		return &AppError{
 			Error:   err,
 			Message: "Error with the date",
-			Code:    http.StatusInternalServerError,
+			Code:    http.StatusBadRequest,
 		}
 	}
 
-	if endTime, err = time.Parse(format, intermediate.EndDate); err != nil {
+	vars := mux.Vars(r)
+
+	if scheduleId, err = strconv.Atoi(vars["id"]); err != nil {
 		return &AppError{
 			Error:   err,
-			Message: "Error with the date",
+			Message: "Id atoi conversion error",
 			Code:    http.StatusInternalServerError,
 		}
 	}
 
-	schedule = model.Schedule{
-		ProjectId: intermediate.ProjectId,
-		StartDate: sql.NullTime{Valid: true, Time: startTime},
-		EndDate:   sql.NullTime{Valid: true, Time: endTime},
-	}
+	schedule.ScheduleId = int64(scheduleId)
 
-	vars := mux.Vars(r)
+	scope, appErr := scheduleScope(r)
+	if appErr != nil {
+		return appErr
+	}
 
-	if scheduleId, err = strconv.Atoi(vars["id"]); err != nil {
+	if stored, err = env.DB.GetSchedule(schedule.ScheduleId); err != nil {
 		return &AppError{
 			Error:   err,
-			Message: "Id atoi conversion error",
+			Message: "Error when fetching the schedule",
 			Code:    http.StatusInternalServerError,
 		}
 	}
 
-	schedule.ScheduleId = int64(scheduleId)
+	// The change of an occurrence can be applied to the following ones, or to the whole series
+	if scope != ScopeOccurrence {
+		return env.updateOccurrences(w, r, stored, schedule, scope)
+	}
+
+	if users, err = env.DB.GetUsersOfSchedule(schedule.ScheduleId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the users of the schedule",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+	userIds := []int64{}
+	for _, user := range users {
+		userIds = append(userIds, user.UserId)
+	}
+	if appErr := env.complianceError(model.Schedules{schedule}, userIds, schedule.ScheduleId); appErr != nil {
+		return appErr
+	}
 
 	globals.Log.Debug("Calling UpdateSchedule method")
 
 	if schedule, err = env.DB.UpdateSchedule(schedule); err != nil {
+		if appErr := env.overlapError(r, err); appErr != nil {
+			return appErr
+		}
 		return &AppError{
 			Error:   err,
 			Message: "Error when updating the schedule",
@@ -320,18 +325,16 @@ func (env *Env) UpdateScheduleHandler(w http.ResponseWriter, r *http.Request) *A
 
 	globals.Log.Debug("Schedule updated")
 
-	startDate := schedule.StartDate.Time.Format(format)
-	endDate := schedule.EndDate.Time.Format(format)
+	schedule.SeriesId = stored.SeriesId
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
 
 	w.Header().Set("Content-type", "application/json;charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 
-	json.NewEncoder(w).Encode(ScheduleIntermediate{
-		ScheduleId: schedule.ScheduleId,
-		ProjectId:  schedule.ProjectId,
-		StartDate:  startDate,
-		EndDate:    endDate,
-	})
+	json.NewEncoder(w).Encode(ScheduleToIntermediate(schedule, location))
 
 	return nil
 }
@@ -339,10 +342,13 @@ func (env *Env) UpdateScheduleHandler(w http.ResponseWriter, r *http.Request) *A
 //	DeleteScheduleHandler
 /*	The handler called by the following endpoint : DELETE /schedules/{id}
 	This method is used to delete a schedule.
+	An occurrence of a series is deleted alone by default, and is not created again : ?scope=following deletes
+	the following ones too, and ?scope=series the whole series.
 */
 func (env *Env) DeleteScheduleHandler(w http.ResponseWriter, r *http.Request) *AppError {
 	var (
 		err        error
+		stored     model.Schedule
 		scheduleId int
 	)
 
@@ -358,9 +364,33 @@ func (env *Env) DeleteScheduleHandler(w http.ResponseWriter, r *http.Request) *A
 		}
 	}
 
+	scope, appErr := scheduleScope(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	if stored, err = env.DB.GetSchedule(int64(scheduleId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the schedule",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
 	globals.Log.Debug("Calling DeleteSchedule method")
 
-	if err = env.DB.DeleteSchedule(int64(scheduleId)); err != nil {
+	switch {
+	case scope != ScopeOccurrence:
+		if appErr = env.deleteOccurrences(stored, scope); appErr != nil {
+			return appErr
+		}
+	case stored.SeriesId.Valid:
+		// The occurrence becomes an exception of its series, so it is not created again
+		err = env.DB.DeleteOccurrence(stored.ScheduleId)
+	default:
+		err = env.DB.DeleteSchedule(stored.ScheduleId)
+	}
+	if err != nil {
 		return &AppError{
 			Error:   err,
 			Message: "Error when deleting the schedule",
@@ -375,3 +405,30 @@ func (env *Env) DeleteScheduleHandler(w http.ResponseWriter, r *http.Request) *A
 
 	return nil
 }
+
+//	userIds() []int64
+/*	Returns the ids of the users a new schedule is linked to : the one of the request, if any.
+ */
+func (SI ScheduleIntermediate) userIds() []int64 {
+	if SI.UserId == 0 {
+		return nil
+	}
+	return []int64{SI.UserId}
+}
+
+//	scheduleScope(r *http.Request) (string, *AppError)
+/*	Returns the scope of a change of a schedule, given by ?scope= : occurrence by default, following or series.
+ */
+func scheduleScope(r *http.Request) (string, *AppError) {
+	scope := r.URL.Query().Get("scope")
+	switch scope {
+	case "":
+		return ScopeOccurrence, nil
+	case ScopeOccurrence, ScopeFollowing, ScopeSeries:
+		return scope, nil
+	}
+
+	v := &validation{}
+	v.add("scope", "must be "+ScopeOccurrence+", "+ScopeFollowing+" or "+ScopeSeries)
+	return "", v.result()
+}
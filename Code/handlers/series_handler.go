@@ -0,0 +1,814 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// The scopes of a change of an occurrence of a series, given by ?scope= to PATCH and DELETE /schedules/{id}.
+const (
+	ScopeOccurrence = "occurrence"
+	ScopeFollowing  = "following"
+	ScopeSeries     = "series"
+)
+
+//	GetSeriesHandler
+/*	The handler called by the following endpoint : GET /series/{id}
+	This method is used to get a recurring series of schedules, with its saved occurrences.
+*/
+func (env *Env) GetSeriesHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		seriesId int
+	)
+
+	globals.Log.Debug("Calling GetSeriesHandler")
+
+	if seriesId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeSeries(w, r, int64(seriesId))
+}
+
+//	GetSeriesOfUserHandler
+/*	The handler called by the following endpoint : GET /users/{id}/series, and GET /me/series
+	This method is used to get the recurring series of a user, without their occurrences.
+*/
+func (env *Env) GetSeriesOfUserHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		userId   int
+		series   model.ScheduleSeriesList
+		location *time.Location
+	)
+
+	globals.Log.Debug("Calling GetSeriesOfUserHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if series, err = env.DB.GetSeriesOfUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	intermediates := []SeriesIntermediate{}
+	for _, oneSeries := range series {
+		intermediates = append(intermediates, SeriesToIntermediate(oneSeries, nil, location))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(intermediates); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	CreateSeriesHandler
+/*	The handler called by the following endpoint : POST /series
+	This method is used to create schedules repeated by a recurrence rule of RFC 5545, like every monday from 8 to 12.
+	The start and the end give the first occurrence, and the rule is followed in the zone of the series, the one
+	of the user by default. The occurrences are saved as schedules of the user until a horizon after now,
+	and the following ones as the time goes.
+	It is refused when its occurrences break the working time rules of the contract of the user, if the contract blocks them.
+*/
+func (env *Env) CreateSeriesHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err         error
+		series      model.ScheduleSeries
+		occurrences model.Schedules
+		seriesId    int64
+	)
+
+	globals.Log.Debug("Calling CreateSeriesHandler")
+
+	intermediate := SeriesIntermediate{}
+	if err = json.NewDecoder(r.Body).Decode(&intermediate); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
+	}
+
+	if series, err = env.readSeries(intermediate); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when reading the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	series.GeneratedUntil = seriesHorizon()
+	if occurrences, err = env.occurrencesOf(series, series.StartDate); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when reading the recurrence rule",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.complianceError(occurrences, []int64{series.UserId}); appErr != nil {
+		return appErr
+	}
+
+	if seriesId, err = env.DB.CreateSeries(series, occurrences); err != nil {
+		if appErr := env.overlapError(r, err); appErr != nil {
+			return appErr
+		}
+		return &AppError{
+			Error:   err,
+			Message: "Error when creating the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeSeries(w, r, seriesId)
+}
+
+//	UpdateSeriesHandler
+/*	The handler called by the following endpoint : PATCH /series/{id}
+	This method is used to change a whole series : its occurrences from now, or from the lock date when it is later,
+	are created again from the new rule, even the ones that were changed alone. When the series has earlier occurrences,
+	it ends before this date with them, and the new occurrences become a new series.
+*/
+func (env *Env) UpdateSeriesHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		seriesId int
+		stored   model.ScheduleSeries
+		series   model.ScheduleSeries
+	)
+
+	globals.Log.Debug("Calling UpdateSeriesHandler")
+
+	if seriesId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if stored, err = env.DB.GetSeries(int64(seriesId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	intermediate := SeriesIntermediate{}
+	if err = json.NewDecoder(r.Body).Decode(&intermediate); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
+	}
+
+	if series, err = env.readSeries(intermediate); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when reading the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+	series.SeriesId = int64(seriesId)
+
+	return env.replaceSeries(w, r, stored, series)
+}
+
+//	DeleteSeriesHandler
+/*	The handler called by the following endpoint : DELETE /series/{id}
+	This method is used to delete a series, with its occurrences from now, or from the lock date when it is later.
+	The earlier ones are kept as schedules of the user.
+*/
+func (env *Env) DeleteSeriesHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		seriesId int
+	)
+
+	globals.Log.Debug("Calling DeleteSeriesHandler")
+
+	if seriesId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	var from time.Time
+	if _, err = env.DB.GetSeries(int64(seriesId)); err == nil {
+		if from, err = env.historyEnd(); err == nil {
+			err = env.DB.DeleteSeries(int64(seriesId), from)
+		}
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when deleting the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+//	ExtendSeries(Now time.Time) (int, error)
+/*	Saves the occurrences of the series that reach the horizon after a date, and returns how many series were extended.
+	The occurrences that overlap another schedule of the user, are locked, or break the working time rules
+	of his contract when it blocks them, are skipped as exceptions.
+*/
+func (env *Env) ExtendSeries(Now time.Time) (int, error) {
+	horizon := Now.Add(globals.SeriesHorizon)
+
+	series, err := env.DB.GetSeriesToExtend(horizon)
+	if err != nil {
+		return 0, err
+	}
+
+	extended := 0
+	for _, oneSeries := range series {
+		from := oneSeries.GeneratedUntil
+		if from.Before(oneSeries.StartDate) {
+			from = oneSeries.StartDate
+		}
+		oneSeries.GeneratedUntil = horizon
+
+		occurrences, err := env.occurrencesOf(oneSeries, from)
+		if err == nil {
+			var refused []time.Time
+			if occurrences, refused, err = env.compliantOccurrences(oneSeries.UserId, occurrences); err == nil {
+				oneSeries.SetExceptions(append(oneSeries.Exceptions(), refused...))
+				_, err = env.DB.ExtendSeries(oneSeries, occurrences)
+			}
+		}
+		if err != nil {
+			return extended, err
+		}
+		extended++
+	}
+
+	return extended, nil
+}
+
+//	AutoExtendSeries()
+/*	Extends the series regularly, as the horizon moves. Never returns : it is started in its own goroutine.
+ */
+func (env *Env) AutoExtendSeries() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if extended, err := env.ExtendSeries(now); err != nil {
+			globals.Log.Warn("Could not extend the series : " + err.Error())
+		} else if extended > 0 {
+			globals.Log.Debug(strconv.Itoa(extended) + " series extended")
+		}
+	}
+}
+
+//	updateOccurrences(w http.ResponseWriter, r *http.Request, Stored model.Schedule, Changed model.Schedule, Scope string) *AppError
+/*	Applies the change of an occurrence to the following ones, or to the whole series, and answers with the series
+	that holds the changed occurrence. The following ones become a new series, and the old one ends before them.
+*/
+func (env *Env) updateOccurrences(w http.ResponseWriter, r *http.Request, Stored model.Schedule, Changed model.Schedule, Scope string) *AppError {
+	series, rule, location, appErr := env.seriesOfOccurrence(Stored)
+	if appErr != nil {
+		return appErr
+	}
+
+	// The dates of the series move as much as the occurrence, and its days with it
+	from := Stored.StartDate.Time
+	shift := Changed.StartDate.Time.Sub(from)
+	length := Changed.EndDate.Time.Sub(Changed.StartDate.Time)
+	days := daysBetween(from, Changed.StartDate.Time, location)
+
+	following := series
+	following.ProjectId = Changed.ProjectId
+	following.GeneratedUntil = seriesHorizon()
+	exceptions := []time.Time{}
+	for _, exception := range series.Exceptions() {
+		if Scope == ScopeSeries || !exception.Before(from) {
+			exceptions = append(exceptions, exception.Add(shift))
+		}
+	}
+	following.SetExceptions(exceptions)
+
+	if Scope == ScopeSeries || !from.After(series.StartDate) {
+		following.StartDate = series.StartDate.Add(shift)
+		following.EndDate = following.StartDate.Add(length)
+		following.RecurrenceRule = rule.Shift(days).String()
+		return env.replaceSeries(w, r, series, following)
+	}
+
+	before, after := splitRule(rule, series.StartDate, location, from)
+	series.RecurrenceRule = before.String()
+	following.StartDate = Changed.StartDate.Time
+	following.EndDate = Changed.EndDate.Time
+	following.RecurrenceRule = after.Shift(days).String()
+
+	occurrences, err := env.occurrencesOf(following, following.StartDate)
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when reading the recurrence rule",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.seriesComplianceError(series, from, occurrences); appErr != nil {
+		return appErr
+	}
+
+	seriesId, err := env.DB.SplitSeries(series, from, following, occurrences)
+	if err != nil {
+		if appErr := env.overlapError(r, err); appErr != nil {
+			return appErr
+		}
+		return &AppError{
+			Error:   err,
+			Message: "Error when changing the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeSeries(w, r, seriesId)
+}
+
+//	deleteOccurrences(Stored model.Schedule, Scope string) *AppError
+/*	Deletes an occurrence of a series with the following ones, so the series ends before them, or the whole series.
+ */
+func (env *Env) deleteOccurrences(Stored model.Schedule, Scope string) *AppError {
+	series, rule, location, appErr := env.seriesOfOccurrence(Stored)
+	if appErr != nil {
+		return appErr
+	}
+
+	var (
+		err  error
+		from time.Time
+	)
+	if Scope == ScopeSeries || !Stored.StartDate.Time.After(series.StartDate) {
+		if from, err = env.historyEnd(); err == nil {
+			err = env.DB.DeleteSeries(series.SeriesId, from)
+		}
+	} else {
+		before, _ := splitRule(rule, series.StartDate, location, Stored.StartDate.Time)
+		series.RecurrenceRule = before.String()
+		err = env.DB.EndSeries(series, Stored.StartDate.Time)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when deleting the occurrences",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	replaceSeries(w http.ResponseWriter, r *http.Request, Stored model.ScheduleSeries, Series model.ScheduleSeries) *AppError
+/*	Saves a whole series with its new occurrences from now, or from the lock date when it is later, and answers with it.
+	When the saved series has earlier occurrences, it ends before this date with them, as the history of its old rule,
+	and the new occurrences become a new series. Otherwise, the series is changed.
+	It is refused when they break the working time rules of the contract of the user, if the contract blocks them.
+*/
+func (env *Env) replaceSeries(w http.ResponseWriter, r *http.Request, Stored model.ScheduleSeries, Series model.ScheduleSeries) *AppError {
+	var (
+		err      error
+		from     time.Time
+		saved    model.Schedules
+		seriesId int64
+	)
+
+	if from, err = env.historyEnd(); err == nil {
+		saved, err = env.DB.GetSchedulesOfSeries(Stored.SeriesId)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// The occurrences before the date are kept with the old series, which ends before it
+	history := len(saved) > 0 && saved[0].StartDate.Time.Before(from)
+	if history {
+		rule, location, err := seriesRule(Stored)
+		if err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when reading the recurrence rule",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+		before, _ := splitRule(rule, Stored.StartDate, location, from)
+		Stored.RecurrenceRule = before.String()
+	}
+
+	following, appErr := seriesFrom(Series, from)
+	if appErr != nil {
+		return appErr
+	}
+	following.GeneratedUntil = seriesHorizon()
+
+	occurrences, err := env.occurrencesOf(following, following.StartDate)
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when reading the recurrence rule",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.seriesComplianceError(Stored, from, occurrences); appErr != nil {
+		return appErr
+	}
+
+	if history {
+		seriesId, err = env.DB.SplitSeries(Stored, from, following, occurrences)
+	} else {
+		seriesId = Stored.SeriesId
+		err = env.DB.UpdateSeries(following, from, occurrences)
+	}
+	if err != nil {
+		if appErr := env.overlapError(r, err); appErr != nil {
+			return appErr
+		}
+		return &AppError{
+			Error:   err,
+			Message: "Error when changing the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeSeries(w, r, seriesId)
+}
+
+//	seriesFrom(Series model.ScheduleSeries, From time.Time) (model.ScheduleSeries, *AppError)
+/*	Returns the part of a series left from a date : it starts at its first occurrence from the date, with what is left
+	of its COUNT and its following exceptions. Returns a 422 error on the rule if it gives no occurrence from the date.
+*/
+func seriesFrom(Series model.ScheduleSeries, From time.Time) (model.ScheduleSeries, *AppError) {
+	if !Series.StartDate.Before(From) {
+		return Series, nil
+	}
+
+	rule, location, err := seriesRule(Series)
+	if err != nil {
+		return model.ScheduleSeries{}, &AppError{
+			Error:   err,
+			Message: "Error when reading the recurrence rule",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	starts := rule.Occurrences(Series.StartDate, location, nil, From, From.Add(globals.SeriesHorizon).AddDate(1, 0, 0))
+	if len(starts) == 0 {
+		v := &validation{}
+		v.add("recurrence_rule", "must give an occurrence from now, and from the lock date")
+		return model.ScheduleSeries{}, v.result()
+	}
+
+	_, after := splitRule(rule, Series.StartDate, location, From)
+	length := Series.EndDate.Sub(Series.StartDate)
+	Series.StartDate, Series.EndDate = starts[0], starts[0].Add(length)
+	Series.RecurrenceRule = after.String()
+
+	exceptions := []time.Time{}
+	for _, exception := range Series.Exceptions() {
+		if !exception.Before(From) {
+			exceptions = append(exceptions, exception)
+		}
+	}
+	Series.SetExceptions(exceptions)
+
+	return Series, nil
+}
+
+//	seriesRule(Series model.ScheduleSeries) (globals.Recurrence, *time.Location, error)
+/*	Returns the rule of a series, and the zone it is followed in.
+ */
+func seriesRule(Series model.ScheduleSeries) (globals.Recurrence, *time.Location, error) {
+	location, err := globals.LoadTimeZone(Series.TimeZone)
+	if err != nil {
+		return globals.Recurrence{}, nil, err
+	}
+
+	rule, err := globals.ParseRecurrence(Series.RecurrenceRule, location)
+	if err != nil {
+		return globals.Recurrence{}, nil, err
+	}
+
+	return rule, location, nil
+}
+
+//	seriesOfOccurrence(Occurrence model.Schedule) (model.ScheduleSeries, globals.Recurrence, *time.Location, *AppError)
+/*	Returns the series of an occurrence, with its rule and its zone.
+	Returns a 422 error on the scope if the schedule is not in a series.
+*/
+func (env *Env) seriesOfOccurrence(Occurrence model.Schedule) (model.ScheduleSeries, globals.Recurrence, *time.Location, *AppError) {
+	if !Occurrence.SeriesId.Valid {
+		v := &validation{}
+		v.add("scope", "can only be "+ScopeOccurrence+" for a schedule that is not in a series")
+		return model.ScheduleSeries{}, globals.Recurrence{}, nil, v.result()
+	}
+
+	series, err := env.DB.GetSeries(Occurrence.SeriesId.Int64)
+	if err != nil {
+		return model.ScheduleSeries{}, globals.Recurrence{}, nil, &AppError{
+			Error:   err,
+			Message: "Error when fetching the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, err := globals.LoadTimeZone(series.TimeZone)
+	if err == nil {
+		var rule globals.Recurrence
+		if rule, err = globals.ParseRecurrence(series.RecurrenceRule, location); err == nil {
+			return series, rule, location, nil
+		}
+	}
+	return model.ScheduleSeries{}, globals.Recurrence{}, nil, &AppError{
+		Error:   err,
+		Message: "Error when reading the recurrence rule",
+		Code:    http.StatusInternalServerError,
+	}
+}
+
+//	readSeries(Intermediate SeriesIntermediate) (model.ScheduleSeries, error)
+/*	Returns the series of a request, with its dates in UTC and its zone.
+	The request must have been validated.
+*/
+func (env *Env) readSeries(Intermediate SeriesIntermediate) (model.ScheduleSeries, error) {
+	var (
+		err      error
+		series   model.ScheduleSeries
+		location *time.Location
+	)
+
+	if location, err = env.seriesLocation(Intermediate); err != nil {
+		return model.ScheduleSeries{}, err
+	}
+
+	series = model.ScheduleSeries{
+		ProjectId: Intermediate.ProjectId,
+		UserId:    Intermediate.UserId,
+		TimeZone:  location.String(),
+	}
+	if series.StartDate, err = globals.ParseDate(Intermediate.StartDate); err != nil {
+		return model.ScheduleSeries{}, err
+	}
+	if series.EndDate, err = globals.ParseDate(Intermediate.EndDate); err != nil {
+		return model.ScheduleSeries{}, err
+	}
+
+	rule, err := globals.ParseRecurrence(Intermediate.RecurrenceRule, location)
+	if err != nil {
+		return model.ScheduleSeries{}, err
+	}
+	series.RecurrenceRule = rule.String()
+
+	exceptions := []time.Time{}
+	for _, value := range Intermediate.ExceptionDates {
+		exception, err := globals.ParseDate(value)
+		if err != nil {
+			return model.ScheduleSeries{}, err
+		}
+		exceptions = append(exceptions, exception)
+	}
+	series.SetExceptions(exceptions)
+
+	return series, nil
+}
+
+//	seriesLocation(Series SeriesIntermediate) (*time.Location, error)
+/*	Returns the zone the rule of a series is followed in : the one of the request, or the one of the user.
+ */
+func (env *Env) seriesLocation(Series SeriesIntermediate) (*time.Location, error) {
+	if Series.TimeZone != "" {
+		return globals.LoadTimeZone(Series.TimeZone)
+	}
+	return env.userLocation(Series.UserId)
+}
+
+//	writeSeries(w http.ResponseWriter, r *http.Request, SeriesId int64) *AppError
+/*	Answers with a series and its saved occurrences, in the zone of the connected user.
+ */
+func (env *Env) writeSeries(w http.ResponseWriter, r *http.Request, SeriesId int64) *AppError {
+	var (
+		err       error
+		series    model.ScheduleSeries
+		schedules model.Schedules
+	)
+
+	if series, err = env.DB.GetSeries(SeriesId); err == nil {
+		schedules, err = env.DB.GetSchedulesOfSeries(SeriesId)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(SeriesToIntermediate(series, schedules, location)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	occurrencesOf(Series model.ScheduleSeries, From time.Time) (model.Schedules, error)
+/*	Returns the occurrences of a series that start from a date, before the date it is saved until.
+	The occurrences that would start on a day off of its user are left out.
+*/
+func (env *Env) occurrencesOf(Series model.ScheduleSeries, From time.Time) (model.Schedules, error) {
+	rule, location, err := seriesRule(Series)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays, err := env.holidaysOfUser(Series.UserId, From, Series.GeneratedUntil, location)
+	if err != nil {
+		return nil, err
+	}
+
+	length := Series.EndDate.Sub(Series.StartDate)
+	occurrences := model.Schedules{}
+	for _, start := range rule.Occurrences(Series.StartDate, location, Series.Exceptions(), From, Series.GeneratedUntil) {
+		if _, off := holidays[start.In(location).Format(globals.DayLayout)]; off {
+			continue
+		}
+		occurrences = append(occurrences, model.Schedule{
+			ProjectId: Series.ProjectId,
+			StartDate: sql.NullTime{Valid: true, Time: start.UTC()},
+			EndDate:   sql.NullTime{Valid: true, Time: start.Add(length).UTC()},
+		})
+	}
+
+	return occurrences, nil
+}
+
+//	splitRule(Rule globals.Recurrence, Start time.Time, Location *time.Location, From time.Time) (globals.Recurrence, globals.Recurrence)
+/*	Splits the rule of a series at a date : the first rule ends before it, and the second one gives the occurrences
+	left from it, as a COUNT is shared between both.
+*/
+func splitRule(Rule globals.Recurrence, Start time.Time, Location *time.Location, From time.Time) (globals.Recurrence, globals.Recurrence) {
+	before, after := Rule, Rule
+
+	if Rule.Count > 0 {
+		before.Count = Rule.CountBefore(Start, Location, From)
+		after.Count = Rule.Count - before.Count
+		if after.Count < 1 {
+			after.Count = 1
+		}
+	} else {
+		before.Until = From.Add(-time.Second)
+	}
+
+	return before, after
+}
+
+//	seriesComplianceError(Series model.ScheduleSeries, From time.Time, Occurrences model.Schedules) *AppError
+/*	Refuses the new occurrences of a series, instead of its saved ones from a date, when they break the working time
+	rules of the contract of its user, if the contract blocks them.
+*/
+func (env *Env) seriesComplianceError(Series model.ScheduleSeries, From time.Time, Occurrences model.Schedules) *AppError {
+	saved, err := env.DB.GetSchedulesOfSeries(Series.SeriesId)
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the occurrences of the series",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	replaced := []int64{}
+	for _, occurrence := range saved {
+		if !occurrence.StartDate.Time.Before(From) {
+			replaced = append(replaced, occurrence.ScheduleId)
+		}
+	}
+
+	return env.complianceError(Occurrences, []int64{Series.UserId}, replaced...)
+}
+
+//	compliantOccurrences(UserId int64, Occurrences model.Schedules) (model.Schedules, []time.Time, error)
+/*	Returns the occurrences a user can work one after the other without breaking the working time rules of his contract,
+	and the starts of the other ones, refused if his contract blocks them.
+*/
+func (env *Env) compliantOccurrences(UserId int64, Occurrences model.Schedules) (model.Schedules, []time.Time, error) {
+	accepted := model.Schedules{}
+	refused := []time.Time{}
+
+	for _, occurrence := range Occurrences {
+		if appErr := env.complianceError(append(model.Schedules{occurrence}, accepted...), []int64{UserId}); appErr == nil {
+			accepted = append(accepted, occurrence)
+		} else if appErr.ErrorCode == ErrorComplianceViolation {
+			refused = append(refused, occurrence.StartDate.Time)
+		} else {
+			return nil, nil, appErr.Error
+		}
+	}
+
+	return accepted, refused, nil
+}
+
+//	historyEnd() (time.Time, error)
+/*	Returns the date from which the occurrences of a whole series are created again or deleted : now, or the lock date
+	when it is later. The earlier ones are kept as its history.
+*/
+func (env *Env) historyEnd() (time.Time, error) {
+	from := time.Now()
+
+	lock, err := env.DB.GetLockDate()
+	if err != nil && err != sql.ErrNoRows {
+		return time.Time{}, err
+	}
+	if lock.LockDate.Valid && lock.LockDate.Time.After(from) {
+		from = lock.LockDate.Time
+	}
+
+	return from, nil
+}
+
+//	daysBetween(From time.Time, To time.Time, Location *time.Location) int
+/*	Returns how many days there are from the day of a date to the day of another one, in a zone.
+ */
+func daysBetween(From time.Time, To time.Time, Location *time.Location) int {
+	from, to := From.In(Location), To.In(Location)
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+//	seriesHorizon() time.Time
+/*	Returns the date until which the occurrences of the series are saved.
+ */
+func seriesHorizon() time.Time {
+	return time.Now().Add(globals.SeriesHorizon)
+}
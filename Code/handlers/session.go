@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+// How long a session lasts after the login.
+const sessionLifetime = 8 * time.Hour
+
+// The cookie and the header of the CSRF token : the web application reads the cookie and copies it in the header.
+const (
+	csrfCookieName = "csrf_token"
+	csrfHeaderName = "X-CSRF-Token"
+)
+
+// The methods the web applications of the other origins can use.
+var corsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodPut, http.MethodDelete}
+
+//	issueSession(w http.ResponseWriter, Claims jwt.MapClaims, Expires time.Time) (string, error)
+/*	Signs a session token with the given claims, and sets it in the "token" cookie, which the scripts of the pages can't read.
+	A new CSRF token is put in the claims and in the "csrf_token" cookie, which the web application copies
+	in the X-CSRF-Token header of the requests that modify something (double-submit cookie).
+	Returns the session token.
+*/
+func issueSession(w http.ResponseWriter, Claims jwt.MapClaims, Expires time.Time) (string, error) {
+	var (
+		err         error
+		csrfToken   string
+		tokenString string
+	)
+
+	if csrfToken, err = authentication.RandomURLToken(); err != nil {
+		return "", err
+	}
+
+	Claims["csrf"] = csrfToken
+	Claims["expiration"] = Expires
+	Claims["exp"] = Expires.Unix()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims)
+	if tokenString, err = token.SignedString(globals.TokenSignKey); err != nil {
+		return "", err
+	}
+
+	http.SetCookie(w, sessionCookie("token", tokenString, Expires, true))
+	http.SetCookie(w, sessionCookie(csrfCookieName, csrfToken, Expires, false))
+
+	return tokenString, nil
+}
+
+//	clearSession(w http.ResponseWriter)
+/*	Deletes the session cookies.
+ */
+func clearSession(w http.ResponseWriter) {
+	http.SetCookie(w, sessionCookie("token", "", time.Unix(0, 0), true))
+	http.SetCookie(w, sessionCookie(csrfCookieName, "", time.Unix(0, 0), false))
+}
+
+//	sessionCookie(Name string, Value string, Expires time.Time, HttpOnly bool) *http.Cookie
+/*	Returns a session cookie, following the configured rules (see globals.CookieRules).
+ */
+func sessionCookie(Name string, Value string, Expires time.Time, HttpOnly bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     Name,
+		Value:    Value,
+		Path:     "/",
+		Domain:   globals.SessionCookies.Domain,
+		Expires:  Expires,
+		MaxAge:   int(time.Until(Expires).Seconds()),
+		Secure:   globals.SessionCookies.Secure,
+		HttpOnly: HttpOnly,
+		SameSite: globals.SessionCookies.SameSite,
+	}
+
+	if Value == "" || cookie.MaxAge <= 0 {
+		cookie.MaxAge = -1
+	}
+
+	return cookie
+}
+
+//	validCSRFToken(r *http.Request, Claims jwt.MapClaims) bool
+/*	Verifies the CSRF token of a request authenticated with the session cookie.
+	The requests that can't modify anything don't need it. For the others, the X-CSRF-Token header
+	must be the same as the "csrf_token" cookie, and both must be the one of the session.
+*/
+func validCSRFToken(r *http.Request, Claims jwt.MapClaims) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return true
+	}
+
+	expected, _ := Claims["csrf"].(string)
+	header := r.Header.Get(csrfHeaderName)
+	cookie, err := r.Cookie(csrfCookieName)
+	if expected == "" || header == "" || err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(header), []byte(expected)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(expected)) == 1
+}
+
+//	PreflightHandler
+/*	The handler called by the following endpoint : OPTIONS on every path.
+	The CORS headers of the preflight requests are set by HeadersMiddleware, for the allowed origins only.
+*/
+func (env *Env) PreflightHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	w.Header().Set("Allow", strings.Join(append(corsMethods, http.MethodOptions), ", "))
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,582 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// The states of the slots of a template applied to a week.
+const (
+	SlotFree     = "free"
+	SlotCreated  = "created"
+	SlotConflict = "conflict"
+	SlotVacation = "vacation"
+	SlotSkipped  = "skipped"
+	SlotHoliday  = "holiday"
+	SlotLocked   = "locked"
+)
+
+//	GetTemplateHandler
+/*	The handler called by the following endpoint : GET /templates/{id}
+	This method is used to get a template of schedules with its time slots.
+*/
+func (env *Env) GetTemplateHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		template model.ScheduleTemplate
+	)
+
+	globals.Log.Debug("Calling GetTemplateHandler")
+
+	if template, err = env.templateOfRequest(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the template",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireTemplateAccess(r, template, false); appErr != nil {
+		return appErr
+	}
+
+	return env.writeTemplate(w, template)
+}
+
+//	GetTemplatesOfUserHandler
+/*	The handler called by the following endpoint : GET /users/{id}/templates, and GET /me/templates
+	This method is used to get the templates a user can apply : his own ones, and the ones of his companies.
+	The templates of a user are seen by him and by the managers.
+*/
+func (env *Env) GetTemplatesOfUserHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err       error
+		userId    int
+		templates model.ScheduleTemplates
+	)
+
+	globals.Log.Debug("Calling GetTemplatesOfUserHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireReportAccess(r, int64(userId)); appErr != nil {
+		return appErr
+	}
+
+	if templates, err = env.DB.GetTemplatesOfUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the templates",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeTemplates(w, templates)
+}
+
+//	GetTemplatesOfCompanyHandler
+/*	The handler called by the following endpoint : GET /companies/{id}/templates
+	This method is used to get the templates shared with the users of a company.
+*/
+func (env *Env) GetTemplatesOfCompanyHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err       error
+		companyId int
+		templates model.ScheduleTemplates
+	)
+
+	globals.Log.Debug("Calling GetTemplatesOfCompanyHandler")
+
+	if companyId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if templates, err = env.DB.GetTemplatesOfCompany(int64(companyId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the templates",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeTemplates(w, templates)
+}
+
+//	CreateTemplateHandler
+/*	The handler called by the following endpoint : POST /templates
+	This method is used to save a typical week of schedules, for a user or for the users of a company.
+	A user saves his own templates, and the managers the ones of the other users and of the companies.
+*/
+func (env *Env) CreateTemplateHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err        error
+		templateId int64
+		template   model.ScheduleTemplate
+	)
+
+	globals.Log.Debug("Calling CreateTemplateHandler")
+
+	intermediate := TemplateIntermediate{}
+	if err = json.NewDecoder(r.Body).Decode(&intermediate); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
+	}
+
+	template = IntermediateToTemplate(intermediate)
+	if appErr := env.requireTemplateAccess(r, template, true); appErr != nil {
+		return appErr
+	}
+
+	if templateId, err = env.DB.CreateTemplate(template, templateSlots(intermediate.Slots)); err == nil {
+		template, err = env.DB.GetTemplate(templateId)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when creating the template",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeTemplate(w, template)
+}
+
+//	UpdateTemplateHandler
+/*	The handler called by the following endpoint : PATCH /templates/{id}
+	This method is used to rename a template and replace its time slots. It stays with its user or its company,
+	and the schedules already filled from it don't change.
+*/
+func (env *Env) UpdateTemplateHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		template model.ScheduleTemplate
+	)
+
+	globals.Log.Debug("Calling UpdateTemplateHandler")
+
+	if template, err = env.templateOfRequest(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the template",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireTemplateAccess(r, template, true); appErr != nil {
+		return appErr
+	}
+
+	intermediate := TemplateIntermediate{}
+	if err = json.NewDecoder(r.Body).Decode(&intermediate); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+	intermediate.UserId, intermediate.CompanyId = template.UserId.Int64, template.CompanyId.Int64
+
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
+	}
+
+	template.TemplateName = intermediate.TemplateName
+	if err = env.DB.UpdateTemplate(template, templateSlots(intermediate.Slots)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when updating the template",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeTemplate(w, template)
+}
+
+//	DeleteTemplateHandler
+/*	The handler called by the following endpoint : DELETE /templates/{id}
+	This method is used to delete a template. The schedules filled from it are kept.
+*/
+func (env *Env) DeleteTemplateHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		template model.ScheduleTemplate
+	)
+
+	globals.Log.Debug("Calling DeleteTemplateHandler")
+
+	if template, err = env.templateOfRequest(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the template",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireTemplateAccess(r, template, true); appErr != nil {
+		return appErr
+	}
+
+	if err = env.DB.DeleteTemplate(template.TemplateId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when deleting the template",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+//	ApplyTemplateHandler
+/*	The handler called by the following endpoint : POST /templates/{id}/apply
+	This method is used to fill a week of a user with the schedules of a template, at the hours of the zone of the user.
+	The slots that overlap his other schedules are not created and are returned with their conflicts, as the ones on
+	his vacations, on the skipped days, on his days off, or locked.
+	It is refused when the created schedules break the working time rules of the contract of the user, if the contract blocks them.
+*/
+func (env *Env) ApplyTemplateHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	globals.Log.Debug("Calling ApplyTemplateHandler")
+
+	return env.applyTemplate(w, r, true)
+}
+
+//	PreviewTemplateHandler
+/*	The handler called by the following endpoint : POST /templates/{id}/preview
+	This method is used to know what applying a template to a week would do, without creating any schedule.
+*/
+func (env *Env) PreviewTemplateHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	globals.Log.Debug("Calling PreviewTemplateHandler")
+
+	return env.applyTemplate(w, r, false)
+}
+
+//	applyTemplate(w http.ResponseWriter, r *http.Request, Save bool) *AppError
+/*	Places the slots of a template on a week of a user, and creates the free ones if asked to.
+	Either all the free slots are created, or none if another schedule took their hours meanwhile.
+*/
+func (env *Env) applyTemplate(w http.ResponseWriter, r *http.Request, Save bool) *AppError {
+	var (
+		err             error
+		template        model.ScheduleTemplate
+		slots           model.TemplateSlots
+		userLocation    *time.Location
+		monday          time.Time
+		vacationProject model.Project
+		overlapErr      *datastores.OverlapError
+		lockedErr       *datastores.LockedError
+	)
+
+	if template, err = env.templateOfRequest(r); err == nil {
+		slots, err = env.DB.GetSlotsOfTemplate(template.TemplateId)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the template",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	application := TemplateApplication{}
+	if err = json.NewDecoder(r.Body).Decode(&application); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if appErr := env.validate(&application).result(); appErr != nil {
+		return appErr
+	}
+
+	// The week of the connected user by default
+	if application.UserId == 0 {
+		if application.UserId, _, err = contextUser(r); err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Id atoi conversion error",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+	}
+
+	if appErr := env.requireReportAccess(r, application.UserId); appErr != nil {
+		return appErr
+	}
+	if appErr := env.requireTemplateAccess(r, template, false); appErr != nil {
+		return appErr
+	}
+
+	if userLocation, err = env.userLocation(application.UserId); err == nil {
+		if monday, err = globals.ParseWeek(application.Week, userLocation); err == nil {
+			vacationProject, err = env.DB.GetVacationProject()
+		}
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when reading the week",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	holidays, err := env.holidaysOfUser(application.UserId, monday, globals.AddDays(monday, 7, userLocation), userLocation)
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the days off of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	skipped := map[string]bool{}
+	for _, day := range application.SkipDays {
+		skipped[day] = true
+	}
+
+	result := TemplateApplicationResult{
+		TemplateId: template.TemplateId,
+		UserId:     application.UserId,
+		Week:       application.Week,
+		Applied:    Save,
+		Slots:      []AppliedSlot{},
+	}
+	free := model.Schedules{}
+	freeSlots := []int{}
+
+	for _, slot := range slots {
+		schedule, err := slotSchedule(slot, monday, userLocation)
+		if err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when placing the slots of the template",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+
+		applied := AppliedSlot{
+			ProjectId: slot.ProjectId,
+			StartDate: globals.FormatDate(schedule.StartDate.Time, location),
+			EndDate:   globals.FormatDate(schedule.EndDate.Time, location),
+			Status:    SlotFree,
+		}
+
+		day := schedule.StartDate.Time.In(userLocation).Format(globals.DayLayout)
+		if skipped[day] {
+			applied.Status = SlotSkipped
+		} else if _, off := holidays[day]; off {
+			applied.Status = SlotHoliday
+		} else if err = env.DB.CheckSchedule(schedule, application.UserId); errors.As(err, &overlapErr) {
+			// The slots on the vacations are left out, the other overlaps are conflicts
+			applied.Status = SlotVacation
+			for _, conflict := range overlapErr.Schedules {
+				if conflict.ProjectId != vacationProject.ProjectId {
+					applied.Status = SlotConflict
+				}
+				applied.Conflicts = append(applied.Conflicts, ScheduleToIntermediate(conflict, location))
+			}
+		} else if errors.As(err, &lockedErr) {
+			applied.Status = SlotLocked
+		} else if err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when checking the slots of the template",
+				Code:    http.StatusInternalServerError,
+			}
+		} else {
+			free = append(free, schedule)
+			freeSlots = append(freeSlots, len(result.Slots))
+		}
+
+		result.Slots = append(result.Slots, applied)
+	}
+
+	if Save && len(free) > 0 {
+		if appErr := env.complianceError(free, []int64{application.UserId}); appErr != nil {
+			return appErr
+		}
+
+		scheduleIds, err := env.DB.CreateSchedules(free, application.UserId)
+		if err != nil {
+			if appErr := env.overlapError(r, err); appErr != nil {
+				return appErr
+			}
+			return &AppError{
+				Error:   err,
+				Message: "Error when creating the schedules",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+		for i, index := range freeSlots {
+			result.Slots[index].Status = SlotCreated
+			result.Slots[index].ScheduleId = scheduleIds[i]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(result); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the slots",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	requireTemplateAccess(r *http.Request, Template model.ScheduleTemplate, Write bool) *AppError
+/*	Verifies the connected user can use a template, or change it. The templates of a user are his own and the
+	managers' ones. The templates of a company are applied by everyone, and changed by the managers.
+*/
+func (env *Env) requireTemplateAccess(r *http.Request, Template model.ScheduleTemplate, Write bool) *AppError {
+	if Template.UserId.Valid {
+		return env.requireReportAccess(r, Template.UserId.Int64)
+	}
+	if Write {
+		return env.requireManager(r, "Managing the templates of a company")
+	}
+	return nil
+}
+
+//	templateOfRequest(r *http.Request) (model.ScheduleTemplate, error)
+/*	Returns the template of the id of a route.
+ */
+func (env *Env) templateOfRequest(r *http.Request) (model.ScheduleTemplate, error) {
+	templateId, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return model.ScheduleTemplate{}, err
+	}
+	return env.DB.GetTemplate(int64(templateId))
+}
+
+//	writeTemplate(w http.ResponseWriter, Template model.ScheduleTemplate) *AppError
+/*	Answers with a template and its time slots.
+ */
+func (env *Env) writeTemplate(w http.ResponseWriter, Template model.ScheduleTemplate) *AppError {
+	slots, err := env.DB.GetSlotsOfTemplate(Template.TemplateId)
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the slots of the template",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(TemplateToIntermediate(Template, slots)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the template",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	writeTemplates(w http.ResponseWriter, Templates model.ScheduleTemplates) *AppError
+/*	Answers with some templates, with their time slots.
+ */
+func (env *Env) writeTemplates(w http.ResponseWriter, Templates model.ScheduleTemplates) *AppError {
+	intermediates := []TemplateIntermediate{}
+	for _, template := range Templates {
+		slots, err := env.DB.GetSlotsOfTemplate(template.TemplateId)
+		if err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when fetching the slots of the template",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+		intermediates = append(intermediates, TemplateToIntermediate(template, slots))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(intermediates); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the templates",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	slotSchedule(Slot model.TemplateSlot, Monday time.Time, Location *time.Location) (model.Schedule, error)
+/*	Returns the schedule a slot gives in the week of a monday, at the hours of a zone.
+ */
+func slotSchedule(Slot model.TemplateSlot, Monday time.Time, Location *time.Location) (model.Schedule, error) {
+	day := globals.AddDays(Monday, Slot.Weekday-1, Location)
+
+	start, err := globals.AtClock(day, Slot.StartTime, Location)
+	if err != nil {
+		return model.Schedule{}, err
+	}
+	end, err := globals.AtClock(day, Slot.EndTime, Location)
+	if err != nil {
+		return model.Schedule{}, err
+	}
+
+	return model.Schedule{
+		ProjectId: Slot.ProjectId,
+		StartDate: sql.NullTime{Valid: true, Time: start.UTC()},
+		EndDate:   sql.NullTime{Valid: true, Time: end.UTC()},
+	}, nil
+}
+
+//	templateSlots(Slots model.TemplateSlots) model.TemplateSlots
+/*	Returns the slots of a request with their hours written like 08:30. The slots must have been validated.
+ */
+func templateSlots(Slots model.TemplateSlots) model.TemplateSlots {
+	slots := model.TemplateSlots{}
+	for _, slot := range Slots {
+		start, _ := time.Parse(globals.ClockLayout, slot.StartTime)
+		end, _ := time.Parse(globals.ClockLayout, slot.EndTime)
+		slot.StartTime, slot.EndTime = start.Format(globals.ClockLayout), end.Format(globals.ClockLayout)
+		slots = append(slots, slot)
+	}
+	return slots
+}
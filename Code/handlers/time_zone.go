@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//	userLocation(UserId int64) (*time.Location, error)
+/*	Returns the zone of a user : his own, or else the one of the first of his companies that has one,
+	or else the default zone of the application.
+*/
+func (env *Env) userLocation(UserId int64) (*time.Location, error) {
+	var (
+		err       error
+		user      model.User
+		companies model.Companies
+	)
+
+	if user, err = env.DB.GetUser(UserId); err != nil {
+		return nil, err
+	}
+
+	if user.TimeZone != "" {
+		return globals.LoadTimeZone(user.TimeZone)
+	}
+
+	if companies, err = env.DB.GetCompaniesOfUser(UserId); err != nil {
+		return nil, err
+	}
+
+	for _, company := range companies {
+		if company.TimeZone != "" {
+			return globals.LoadTimeZone(company.TimeZone)
+		}
+	}
+
+	return globals.DefaultTimeZone, nil
+}
+
+//	requestLocation(r *http.Request) (*time.Location, *AppError)
+/*	Returns the zone of the connected user, in which the dates of the answers are written.
+ */
+func (env *Env) requestLocation(r *http.Request) (*time.Location, *AppError) {
+	var (
+		err      error
+		userId   int64
+		location *time.Location
+	)
+
+	if userId, _, err = contextUser(r); err != nil {
+		return nil, &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if location, err = env.userLocation(userId); err != nil {
+		return nil, &AppError{
+			Error:   err,
+			Message: "Error when fetching the time zone",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return location, nil
+}
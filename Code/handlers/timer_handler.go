@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//	GetTimerHandler
+/*	The handler called by the following endpoint : GET /me/timer
+	This method is used to get the running timer of the connected user : his schedule that has no end yet.
+	A forgotten timer is stopped first, and refused with a 409 error if its hours are locked.
+*/
+func (env *Env) GetTimerHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err       error
+		userId    int
+		schedule  model.Schedule
+		forgotten bool
+	)
+
+	globals.Log.Debug("Calling GetTimerHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if schedule, err = env.DB.GetRunningScheduleOfUser(int64(userId)); err == nil {
+		forgotten, err = env.stopIfForgotten(int64(userId), schedule, time.Now())
+	}
+	// A forgotten timer that could not be stopped, as in locked hours, is still running
+	if err == sql.ErrNoRows || (err == nil && forgotten) {
+		return &AppError{
+			Error:   sql.ErrNoRows,
+			Message: "No timer is running",
+			Code:    http.StatusNotFound,
+		}
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the running timer",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return env.writeTimer(w, r, schedule.ScheduleId)
+}
+
+//	StartTimerHandler
+/*	The handler called by the following endpoint : POST /me/timer/start
+	This method is used to start a timer on a project : a schedule that starts now and has no end yet.
+	A user has only one running timer : the previous one must be stopped before.
+*/
+func (env *Env) StartTimerHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err        error
+		userId     int
+		running    model.Schedule
+		scheduleId int64
+	)
+
+	globals.Log.Debug("Calling StartTimerHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	timer := TimerStart{}
+
+	if err = json.NewDecoder(r.Body).Decode(&timer); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if appErr := env.validate(&timer).result(); appErr != nil {
+		return appErr
+	}
+
+	now := time.Now()
+
+	// A forgotten timer is stopped first, so it does not prevent starting a new one
+	if running, err = env.DB.GetRunningScheduleOfUser(int64(userId)); err == nil {
+		_, err = env.stopIfForgotten(int64(userId), running, now)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the running timer",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	scheduleId, err = env.DB.StartTimer(int64(userId), model.Schedule{
+		ProjectId: timer.ProjectId,
+		StartDate: sql.NullTime{Valid: true, Time: now},
+	})
+	if err == datastores.ErrTimerRunning {
+		return &AppError{
+			Error:   err,
+			Message: "A timer is already running, it must be stopped first",
+			Code:    http.StatusConflict,
+		}
+	}
+	if appErr := env.overlapError(r, err); appErr != nil {
+		return appErr
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when starting the timer",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.Debug("Timer started")
+
+	return env.writeTimer(w, r, scheduleId)
+}
+
+//	StopTimerHandler
+/*	The handler called by the following endpoint : POST /me/timer/stop
+	This method is used to stop the running timer of the connected user : his schedule ends now.
+	A forgotten timer ends at the hour the timers are stopped automatically, not now, and a timer ends before the
+	schedules added in its hours while it was running.
+*/
+func (env *Env) StopTimerHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err       error
+		userId    int
+		schedule  model.Schedule
+		forgotten bool
+	)
+
+	globals.Log.Debug("Calling StopTimerHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if schedule, err = env.DB.GetRunningScheduleOfUser(int64(userId)); err == sql.ErrNoRows {
+		return &AppError{
+			Error:   err,
+			Message: "No timer is running",
+			Code:    http.StatusConflict,
+		}
+	} else if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the running timer",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	now := time.Now()
+
+	if forgotten, err = env.stopIfForgotten(int64(userId), schedule, now); err == nil && !forgotten {
+		err = env.DB.StopSchedule(schedule.ScheduleId, now)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when stopping the timer",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.Debug("Timer stopped")
+
+	return env.writeTimer(w, r, schedule.ScheduleId)
+}
+
+//	StopForgottenTimers(Now time.Time) (int, error)
+/*	Stops the timers still running after the hour they are stopped automatically (see globals.TimerRules),
+	and returns how many were stopped. The timers whose hours were locked meanwhile are left to the managers.
+*/
+func (env *Env) StopForgottenTimers(Now time.Time) (int, error) {
+	var (
+		err      error
+		running  model.UsersSchedules
+		schedule model.Schedule
+		stopped  int
+	)
+
+	if running, err = env.DB.GetRunningUsersSchedules(); err != nil {
+		return 0, err
+	}
+
+	for _, link := range running {
+		if schedule, err = env.DB.GetSchedule(link.ScheduleId); err != nil {
+			return stopped, err
+		}
+
+		forgotten, err := env.stopIfForgotten(link.UserId, schedule, Now)
+		var lockedErr *datastores.LockedError
+		if errors.As(err, &lockedErr) {
+			globals.Log.Warn("Could not stop the forgotten timer " + strconv.FormatInt(schedule.ScheduleId, 10) + " : " + err.Error())
+			continue
+		} else if err != nil {
+			return stopped, err
+		}
+		if forgotten {
+			stopped++
+		}
+	}
+
+	return stopped, nil
+}
+
+//	AutoStopTimers()
+/*	Looks for the forgotten timers regularly, and stops them. Never returns : it is started in its own goroutine.
+ */
+func (env *Env) AutoStopTimers() {
+	ticker := time.NewTicker(globals.Timers.AutoStopCheck)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if stopped, err := env.StopForgottenTimers(now); err != nil {
+			globals.Log.Warn("Could not stop the forgotten timers : " + err.Error())
+		} else if stopped > 0 {
+			globals.Log.Info(strconv.Itoa(stopped) + " forgotten timers stopped")
+		}
+	}
+}
+
+//	stopIfForgotten(UserId int64, Schedule model.Schedule, Now time.Time) (bool, error)
+/*	Stops a running timer at the hour the timers are stopped automatically, in the zone of the user,
+	if this hour is passed. Tells wether the timer was stopped.
+*/
+func (env *Env) stopIfForgotten(UserId int64, Schedule model.Schedule, Now time.Time) (bool, error) {
+	location, err := env.userLocation(UserId)
+	if err != nil {
+		return false, err
+	}
+
+	stop, ok := globals.Timers.AutoStopDate(Schedule.StartDate.Time, location)
+	if !ok || stop.After(Now) {
+		return false, nil
+	}
+
+	globals.Log.Debug("Stopping the forgotten timer " + strconv.FormatInt(Schedule.ScheduleId, 10))
+
+	return true, env.DB.StopSchedule(Schedule.ScheduleId, stop)
+}
+
+//	writeTimer(w http.ResponseWriter, r *http.Request, ScheduleId int64) *AppError
+/*	Writes the schedule of a timer, in the zone of the connected user.
+ */
+func (env *Env) writeTimer(w http.ResponseWriter, r *http.Request, ScheduleId int64) *AppError {
+	schedule, err := env.DB.GetSchedule(ScheduleId)
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the timer",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(ScheduleToIntermediate(schedule, location)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the timer",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// The days of the week the theorical hours are spread over, from monday.
+const workingDaysPerWeek = 5
+
+// dayHours : The hours spent by a user during a day of his zone.
+/*	hours : The hours worked, without the vacations.
+	vacationHours : The hours spent in vacation.
+	projects : The hours worked per project.
+*/
+type dayHours struct {
+	hours         float64
+	vacationHours float64
+	projects      map[int64]float64
+}
+
+//	GetTimesheetHandler
+/*	The handler called by the following endpoint : GET /users/{id}/timesheets?week=2021-W12, or ?month=2021-03
+	This method is used to get the timesheet of a user for an ISO week or a month of his zone : the hours worked per
+	day and per project, the hours expected from his theorical hours once the vacations are removed, the overtime
+	(negative when he worked less), and the balance of every day since his first schedule until the end of the period.
+	The days he worked longer than his contract allows without a break are flagged. Nothing is expected on his days off,
+	and the vacations don't count on them.
+	Users can see their own timesheets, and the users that can see the reports the ones of everybody.
+*/
+func (env *Env) GetTimesheetHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err             error
+		userId          int
+		user            model.User
+		contract        model.Contract
+		location        *time.Location
+		schedules       model.Schedules
+		vacationProject model.Project
+		holidays        map[string]string
+		start, end      time.Time
+	)
+
+	globals.Log.Debug("Calling GetTimesheetHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireReportAccess(r, int64(userId)); appErr != nil {
+		return appErr
+	}
+
+	if user, err = env.DB.GetUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if contract, err = env.DB.GetContract(user.ContractId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the contract of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if location, err = env.userLocation(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the time zone of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// Reading the period of the timesheet, in the zone of the user
+	query := r.URL.Query()
+	week, month := query.Get("week"), query.Get("month")
+
+	v := &validation{}
+	switch {
+	case week != "" && month != "":
+		v.add("month", "can't be given with week")
+	case week != "":
+		if start, err = globals.ParseWeek(week, location); err != nil {
+			v.add("week", err.Error())
+		}
+		end = globals.AddDays(start, 7, location)
+	case month != "":
+		if start, err = globals.ParseMonth(month, location); err != nil {
+			v.add("month", err.Error())
+		}
+		end = start.AddDate(0, 1, 0)
+	default:
+		v.add("week", "is required, unless month is given")
+	}
+
+	if appErr := v.result(); appErr != nil {
+		return appErr
+	}
+
+	if schedules, err = env.schedulesOfUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the schedules",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if vacationProject, err = env.DB.GetVacationProject(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the vacation project",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	timesheet := Timesheet{
+		UserId:         int64(userId),
+		TimeZone:       location.String(),
+		Period:         week + month,
+		Start:          globals.FormatDate(start, location),
+		End:            globals.FormatDate(end, location),
+		TheoricalHours: user.TheoricalHoursWorked,
+		Projects:       []TimesheetProject{},
+		Days:           []TimesheetDay{},
+	}
+
+	// The balance runs from the day of the first schedule of the user
+	historyStart := end
+	for _, schedule := range schedules {
+		if schedule.StartDate.Time.Before(historyStart) {
+			historyStart = globals.StartOfDay(schedule.StartDate.Time, location)
+		}
+	}
+
+	first := start
+	if historyStart.Before(first) {
+		first = historyStart
+	}
+
+	if holidays, err = env.holidaysOfUser(int64(userId), first, end, location); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the days off of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	days := hoursPerDay(schedules, vacationProject.ProjectId, holidays, first, end, location)
+	missing := missingBreaks(schedules, vacationProject.ProjectId, contract, location)
+	projects := map[int64]float64{}
+	// The days to come are not in the balance yet, as their hours are not worked
+	tomorrow := globals.AddDays(globals.StartOfDay(time.Now(), location), 1, location)
+
+	for day := first; day.Before(end); day = globals.AddDays(day, 1, location) {
+		key := day.Format(globals.DayLayout)
+		timesheetDay := timesheetDayOf(day, days[key], user.TheoricalHoursWorked, holidays[key])
+		if !day.Before(historyStart) && day.Before(tomorrow) {
+			timesheet.CumulativeBalance += timesheetDay.OvertimeHours
+		}
+
+		if day.Before(start) {
+			continue
+		}
+
+		timesheet.ExpectedHours += timesheetDay.ExpectedHours
+		timesheet.Hours += timesheetDay.Hours
+		timesheet.VacationHours += timesheetDay.VacationHours
+		timesheet.OvertimeHours += timesheetDay.OvertimeHours
+		if timesheetDay.MissingBreak = missing[timesheetDay.Day]; timesheetDay.MissingBreak {
+			timesheet.MissingBreaks++
+		}
+		for _, project := range timesheetDay.Projects {
+			projects[project.ProjectId] += project.Hours
+		}
+		timesheet.Days = append(timesheet.Days, timesheetDay)
+	}
+
+	timesheet.ExpectedHours = roundHours(timesheet.ExpectedHours)
+	timesheet.Hours = roundHours(timesheet.Hours)
+	timesheet.VacationHours = roundHours(timesheet.VacationHours)
+	timesheet.OvertimeHours = roundHours(timesheet.OvertimeHours)
+	timesheet.CumulativeBalance = roundHours(timesheet.CumulativeBalance)
+	timesheet.Projects = sortedProjects(projects)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(timesheet); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the timesheet",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	hoursPerDay(Schedules model.Schedules, VacationProjectId int64, Holidays map[string]string, Start time.Time, End time.Time, Location *time.Location) map[string]*dayHours
+/*	Splits the schedules between two dates into the days of a zone they cover, keyed by day like 2021-03-28.
+	A schedule over midnight counts in both days, a running timer lasts until now, and the breaks don't count.
+	Neither do the vacations on the days off.
+*/
+func hoursPerDay(Schedules model.Schedules, VacationProjectId int64, Holidays map[string]string, Start time.Time, End time.Time, Location *time.Location) map[string]*dayHours {
+	days := map[string]*dayHours{}
+
+	for _, schedule := range Schedules {
+		if overlap(schedule, Start, End) == 0 {
+			continue
+		}
+
+		scheduleEnd := schedule.EndDate.Time
+		if !schedule.EndDate.Valid {
+			scheduleEnd = time.Now()
+		}
+
+		day := globals.StartOfDay(schedule.StartDate.Time, Location)
+		if day.Before(Start) {
+			day = Start
+		}
+
+		for ; day.Before(End) && day.Before(scheduleEnd); day = globals.AddDays(day, 1, Location) {
+			duration := worked(schedule, day, globals.AddDays(day, 1, Location))
+			if duration == 0 {
+				continue
+			}
+
+			key := day.Format(globals.DayLayout)
+			if _, off := Holidays[key]; off && schedule.ProjectId == VacationProjectId {
+				continue
+			}
+			if days[key] == nil {
+				days[key] = &dayHours{projects: map[int64]float64{}}
+			}
+
+			if schedule.ProjectId == VacationProjectId {
+				days[key].vacationHours += duration.Hours()
+			} else {
+				days[key].hours += duration.Hours()
+				days[key].projects[schedule.ProjectId] += duration.Hours()
+			}
+		}
+	}
+
+	return days
+}
+
+//	missingBreaks(Schedules model.Schedules, VacationProjectId int64, Contract model.Contract, Location *time.Location) map[string]bool
+/*	Returns the days of a zone, like 2021-03-28, on which a user worked longer than the minutes of his contract without
+	the mandatory break. The pauses shorter than this break, during the schedules or between them, don't stop the work :
+	a stretch of work too long is flagged on the day it starts. A contract without rule flags nothing.
+*/
+func missingBreaks(Schedules model.Schedules, VacationProjectId int64, Contract model.Contract, Location *time.Location) map[string]bool {
+	days := map[string]bool{}
+	if Contract.BreakAfterMinutes == 0 || Contract.MinimumBreakMinutes == 0 {
+		return days
+	}
+	breakAfter := time.Duration(Contract.BreakAfterMinutes) * time.Minute
+	minimumBreak := time.Duration(Contract.MinimumBreakMinutes) * time.Minute
+
+	work := workIntervals(Schedules, VacationProjectId)
+
+	var (
+		stretch  interval
+		duration time.Duration
+	)
+	for i, hours := range work {
+		if i == 0 || hours.start.Sub(stretch.end) >= minimumBreak {
+			if duration > breakAfter {
+				days[stretch.start.In(Location).Format(globals.DayLayout)] = true
+			}
+			stretch, duration = hours, 0
+		}
+
+		duration += hours.end.Sub(hours.start)
+		if hours.end.After(stretch.end) {
+			stretch.end = hours.end
+		}
+	}
+	if duration > breakAfter {
+		days[stretch.start.In(Location).Format(globals.DayLayout)] = true
+	}
+
+	return days
+}
+
+// interval : A span of time, from start to end.
+type interval struct{ start, end time.Time }
+
+//	workIntervals(Schedules model.Schedules, VacationProjectId int64) []interval
+/*	Returns the hours worked, out of the vacations and the breaks, the first ones first. A running timer lasts until now.
+ */
+func workIntervals(Schedules model.Schedules, VacationProjectId int64) []interval {
+	work := []interval{}
+	for _, schedule := range Schedules {
+		if schedule.ProjectId == VacationProjectId {
+			continue
+		}
+
+		scheduleEnd := schedule.EndDate.Time
+		if !schedule.EndDate.Valid {
+			scheduleEnd = time.Now()
+		}
+
+		from := schedule.StartDate.Time
+		for _, scheduleBreak := range schedule.Breaks {
+			if scheduleBreak.StartDate.After(from) {
+				work = append(work, interval{from, scheduleBreak.StartDate})
+			}
+			if scheduleBreak.EndDate.After(from) {
+				from = scheduleBreak.EndDate
+			}
+		}
+		if scheduleEnd.After(from) {
+			work = append(work, interval{from, scheduleEnd})
+		}
+	}
+	sort.Slice(work, func(i, j int) bool { return work[i].start.Before(work[j].start) })
+
+	return work
+}
+
+//	timesheetDayOf(Day time.Time, Hours *dayHours, TheoricalHours int64, Holiday string) TimesheetDay
+/*	Returns the line of a day in a timesheet. The theorical hours of the week are spread from monday to friday,
+	and the vacations of the day are removed from them. Nothing is expected on a day off, named by Holiday.
+*/
+func timesheetDayOf(Day time.Time, Hours *dayHours, TheoricalHours int64, Holiday string) TimesheetDay {
+	timesheetDay := TimesheetDay{
+		Day:      Day.Format(globals.DayLayout),
+		Holiday:  Holiday,
+		Projects: []TimesheetProject{},
+	}
+
+	if Day.Weekday() != time.Saturday && Day.Weekday() != time.Sunday && Holiday == "" {
+		timesheetDay.ExpectedHours = float64(TheoricalHours) / workingDaysPerWeek
+	}
+
+	if Hours != nil {
+		timesheetDay.Hours = roundHours(Hours.hours)
+		timesheetDay.VacationHours = roundHours(Hours.vacationHours)
+		timesheetDay.Projects = sortedProjects(Hours.projects)
+	}
+
+	timesheetDay.ExpectedHours -= timesheetDay.VacationHours
+	if timesheetDay.ExpectedHours < 0 {
+		timesheetDay.ExpectedHours = 0
+	}
+	timesheetDay.ExpectedHours = roundHours(timesheetDay.ExpectedHours)
+	timesheetDay.OvertimeHours = roundHours(timesheetDay.Hours - timesheetDay.ExpectedHours)
+
+	return timesheetDay
+}
+
+//	sortedProjects(Projects map[int64]float64) []TimesheetProject
+/*	Returns the hours per project, sorted by project id and rounded.
+ */
+func sortedProjects(Projects map[int64]float64) []TimesheetProject {
+	projects := []TimesheetProject{}
+
+	for projectId, hours := range Projects {
+		projects = append(projects, TimesheetProject{ProjectId: projectId, Hours: roundHours(hours)})
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].ProjectId < projects[j].ProjectId })
+
+	return projects
+}
@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+//	GetTimesheetPeriodsOfUserHandler
+/*	The handler called by the following endpoint : GET /users/{id}/timesheets/periods, and GET /me/timesheets/periods
+	This method is used to get the periods of the timesheet of a user that were submitted at least once, the last ones first.
+	Users can see their own periods, and the users that can see the reports the ones of everybody.
+*/
+func (env *Env) GetTimesheetPeriodsOfUserHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		userId   int
+		periods  model.TimesheetPeriods
+		location *time.Location
+	)
+
+	globals.Log.Debug("Calling GetTimesheetPeriodsOfUserHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireReportAccess(r, int64(userId)); appErr != nil {
+		return appErr
+	}
+
+	if periods, err = env.DB.GetTimesheetPeriodsOfUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the timesheet periods",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	return writeTimesheetPeriods(w, periods, location)
+}
+
+//	GetTimesheetPeriodsHandler
+/*	The handler called by the following endpoint : GET /timesheets?state=submitted
+	This method is used to get the periods of every user in a state, the submitted ones by default :
+	the timesheets waiting for a manager. It needs the right to see the reports.
+*/
+func (env *Env) GetTimesheetPeriodsHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		periods  model.TimesheetPeriods
+		location *time.Location
+	)
+
+	globals.Log.Debug("Calling GetTimesheetPeriodsHandler")
+
+	if appErr := env.requireTimesheetManager(r, 0); appErr != nil {
+		return appErr
+	}
+
+	state := r.URL.Query().Get("state")
+	if state == "" {
+		state = model.TimesheetSubmitted
+	}
+
+	v := &validation{}
+	if state != model.TimesheetDraft && state != model.TimesheetSubmitted && state != model.TimesheetApproved && state != model.TimesheetRejected {
+		v.add("state", "must be draft, submitted, approved or rejected")
+	}
+	if appErr := v.result(); appErr != nil {
+		return appErr
+	}
+
+	if periods, err = env.DB.GetTimesheetPeriodsByState(state); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the timesheet periods",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	return writeTimesheetPeriods(w, periods, location)
+}
+
+//	GetTimesheetPeriodHandler
+/*	The handler called by the following endpoint : GET /users/{id}/timesheets/{period}, and GET /me/timesheets/{period}
+	This method is used to get the state of a week or a month of the timesheet of a user, with its history.
+	A period that was never submitted is a draft, without history.
+	Users can see their own periods, and the users that can see the reports the ones of everybody.
+*/
+func (env *Env) GetTimesheetPeriodHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err      error
+		userId   int
+		period   model.TimesheetPeriod
+		events   model.TimesheetEvents
+		location *time.Location
+	)
+
+	globals.Log.Debug("Calling GetTimesheetPeriodHandler")
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if appErr := env.requireReportAccess(r, int64(userId)); appErr != nil {
+		return appErr
+	}
+
+	if period, err = env.DB.GetTimesheetPeriodOfUser(int64(userId), mux.Vars(r)["period"]); err == sql.ErrNoRows {
+		var appErr *AppError
+		if period, appErr = env.newTimesheetPeriod(int64(userId), mux.Vars(r)["period"]); appErr != nil {
+			return appErr
+		}
+	} else if err == nil {
+		events, err = env.DB.GetTimesheetEvents(period.PeriodId)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the timesheet period",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	return writeTimesheetPeriod(w, PeriodToIntermediate(period, events, location))
+}
+
+//	SubmitTimesheetHandler
+/*	The handler called by the following endpoint : POST /users/{id}/timesheets/{period}/submit, and POST /me/timesheets/{period}/submit
+	This method is used by a user to submit a week or a month of his own timesheet to the managers.
+	A draft or a rejected period can be submitted.
+*/
+func (env *Env) SubmitTimesheetHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	globals.Log.Debug("Calling SubmitTimesheetHandler")
+	return env.changeTimesheetState(w, r, model.TimesheetSubmitted)
+}
+
+//	ApproveTimesheetHandler
+/*	The handler called by the following endpoint : POST /users/{id}/timesheets/{period}/approve
+	This method is used by a manager to approve a submitted period. Its schedules and their comments are then locked.
+*/
+func (env *Env) ApproveTimesheetHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	globals.Log.Debug("Calling ApproveTimesheetHandler")
+	return env.changeTimesheetState(w, r, model.TimesheetApproved)
+}
+
+//	RejectTimesheetHandler
+/*	The handler called by the following endpoint : POST /users/{id}/timesheets/{period}/reject
+	This method is used by a manager to reject a submitted period, with a reason. The user can then correct it and submit it again.
+*/
+func (env *Env) RejectTimesheetHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	globals.Log.Debug("Calling RejectTimesheetHandler")
+	return env.changeTimesheetState(w, r, model.TimesheetRejected)
+}
+
+//	ReopenTimesheetHandler
+/*	The handler called by the following endpoint : POST /users/{id}/timesheets/{period}/reopen
+	This method is used by a manager to reopen an approved period as a draft, which unlocks its schedules.
+*/
+func (env *Env) ReopenTimesheetHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	globals.Log.Debug("Calling ReopenTimesheetHandler")
+	return env.changeTimesheetState(w, r, model.TimesheetDraft)
+}
+
+//	changeTimesheetState(w http.ResponseWriter, r *http.Request, State string) *AppError
+/*	Moves the period of the request to a state, and answers with the period and its history.
+	A user submits his own periods, and the managers (the users that can see the reports) decide on the other ones.
+	A rejection needs a reason, and the user is told about the decisions.
+*/
+func (env *Env) changeTimesheetState(w http.ResponseWriter, r *http.Request, State string) *AppError {
+	var (
+		err           error
+		userId        int
+		currentUserId int64
+		period        model.TimesheetPeriod
+		events        model.TimesheetEvents
+		user          model.User
+		location      *time.Location
+	)
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if currentUserId, _, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if State == model.TimesheetSubmitted {
+		if currentUserId != int64(userId) {
+			return &AppError{
+				Error:   errors.New("forbidden"),
+				Message: "A user can only submit his own timesheet",
+				Code:    http.StatusForbidden,
+			}
+		}
+	} else if appErr := env.requireTimesheetManager(r, int64(userId)); appErr != nil {
+		return appErr
+	}
+
+	decision := TimesheetDecision{}
+	if State != model.TimesheetSubmitted {
+		if err = json.NewDecoder(r.Body).Decode(&decision); err != nil && err != io.EOF {
+			return &AppError{
+				Error:   err,
+				Message: "Error when decoding the form",
+				Code:    http.StatusBadRequest,
+			}
+		}
+	}
+
+	v := env.validate(&decision)
+	if State == model.TimesheetRejected && !v.has("reason") && decision.Reason == "" {
+		v.add("reason", "is required to reject a timesheet")
+	}
+	if appErr := v.result(); appErr != nil {
+		return appErr
+	}
+
+	// Only a submission creates a period, the decisions need a submitted one
+	if period, err = env.DB.GetTimesheetPeriodOfUser(int64(userId), mux.Vars(r)["period"]); err == sql.ErrNoRows && State == model.TimesheetSubmitted {
+		var appErr *AppError
+		if period, appErr = env.newTimesheetPeriod(int64(userId), mux.Vars(r)["period"]); appErr != nil {
+			return appErr
+		}
+	} else if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the timesheet period",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	period, err = env.DB.ChangeTimesheetPeriodState(period, model.TimesheetEvent{
+		State:    State,
+		AuthorId: currentUserId,
+		Reason:   decision.Reason,
+	})
+	if err == datastores.ErrTimesheetState {
+		return &AppError{
+			Error:   err,
+			Message: "The timesheet period is " + period.State + ", it can't go to " + State,
+			Code:    http.StatusConflict,
+		}
+	} else if err == nil {
+		events, err = env.DB.GetTimesheetEvents(period.PeriodId)
+	}
+	if err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when changing the state of the timesheet period",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// The user is told about the decisions of the managers
+	if State != model.TimesheetSubmitted {
+		if user, err = env.DB.GetUser(int64(userId)); err == nil {
+			message := "Your timesheet " + period.Period + " is now " + State + "."
+			if decision.Reason != "" {
+				message += " Reason : " + decision.Reason
+			}
+			env.notify(user, "Timesheet "+period.Period+" "+State, message)
+		}
+	}
+
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
+
+	return writeTimesheetPeriod(w, PeriodToIntermediate(period, events, location))
+}
+
+//	newTimesheetPeriod(UserId int64, Period string) (model.TimesheetPeriod, *AppError)
+/*	Returns a draft period of a user, with the dates of the week or the month in his zone.
+ */
+func (env *Env) newTimesheetPeriod(UserId int64, Period string) (model.TimesheetPeriod, *AppError) {
+	location, err := env.userLocation(UserId)
+	if err != nil {
+		return model.TimesheetPeriod{}, &AppError{
+			Error:   err,
+			Message: "Error when fetching the time zone of the user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	period := model.TimesheetPeriod{UserId: UserId, Period: Period, State: model.TimesheetDraft}
+	if period.StartDate, period.EndDate, err = globals.ParsePeriod(Period, location); err != nil {
+		v := &validation{}
+		v.add("period", err.Error())
+		return model.TimesheetPeriod{}, v.result()
+	}
+
+	return period, nil
+}
+
+//	requireTimesheetManager(r *http.Request, UserId int64) *AppError
+/*	Verifies the connected user can decide on the timesheets : his role must see the reports,
+	and he can't decide on his own timesheet.
+*/
+func (env *Env) requireTimesheetManager(r *http.Request, UserId int64) *AppError {
+	var (
+		err           error
+		currentUserId int64
+		currentRoleId int64
+		currentRole   model.Role
+	)
+
+	if currentUserId, currentRoleId, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if currentRole, err = env.DB.GetRole(currentRoleId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the role",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if !currentRole.CanSeeReports {
+		return &AppError{
+			Error:   errors.New("forbidden"),
+			Message: "Managing the timesheets is forbidden",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if currentUserId == UserId {
+		return &AppError{
+			Error:   errors.New("forbidden"),
+			Message: "A manager can't decide on his own timesheet",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	return nil
+}
+
+//	writeTimesheetPeriod(w http.ResponseWriter, Period TimesheetPeriodIntermediate) *AppError
+/*	Answers with a timesheet period.
+ */
+func writeTimesheetPeriod(w http.ResponseWriter, Period TimesheetPeriodIntermediate) *AppError {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(Period); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the timesheet period",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	writeTimesheetPeriods(w http.ResponseWriter, Periods model.TimesheetPeriods, Location *time.Location) *AppError
+/*	Answers with some timesheet periods, without their history.
+ */
+func writeTimesheetPeriods(w http.ResponseWriter, Periods model.TimesheetPeriods, Location *time.Location) *AppError {
+	intermediates := []TimesheetPeriodIntermediate{}
+	for _, period := range Periods {
+		intermediates = append(intermediates, PeriodToIntermediate(period, nil, Location))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(intermediates); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the timesheet periods",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// The number of recovery codes given when the two-factor authentication gets enabled.
+const recoveryCodesCount = 10
+
+//	StartTwoFactorHandler
+/*	The handler called by the following endpoint : POST /users/{id}/two-factor
+	This method is used to start the enrollment of a user in the two-factor authentication.
+	It returns the secret and the URI to give to the authenticator application.
+	The two-factor authentication is only enabled once a code is confirmed with POST /users/{id}/two-factor/confirm.
+*/
+func (env *Env) StartTwoFactorHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err       error
+		userId    int64
+		user      model.User
+		twoFactor model.TwoFactor
+		secret    string
+	)
+
+	globals.Log.Debug("StartTwoFactorHandler called")
+
+	if appErr := forbidDelegatedSession(r); appErr != nil {
+		return appErr
+	}
+
+	if userId, err = env.selfUserId(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Users can only enroll themselves in the two-factor authentication",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if twoFactor, err = env.DB.GetTwoFactor(userId); err == nil && twoFactor.Enabled {
+		return &AppError{
+			Error:   errors.New("already enabled"),
+			Message: "The two-factor authentication is already enabled",
+			Code:    http.StatusConflict,
+		}
+	}
+
+	if user, err = env.DB.GetUser(userId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Unexisting user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if secret, err = globals.GenerateTOTPSecret(); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when generating the secret",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if err = env.DB.SaveTwoFactor(model.TwoFactor{
+		UserId:  userId,
+		Secret:  secret,
+		Enabled: false,
+	}); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when saving the two-factor authentication",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: globals.TOTPProvisioningURI(globals.TOTPIssuer, user.Mail, secret),
+	}); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the secret",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	ConfirmTwoFactorHandler
+/*	The handler called by the following endpoint : POST /users/{id}/two-factor/confirm
+	This method is used to enable the two-factor authentication, with a code given by the authenticator application.
+	It returns the recovery codes, that will never be shown again.
+*/
+func (env *Env) ConfirmTwoFactorHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err       error
+		userId    int64
+		form      TwoFactorCode
+		twoFactor model.TwoFactor
+		codes     []string
+	)
+
+	globals.Log.Debug("ConfirmTwoFactorHandler called")
+
+	if appErr := forbidDelegatedSession(r); appErr != nil {
+		return appErr
+	}
+
+	if err = json.NewDecoder(r.Body).Decode(&form); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if userId, err = env.selfUserId(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Users can only enroll themselves in the two-factor authentication",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if twoFactor, err = env.DB.GetTwoFactor(userId); err != nil {
+		if err == sql.ErrNoRows {
+			return &AppError{
+				Error:   err,
+				Message: "The enrollment has not been started",
+				Code:    http.StatusBadRequest,
+			}
+		}
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the two-factor authentication",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if twoFactor.Enabled {
+		return &AppError{
+			Error:   errors.New("already enabled"),
+			Message: "The two-factor authentication is already enabled",
+			Code:    http.StatusConflict,
+		}
+	}
+
+	step, ok := globals.MatchTOTPCode(twoFactor.Secret, form.Code, time.Now())
+	if !ok {
+		return &AppError{
+			Error:   errors.New("invalid code"),
+			Message: "The code is incorrect",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	twoFactor.Enabled = true
+	twoFactor.LastUsedStep = step
+
+	if err = env.DB.SaveTwoFactor(twoFactor); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when saving the two-factor authentication",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// Generating the recovery codes, only their hash is kept
+	if codes, err = globals.GenerateRecoveryCodes(recoveryCodesCount); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when generating the recovery codes",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	hashes := make([]string, len(codes))
+	for index, code := range codes {
+		hashes[index] = globals.HashRecoveryCode(code)
+	}
+
+	if err = env.DB.SetRecoveryCodes(userId, hashes); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when saving the recovery codes",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.Debug("Two-factor authentication enabled")
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(TwoFactorRecoveryCodes{
+		RecoveryCodes: codes,
+	}); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when encoding the recovery codes",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	return nil
+}
+
+//	DisableTwoFactorHandler
+/*	The handler called by the following endpoint : DELETE /users/{id}/two-factor
+	This method is used to disable the two-factor authentication of a user.
+	Users disabling their own need to give a code, and can't do it if their role requires it.
+	Users that can add and modify users can disable it for somebody else, for instance when a device is lost.
+*/
+func (env *Env) DisableTwoFactorHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err           error
+		userId        int
+		currentUserId int64
+		currentRoleId int64
+		currentRole   model.Role
+		form          TwoFactorCode
+		twoFactor     model.TwoFactor
+		valid         bool
+	)
+
+	globals.Log.Debug("DisableTwoFactorHandler called")
+
+	if appErr := forbidDelegatedSession(r); appErr != nil {
+		return appErr
+	}
+
+	// The body is only needed when disabling his own two-factor authentication
+	if err = json.NewDecoder(r.Body).Decode(&form); err != nil && err != io.EOF {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	vars := mux.Vars(r)
+
+	if userId, err = strconv.Atoi(vars["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if currentUserId, currentRoleId, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if currentRole, err = env.DB.GetRole(currentRoleId); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the role",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if twoFactor, err = env.DB.GetTwoFactor(int64(userId)); err != nil {
+		if err == sql.ErrNoRows {
+			return &AppError{
+				Error:   err,
+				Message: "The two-factor authentication is not enabled",
+				Code:    http.StatusBadRequest,
+			}
+		}
+		return &AppError{
+			Error:   err,
+			Message: "Error when fetching the two-factor authentication",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if int64(userId) == currentUserId {
+		if currentRole.RequireTwoFactor {
+			return &AppError{
+				Error:   errors.New("two-factor required"),
+				Message: "The two-factor authentication is mandatory for your role",
+				Code:    http.StatusForbidden,
+			}
+		}
+
+		// An enabled two-factor authentication can only be disabled with a code
+		if twoFactor.Enabled {
+			if valid, err = env.checkTwoFactorCode(twoFactor, form.Code); err != nil {
+				return &AppError{
+					Error:   err,
+					Message: "Error when verifying the code",
+					Code:    http.StatusInternalServerError,
+				}
+			}
+
+			if !valid {
+				return &AppError{
+					Error:   errors.New("invalid code"),
+					Message: "The code is incorrect",
+					Code:    http.StatusUnauthorized,
+				}
+			}
+		}
+	} else if !currentRole.CanAddAndModifyUsers {
+		return &AppError{
+			Error:   errors.New("forbidden"),
+			Message: "Disabling the two-factor authentication of another user is forbidden",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if err = env.DB.DeleteTwoFactor(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when disabling the two-factor authentication",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.Debug("Two-factor authentication disabled")
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
+//	selfUserId(r *http.Request) (int64, error)
+/*	Returns the id of the request, only if it is the one of the connected user.
+ */
+func (env *Env) selfUserId(r *http.Request) (int64, error) {
+	var (
+		err           error
+		userId        int
+		currentUserId int64
+	)
+
+	if userId, err = strconv.Atoi(mux.Vars(r)["id"]); err != nil {
+		return 0, err
+	}
+
+	if currentUserId, _, err = contextUser(r); err != nil {
+		return 0, err
+	}
+
+	if int64(userId) != currentUserId {
+		return 0, errors.New("not the connected user")
+	}
+
+	return currentUserId, nil
+}
@@ -5,20 +5,57 @@ import (
 	"net/http"
 	"time"
 
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
 )
 
 type Env struct {
-	DB datastores.IDatastore
+	DB            datastores.IDatastore
+	Notifier      Notifier
+	AuthProviders map[string]authentication.Provider
+	OIDCProviders map[string]*authentication.OIDCProvider
 }
 
 type AppHandlerFunc func(http.ResponseWriter, *http.Request) *AppError
 
+// AppError : An error returned by a handler, written by AppMiddleware in the JSON envelope.
+/*	Error : The cause, only logged.
+	Message : The message for the humans.
+	Code : The HTTP status.
+	ErrorCode : The machine-readable code, deduced from the status if empty.
+	Details : The errors of the fields of the request, if any.
+	Conflicts : The schedules a schedule of the request would overlap, if any.
+	Violations : The working time rules a schedule of the request would break, if any.
+*/
 type AppError struct {
-	Error   error
-	Message string
-	Code    int
+	Error      error
+	Message    string
+	Code       int
+	ErrorCode  string
+	Details    []ErrorDetail
+	Conflicts  []ScheduleIntermediate
+	Violations []ComplianceViolation
+}
+
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ErrorBody struct {
+	Code       string                 `json:"code"`
+	Message    string                 `json:"message"`
+	Status     int                    `json:"status"`
+	RequestId  string                 `json:"request_id"`
+	Details    []ErrorDetail          `json:"details,omitempty"`
+	Conflicts  []ScheduleIntermediate `json:"conflicts,omitempty"`
+	Violations []ComplianceViolation  `json:"violations,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
 }
 
 type UserIntermediate struct {
@@ -33,13 +70,264 @@ type UserIntermediate struct {
 	VacationHours        int64  `db:"vacation_hours" json:"vacation_hours"`
 }
 
+type PasswordChange struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type TwoFactorRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	TwoFactorToken    string `json:"two_factor_token"`
+}
+
+type TwoFactorLogin struct {
+	TwoFactorToken string `json:"two_factor_token"`
+	Code           string `json:"code"`
+}
+
+type AccessTokenRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scope     string     `json:"scope"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type AccessTokenCreated struct {
+	model.AccessToken
+	Token string `json:"token"`
+}
+
+type PersonalDataExport struct {
+	ExportedAt       time.Time            `json:"exported_at"`
+	Profile          model.User           `json:"profile"`
+	Contract         model.Contract       `json:"contract"`
+	Role             model.Role           `json:"role"`
+	Functions        model.Functions      `json:"functions"`
+	Companies        model.Companies      `json:"companies"`
+	Schedules        model.Schedules      `json:"schedules"`
+	Vacations        model.Schedules      `json:"vacations"`
+	Comments         model.Comments       `json:"comments"`
+	AccessTokens     model.AccessTokens   `json:"access_tokens"`
+	TwoFactorEnabled bool                 `json:"two_factor_enabled"`
+	Impersonations   model.Impersonations `json:"impersonations"`
+}
+
+type ImpersonationRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ImpersonationStarted struct {
+	model.Impersonation
+	Token string `json:"token"`
+}
+
+type Report struct {
+	UserId        int64          `json:"user_id"`
+	TimeZone      string         `json:"time_zone"`
+	Period        string         `json:"period"`
+	Hours         float64        `json:"hours"`
+	VacationHours float64        `json:"vacation_hours"`
+	Periods       []ReportPeriod `json:"periods"`
+}
+
+type ReportPeriod struct {
+	Start         string  `json:"start"`
+	End           string  `json:"end"`
+	Hours         float64 `json:"hours"`
+	VacationHours float64 `json:"vacation_hours"`
+}
+
+type Balance struct {
+	UserId                 int64   `json:"user_id"`
+	TimeZone               string  `json:"time_zone"`
+	WeekStart              string  `json:"week_start"`
+	WeekEnd                string  `json:"week_end"`
+	TheoricalHours         int64   `json:"theorical_hours"`
+	Hours                  float64 `json:"hours"`
+	VacationHours          float64 `json:"vacation_hours"`
+	HolidayHours           float64 `json:"holiday_hours"`
+	RemainingHours         float64 `json:"remaining_hours"`
+	RemainingVacationHours int64   `json:"remaining_vacation_hours"`
+}
+
+type Timesheet struct {
+	UserId            int64              `json:"user_id"`
+	TimeZone          string             `json:"time_zone"`
+	Period            string             `json:"period"`
+	Start             string             `json:"start"`
+	End               string             `json:"end"`
+	TheoricalHours    int64              `json:"theorical_hours"`
+	ExpectedHours     float64            `json:"expected_hours"`
+	Hours             float64            `json:"hours"`
+	VacationHours     float64            `json:"vacation_hours"`
+	OvertimeHours     float64            `json:"overtime_hours"`
+	CumulativeBalance float64            `json:"cumulative_balance"`
+	MissingBreaks     int                `json:"missing_breaks"`
+	Projects          []TimesheetProject `json:"projects"`
+	Days              []TimesheetDay     `json:"days"`
+}
+
+type TimesheetDay struct {
+	Day           string             `json:"day"`
+	ExpectedHours float64            `json:"expected_hours"`
+	Hours         float64            `json:"hours"`
+	VacationHours float64            `json:"vacation_hours"`
+	OvertimeHours float64            `json:"overtime_hours"`
+	MissingBreak  bool               `json:"missing_break"`
+	Holiday       string             `json:"holiday,omitempty"`
+	Projects      []TimesheetProject `json:"projects"`
+}
+
+type CalendarDay struct {
+	Day       string `json:"day"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	CompanyId int64  `json:"company_id"`
+	HolidayId int64  `json:"holiday_id,omitempty"`
+}
+
+type Compliance struct {
+	UserId     int64                 `json:"user_id"`
+	TimeZone   string                `json:"time_zone"`
+	From       string                `json:"from"`
+	To         string                `json:"to"`
+	Violations []ComplianceViolation `json:"violations"`
+}
+
+type ComplianceViolation struct {
+	UserId       int64  `json:"user_id"`
+	Rule         string `json:"rule"`
+	Start        string `json:"start"`
+	End          string `json:"end"`
+	Minutes      int64  `json:"minutes"`
+	LimitMinutes int64  `json:"limit_minutes"`
+}
+
+type TimesheetProject struct {
+	ProjectId int64   `json:"project_id"`
+	Hours     float64 `json:"hours"`
+}
+
+type TimesheetPeriodIntermediate struct {
+	PeriodId  int64                        `json:"period_id"`
+	UserId    int64                        `json:"user_id"`
+	Period    string                       `json:"period"`
+	StartDate string                       `json:"start_date"`
+	EndDate   string                       `json:"end_date"`
+	State     string                       `json:"state"`
+	Events    []TimesheetEventIntermediate `json:"events,omitempty"`
+}
+
+type TimesheetEventIntermediate struct {
+	EventId   int64  `json:"event_id"`
+	State     string `json:"state"`
+	AuthorId  int64  `json:"author_id"`
+	Reason    string `json:"reason"`
+	CreatedAt string `json:"created_at"`
+}
+
+type TimesheetDecision struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+type LockDateIntermediate struct {
+	LockId    int64  `json:"lock_id"`
+	LockDate  string `json:"lock_date"`
+	AuthorId  int64  `json:"author_id"`
+	Reason    string `json:"reason"`
+	CreatedAt string `json:"created_at"`
+}
+
+type LockRequest struct {
+	LockDate string `json:"lock_date"`
+	Reason   string `json:"reason" validate:"max=500"`
+}
+
+type TimerStart struct {
+	ProjectId int64 `json:"project_id" validate:"required"`
+}
+
 type ScheduleIntermediate struct {
 	ScheduleId int64  `json:"schedule_id"`
-	ProjectId  int64  `json:"project_id"`
-	StartDate  string `json:"start_date"`
-	EndDate    string `json:"end_date"`
+	ProjectId  int64  `json:"project_id" validate:"required"`
+	StartDate  string `json:"start_date" validate:"required"`
+	EndDate    string `json:"end_date" validate:"required"`
+	UserId     int64  `json:"user_id,omitempty"`
+	SeriesId   int64  `json:"series_id,omitempty"`
+}
+
+type BreakIntermediate struct {
+	BreakId    int64  `json:"break_id"`
+	ScheduleId int64  `json:"schedule_id" validate:"required"`
+	StartDate  string `json:"start_date" validate:"required"`
+	EndDate    string `json:"end_date" validate:"required"`
+}
+
+type SeriesIntermediate struct {
+	SeriesId       int64                  `json:"series_id"`
+	ProjectId      int64                  `json:"project_id" validate:"required"`
+	UserId         int64                  `json:"user_id" validate:"required"`
+	StartDate      string                 `json:"start_date" validate:"required"`
+	EndDate        string                 `json:"end_date" validate:"required"`
+	TimeZone       string                 `json:"time_zone" validate:"timezone"`
+	RecurrenceRule string                 `json:"recurrence_rule" validate:"required,max=500"`
+	ExceptionDates []string               `json:"exception_dates"`
+	GeneratedUntil string                 `json:"generated_until"`
+	Schedules      []ScheduleIntermediate `json:"schedules,omitempty"`
+}
+
+type TemplateIntermediate struct {
+	TemplateId   int64               `json:"template_id"`
+	TemplateName string              `json:"template_name" validate:"required,max=100"`
+	UserId       int64               `json:"user_id,omitempty"`
+	CompanyId    int64               `json:"company_id,omitempty"`
+	Slots        model.TemplateSlots `json:"slots"`
+}
+
+type TemplateApplication struct {
+	UserId   int64    `json:"user_id"`
+	Week     string   `json:"week" validate:"required"`
+	SkipDays []string `json:"skip_days"`
+}
+
+type AppliedSlot struct {
+	ProjectId  int64                  `json:"project_id"`
+	StartDate  string                 `json:"start_date"`
+	EndDate    string                 `json:"end_date"`
+	Status     string                 `json:"status"`
+	ScheduleId int64                  `json:"schedule_id,omitempty"`
+	Conflicts  []ScheduleIntermediate `json:"conflicts,omitempty"`
+}
+
+type TemplateApplicationResult struct {
+	TemplateId int64         `json:"template_id"`
+	UserId     int64         `json:"user_id"`
+	Week       string        `json:"week"`
+	Applied    bool          `json:"applied"`
+	Slots      []AppliedSlot `json:"slots"`
+}
+
+type ScheduleOverlap struct {
+	UserId int64                `json:"user_id"`
+	First  ScheduleIntermediate `json:"first"`
+	Second ScheduleIntermediate `json:"second"`
 }
 
+//	IntermediateToSchedule(SI ScheduleIntermediate) (model.Schedule, error)
+/*	Reads the RFC 3339 dates of a schedule sent by a client. The dates are returned in UTC.
+ */
 func IntermediateToSchedule(SI ScheduleIntermediate) (model.Schedule, error) {
 	var (
 		startTime time.Time
@@ -47,13 +335,11 @@ func IntermediateToSchedule(SI ScheduleIntermediate) (model.Schedule, error) {
 		err       error
 	)
 
-	format := "2006-01-02 15:04:05"
-
-	if startTime, err = time.Parse(format, SI.StartDate); err != nil {
+	if startTime, err = globals.ParseDate(SI.StartDate); err != nil {
 		return model.Schedule{}, err
 	}
 
-	if endTime, err = time.Parse(format, SI.EndDate); err != nil {
+	if endTime, err = globals.ParseDate(SI.EndDate); err != nil {
 		return model.Schedule{}, err
 	}
 
@@ -65,12 +351,145 @@ func IntermediateToSchedule(SI ScheduleIntermediate) (model.Schedule, error) {
 	}, nil
 }
 
-func ScheduleToIntermediate(S model.Schedule) ScheduleIntermediate {
-	format := "2006-01-02 15:04:05"
-	return ScheduleIntermediate{
+//	ScheduleToIntermediate(S model.Schedule, Location *time.Location) ScheduleIntermediate
+/*	Writes the dates of a schedule in RFC 3339, with the offset of the zone of the user.
+	The end of a running timer is empty.
+*/
+func ScheduleToIntermediate(S model.Schedule, Location *time.Location) ScheduleIntermediate {
+	intermediate := ScheduleIntermediate{
 		ScheduleId: S.ScheduleId,
 		ProjectId:  S.ProjectId,
-		StartDate:  S.StartDate.Time.Format(format),
-		EndDate:    S.StartDate.Time.Format(format),
+		StartDate:  globals.FormatDate(S.StartDate.Time, Location),
+	}
+	if S.EndDate.Valid {
+		intermediate.EndDate = globals.FormatDate(S.EndDate.Time, Location)
+	}
+	if S.SeriesId.Valid {
+		intermediate.SeriesId = S.SeriesId.Int64
+	}
+	return intermediate
+}
+
+//	BreakToIntermediate(B model.ScheduleBreak, Location *time.Location) BreakIntermediate
+/*	Writes the dates of a break in RFC 3339, with the offset of the zone of the user.
+ */
+func BreakToIntermediate(B model.ScheduleBreak, Location *time.Location) BreakIntermediate {
+	return BreakIntermediate{
+		BreakId:    B.BreakId,
+		ScheduleId: B.ScheduleId,
+		StartDate:  globals.FormatDate(B.StartDate, Location),
+		EndDate:    globals.FormatDate(B.EndDate, Location),
+	}
+}
+
+//	IntermediateToBreak(BI BreakIntermediate) (model.ScheduleBreak, error)
+/*	Reads the RFC 3339 dates of a break sent by a client. The dates are returned in UTC.
+ */
+func IntermediateToBreak(BI BreakIntermediate) (model.ScheduleBreak, error) {
+	startDate, err := globals.ParseDate(BI.StartDate)
+	if err != nil {
+		return model.ScheduleBreak{}, err
+	}
+
+	endDate, err := globals.ParseDate(BI.EndDate)
+	if err != nil {
+		return model.ScheduleBreak{}, err
+	}
+
+	return model.ScheduleBreak{
+		BreakId:    BI.BreakId,
+		ScheduleId: BI.ScheduleId,
+		StartDate:  startDate,
+		EndDate:    endDate,
+	}, nil
+}
+
+//	SeriesToIntermediate(S model.ScheduleSeries, Schedules model.Schedules, Location *time.Location) SeriesIntermediate
+/*	Writes the dates of a recurring series and of its saved occurrences in RFC 3339, with the offset of the zone of the user.
+ */
+func SeriesToIntermediate(S model.ScheduleSeries, Schedules model.Schedules, Location *time.Location) SeriesIntermediate {
+	intermediate := SeriesIntermediate{
+		SeriesId:       S.SeriesId,
+		ProjectId:      S.ProjectId,
+		UserId:         S.UserId,
+		StartDate:      globals.FormatDate(S.StartDate, Location),
+		EndDate:        globals.FormatDate(S.EndDate, Location),
+		TimeZone:       S.TimeZone,
+		RecurrenceRule: S.RecurrenceRule,
+		ExceptionDates: []string{},
+		GeneratedUntil: globals.FormatDate(S.GeneratedUntil, Location),
+	}
+	for _, exception := range S.Exceptions() {
+		intermediate.ExceptionDates = append(intermediate.ExceptionDates, globals.FormatDate(exception, Location))
+	}
+	for _, schedule := range Schedules {
+		intermediate.Schedules = append(intermediate.Schedules, ScheduleToIntermediate(schedule, Location))
+	}
+	return intermediate
+}
+
+//	TemplateToIntermediate(T model.ScheduleTemplate, Slots model.TemplateSlots) TemplateIntermediate
+/*	Writes a template with its time slots, with the id of its user or of its company.
+ */
+func TemplateToIntermediate(T model.ScheduleTemplate, Slots model.TemplateSlots) TemplateIntermediate {
+	return TemplateIntermediate{
+		TemplateId:   T.TemplateId,
+		TemplateName: T.TemplateName,
+		UserId:       T.UserId.Int64,
+		CompanyId:    T.CompanyId.Int64,
+		Slots:        Slots,
+	}
+}
+
+//	IntermediateToTemplate(TI TemplateIntermediate) model.ScheduleTemplate
+/*	Reads a template sent by a client : a template belongs to a user or to a company.
+ */
+func IntermediateToTemplate(TI TemplateIntermediate) model.ScheduleTemplate {
+	return model.ScheduleTemplate{
+		TemplateId:   TI.TemplateId,
+		TemplateName: TI.TemplateName,
+		UserId:       sql.NullInt64{Valid: TI.UserId != 0, Int64: TI.UserId},
+		CompanyId:    sql.NullInt64{Valid: TI.CompanyId != 0, Int64: TI.CompanyId},
+	}
+}
+
+//	PeriodToIntermediate(P model.TimesheetPeriod, Events model.TimesheetEvents, Location *time.Location) TimesheetPeriodIntermediate
+/*	Writes the dates of a timesheet period and of its history in RFC 3339, with the offset of the zone of the user.
+ */
+func PeriodToIntermediate(P model.TimesheetPeriod, Events model.TimesheetEvents, Location *time.Location) TimesheetPeriodIntermediate {
+	intermediate := TimesheetPeriodIntermediate{
+		PeriodId:  P.PeriodId,
+		UserId:    P.UserId,
+		Period:    P.Period,
+		StartDate: globals.FormatDate(P.StartDate, Location),
+		EndDate:   globals.FormatDate(P.EndDate, Location),
+		State:     P.State,
+	}
+	for _, event := range Events {
+		intermediate.Events = append(intermediate.Events, TimesheetEventIntermediate{
+			EventId:   event.EventId,
+			State:     event.State,
+			AuthorId:  event.AuthorId,
+			Reason:    event.Reason,
+			CreatedAt: globals.FormatDate(event.CreatedAt, Location),
+		})
+	}
+	return intermediate
+}
+
+//	LockDateToIntermediate(L model.LockDate, Location *time.Location) LockDateIntermediate
+/*	Writes the dates of a change of the lock date in RFC 3339, with the offset of the zone of the user.
+	A removed lock date is empty.
+*/
+func LockDateToIntermediate(L model.LockDate, Location *time.Location) LockDateIntermediate {
+	intermediate := LockDateIntermediate{
+		LockId:    L.LockId,
+		AuthorId:  L.AuthorId,
+		Reason:    L.Reason,
+		CreatedAt: globals.FormatDate(L.CreatedAt, Location),
+	}
+	if L.LockDate.Valid {
+		intermediate.LockDate = globals.FormatDate(L.LockDate.Time, Location)
 	}
+	return intermediate
 }
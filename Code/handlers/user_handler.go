@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -194,6 +195,7 @@ func (env *Env) GetUsersOfProjectHandler(w http.ResponseWriter, r *http.Request)
 //	CreateUserHandler
 /*	The handler called by the following endpoint : POST /users
 	This method is used to create a user.
+	A user authenticated by a directory (auth_provider) has no password.
 */
 func (env *Env) CreateUserHandler(w http.ResponseWriter, r *http.Request) *AppError {
 	var (
@@ -213,14 +215,30 @@ func (env *Env) CreateUserHandler(w http.ResponseWriter, r *http.Request) *AppEr
 		}
 	}
 
-	if cryptedPassword, err = bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost); err != nil {
-		return &AppError{
-			Error:   err,
-			Message: "Error when crypting the password",
-			Code:    http.StatusInternalServerError,
+	// The password of a local user must follow the password policy
+	validation := env.validate(&user)
+	if user.UsesLocalAuthentication() {
+		validation.addAll("password", globals.PasswordRules.Check(user.Password, user.Username, user.Mail))
+	}
+
+	if appErr := validation.result(); appErr != nil {
+		return appErr
+	}
+
+	if user.UsesLocalAuthentication() {
+		if cryptedPassword, err = bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost); err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when crypting the password",
+				Code:    http.StatusInternalServerError,
+			}
 		}
+		user.Password = string(cryptedPassword)
+	} else {
+		// The password of a directory user is checked by the directory
+		user.Password = noPassword
+		user.MustChangePassword = false
 	}
-	user.Password = string(cryptedPassword)
 
 	globals.Log.Debug("Calling CreateUser method")
 
@@ -274,6 +292,10 @@ func (env *Env) UpdateUserHandler(w http.ResponseWriter, r *http.Request) *AppEr
 		}
 	}
 
+	if appErr := env.validate(&user).result(); appErr != nil {
+		return appErr
+	}
+
 	vars := mux.Vars(r)
 
 	if userId, err = strconv.Atoi(vars["id"]); err != nil {
@@ -294,7 +316,19 @@ func (env *Env) UpdateUserHandler(w http.ResponseWriter, r *http.Request) *AppEr
 		}
 	}
 
+	// The password can only be changed through POST /users/{id}/password,
+	// and the obligation to change it can be set here but only removed by changing it.
 	user.Password = dbUser.Password
+	user.MustChangePassword = user.MustChangePassword || dbUser.MustChangePassword
+
+	// A user moved to a directory has no password anymore. A user moved back to the local
+	// authentication has none either, until an administrator sets it with POST /users/{id}/password.
+	if user.UsesLocalAuthentication() != dbUser.UsesLocalAuthentication() {
+		user.Password = noPassword
+	}
+	if !user.UsesLocalAuthentication() {
+		user.MustChangePassword = false
+	}
 
 	globals.Log.Debug("Calling CreateUser method")
 
@@ -322,6 +356,147 @@ func (env *Env) UpdateUserHandler(w http.ResponseWriter, r *http.Request) *AppEr
 	return nil
 }
 
+//	ChangePasswordHandler
+/*	The handler called by the following endpoint : POST /users/{id}/password
+	This method is used to change the password of a user.
+	A user changing his own password has to give the old one.
+	A user that can add and modify users can set the password of somebody else, who will then have to change it.
+*/
+func (env *Env) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) *AppError {
+	var (
+		err             error
+		change          PasswordChange
+		dbUser          model.User
+		currentRole     model.Role
+		userId          int
+		currentUserId   int64
+		currentRoleId   int64
+		cryptedPassword []byte
+	)
+
+	globals.Log.Debug("ChangePasswordHandler called")
+
+	if appErr := forbidDelegatedSession(r); appErr != nil {
+		return appErr
+	}
+
+	if err = json.NewDecoder(r.Body).Decode(&change); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when decoding the form",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	vars := mux.Vars(r)
+
+	if userId, err = strconv.Atoi(vars["id"]); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// Extracting the connected user from the context
+	if currentUserId, currentRoleId, err = contextUser(r); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Id atoi conversion error",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if dbUser, err = env.DB.GetUser(int64(userId)); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Unexisting user",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	// The password of a directory user is managed by the directory
+	if !dbUser.UsesLocalAuthentication() {
+		return &AppError{
+			Error:   errors.New("directory user"),
+			Message: "The password of this user is managed by the " + dbUser.AuthProvider + " directory",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	isSelf := int64(userId) == currentUserId
+
+	if isSelf {
+		// The old password is needed to change his own password
+		if err = bcrypt.CompareHashAndPassword([]byte(dbUser.Password), []byte(change.OldPassword)); err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "The old password is incorrect",
+				Code:    http.StatusUnauthorized,
+			}
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(dbUser.Password), []byte(change.NewPassword)) == nil {
+			return &AppError{
+				Error:   errors.New("same password"),
+				Message: "The new password must be different from the old one",
+				Code:    http.StatusBadRequest,
+			}
+		}
+	} else {
+		// Otherwise, the right to modify users is needed
+		if currentRole, err = env.DB.GetRole(currentRoleId); err != nil {
+			return &AppError{
+				Error:   err,
+				Message: "Error when fetching the role",
+				Code:    http.StatusInternalServerError,
+			}
+		}
+
+		if !currentRole.CanAddAndModifyUsers {
+			return &AppError{
+				Error:   errors.New("forbidden"),
+				Message: "Changing the password of another user is forbidden",
+				Code:    http.StatusForbidden,
+			}
+		}
+	}
+
+	// Verifying the password follows the password policy
+	validation := env.validate(&change)
+	validation.addAll("new_password", globals.PasswordRules.Check(change.NewPassword, dbUser.Username, dbUser.Mail))
+
+	if appErr := validation.result(); appErr != nil {
+		return appErr
+	}
+
+	if cryptedPassword, err = bcrypt.GenerateFromPassword([]byte(change.NewPassword), bcrypt.DefaultCost); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when crypting the password",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.Debug("Calling UpdateUserPassword method")
+
+	// A password set by somebody else has to be changed by its owner
+	if err = env.DB.UpdateUserPassword(int64(userId), string(cryptedPassword), !isSelf); err != nil {
+		return &AppError{
+			Error:   err,
+			Message: "Error when updating the password",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	globals.Log.Debug("Password updated")
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+
+	return nil
+}
+
 //	DeleteUserHandler
 /*	The handler called by the following endpoint : DELETE /users/{id}
 	This method is used to delete an existing user.
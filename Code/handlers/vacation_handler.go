@@ -1,11 +1,9 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gorilla/mux"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
@@ -43,19 +41,15 @@ func (env *Env) GetVacationHandler(w http.ResponseWriter, r *http.Request) *AppE
 		}
 	}
 
-	format := "2006-01-02 15:04:05"
-	startDate := vacation.StartDate.Time.Format(format)
-	endDate := vacation.EndDate.Time.Format(format)
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
 
 	w.Header().Set("Content-type", "application/json;charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 
-	json.NewEncoder(w).Encode(ScheduleIntermediate{
-		ScheduleId: vacation.ScheduleId,
-		ProjectId:  vacation.ProjectId,
-		StartDate:  startDate,
-		EndDate:    endDate,
-	})
+	json.NewEncoder(w).Encode(ScheduleToIntermediate(vacation, location))
 
 	return nil
 }
@@ -92,18 +86,13 @@ func (env *Env) GetVacationsOfUserHandler(w http.ResponseWriter, r *http.Request
 		}
 	}
 
-	format := "2006-01-02 15:04:05"
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
 
 	for _, vacation := range vacations {
-		startDate := vacation.StartDate.Time.Format(format)
-		endDate := vacation.EndDate.Time.Format(format)
-
-		intermediates = append(intermediates, ScheduleIntermediate{
-			ScheduleId: vacation.ScheduleId,
-			ProjectId:  vacation.ProjectId,
-			StartDate:  startDate,
-			EndDate:    endDate,
-		})
+		intermediates = append(intermediates, ScheduleToIntermediate(vacation, location))
 	}
 
 	w.Header().Set("Content-type", "application/json;charset=UTF-8")
@@ -120,11 +109,10 @@ func (env *Env) GetVacationsOfUserHandler(w http.ResponseWriter, r *http.Request
 */
 func (env *Env) CreateVacationHandler(w http.ResponseWriter, r *http.Request) *AppError {
 	var (
-		err        error
-		vacation   model.Schedule
-		vacationId int64
-		startTime  time.Time
-		endTime    time.Time
+		err             error
+		vacation        model.Schedule
+		vacationId      int64
+		vacationProject model.Project
 	)
 
 	globals.Log.Debug("CreateVacationHandler called")
@@ -139,33 +127,34 @@ func (env *Env) CreateVacationHandler(w http.ResponseWriter, r *http.Request) *A
 		}
 	}
 
-	format := "2006-01-02 15:04:05"
-
-	if startTime, err = time.Parse(format, intermediate.StartDate); err != nil {
+	// A vacation always belongs to the vacation project
+	if vacationProject, err = env.DB.GetVacationProject(); err != nil {
 		return &AppError{
 			Error:   err,
-			Message: "Error with the date",
+			Message: "Error when fetching the vacation project",
 			Code:    http.StatusInternalServerError,
 		}
 	}
+	intermediate.ProjectId = vacationProject.ProjectId
+
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
+	}
 
-	if endTime, err = time.Parse(format, intermediate.EndDate); err != nil {
+	if vacation, err = IntermediateToSchedule(intermediate); err != nil {
 		return &AppError{
 			Error:   err,
 			Message: "Error with the date",
-			Code:    http.StatusInternalServerError,
+			Code:    http.StatusBadRequest,
 		}
 	}
 
-	vacation = model.Schedule{
-		ProjectId: intermediate.ProjectId,
-		StartDate: sql.NullTime{Valid: true, Time: startTime},
-		EndDate:   sql.NullTime{Valid: true, Time: endTime},
-	}
-
 	globals.Log.Debug("Calling CreateVacation method")
 
-	if vacationId, err = env.DB.CreateVacation(vacation); err != nil {
+	if vacationId, err = env.DB.CreateVacation(vacation, intermediate.userIds()...); err != nil {
+		if appErr := env.overlapError(r, err); appErr != nil {
+			return appErr
+		}
 		return &AppError{
 			Error:   err,
 			Message: "Error creating the vacation",
@@ -199,11 +188,10 @@ func (env *Env) CreateVacationHandler(w http.ResponseWriter, r *http.Request) *A
 */
 func (env *Env) UpdateVacationHandler(w http.ResponseWriter, r *http.Request) *AppError {
 	var (
-		err        error
-		schedule   model.Schedule
-		scheduleId int
-		startTime  time.Time
-		endTime    time.Time
+		err             error
+		schedule        model.Schedule
+		scheduleId      int
+		vacationProject model.Project
 	)
 
 	globals.Log.Debug("UpdateVacationHandler called")
@@ -218,30 +206,28 @@ func (env *Env) UpdateVacationHandler(w http.ResponseWriter, r *http.Request) *A
 		}
 	}
 
-	format := "2006-01-02 15:04:05"
-
-	if startTime, err = time.Parse(format, intermediate.StartDate); err != nil {
+	// A vacation always belongs to the vacation project
+	if vacationProject, err = env.DB.GetVacationProject(); err != nil {
 		return &AppError{
 			Error:   err,
-			Message: "Error with the date",
+			Message: "Error when fetching the vacation project",
 			Code:    http.StatusInternalServerError,
 		}
 	}
+	intermediate.ProjectId = vacationProject.ProjectId
+
+	if appErr := env.validate(&intermediate).result(); appErr != nil {
+		return appErr
+	}
 
-	if endTime, err = time.Parse(format, intermediate.EndDate); err != nil {
+	if schedule, err = IntermediateToSchedule(intermediate); err != nil {
 		return &AppError{
 			Error:   err,
 			Message: "Error with the date",
-			Code:    http.StatusInternalServerError,
+			Code:    http.StatusBadRequest,
 		}
 	}
 
-	schedule = model.Schedule{
-		ProjectId: intermediate.ProjectId,
-		StartDate: sql.NullTime{Valid: true, Time: startTime},
-		EndDate:   sql.NullTime{Valid: true, Time: endTime},
-	}
-
 	vars := mux.Vars(r)
 
 	if scheduleId, err = strconv.Atoi(vars["id"]); err != nil {
@@ -257,6 +243,9 @@ func (env *Env) UpdateVacationHandler(w http.ResponseWriter, r *http.Request) *A
 	globals.Log.Debug("Calling UpdateVacation method")
 
 	if schedule, err = env.DB.UpdateVacation(schedule); err != nil {
+		if appErr := env.overlapError(r, err); appErr != nil {
+			return appErr
+		}
 		return &AppError{
 			Error:   err,
 			Message: "Error when updating the schedule",
@@ -266,18 +255,15 @@ func (env *Env) UpdateVacationHandler(w http.ResponseWriter, r *http.Request) *A
 
 	globals.Log.Debug("Vacation updated")
 
-	startDate := schedule.StartDate.Time.Format(format)
-	endDate := schedule.EndDate.Time.Format(format)
+	location, appErr := env.requestLocation(r)
+	if appErr != nil {
+		return appErr
+	}
 
 	w.Header().Set("Content-type", "application/json;charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 
-	json.NewEncoder(w).Encode(ScheduleIntermediate{
-		ScheduleId: schedule.ScheduleId,
-		ProjectId:  schedule.ProjectId,
-		StartDate:  startDate,
-		EndDate:    endDate,
-	})
+	json.NewEncoder(w).Encode(ScheduleToIntermediate(schedule, location))
 
 	return nil
 }
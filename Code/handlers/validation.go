@@ -0,0 +1,520 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// A mail must have something on both sides of a single @, and no spaces.
+var mailRegex = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+
+// validation : The violations found in a request, so they are all returned at once.
+/*	details : The violations, named after the json fields.
+	err : An error of the database met while checking the referenced ids.
+*/
+type validation struct {
+	details []ErrorDetail
+	err     error
+}
+
+//	validate(Value interface{}) *validation
+/*	Checks a request before it is given to the datastore : first the rules of the validate tags of its fields,
+	then the rules between its fields and the ids it references. The handlers can add their own rules to the result,
+	and return it with result().
+*/
+func (env *Env) validate(Value interface{}) *validation {
+	v := &validation{}
+	v.checkFields(reflect.Indirect(reflect.ValueOf(Value)))
+
+	switch value := Value.(type) {
+	case *model.User:
+		env.validateUser(v, *value)
+	case *model.Comment:
+		env.validateComment(v, *value)
+	case *ScheduleIntermediate:
+		env.validateSchedule(v, *value)
+	case *BreakIntermediate:
+		env.validateBreak(v, *value)
+	case *SeriesIntermediate:
+		env.validateSeries(v, *value)
+	case *TemplateIntermediate:
+		env.validateTemplate(v, *value)
+	case *TimerStart:
+		env.validateTimerStart(v, *value)
+	case *model.Holiday:
+		env.validateHoliday(v, *value)
+	case *TemplateApplication:
+		validateTemplateApplication(v, *value)
+	case *AccessTokenRequest:
+		validateAccessTokenRequest(v, *value)
+	}
+
+	return v
+}
+
+//	checkFields(Value reflect.Value)
+/*	Checks the fields of a struct against the rules of their validate tag, like `validate:"required,max=100"` :
+	required : a string that is not blank, or a number that is not 0, like an id.
+	min=N, max=N : the length of a string, or the value of a number.
+	mail : a string that looks like a mail, if it is given.
+	timezone : an IANA zone, if it is given.
+	holidaycalendar : a known calendar of public holidays, if it is given.
+*/
+func (v *validation) checkFields(Value reflect.Value) {
+	if Value.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < Value.NumField(); i++ {
+		field := Value.Type().Field(i)
+
+		if field.Anonymous {
+			v.checkFields(reflect.Indirect(Value.Field(i)))
+			continue
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			v.checkRule(name, rule, Value.Field(i))
+		}
+	}
+}
+
+//	checkRule(Field string, Rule string, Value reflect.Value)
+/*	Checks one rule of a validate tag.
+ */
+func (v *validation) checkRule(Field string, Rule string, Value reflect.Value) {
+	var (
+		isString = Value.Kind() == reflect.String
+		length   int64
+		number   int64
+		limit    int64
+	)
+
+	if isString {
+		length = int64(utf8.RuneCountInString(Value.String()))
+	} else if Value.Kind() >= reflect.Int && Value.Kind() <= reflect.Int64 {
+		number = Value.Int()
+	}
+
+	if parts := strings.SplitN(Rule, "=", 2); len(parts) == 2 {
+		Rule = parts[0]
+		limit, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+
+	switch Rule {
+	case "required":
+		if (isString && strings.TrimSpace(Value.String()) == "") || (!isString && number == 0) {
+			v.add(Field, "is required")
+		}
+	case "min":
+		if isString && length < limit {
+			v.add(Field, "must be at least "+strconv.FormatInt(limit, 10)+" characters long")
+		} else if !isString && number < limit {
+			v.add(Field, "must be at least "+strconv.FormatInt(limit, 10))
+		}
+	case "max":
+		if isString && length > limit {
+			v.add(Field, "must be at most "+strconv.FormatInt(limit, 10)+" characters long")
+		} else if !isString && number > limit {
+			v.add(Field, "must be at most "+strconv.FormatInt(limit, 10))
+		}
+	case "mail":
+		if isString && Value.String() != "" && !mailRegex.MatchString(Value.String()) {
+			v.add(Field, "is not a valid mail address")
+		}
+	case "timezone":
+		if isString && Value.String() != "" {
+			if _, err := globals.LoadTimeZone(Value.String()); err != nil {
+				v.add(Field, "is not an IANA time zone, like Europe/Paris")
+			}
+		}
+	case "holidaycalendar":
+		if isString && Value.String() != "" && !globals.IsHolidayCalendar(Value.String()) {
+			v.add(Field, "must be one of "+strings.Join(globals.HolidayCalendars, ", "))
+		}
+	}
+}
+
+//	add(Field string, Message string)
+/*	Adds a violation.
+ */
+func (v *validation) add(Field string, Message string) {
+	v.details = append(v.details, ErrorDetail{Field: Field, Message: Message})
+}
+
+//	addAll(Field string, Messages []string)
+/*	Adds several violations of the same field, like the ones of the password policy.
+ */
+func (v *validation) addAll(Field string, Messages []string) {
+	for _, message := range Messages {
+		v.add(Field, message)
+	}
+}
+
+//	has(Field string) bool
+/*	Tells wether a field already has a violation, to not check further what is already wrong.
+ */
+func (v *validation) has(Field string) bool {
+	for _, detail := range v.details {
+		if detail.Field == Field {
+			return true
+		}
+	}
+	return false
+}
+
+//	exists(Field string, Err error)
+/*	Records the result of fetching an item referenced by a field : a missing row is a violation,
+	any other error is kept to be returned as an internal error.
+*/
+func (v *validation) exists(Field string, Err error) {
+	if Err == sql.ErrNoRows {
+		v.add(Field, "does not exist")
+	} else if Err != nil && v.err == nil {
+		v.err = Err
+	}
+}
+
+//	date(Field string, Value string) (time.Time, bool)
+/*	Parses an RFC 3339 date of a request, adding a violation if it can't be read. An empty date is left to the required rule.
+ */
+func (v *validation) date(Field string, Value string) (time.Time, bool) {
+	if Value == "" {
+		return time.Time{}, false
+	}
+
+	date, err := globals.ParseDate(Value)
+	if err != nil {
+		v.add(Field, "must be an RFC 3339 date, like 2021-03-01T08:00:00+01:00")
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+//	result() *AppError
+/*	Returns nil if the request is valid, otherwise a 422 error with every violation.
+ */
+func (v *validation) result() *AppError {
+	if v.err != nil {
+		return &AppError{
+			Error:   v.err,
+			Message: "Error when checking the request",
+			Code:    http.StatusInternalServerError,
+		}
+	}
+
+	if len(v.details) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(v.details))
+	for i, detail := range v.details {
+		messages[i] = detail.Field + " : " + detail.Message
+	}
+
+	return &AppError{
+		Error:     errors.New(strings.Join(messages, ", ")),
+		Message:   "The request is not valid : " + strings.Join(messages, ", "),
+		Code:      http.StatusUnprocessableEntity,
+		ErrorCode: ErrorValidationFailed,
+		Details:   v.details,
+	}
+}
+
+//	validateUser(v *validation, User model.User)
+/*	A user must have an existing contract and role, and a configured authentication provider.
+ */
+func (env *Env) validateUser(v *validation, User model.User) {
+	if !v.has("contract_id") {
+		_, err := env.DB.GetContract(User.ContractId)
+		v.exists("contract_id", err)
+	}
+
+	if !v.has("role_id") {
+		_, err := env.DB.GetRole(User.RoleId)
+		v.exists("role_id", err)
+	}
+
+	if !env.hasAuthProvider(User) {
+		v.add("auth_provider", "is not a configured authentication provider")
+	}
+}
+
+//	validateComment(v *validation, Comment model.Comment)
+/*	A comment must be about an existing schedule.
+ */
+func (env *Env) validateComment(v *validation, Comment model.Comment) {
+	if !v.has("schedule_id") {
+		_, err := env.DB.GetSchedule(Comment.ScheduleId)
+		v.exists("schedule_id", err)
+	}
+}
+
+//	validateSchedule(v *validation, Schedule ScheduleIntermediate)
+/*	A schedule must be about an existing project, and can't end before it starts.
+ */
+func (env *Env) validateSchedule(v *validation, Schedule ScheduleIntermediate) {
+	if !v.has("project_id") {
+		_, err := env.DB.GetProject(Schedule.ProjectId)
+		v.exists("project_id", err)
+	}
+
+	if Schedule.UserId != 0 {
+		_, err := env.DB.GetUser(Schedule.UserId)
+		v.exists("user_id", err)
+	}
+
+	startDate, hasStart := v.date("start_date", Schedule.StartDate)
+	endDate, hasEnd := v.date("end_date", Schedule.EndDate)
+
+	if hasStart && hasEnd && endDate.Before(startDate) {
+		v.add("end_date", "must not be before start_date")
+	}
+}
+
+//	validateBreak(v *validation, Break BreakIntermediate)
+/*	A break is taken during an existing schedule, other than a vacation, and within its hours : until now for a
+	running timer. It can't overlap the other breaks of the schedule.
+*/
+func (env *Env) validateBreak(v *validation, Break BreakIntermediate) {
+	var (
+		schedule        model.Schedule
+		vacationProject model.Project
+		breaks          model.ScheduleBreaks
+		err             error
+	)
+
+	startDate, hasStart := v.date("start_date", Break.StartDate)
+	endDate, hasEnd := v.date("end_date", Break.EndDate)
+
+	if hasStart && hasEnd && !endDate.After(startDate) {
+		v.add("end_date", "must be after start_date")
+		hasEnd = false
+	}
+
+	if v.has("schedule_id") {
+		return
+	}
+	if schedule, err = env.DB.GetSchedule(Break.ScheduleId); err == nil {
+		if vacationProject, err = env.DB.GetVacationProject(); err == nil {
+			breaks, err = env.DB.GetBreaksOfSchedule(Break.ScheduleId)
+		}
+	}
+	if err != nil {
+		v.exists("schedule_id", err)
+		return
+	}
+
+	if schedule.ProjectId == vacationProject.ProjectId {
+		v.add("schedule_id", "must not be a vacation")
+		return
+	}
+
+	scheduleEnd := schedule.EndDate.Time
+	if !schedule.EndDate.Valid {
+		scheduleEnd = time.Now()
+	}
+	if hasStart && startDate.Before(schedule.StartDate.Time) {
+		v.add("start_date", "must not be before the start of the schedule")
+	}
+	if hasEnd && endDate.After(scheduleEnd) {
+		v.add("end_date", "must not be after the end of the schedule")
+	}
+
+	if hasStart && hasEnd {
+		for _, other := range breaks {
+			if other.BreakId != Break.BreakId && startDate.Before(other.EndDate) && other.StartDate.Before(endDate) {
+				v.add("start_date", "must not overlap another break of the schedule")
+				break
+			}
+		}
+	}
+}
+
+//	validateSeries(v *validation, Series SeriesIntermediate)
+/*	A recurring series is about an existing project and user, its first occurrence can't end before it starts,
+	and its rule and its exceptions must be readable.
+*/
+func (env *Env) validateSeries(v *validation, Series SeriesIntermediate) {
+	if !v.has("project_id") {
+		_, err := env.DB.GetProject(Series.ProjectId)
+		v.exists("project_id", err)
+	}
+
+	if !v.has("user_id") {
+		_, err := env.DB.GetUser(Series.UserId)
+		v.exists("user_id", err)
+	}
+
+	startDate, hasStart := v.date("start_date", Series.StartDate)
+	endDate, hasEnd := v.date("end_date", Series.EndDate)
+
+	if hasStart && hasEnd && !endDate.After(startDate) {
+		v.add("end_date", "must be after start_date")
+	}
+
+	for _, exception := range Series.ExceptionDates {
+		if _, ok := v.date("exception_dates", exception); !ok && !v.has("exception_dates") {
+			v.add("exception_dates", "must be RFC 3339 dates")
+		}
+	}
+
+	if !v.has("recurrence_rule") && !v.has("time_zone") && !v.has("user_id") {
+		location, err := env.seriesLocation(Series)
+		if err != nil && v.err == nil {
+			v.err = err
+		} else if err == nil {
+			if _, err = globals.ParseRecurrence(Series.RecurrenceRule, location); err != nil {
+				v.add("recurrence_rule", err.Error())
+			}
+		}
+	}
+}
+
+//	validateTemplate(v *validation, Template TemplateIntermediate)
+/*	A template belongs to an existing user or company, but not both. Its slots are on existing projects,
+	other than the one of the vacations, from monday (1) to sunday (7), and don't overlap each other.
+*/
+func (env *Env) validateTemplate(v *validation, Template TemplateIntermediate) {
+	switch {
+	case (Template.UserId == 0) == (Template.CompanyId == 0):
+		v.add("user_id", "or company_id must be given, but not both")
+	case Template.UserId != 0:
+		_, err := env.DB.GetUser(Template.UserId)
+		v.exists("user_id", err)
+	default:
+		_, err := env.DB.GetCompany(Template.CompanyId)
+		v.exists("company_id", err)
+	}
+
+	vacationProject, err := env.DB.GetVacationProject()
+	if err != nil {
+		if v.err == nil {
+			v.err = err
+		}
+		return
+	}
+
+	// The first wrong slot is enough to say what is wrong
+	starts, ends := []time.Time{}, []time.Time{}
+	for i, slot := range Template.Slots {
+		start, startErr := time.Parse(globals.ClockLayout, slot.StartTime)
+		end, endErr := time.Parse(globals.ClockLayout, slot.EndTime)
+		_, projectErr := env.DB.GetProject(slot.ProjectId)
+
+		switch {
+		case projectErr == sql.ErrNoRows:
+			v.add("slots", "project "+strconv.FormatInt(slot.ProjectId, 10)+" does not exist")
+		case projectErr != nil:
+			v.exists("slots", projectErr)
+		case slot.ProjectId == vacationProject.ProjectId:
+			v.add("slots", "must not be on the vacation project")
+		case slot.Weekday < 1 || slot.Weekday > 7:
+			v.add("slots", "weekday must be from 1 for monday to 7 for sunday")
+		case startErr != nil || endErr != nil:
+			v.add("slots", "start_time and end_time must be hours like 08:30")
+		case !end.After(start):
+			v.add("slots", "end_time must be after start_time")
+		}
+		if v.has("slots") || v.err != nil {
+			return
+		}
+
+		for j, other := range Template.Slots[:i] {
+			if other.Weekday == slot.Weekday && starts[j].Before(end) && start.Before(ends[j]) {
+				v.add("slots", "must not overlap each other")
+				return
+			}
+		}
+		starts, ends = append(starts, start), append(ends, end)
+	}
+}
+
+//	validateHoliday(v *validation, Holiday model.Holiday)
+/*	A day off is a day like 2021-05-14 of an existing company, which is a holiday or a closure day.
+ */
+func (env *Env) validateHoliday(v *validation, Holiday model.Holiday) {
+	if Holiday.Day != "" {
+		if _, err := time.Parse(globals.DayLayout, Holiday.Day); err != nil {
+			v.add("day", "must be a day like 2021-05-14")
+		}
+	}
+
+	if Holiday.Kind != model.HolidayKindHoliday && Holiday.Kind != model.HolidayKindClosure {
+		v.add("kind", "must be "+model.HolidayKindHoliday+" or "+model.HolidayKindClosure)
+	}
+
+	if Holiday.CompanyId != 0 {
+		_, err := env.DB.GetCompany(Holiday.CompanyId)
+		v.exists("company_id", err)
+	}
+}
+
+//	validateTemplateApplication(v *validation, Application TemplateApplication)
+/*	A template is applied to an ISO week like 2021-W12, skipping some days like 2021-05-13.
+ */
+func validateTemplateApplication(v *validation, Application TemplateApplication) {
+	if Application.Week != "" {
+		if _, err := globals.ParseWeek(Application.Week, time.UTC); err != nil {
+			v.add("week", "must be an ISO week like 2021-W12")
+		}
+	}
+
+	for _, day := range Application.SkipDays {
+		if _, err := time.Parse(globals.DayLayout, day); err != nil {
+			v.add("skip_days", "must be days like 2021-05-13")
+			return
+		}
+	}
+}
+
+//	validateTimerStart(v *validation, Timer TimerStart)
+/*	A timer runs on an existing project, which is not the one of the vacations.
+ */
+func (env *Env) validateTimerStart(v *validation, Timer TimerStart) {
+	if v.has("project_id") {
+		return
+	}
+
+	_, err := env.DB.GetProject(Timer.ProjectId)
+	v.exists("project_id", err)
+
+	if vacationProject, err := env.DB.GetVacationProject(); err != nil && v.err == nil {
+		v.err = err
+	} else if err == nil && Timer.ProjectId == vacationProject.ProjectId {
+		v.add("project_id", "must not be the vacation project")
+	}
+}
+
+//	validateAccessTokenRequest(v *validation, Form AccessTokenRequest)
+/*	The scope of an API token must be a list of known permissions, and its expiration in the future.
+ */
+func validateAccessTokenRequest(v *validation, Form AccessTokenRequest) {
+	if !globals.ValidAccessTokenScope(Form.Scope) {
+		v.add("scope", "must be a list of read, write, <item>:read or <item>:write")
+	}
+
+	if Form.ExpiresAt != nil && !Form.ExpiresAt.After(time.Now()) {
+		v.add("expires_at", "must be in the future")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
 	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/handlers"
@@ -23,6 +24,12 @@ func main() {
 	var err error
 
 	globals.Init()
+	globals.PasswordRules = globals.PasswordPolicyFromEnvironment()
+	globals.SessionCookies = globals.CookieRulesFromEnvironment()
+	globals.CORS = globals.CORSRulesFromEnvironment()
+	globals.DefaultTimeZone = globals.TimeZoneFromEnvironment()
+	globals.LegacyAPI = globals.LegacyAPIRulesFromEnvironment()
+	globals.Timers = globals.TimerRulesFromEnvironment()
 
 	globals.Log.Info("Creating database")
 	if datastore, err = datastores.NewDatabase("myDatabase.db"); err != nil {
@@ -30,9 +37,33 @@ func main() {
 	}
 
 	e = handlers.Env{
-		DB: datastore,
+		DB:            datastore,
+		Notifier:      handlers.LogNotifier{},
+		AuthProviders: map[string]authentication.Provider{},
 	}
 
+	// The LDAP directory is used if it is configured in the environment
+	if ldapConfig, ok := authentication.LDAPConfigFromEnvironment(); ok {
+		globals.Log.Info("Using the LDAP directory " + ldapConfig.URL)
+		ldapProvider := &authentication.LDAPProvider{Config: ldapConfig}
+		e.AuthProviders[ldapProvider.Name()] = ldapProvider
+	}
+
+	// And so are the OpenID Connect identity providers
+	e.OIDCProviders = map[string]*authentication.OIDCProvider{}
+	for _, oidcConfig := range authentication.OIDCConfigsFromEnvironment() {
+		globals.Log.Info("Using the identity provider " + oidcConfig.Name + " : " + oidcConfig.Issuer)
+		e.OIDCProviders[oidcConfig.Name] = authentication.NewOIDCProvider(oidcConfig)
+	}
+
+	// The timers the users forgot to stop are stopped in the background
+	if globals.Timers.AutoStop {
+		go e.AutoStopTimers()
+	}
+
+	// And the occurrences of the recurring schedules are saved as the time goes
+	go e.AutoExtendSeries()
+
 	globals.Log.Info("Creating the routes")
 
 	r := mux.NewRouter()
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// AccessToken : A long-lived API token, used by the scripts instead of the password of their owner.
+/*	AccessTokenId : The id of the token.
+	UserId : The owner of the token. The token never has more permissions than him.
+	Name : What the token is used for, chosen by its owner.
+	TokenHash : The SHA-256 hash of the token. The token itself is only shown when it is created.
+	Prefix : The beginning of the token, so its owner can recognize it.
+	Scope : The space separated list of what the token can do, like "read" or "schedules:write".
+		Empty, the token has every permission of its owner.
+	CreatedAt : The creation date of the token.
+	ExpiresAt : The date after which the token is refused, if any.
+	LastUsedAt : The date of the last request made with the token, if any.
+*/
+type AccessToken struct {
+	AccessTokenId int64      `db:"access_token_id" json:"access_token_id"`
+	UserId        int64      `db:"user_id" json:"user_id"`
+	Name          string     `db:"name" json:"name"`
+	TokenHash     string     `db:"token_hash" json:"-"`
+	Prefix        string     `db:"prefix" json:"prefix"`
+	Scope         string     `db:"scope" json:"scope"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	ExpiresAt     *time.Time `db:"expires_at" json:"expires_at"`
+	LastUsedAt    *time.Time `db:"last_used_at" json:"last_used_at"`
+}
+
+type AccessTokens []AccessToken
@@ -13,8 +13,8 @@ import (
 */
 type Comment struct {
 	CommentId   int64  `db:"comment_id" json:"comment_id"`
-	ScheduleId  int64  `db:"schedule_id" json:"schedule_id"`
-	Comment     string `db:"comment" json:"comment"`
+	ScheduleId  int64  `db:"schedule_id" json:"schedule_id" validate:"required"`
+	Comment     string `db:"comment" json:"comment" validate:"required,max=2000"`
 	IsImportant bool   `db:"is_important" json:"is_important"`
 }
 
@@ -9,10 +9,15 @@ import (
 
 // Company : Represents a company.
 /*	CompanyName : The name of the company : Biomarqueurs/Biopass...
- */
+	TimeZone : The IANA zone of the users of the company that have none, like "Europe/Paris".
+		Empty to use the default zone of the application.
+	HolidayCalendar : The public holidays its users don't work on : FR, or FR-ALSACE-MOSELLE. Empty for none.
+*/
 type Company struct {
-	CompanyId   int64  `db:"company_id" json:"company_id"`
-	CompanyName string `db:"company_name" json:"company_name"`
+	CompanyId       int64  `db:"company_id" json:"company_id"`
+	CompanyName     string `db:"company_name" json:"company_name" validate:"required,max=100"`
+	TimeZone        string `db:"time_zone" json:"time_zone" validate:"timezone"`
+	HolidayCalendar string `db:"holiday_calendar" json:"holiday_calendar" validate:"holidaycalendar"`
 }
 
 type Companies []Company
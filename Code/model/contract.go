@@ -9,10 +9,30 @@ import (
 
 // Contract represents a type of contract.
 /*	ContractName : Alternance/Stage/CDI/CDD...
- */
+	BreakAfterMinutes : The minutes of work after which a break is mandatory, like 360 for 6 hours. 0 for no rule.
+	MinimumBreakMinutes : The minutes the mandatory break lasts at least, like 20. 0 for no rule.
+	MaxDailyMinutes : The most minutes of work in a day, like 600 for 10 hours, or 480 for an apprentice. 0 for no rule.
+	MaxWeeklyMinutes : The most minutes of work in a week, like 2880 for 48 hours, or 2100 for an apprentice. 0 for no rule.
+	MaxAverageWeeklyMinutes : The most minutes of work per week on average over 12 weeks, like 2640 for 44 hours. 0 for no rule.
+	MinDailyRestMinutes : The least minutes of rest between two days of work, like 660 for 11 hours, or 720 for an apprentice. 0 for no rule.
+	MinWeeklyRestMinutes : The least minutes of rest in a row during a week, like 2100 for 35 hours. 0 for no rule.
+	MaxNightMinutes : The most minutes of work during a night, from 21h to 6h, like 480. 0 for no rule.
+	NightWorkForbidden : No work at all during the nights, as for the young apprentices.
+	BlockViolations : The schedules breaking these rules are refused, instead of only being reported.
+*/
 type Contract struct {
-	ContractId   int64  `db:"contract_id" json:"contract_id"`
-	ContractName string `db:"contract_name" json:"contract_name"`
+	ContractId              int64  `db:"contract_id" json:"contract_id"`
+	ContractName            string `db:"contract_name" json:"contract_name" validate:"required,max=100"`
+	BreakAfterMinutes       int64  `db:"break_after_minutes" json:"break_after_minutes" validate:"min=0,max=1440"`
+	MinimumBreakMinutes     int64  `db:"minimum_break_minutes" json:"minimum_break_minutes" validate:"min=0,max=1440"`
+	MaxDailyMinutes         int64  `db:"max_daily_minutes" json:"max_daily_minutes" validate:"min=0,max=1440"`
+	MaxWeeklyMinutes        int64  `db:"max_weekly_minutes" json:"max_weekly_minutes" validate:"min=0,max=10080"`
+	MaxAverageWeeklyMinutes int64  `db:"max_average_weekly_minutes" json:"max_average_weekly_minutes" validate:"min=0,max=10080"`
+	MinDailyRestMinutes     int64  `db:"min_daily_rest_minutes" json:"min_daily_rest_minutes" validate:"min=0,max=1440"`
+	MinWeeklyRestMinutes    int64  `db:"min_weekly_rest_minutes" json:"min_weekly_rest_minutes" validate:"min=0,max=10080"`
+	MaxNightMinutes         int64  `db:"max_night_minutes" json:"max_night_minutes" validate:"min=0,max=540"`
+	NightWorkForbidden      bool   `db:"night_work_forbidden" json:"night_work_forbidden"`
+	BlockViolations         bool   `db:"block_violations" json:"block_violations"`
 }
 
 type Contracts []Contract
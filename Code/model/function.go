@@ -12,7 +12,7 @@ import (
  */
 type Function struct {
 	FunctionId   int64  `db:"function_id" json:"function_id"`
-	FunctionName string `db:"function_name" json:"function_name"`
+	FunctionName string `db:"function_name" json:"function_name" validate:"required,max=100"`
 }
 
 type Functions []Function
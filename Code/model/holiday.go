@@ -0,0 +1,30 @@
+package model
+
+import (
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The kinds of the days off of a company.
+const (
+	HolidayKindHoliday = "holiday"
+	HolidayKindClosure = "closure"
+)
+
+// Holiday : A day off of a company, added to the public holidays of its calendar, like a local holiday or a day the
+// company is closed. Nobody is expected to work on it, and the vacations don't count on it.
+/*	HolidayId : The id of the day off.
+	CompanyId : The company it belongs to.
+	Day : The day, like 2021-05-14, in the zone of the users.
+	Name : Why the day is off, like Pont de l'Ascension.
+	Kind : holiday, or closure for the days the company is closed.
+*/
+type Holiday struct {
+	HolidayId int64  `db:"holiday_id" json:"holiday_id"`
+	CompanyId int64  `db:"company_id" json:"company_id" validate:"required"`
+	Day       string `db:"day" json:"day" validate:"required"`
+	Name      string `db:"name" json:"name" validate:"required,max=100"`
+	Kind      string `db:"kind" json:"kind"`
+}
+
+type Holidays []Holiday
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Impersonation : A period during which a user that can add and modify users sees the API as another user.
+// The users are only kept as ids, so the record outlives them.
+/*	ImpersonationId : The id of the impersonation.
+	ImpersonatorId : The real user, who asked for the impersonation.
+	UserId : The impersonated user, whose permissions are used.
+	Reason : Why the impersonation was needed, like the number of a support ticket.
+	StartedAt : The start of the impersonation.
+	ExpiresAt : The date after which the impersonation token is refused.
+	EndedAt : The date the impersonation was stopped, if it was.
+*/
+type Impersonation struct {
+	ImpersonationId int64      `db:"impersonation_id" json:"impersonation_id"`
+	ImpersonatorId  int64      `db:"impersonator_id" json:"impersonator_id"`
+	UserId          int64      `db:"user_id" json:"user_id"`
+	Reason          string     `db:"reason" json:"reason"`
+	StartedAt       time.Time  `db:"started_at" json:"started_at"`
+	ExpiresAt       time.Time  `db:"expires_at" json:"expires_at"`
+	EndedAt         *time.Time `db:"ended_at" json:"ended_at"`
+}
+
+type Impersonations []Impersonation
+
+// ImpersonationAction : A request that modified something, made during an impersonation.
+/*	ImpersonationActionId : The id of the action.
+	ImpersonationId : The impersonation during which the request was made.
+	ImpersonatorId : The real user who made the request.
+	UserId : The impersonated user, in whose name the request was made.
+	Method, Path : The request.
+	StatusCode : The code of the answer, or 0 if the request did not end.
+	CreatedAt : The date of the request.
+*/
+type ImpersonationAction struct {
+	ImpersonationActionId int64     `db:"impersonation_action_id" json:"impersonation_action_id"`
+	ImpersonationId       int64     `db:"impersonation_id" json:"impersonation_id"`
+	ImpersonatorId        int64     `db:"impersonator_id" json:"impersonator_id"`
+	UserId                int64     `db:"user_id" json:"user_id"`
+	Method                string    `db:"method" json:"method"`
+	Path                  string    `db:"path" json:"path"`
+	StatusCode            int64     `db:"status_code" json:"status_code"`
+	CreatedAt             time.Time `db:"created_at" json:"created_at"`
+}
+
+type ImpersonationActions []ImpersonationAction
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// LockDate : A change of the date before which the schedules and the vacations of the whole company can't change,
+// once the payroll of the months before it is closed. The last change is the current lock date, the others are its history.
+/*	LockId : The id of the change.
+	LockDate : The new lock date. Empty when everything was unlocked.
+	AuthorId : The manager who locked or unlocked the hours.
+	Reason : Why the hours were unlocked, or locked.
+	CreatedAt : The date of the change.
+*/
+type LockDate struct {
+	LockId    int64        `db:"lock_id" json:"lock_id"`
+	LockDate  sql.NullTime `db:"lock_date" json:"lock_date"`
+	AuthorId  int64        `db:"author_id" json:"author_id"`
+	Reason    string       `db:"reason" json:"reason"`
+	CreatedAt time.Time    `db:"created_at" json:"created_at"`
+}
+
+type LockDates []LockDate
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"database/sql"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// LoginAttempt : Counts the failed login attempts for a mail address or for a client address.
+/*	AttemptKey : What the attempts are counted for : "mail:<mail>" or "ip:<address>".
+	FailedAttempts : The number of failed attempts since the last success or the last reset.
+	LastFailure : The date of the last failed attempt.
+	LockedUntil : The date until which no attempt is allowed, if the key is locked.
+*/
+type LoginAttempt struct {
+	AttemptKey     string       `db:"attempt_key" json:"attempt_key"`
+	FailedAttempts int64        `db:"failed_attempts" json:"failed_attempts"`
+	LastFailure    sql.NullTime `db:"last_failure" json:"last_failure"`
+	LockedUntil    sql.NullTime `db:"locked_until" json:"locked_until"`
+}
+
+type LoginAttempts []LoginAttempt
@@ -7,10 +7,13 @@ import (
 
 // Project : Represents a company.
 /*	ProjectName : The name of the project : Biorcell 3D/Lightspot...
- */
+	AllowOverlaps : Wether the schedules of the project can cover the same hours as the other schedules of a user,
+		like on-call duties.
+*/
 type Project struct {
-	ProjectId   int64  `db:"project_id" json:"project_id"`
-	ProjectName string `db:"project_name" json:"project_name"`
+	ProjectId     int64  `db:"project_id" json:"project_id"`
+	ProjectName   string `db:"project_name" json:"project_name" validate:"required,max=100"`
+	AllowOverlaps bool   `db:"allow_overlaps" json:"allow_overlaps"`
 }
 
 type Projects []Project
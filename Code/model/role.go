@@ -11,14 +11,16 @@ import (
 	CanSeeOtherSchedules : Wether this role can see other Users' schedules.
 	CanAddProjects : Wether this role can add data in the Projects table.
 	CanSeeReports : Wether this role can see the reports (a report includes all schedules and their comments and users)
+	RequireTwoFactor : Wether the users of this role must enable the two-factor authentication before using the API.
 */
 type Role struct {
 	RoleId               int64  `db:"role_id" json:"role_id"`
-	RoleName             string `db:"role_name" json:"role_name"`
+	RoleName             string `db:"role_name" json:"role_name" validate:"required,max=50"`
 	CanAddAndModifyUsers bool   `db:"can_add_and_modify_users" json:"can_add_and_modify_users"`
 	CanSeeOtherSchedules bool   `db:"can_see_other_schedules" json:"can_see_other_schedules"`
 	CanAddProjects       bool   `db:"can_add_projects" json:"can_add_projects"`
 	CanSeeReports        bool   `db:"can_see_reports" json:"can_see_reports"`
+	RequireTwoFactor     bool   `db:"require_two_factor" json:"require_two_factor"`
 }
 
 type Roles []Role
@@ -2,6 +2,7 @@ package model
 
 import (
 	"database/sql"
+	"time"
 
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
@@ -10,13 +11,45 @@ import (
 // Schedule : Represents a period of time during which something happened.
 /*	ProjectId : The id of the project this schedule is linked to.
 	StartDate : The start date of this Schedule.
-	EndDate : The end date of this schedule.
+	EndDate : The end date of this schedule. Not valid while the schedule is a running timer.
+	SeriesId : The recurring series this schedule is an occurrence of, if any.
+	Breaks : The breaks taken during this schedule, when they are fetched with it. They don't count as worked.
 */
 type Schedule struct {
-	ScheduleId int64        `db:"schedule_id" json:"schedule_id"`
-	ProjectId  int64        `db:"project_id" json:"project_id"`
-	StartDate  sql.NullTime `db:"start_date" json:"start_date"`
-	EndDate    sql.NullTime `db:"end_date" json:"end_date"`
+	ScheduleId int64          `db:"schedule_id" json:"schedule_id"`
+	ProjectId  int64          `db:"project_id" json:"project_id"`
+	StartDate  sql.NullTime   `db:"start_date" json:"start_date"`
+	EndDate    sql.NullTime   `db:"end_date" json:"end_date"`
+	SeriesId   sql.NullInt64  `db:"series_id" json:"series_id"`
+	Breaks     ScheduleBreaks `db:"-" json:"breaks,omitempty"`
 }
 
 type Schedules []Schedule
+
+// ScheduleBreak : A break taken during a schedule, like the lunch, so a day is not split into several schedules.
+/*	BreakId : The id of the break.
+	ScheduleId : The schedule the break is taken during.
+	StartDate : The start date of the break, after the start of its schedule.
+	EndDate : The end date of the break, before the end of its schedule.
+*/
+type ScheduleBreak struct {
+	BreakId    int64     `db:"break_id" json:"break_id"`
+	ScheduleId int64     `db:"schedule_id" json:"schedule_id"`
+	StartDate  time.Time `db:"start_date" json:"start_date"`
+	EndDate    time.Time `db:"end_date" json:"end_date"`
+}
+
+type ScheduleBreaks []ScheduleBreak
+
+// ScheduleOverlap : Two schedules of a user that cover the same hours.
+/*	UserId : The id of the user linked to both schedules.
+	First : The schedule that starts first.
+	Second : The other schedule.
+*/
+type ScheduleOverlap struct {
+	UserId int64    `json:"user_id"`
+	First  Schedule `json:"first"`
+	Second Schedule `json:"second"`
+}
+
+type ScheduleOverlaps []ScheduleOverlap
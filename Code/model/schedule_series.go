@@ -0,0 +1,56 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ScheduleSeries : Schedules of a user repeated by a recurrence rule of RFC 5545, like every monday from 8 to 12.
+// Its occurrences are saved as schedules up to a horizon after now, and keep the id of their series.
+/*	SeriesId : The id of the series.
+	ProjectId : The project of the occurrences.
+	UserId : The user the occurrences are linked to.
+	StartDate : The start of the first occurrence, the DTSTART of the rule.
+	EndDate : The end of the first occurrence, which gives how long the others last.
+	TimeZone : The IANA zone the rule is followed in, so the occurrences keep their hours when the clocks change.
+	RecurrenceRule : The RRULE, like FREQ=WEEKLY;BYDAY=MO,TU.
+	ExceptionDates : The starts of the occurrences removed from the series (the EXDATEs), in RFC 3339 and UTC,
+		separated by commas.
+	GeneratedUntil : The occurrences starting before this date are saved as schedules.
+*/
+type ScheduleSeries struct {
+	SeriesId       int64     `db:"series_id" json:"series_id"`
+	ProjectId      int64     `db:"project_id" json:"project_id"`
+	UserId         int64     `db:"user_id" json:"user_id"`
+	StartDate      time.Time `db:"start_date" json:"start_date"`
+	EndDate        time.Time `db:"end_date" json:"end_date"`
+	TimeZone       string    `db:"time_zone" json:"time_zone"`
+	RecurrenceRule string    `db:"recurrence_rule" json:"recurrence_rule"`
+	ExceptionDates string    `db:"exception_dates" json:"exception_dates"`
+	GeneratedUntil time.Time `db:"generated_until" json:"generated_until"`
+}
+
+type ScheduleSeriesList []ScheduleSeries
+
+// Exceptions : Returns the starts of the occurrences removed from the series. The unreadable ones are ignored.
+func (series *ScheduleSeries) Exceptions() []time.Time {
+	exceptions := []time.Time{}
+	for _, value := range strings.Split(series.ExceptionDates, ",") {
+		if exception, err := time.Parse(time.RFC3339, value); err == nil {
+			exceptions = append(exceptions, exception)
+		}
+	}
+	return exceptions
+}
+
+// SetExceptions : Replaces the starts of the occurrences removed from the series.
+func (series *ScheduleSeries) SetExceptions(Exceptions []time.Time) {
+	values := []string{}
+	for _, exception := range Exceptions {
+		values = append(values, exception.UTC().Format(time.RFC3339))
+	}
+	series.ExceptionDates = strings.Join(values, ",")
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"database/sql"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ScheduleTemplate : A typical week of schedules, saved to fill any week quickly. A template belongs to a user,
+// or is shared with the users of a company.
+/*	TemplateId : The id of the template.
+	TemplateName : The name of the template, like "Week at the office".
+	UserId : The user the template belongs to. Null for the templates of a company.
+	CompanyId : The company the template is shared with. Null for the templates of a user.
+*/
+type ScheduleTemplate struct {
+	TemplateId   int64         `db:"template_id" json:"template_id"`
+	TemplateName string        `db:"template_name" json:"template_name"`
+	UserId       sql.NullInt64 `db:"user_id" json:"user_id"`
+	CompanyId    sql.NullInt64 `db:"company_id" json:"company_id"`
+}
+
+type ScheduleTemplates []ScheduleTemplate
+
+// TemplateSlot : A time slot of a template, on a project and a day of the week.
+/*	SlotId : The id of the slot.
+	TemplateId : The template of the slot.
+	ProjectId : The project of the schedule the slot gives.
+	Weekday : The day of the week, from 1 for monday to 7 for sunday, as in the ISO weeks.
+	StartTime : The hour the slot starts at, like "08:00", in the zone of the user the template is applied to.
+	EndTime : The hour the slot ends at, after its start : a slot can't go over midnight.
+*/
+type TemplateSlot struct {
+	SlotId     int64  `db:"slot_id" json:"slot_id"`
+	TemplateId int64  `db:"template_id" json:"template_id"`
+	ProjectId  int64  `db:"project_id" json:"project_id"`
+	Weekday    int    `db:"weekday" json:"weekday"`
+	StartTime  string `db:"start_time" json:"start_time"`
+	EndTime    string `db:"end_time" json:"end_time"`
+}
+
+type TemplateSlots []TemplateSlot
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The states of a timesheet period.
+const (
+	TimesheetDraft     = "draft"
+	TimesheetSubmitted = "submitted"
+	TimesheetApproved  = "approved"
+	TimesheetRejected  = "rejected"
+)
+
+// TimesheetPeriod : A week or a month of the timesheet of a user, submitted to a manager for approval.
+// The user is only kept as an id, so the record outlives him.
+/*	PeriodId : The id of the period.
+	UserId : The user whose hours are in the period.
+	Period : The week or the month, like 2021-W12 or 2021-03.
+	StartDate : The start of the period, in the zone of the user when it was first submitted.
+	EndDate : The end of the period, excluded.
+	State : draft, submitted, approved or rejected. The schedules and the comments of an approved period are locked.
+*/
+type TimesheetPeriod struct {
+	PeriodId  int64     `db:"period_id" json:"period_id"`
+	UserId    int64     `db:"user_id" json:"user_id"`
+	Period    string    `db:"period" json:"period"`
+	StartDate time.Time `db:"start_date" json:"start_date"`
+	EndDate   time.Time `db:"end_date" json:"end_date"`
+	State     string    `db:"state" json:"state"`
+}
+
+type TimesheetPeriods []TimesheetPeriod
+
+// TimesheetEvent : A change of the state of a timesheet period, kept as its history.
+/*	EventId : The id of the change.
+	PeriodId : The period that changed.
+	State : The new state of the period.
+	AuthorId : The user who changed it : the owner for a submission, a manager otherwise.
+	Reason : Why the period was rejected or reopened, if given.
+	CreatedAt : The date of the change.
+*/
+type TimesheetEvent struct {
+	EventId   int64     `db:"event_id" json:"event_id"`
+	PeriodId  int64     `db:"period_id" json:"period_id"`
+	State     string    `db:"state" json:"state"`
+	AuthorId  int64     `db:"author_id" json:"author_id"`
+	Reason    string    `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type TimesheetEvents []TimesheetEvent
@@ -0,0 +1,32 @@
+package model
+
+import (
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TwoFactor : The TOTP two-factor authentication of a user.
+/*	UserId : The id of the user.
+	Secret : The shared secret, encoded in base32. It is never sent back once the enrollment started.
+	Enabled : Wether the enrollment got confirmed with a valid code. Until then, the secret is not used to log in.
+	LastUsedStep : The time step of the last code used, so that a code can't be used twice.
+*/
+type TwoFactor struct {
+	UserId       int64  `db:"user_id" json:"user_id"`
+	Secret       string `db:"secret" json:"-"`
+	Enabled      bool   `db:"enabled" json:"enabled"`
+	LastUsedStep int64  `db:"last_used_step" json:"-"`
+}
+
+type TwoFactors []TwoFactor
+
+// RecoveryCode : A single-use code that replaces a TOTP code when the device is lost.
+/*	UserId : The id of the user.
+	CodeHash : The SHA-256 hash of the code.
+*/
+type RecoveryCode struct {
+	UserId   int64  `db:"user_id" json:"user_id"`
+	CodeHash string `db:"code_hash" json:"-"`
+}
+
+type RecoveryCodes []RecoveryCode
@@ -15,18 +15,33 @@ import (
 	Mail : User's UCA email address.
 	TheoricalHoursWorked : The theorical number of hours the user has to work every week (probably 35).
 	VacationHours : The remaining paid vacation hours the user has.
+	MustChangePassword : Wether the user has to change his password before using the API.
+	AuthProvider : How the user is authenticated : "local" (or empty) for the password stored in the database,
+		or the name of a directory provider, like "ldap".
+	TimeZone : The IANA zone of the user, like "Europe/Paris", used for his days and weeks.
+		Empty to use the zone of his company.
 */
 type User struct {
 	UserId               int64  `db:"user_id" json:"user_id"`
-	ContractId           int64  `db:"contract_id" json:"contract_id"`
-	RoleId               int64  `db:"role_id" json:"role_id"`
-	Username             string `db:"username" json:"username"`
+	ContractId           int64  `db:"contract_id" json:"contract_id" validate:"required"`
+	RoleId               int64  `db:"role_id" json:"role_id" validate:"required"`
+	Username             string `db:"username" json:"username" validate:"max=100"`
 	Password             string `db:"password" json:"password"`
-	LastName             string `db:"last_name" json:"last_name"`
-	FirstName            string `db:"first_name" json:"first_name"`
-	Mail                 string `db:"mail" json:"mail"`
-	TheoricalHoursWorked int64  `db:"theorical_hours_worked" json:"theorical_hours_worked"`
-	VacationHours        int64  `db:"vacation_hours" json:"vacation_hours"`
+	LastName             string `db:"last_name" json:"last_name" validate:"max=100"`
+	FirstName            string `db:"first_name" json:"first_name" validate:"max=100"`
+	Mail                 string `db:"mail" json:"mail" validate:"required,mail,max=254"`
+	TheoricalHoursWorked int64  `db:"theorical_hours_worked" json:"theorical_hours_worked" validate:"min=0"`
+	VacationHours        int64  `db:"vacation_hours" json:"vacation_hours" validate:"min=0"`
+	MustChangePassword   bool   `db:"must_change_password" json:"must_change_password"`
+	AuthProvider         string `db:"auth_provider" json:"auth_provider" validate:"max=50"`
+	TimeZone             string `db:"time_zone" json:"time_zone" validate:"timezone"`
 }
 
 type Users []User
+
+//	UsesLocalAuthentication() bool
+/*	Tells wether the user is authenticated with the password stored in the database.
+ */
+func (user User) UsesLocalAuthentication() bool {
+	return user.AuthProvider == "" || user.AuthProvider == "local"
+}
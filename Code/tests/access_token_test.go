@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : CreateAccessToken(Token model.AccessToken) (int64, error)
+	TESTED : GetAccessToken(AccessTokenId int64) (model.AccessToken, error)
+	TESTED : GetAccessTokenFromHash(TokenHash string) (model.AccessToken, error)
+	TESTED : GetAccessTokensOfUser(UserId int64) (model.AccessTokens, error)
+	TESTED : UpdateAccessTokenLastUse(AccessTokenId int64, LastUsedAt time.Time) error
+	TESTED : DeleteAccessToken(AccessTokenId int64) error
+	TESTED : GenerateAccessToken() (string, error)
+	TESTED : ValidAccessTokenScope(Scope string) bool
+	TESTED : AccessTokenScopeAllows(Scope string, Item string, Method string) bool
+*/
+func TestAccessToken(t *testing.T) {
+	// Initializing variables
+	var (
+		err           error
+		testDatastore *datastores.ConcreteDatastore
+		dbToken       model.AccessToken
+		dbTokens      model.AccessTokens
+		rawToken      string
+	)
+
+	if testDatastore, err = datastores.NewDatabase("myTestDatabase.db"); err != nil {
+		t.Error(err)
+	}
+
+	//
+	// Test GenerateAccessToken
+	//
+	if rawToken, err = globals.GenerateAccessToken(); err != nil {
+		t.Error(err)
+	}
+
+	if !strings.HasPrefix(rawToken, globals.AccessTokenPrefix) {
+		t.Error("The API token does not start with the prefix")
+	}
+
+	if otherToken, _ := globals.GenerateAccessToken(); otherToken == rawToken {
+		t.Error("The same API token was generated twice")
+	}
+
+	globals.Log.Debug("GenerateAccessToken test - PASSED")
+
+	//
+	// Test CreateAccessToken, GetAccessToken and GetAccessTokenFromHash
+	//
+	expiration := time.Now().Add(time.Hour).Truncate(time.Second)
+	token1 := model.AccessToken{
+		UserId:    1,
+		Name:      "Payroll",
+		TokenHash: globals.HashAccessToken(rawToken),
+		Prefix:    rawToken[:10],
+		Scope:     "schedules:read users:read",
+		CreatedAt: time.Now().Truncate(time.Second),
+		ExpiresAt: &expiration,
+	}
+
+	token2 := model.AccessToken{
+		UserId:    1,
+		Name:      "Backup",
+		TokenHash: globals.HashAccessToken("another token"),
+		Prefix:    "gtp_abcdef",
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+
+	if token1.AccessTokenId, err = testDatastore.CreateAccessToken(token1); err != nil {
+		t.Error(err)
+	}
+
+	if token2.AccessTokenId, err = testDatastore.CreateAccessToken(token2); err != nil {
+		t.Error(err)
+	}
+
+	if dbToken, err = testDatastore.GetAccessToken(token1.AccessTokenId); err != nil {
+		t.Error(err)
+	}
+
+	if !cmp.Equal(token1, dbToken) {
+		t.Error("API tokens are not the same")
+	}
+
+	if dbToken, err = testDatastore.GetAccessTokenFromHash(globals.HashAccessToken(rawToken)); err != nil {
+		t.Error(err)
+	}
+
+	if !cmp.Equal(token1, dbToken) {
+		t.Error("API tokens are not the same")
+	}
+
+	if _, err = testDatastore.GetAccessTokenFromHash(globals.HashAccessToken("unknown token")); err != sql.ErrNoRows {
+		t.Error("An unknown API token was found")
+	}
+
+	globals.Log.Debug("CreateAccessToken test - PASSED")
+
+	//
+	// Test GetAccessTokensOfUser
+	//
+	if dbTokens, err = testDatastore.GetAccessTokensOfUser(1); err != nil {
+		t.Error(err)
+	}
+
+	if !cmp.Equal(dbTokens, model.AccessTokens{token1, token2}) {
+		t.Error("API tokens are not the same")
+	}
+
+	globals.Log.Debug("GetAccessTokensOfUser test - PASSED")
+
+	//
+	// Test UpdateAccessTokenLastUse
+	//
+	lastUse := time.Now().Truncate(time.Second)
+	if err = testDatastore.UpdateAccessTokenLastUse(token2.AccessTokenId, lastUse); err != nil {
+		t.Error(err)
+	}
+
+	if dbToken, err = testDatastore.GetAccessToken(token2.AccessTokenId); err != nil {
+		t.Error(err)
+	}
+
+	if dbToken.LastUsedAt == nil || !dbToken.LastUsedAt.Equal(lastUse) {
+		t.Error("The last use was not saved")
+	}
+
+	globals.Log.Debug("UpdateAccessTokenLastUse test - PASSED")
+
+	//
+	// Test DeleteAccessToken
+	//
+	if err = testDatastore.DeleteAccessToken(token1.AccessTokenId); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = testDatastore.GetAccessToken(token1.AccessTokenId); err == nil {
+		t.Error("A revoked API token was found")
+	}
+
+	testDatastore.DeleteAccessToken(token2.AccessTokenId)
+
+	globals.Log.Debug("DeleteAccessToken test - PASSED")
+
+	//
+	// Test ValidAccessTokenScope
+	//
+	for _, scope := range []string{"", "read", "write", "read schedules:write", "users:read projects:write"} {
+		if !globals.ValidAccessTokenScope(scope) {
+			t.Error("A valid scope was refused :", scope)
+		}
+	}
+
+	for _, scope := range []string{"admin", "schedules", "schedules:delete", "unknown:read", ":read"} {
+		if globals.ValidAccessTokenScope(scope) {
+			t.Error("An invalid scope was accepted :", scope)
+		}
+	}
+
+	globals.Log.Debug("ValidAccessTokenScope test - PASSED")
+
+	//
+	// Test AccessTokenScopeAllows
+	//
+	allowed := []struct {
+		scope, item, method string
+		allowed             bool
+	}{
+		{"", "users", "DELETE", true},
+		{"read", "schedules", "GET", true},
+		{"read", "schedules", "POST", false},
+		{"write", "schedules", "GET", true},
+		{"schedules:read", "schedules", "GET", true},
+		{"schedules:read", "users", "GET", false},
+		{"schedules:read", "schedules", "PATCH", false},
+		{"schedules:write", "schedules", "DELETE", true},
+		{"schedules:write", "schedules", "GET", true},
+		{"schedules:write", "", "POST", false},
+		{"users:read schedules:write", "users", "GET", true},
+	}
+
+	for _, test := range allowed {
+		if globals.AccessTokenScopeAllows(test.scope, test.item, test.method) != test.allowed {
+			t.Error("Wrong answer for", test.scope, test.item, test.method)
+		}
+	}
+
+	globals.Log.Debug("AccessTokenScopeAllows test - PASSED")
+
+	testDatastore.CloseDatabase()
+}
@@ -0,0 +1,34 @@
+package tests
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+/*
+	TESTED : LegacyAPIRulesFromEnvironment() LegacyAPIRules
+*/
+func TestAPIVersions(t *testing.T) {
+	if rules := globals.LegacyAPIRulesFromEnvironment(); !rules.Enabled || !rules.Sunset.Equal(globals.DefaultLegacyAPIRules().Sunset) {
+		t.Error("Wrong default rules :", rules)
+	}
+
+	os.Setenv("LEGACY_API_ENABLED", "false")
+	os.Setenv("LEGACY_API_SUNSET", "2027-01-15")
+	if rules := globals.LegacyAPIRulesFromEnvironment(); rules.Enabled || !rules.Sunset.Equal(time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Error("The environment was not used :", rules)
+	}
+
+	os.Setenv("LEGACY_API_SUNSET", "2027-01-15T12:00:00+01:00")
+	if rules := globals.LegacyAPIRulesFromEnvironment(); !rules.Sunset.Equal(time.Date(2027, 1, 15, 11, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong sunset :", rules.Sunset)
+	}
+
+	os.Unsetenv("LEGACY_API_ENABLED")
+	os.Unsetenv("LEGACY_API_SUNSET")
+
+	globals.Log.Debug("LegacyAPIRulesFromEnvironment test - PASSED")
+}
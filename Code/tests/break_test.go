@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : CreateBreak(Break model.ScheduleBreak) (int64, error)
+	TESTED : GetBreak(BreakId int64) (model.ScheduleBreak, error)
+	TESTED : GetBreaksOfSchedule(ScheduleId int64) (model.ScheduleBreaks, error)
+	TESTED : GetBreaksOfUser(UserId int64) (model.ScheduleBreaks, error)
+	TESTED : UpdateBreak(Break model.ScheduleBreak) (model.ScheduleBreak, error)
+	TESTED : DeleteBreak(BreakId int64) error
+	TESTED : The breaks dropped by UpdateSchedule and DeleteSchedule
+*/
+func TestBreak(t *testing.T) {
+	var (
+		err           error
+		userId        int64
+		projectId     int64
+		scheduleId    int64
+		lunchId       int64
+		coffeeId      int64
+		scheduleBreak model.ScheduleBreak
+		breaks        model.ScheduleBreaks
+	)
+
+	testDatastore, err := datastores.NewDatabase("myTestDatabase.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userId, _ = testDatastore.CreateUser(model.User{ContractId: 1, RoleId: 3, Mail: "break@user.com"})
+	projectId, _ = testDatastore.CreateProject(model.Project{ProjectName: "Break project"})
+	schedule := scheduleBetween(projectId, 8, 17)
+	if scheduleId, err = testDatastore.CreateSchedule(schedule, userId); err != nil {
+		t.Fatal(err)
+	}
+	schedule.ScheduleId = scheduleId
+
+	at := func(Hour int, Minute int) time.Time {
+		return time.Date(2021, 3, 1, Hour, Minute, 0, 0, time.UTC)
+	}
+
+	//
+	// Test CreateBreak, GetBreak, GetBreaksOfSchedule and GetBreaksOfUser
+	//
+	lunch := model.ScheduleBreak{ScheduleId: scheduleId, StartDate: at(12, 0), EndDate: at(13, 0)}
+	if lunchId, err = testDatastore.CreateBreak(lunch); err != nil {
+		t.Fatal(err)
+	}
+	coffee := model.ScheduleBreak{ScheduleId: scheduleId, StartDate: at(10, 0), EndDate: at(10, 15)}
+	if coffeeId, err = testDatastore.CreateBreak(coffee); err != nil {
+		t.Fatal(err)
+	}
+
+	if scheduleBreak, err = testDatastore.GetBreak(lunchId); err != nil || scheduleBreak.ScheduleId != scheduleId || !scheduleBreak.StartDate.Equal(at(12, 0)) || !scheduleBreak.EndDate.Equal(at(13, 0)) {
+		t.Error("Wrong break :", scheduleBreak, err)
+	}
+	if breaks, err = testDatastore.GetBreaksOfSchedule(scheduleId); err != nil || len(breaks) != 2 || breaks[0].BreakId != coffeeId {
+		t.Error("Wrong breaks of the schedule :", breaks, err)
+	}
+	if breaks, err = testDatastore.GetBreaksOfUser(userId); err != nil || len(breaks) != 2 || breaks[1].BreakId != lunchId {
+		t.Error("Wrong breaks of the user :", breaks, err)
+	}
+
+	globals.Log.Debug("CreateBreak test - PASSED")
+
+	//
+	// Test UpdateBreak and DeleteBreak
+	//
+	scheduleBreak.StartDate, scheduleBreak.EndDate = at(12, 30), at(13, 30)
+	if _, err = testDatastore.UpdateBreak(scheduleBreak); err != nil {
+		t.Fatal(err)
+	}
+	if scheduleBreak, _ = testDatastore.GetBreak(lunchId); !scheduleBreak.StartDate.Equal(at(12, 30)) {
+		t.Error("The break was not changed :", scheduleBreak)
+	}
+
+	if err = testDatastore.DeleteBreak(coffeeId); err != nil {
+		t.Error(err)
+	}
+	if _, err = testDatastore.GetBreak(coffeeId); err != sql.ErrNoRows {
+		t.Error("The break was not deleted :", err)
+	}
+
+	globals.Log.Debug("UpdateBreak test - PASSED")
+
+	//
+	// The breaks out of the new hours of a schedule are dropped, and the other ones go with it
+	//
+	if _, err = testDatastore.CreateBreak(coffee); err != nil {
+		t.Fatal(err)
+	}
+	schedule.StartDate.Time = at(11, 0)
+	if _, err = testDatastore.UpdateSchedule(schedule); err != nil {
+		t.Fatal(err)
+	}
+	if breaks, _ = testDatastore.GetBreaksOfSchedule(scheduleId); len(breaks) != 1 || breaks[0].BreakId != lunchId {
+		t.Error("Wrong breaks of the shortened schedule :", breaks)
+	}
+
+	if err = testDatastore.DeleteUserSchedule(model.UserSchedule{UserId: userId, ScheduleId: scheduleId}); err != nil {
+		t.Error(err)
+	}
+	if err = testDatastore.DeleteSchedule(scheduleId); err != nil {
+		t.Error("Could not delete a schedule with breaks :", err)
+	}
+	if _, err = testDatastore.GetBreak(lunchId); err != sql.ErrNoRows {
+		t.Error("The breaks of the schedule were not deleted :", err)
+	}
+
+	globals.Log.Debug("Breaks of the changed schedules test - PASSED")
+
+	for _, err = range []error{
+		testDatastore.DeleteProject(projectId),
+		testDatastore.DeleteUser(userId),
+	} {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
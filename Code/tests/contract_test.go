@@ -183,6 +183,10 @@ func TestContract(t *testing.T) {
 	var updatedContract model.Contract
 
 	contract1.ContractName = "New contract name"
+	contract1.BreakAfterMinutes, contract1.MinimumBreakMinutes = 360, 20
+	contract1.MaxDailyMinutes, contract1.MaxWeeklyMinutes, contract1.MaxAverageWeeklyMinutes = 480, 2100, 2100
+	contract1.MinDailyRestMinutes, contract1.MinWeeklyRestMinutes = 720, 2880
+	contract1.NightWorkForbidden, contract1.BlockViolations = true, true
 	testDatastore.UpdateContract(contract1)
 
 	// Getting the updated contract
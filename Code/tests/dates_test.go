@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+/*
+	TESTED : ParseDate(Value string) (time.Time, error)
+	TESTED : StartOfWeek(Date time.Time, Location *time.Location) time.Time
+	TESTED : AddDays(Date time.Time, Days int, Location *time.Location) time.Time
+	TESTED : TimeZoneFromEnvironment() *time.Location
+	TESTED : ParseWeek(Value string, Location *time.Location) (time.Time, error)
+	TESTED : ParseMonth(Value string, Location *time.Location) (time.Time, error)
+	TESTED : ParsePeriod(Value string, Location *time.Location) (time.Time, time.Time, error)
+*/
+func TestDates(t *testing.T) {
+	paris, err := globals.LoadTimeZone("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//
+	// Test ParseDate
+	//
+	date, err := globals.ParseDate("2021-03-28T04:00:00+02:00")
+	if err != nil || !date.Equal(time.Date(2021, 3, 28, 2, 0, 0, 0, time.UTC)) || date.Location() != time.UTC {
+		t.Error("Wrong date :", date, err)
+	}
+
+	if _, err = globals.ParseDate("2021-03-28 04:00:00"); err == nil {
+		t.Error("A date without offset was accepted")
+	}
+
+	if globals.FormatDate(date, paris) != "2021-03-28T04:00:00+02:00" {
+		t.Error("Wrong formatted date :", globals.FormatDate(date, paris))
+	}
+
+	globals.Log.Debug("ParseDate test - PASSED")
+
+	//
+	// Test StartOfWeek
+	//
+	for _, day := range []int{22, 24, 28} {
+		monday := globals.StartOfWeek(time.Date(2021, 3, day, 23, 30, 0, 0, paris), paris)
+		if !monday.Equal(time.Date(2021, 3, 22, 0, 0, 0, 0, paris)) {
+			t.Error("Wrong start of the week of the", day, ":", monday)
+		}
+	}
+
+	globals.Log.Debug("StartOfWeek test - PASSED")
+
+	//
+	// Test AddDays
+	//
+
+	// The day the clocks go forward lasts 23 hours, and the one they go back 25 hours
+	midnight := time.Date(2021, 3, 28, 0, 0, 0, 0, paris)
+	if length := globals.AddDays(midnight, 1, paris).Sub(midnight); length != 23*time.Hour {
+		t.Error("Wrong length of the 28th of march :", length)
+	}
+
+	midnight = time.Date(2021, 10, 31, 0, 0, 0, 0, paris)
+	if length := globals.AddDays(midnight, 1, paris).Sub(midnight); length != 25*time.Hour {
+		t.Error("Wrong length of the 31st of october :", length)
+	}
+
+	globals.Log.Debug("AddDays test - PASSED")
+
+	//
+	// Test TimeZoneFromEnvironment
+	//
+	os.Setenv("DEFAULT_TIME_ZONE", "America/New_York")
+	if location := globals.TimeZoneFromEnvironment(); location.String() != "America/New_York" {
+		t.Error("The zone of the environment was not used :", location)
+	}
+
+	os.Setenv("DEFAULT_TIME_ZONE", "Local")
+	if location := globals.TimeZoneFromEnvironment(); location.String() != "Europe/Paris" {
+		t.Error("The local zone of the server was used :", location)
+	}
+	os.Unsetenv("DEFAULT_TIME_ZONE")
+
+	globals.Log.Debug("TimeZoneFromEnvironment test - PASSED")
+
+	//
+	// Test ParseWeek
+	//
+	for value, monday := range map[string]time.Time{
+		"2021-W12": time.Date(2021, 3, 22, 0, 0, 0, 0, paris),
+		"2021-W01": time.Date(2021, 1, 4, 0, 0, 0, 0, paris),
+		"2020-W53": time.Date(2020, 12, 28, 0, 0, 0, 0, paris),
+		"2026-W01": time.Date(2025, 12, 29, 0, 0, 0, 0, paris),
+		"2026-W42": time.Date(2026, 10, 12, 0, 0, 0, 0, paris),
+	} {
+		if week, err := globals.ParseWeek(value, paris); err != nil || !week.Equal(monday) {
+			t.Error("Wrong monday of the week", value, ":", week, err)
+		}
+	}
+
+	for _, value := range []string{"2021-W53", "2021-W00", "2021-12", "2021-W1", "2021-W012"} {
+		if _, err := globals.ParseWeek(value, paris); err == nil {
+			t.Error("A wrong week was accepted :", value)
+		}
+	}
+
+	globals.Log.Debug("ParseWeek test - PASSED")
+
+	//
+	// Test ParseMonth
+	//
+	if month, err := globals.ParseMonth("2021-03", paris); err != nil || !month.Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, paris)) {
+		t.Error("Wrong month :", month, err)
+	}
+
+	if _, err := globals.ParseMonth("2021-W12", paris); err == nil {
+		t.Error("A week was accepted as a month")
+	}
+
+	globals.Log.Debug("ParseMonth test - PASSED")
+
+	//
+	// Test ParsePeriod
+	//
+	start, end, err := globals.ParsePeriod("2021-W12", paris)
+	if err != nil || !start.Equal(time.Date(2021, 3, 22, 0, 0, 0, 0, paris)) || !end.Equal(time.Date(2021, 3, 29, 0, 0, 0, 0, paris)) {
+		t.Error("Wrong week :", start, end, err)
+	}
+
+	start, end, err = globals.ParsePeriod("2021-02", paris)
+	if err != nil || !start.Equal(time.Date(2021, 2, 1, 0, 0, 0, 0, paris)) || !end.Equal(time.Date(2021, 3, 1, 0, 0, 0, 0, paris)) {
+		t.Error("Wrong month :", start, end, err)
+	}
+
+	for _, value := range []string{"2021-W54", "2021-13", "2021"} {
+		if _, _, err = globals.ParsePeriod(value, paris); err == nil {
+			t.Error("A wrong period was accepted :", value)
+		}
+	}
+
+	globals.Log.Debug("ParsePeriod test - PASSED")
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : Easter(Year int) time.Time
+	TESTED : PublicHolidays(Calendar string, Year int) []globals.PublicHoliday
+	TESTED : IsHolidayCalendar(Calendar string) bool
+*/
+func TestPublicHolidays(t *testing.T) {
+	//
+	// Test Easter
+	//
+	for year, day := range map[int]time.Time{
+		2019: time.Date(2019, 4, 21, 0, 0, 0, 0, time.UTC),
+		2021: time.Date(2021, 4, 4, 0, 0, 0, 0, time.UTC),
+		2024: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		2038: time.Date(2038, 4, 25, 0, 0, 0, 0, time.UTC),
+	} {
+		if easter := globals.Easter(year); !easter.Equal(day) {
+			t.Error("Wrong easter of", year, ":", easter)
+		}
+	}
+
+	globals.Log.Debug("Easter test - PASSED")
+
+	//
+	// Test PublicHolidays
+	//
+	names := func(Holidays []globals.PublicHoliday) map[string]string {
+		days := map[string]string{}
+		for _, holiday := range Holidays {
+			days[holiday.Day] = holiday.Name
+		}
+		return days
+	}
+
+	france := globals.PublicHolidays(globals.HolidayCalendarFrance, 2021)
+	if len(france) != 11 || france[0].Day != "2021-01-01" || france[10].Day != "2021-12-25" {
+		t.Fatal("Wrong public holidays of France :", france)
+	}
+	days := names(france)
+	for day, name := range map[string]string{
+		"2021-04-05": "Lundi de Pâques",
+		"2021-05-13": "Ascension",
+		"2021-05-24": "Lundi de Pentecôte",
+		"2021-07-14": "Fête nationale",
+	} {
+		if days[day] != name {
+			t.Error("Wrong public holiday on the", day, ":", days[day])
+		}
+	}
+	if _, found := days["2021-04-02"]; found {
+		t.Error("Good friday is a public holiday of France")
+	}
+
+	alsace := globals.PublicHolidays(globals.HolidayCalendarAlsaceMoselle, 2021)
+	if days = names(alsace); len(alsace) != 13 || days["2021-04-02"] != "Vendredi saint" || days["2021-12-26"] != "Saint Étienne" {
+		t.Error("Wrong public holidays of Alsace-Moselle :", alsace)
+	}
+
+	if holidays := globals.PublicHolidays("", 2021); len(holidays) != 0 {
+		t.Error("A company without calendar has public holidays :", holidays)
+	}
+	if globals.IsHolidayCalendar("FR-CORSE") || !globals.IsHolidayCalendar(globals.HolidayCalendarAlsaceMoselle) {
+		t.Error("Wrong known calendars")
+	}
+
+	globals.Log.Debug("PublicHolidays test - PASSED")
+}
+
+/*
+	TESTED : GetHoliday(HolidayId int64) (model.Holiday, error)
+	TESTED : GetHolidaysOfCompany(CompanyId int64) (model.Holidays, error)
+	TESTED : GetHolidaysOfUser(UserId int64) (model.Holidays, error)
+	TESTED : CreateHoliday(Holiday model.Holiday) (int64, error)
+	TESTED : DeleteHoliday(HolidayId int64) error
+*/
+func TestHoliday(t *testing.T) {
+	var (
+		err      error
+		holiday  model.Holiday
+		holidays model.Holidays
+	)
+
+	testDatastore, err := datastores.NewDatabase("myTestDatabase.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	company := model.Company{CompanyName: "Holiday company", HolidayCalendar: globals.HolidayCalendarFrance}
+	if company.CompanyId, err = testDatastore.CreateCompany(company); err != nil {
+		t.Fatal(err)
+	}
+	userId, err := testDatastore.CreateUser(model.User{ContractId: 1, RoleId: 3, Mail: "holiday@user.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = testDatastore.CreateCompanyUser(model.CompanyUser{CompanyId: company.CompanyId, UserId: userId}); err != nil {
+		t.Fatal(err)
+	}
+
+	if companies, err := testDatastore.GetCompaniesOfUser(userId); err != nil || len(companies) != 1 || companies[0].HolidayCalendar != globals.HolidayCalendarFrance {
+		t.Error("Wrong calendar of the company :", companies, err)
+	}
+
+	//
+	// Test CreateHoliday and GetHoliday
+	//
+	closure := model.Holiday{CompanyId: company.CompanyId, Day: "2021-05-14", Name: "Pont de l'Ascension", Kind: model.HolidayKindClosure}
+	if closure.HolidayId, err = testDatastore.CreateHoliday(closure); err != nil {
+		t.Fatal(err)
+	}
+	if holiday, err = testDatastore.GetHoliday(closure.HolidayId); err != nil || holiday != closure {
+		t.Error("Wrong day off :", holiday, err)
+	}
+
+	newYear := model.Holiday{CompanyId: company.CompanyId, Day: "2021-01-04", Name: "Inventaire", Kind: model.HolidayKindHoliday}
+	if newYear.HolidayId, err = testDatastore.CreateHoliday(newYear); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = testDatastore.CreateHoliday(model.Holiday{CompanyId: company.CompanyId, Day: "2021-05-14", Name: "Twice", Kind: model.HolidayKindHoliday}); err == nil {
+		t.Error("The same day off was added twice to a company")
+	}
+
+	globals.Log.Debug("CreateHoliday test - PASSED")
+
+	//
+	// Test GetHolidaysOfCompany and GetHolidaysOfUser
+	//
+	if holidays, err = testDatastore.GetHolidaysOfCompany(company.CompanyId); err != nil || len(holidays) != 2 || holidays[0] != newYear || holidays[1] != closure {
+		t.Error("Wrong days off of the company :", holidays, err)
+	}
+	if holidays, err = testDatastore.GetHolidaysOfUser(userId); err != nil || len(holidays) != 2 || holidays[0] != newYear {
+		t.Error("Wrong days off of the user :", holidays, err)
+	}
+	if holidays, err = testDatastore.GetHolidaysOfUser(1); err != nil || len(holidays) != 0 {
+		t.Error("A user out of the company has its days off :", holidays, err)
+	}
+
+	globals.Log.Debug("GetHolidaysOfCompany test - PASSED")
+
+	//
+	// Test DeleteHoliday
+	//
+	for _, holidayId := range []int64{closure.HolidayId, newYear.HolidayId} {
+		if err = testDatastore.DeleteHoliday(holidayId); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err = testDatastore.GetHoliday(closure.HolidayId); err != sql.ErrNoRows {
+		t.Error("A deleted day off was found :", err)
+	}
+
+	globals.Log.Debug("DeleteHoliday test - PASSED")
+
+	testDatastore.DeleteCompanyUser(model.CompanyUser{CompanyId: company.CompanyId, UserId: userId})
+	testDatastore.DeleteUser(userId)
+	testDatastore.DeleteCompany(company.CompanyId)
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+/*
+	TESTED : AllowedOrigin(Origin string) (bool, bool)
+	TESTED : CookieRulesFromEnvironment() CookieRules
+	TESTED : CORSRulesFromEnvironment() CORSRules
+*/
+func TestHTTPSecurity(t *testing.T) {
+	//
+	// Test AllowedOrigin
+	//
+	rules := globals.CORSRules{AllowedOrigins: []string{"https://tps.uca.fr", "*"}, AllowCredentials: true}
+
+	if allowed, credentials := rules.AllowedOrigin("https://TPS.uca.fr"); !allowed || !credentials {
+		t.Error("A listed origin was not allowed")
+	}
+
+	// The wildcard never allows the credentials
+	if allowed, credentials := rules.AllowedOrigin("https://other.example"); !allowed || credentials {
+		t.Error("Wrong rights for an origin allowed by the wildcard")
+	}
+
+	if allowed, _ := globals.DefaultCORSRules().AllowedOrigin("https://tps.uca.fr"); allowed {
+		t.Error("An origin was allowed by default")
+	}
+
+	if allowed, _ := rules.AllowedOrigin(""); allowed {
+		t.Error("A request without origin was allowed")
+	}
+
+	globals.Log.Debug("AllowedOrigin test - PASSED")
+
+	//
+	// Test CookieRulesFromEnvironment and CORSRulesFromEnvironment
+	//
+	if cookieRules := globals.CookieRulesFromEnvironment(); !cookieRules.Secure || cookieRules.SameSite != http.SameSiteStrictMode {
+		t.Error("The cookies are not protected by default")
+	}
+
+	os.Setenv("COOKIE_SECURE", "false")
+	os.Setenv("COOKIE_SAMESITE", "lax")
+	os.Setenv("CORS_ALLOWED_ORIGINS", "https://tps.uca.fr, https://admin.tps.uca.fr")
+	os.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	os.Setenv("CORS_MAX_AGE", "60")
+	defer func() {
+		for _, name := range []string{"COOKIE_SECURE", "COOKIE_SAMESITE", "CORS_ALLOWED_ORIGINS", "CORS_ALLOW_CREDENTIALS", "CORS_MAX_AGE"} {
+			os.Unsetenv(name)
+		}
+	}()
+
+	if cookieRules := globals.CookieRulesFromEnvironment(); cookieRules.Secure || cookieRules.SameSite != http.SameSiteLaxMode {
+		t.Error("Wrong cookie rules :", cookieRules)
+	}
+
+	// SameSite=None is only accepted by the browsers on secure cookies
+	os.Setenv("COOKIE_SAMESITE", "none")
+	if cookieRules := globals.CookieRulesFromEnvironment(); !cookieRules.Secure || cookieRules.SameSite != http.SameSiteNoneMode {
+		t.Error("Wrong cookie rules :", cookieRules)
+	}
+
+	corsRules := globals.CORSRulesFromEnvironment()
+	if len(corsRules.AllowedOrigins) != 2 || corsRules.AllowedOrigins[1] != "https://admin.tps.uca.fr" || !corsRules.AllowCredentials || corsRules.MaxAge != 60 {
+		t.Error("Wrong CORS rules :", corsRules)
+	}
+
+	globals.Log.Debug("RulesFromEnvironment test - PASSED")
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : CreateImpersonation(Impersonation model.Impersonation) (int64, error)
+	TESTED : GetImpersonation(ImpersonationId int64) (model.Impersonation, error)
+	TESTED : GetImpersonations() (model.Impersonations, error)
+	TESTED : EndImpersonation(ImpersonationId int64, EndedAt time.Time) error
+	TESTED : CreateImpersonationAction(Action model.ImpersonationAction) (int64, error)
+	TESTED : UpdateImpersonationActionStatus(ImpersonationActionId int64, StatusCode int64) error
+	TESTED : GetActionsOfImpersonation(ImpersonationId int64) (model.ImpersonationActions, error)
+*/
+func TestImpersonation(t *testing.T) {
+	// Initializing variables
+	var (
+		err              error
+		testDatastore    *datastores.ConcreteDatastore
+		dbImpersonation  model.Impersonation
+		dbImpersonations model.Impersonations
+		dbActions        model.ImpersonationActions
+	)
+
+	if testDatastore, err = datastores.NewDatabase("myTestDatabase.db"); err != nil {
+		t.Error(err)
+	}
+
+	//
+	// Test CreateImpersonation and GetImpersonation
+	//
+	now := time.Now().Truncate(time.Second)
+	impersonation1 := model.Impersonation{
+		ImpersonatorId: 1,
+		UserId:         2,
+		Reason:         "Ticket 42",
+		StartedAt:      now.Add(-time.Hour),
+		ExpiresAt:      now.Add(-30 * time.Minute),
+	}
+
+	impersonation2 := model.Impersonation{
+		ImpersonatorId: 1,
+		UserId:         3,
+		StartedAt:      now,
+		ExpiresAt:      now.Add(30 * time.Minute),
+	}
+
+	if impersonation1.ImpersonationId, err = testDatastore.CreateImpersonation(impersonation1); err != nil {
+		t.Error(err)
+	}
+
+	if impersonation2.ImpersonationId, err = testDatastore.CreateImpersonation(impersonation2); err != nil {
+		t.Error(err)
+	}
+
+	if dbImpersonation, err = testDatastore.GetImpersonation(impersonation1.ImpersonationId); err != nil {
+		t.Error(err)
+	}
+
+	if !cmp.Equal(impersonation1, dbImpersonation) {
+		t.Error("Impersonations are not the same")
+	}
+
+	globals.Log.Debug("CreateImpersonation test - PASSED")
+
+	//
+	// Test GetImpersonations
+	//
+	if dbImpersonations, err = testDatastore.GetImpersonations(); err != nil {
+		t.Error(err)
+	}
+
+	// The most recent first
+	if !cmp.Equal(dbImpersonations, model.Impersonations{impersonation2, impersonation1}) {
+		t.Error("Impersonations are not the same")
+	}
+
+	globals.Log.Debug("GetImpersonations test - PASSED")
+
+	//
+	// Test EndImpersonation
+	//
+	if err = testDatastore.EndImpersonation(impersonation2.ImpersonationId, now); err != nil {
+		t.Error(err)
+	}
+
+	// Ending it again does not change the date
+	if err = testDatastore.EndImpersonation(impersonation2.ImpersonationId, now.Add(time.Minute)); err != nil {
+		t.Error(err)
+	}
+
+	if dbImpersonation, err = testDatastore.GetImpersonation(impersonation2.ImpersonationId); err != nil {
+		t.Error(err)
+	}
+
+	if dbImpersonation.EndedAt == nil || !dbImpersonation.EndedAt.Equal(now) {
+		t.Error("The end of the impersonation was not saved")
+	}
+
+	globals.Log.Debug("EndImpersonation test - PASSED")
+
+	//
+	// Test CreateImpersonationAction, UpdateImpersonationActionStatus and GetActionsOfImpersonation
+	//
+	action := model.ImpersonationAction{
+		ImpersonationId: impersonation1.ImpersonationId,
+		ImpersonatorId:  1,
+		UserId:          2,
+		Method:          "PATCH",
+		Path:            "/users/2",
+		CreatedAt:       now.Add(-45 * time.Minute),
+	}
+
+	if action.ImpersonationActionId, err = testDatastore.CreateImpersonationAction(action); err != nil {
+		t.Error(err)
+	}
+
+	if err = testDatastore.UpdateImpersonationActionStatus(action.ImpersonationActionId, 200); err != nil {
+		t.Error(err)
+	}
+	action.StatusCode = 200
+
+	if dbActions, err = testDatastore.GetActionsOfImpersonation(impersonation1.ImpersonationId); err != nil {
+		t.Error(err)
+	}
+
+	if !cmp.Equal(dbActions, model.ImpersonationActions{action}) {
+		t.Error("Impersonation actions are not the same")
+	}
+
+	if dbActions, err = testDatastore.GetActionsOfImpersonation(impersonation2.ImpersonationId); err != nil || len(dbActions) != 0 {
+		t.Error("Actions were found for another impersonation")
+	}
+
+	globals.Log.Debug("ImpersonationAction test - PASSED")
+}
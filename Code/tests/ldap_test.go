@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+/*
+	TESTED : (*LDAPProvider) Authenticate(Login string, Password string) (Identity, error)
+*/
+func TestLDAP(t *testing.T) {
+	var (
+		err      error
+		identity authentication.Identity
+	)
+
+	// Starting a stand-in directory
+	directory, err := authentication.StartLDAPStandIn([]authentication.LDAPStandInEntry{
+		{
+			DN:       "cn=service,dc=uca,dc=fr",
+			Password: "Service-passw0rd",
+		},
+		{
+			DN:       "uid=jdupont,ou=people,dc=uca,dc=fr",
+			Password: "Directory-passw0rd",
+			Attributes: map[string][]string{
+				"uid":       {"jdupont"},
+				"mail":      {"jean.dupont@uca.fr"},
+				"givenName": {"Jean"},
+				"sn":        {"Dupont"},
+			},
+		},
+		{
+			DN:       "uid=homonym1,ou=people,dc=uca,dc=fr",
+			Password: "Homonym-passw0rd",
+			Attributes: map[string][]string{
+				"uid":  {"homonym1"},
+				"mail": {"homonym@uca.fr"},
+			},
+		},
+		{
+			DN:       "uid=homonym2,ou=people,dc=uca,dc=fr",
+			Password: "Homonym-passw0rd",
+			Attributes: map[string][]string{
+				"uid":  {"homonym2"},
+				"mail": {"homonym@uca.fr"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer directory.Close()
+
+	config := authentication.DefaultLDAPConfig(directory.URL(), "ou=people,dc=uca,dc=fr")
+	config.BindDN = "cn=service,dc=uca,dc=fr"
+	config.BindPassword = "Service-passw0rd"
+	provider := &authentication.LDAPProvider{Config: config}
+
+	//
+	// Test Authenticate with the username and the mail
+	//
+	expected := authentication.Identity{
+		Username:  "jdupont",
+		Mail:      "jean.dupont@uca.fr",
+		FirstName: "Jean",
+		LastName:  "Dupont",
+	}
+
+	for _, login := range []string{"jdupont", "jean.dupont@uca.fr"} {
+		if identity, err = provider.Authenticate(login, "Directory-passw0rd"); err != nil {
+			t.Error(err)
+		}
+		if identity != expected {
+			t.Error("Wrong identity :", identity)
+		}
+	}
+
+	globals.Log.Debug("Authenticate test - PASSED")
+
+	//
+	// Test Authenticate with wrong credentials
+	//
+	if _, err = provider.Authenticate("jdupont", "Wrong-passw0rd"); err != authentication.ErrInvalidCredentials {
+		t.Error("A wrong password was accepted :", err)
+	}
+
+	if _, err = provider.Authenticate("unknown", "Directory-passw0rd"); err != authentication.ErrInvalidCredentials {
+		t.Error("An unknown user was accepted :", err)
+	}
+
+	// An empty password would be an anonymous bind
+	binds := directory.Binds()
+	if _, err = provider.Authenticate("jdupont", ""); err != authentication.ErrInvalidCredentials {
+		t.Error("An empty password was accepted :", err)
+	}
+	if directory.Binds() != binds {
+		t.Error("The directory was contacted with an empty password")
+	}
+
+	// A login matching several entries is refused
+	if _, err = provider.Authenticate("homonym@uca.fr", "Homonym-passw0rd"); err != authentication.ErrInvalidCredentials {
+		t.Error("An ambiguous login was accepted :", err)
+	}
+
+	// The login can't inject a filter
+	if _, err = provider.Authenticate("*", "Directory-passw0rd"); err != authentication.ErrInvalidCredentials {
+		t.Error("A wildcard login was accepted :", err)
+	}
+
+	globals.Log.Debug("Authenticate with wrong credentials test - PASSED")
+
+	//
+	// Test Authenticate with a wrong service account
+	//
+	config.BindPassword = "Wrong-passw0rd"
+	wrongService := &authentication.LDAPProvider{Config: config}
+
+	if _, err = wrongService.Authenticate("jdupont", "Directory-passw0rd"); err == nil || err == authentication.ErrInvalidCredentials {
+		t.Error("A wrong service account did not return an error :", err)
+	}
+
+	//
+	// Test Authenticate with an unreachable directory
+	//
+	directory.Close()
+
+	if _, err = provider.Authenticate("jdupont", "Directory-passw0rd"); err == nil || err == authentication.ErrInvalidCredentials {
+		t.Error("An unreachable directory did not return an error :", err)
+	}
+
+	globals.Log.Debug("Authenticate with a broken directory test - PASSED")
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : Lock(Lock model.LockDate) (model.LockDate, error)
+	TESTED : Unlock(Lock model.LockDate) (model.LockDate, error)
+	TESTED : GetLockDate() (model.LockDate, error)
+	TESTED : GetLockDates() (model.LockDates, error)
+	TESTED : The schedules and the vacations locked by the lock date
+*/
+func TestLockDate(t *testing.T) {
+	var (
+		err        error
+		lockedErr  *datastores.LockedError
+		lock       model.LockDate
+		locks      model.LockDates
+		projectId  int64
+		scheduleId int64
+		vacationId int64
+		laterId    int64
+	)
+
+	testDatastore, err := datastores.NewDatabase("myTestDatabase.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	april := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	lockOn := func(Date time.Time, Reason string) model.LockDate {
+		return model.LockDate{LockDate: sql.NullTime{Valid: !Date.IsZero(), Time: Date}, AuthorId: 1, Reason: Reason}
+	}
+
+	projectId, _ = testDatastore.CreateProject(model.Project{ProjectName: "Lock project"})
+	if scheduleId, err = testDatastore.CreateSchedule(scheduleBetween(projectId, 8, 12)); err != nil {
+		t.Fatal(err)
+	}
+	if vacationId, err = testDatastore.CreateVacation(scheduleBetween(0, 13, 14)); err != nil {
+		t.Fatal(err)
+	}
+
+	//
+	// Test Lock, Unlock and GetLockDate
+	//
+	if _, err = testDatastore.GetLockDate(); err != sql.ErrNoRows {
+		t.Error("A lock date was found before any lock :", err)
+	}
+	if _, err = testDatastore.Unlock(lockOn(time.Time{}, "Nothing")); err != datastores.ErrLockDate {
+		t.Error("Nothing was unlocked :", err)
+	}
+
+	if lock, err = testDatastore.Lock(lockOn(april, "March is closed")); err != nil || lock.LockId == 0 || !lock.LockDate.Time.Equal(april) {
+		t.Fatal("Could not lock :", lock, err)
+	}
+	if _, err = testDatastore.Lock(lockOn(april.AddDate(0, -1, 0), "Back")); err != datastores.ErrLockDate {
+		t.Error("The lock date was moved back by a lock :", err)
+	}
+	if lock, err = testDatastore.GetLockDate(); err != nil || !lock.LockDate.Time.Equal(april) || lock.AuthorId != 1 || lock.Reason != "March is closed" {
+		t.Error("Wrong lock date :", lock, err)
+	}
+
+	globals.Log.Debug("Lock test - PASSED")
+
+	//
+	// Test the locks
+	//
+	moved := scheduleBetween(projectId, 13, 14)
+	moved.ScheduleId = scheduleId
+	movedBack := scheduleBetween(projectId, 8, 12)
+	movedBack.StartDate.Time, movedBack.EndDate.Time = movedBack.StartDate.Time.AddDate(0, 2, 0), movedBack.EndDate.Time.AddDate(0, 2, 0)
+	if laterId, err = testDatastore.CreateSchedule(movedBack); err != nil {
+		t.Fatal("A schedule after the lock date was locked :", err)
+	}
+	movedBack.ScheduleId = laterId
+	movedBack.StartDate = moved.StartDate
+
+	for name, locked := range map[string]error{
+		"CreateSchedule": func() error {
+			_, err := testDatastore.CreateSchedule(scheduleBetween(projectId, 13, 14))
+			return err
+		}(),
+		"CreateVacation": func() error {
+			_, err := testDatastore.CreateVacation(scheduleBetween(0, 15, 16))
+			return err
+		}(),
+		"UpdateSchedule": func() error {
+			_, err := testDatastore.UpdateSchedule(moved)
+			return err
+		}(),
+		"UpdateSchedule before the lock date": func() error {
+			_, err := testDatastore.UpdateSchedule(movedBack)
+			return err
+		}(),
+		"UpdateVacation": func() error {
+			vacation := scheduleBetween(0, 15, 16)
+			vacation.ScheduleId = vacationId
+			_, err := testDatastore.UpdateVacation(vacation)
+			return err
+		}(),
+		"DeleteSchedule": testDatastore.DeleteSchedule(scheduleId),
+		"DeleteVacation": testDatastore.DeleteVacation(vacationId),
+	} {
+		if !errors.As(locked, &lockedErr) || !lockedErr.LockDate.Equal(april) {
+			t.Error(name, "was not locked :", locked)
+		}
+	}
+
+	globals.Log.Debug("Locks test - PASSED")
+
+	//
+	// Test Unlock and GetLockDates
+	//
+	if _, err = testDatastore.Unlock(lockOn(april.AddDate(0, 0, 14), "Later")); err != datastores.ErrLockDate {
+		t.Error("The lock date was moved forward by an unlock :", err)
+	}
+	if lock, err = testDatastore.Unlock(lockOn(april.AddDate(0, -1, 0), "Wrong project in March")); err != nil || !lock.LockDate.Time.Equal(april.AddDate(0, -1, 0)) {
+		t.Fatal("Could not unlock :", lock, err)
+	}
+	if _, err = testDatastore.UpdateSchedule(moved); err != nil {
+		t.Error("An unlocked schedule could not change :", err)
+	}
+	if lock, err = testDatastore.Unlock(lockOn(time.Time{}, "Everything")); err != nil || lock.LockDate.Valid {
+		t.Fatal("Could not remove the lock date :", lock, err)
+	}
+
+	if locks, err = testDatastore.GetLockDates(); err != nil || len(locks) != 3 {
+		t.Fatal("Wrong history :", locks, err)
+	}
+	if locks[0].LockDate.Valid || locks[0].Reason != "Everything" || locks[1].Reason != "Wrong project in March" || !locks[2].LockDate.Time.Equal(april) {
+		t.Error("Wrong history :", locks)
+	}
+
+	globals.Log.Debug("Unlock test - PASSED")
+
+	for _, cleanup := range []error{
+		testDatastore.DeleteSchedule(scheduleId),
+		testDatastore.DeleteSchedule(laterId),
+		testDatastore.DeleteVacation(vacationId),
+		testDatastore.DeleteProject(projectId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+
+	testDatastore.CloseDatabase()
+}
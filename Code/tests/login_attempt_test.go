@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : GetLoginAttempt(AttemptKey string) (model.LoginAttempt, error)
+	TESTED : SaveLoginAttempt(Attempt model.LoginAttempt) error
+	TESTED : DeleteLoginAttempt(AttemptKey string) error
+*/
+func TestLoginAttempt(t *testing.T) {
+	// Initializing variables
+	var (
+		err           error
+		testDatastore *datastores.ConcreteDatastore
+		dbAttempt     model.LoginAttempt
+	)
+
+	if testDatastore, err = datastores.NewDatabase("myTestDatabase.db"); err != nil {
+		t.Error(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	attempt := model.LoginAttempt{
+		AttemptKey:     "mail:someone@mydb",
+		FailedAttempts: 1,
+		LastFailure:    sql.NullTime{Valid: true, Time: now},
+	}
+
+	//
+	// Test GetLoginAttempt on an unknown key
+	//
+	if _, err = testDatastore.GetLoginAttempt(attempt.AttemptKey); err != sql.ErrNoRows {
+		t.Error("Got login attempts for an unknown key")
+	}
+
+	//
+	// Test SaveLoginAttempt, for a new key
+	//
+	if err = testDatastore.SaveLoginAttempt(attempt); err != nil {
+		t.Error(err)
+	}
+
+	if dbAttempt, err = testDatastore.GetLoginAttempt(attempt.AttemptKey); err != nil {
+		t.Error(err)
+	}
+
+	if dbAttempt.FailedAttempts != 1 || !dbAttempt.LastFailure.Time.Equal(now) || dbAttempt.LockedUntil.Valid {
+		t.Error("Login attempts are not the same")
+	}
+
+	globals.Log.Debug("SaveLoginAttempt test - PASSED")
+
+	//
+	// Test SaveLoginAttempt, for an existing key
+	//
+	attempt.FailedAttempts = 2
+	attempt.LockedUntil = sql.NullTime{Valid: true, Time: now.Add(time.Hour)}
+
+	if err = testDatastore.SaveLoginAttempt(attempt); err != nil {
+		t.Error(err)
+	}
+
+	if dbAttempt, err = testDatastore.GetLoginAttempt(attempt.AttemptKey); err != nil {
+		t.Error(err)
+	}
+
+	if dbAttempt.FailedAttempts != 2 || !dbAttempt.LockedUntil.Time.Equal(now.Add(time.Hour)) {
+		t.Error("Login attempts are not the same")
+	}
+
+	globals.Log.Debug("GetLoginAttempt test - PASSED")
+
+	//
+	// Test DeleteLoginAttempt
+	//
+	if err = testDatastore.DeleteLoginAttempt(attempt.AttemptKey); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = testDatastore.GetLoginAttempt(attempt.AttemptKey); err != sql.ErrNoRows {
+		t.Error("The login attempts did not get deleted")
+	}
+
+	globals.Log.Debug("DeleteLoginAttempt test - PASSED")
+
+	testDatastore.CloseDatabase()
+}
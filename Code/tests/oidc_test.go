@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/authentication"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+// Logs in at the stand-in issuer and returns the code it gives to the callback
+func oidcCode(t *testing.T, Provider *authentication.OIDCProvider, State string, Nonce string, Verifier string) string {
+	authURL, err := Provider.AuthCodeURL(State, Nonce, Verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	response, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Query().Get("state") != State {
+		t.Error("The issuer did not give the state back")
+	}
+
+	return location.Query().Get("code")
+}
+
+/*
+	TESTED : PKCEChallenge(Verifier string) string
+	TESTED : (*OIDCProvider) AuthCodeURL(State string, Nonce string, Verifier string) (string, error)
+	TESTED : (*OIDCProvider) Exchange(Code string, Verifier string, Nonce string) (Identity, error)
+*/
+func TestOIDC(t *testing.T) {
+	var (
+		err      error
+		identity authentication.Identity
+	)
+
+	//
+	// Test PKCEChallenge, with the example of RFC 7636
+	//
+	if challenge := authentication.PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); challenge != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Error("Wrong PKCE challenge :", challenge)
+	}
+
+	globals.Log.Debug("PKCEChallenge test - PASSED")
+
+	// Starting a stand-in issuer
+	issuer, err := authentication.StartOIDCStandIn("gestion-tps", "Client-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer issuer.Close()
+
+	issuer.SignIn(authentication.OIDCStandInUser{
+		Subject:      "1234",
+		Mail:         "jean.dupont@partner.fr",
+		MailVerified: true,
+		FirstName:    "Jean",
+		LastName:     "Dupont",
+	})
+
+	provider := authentication.NewOIDCProvider(authentication.OIDCConfig{
+		Name:         "partner",
+		Issuer:       issuer.Issuer(),
+		ClientID:     "gestion-tps",
+		ClientSecret: "Client-secret",
+		RedirectURL:  "http://localhost:8080/oidc/partner/callback",
+	})
+
+	//
+	// Test Exchange
+	//
+	code := oidcCode(t, provider, "state", "nonce", "verifier")
+	if identity, err = provider.Exchange(code, "verifier", "nonce"); err != nil {
+		t.Fatal(err)
+	}
+
+	if identity.Mail != "jean.dupont@partner.fr" || identity.FirstName != "Jean" || identity.LastName != "Dupont" {
+		t.Error("Wrong identity :", identity)
+	}
+
+	// A code can only be used once
+	if _, err = provider.Exchange(code, "verifier", "nonce"); err == nil {
+		t.Error("A code was used twice")
+	}
+
+	// The verifier must match the challenge
+	code = oidcCode(t, provider, "state", "nonce", "verifier")
+	if _, err = provider.Exchange(code, "another verifier", "nonce"); err == nil {
+		t.Error("A code was exchanged with a wrong verifier")
+	}
+
+	// The nonce must match the one of the login
+	code = oidcCode(t, provider, "state", "nonce", "verifier")
+	if _, err = provider.Exchange(code, "verifier", "another nonce"); err == nil {
+		t.Error("A token was accepted with a wrong nonce")
+	}
+
+	globals.Log.Debug("Exchange test - PASSED")
+
+	//
+	// Test Exchange with invalid ID tokens
+	//
+	tampers := map[string]func(jwt.MapClaims){
+		"wrong issuer":      func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example" },
+		"wrong audience":    func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+		"expired":           func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no expiration":     func(claims jwt.MapClaims) { delete(claims, "exp") },
+		"unverified mail":   func(claims jwt.MapClaims) { claims["email_verified"] = false },
+		"no mail":           func(claims jwt.MapClaims) { delete(claims, "email") },
+		"several audiences": func(claims jwt.MapClaims) { claims["aud"] = []string{"gestion-tps", "another-client"} },
+		"wrong authorized party": func(claims jwt.MapClaims) {
+			claims["aud"] = []string{"gestion-tps", "another-client"}
+			claims["azp"] = "another-client"
+		},
+	}
+
+	for name, tamper := range tampers {
+		issuer.Tamper = tamper
+		code = oidcCode(t, provider, "state", "nonce", "verifier")
+		if _, err = provider.Exchange(code, "verifier", "nonce"); err == nil {
+			t.Error("An ID token was accepted with :", name)
+		} else if _, ok := err.(*authentication.InvalidTokenError); !ok {
+			t.Error("Not an InvalidTokenError for", name, ":", err)
+		}
+	}
+	issuer.Tamper = nil
+
+	// A provider is only trusted for its domains
+	provider.Config.AllowedDomains = []string{"uca.fr"}
+	code = oidcCode(t, provider, "state", "nonce", "verifier")
+	if _, err = provider.Exchange(code, "verifier", "nonce"); err == nil {
+		t.Error("A mail of another domain was accepted")
+	}
+
+	globals.Log.Debug("Exchange with invalid tokens test - PASSED")
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+// Returns a schedule of a project between two hours of the 1st of march 2021
+func scheduleBetween(ProjectId int64, Start int, End int) model.Schedule {
+	return model.Schedule{
+		ProjectId: ProjectId,
+		StartDate: sql.NullTime{Valid: true, Time: time.Date(2021, 3, 1, Start, 0, 0, 0, time.UTC)},
+		EndDate:   sql.NullTime{Valid: true, Time: time.Date(2021, 3, 1, End, 0, 0, 0, time.UTC)},
+	}
+}
+
+/*
+	TESTED : The overlaps checked by CreateSchedule, UpdateSchedule, CreateVacation and CreateUserSchedule
+	TESTED : GetOverlapsOfUser(UserId int64) (model.ScheduleOverlaps, error)
+*/
+func TestOverlap(t *testing.T) {
+	var (
+		err        error
+		overlapErr *datastores.OverlapError
+		userId     int64
+		otherId    int64
+		projectId  int64
+		onCallId   int64
+		scheduleId int64
+		overlaps   model.ScheduleOverlaps
+	)
+
+	testDatastore, err := datastores.NewDatabase("myTestDatabase.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userId, _ = testDatastore.CreateUser(model.User{ContractId: 1, RoleId: 3, Mail: "overlap@user.com"})
+	otherId, _ = testDatastore.CreateUser(model.User{ContractId: 1, RoleId: 3, Mail: "other@user.com"})
+	projectId, _ = testDatastore.CreateProject(model.Project{ProjectName: "Overlap project"})
+	onCallId, _ = testDatastore.CreateProject(model.Project{ProjectName: "On-call", AllowOverlaps: true})
+
+	// From 8 to 12
+	if scheduleId, err = testDatastore.CreateSchedule(scheduleBetween(projectId, 8, 12), userId); err != nil {
+		t.Fatal(err)
+	}
+
+	//
+	// Test CreateSchedule
+	//
+	_, err = testDatastore.CreateSchedule(scheduleBetween(projectId, 11, 14), userId)
+	if !errors.As(err, &overlapErr) || len(overlapErr.Schedules) != 1 || overlapErr.Schedules[0].ScheduleId != scheduleId {
+		t.Error("An overlapping schedule was created :", err)
+	}
+
+	// Right after, or for another user, or on a project that allows overlaps, it is accepted
+	afternoonId, err := testDatastore.CreateSchedule(scheduleBetween(projectId, 12, 14), userId)
+	if err != nil {
+		t.Error("A following schedule was refused :", err)
+	}
+	if _, err = testDatastore.CreateSchedule(scheduleBetween(projectId, 9, 10), otherId); err != nil {
+		t.Error("The schedule of another user was refused :", err)
+	}
+	onCallScheduleId, err := testDatastore.CreateSchedule(scheduleBetween(onCallId, 0, 23), userId)
+	if err != nil {
+		t.Error("An on-call schedule was refused :", err)
+	}
+
+	globals.Log.Debug("CreateSchedule overlaps test - PASSED")
+
+	//
+	// Test UpdateSchedule
+	//
+	afternoon := scheduleBetween(projectId, 10, 14)
+	afternoon.ScheduleId = afternoonId
+	if _, err = testDatastore.UpdateSchedule(afternoon); !errors.As(err, &overlapErr) {
+		t.Error("A schedule was moved over another one :", err)
+	}
+
+	// A schedule does not overlap itself
+	afternoon = scheduleBetween(projectId, 12, 15)
+	afternoon.ScheduleId = afternoonId
+	if _, err = testDatastore.UpdateSchedule(afternoon); err != nil {
+		t.Error("A schedule could not be extended :", err)
+	}
+
+	globals.Log.Debug("UpdateSchedule overlaps test - PASSED")
+
+	//
+	// Test CreateVacation and CreateUserSchedule
+	//
+	if _, err = testDatastore.CreateVacation(scheduleBetween(0, 7, 9), userId); !errors.As(err, &overlapErr) {
+		t.Error("An overlapping vacation was created :", err)
+	}
+
+	unlinkedId, _ := testDatastore.CreateSchedule(scheduleBetween(projectId, 14, 16))
+	if err = testDatastore.CreateUserSchedule(model.UserSchedule{UserId: userId, ScheduleId: unlinkedId}); !errors.As(err, &overlapErr) {
+		t.Error("An overlapping schedule was linked :", err)
+	}
+	if err = testDatastore.CreateUserSchedule(model.UserSchedule{UserId: otherId, ScheduleId: unlinkedId}); err != nil {
+		t.Error(err)
+	}
+
+	globals.Log.Debug("CreateVacation and CreateUserSchedule overlaps test - PASSED")
+
+	//
+	// Test GetOverlapsOfUser
+	//
+	if overlaps, err = testDatastore.GetOverlapsOfUser(userId); err != nil || len(overlaps) != 0 {
+		t.Error("Wrong overlaps :", overlaps, err)
+	}
+
+	// The overlaps that were allowed by a project are listed once it does not allow them anymore
+	if _, err = testDatastore.UpdateProject(model.Project{ProjectId: onCallId, ProjectName: "On-call"}); err != nil {
+		t.Error(err)
+	}
+	if overlaps, err = testDatastore.GetOverlapsOfUser(userId); err != nil || len(overlaps) != 2 {
+		t.Fatal("Wrong overlaps :", overlaps, err)
+	}
+	for i, second := range []int64{scheduleId, afternoonId} {
+		if overlaps[i].UserId != userId || overlaps[i].First.ScheduleId != onCallScheduleId || overlaps[i].Second.ScheduleId != second {
+			t.Error("Wrong overlap :", overlaps[i])
+		}
+	}
+
+	globals.Log.Debug("GetOverlapsOfUser test - PASSED")
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+/*
+	TESTED : PasswordPolicyFromEnvironment() PasswordPolicy
+	TESTED : Check(Password string, Username string, Mail string) []string
+*/
+func TestPasswordPolicy(t *testing.T) {
+	policy := globals.DefaultPasswordPolicy()
+
+	if violations := policy.Check("Correct-Horse-42", "horse", "horse@mydb"); len(violations) != 0 {
+		t.Error("A valid password was refused :", violations)
+	}
+	if violations := policy.Check("short", "", ""); len(violations) != 4 {
+		t.Error("Wrong violations of a short password :", violations)
+	}
+	if violations := policy.Check("P@ssw0rd", "", ""); len(violations) != 2 {
+		t.Error("Wrong violations of a common password :", violations)
+	}
+
+	globals.Log.Debug("Check test - PASSED")
+
+	//
+	// Test PasswordPolicyFromEnvironment
+	//
+	denylist, err := ioutil.TempFile("", "denylist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(denylist.Name())
+	denylist.WriteString("Gestion-TPS-2021\n\n  Clermont-Ferrand63  \n")
+	denylist.Close()
+
+	os.Setenv("PASSWORD_MIN_LENGTH", "16")
+	os.Setenv("PASSWORD_REQUIRED_CLASSES", "lower, DIGIT")
+	os.Setenv("PASSWORD_DENYLIST_FILE", denylist.Name())
+	policy = globals.PasswordPolicyFromEnvironment()
+	if policy.MinLength != 16 || !policy.RequireLower || policy.RequireUpper || !policy.RequireDigit || policy.RequireSpecial {
+		t.Error("The environment was not used :", policy)
+	}
+	if len(policy.Denylist) != len(globals.DefaultPasswordPolicy().Denylist)+2 {
+		t.Error("Wrong denylist :", policy.Denylist)
+	}
+	if violations := policy.Check("clermont-ferrand63", "", ""); len(violations) != 1 {
+		t.Error("A password of the denylist file was accepted :", violations)
+	}
+	if violations := policy.Check("azerty", "", ""); len(violations) != 3 {
+		t.Error("Wrong violations of a default common password :", violations)
+	}
+
+	os.Setenv("PASSWORD_MIN_LENGTH", "ten")
+	os.Setenv("PASSWORD_REQUIRED_CLASSES", "none")
+	os.Setenv("PASSWORD_DENYLIST_FILE", denylist.Name()+".missing")
+	policy = globals.PasswordPolicyFromEnvironment()
+	if policy.MinLength != globals.DefaultPasswordPolicy().MinLength || policy.RequireLower || policy.RequireUpper || policy.RequireDigit || policy.RequireSpecial {
+		t.Error("Wrong policy from an invalid environment :", policy)
+	}
+	if len(policy.Denylist) != len(globals.DefaultPasswordPolicy().Denylist) {
+		t.Error("A missing denylist file changed the denylist :", policy.Denylist)
+	}
+
+	os.Unsetenv("PASSWORD_MIN_LENGTH")
+	os.Unsetenv("PASSWORD_REQUIRED_CLASSES")
+	os.Unsetenv("PASSWORD_DENYLIST_FILE")
+
+	globals.Log.Debug("PasswordPolicyFromEnvironment test - PASSED")
+}
@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : GetCompaniesOfUser(UserId int64) (model.Companies, error)
+	TESTED : AnonymizeUser(Anonymous model.User) error
+*/
+func TestPersonalData(t *testing.T) {
+	// Initializing variables
+	var (
+		err           error
+		testDatastore *datastores.ConcreteDatastore
+		dbCompanies   model.Companies
+		dbUser        model.User
+		dbComment     model.Comment
+		dbSchedules   model.Schedules
+	)
+
+	if testDatastore, err = datastores.NewDatabase("myTestDatabase.db"); err != nil {
+		t.Error(err)
+	}
+
+	// Creating some data : two users sharing a schedule, and a schedule of the first one only
+	user := model.User{ContractId: 1, RoleId: 3, Username: "jdupont", FirstName: "Jean", LastName: "Dupont", Mail: "jean.dupont@uca.fr", Password: "hash", TheoricalHoursWorked: 35}
+	if user.UserId, err = testDatastore.CreateUser(user); err != nil {
+		t.Error(err)
+	}
+
+	colleague := model.User{ContractId: 1, RoleId: 3, Mail: "colleague@uca.fr", Password: "hash"}
+	if colleague.UserId, err = testDatastore.CreateUser(colleague); err != nil {
+		t.Error(err)
+	}
+
+	company := model.Company{CompanyName: "Test company"}
+	if company.CompanyId, err = testDatastore.CreateCompany(company); err != nil {
+		t.Error(err)
+	}
+
+	if err = testDatastore.CreateCompanyUser(model.CompanyUser{CompanyId: company.CompanyId, UserId: user.UserId}); err != nil {
+		t.Error(err)
+	}
+
+	projectId, err := testDatastore.CreateProject(model.Project{ProjectName: "Test project"})
+	if err != nil {
+		t.Error(err)
+	}
+
+	var scheduleIds []int64
+	for i := 0; i < 2; i++ {
+		// One hour apart, so they don't overlap
+		start := time.Now().Add(time.Duration(2*i) * time.Hour)
+		scheduleId, err := testDatastore.CreateSchedule(model.Schedule{
+			ProjectId: projectId,
+			StartDate: sql.NullTime{Valid: true, Time: start},
+			EndDate:   sql.NullTime{Valid: true, Time: start.Add(time.Hour)},
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if err = testDatastore.CreateUserSchedule(model.UserSchedule{UserId: user.UserId, ScheduleId: scheduleId}); err != nil {
+			t.Error(err)
+		}
+		scheduleIds = append(scheduleIds, scheduleId)
+	}
+
+	if err = testDatastore.CreateUserSchedule(model.UserSchedule{UserId: colleague.UserId, ScheduleId: scheduleIds[1]}); err != nil {
+		t.Error(err)
+	}
+
+	ownCommentId, _ := testDatastore.CreateComment(model.Comment{ScheduleId: scheduleIds[0], Comment: "Jean was sick"})
+	sharedCommentId, _ := testDatastore.CreateComment(model.Comment{ScheduleId: scheduleIds[1], Comment: "Meeting with the client"})
+
+	if _, err = testDatastore.CreateAccessToken(model.AccessToken{UserId: user.UserId, Name: "Script", TokenHash: "hash", Prefix: "gtp_abcdef", CreatedAt: time.Now()}); err != nil {
+		t.Error(err)
+	}
+
+	if err = testDatastore.SaveTwoFactor(model.TwoFactor{UserId: user.UserId, Secret: "secret", Enabled: true}); err != nil {
+		t.Error(err)
+	}
+
+	//
+	// Test GetCompaniesOfUser
+	//
+	if dbCompanies, err = testDatastore.GetCompaniesOfUser(user.UserId); err != nil {
+		t.Error(err)
+	}
+
+	if !cmp.Equal(dbCompanies, model.Companies{company}) {
+		t.Error("Companies are not the same")
+	}
+
+	if dbCompanies, err = testDatastore.GetCompaniesOfUser(colleague.UserId); err != nil || len(dbCompanies) != 0 {
+		t.Error("Companies were found for a user without company")
+	}
+
+	globals.Log.Debug("GetCompaniesOfUser test - PASSED")
+
+	//
+	// Test AnonymizeUser
+	//
+	anonymous := user
+	anonymous.Username, anonymous.FirstName, anonymous.LastName = "anonymous", "", ""
+	anonymous.Mail, anonymous.Password, anonymous.AuthProvider = "anonymous@anonymized.invalid", "!", "local"
+
+	if err = testDatastore.AnonymizeUser(anonymous); err != nil {
+		t.Error(err)
+	}
+
+	if dbUser, err = testDatastore.GetUser(user.UserId); err != nil {
+		t.Error(err)
+	}
+
+	if !cmp.Equal(dbUser, anonymous) {
+		t.Error("The user was not anonymized :", dbUser)
+	}
+
+	// The hours are kept
+	if dbSchedules, err = testDatastore.GetSchedulesOfUser(user.UserId); err != nil || len(dbSchedules) != 2 {
+		t.Error("The schedules of the anonymized user were lost")
+	}
+
+	// The comments are scrubbed, except the ones shared with another user
+	if dbComment, err = testDatastore.GetComment(ownCommentId); err != nil || dbComment.Comment != "" {
+		t.Error("The comment of the anonymized user was kept :", dbComment.Comment)
+	}
+
+	if dbComment, err = testDatastore.GetComment(sharedCommentId); err != nil || dbComment.Comment != "Meeting with the client" {
+		t.Error("The comment of a shared schedule was scrubbed")
+	}
+
+	if tokens, err := testDatastore.GetAccessTokensOfUser(user.UserId); err != nil || len(tokens) != 0 {
+		t.Error("The API tokens of the anonymized user were kept")
+	}
+
+	if _, err = testDatastore.GetTwoFactor(user.UserId); err != sql.ErrNoRows {
+		t.Error("The two-factor authentication of the anonymized user was kept")
+	}
+
+	// The other user is not changed
+	if dbUser, err = testDatastore.GetUser(colleague.UserId); err != nil || dbUser.Mail != colleague.Mail {
+		t.Error("Another user was anonymized")
+	}
+
+	globals.Log.Debug("AnonymizeUser test - PASSED")
+}
@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+/*
+	TESTED : ParseRecurrence(Value string, Location *time.Location) (Recurrence, error)
+	TESTED : String() string
+	TESTED : Occurrences(Start time.Time, Location *time.Location, Exceptions []time.Time, From time.Time, To time.Time) []time.Time
+	TESTED : CountBefore(Start time.Time, Location *time.Location, To time.Time) int
+	TESTED : Shift(Days int) Recurrence
+*/
+func TestRecurrence(t *testing.T) {
+	paris, err := globals.LoadTimeZone("Europe/Paris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The occurrences of a rule from the first one, until a far date
+	farAway := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	occurrencesOf := func(Value string, Start time.Time, Exceptions ...time.Time) []time.Time {
+		rule, err := globals.ParseRecurrence(Value, paris)
+		if err != nil {
+			t.Fatal("Could not read", Value, ":", err)
+		}
+		return rule.Occurrences(Start, paris, Exceptions, Start, farAway)
+	}
+	sameDates := func(Dates []time.Time, Wanted ...time.Time) bool {
+		if len(Dates) != len(Wanted) {
+			return false
+		}
+		for i := range Dates {
+			if !Dates[i].Equal(Wanted[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	at := func(Month time.Month, Day int, Hour int) time.Time {
+		return time.Date(2021, Month, Day, Hour, 0, 0, 0, paris)
+	}
+
+	//
+	// Test ParseRecurrence
+	//
+	for _, value := range []string{
+		"",
+		"COUNT=3",
+		"FREQ=YEARLY",
+		"FREQ=WEEKLY;COUNT=0",
+		"FREQ=WEEKLY;COUNT=2;UNTIL=20210401",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYSETPOS=1",
+		"FREQ=WEEKLY;WKST=SU",
+		"FREQ=WEEKLY;UNTIL=tomorrow",
+	} {
+		if _, err = globals.ParseRecurrence(value, paris); err == nil {
+			t.Error("A wrong rule was accepted :", value)
+		}
+	}
+
+	rule, err := globals.ParseRecurrence("RRULE:freq=weekly;byday=MO,WE;interval=2;count=3;wkst=MO", paris)
+	if err != nil || rule.String() != "FREQ=WEEKLY;INTERVAL=2;COUNT=3;BYDAY=MO,WE" {
+		t.Error("Wrong rule :", rule.String(), err)
+	}
+
+	// An UNTIL without zone is read in the zone of the series, and an UNTIL day includes the whole day
+	if rule, err = globals.ParseRecurrence("FREQ=DAILY;UNTIL=20210303T090000", paris); err != nil || !rule.Until.Equal(at(3, 3, 9)) {
+		t.Error("Wrong local UNTIL :", rule.Until, err)
+	}
+	if rule, err = globals.ParseRecurrence("FREQ=DAILY;UNTIL=20210303", paris); err != nil || !rule.Until.Equal(at(3, 4, 0).Add(-time.Second)) {
+		t.Error("Wrong UNTIL day :", rule.Until, err)
+	}
+	if rule.String() != "FREQ=DAILY;UNTIL=20210303T225959Z" {
+		t.Error("Wrong rule :", rule.String())
+	}
+
+	globals.Log.Debug("ParseRecurrence test - PASSED")
+
+	//
+	// Test Occurrences
+	//
+	monday := at(3, 1, 8)
+
+	if dates := occurrencesOf("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4", monday); !sameDates(dates, at(3, 1, 8), at(3, 3, 8), at(3, 8, 8), at(3, 10, 8)) {
+		t.Error("Wrong weekly occurrences :", dates)
+	}
+
+	// The exceptions count in COUNT, and the first occurrence counts even if the rule would not give it
+	if dates := occurrencesOf("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4", monday, at(3, 3, 8)); !sameDates(dates, at(3, 1, 8), at(3, 8, 8), at(3, 10, 8)) {
+		t.Error("Wrong occurrences with an exception :", dates)
+	}
+	if dates := occurrencesOf("FREQ=WEEKLY;BYDAY=TU;COUNT=3", monday); !sameDates(dates, at(3, 1, 8), at(3, 2, 8), at(3, 9, 8)) {
+		t.Error("Wrong occurrences from another day :", dates)
+	}
+
+	// The occurrences keep their hour when the clocks go forward, on the 28th of March
+	if dates := occurrencesOf("FREQ=WEEKLY;INTERVAL=2;COUNT=3", monday); !sameDates(dates, at(3, 1, 8), at(3, 15, 8), at(3, 29, 8)) || dates[2].UTC().Hour() != 6 {
+		t.Error("Wrong occurrences around the change of hour :", dates)
+	}
+
+	if dates := occurrencesOf("FREQ=DAILY;UNTIL=20210303", monday); !sameDates(dates, at(3, 1, 8), at(3, 2, 8), at(3, 3, 8)) {
+		t.Error("Wrong daily occurrences :", dates)
+	}
+	if dates := occurrencesOf("FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=6", at(3, 4, 8)); !sameDates(dates, at(3, 4, 8), at(3, 5, 8), at(3, 8, 8), at(3, 9, 8), at(3, 10, 8), at(3, 11, 8)) {
+		t.Error("Wrong occurrences on the working days :", dates)
+	}
+
+	// The months without the day of the first occurrence are skipped, unless the days are counted from the end
+	if dates := occurrencesOf("FREQ=MONTHLY;COUNT=3", at(1, 31, 8)); !sameDates(dates, at(1, 31, 8), at(3, 31, 8), at(5, 31, 8)) {
+		t.Error("Wrong monthly occurrences :", dates)
+	}
+	if dates := occurrencesOf("FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=3", at(1, 31, 8)); !sameDates(dates, at(1, 31, 8), at(2, 28, 8), at(3, 31, 8)) {
+		t.Error("Wrong occurrences on the last days of the months :", dates)
+	}
+	if dates := occurrencesOf("FREQ=MONTHLY;BYDAY=MO;COUNT=6", monday); !sameDates(dates, at(3, 1, 8), at(3, 8, 8), at(3, 15, 8), at(3, 22, 8), at(3, 29, 8), at(4, 5, 8)) {
+		t.Error("Wrong occurrences on the mondays of the months :", dates)
+	}
+
+	// Only the occurrences between two dates are given
+	rule, _ = globals.ParseRecurrence("FREQ=DAILY", paris)
+	if dates := rule.Occurrences(monday, paris, nil, at(3, 10, 0), at(3, 12, 8)); !sameDates(dates, at(3, 10, 8), at(3, 11, 8)) {
+		t.Error("Wrong occurrences between two dates :", dates)
+	}
+
+	globals.Log.Debug("Occurrences test - PASSED")
+
+	//
+	// Test CountBefore
+	//
+	rule, _ = globals.ParseRecurrence("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10", paris)
+	if count := rule.CountBefore(monday, paris, at(3, 8, 8)); count != 2 {
+		t.Error("Wrong count before a date :", count)
+	}
+
+	globals.Log.Debug("CountBefore test - PASSED")
+
+	//
+	// Test Shift
+	//
+
+	// The mondays and the wednesdays moved to the next day are the tuesdays and the thursdays
+	rule, _ = globals.ParseRecurrence("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4", paris)
+	if shifted := rule.Shift(1); shifted.String() != "FREQ=WEEKLY;COUNT=4;BYDAY=TU,TH" || rule.String() != "FREQ=WEEKLY;COUNT=4;BYDAY=MO,WE" {
+		t.Error("Wrong rule moved to the next day :", shifted, rule)
+	}
+	if dates := rule.Shift(1).Occurrences(monday.AddDate(0, 0, 1), paris, nil, monday, farAway); !sameDates(dates, at(3, 2, 8), at(3, 4, 8), at(3, 9, 8), at(3, 11, 8)) {
+		t.Error("Wrong occurrences of the rule moved to the next day :", dates)
+	}
+	if shifted := rule.Shift(-2); shifted.String() != "FREQ=WEEKLY;COUNT=4;BYDAY=SA,MO" {
+		t.Error("Wrong rule moved two days before :", shifted)
+	}
+
+	rule, _ = globals.ParseRecurrence("FREQ=MONTHLY;BYMONTHDAY=1,15,-1", paris)
+	if shifted := rule.Shift(-1); shifted.String() != "FREQ=MONTHLY;BYMONTHDAY=-1,14,-2" {
+		t.Error("Wrong days of the month moved to the day before :", shifted)
+	}
+	if shifted := rule.Shift(1); shifted.String() != "FREQ=MONTHLY;BYMONTHDAY=2,16,1" {
+		t.Error("Wrong days of the month moved to the next day :", shifted)
+	}
+
+	globals.Log.Debug("Shift test - PASSED")
+}
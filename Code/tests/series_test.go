@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : CreateSeries(Series model.ScheduleSeries, Occurrences model.Schedules) (int64, error)
+	TESTED : GetSeries(SeriesId int64) (model.ScheduleSeries, error)
+	TESTED : GetSeriesOfUser(UserId int64) (model.ScheduleSeriesList, error)
+	TESTED : GetSchedulesOfSeries(SeriesId int64) (model.Schedules, error)
+	TESTED : DeleteOccurrence(ScheduleId int64) error
+	TESTED : GetSeriesToExtend(Until time.Time) (model.ScheduleSeriesList, error)
+	TESTED : ExtendSeries(Series model.ScheduleSeries, Occurrences model.Schedules) (model.ScheduleSeries, error)
+	TESTED : UpdateSeries(Series model.ScheduleSeries, From time.Time, Occurrences model.Schedules) error
+	TESTED : SplitSeries(Series model.ScheduleSeries, From time.Time, Following model.ScheduleSeries, Occurrences model.Schedules) (int64, error)
+	TESTED : EndSeries(Series model.ScheduleSeries, From time.Time) error
+	TESTED : DeleteSeries(SeriesId int64, From time.Time) error
+*/
+func TestSeries(t *testing.T) {
+	var (
+		err         error
+		overlapErr  *datastores.OverlapError
+		userId      int64
+		projectId   int64
+		seriesId    int64
+		followingId int64
+		otherId     int64
+		series      model.ScheduleSeries
+		list        model.ScheduleSeriesList
+		schedules   model.Schedules
+	)
+
+	testDatastore, err := datastores.NewDatabase("myTestDatabase.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userId, _ = testDatastore.CreateUser(model.User{ContractId: 1, RoleId: 3, Mail: "series@user.com"})
+	projectId, _ = testDatastore.CreateProject(model.Project{ProjectName: "Series project"})
+
+	// From 8 to 12 on the mondays of March 2021, a number of days after the first one
+	occurrence := func(Days int, Start int, End int) model.Schedule {
+		schedule := scheduleBetween(projectId, Start, End)
+		schedule.StartDate.Time, schedule.EndDate.Time = schedule.StartDate.Time.AddDate(0, 0, Days), schedule.EndDate.Time.AddDate(0, 0, Days)
+		return schedule
+	}
+	march := func(Day int) time.Time {
+		return time.Date(2021, 3, Day, 8, 0, 0, 0, time.UTC)
+	}
+	countOf := func(SeriesId int64) int {
+		schedules, err := testDatastore.GetSchedulesOfSeries(SeriesId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(schedules)
+	}
+
+	weekly := model.ScheduleSeries{
+		ProjectId:      projectId,
+		UserId:         userId,
+		StartDate:      march(1),
+		EndDate:        march(1).Add(4 * time.Hour),
+		TimeZone:       "UTC",
+		RecurrenceRule: "FREQ=WEEKLY",
+		GeneratedUntil: march(16),
+	}
+
+	//
+	// Test CreateSeries, GetSeries, GetSeriesOfUser and GetSchedulesOfSeries
+	//
+	if seriesId, err = testDatastore.CreateSeries(weekly, model.Schedules{occurrence(0, 8, 12), occurrence(7, 8, 12), occurrence(14, 8, 12)}); err != nil {
+		t.Fatal(err)
+	}
+	if series, err = testDatastore.GetSeries(seriesId); err != nil || series.RecurrenceRule != "FREQ=WEEKLY" || !series.StartDate.Equal(march(1)) || !series.GeneratedUntil.Equal(march(16)) {
+		t.Error("Wrong series :", series, err)
+	}
+	if list, err = testDatastore.GetSeriesOfUser(userId); err != nil || len(list) != 1 || list[0].SeriesId != seriesId {
+		t.Error("Wrong series of the user :", list, err)
+	}
+	if schedules, err = testDatastore.GetSchedulesOfSeries(seriesId); err != nil || len(schedules) != 3 || schedules[1].SeriesId.Int64 != seriesId || !schedules[1].StartDate.Time.Equal(march(8)) {
+		t.Error("Wrong occurrences :", schedules, err)
+	}
+	if schedules, err = testDatastore.GetSchedulesOfUser(userId); err != nil || len(schedules) != 3 {
+		t.Error("The occurrences are not schedules of the user :", schedules, err)
+	}
+
+	// A series that overlaps is not saved at all
+	other := weekly
+	other.StartDate, other.EndDate = march(8).Add(time.Hour), march(8).Add(2*time.Hour)
+	if _, err = testDatastore.CreateSeries(other, model.Schedules{occurrence(7, 9, 10)}); !errors.As(err, &overlapErr) || len(overlapErr.Schedules) != 1 {
+		t.Error("An overlapping series was created :", err)
+	}
+	if list, _ = testDatastore.GetSeriesOfUser(userId); len(list) != 1 {
+		t.Error("The overlapping series was saved :", list)
+	}
+
+	globals.Log.Debug("CreateSeries test - PASSED")
+
+	//
+	// Test DeleteOccurrence
+	//
+	schedules, _ = testDatastore.GetSchedulesOfSeries(seriesId)
+	if err = testDatastore.DeleteOccurrence(schedules[1].ScheduleId); err != nil {
+		t.Fatal(err)
+	}
+	if series, _ = testDatastore.GetSeries(seriesId); len(series.Exceptions()) != 1 || !series.Exceptions()[0].Equal(march(8)) || countOf(seriesId) != 2 {
+		t.Error("Wrong series after the deletion of an occurrence :", series)
+	}
+
+	// A schedule that is not in a series is not an occurrence
+	if otherId, err = testDatastore.CreateSchedule(occurrence(21, 9, 10), userId); err != nil {
+		t.Fatal(err)
+	}
+	if err = testDatastore.DeleteOccurrence(otherId); err != sql.ErrNoRows {
+		t.Error("A schedule was deleted as an occurrence :", err)
+	}
+
+	globals.Log.Debug("DeleteOccurrence test - PASSED")
+
+	//
+	// Test GetSeriesToExtend and ExtendSeries
+	//
+	if list, err = testDatastore.GetSeriesToExtend(march(16)); err != nil || len(list) != 0 {
+		t.Error("A series was extended before its horizon :", list, err)
+	}
+	if list, err = testDatastore.GetSeriesToExtend(march(30)); err != nil || len(list) != 1 {
+		t.Fatal("Wrong series to extend :", list, err)
+	}
+
+	// The occurrence that overlaps the other schedule is skipped
+	series.GeneratedUntil = march(30)
+	if series, err = testDatastore.ExtendSeries(series, model.Schedules{occurrence(21, 8, 12), occurrence(28, 8, 12)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(series.Exceptions()) != 2 || !series.Exceptions()[1].Equal(march(22)) || countOf(seriesId) != 3 {
+		t.Error("Wrong extended series :", series)
+	}
+	if series, _ = testDatastore.GetSeries(seriesId); !series.GeneratedUntil.Equal(march(30)) || len(series.Exceptions()) != 2 {
+		t.Error("The extended series was not saved :", series)
+	}
+
+	globals.Log.Debug("ExtendSeries test - PASSED")
+
+	//
+	// Test UpdateSeries
+	//
+	series.StartDate, series.EndDate = march(2), march(2).Add(4*time.Hour)
+	series.SetExceptions(nil)
+	if err = testDatastore.UpdateSeries(series, march(1), model.Schedules{occurrence(1, 8, 12), occurrence(8, 8, 12)}); err != nil {
+		t.Fatal(err)
+	}
+	if schedules, _ = testDatastore.GetSchedulesOfSeries(seriesId); len(schedules) != 2 || !schedules[0].StartDate.Time.Equal(march(2)) {
+		t.Error("Wrong occurrences of the changed series :", schedules)
+	}
+
+	// Only the occurrences from a date are created again
+	if err = testDatastore.UpdateSeries(series, march(9), model.Schedules{occurrence(8, 13, 14)}); err != nil {
+		t.Fatal(err)
+	}
+	if schedules, _ = testDatastore.GetSchedulesOfSeries(seriesId); len(schedules) != 2 || !schedules[0].StartDate.Time.Equal(march(2)) || !schedules[1].StartDate.Time.Equal(march(9).Add(5*time.Hour)) {
+		t.Error("Wrong occurrences created again from a date :", schedules)
+	}
+
+	globals.Log.Debug("UpdateSeries test - PASSED")
+
+	//
+	// Test SplitSeries and EndSeries
+	//
+	following := series
+	following.StartDate, following.EndDate = march(9).Add(5*time.Hour), march(9).Add(6*time.Hour)
+	series.RecurrenceRule = "FREQ=WEEKLY;UNTIL=20210309T075959Z"
+	if followingId, err = testDatastore.SplitSeries(series, march(9), following, model.Schedules{occurrence(8, 13, 14)}); err != nil {
+		t.Fatal(err)
+	}
+	if series, _ = testDatastore.GetSeries(seriesId); series.RecurrenceRule != "FREQ=WEEKLY;UNTIL=20210309T075959Z" || countOf(seriesId) != 1 {
+		t.Error("Wrong series before the split :", series)
+	}
+	if schedules, _ = testDatastore.GetSchedulesOfSeries(followingId); len(schedules) != 1 || !schedules[0].StartDate.Time.Equal(march(9).Add(5*time.Hour)) {
+		t.Error("Wrong series after the split :", schedules)
+	}
+
+	following.SeriesId = followingId
+	if err = testDatastore.EndSeries(following, march(9)); err != nil || countOf(followingId) != 0 {
+		t.Error("Could not end the series :", err)
+	}
+
+	globals.Log.Debug("SplitSeries test - PASSED")
+
+	//
+	// Test DeleteSeries
+	//
+	for _, id := range []int64{seriesId, followingId} {
+		if err = testDatastore.DeleteSeries(id, march(9)); err != nil {
+			t.Error(err)
+		}
+		if _, err = testDatastore.GetSeries(id); err != sql.ErrNoRows {
+			t.Error("The series was not deleted :", err)
+		}
+	}
+	// The occurrence before the date is kept as a schedule out of any series
+	var historyId int64
+	schedules, _ = testDatastore.GetSchedulesOfUser(userId)
+	for _, schedule := range schedules {
+		if schedule.ScheduleId != otherId && !schedule.SeriesId.Valid && schedule.StartDate.Time.Equal(march(2)) {
+			historyId = schedule.ScheduleId
+		}
+	}
+	if len(schedules) != 2 || historyId == 0 {
+		t.Error("Wrong schedules left by the deleted series :", schedules)
+	}
+
+	globals.Log.Debug("DeleteSeries test - PASSED")
+
+	for _, err = range []error{
+		testDatastore.DeleteUserSchedule(model.UserSchedule{UserId: userId, ScheduleId: otherId}),
+		testDatastore.DeleteSchedule(otherId),
+		testDatastore.DeleteUserSchedule(model.UserSchedule{UserId: userId, ScheduleId: historyId}),
+		testDatastore.DeleteSchedule(historyId),
+		testDatastore.DeleteProject(projectId),
+		testDatastore.DeleteUser(userId),
+	} {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
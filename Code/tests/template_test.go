@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : CreateTemplate(Template model.ScheduleTemplate, Slots model.TemplateSlots) (int64, error)
+	TESTED : GetTemplate(TemplateId int64) (model.ScheduleTemplate, error)
+	TESTED : GetSlotsOfTemplate(TemplateId int64) (model.TemplateSlots, error)
+	TESTED : GetTemplatesOfUser(UserId int64) (model.ScheduleTemplates, error)
+	TESTED : GetTemplatesOfCompany(CompanyId int64) (model.ScheduleTemplates, error)
+	TESTED : UpdateTemplate(Template model.ScheduleTemplate, Slots model.TemplateSlots) error
+	TESTED : DeleteTemplate(TemplateId int64) error
+*/
+func TestTemplate(t *testing.T) {
+	var (
+		err        error
+		userId     int64
+		companyId  int64
+		projectId  int64
+		ownId      int64
+		sharedId   int64
+		template   model.ScheduleTemplate
+		templates  model.ScheduleTemplates
+		slots      model.TemplateSlots
+		otherSlots model.TemplateSlots
+	)
+
+	testDatastore, err := datastores.NewDatabase("myTestDatabase.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userId, _ = testDatastore.CreateUser(model.User{ContractId: 1, RoleId: 3, Mail: "template@user.com"})
+	companyId, _ = testDatastore.CreateCompany(model.Company{CompanyName: "Template company"})
+	projectId, _ = testDatastore.CreateProject(model.Project{ProjectName: "Template project"})
+	if err = testDatastore.CreateCompanyUser(model.CompanyUser{CompanyId: companyId, UserId: userId}); err != nil {
+		t.Fatal(err)
+	}
+
+	slots = model.TemplateSlots{
+		{ProjectId: projectId, Weekday: 2, StartTime: "08:00", EndTime: "12:00"},
+		{ProjectId: projectId, Weekday: 1, StartTime: "13:30", EndTime: "17:00"},
+		{ProjectId: projectId, Weekday: 1, StartTime: "08:00", EndTime: "12:00"},
+	}
+
+	//
+	// Test CreateTemplate, GetTemplate and GetSlotsOfTemplate
+	//
+	own := model.ScheduleTemplate{TemplateName: "Week at the office", UserId: sql.NullInt64{Valid: true, Int64: userId}}
+	if ownId, err = testDatastore.CreateTemplate(own, slots); err != nil {
+		t.Fatal(err)
+	}
+	shared := model.ScheduleTemplate{TemplateName: "Shared week", CompanyId: sql.NullInt64{Valid: true, Int64: companyId}}
+	if sharedId, err = testDatastore.CreateTemplate(shared, slots[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if template, err = testDatastore.GetTemplate(ownId); err != nil || template.TemplateName != own.TemplateName || template.UserId != own.UserId || template.CompanyId.Valid {
+		t.Error("Wrong template :", template, err)
+	}
+	if otherSlots, err = testDatastore.GetSlotsOfTemplate(ownId); err != nil || len(otherSlots) != 3 {
+		t.Fatal("Wrong slots :", otherSlots, err)
+	}
+	if otherSlots[0].Weekday != 1 || otherSlots[0].StartTime != "08:00" || otherSlots[1].StartTime != "13:30" || otherSlots[2].Weekday != 2 {
+		t.Error("The slots are not in the order of the week :", otherSlots)
+	}
+
+	globals.Log.Debug("CreateTemplate test - PASSED")
+
+	//
+	// Test GetTemplatesOfUser and GetTemplatesOfCompany
+	//
+	if templates, err = testDatastore.GetTemplatesOfUser(userId); err != nil || len(templates) != 2 || templates[0].TemplateId != sharedId || templates[1].TemplateId != ownId {
+		t.Error("Wrong templates of the user :", templates, err)
+	}
+	if templates, err = testDatastore.GetTemplatesOfCompany(companyId); err != nil || len(templates) != 1 || templates[0].TemplateId != sharedId {
+		t.Error("Wrong templates of the company :", templates, err)
+	}
+
+	globals.Log.Debug("GetTemplatesOfUser test - PASSED")
+
+	//
+	// Test UpdateTemplate
+	//
+	template.TemplateName = "Short week"
+	if err = testDatastore.UpdateTemplate(template, slots[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if template, _ = testDatastore.GetTemplate(ownId); template.TemplateName != "Short week" {
+		t.Error("The template was not renamed :", template)
+	}
+	if otherSlots, _ = testDatastore.GetSlotsOfTemplate(ownId); len(otherSlots) != 1 || otherSlots[0].Weekday != 2 {
+		t.Error("The slots were not replaced :", otherSlots)
+	}
+
+	globals.Log.Debug("UpdateTemplate test - PASSED")
+
+	//
+	// Test DeleteTemplate
+	//
+	for _, id := range []int64{ownId, sharedId} {
+		if err = testDatastore.DeleteTemplate(id); err != nil {
+			t.Error(err)
+		}
+		if _, err = testDatastore.GetTemplate(id); err != sql.ErrNoRows {
+			t.Error("The template was not deleted :", err)
+		}
+		if otherSlots, _ = testDatastore.GetSlotsOfTemplate(id); len(otherSlots) != 0 {
+			t.Error("The slots were not deleted :", otherSlots)
+		}
+	}
+
+	globals.Log.Debug("DeleteTemplate test - PASSED")
+
+	for _, err = range []error{
+		testDatastore.DeleteCompanyUser(model.CompanyUser{CompanyId: companyId, UserId: userId}),
+		testDatastore.DeleteCompany(companyId),
+		testDatastore.DeleteProject(projectId),
+		testDatastore.DeleteUser(userId),
+	} {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+/*
+	TESTED : CheckSchedule(Schedule model.Schedule, UserIds ...int64) error
+	TESTED : CreateSchedules(Schedules model.Schedules, UserIds ...int64) ([]int64, error)
+*/
+func TestCreateSchedules(t *testing.T) {
+	var (
+		err         error
+		overlapErr  *datastores.OverlapError
+		userId      int64
+		projectId   int64
+		existingId  int64
+		scheduleIds []int64
+		schedules   model.Schedules
+	)
+
+	testDatastore, err := datastores.NewDatabase("myTestDatabase.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userId, _ = testDatastore.CreateUser(model.User{ContractId: 1, RoleId: 3, Mail: "schedules@user.com"})
+	projectId, _ = testDatastore.CreateProject(model.Project{ProjectName: "Schedules project"})
+	if existingId, err = testDatastore.CreateSchedule(scheduleBetween(projectId, 8, 10), userId); err != nil {
+		t.Fatal(err)
+	}
+
+	//
+	// Test CheckSchedule
+	//
+	if err = testDatastore.CheckSchedule(scheduleBetween(projectId, 9, 11), userId); !errors.As(err, &overlapErr) || overlapErr.Schedules[0].ScheduleId != existingId {
+		t.Error("The overlap was not found :", err)
+	}
+	if err = testDatastore.CheckSchedule(scheduleBetween(projectId, 10, 12), userId); err != nil {
+		t.Error(err)
+	}
+	if schedules, _ = testDatastore.GetSchedulesOfUser(userId); len(schedules) != 1 {
+		t.Error("CheckSchedule created a schedule :", schedules)
+	}
+
+	globals.Log.Debug("CheckSchedule test - PASSED")
+
+	//
+	// Test CreateSchedules
+	//
+	if _, err = testDatastore.CreateSchedules(model.Schedules{scheduleBetween(projectId, 10, 12), scheduleBetween(projectId, 9, 11)}, userId); !errors.As(err, &overlapErr) {
+		t.Error("The overlap was not found :", err)
+	}
+	if _, err = testDatastore.CreateSchedules(model.Schedules{scheduleBetween(projectId, 10, 12), scheduleBetween(projectId, 11, 13)}, userId); !errors.As(err, &overlapErr) {
+		t.Error("The overlap between the new schedules was not found :", err)
+	}
+	if schedules, _ = testDatastore.GetSchedulesOfUser(userId); len(schedules) != 1 {
+		t.Error("The schedules were partly created :", schedules)
+	}
+
+	if scheduleIds, err = testDatastore.CreateSchedules(model.Schedules{scheduleBetween(projectId, 10, 12), scheduleBetween(projectId, 13, 17)}, userId); err != nil || len(scheduleIds) != 2 {
+		t.Fatal("Could not create the schedules :", scheduleIds, err)
+	}
+	if schedules, _ = testDatastore.GetSchedulesOfUser(userId); len(schedules) != 3 {
+		t.Error("The schedules were not created :", schedules)
+	}
+
+	globals.Log.Debug("CreateSchedules test - PASSED")
+
+	for _, id := range append(scheduleIds, existingId) {
+		if err = testDatastore.DeleteUserSchedule(model.UserSchedule{UserId: userId, ScheduleId: id}); err == nil {
+			err = testDatastore.DeleteSchedule(id)
+		}
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	for _, err = range []error{
+		testDatastore.DeleteProject(projectId),
+		testDatastore.DeleteUser(userId),
+	} {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
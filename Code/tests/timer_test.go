@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : StartTimer(UserId int64, Schedule model.Schedule) (int64, error)
+	TESTED : GetRunningScheduleOfUser(UserId int64) (model.Schedule, error)
+	TESTED : GetRunningUsersSchedules() (model.UsersSchedules, error)
+	TESTED : StopSchedule(ScheduleId int64, EndDate time.Time) error
+*/
+func TestTimer(t *testing.T) {
+	var (
+		err        error
+		userId     int64
+		projectId  int64
+		scheduleId int64
+		running    model.Schedule
+		links      model.UsersSchedules
+	)
+
+	testDatastore, err := datastores.NewDatabase("myTestDatabase.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if userId, err = testDatastore.CreateUser(model.User{ContractId: 1, RoleId: 3, Mail: "timer@user.com"}); err != nil {
+		t.Error(err)
+	}
+	if projectId, err = testDatastore.CreateProject(model.Project{ProjectName: "Timer project"}); err != nil {
+		t.Error(err)
+	}
+
+	start := time.Date(2021, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	//
+	// Test StartTimer
+	//
+	if scheduleId, err = testDatastore.StartTimer(userId, model.Schedule{ProjectId: projectId, StartDate: sql.NullTime{Valid: true, Time: start}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = testDatastore.StartTimer(userId, model.Schedule{ProjectId: projectId, StartDate: sql.NullTime{Valid: true, Time: start}}); err != datastores.ErrTimerRunning {
+		t.Error("A second timer was started :", err)
+	}
+
+	globals.Log.Debug("StartTimer test - PASSED")
+
+	//
+	// Test GetRunningScheduleOfUser and GetRunningUsersSchedules
+	//
+	if running, err = testDatastore.GetRunningScheduleOfUser(userId); err != nil {
+		t.Fatal(err)
+	}
+	if running.ScheduleId != scheduleId || !running.StartDate.Time.Equal(start) || running.EndDate.Valid {
+		t.Error("Wrong running timer :", running)
+	}
+
+	if links, err = testDatastore.GetRunningUsersSchedules(); err != nil {
+		t.Error(err)
+	}
+	if len(links) != 1 || links[0] != (model.UserSchedule{UserId: userId, ScheduleId: scheduleId}) {
+		t.Error("Wrong running timers :", links)
+	}
+
+	globals.Log.Debug("GetRunningScheduleOfUser test - PASSED")
+
+	//
+	// Test StopSchedule
+	//
+	if err = testDatastore.StopSchedule(scheduleId, start.Add(2*time.Hour)); err != nil {
+		t.Error(err)
+	}
+	// A stopped schedule is not changed anymore
+	if err = testDatastore.StopSchedule(scheduleId, start.Add(5*time.Hour)); err != nil {
+		t.Error(err)
+	}
+
+	if running, err = testDatastore.GetSchedule(scheduleId); err != nil {
+		t.Error(err)
+	}
+	if !running.EndDate.Valid || !running.EndDate.Time.Equal(start.Add(2*time.Hour)) {
+		t.Error("Wrong end of the stopped timer :", running.EndDate)
+	}
+
+	if _, err = testDatastore.GetRunningScheduleOfUser(userId); err != sql.ErrNoRows {
+		t.Error("The stopped timer is still running :", err)
+	}
+
+	// And another timer can be started
+	if scheduleId, err = testDatastore.StartTimer(userId, model.Schedule{ProjectId: projectId, StartDate: sql.NullTime{Valid: true, Time: start.Add(3 * time.Hour)}}); err != nil {
+		t.Error(err)
+	}
+	testDatastore.StopSchedule(scheduleId, start.Add(4*time.Hour))
+
+	// A timer can't end in locked hours
+	if scheduleId, err = testDatastore.StartTimer(userId, model.Schedule{ProjectId: projectId, StartDate: sql.NullTime{Valid: true, Time: start.Add(6 * time.Hour)}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = testDatastore.Lock(model.LockDate{LockDate: sql.NullTime{Valid: true, Time: start.AddDate(0, 1, 0)}, AuthorId: 1, Reason: "Timer"}); err != nil {
+		t.Fatal(err)
+	}
+	var lockedErr *datastores.LockedError
+	if err = testDatastore.StopSchedule(scheduleId, start.Add(7*time.Hour)); !errors.As(err, &lockedErr) {
+		t.Error("A timer was stopped in locked hours :", err)
+	}
+	if _, err = testDatastore.GetRunningScheduleOfUser(userId); err != nil {
+		t.Error("The locked timer is not running anymore :", err)
+	}
+	if _, err = testDatastore.Unlock(model.LockDate{AuthorId: 1, Reason: "Timer"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = testDatastore.StopSchedule(scheduleId, start.Add(7*time.Hour)); err != nil {
+		t.Error("Could not stop the unlocked timer :", err)
+	}
+
+	// A timer ends before the schedules added in its hours while it was running
+	now := time.Now().Truncate(time.Second)
+	if scheduleId, err = testDatastore.StartTimer(userId, model.Schedule{ProjectId: projectId, StartDate: sql.NullTime{Valid: true, Time: now.Add(-time.Hour)}}); err != nil {
+		t.Fatal(err)
+	}
+	later := model.Schedule{
+		ProjectId: projectId,
+		StartDate: sql.NullTime{Valid: true, Time: now.Add(time.Hour)},
+		EndDate:   sql.NullTime{Valid: true, Time: now.Add(2 * time.Hour)},
+	}
+	if later.ScheduleId, err = testDatastore.CreateSchedule(later, userId); err != nil {
+		t.Fatal(err)
+	}
+	if err = testDatastore.StopSchedule(scheduleId, now.Add(3*time.Hour)); err != nil {
+		t.Error(err)
+	}
+	if running, err = testDatastore.GetSchedule(scheduleId); err != nil || !running.EndDate.Time.Equal(now.Add(time.Hour)) {
+		t.Error("The stopped timer overlaps a later schedule :", running.EndDate, err)
+	}
+
+	globals.Log.Debug("StopSchedule test - PASSED")
+}
+
+/*
+	TESTED : TimerRulesFromEnvironment() TimerRules
+	TESTED : AutoStopDate(Start time.Time, Location *time.Location) (time.Time, bool)
+*/
+func TestTimerRules(t *testing.T) {
+	paris, _ := time.LoadLocation("Europe/Paris")
+	rules := globals.DefaultTimerRules()
+
+	// Started in the morning, stopped at 8 PM the same day
+	if stop, ok := rules.AutoStopDate(time.Date(2021, 3, 26, 8, 0, 0, 0, paris), paris); !ok || !stop.Equal(time.Date(2021, 3, 26, 20, 0, 0, 0, paris)) {
+		t.Error("Wrong automatic stop :", stop, ok)
+	}
+
+	// Started in the night, stopped at 8 PM the next day, after the clocks went forward
+	if stop, ok := rules.AutoStopDate(time.Date(2021, 3, 27, 21, 0, 0, 0, paris), paris); !ok || !stop.Equal(time.Date(2021, 3, 28, 18, 0, 0, 0, time.UTC)) {
+		t.Error("Wrong automatic stop :", stop, ok)
+	}
+
+	os.Setenv("TIMER_AUTO_STOP_HOUR", "23")
+	if rules = globals.TimerRulesFromEnvironment(); !rules.AutoStop || rules.AutoStopHour != 23 {
+		t.Error("The environment was not used :", rules)
+	}
+
+	os.Setenv("TIMER_AUTO_STOP_HOUR", "24")
+	if rules = globals.TimerRulesFromEnvironment(); rules.AutoStopHour != globals.DefaultTimerRules().AutoStopHour {
+		t.Error("An invalid hour was used :", rules)
+	}
+
+	os.Setenv("TIMER_AUTO_STOP_HOUR", "none")
+	if rules = globals.TimerRulesFromEnvironment(); rules.AutoStop {
+		t.Error("The timers are still stopped :", rules)
+	}
+	if _, ok := rules.AutoStopDate(time.Now(), paris); ok {
+		t.Error("A timer would be stopped")
+	}
+
+	os.Unsetenv("TIMER_AUTO_STOP_HOUR")
+
+	globals.Log.Debug("TimerRules test - PASSED")
+}
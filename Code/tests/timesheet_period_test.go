@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : ChangeTimesheetPeriodState(Period model.TimesheetPeriod, Event model.TimesheetEvent) (model.TimesheetPeriod, error)
+	TESTED : GetTimesheetPeriodOfUser(UserId int64, Period string) (model.TimesheetPeriod, error)
+	TESTED : GetTimesheetPeriodsOfUser(UserId int64) (model.TimesheetPeriods, error)
+	TESTED : GetTimesheetPeriodsByState(State string) (model.TimesheetPeriods, error)
+	TESTED : GetTimesheetEvents(PeriodId int64) (model.TimesheetEvents, error)
+	TESTED : The schedules and the comments locked by an approved period
+*/
+func TestTimesheetPeriod(t *testing.T) {
+	var (
+		err        error
+		lockedErr  *datastores.LockedError
+		userId     int64
+		projectId  int64
+		scheduleId int64
+		outsideId  int64
+		commentId  int64
+		period     model.TimesheetPeriod
+		periods    model.TimesheetPeriods
+		events     model.TimesheetEvents
+	)
+
+	testDatastore, err := datastores.NewDatabase("myTestDatabase.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userId, _ = testDatastore.CreateUser(model.User{ContractId: 1, RoleId: 3, Mail: "timesheet@user.com"})
+	projectId, _ = testDatastore.CreateProject(model.Project{ProjectName: "Timesheet project"})
+
+	// From 8 to 12 on the monday of the week 2021-W09, and the monday after
+	if scheduleId, err = testDatastore.CreateSchedule(scheduleBetween(projectId, 8, 12), userId); err != nil {
+		t.Fatal(err)
+	}
+	nextWeek := scheduleBetween(projectId, 8, 12)
+	nextWeek.StartDate.Time, nextWeek.EndDate.Time = nextWeek.StartDate.Time.AddDate(0, 0, 7), nextWeek.EndDate.Time.AddDate(0, 0, 7)
+	if outsideId, err = testDatastore.CreateSchedule(nextWeek, userId); err != nil {
+		t.Fatal(err)
+	}
+	if commentId, err = testDatastore.CreateComment(model.Comment{ScheduleId: scheduleId, Comment: "Before the approval"}); err != nil {
+		t.Fatal(err)
+	}
+
+	week := model.TimesheetPeriod{
+		UserId:    userId,
+		Period:    "2021-W09",
+		StartDate: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2021, 3, 8, 0, 0, 0, 0, time.UTC),
+	}
+
+	//
+	// Test ChangeTimesheetPeriodState
+	//
+
+	// A draft can't be approved, and is not saved
+	if _, err = testDatastore.ChangeTimesheetPeriodState(week, model.TimesheetEvent{State: model.TimesheetApproved, AuthorId: 1}); err != datastores.ErrTimesheetState {
+		t.Error("A draft was approved :", err)
+	}
+	if _, err = testDatastore.GetTimesheetPeriodOfUser(userId, "2021-W09"); err != sql.ErrNoRows {
+		t.Error("The refused period was saved :", err)
+	}
+
+	for _, event := range []model.TimesheetEvent{
+		{State: model.TimesheetSubmitted, AuthorId: userId},
+		{State: model.TimesheetRejected, AuthorId: 1, Reason: "Missing the afternoon"},
+		{State: model.TimesheetSubmitted, AuthorId: userId},
+		{State: model.TimesheetApproved, AuthorId: 1},
+	} {
+		if period, err = testDatastore.ChangeTimesheetPeriodState(week, event); err != nil || period.State != event.State {
+			t.Fatal("Could not change the period to", event.State, ":", period, err)
+		}
+	}
+
+	if _, err = testDatastore.ChangeTimesheetPeriodState(week, model.TimesheetEvent{State: model.TimesheetSubmitted, AuthorId: userId}); err != datastores.ErrTimesheetState {
+		t.Error("An approved period was submitted again :", err)
+	}
+
+	globals.Log.Debug("ChangeTimesheetPeriodState test - PASSED")
+
+	//
+	// Test GetTimesheetPeriodOfUser, GetTimesheetPeriodsOfUser and GetTimesheetPeriodsByState
+	//
+	if period, err = testDatastore.GetTimesheetPeriodOfUser(userId, "2021-W09"); err != nil || period.State != model.TimesheetApproved || !period.StartDate.Equal(week.StartDate) || !period.EndDate.Equal(week.EndDate) {
+		t.Error("Wrong period :", period, err)
+	}
+
+	if periods, err = testDatastore.GetTimesheetPeriodsOfUser(userId); err != nil || len(periods) != 1 || periods[0].PeriodId != period.PeriodId {
+		t.Error("Wrong periods of the user :", periods, err)
+	}
+
+	if periods, err = testDatastore.GetTimesheetPeriodsByState(model.TimesheetApproved); err != nil {
+		t.Error(err)
+	}
+	found := false
+	for _, approved := range periods {
+		found = found || approved.PeriodId == period.PeriodId
+	}
+	if !found {
+		t.Error("The approved period was not found :", periods)
+	}
+
+	globals.Log.Debug("GetTimesheetPeriods tests - PASSED")
+
+	//
+	// Test GetTimesheetEvents
+	//
+	if events, err = testDatastore.GetTimesheetEvents(period.PeriodId); err != nil || len(events) != 4 {
+		t.Fatal("Wrong history :", events, err)
+	}
+	if events[0].State != model.TimesheetSubmitted || events[1].Reason != "Missing the afternoon" || events[1].AuthorId != 1 || events[3].State != model.TimesheetApproved {
+		t.Error("Wrong history :", events)
+	}
+
+	globals.Log.Debug("GetTimesheetEvents test - PASSED")
+
+	//
+	// Test the locks
+	//
+	moved := scheduleBetween(projectId, 13, 14)
+	moved.ScheduleId = scheduleId
+	movedIn := scheduleBetween(projectId, 13, 14)
+	movedIn.ScheduleId = outsideId
+	unlinkedId, _ := testDatastore.CreateSchedule(scheduleBetween(projectId, 13, 14))
+
+	for name, locked := range map[string]error{
+		"CreateSchedule": func() error {
+			_, err := testDatastore.CreateSchedule(scheduleBetween(projectId, 13, 14), userId)
+			return err
+		}(),
+		"CreateVacation": func() error {
+			_, err := testDatastore.CreateVacation(scheduleBetween(0, 13, 14), userId)
+			return err
+		}(),
+		"UpdateSchedule": func() error {
+			_, err := testDatastore.UpdateSchedule(moved)
+			return err
+		}(),
+		"UpdateSchedule into the period": func() error {
+			_, err := testDatastore.UpdateSchedule(movedIn)
+			return err
+		}(),
+		"DeleteSchedule":     testDatastore.DeleteSchedule(scheduleId),
+		"CreateUserSchedule": testDatastore.CreateUserSchedule(model.UserSchedule{UserId: userId, ScheduleId: unlinkedId}),
+		"DeleteUserSchedule": testDatastore.DeleteUserSchedule(model.UserSchedule{UserId: userId, ScheduleId: scheduleId}),
+		"CreateComment": func() error {
+			_, err := testDatastore.CreateComment(model.Comment{ScheduleId: scheduleId, Comment: "After the approval"})
+			return err
+		}(),
+		"UpdateComment": func() error {
+			_, err := testDatastore.UpdateComment(model.Comment{CommentId: commentId, ScheduleId: scheduleId, Comment: "Changed"})
+			return err
+		}(),
+		"DeleteComment": testDatastore.DeleteComment(commentId),
+	} {
+		if !errors.As(locked, &lockedErr) || lockedErr.Period.PeriodId != period.PeriodId {
+			t.Error(name, "was not locked :", locked)
+		}
+	}
+
+	// The other weeks and the schedules of nobody are not locked
+	nextWeek.ScheduleId = outsideId
+	nextWeek.EndDate.Time = nextWeek.EndDate.Time.Add(time.Hour)
+	if _, err = testDatastore.UpdateSchedule(nextWeek); err != nil {
+		t.Error("A schedule outside of the period was locked :", err)
+	}
+	if err = testDatastore.DeleteSchedule(unlinkedId); err != nil {
+		t.Error("A schedule without user was locked :", err)
+	}
+
+	globals.Log.Debug("Locks test - PASSED")
+
+	// Once reopened, everything can change again
+	if period, err = testDatastore.ChangeTimesheetPeriodState(week, model.TimesheetEvent{State: model.TimesheetDraft, AuthorId: 1, Reason: "Forgotten comment"}); err != nil || period.State != model.TimesheetDraft {
+		t.Fatal("Could not reopen the period :", period, err)
+	}
+
+	for _, cleanup := range []error{
+		testDatastore.DeleteComment(commentId),
+		testDatastore.DeleteUserSchedule(model.UserSchedule{UserId: userId, ScheduleId: scheduleId}),
+		testDatastore.DeleteUserSchedule(model.UserSchedule{UserId: userId, ScheduleId: outsideId}),
+		testDatastore.DeleteSchedule(scheduleId),
+		testDatastore.DeleteSchedule(outsideId),
+		testDatastore.DeleteProject(projectId),
+		testDatastore.DeleteUser(userId),
+	} {
+		if cleanup != nil {
+			t.Error(cleanup)
+		}
+	}
+
+	testDatastore.CloseDatabase()
+}
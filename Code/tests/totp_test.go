@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+)
+
+/*
+	TESTED : TOTPCode(Secret string, Step int64) (string, error)
+	TESTED : MatchTOTPCode(Secret string, Code string, Now time.Time) (int64, bool)
+	TESTED : HashRecoveryCode(Code string) string
+*/
+func TestTOTP(t *testing.T) {
+	// The secret of the RFC 6238 test vectors : "12345678901234567890" in base32
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	//
+	// Test TOTPCode, with the RFC 6238 test vectors (only the 6 last digits)
+	//
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, expected := range vectors {
+		code, err := globals.TOTPCode(secret, globals.TOTPStep(time.Unix(unix, 0)))
+		if err != nil {
+			t.Error(err)
+		}
+		if code != expected {
+			t.Error("Wrong code for", unix, ":", code, "instead of", expected)
+		}
+	}
+
+	globals.Log.Debug("TOTPCode test - PASSED")
+
+	//
+	// Test MatchTOTPCode
+	//
+	now := time.Unix(1111111111, 0)
+
+	if step, ok := globals.MatchTOTPCode(secret, "050471", now); !ok || step != globals.TOTPStep(now) {
+		t.Error("The current code did not match")
+	}
+
+	// The previous code is accepted, to allow clock drifts
+	if _, ok := globals.MatchTOTPCode(secret, "081804", now); !ok {
+		t.Error("The previous code did not match")
+	}
+
+	// But not an older one
+	if _, ok := globals.MatchTOTPCode(secret, "081804", now.Add(time.Minute)); ok {
+		t.Error("An old code matched")
+	}
+
+	globals.Log.Debug("MatchTOTPCode test - PASSED")
+
+	//
+	// Test HashRecoveryCode
+	//
+	if globals.HashRecoveryCode("abcd-efgh") != globals.HashRecoveryCode(" ABCDEFGH ") {
+		t.Error("Recovery codes are not normalized")
+	}
+
+	globals.Log.Debug("HashRecoveryCode test - PASSED")
+}
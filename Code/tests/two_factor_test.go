@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/datastores"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/globals"
+	"gitlab.iut-clermont.uca.fr/esriat/gestion-tps-projet/Code/model"
+)
+
+/*
+	TESTED : GetTwoFactor(UserId int64) (model.TwoFactor, error)
+	TESTED : SaveTwoFactor(TwoFactor model.TwoFactor) error
+	TESTED : DeleteTwoFactor(UserId int64) error
+	TESTED : SetRecoveryCodes(UserId int64, CodeHashes []string) error
+	TESTED : UseRecoveryCode(UserId int64, CodeHash string) (bool, error)
+*/
+func TestTwoFactor(t *testing.T) {
+	// Initializing variables
+	var (
+		err           error
+		testDatastore *datastores.ConcreteDatastore
+		dbTwoFactor   model.TwoFactor
+		used          bool
+	)
+
+	if testDatastore, err = datastores.NewDatabase("myTestDatabase.db"); err != nil {
+		t.Error(err)
+	}
+
+	// The default admin
+	twoFactor := model.TwoFactor{
+		UserId:  1,
+		Secret:  "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		Enabled: false,
+	}
+
+	//
+	// Test SaveTwoFactor and GetTwoFactor
+	//
+	if err = testDatastore.SaveTwoFactor(twoFactor); err != nil {
+		t.Error(err)
+	}
+
+	if dbTwoFactor, err = testDatastore.GetTwoFactor(twoFactor.UserId); err != nil {
+		t.Error(err)
+	}
+
+	if !cmp.Equal(twoFactor, dbTwoFactor) {
+		t.Error("Two-factor authentications are not the same")
+	}
+
+	// Enabling it
+	twoFactor.Enabled = true
+	twoFactor.LastUsedStep = 42
+
+	if err = testDatastore.SaveTwoFactor(twoFactor); err != nil {
+		t.Error(err)
+	}
+
+	if dbTwoFactor, err = testDatastore.GetTwoFactor(twoFactor.UserId); err != nil {
+		t.Error(err)
+	}
+
+	if !cmp.Equal(twoFactor, dbTwoFactor) {
+		t.Error("Two-factor authentications are not the same")
+	}
+
+	globals.Log.Debug("SaveTwoFactor test - PASSED")
+	globals.Log.Debug("GetTwoFactor test - PASSED")
+
+	//
+	// Test SetRecoveryCodes and UseRecoveryCode
+	//
+	if err = testDatastore.SetRecoveryCodes(twoFactor.UserId, []string{"hash 1", "hash 2"}); err != nil {
+		t.Error(err)
+	}
+
+	if used, err = testDatastore.UseRecoveryCode(twoFactor.UserId, "hash 1"); err != nil || !used {
+		t.Error("Could not use a recovery code")
+	}
+
+	// A code can only be used once
+	if used, err = testDatastore.UseRecoveryCode(twoFactor.UserId, "hash 1"); err != nil || used {
+		t.Error("A recovery code got used twice")
+	}
+
+	// Replacing the codes removes the old ones
+	if err = testDatastore.SetRecoveryCodes(twoFactor.UserId, []string{"hash 3"}); err != nil {
+		t.Error(err)
+	}
+
+	if used, err = testDatastore.UseRecoveryCode(twoFactor.UserId, "hash 2"); err != nil || used {
+		t.Error("An old recovery code got used")
+	}
+
+	globals.Log.Debug("SetRecoveryCodes test - PASSED")
+	globals.Log.Debug("UseRecoveryCode test - PASSED")
+
+	//
+	// Test DeleteTwoFactor
+	//
+	if err = testDatastore.DeleteTwoFactor(twoFactor.UserId); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = testDatastore.GetTwoFactor(twoFactor.UserId); err != sql.ErrNoRows {
+		t.Error("The two-factor authentication did not get deleted")
+	}
+
+	if used, err = testDatastore.UseRecoveryCode(twoFactor.UserId, "hash 3"); err != nil || used {
+		t.Error("The recovery codes did not get deleted")
+	}
+
+	globals.Log.Debug("DeleteTwoFactor test - PASSED")
+
+	testDatastore.CloseDatabase()
+}
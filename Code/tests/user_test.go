@@ -21,6 +21,7 @@ import (
 	TESTED : CreateUser(User model.User) (int64, error)
 	TESTED : DeleteUser(UserId int64) error
 	TESTED : UpdateUser(User model.User) (model.User, error)
+	TESTED : UpdateUserPassword(UserId int64, Password string, MustChangePassword bool) error
 */
 func TestUser(t *testing.T) {
 	var err error
@@ -395,6 +396,26 @@ func TestUser(t *testing.T) {
 
 	globals.Log.Debug("UpdateUser test - PASSED")
 
+	//
+	// Test UpdateUserPassword(UserId, Password, MustChangePassword)
+	//
+
+	// Changing the password
+	if err = testDatastore.UpdateUserPassword(user1.UserId, "This is a new password", true); err != nil {
+		t.Error(err)
+	}
+
+	// Checking if changes got saved
+	if updatedUser, err = testDatastore.GetUser(user1.UserId); err != nil {
+		t.Error(err)
+	}
+
+	if updatedUser.Password != "This is a new password" || !updatedUser.MustChangePassword {
+		t.Error("The password did not get updated")
+	}
+
+	globals.Log.Debug("UpdateUserPassword test - PASSED")
+
 	//
 	// Test DeleteProject(ProjectId)
 	//